@@ -0,0 +1,166 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type CreateAPIKeyRequest struct {
+	Name string `json:"name"`
+	// RateLimitPerMinute is how many requests the key can make per minute, zero uses the server's default
+	RateLimitPerMinute int `json:"rateLimitPerMinute,omitempty"`
+	// TenantID is the tenant the key belongs to, the key only sees the status pages the tenant tracks
+	TenantID string `json:"tenantId,omitempty"`
+}
+
+type CreateAPIKeyResponse struct {
+	APIKey api.APIKey `json:"apiKey"`
+	// Key is the value of the X-API-Key header, it can't be retrieved again
+	Key string `json:"key"`
+}
+
+type APIKeysResponse struct {
+	APIKeys []api.APIKey `json:"apiKeys"`
+}
+
+type APIKeyResponse struct {
+	APIKey api.APIKey `json:"apiKey"`
+}
+
+// createAPIKey is a handler for the POST /apiKeys endpoint, it requires the admin token, and for the POST /tenant/apiKeys
+// endpoint, which requires an api key of a tenant and issues keys of the same tenant.
+// It issues a new api key, the key is only in this response
+func (s *Server) createAPIKey(context *gin.Context) {
+	ctx := context.Request.Context()
+	var request CreateAPIKeyRequest
+	decoder := json.NewDecoder(context.Request.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&request)
+	if err != nil {
+		respondWithError(context, api.ErrorCodeInvalidBody, "the body must be an api key request: "+err.Error(), nil)
+		return
+	}
+	if request.Name == "" {
+		respondWithError(context, api.ErrorCodeInvalidBody, "name is required", nil)
+		return
+	}
+	if request.RateLimitPerMinute < 0 {
+		respondWithError(context, api.ErrorCodeInvalidBody, "rateLimitPerMinute must not be negative", nil)
+		return
+	}
+	if tenantID, isTenant := db.TenantFromContext(ctx); isTenant {
+		if request.TenantID != "" && request.TenantID != tenantID {
+			respondWithError(context, api.ErrorCodeInvalidBody, "a tenant can only issue its own api keys", nil)
+			return
+		}
+		// A tenant can't issue itself a key with a higher limit than the key it uses
+		limit := requestAPIKey(context).RateLimitPerMinute
+		if limit == 0 {
+			limit = s.config.APIKeyRateLimitPerMinute
+		}
+		if request.RateLimitPerMinute > limit {
+			respondWithError(context, api.ErrorCodeInvalidBody, "rateLimitPerMinute must be at most "+strconv.Itoa(limit), nil)
+			return
+		}
+		request.TenantID = tenantID
+	} else if request.TenantID != "" {
+		tenant, err := s.dbClient.GetTenant(ctx, request.TenantID)
+		if err != nil {
+			s.logger.Error("failed to get tenant", zap.Error(err), zap.String("tenantId", request.TenantID))
+			respondWithInternalError(context, "failed to get tenant")
+			return
+		}
+		if tenant == nil {
+			respondWithError(context, api.ErrorCodeInvalidBody, "unknown tenant "+request.TenantID, nil)
+			return
+		}
+	}
+
+	id, err := randomHex(8)
+	if err != nil {
+		s.logger.Error("failed to generate api key id", zap.Error(err))
+		respondWithInternalError(context, "failed to generate api key")
+		return
+	}
+	secret, err := randomHex(24)
+	if err != nil {
+		s.logger.Error("failed to generate api key", zap.Error(err))
+		respondWithInternalError(context, "failed to generate api key")
+		return
+	}
+	rawKey := "sp_" + id + "_" + secret
+	key := api.APIKey{
+		ID:                 id,
+		Name:               request.Name,
+		KeyHash:            hashAPIKey(rawKey),
+		RateLimitPerMinute: request.RateLimitPerMinute,
+		TenantID:           request.TenantID,
+		CreatedAt:          time.Now().UTC(),
+	}
+	err = s.dbClient.CreateAPIKey(ctx, key)
+	if err != nil {
+		s.logger.Error("failed to create api key", zap.Error(err), zap.String("name", request.Name))
+		respondWithInternalError(context, "failed to create api key")
+		return
+	}
+	s.logger.Info("created api key", zap.String("id", key.ID), zap.String("name", key.Name), zap.String("tenantId", key.TenantID))
+	context.JSON(http.StatusOK, CreateAPIKeyResponse{APIKey: key, Key: rawKey})
+}
+
+// apiKeys is a handler for the GET /apiKeys endpoint, it requires the admin token, and for the GET /tenant/apiKeys
+// endpoint, which requires an api key of a tenant and lists the keys of the tenant.
+// It lists every api key, the keys themselves aren't returned
+func (s *Server) apiKeys(context *gin.Context) {
+	keys, err := s.dbClient.GetAPIKeys(context.Request.Context())
+	if err != nil {
+		s.logger.Error("failed to get api keys", zap.Error(err))
+		respondWithInternalError(context, "failed to get api keys")
+		return
+	}
+	if keys == nil {
+		keys = []api.APIKey{}
+	}
+	context.JSON(http.StatusOK, APIKeysResponse{APIKeys: keys})
+}
+
+// revokeAPIKey is a handler for the DELETE /apiKeys endpoint, it requires the admin token, and for the
+// DELETE /tenant/apiKeys endpoint, which requires an api key of a tenant and only revokes the keys of the tenant.
+// It has a required query parameter of id. The api servers stop accepting the key within a minute
+func (s *Server) revokeAPIKey(context *gin.Context) {
+	ctx := context.Request.Context()
+	id := context.Query("id")
+	if id == "" {
+		respondWithMissingParameter(context, "id", "id is required")
+		return
+	}
+	key, err := s.dbClient.RevokeAPIKey(ctx, id, time.Now().UTC())
+	if err != nil {
+		s.logger.Error("failed to revoke api key", zap.Error(err), zap.String("id", id))
+		respondWithInternalError(context, "failed to revoke api key")
+		return
+	}
+	if key == nil {
+		respondWithError(context, api.ErrorCodeNotFound, "api key not found", map[string]string{"parameter": "id"})
+		return
+	}
+	s.logger.Info("revoked api key", zap.String("id", id))
+	s.apiKeyCache.Flush()
+	context.JSON(http.StatusOK, APIKeyResponse{APIKey: *key})
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
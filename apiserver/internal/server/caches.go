@@ -9,6 +9,7 @@ import (
 
 func (s *Server) StartCaches(ctx context.Context) {
 	go s.updateStatusPageCache(ctx)
+	go s.updateDbStats(ctx)
 }
 
 const statusPageCacheRefreshInterval = 1 * time.Minute
@@ -31,7 +32,63 @@ func (s *Server) updateStatusPageCacheInner(ctx context.Context) {
 		return
 	}
 
+	stored := map[string]bool{}
 	for _, statusPage := range statusPages {
+		stored[statusPage.URL] = true
 		s.statusPageCache.Set(statusPage.URL, statusPage, cache.DefaultExpiration)
 	}
+	// Status pages that have moved to another url are no longer stored at the old one
+	for url := range s.statusPageCache.Items() {
+		if !stored[url] {
+			s.statusPageCache.Delete(url)
+		}
+	}
+
+	aliases, err := s.dbClient.GetStatusPageAliases(ctx)
+	if err != nil {
+		s.logger.Error("failed to get status page aliases", zap.Error(err))
+		return
+	}
+	for _, alias := range aliases {
+		s.aliasCache.Set(alias.URL, alias.StatusPageUrl, cache.DefaultExpiration)
+	}
+}
+
+// canonicalStatusPageUrl returns the url that the status page at statusPageUrl has moved to,
+// or statusPageUrl if it hasn't moved, so requests for an old url are answered with the status page's incidents
+func (s *Server) canonicalStatusPageUrl(statusPageUrl string) string {
+	if canonicalUrl, found := s.aliasCache.Get(statusPageUrl); found {
+		return canonicalUrl.(string)
+	}
+	return statusPageUrl
+}
+
+const dbStatsRefreshInterval = 5 * time.Minute
+const dbStatsCacheKey = "dbStats"
+
+// updateDbStats periodically collects and logs the database stats so operators can watch growth and spot stalled scrapes
+// The size of the database is recorded each time, the operator summary reports its growth from them
+func (s *Server) updateDbStats(ctx context.Context) {
+	ticker := time.NewTicker(dbStatsRefreshInterval)
+	s.updateDbStatsInner(ctx)
+	for {
+		select {
+		case <-ticker.C:
+			s.updateDbStatsInner(ctx)
+		}
+	}
+}
+
+func (s *Server) updateDbStatsInner(ctx context.Context) {
+	stats, err := s.dbClient.DbStats(ctx)
+	if err != nil {
+		s.logger.Error("failed to get db stats", zap.Error(err))
+		return
+	}
+	s.logger.Info("db stats", zap.Any("tables", stats.Tables), zap.Timep("oldestIncident", stats.OldestIncident), zap.Timep("newestIncident", stats.NewestIncident))
+	s.dbStatsCache.Set(dbStatsCacheKey, *stats, cache.NoExpiration)
+	err = s.dbClient.RecordDbSizeSnapshot(ctx, stats.Snapshot())
+	if err != nil {
+		s.logger.Error("failed to record db size snapshot", zap.Error(err))
+	}
 }
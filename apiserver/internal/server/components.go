@@ -0,0 +1,56 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type ComponentsResponse struct {
+	Data []api.Component `json:"data"`
+	Page
+}
+
+// components is a handler for the /components endpoint.
+// It has a required query parameter of statusPageUrl and returns the component list of the status page
+func (s *Server) components(context *gin.Context) {
+	ctx := context.Request.Context()
+	page, ok := parsePageRequest(context, 0, textKey, textKey)
+	if !ok {
+		return
+	}
+	statusPageUrl := s.canonicalStatusPageUrl(context.Query("statusPageUrl"))
+	if statusPageUrl == "" {
+		respondWithMissingParameter(context, "statusPageUrl", "statusPageUrl is required")
+		return
+	}
+
+	if _, found := s.statusPageCache.Get(statusPageUrl); !found {
+		respondWithStatusPageNotFound(context)
+		return
+	}
+
+	components, err := s.dbClient.GetComponentsPage(ctx, statusPageUrl, page.query())
+	if err != nil {
+		s.logger.Error("failed to get components", zap.Error(err))
+		respondWithInternalError(context, "failed to get components")
+		return
+	}
+	total := int64(len(components))
+	if page.paginated() {
+		total, err = s.dbClient.CountComponents(ctx, statusPageUrl)
+		if err != nil {
+			s.logger.Error("failed to count components", zap.Error(err))
+			respondWithInternalError(context, "failed to get components")
+			return
+		}
+	}
+	if components == nil {
+		components = []api.Component{}
+	}
+	components, envelope := pageOf(components, page, int(total), func(component api.Component) []string {
+		return []string{component.Group, component.Name}
+	})
+	context.JSON(http.StatusOK, ComponentsResponse{Data: components, Page: envelope})
+}
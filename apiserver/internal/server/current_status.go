@@ -31,21 +31,21 @@ type CurrentStatusResponse struct {
 // If the status page is known to statusphere and it is not indexed, it returns UNKNOWN.
 func (s *Server) currentStatus(context *gin.Context) {
 	ctx := context.Request.Context()
-	statusPageUrl := context.Query("statusPageUrl")
+	statusPageUrl := s.canonicalStatusPageUrl(context.Query("statusPageUrl"))
 	if statusPageUrl == "" {
-		context.JSON(http.StatusBadRequest, gin.H{"error": "statusPageUrl is required"})
+		respondWithMissingParameter(context, "statusPageUrl", "statusPageUrl is required")
 		return
 	}
 
 	statusPageInterface, found := s.statusPageCache.Get(statusPageUrl)
 	if !found {
-		context.JSON(http.StatusNotFound, gin.H{"error": "status page not known to statusphere"})
+		respondWithStatusPageNotFound(context)
 		return
 	}
 
 	statusPageInterfaceCasted, ok := statusPageInterface.(api.StatusPage)
 	if !ok {
-		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cast status page to api.StatusPage"})
+		respondWithInternalError(context, "failed to cast status page to api.StatusPage")
 		return
 	}
 
@@ -58,15 +58,11 @@ func (s *Server) currentStatus(context *gin.Context) {
 	incidents, found, err := s.getCurrentIncidentsFromCache(ctx, statusPageUrl)
 	if err != nil {
 		s.logger.Error("failed to get incidents from cache", zap.Error(err))
-		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get incidents from cache"})
+		respondWithInternalError(context, "failed to get incidents from cache")
 		return
 	}
 	if found {
-		if len(incidents) > 0 {
-			context.JSON(http.StatusOK, CurrentStatusResponse{Status: StatusDegraded, IsIndexed: true})
-			return
-		}
-		context.JSON(http.StatusOK, CurrentStatusResponse{Status: StatusUp, IsIndexed: true})
+		s.respondWithCurrentStatus(context, incidents)
 		return
 	}
 
@@ -74,21 +70,25 @@ func (s *Server) currentStatus(context *gin.Context) {
 	incidents, found, err = s.getCurrentIncidentsFromDatabase(ctx, statusPageUrl)
 	if err != nil {
 		s.logger.Error("failed to get incidents from database", zap.Error(err))
-		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get incidents from database"})
+		respondWithInternalError(context, "failed to get incidents from database")
 		return
 	}
 	if !found {
-		context.JSON(http.StatusNotFound, gin.H{"error": "status page not known to statusphere"})
+		respondWithStatusPageNotFound(context)
 		return
 	}
 
 	s.currentIncidentCache.Set(statusPageUrl, incidents, cache.DefaultExpiration)
+	s.respondWithCurrentStatus(context, incidents)
+}
+
+// respondWithCurrentStatus writes DEGRADED if there are current incidents and UP otherwise, versioned by the incidents
+func (s *Server) respondWithCurrentStatus(context *gin.Context, incidents []api.Incident) {
+	status := StatusUp
 	if len(incidents) > 0 {
-		context.JSON(http.StatusOK, CurrentStatusResponse{Status: StatusDegraded, IsIndexed: true})
-		return
+		status = StatusDegraded
 	}
-
-	context.JSON(http.StatusOK, CurrentStatusResponse{Status: StatusUp, IsIndexed: true})
+	s.respondWithETag(context, incidentsETag(context, incidents, len(incidents)), CurrentStatusResponse{Status: status, IsIndexed: true})
 }
 
 // getCurrentIncidentsFromCache attempts to get the current incidents from the cache.
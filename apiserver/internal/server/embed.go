@@ -0,0 +1,151 @@
+package server
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/patrickmn/go-cache"
+	"go.uber.org/zap"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+// embedIncidents is the number of recent incidents the widget shows
+const embedIncidents = 5
+
+type EmbedResponse struct {
+	StatusPageUrl string `json:"statusPageUrl"`
+	Name          string `json:"name"`
+	Status        Status `json:"status"`
+	IsIndexed     bool   `json:"isIndexed"`
+	// Incidents are the most recent incidents of the status page, most recent first
+	Incidents []api.Incident `json:"incidents"`
+}
+
+// embed is a handler for the /embed/{statusPageUrl} endpoint, a widget of the current status and recent incidents of a
+// status page that can be put in an iframe. It is html unless format=json is given or the client only accepts json
+// It doesn't require an api key so that pages can embed it, and it is cacheable at the edge for the configured max age
+func (s *Server) embed(context *gin.Context) {
+	ctx := context.Request.Context()
+	statusPageUrl := s.canonicalStatusPageUrl(embedStatusPageUrl(context.Param("statusPageUrl")))
+	if statusPageUrl == "" {
+		respondWithMissingParameter(context, "statusPageUrl", "statusPageUrl is required")
+		return
+	}
+	statusPageInterface, found := s.statusPageCache.Get(statusPageUrl)
+	if !found {
+		respondWithStatusPageNotFound(context)
+		return
+	}
+	statusPage, ok := statusPageInterface.(api.StatusPage)
+	if !ok {
+		respondWithInternalError(context, "failed to cast status page to api.StatusPage")
+		return
+	}
+
+	response := EmbedResponse{StatusPageUrl: statusPageUrl, Name: statusPage.Name, Status: StatusUnknown, IsIndexed: statusPage.IsIndexed, Incidents: []api.Incident{}}
+	var current []api.Incident
+	if statusPage.IsIndexed {
+		cached, found, err := s.getCurrentIncidentsFromCache(ctx, statusPageUrl)
+		current = cached
+		if err != nil || !found {
+			current, _, err = s.getCurrentIncidentsFromDatabase(ctx, statusPageUrl)
+			if err != nil {
+				s.logger.Error("failed to get current incidents", zap.Error(err), zap.String("statusPageUrl", statusPageUrl))
+				respondWithInternalError(context, "failed to get current incidents")
+				return
+			}
+			s.currentIncidentCache.Set(statusPageUrl, current, cache.DefaultExpiration)
+		}
+		response.Status = StatusUp
+		if len(current) > 0 {
+			response.Status = StatusDegraded
+		}
+
+		// The cached incidents are sorted most recent first
+		if cached, found, err := s.getIncidentsFromCache(ctx, statusPageUrl); err == nil && found {
+			response.Incidents = append(response.Incidents, cached[:min(embedIncidents, len(cached))]...)
+		} else {
+			recent, err := s.dbClient.GetIncidentsByQuery(ctx, statusPageUrl, db.IncidentQuery{Page: db.Page{Limit: embedIncidents}})
+			if err != nil {
+				s.logger.Error("failed to get incidents", zap.Error(err), zap.String("statusPageUrl", statusPageUrl))
+				respondWithInternalError(context, "failed to get incidents")
+				return
+			}
+			response.Incidents = append(response.Incidents, recent...)
+		}
+	}
+
+	html := context.Query("format") != "json" && !strings.HasPrefix(context.GetHeader("Accept"), "application/json")
+	format := "json"
+	if html {
+		format = "html"
+	}
+	// The status follows from the current incidents, the format tells apart the representations of the same incidents
+	etag := strings.TrimSuffix(incidentsETag(context, append(append([]api.Incident{}, current...), response.Incidents...), len(current)), `"`) + "-" + format + `"`
+	context.Header("Vary", "Accept")
+	if s.notModified(context, etag) {
+		return
+	}
+	if !html {
+		context.JSON(http.StatusOK, response)
+		return
+	}
+	var body bytes.Buffer
+	err := embedTemplate.Execute(&body, response)
+	if err != nil {
+		s.logger.Error("failed to render the embed", zap.Error(err), zap.String("statusPageUrl", statusPageUrl))
+		respondWithInternalError(context, "failed to render the embed")
+		return
+	}
+	context.Data(http.StatusOK, "text/html; charset=utf-8", body.Bytes())
+}
+
+// embedStatusPageUrl is the status page url of the path, which is either escaped or a plain url
+// Proxies often merge the slashes of the scheme, so https:/status.example.com is read as https://status.example.com
+func embedStatusPageUrl(path string) string {
+	url := strings.TrimPrefix(path, "/")
+	for _, scheme := range []string{"https:/", "http:/"} {
+		if strings.HasPrefix(url, scheme) && !strings.HasPrefix(url, scheme+"/") {
+			url = scheme + url[len(scheme)-1:]
+		}
+	}
+	return strings.TrimSuffix(url, "/")
+}
+
+var embedTemplate = template.Must(template.New("embed").Funcs(template.FuncMap{
+	"lower": func(status Status) string { return strings.ToLower(string(status)) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Name}} status</title>
+<style>
+body { margin: 0; padding: 12px; font: 14px/1.4 -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2328; }
+a { color: inherit; }
+.status { display: flex; align-items: center; gap: 8px; font-weight: 600; }
+.dot { width: 10px; height: 10px; border-radius: 50%; background: #8c959f; }
+.up .dot { background: #1a7f37; }
+.degraded .dot { background: #cf222e; }
+ul { list-style: none; margin: 8px 0 0; padding: 0; }
+li { padding: 4px 0; border-top: 1px solid #d0d7de; }
+.meta { color: #656d76; font-size: 12px; }
+</style>
+</head>
+<body>
+<div class="status {{lower .Status}}"><span class="dot"></span><a href="{{.StatusPageUrl}}" target="_blank" rel="noopener">{{.Name}}</a>
+{{- if eq .Status "UP"}} is operational{{else if eq .Status "DEGRADED"}} has an ongoing incident{{else}} status is unknown{{end}}</div>
+{{- if .Incidents}}
+<ul>
+{{- range .Incidents}}
+<li><a href="{{.DeepLink}}" target="_blank" rel="noopener">{{.Title}}</a>
+<div class="meta">{{.Impact}} &middot; {{.StartTime.Format "Jan 2, 2006 15:04 MST"}}{{if not .EndTime}} &middot; ongoing{{end}}</div></li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+)
+
+// respondWithError writes the error with the http status of the error code, as problem details if the client accepts them
+// and in the error envelope otherwise
+func respondWithError(context *gin.Context, code api.ErrorCode, message string, details map[string]string) {
+	if api.AcceptsProblem(context.GetHeader("Accept")) {
+		context.Header("Content-Type", api.ProblemContentType)
+		context.AbortWithStatusJSON(code.Status(), api.NewProblem(code, message, details, context.Request.URL.Path))
+		return
+	}
+	context.AbortWithStatusJSON(code.Status(), api.ErrorResponse{Error: api.NewError(code, message, details)})
+}
+
+func respondWithMissingParameter(context *gin.Context, parameter string, message string) {
+	respondWithError(context, api.ErrorCodeMissingParameter, message, map[string]string{"parameter": parameter})
+}
+
+func respondWithInvalidParameter(context *gin.Context, parameter string, message string) {
+	respondWithError(context, api.ErrorCodeInvalidParameter, message, map[string]string{"parameter": parameter})
+}
+
+func respondWithStatusPageNotFound(context *gin.Context) {
+	respondWithError(context, api.ErrorCodeStatusPageNotFound, "status page not known to statusphere", nil)
+}
+
+func respondWithInternalError(context *gin.Context, message string) {
+	respondWithError(context, api.ErrorCodeInternal, message, nil)
+}
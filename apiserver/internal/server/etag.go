@@ -0,0 +1,74 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// incidentsETag is a weak ETag of a response made of the incidents out of total, it changes when one of them is updated,
+// added or removed. The query string is part of it as the parameters shape the response around the incidents
+func incidentsETag(context *gin.Context, incidents []api.Incident, total int) string {
+	hash := sha256.New()
+	hash.Write([]byte(context.Request.URL.RawQuery + "\n" + strconv.Itoa(total)))
+	for _, incident := range incidents {
+		hash.Write([]byte("\n" + incident.DeepLink + "\n" + strconv.FormatInt(incident.UpdatedAt.UnixMicro(), 10)))
+	}
+	return `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// respondWithETag writes the body with the ETag and Cache-Control headers
+// or 304 Not Modified without a body if the client already has the version, see If-None-Match
+func (s *Server) respondWithETag(context *gin.Context, etag string, body interface{}) {
+	if s.notModified(context, etag) {
+		return
+	}
+	context.JSON(http.StatusOK, body)
+}
+
+// notModified writes the ETag and Cache-Control headers, and 304 Not Modified if the client already has the version
+// It returns true if the 304 was written, otherwise the caller writes the body
+func (s *Server) notModified(context *gin.Context, etag string) bool {
+	context.Header("ETag", etag)
+	context.Header("Cache-Control", s.cacheControl(context))
+	// The responses depend on the tenant of the api key, a shared cache must not answer one key with those of another
+	context.Writer.Header().Add("Vary", apiKeyHeader)
+	if etagMatches(context.GetHeader("If-None-Match"), etag) {
+		context.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// cacheControl lets shared caches keep the responses for the configured max age, they are revalidated after it
+// The responses to a request with an api key are private, only the client can keep them, as they can be scoped to the
+// tenant of the key and a shared cache would otherwise serve them without the key being checked. The key is always sent
+// when the api key is required
+func (s *Server) cacheControl(context *gin.Context) string {
+	if s.config.CacheMaxAge <= 0 {
+		return "no-cache"
+	}
+	maxAge := "max-age=" + strconv.Itoa(int(s.config.CacheMaxAge.Seconds()))
+	if context.GetHeader(apiKeyHeader) != "" {
+		return "private, " + maxAge
+	}
+	return "public, " + maxAge
+}
+
+// etagMatches compares the ETags of an If-None-Match header with the etag, weakly as the spec requires for it
+func etagMatches(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
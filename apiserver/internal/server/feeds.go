@@ -0,0 +1,243 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"go.uber.org/zap"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// feedEntries is the number of the most recently updated incidents a feed has
+const feedEntries = 50
+
+// atomFeed is an Atom 1.0 feed, see RFC 4287
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomPerson  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Author     *atomPerson    `xml:"author,omitempty"`
+	Link       atomLink       `xml:"link"`
+	Categories []atomCategory `xml:"category"`
+	Content    atomText       `xml:"content"`
+}
+
+// feed is a handler for the /feeds/{statusPageUrl}.atom and /feeds/tag/{tag}.atom endpoints, and routes
+// /feeds/maintenance.ics to maintenanceFeed as gin can't have other routes next to the wildcard.
+// It returns an Atom feed of the incidents of a status page, or of the status pages with the tag, an entry per incident
+// that is updated as the incident changes, the most recently updated first
+// It doesn't require an api key so that feed readers can follow it, the tags of the tenant are used if one is sent
+func (s *Server) feed(context *gin.Context) {
+	ctx := context.Request.Context()
+	path := strings.TrimPrefix(context.Param("feed"), "/")
+	if path == "maintenance.ics" {
+		s.maintenanceFeed(context)
+		return
+	}
+	if !strings.HasSuffix(path, ".atom") {
+		respondWithError(context, api.ErrorCodeNotFound, "feeds end with .atom, or are maintenance.ics", map[string]string{"feed": path})
+		return
+	}
+	path = strings.TrimSuffix(path, ".atom")
+
+	var title string
+	var alternate string
+	var statusPageUrls []string
+	if tag, isTag := strings.CutPrefix(path, "tag/"); isTag {
+		tag = strings.ToLower(tag)
+		tags, err := s.getStatusPageTags(ctx)
+		if err != nil {
+			s.logger.Error("failed to get status page tags", zap.Error(err))
+			respondWithInternalError(context, "failed to get status page tags")
+			return
+		}
+		for statusPageUrl, statusPageTags := range tags {
+			statusPage, found := s.getStatusPageFromCache(statusPageUrl)
+			if !found || !includeStatusPage(context, statusPage) {
+				continue
+			}
+			for _, statusPageTag := range statusPageTags {
+				if statusPageTag == tag {
+					statusPageUrls = append(statusPageUrls, statusPageUrl)
+					break
+				}
+			}
+		}
+		if len(statusPageUrls) == 0 {
+			respondWithError(context, api.ErrorCodeNotFound, "no status page has the tag", map[string]string{"tag": tag})
+			return
+		}
+		sort.Strings(statusPageUrls)
+		title = "Incidents tagged " + tag
+	} else {
+		statusPageUrl := s.canonicalStatusPageUrl(embedStatusPageUrl(path))
+		if statusPageUrl == "" {
+			respondWithMissingParameter(context, "statusPageUrl", "statusPageUrl is required")
+			return
+		}
+		statusPage, found := s.getStatusPageFromCache(statusPageUrl)
+		if !found {
+			respondWithStatusPageNotFound(context)
+			return
+		}
+		statusPageUrls = []string{statusPageUrl}
+		title = statusPage.Name + " incidents"
+		alternate = statusPageUrl
+	}
+
+	incidents, err := s.dbClient.GetLatestIncidents(ctx, statusPageUrls, feedEntries)
+	if err != nil {
+		s.logger.Error("failed to get incidents", zap.Error(err), zap.Strings("statusPageUrls", statusPageUrls))
+		respondWithInternalError(context, "failed to get incidents")
+		return
+	}
+	// The entries change with the incidents, the status pages of a tag with the tags
+	statusPagesHash := sha256.Sum256([]byte(strings.Join(statusPageUrls, "\n")))
+	if s.notModified(context, strings.TrimSuffix(incidentsETag(context, incidents, len(incidents)), `"`)+"-"+hex.EncodeToString(statusPagesHash[:8])+`"`) {
+		return
+	}
+
+	self := s.publicURL(context) + context.Request.URL.Path
+	feed := atomFeed{ID: self, Title: title, Author: atomPerson{Name: "Statusphere"}, Links: []atomLink{{Rel: "self", Type: "application/atom+xml", Href: self}}, Entries: []atomEntry{}}
+	if alternate != "" {
+		feed.Links = append(feed.Links, atomLink{Rel: "alternate", Type: "text/html", Href: alternate})
+	}
+	// A feed without incidents was last updated when the first of its status pages was added, which isn't stored
+	updated := time.Unix(0, 0).UTC()
+	for _, incident := range incidents {
+		entry, err := s.feedEntry(incident, len(statusPageUrls) > 1)
+		if err != nil {
+			s.logger.Error("failed to render the feed entry", zap.Error(err), zap.String("deepLink", incident.DeepLink))
+			respondWithInternalError(context, "failed to render the feed")
+			return
+		}
+		feed.Entries = append(feed.Entries, entry)
+		if incident.UpdatedAt.After(updated) {
+			updated = incident.UpdatedAt.UTC()
+		}
+	}
+	feed.Updated = updated.Format(time.RFC3339)
+
+	body, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		s.logger.Error("failed to marshal the feed", zap.Error(err))
+		respondWithInternalError(context, "failed to render the feed")
+		return
+	}
+	context.Data(http.StatusOK, "application/atom+xml; charset=utf-8", append([]byte(xml.Header), body...))
+}
+
+// feedEntry is the entry of the incident, the name of its status page is in the title if the feed has several
+func (s *Server) feedEntry(incident api.Incident, named bool) (atomEntry, error) {
+	name := incident.StatusPageUrl
+	if statusPage, found := s.getStatusPageFromCache(incident.StatusPageUrl); found {
+		name = statusPage.Name
+	}
+	title := incident.Title
+	if incident.EndTime != nil {
+		title = "Resolved: " + title
+	}
+	if named {
+		title = name + ": " + title
+	}
+	events := append([]api.IncidentUpdate{}, incident.Events...)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.After(events[j].Time)
+	})
+	var content bytes.Buffer
+	data := struct {
+		Incident    api.Incident
+		Events      []api.IncidentUpdate
+		Description template.HTML
+	}{Incident: incident, Events: events}
+	// The html of the description is sanitized when it is scraped
+	if incident.DescriptionHTML != nil {
+		data.Description = template.HTML(*incident.DescriptionHTML)
+	}
+	err := feedEntryTemplate.Execute(&content, data)
+	if err != nil {
+		return atomEntry{}, err
+	}
+	entry := atomEntry{
+		ID:         incident.DeepLink,
+		Title:      title,
+		Published:  incident.StartTime.UTC().Format(time.RFC3339),
+		Updated:    incident.UpdatedAt.UTC().Format(time.RFC3339),
+		Author:     &atomPerson{Name: name},
+		Link:       atomLink{Rel: "alternate", Type: "text/html", Href: incident.DeepLink},
+		Categories: []atomCategory{{Term: string(incident.Impact)}},
+		Content:    atomText{Type: "html", Body: content.String()},
+	}
+	// The id of an entry has to be an IRI
+	if parsed, err := url.Parse(incident.DeepLink); err != nil || !parsed.IsAbs() {
+		entry.ID = "urn:statusphere:incident:" + url.PathEscape(incident.DeepLink)
+	}
+	for _, component := range incident.Components {
+		entry.Categories = append(entry.Categories, atomCategory{Term: component})
+	}
+	return entry, nil
+}
+
+// publicURL is the url the api is reached at, the configured one or the one the request was sent to
+func (s *Server) publicURL(context *gin.Context) string {
+	if s.config.PublicURL != "" {
+		return strings.TrimSuffix(s.config.PublicURL, "/")
+	}
+	scheme := "http"
+	if context.Request.TLS != nil {
+		scheme = "https"
+	}
+	if forwarded := context.GetHeader("X-Forwarded-Proto"); forwarded == "http" || forwarded == "https" {
+		scheme = forwarded
+	}
+	return scheme + "://" + context.Request.Host
+}
+
+var feedEntryTemplate = template.Must(template.New("feedEntry").Parse(`<p><strong>Impact:</strong> {{.Incident.Impact}}
+{{- if .Incident.Components}}<br><strong>Components:</strong> {{range $i, $c := .Incident.Components}}{{if $i}}, {{end}}{{$c}}{{end}}{{end}}
+<br><strong>Started:</strong> {{.Incident.StartTime.UTC.Format "Jan 2, 2006 15:04 MST"}}
+{{- if .Incident.EndTime}}<br><strong>Resolved:</strong> {{.Incident.EndTime.UTC.Format "Jan 2, 2006 15:04 MST"}}{{end}}</p>
+{{- range .Events}}
+<p><strong>{{.State}}</strong> &middot; {{.Time.UTC.Format "Jan 2, 2006 15:04 MST"}}<br>{{.Body}}</p>
+{{- end}}
+{{- if and (not .Events) .Description}}
+{{.Description}}
+{{- end}}`))
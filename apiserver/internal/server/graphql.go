@@ -0,0 +1,290 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/metoro-io/statusphere/common/filter"
+	"github.com/metoro-io/statusphere/common/graphql"
+	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultGraphQLPageSize = 20
+	maxGraphQLPageSize     = 100
+	graphQLMaxDepth        = 8
+	graphQLMaxCost         = 10000
+)
+
+// Query is the query type of the GraphQL api
+type Query struct{}
+
+type PageInfo struct {
+	// EndCursor is passed as after to get the next page
+	EndCursor   string `json:"endCursor"`
+	HasNextPage bool   `json:"hasNextPage"`
+}
+
+type StatusPageConnection struct {
+	TotalCount int              `json:"totalCount"`
+	Nodes      []api.StatusPage `json:"nodes"`
+	PageInfo   PageInfo         `json:"pageInfo"`
+}
+
+type IncidentConnection struct {
+	TotalCount int            `json:"totalCount"`
+	Nodes      []api.Incident `json:"nodes"`
+	PageInfo   PageInfo       `json:"pageInfo"`
+}
+
+// newGraphQLSchema returns the GraphQL schema, the objects have the fields of the REST responses
+// and the status pages have their incidents, components, maintenances and current status nested
+func (s *Server) newGraphQLSchema() *graphql.Schema {
+	schema := graphql.NewSchema(Query{}, graphQLMaxDepth, graphQLMaxCost)
+	pageArguments := []graphql.Argument{
+		{Name: "first", Type: "Int", Default: defaultGraphQLPageSize},
+		{Name: "after", Type: "String"},
+	}
+	schema.AddFields(Query{},
+		graphql.Field{
+			Name: "statusPage", Description: "A status page by url or by name, case insensitive",
+			Arguments: []graphql.Argument{{Name: "url", Type: "String"}, {Name: "name", Type: "String"}},
+			Type:      (*api.StatusPage)(nil), Resolve: s.resolveStatusPage,
+		},
+		graphql.Field{
+			Name: "statusPages", Description: "The status pages ordered by name, search matches the name and url",
+			Arguments: append(pageArguments,
+				graphql.Argument{Name: "search", Type: "String"},
+				graphql.Argument{Name: "category", Type: "String"},
+			),
+			Type: StatusPageConnection{}, Resolve: s.resolveStatusPages,
+		},
+		graphql.Field{
+			Name: "incidents", Description: "The incidents of every status page matching a filter expression, most recent first",
+			Arguments: []graphql.Argument{
+				{Name: "filter", Type: "String!"},
+				{Name: "first", Type: "Int", Default: defaultGraphQLPageSize},
+			},
+			Type: []api.Incident{}, Resolve: s.resolveIncidentsQuery,
+		},
+	)
+	schema.AddFields(api.StatusPage{},
+		graphql.Field{
+			Name: "incidents", Description: "The incidents of the status page, most recent first",
+			Arguments: append(pageArguments,
+				graphql.Argument{Name: "impacts", Type: "[String]"},
+				graphql.Argument{Name: "ongoing", Type: "Boolean"},
+			),
+			Type: IncidentConnection{}, Resolve: s.resolveStatusPageIncidents,
+		},
+		graphql.Field{Name: "components", Type: []api.Component{}, Resolve: s.resolveStatusPageComponents},
+		graphql.Field{Name: "maintenances", Type: []api.Maintenance{}, Resolve: s.resolveStatusPageMaintenances},
+		graphql.Field{Name: "currentStatus", Type: Status(""), Resolve: s.resolveStatusPageCurrentStatus},
+	)
+	return schema
+}
+
+// graphQL is a handler for the /graphql endpoint, it executes the query of a POST body or of the query parameters of a GET
+// The response follows the GraphQL spec rather than the error envelope of the REST endpoints
+func (s *Server) graphQL(context *gin.Context) {
+	var request graphql.Request
+	if context.Request.Method == http.MethodPost {
+		err := json.NewDecoder(context.Request.Body).Decode(&request)
+		if err != nil {
+			respondWithError(context, api.ErrorCodeInvalidBody, "the body must be a GraphQL request: "+err.Error(), nil)
+			return
+		}
+	} else {
+		request.Query = context.Query("query")
+		request.OperationName = context.Query("operationName")
+		if variables := context.Query("variables"); variables != "" {
+			err := json.Unmarshal([]byte(variables), &request.Variables)
+			if err != nil {
+				respondWithInvalidParameter(context, "variables", "variables must be a json object")
+				return
+			}
+		}
+	}
+	if request.Query == "" {
+		respondWithMissingParameter(context, "query", "query is required")
+		return
+	}
+	context.JSON(http.StatusOK, s.graphQLSchema.Execute(context.Request.Context(), request))
+}
+
+// graphQLSDL is a handler for the /graphql/schema endpoint, it returns the schema of the GraphQL api as SDL
+func (s *Server) graphQLSDL(context *gin.Context) {
+	context.String(http.StatusOK, s.graphQLSchema.SDL())
+}
+
+func (s *Server) resolveStatusPage(ctx context.Context, parent interface{}, args map[string]interface{}) (interface{}, error) {
+	url, _ := args["url"].(string)
+	name, _ := args["name"].(string)
+	if (url == "") == (name == "") {
+		return nil, errors.New("exactly one of url and name is required")
+	}
+	if url != "" {
+		statusPage, found := s.getStatusPageFromCache(s.canonicalStatusPageUrl(url))
+		if !found || !visibleStatusPage(ctx, statusPage) {
+			return nil, nil
+		}
+		return &statusPage, nil
+	}
+	for _, item := range s.statusPageCache.Items() {
+		statusPage := item.Object.(api.StatusPage)
+		if strings.EqualFold(statusPage.Name, name) && visibleStatusPage(ctx, statusPage) {
+			return &statusPage, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *Server) resolveStatusPages(ctx context.Context, parent interface{}, args map[string]interface{}) (interface{}, error) {
+	search, _ := args["search"].(string)
+	search = strings.ToLower(search)
+	category, _ := args["category"].(string)
+	var statusPages []api.StatusPage
+	for _, item := range s.statusPageCache.Items() {
+		statusPage := item.Object.(api.StatusPage)
+		if !visibleStatusPage(ctx, statusPage) {
+			continue
+		}
+		if category != "" && !strings.EqualFold(statusPage.Category, category) {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(statusPage.Name), search) && !strings.Contains(strings.ToLower(statusPage.URL), search) {
+			continue
+		}
+		statusPages = append(statusPages, statusPage)
+	}
+	sort.Slice(statusPages, func(i, j int) bool {
+		a, b := strings.ToLower(statusPages[i].Name), strings.ToLower(statusPages[j].Name)
+		if a != b {
+			return a < b
+		}
+		return statusPages[i].URL < statusPages[j].URL
+	})
+	nodes, pageInfo, err := paginate(statusPages, args, statusPageKey, statusPageFollows, textKey, textKey)
+	if err != nil {
+		return nil, err
+	}
+	return StatusPageConnection{TotalCount: len(statusPages), Nodes: nodes, PageInfo: pageInfo}, nil
+}
+
+func (s *Server) resolveIncidentsQuery(ctx context.Context, parent interface{}, args map[string]interface{}) (interface{}, error) {
+	expression, err := filter.Parse(args["filter"].(string))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid filter")
+	}
+	first, _ := args["first"].(int)
+	if first < 1 || first > maxGraphQLPageSize {
+		return nil, errors.Errorf("first must be between 1 and %d", maxGraphQLPageSize)
+	}
+	incidents, err := s.dbClient.QueryIncidents(ctx, expression, db.Page{Limit: first})
+	if err != nil {
+		return nil, errors.New("failed to query incidents")
+	}
+	return incidents, nil
+}
+
+func (s *Server) resolveStatusPageIncidents(ctx context.Context, parent interface{}, args map[string]interface{}) (interface{}, error) {
+	statusPage := parent.(api.StatusPage)
+	var incidents []api.Incident
+	if statusPage.IsIndexed {
+		cached, found, err := s.getIncidentsFromCache(ctx, statusPage.URL)
+		if err != nil || !found {
+			cached, _, err = s.getIncidentsFromDatabase(ctx, statusPage.URL)
+			if err != nil {
+				return nil, errors.New("failed to get incidents")
+			}
+			sortIncidentsDescending(cached)
+			s.incidentCache.Set(statusPage.URL, cached, cache.DefaultExpiration)
+		}
+		impacts, _ := args["impacts"].([]interface{})
+		for _, incident := range cached {
+			if len(impacts) > 0 && !slices.Contains(impacts, interface{}(string(incident.Impact))) {
+				continue
+			}
+			if ongoing, set := args["ongoing"].(bool); set && ongoing != (incident.EndTime == nil) {
+				continue
+			}
+			incidents = append(incidents, incident)
+		}
+	}
+	nodes, pageInfo, err := paginate(incidents, args, incidentKey, incidentFollows, timeKey, textKey)
+	if err != nil {
+		return nil, err
+	}
+	return IncidentConnection{TotalCount: len(incidents), Nodes: nodes, PageInfo: pageInfo}, nil
+}
+
+func (s *Server) resolveStatusPageComponents(ctx context.Context, parent interface{}, args map[string]interface{}) (interface{}, error) {
+	components, err := s.dbClient.GetComponents(ctx, parent.(api.StatusPage).URL)
+	if err != nil {
+		return nil, errors.New("failed to get components")
+	}
+	if components == nil {
+		components = []api.Component{}
+	}
+	return components, nil
+}
+
+func (s *Server) resolveStatusPageMaintenances(ctx context.Context, parent interface{}, args map[string]interface{}) (interface{}, error) {
+	maintenances, err := s.dbClient.GetMaintenances(ctx, parent.(api.StatusPage).URL)
+	if err != nil {
+		return nil, errors.New("failed to get maintenances")
+	}
+	if maintenances == nil {
+		maintenances = []api.Maintenance{}
+	}
+	return maintenances, nil
+}
+
+func (s *Server) resolveStatusPageCurrentStatus(ctx context.Context, parent interface{}, args map[string]interface{}) (interface{}, error) {
+	statusPage := parent.(api.StatusPage)
+	if !statusPage.IsIndexed {
+		return StatusUnknown, nil
+	}
+	incidents, found, err := s.getCurrentIncidentsFromCache(ctx, statusPage.URL)
+	if err != nil || !found {
+		incidents, _, err = s.getCurrentIncidentsFromDatabase(ctx, statusPage.URL)
+		if err != nil {
+			return nil, errors.New("failed to get current status")
+		}
+		s.currentIncidentCache.Set(statusPage.URL, incidents, cache.DefaultExpiration)
+	}
+	if len(incidents) > 0 {
+		return StatusDegraded, nil
+	}
+	return StatusUp, nil
+}
+
+// paginate returns the page of the sorted items for the first and after arguments, see pageOfSorted
+// The cursors are the same as the ones of the list endpoints, key are the parts of the key of the items
+func paginate[T any](items []T, args map[string]interface{}, itemKey func(T) []string, follows func(T, []string) bool, key ...keyPart) ([]T, PageInfo, error) {
+	first, _ := args["first"].(int)
+	if first < 1 || first > maxGraphQLPageSize {
+		return nil, PageInfo{}, errors.Errorf("first must be between 1 and %d", maxGraphQLPageSize)
+	}
+	page := pageRequest{limit: first}
+	if after, _ := args["after"].(string); after != "" {
+		var ok bool
+		page.after, ok = decodeKeyCursor(after, key)
+		if !ok {
+			return nil, PageInfo{}, errors.New("after must be an endCursor returned by a previous page")
+		}
+	}
+	nodes, envelope := pageOfSorted(items, page, itemKey, follows)
+	pageInfo := PageInfo{HasNextPage: envelope.NextCursor != nil}
+	if len(nodes) > 0 {
+		pageInfo.EndCursor = encodeKeyCursor(itemKey(nodes[len(nodes)-1]))
+	}
+	return nodes, pageInfo, nil
+}
@@ -4,54 +4,93 @@ import (
 	"context"
 	"github.com/gin-gonic/gin"
 	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
 	"github.com/patrickmn/go-cache"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"net/http"
 	"sort"
-	"strconv"
+	"strings"
+	"time"
 )
 
 type IncidentsResponse struct {
-	Incidents []api.Incident `json:"incidents"`
+	Data      []api.Incident `json:"data"`
 	IsIndexed bool           `json:"isIndexed"`
+	Page
 }
 
 // incidents is a handler for the /incidents endpoint.
-// It has a required query parameter of statusPageUrl
+// It has a required query parameter of statusPageUrl, and optional filters of impact (comma separated), component,
+// state (open or resolved) and from and to (RFC 3339) bounding the start time, sort (startTime or duration) and order (asc or desc)
+// The incidents without filters or sorting are served from the cache, the others are queried from the database
+// The responses of an indexed status page have an ETag, a client that sends it in If-None-Match gets a 304 until they change
 func (s *Server) incidents(context *gin.Context) {
 	ctx := context.Request.Context()
-	statusPageUrl := context.Query("statusPageUrl")
+	statusPageUrl := s.canonicalStatusPageUrl(context.Query("statusPageUrl"))
 	if statusPageUrl == "" {
-		context.JSON(http.StatusBadRequest, gin.H{"error": "statusPageUrl is required"})
+		respondWithMissingParameter(context, "statusPageUrl", "statusPageUrl is required")
 		return
 	}
 
-	var limit *int = nil
-	if limitStr := context.Query("limit"); limitStr != "" {
-		limitInt, err := strconv.Atoi(limitStr)
-		if err != nil {
-			context.JSON(http.StatusBadRequest, gin.H{"error": "limit must be an integer"})
-			return
-		}
-		limit = &limitInt
+	query, filtered, ok := parseIncidentQuery(context)
+	if !ok {
+		return
+	}
+	key := []keyPart{timeKey, textKey}
+	if query.SortBy == db.IncidentSortDuration {
+		key = append([]keyPart{integerKey}, key...)
+	}
+	// Every incident is returned unless a limit is given
+	page, ok := parsePageRequest(context, 0, key...)
+	if !ok {
+		return
 	}
 
 	// Check to see that the status page is known to statusphere and is indexed
 	statusPage, found := s.statusPageCache.Get(statusPageUrl)
 	if !found {
-		context.JSON(http.StatusNotFound, gin.H{"error": "status page not known to statusphere"})
+		respondWithStatusPageNotFound(context)
 		return
 	}
 
 	statusPageCasted, ok := statusPage.(api.StatusPage)
 	if !ok {
-		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cast status page"})
+		respondWithInternalError(context, "failed to cast status page")
 		return
 	}
 
 	if !statusPageCasted.IsIndexed {
-		context.JSON(http.StatusOK, IncidentsResponse{Incidents: []api.Incident{}, IsIndexed: false})
+		context.JSON(http.StatusOK, IncidentsResponse{Data: []api.Incident{}, IsIndexed: false})
+		return
+	}
+
+	// A page is queried from the database, the cache only has every incident
+	if filtered || page.paginated() {
+		query.Page = page.query()
+		incidents, err := s.dbClient.GetIncidentsByQuery(ctx, statusPageUrl, query)
+		if err != nil {
+			s.logger.Error("failed to query incidents", zap.Error(err), zap.String("statusPageUrl", statusPageUrl))
+			respondWithInternalError(context, "failed to get incidents from database")
+			return
+		}
+		total := int64(len(incidents))
+		if page.paginated() {
+			total, err = s.dbClient.CountIncidentsByQuery(ctx, statusPageUrl, query)
+			if err != nil {
+				s.logger.Error("failed to count incidents", zap.Error(err), zap.String("statusPageUrl", statusPageUrl))
+				respondWithInternalError(context, "failed to get incidents from database")
+				return
+			}
+		}
+		if incidents == nil {
+			incidents = []api.Incident{}
+		}
+		now := time.Now()
+		incidents, envelope := pageOf(incidents, page, int(total), func(incident api.Incident) []string {
+			return db.IncidentPageKey(incident, query.SortBy, now)
+		})
+		s.respondWithETag(context, incidentsETag(context, incidents, envelope.Total), IncidentsResponse{Data: incidents, IsIndexed: true, Page: envelope})
 		return
 	}
 
@@ -59,15 +98,12 @@ func (s *Server) incidents(context *gin.Context) {
 	incidents, found, err := s.getIncidentsFromCache(ctx, statusPageUrl)
 	if err != nil {
 		s.logger.Error("failed to get incidents from cache", zap.Error(err))
-		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get incidents from cache"})
+		respondWithInternalError(context, "failed to get incidents from cache")
 		return
 	}
 	if found {
 		sortIncidentsDescending(incidents)
-		if limit != nil && len(incidents) > *limit {
-			incidents = incidents[:*limit]
-		}
-		context.JSON(http.StatusOK, IncidentsResponse{Incidents: incidents, IsIndexed: true})
+		s.respondWithIncidents(context, incidents)
 		return
 	}
 
@@ -75,28 +111,113 @@ func (s *Server) incidents(context *gin.Context) {
 	incidents, found, err = s.getIncidentsFromDatabase(ctx, statusPageUrl)
 	if err != nil {
 		s.logger.Error("failed to get incidents from database", zap.Error(err))
-		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get incidents from database"})
+		respondWithInternalError(context, "failed to get incidents from database")
 		return
 	}
 	if !found {
-		context.JSON(http.StatusNotFound, gin.H{"error": "status page not known to statusphere"})
+		respondWithStatusPageNotFound(context)
 		return
 	}
 
 	sortIncidentsDescending(incidents)
 	s.incidentCache.Set(statusPageUrl, incidents, cache.DefaultExpiration)
-	if limit != nil && len(incidents) > *limit {
-		incidents = incidents[:*limit]
+	s.respondWithIncidents(context, incidents)
+}
+
+// respondWithIncidents responds with every incident of the status page as a single page
+func (s *Server) respondWithIncidents(context *gin.Context, incidents []api.Incident) {
+	s.respondWithETag(context, incidentsETag(context, incidents, len(incidents)), IncidentsResponse{Data: incidents, IsIndexed: true, Page: Page{Total: len(incidents)}})
+}
+
+// parseIncidentQuery parses the filter and sort parameters of /incidents, it returns false for filtered if none are set
+// and false for ok if one is invalid, in which case the error has been written
+func parseIncidentQuery(context *gin.Context) (db.IncidentQuery, bool, bool) {
+	var query db.IncidentQuery
+	filtered := false
+	if impacts := context.Query("impact"); impacts != "" {
+		for _, impact := range strings.Split(impacts, ",") {
+			impact := api.Impact(strings.ToLower(strings.TrimSpace(impact)))
+			if impact.Severity() == -1 {
+				respondWithInvalidParameter(context, "impact", "impact must be a comma separated list of none, maintenance, minor, major and critical")
+				return query, false, false
+			}
+			query.Impacts = append(query.Impacts, impact)
+		}
+		filtered = true
+	}
+	if component := context.Query("component"); component != "" {
+		query.Component = component
+		filtered = true
+	}
+	switch state := context.Query("state"); state {
+	case "":
+	case "open", "resolved":
+		open := state == "open"
+		query.Open = &open
+		filtered = true
+	default:
+		respondWithInvalidParameter(context, "state", "state must be open or resolved")
+		return query, false, false
+	}
+	for _, bound := range []struct {
+		parameter string
+		value     **time.Time
+	}{{"from", &query.StartedAfter}, {"to", &query.StartedBefore}} {
+		if boundStr := context.Query(bound.parameter); boundStr != "" {
+			parsed, err := time.Parse(time.RFC3339, boundStr)
+			if err != nil {
+				respondWithInvalidParameter(context, bound.parameter, bound.parameter+" must be an RFC 3339 timestamp")
+				return query, false, false
+			}
+			*bound.value = &parsed
+			filtered = true
+		}
 	}
-	context.JSON(http.StatusOK, IncidentsResponse{Incidents: incidents, IsIndexed: true})
+	switch sortBy := db.IncidentSort(context.Query("sort")); sortBy {
+	case "":
+	case db.IncidentSortStartTime, db.IncidentSortDuration:
+		query.SortBy = sortBy
+		filtered = true
+	default:
+		respondWithInvalidParameter(context, "sort", "sort must be startTime or duration")
+		return query, false, false
+	}
+	switch order := context.Query("order"); order {
+	case "", "desc":
+	case "asc":
+		query.Ascending = true
+		filtered = true
+	default:
+		respondWithInvalidParameter(context, "order", "order must be asc or desc")
+		return query, false, false
+	}
+	return query, filtered, true
 }
 
+// sortIncidentsDescending sorts the incidents most recent first, like the database does, see incidentKey
 func sortIncidentsDescending(incidents []api.Incident) {
 	sort.Slice(incidents, func(i, j int) bool {
-		return incidents[i].StartTime.After(incidents[j].StartTime)
+		if !incidents[i].StartTime.Equal(incidents[j].StartTime) {
+			return incidents[i].StartTime.After(incidents[j].StartTime)
+		}
+		return incidents[i].DeepLink > incidents[j].DeepLink
 	})
 }
 
+// incidentKey is the key of the incident in the incidents sorted most recent first, see db.IncidentPageKey
+func incidentKey(incident api.Incident) []string {
+	return db.IncidentPageKey(incident, db.IncidentSortStartTime, time.Time{})
+}
+
+// incidentFollows returns true if the incident comes after the key in the incidents sorted most recent first
+func incidentFollows(incident api.Incident, key []string) bool {
+	startTime, _ := time.Parse(time.RFC3339Nano, key[0])
+	if !incident.StartTime.Equal(startTime) {
+		return incident.StartTime.Before(startTime)
+	}
+	return incident.DeepLink < key[1]
+}
+
 // getIncidentsFromCache attempts to get the incidents from the cache.
 // If the incidents are found in the cache, it returns them.
 // If the incidents are not found in the cache, it returns false for the second return value.
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"go.uber.org/zap"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// maxCorrelationWindow bounds the window of a correlation, longer ones match most of the status pages
+const maxCorrelationWindow = 7 * 24 * time.Hour
+
+type CorrelatedStatusPage struct {
+	StatusPageUrl string `json:"statusPageUrl"`
+	Name          string `json:"name"`
+	// OverlapSeconds is how long the status page had an open incident during the window
+	OverlapSeconds int64 `json:"overlapSeconds"`
+	// Incidents are the incidents of the status page that were open during the window, earliest first
+	Incidents []api.Incident `json:"incidents"`
+}
+
+type CorrelatedIncidentsResponse struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// StatusPages are the other status pages with an open incident during the window, the longest overlap first
+	StatusPages []CorrelatedStatusPage `json:"statusPages"`
+}
+
+// incidentsCorrelated is a handler for the /incidents/correlated endpoint.
+// It returns the status pages that had open incidents during a window, which is either the span of the incident with the
+// deep link of the incident parameter, or from and to (RFC 3339, to defaults to now). The status page of the incident,
+// or statusPageUrl, is left out
+func (s *Server) incidentsCorrelated(context *gin.Context) {
+	ctx := context.Request.Context()
+	statusPageUrl := s.canonicalStatusPageUrl(context.Query("statusPageUrl"))
+	to := time.Now().UTC()
+	var from time.Time
+	if deepLink := context.Query("incident"); deepLink != "" {
+		incident, err := s.dbClient.GetIncident(ctx, deepLink)
+		if err != nil {
+			s.logger.Error("failed to get incident", zap.Error(err), zap.String("deepLink", deepLink))
+			respondWithInternalError(context, "failed to get incident")
+			return
+		}
+		if incident == nil {
+			respondWithError(context, api.ErrorCodeNotFound, "no incident has the deep link", map[string]string{"incident": deepLink})
+			return
+		}
+		from = incident.StartTime
+		if incident.EndTime != nil {
+			to = *incident.EndTime
+		}
+		statusPageUrl = incident.StatusPageUrl
+		// An incident that ended the moment it started is matched against the minute it started in, and a long one
+		// against its start as that is when the outages it caused began
+		if !from.Before(to) {
+			to = from.Add(time.Minute)
+		}
+		if to.Sub(from) > maxCorrelationWindow {
+			to = from.Add(maxCorrelationWindow)
+		}
+	} else {
+		fromStr := context.Query("from")
+		if fromStr == "" {
+			respondWithMissingParameter(context, "from", "either incident or from is required")
+			return
+		}
+		parsed, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			respondWithInvalidParameter(context, "from", "from must be an RFC 3339 time")
+			return
+		}
+		from = parsed
+		if toStr := context.Query("to"); toStr != "" {
+			parsed, err := time.Parse(time.RFC3339, toStr)
+			if err != nil {
+				respondWithInvalidParameter(context, "to", "to must be an RFC 3339 time")
+				return
+			}
+			to = parsed
+		}
+		if !from.Before(to) {
+			respondWithInvalidParameter(context, "from", "from must be before to")
+			return
+		}
+		if to.Sub(from) > maxCorrelationWindow {
+			respondWithInvalidParameter(context, "from", "the window can be at most 7 days long")
+			return
+		}
+	}
+
+	incidents, err := s.dbClient.GetOverlappingIncidents(ctx, from, to)
+	if err != nil {
+		s.logger.Error("failed to get overlapping incidents", zap.Error(err))
+		respondWithInternalError(context, "failed to get overlapping incidents")
+		return
+	}
+
+	statusPages := []CorrelatedStatusPage{}
+	for _, incident := range incidents {
+		if incident.StatusPageUrl == statusPageUrl {
+			continue
+		}
+		if len(statusPages) == 0 || statusPages[len(statusPages)-1].StatusPageUrl != incident.StatusPageUrl {
+			statusPage := CorrelatedStatusPage{StatusPageUrl: incident.StatusPageUrl}
+			if cached, found := s.statusPageCache.Get(incident.StatusPageUrl); found {
+				if cachedStatusPage, ok := cached.(api.StatusPage); ok {
+					statusPage.Name = cachedStatusPage.Name
+				}
+			}
+			statusPages = append(statusPages, statusPage)
+		}
+		statusPages[len(statusPages)-1].Incidents = append(statusPages[len(statusPages)-1].Incidents, incident)
+	}
+	now := time.Now()
+	for i := range statusPages {
+		var spans []interval
+		for _, incident := range statusPages[i].Incidents {
+			span := interval{start: incident.StartTime, end: now}
+			if incident.EndTime != nil {
+				span.end = *incident.EndTime
+			}
+			if span.start.Before(from) {
+				span.start = from
+			}
+			if span.end.After(to) {
+				span.end = to
+			}
+			if span.start.Before(span.end) {
+				spans = append(spans, span)
+			}
+		}
+		statusPages[i].OverlapSeconds = int64(totalDuration(mergeIntervals(spans)).Seconds())
+	}
+	sort.SliceStable(statusPages, func(i, j int) bool {
+		return statusPages[i].OverlapSeconds > statusPages[j].OverlapSeconds
+	})
+	context.JSON(http.StatusOK, CorrelatedIncidentsResponse{From: from, To: to, StatusPages: statusPages})
+}
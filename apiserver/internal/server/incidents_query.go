@@ -0,0 +1,58 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/filter"
+	"go.uber.org/zap"
+)
+
+type IncidentsQueryResponse struct {
+	Data []api.Incident `json:"data"`
+	Page
+}
+
+const defaultIncidentsQueryLimit = 100
+
+// incidentsQuery is a handler for the /incidents/query endpoint.
+// It has a required query parameter of filter, a filter expression such as impact>=major AND component~"compute"
+// See the filter package for the supported fields and operators
+// It returns the matching incidents across all status pages, most recent first, a page of limit at a time
+func (s *Server) incidentsQuery(context *gin.Context) {
+	ctx := context.Request.Context()
+	filterStr := context.Query("filter")
+	if filterStr == "" {
+		respondWithMissingParameter(context, "filter", "filter is required")
+		return
+	}
+
+	expression, err := filter.Parse(filterStr)
+	if err != nil {
+		respondWithError(context, api.ErrorCodeInvalidFilter, "invalid filter: "+err.Error(), nil)
+		return
+	}
+
+	page, ok := parsePageRequest(context, defaultIncidentsQueryLimit, timeKey, textKey)
+	if !ok {
+		return
+	}
+
+	incidents, err := s.dbClient.QueryIncidents(ctx, expression, page.query())
+	if err != nil {
+		s.logger.Error("failed to query incidents", zap.Error(err), zap.String("filter", filterStr))
+		respondWithInternalError(context, "failed to query incidents")
+		return
+	}
+	total, err := s.dbClient.CountIncidents(ctx, expression)
+	if err != nil {
+		s.logger.Error("failed to count incidents", zap.Error(err), zap.String("filter", filterStr))
+		respondWithInternalError(context, "failed to query incidents")
+		return
+	}
+	if incidents == nil {
+		incidents = []api.Incident{}
+	}
+
+	incidents, envelope := pageOf(incidents, page, int(total), incidentKey)
+	s.respondWithETag(context, incidentsETag(context, incidents, envelope.Total), IncidentsQueryResponse{Data: incidents, Page: envelope})
+}
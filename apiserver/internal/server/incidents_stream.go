@@ -0,0 +1,212 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"go.uber.org/zap"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// streamPollInterval is how often the outbox is polled for the changes that are pushed to the streams
+	streamPollInterval = 2 * time.Second
+	// streamHeartbeatInterval keeps idle streams from being closed by proxies
+	streamHeartbeatInterval = 15 * time.Second
+	// streamBufferSize is how many changes a stream can fall behind before it is closed, the client reconnects
+	// with Last-Event-ID and catches up from the outbox
+	streamBufferSize = 256
+	// streamReplayLimit bounds the changes that are replayed to a reconnecting client
+	streamReplayLimit = 1000
+)
+
+// StreamEvent is the data of an event of the incident stream
+type StreamEvent struct {
+	Type          api.ChangeEventType `json:"type"`
+	StatusPageUrl string              `json:"statusPageUrl"`
+	Incident      api.Incident        `json:"incident"`
+}
+
+// incidentStream polls the outbox for incident changes and fans them out to the connected streams
+// The changes come from the same outbox as /sync, so a stream sees what a syncing client would
+type incidentStream struct {
+	mu          sync.Mutex
+	subscribers map[chan api.ChangeEvent]bool
+}
+
+func newIncidentStream() *incidentStream {
+	return &incidentStream{subscribers: map[chan api.ChangeEvent]bool{}}
+}
+
+func (b *incidentStream) subscribe() chan api.ChangeEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	events := make(chan api.ChangeEvent, streamBufferSize)
+	b.subscribers[events] = true
+	return events
+}
+
+func (b *incidentStream) unsubscribe(events chan api.ChangeEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subscribers[events] {
+		delete(b.subscribers, events)
+		close(events)
+	}
+}
+
+// publish sends the events to every stream, a stream whose buffer is full is closed rather than blocking the others
+func (b *incidentStream) publish(events []api.ChangeEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for subscriber := range b.subscribers {
+		for _, event := range events {
+			select {
+			case subscriber <- event:
+			default:
+				delete(b.subscribers, subscriber)
+				close(subscriber)
+			}
+			if !b.subscribers[subscriber] {
+				break
+			}
+		}
+	}
+}
+
+// StartIncidentStream polls the outbox for the incident stream until the context is cancelled
+func (s *Server) StartIncidentStream(ctx context.Context) {
+	go func() {
+		_, since, err := s.dbClient.GetChangeEventCursorRange(ctx)
+		for err != nil {
+			s.logger.Error("failed to get change event range", zap.Error(err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(streamPollInterval):
+			}
+			_, since, err = s.dbClient.GetChangeEventCursorRange(ctx)
+		}
+		ticker := time.NewTicker(streamPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			for {
+				events, err := s.dbClient.GetChangeEventsSince(ctx, since, syncLimit)
+				if err != nil {
+					s.logger.Error("failed to get change events for the incident stream", zap.Error(err))
+					break
+				}
+				if len(events) == 0 {
+					break
+				}
+				since = events[len(events)-1].Cursor()
+				s.incidentStream.publish(events)
+				if len(events) < syncLimit {
+					break
+				}
+			}
+		}
+	}()
+}
+
+// incidentsStream is a handler for the /incidents/stream endpoint.
+// It has an optional query parameter of statusPageUrl, and pushes the incident changes as server-sent events until
+// the client disconnects. Each event has the change type as its name, its cursor as its id and a StreamEvent as its
+// data. A client that reconnects with Last-Event-ID is first sent the changes it missed
+func (s *Server) incidentsStream(context *gin.Context) {
+	ctx := context.Request.Context()
+	statusPageUrl := context.Query("statusPageUrl")
+	if statusPageUrl != "" {
+		statusPageUrl = s.canonicalStatusPageUrl(statusPageUrl)
+		if _, found := s.getStatusPageFromCache(statusPageUrl); !found {
+			respondWithStatusPageNotFound(context)
+			return
+		}
+	}
+	// An id from before the events were ordered by transaction can't be resumed from, the stream starts from now
+	var lastEventID api.ChangeEventCursor
+	if lastEventIDStr := context.GetHeader("Last-Event-ID"); lastEventIDStr != "" {
+		var err error
+		lastEventID, _, err = parseChangeEventCursor(lastEventIDStr)
+		if err != nil {
+			respondWithError(context, api.ErrorCodeInvalidParameter, "Last-Event-ID must be the id of an event of the stream", map[string]string{"parameter": "Last-Event-ID"})
+			return
+		}
+	}
+
+	// Subscribe before replaying so no change falls between the two, the duplicates are skipped by id
+	events := s.incidentStream.subscribe()
+	defer s.incidentStream.unsubscribe(events)
+	var replay []api.ChangeEvent
+	if !lastEventID.IsZero() {
+		var err error
+		replay, err = s.dbClient.GetChangeEventsSince(ctx, lastEventID, streamReplayLimit)
+		if err != nil {
+			s.logger.Error("failed to get change events to replay", zap.Error(err), zap.Stringer("since", lastEventID))
+			respondWithInternalError(context, "failed to get changes")
+			return
+		}
+	}
+
+	header := context.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	context.Status(http.StatusOK)
+	context.Writer.Flush()
+
+	send := func(event api.ChangeEvent) bool {
+		if !lastEventID.Before(event.Cursor()) || (statusPageUrl != "" && event.StatusPageUrl != statusPageUrl) {
+			return true
+		}
+		if statusPage, found := s.getStatusPageFromCache(event.StatusPageUrl); found && !includeStatusPage(context, statusPage) {
+			return true
+		}
+		data, err := json.Marshal(StreamEvent{Type: event.Type, StatusPageUrl: event.StatusPageUrl, Incident: api.Incident(event.Incident)})
+		if err != nil {
+			s.logger.Error("failed to marshal stream event", zap.Error(err))
+			return true
+		}
+		lastEventID = event.Cursor()
+		_, err = fmt.Fprintf(context.Writer, "id: %s\nevent: %s\ndata: %s\n\n", lastEventID, event.Type, data)
+		if err != nil {
+			return false
+		}
+		context.Writer.Flush()
+		return true
+	}
+	for _, event := range replay {
+		if !send(event) {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, open := <-events:
+			if !open || !send(event) {
+				return
+			}
+		case <-heartbeat.C:
+			_, err := fmt.Fprint(context.Writer, ": keepalive\n\n")
+			if err != nil {
+				return
+			}
+			context.Writer.Flush()
+		}
+	}
+}
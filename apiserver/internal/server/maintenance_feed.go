@@ -0,0 +1,161 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maintenanceFeedLookback is how long the maintenances stay in the calendar after their window, calendars drop the
+// events that are no longer in the feed
+const maintenanceFeedLookback = 30 * 24 * time.Hour
+
+// maintenanceFeed is a handler for the /feeds/maintenance.ics endpoint, which is served from the root like the Atom feeds.
+// It returns the scheduled maintenances as an iCalendar feed that calendars can subscribe to, the upcoming ones and the
+// ones of the last 30 days. The tag parameter keeps the status pages with one of its comma separated tags, and the
+// statusPageUrl parameter, which can be repeated, keeps the given status pages
+func (s *Server) maintenanceFeed(context *gin.Context) {
+	ctx := context.Request.Context()
+	matchesTag, ok := s.tagFilter(context)
+	if !ok {
+		return
+	}
+	var vendors map[string]bool
+	for _, statusPageUrl := range context.QueryArray("statusPageUrl") {
+		statusPageUrl = s.canonicalStatusPageUrl(statusPageUrl)
+		if _, found := s.getStatusPageFromCache(statusPageUrl); !found {
+			respondWithStatusPageNotFound(context)
+			return
+		}
+		if vendors == nil {
+			vendors = map[string]bool{}
+		}
+		vendors[statusPageUrl] = true
+	}
+
+	maintenances, err := s.dbClient.GetUpcomingMaintenances(ctx, time.Now().Add(-maintenanceFeedLookback))
+	if err != nil {
+		s.logger.Error("failed to get upcoming maintenances", zap.Error(err))
+		respondWithInternalError(context, "failed to get upcoming maintenances")
+		return
+	}
+	hash := sha256.New()
+	hash.Write([]byte(context.Request.URL.RawQuery))
+	var events []string
+	for _, maintenance := range maintenances {
+		statusPage, found := s.getStatusPageFromCache(maintenance.StatusPageUrl)
+		if !found || !includeStatusPage(context, statusPage) || !matchesTag(statusPage.URL) || (vendors != nil && !vendors[statusPage.URL]) {
+			continue
+		}
+		event := maintenanceEvent(maintenance, statusPage.Name)
+		for _, line := range event {
+			hash.Write([]byte(line + "\n"))
+		}
+		events = append(events, event...)
+	}
+	if s.notModified(context, `W/"`+hex.EncodeToString(hash.Sum(nil)[:16])+`"`) {
+		return
+	}
+
+	var calendar strings.Builder
+	writeICalLine(&calendar, "BEGIN:VCALENDAR")
+	writeICalLine(&calendar, "VERSION:2.0")
+	writeICalLine(&calendar, "PRODID:-//Statusphere//Maintenances//EN")
+	writeICalLine(&calendar, "CALSCALE:GREGORIAN")
+	writeICalLine(&calendar, "METHOD:PUBLISH")
+	writeICalLine(&calendar, "X-WR-CALNAME:Vendor maintenances")
+	writeICalLine(&calendar, "REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+	for _, line := range events {
+		writeICalLine(&calendar, line)
+	}
+	writeICalLine(&calendar, "END:VCALENDAR")
+	context.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(calendar.String()))
+}
+
+// maintenanceEvent is the VEVENT of the maintenance, a line per property before they are folded
+// The event has no end if the status page doesn't publish the end of the window and the maintenance isn't completed
+func maintenanceEvent(maintenance api.Maintenance, statusPageName string) []string {
+	uid := sha256.Sum256([]byte(maintenance.DeepLink))
+	latest := latestMaintenanceUpdate(maintenance)
+	// The stamp is when the maintenance last changed rather than now, so that the feed only changes with the maintenances
+	stamp := maintenance.ScheduledStart
+	if latest != nil {
+		stamp = latest.Time
+	}
+	lines := []string{
+		"BEGIN:VEVENT",
+		"UID:" + hex.EncodeToString(uid[:16]) + "@statusphere",
+		"DTSTAMP:" + icalTime(stamp),
+		"DTSTART:" + icalTime(maintenance.ScheduledStart),
+	}
+	if maintenance.ScheduledEnd != nil && maintenance.ScheduledEnd.After(maintenance.ScheduledStart) {
+		lines = append(lines, "DTEND:"+icalTime(*maintenance.ScheduledEnd))
+	} else if maintenance.EndTime != nil && maintenance.EndTime.After(maintenance.ScheduledStart) {
+		lines = append(lines, "DTEND:"+icalTime(*maintenance.EndTime))
+	}
+	lines = append(lines,
+		"SUMMARY:"+icalEscape(statusPageName+": "+maintenance.Title),
+		"DESCRIPTION:"+icalEscape(maintenanceDescription(maintenance, latest)),
+		"URL:"+maintenance.DeepLink,
+		"CATEGORIES:"+icalEscape(statusPageName),
+		"STATUS:CONFIRMED",
+		"TRANSP:TRANSPARENT",
+	)
+	// The sequence tells calendars that the event changed, each update of the maintenance is a revision
+	return append(lines, "SEQUENCE:"+strconv.Itoa(len(maintenance.Updates)), "END:VEVENT")
+}
+
+// latestMaintenanceUpdate returns the most recent update of the maintenance, nil if it has none
+func latestMaintenanceUpdate(maintenance api.Maintenance) *api.IncidentUpdate {
+	var latest *api.IncidentUpdate
+	for i := range maintenance.Updates {
+		if latest == nil || maintenance.Updates[i].Time.After(latest.Time) {
+			latest = &maintenance.Updates[i]
+		}
+	}
+	return latest
+}
+
+// maintenanceDescription is the state, components and latest update of the maintenance
+func maintenanceDescription(maintenance api.Maintenance, latest *api.IncidentUpdate) string {
+	description := "State: " + string(maintenance.State)
+	if len(maintenance.Components) > 0 {
+		description += "\nComponents: " + strings.Join(maintenance.Components, ", ")
+	}
+	if latest != nil && latest.Body != "" {
+		description += "\n\n" + latest.Body
+	}
+	return description + "\n\n" + maintenance.DeepLink
+}
+
+func icalTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// icalEscape escapes a text value, see RFC 5545 3.3.11
+func icalEscape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(text)
+}
+
+// writeICalLine writes the content line folded at 75 octets without splitting a character, see RFC 5545 3.1
+func writeICalLine(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// The space that starts a continuation line counts towards its length
+		limit = 74
+	}
+	b.WriteString(line + "\r\n")
+}
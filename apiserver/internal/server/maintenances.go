@@ -0,0 +1,57 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+type MaintenancesResponse struct {
+	Data []api.Maintenance `json:"data"`
+	Page
+}
+
+// maintenances is a handler for the /maintenances endpoint.
+// It has a required query parameter of statusPageUrl and returns the scheduled maintenances of the status page, latest first
+func (s *Server) maintenances(context *gin.Context) {
+	ctx := context.Request.Context()
+	page, ok := parsePageRequest(context, 0, timeKey, textKey)
+	if !ok {
+		return
+	}
+	statusPageUrl := s.canonicalStatusPageUrl(context.Query("statusPageUrl"))
+	if statusPageUrl == "" {
+		respondWithMissingParameter(context, "statusPageUrl", "statusPageUrl is required")
+		return
+	}
+
+	if _, found := s.statusPageCache.Get(statusPageUrl); !found {
+		respondWithStatusPageNotFound(context)
+		return
+	}
+
+	maintenances, err := s.dbClient.GetMaintenancesPage(ctx, statusPageUrl, page.query())
+	if err != nil {
+		s.logger.Error("failed to get maintenances", zap.Error(err))
+		respondWithInternalError(context, "failed to get maintenances")
+		return
+	}
+	total := int64(len(maintenances))
+	if page.paginated() {
+		total, err = s.dbClient.CountMaintenances(ctx, statusPageUrl)
+		if err != nil {
+			s.logger.Error("failed to count maintenances", zap.Error(err))
+			respondWithInternalError(context, "failed to get maintenances")
+			return
+		}
+	}
+	if maintenances == nil {
+		maintenances = []api.Maintenance{}
+	}
+	maintenances, envelope := pageOf(maintenances, page, int(total), func(maintenance api.Maintenance) []string {
+		return []string{maintenance.ScheduledStart.UTC().Format(time.RFC3339Nano), maintenance.DeepLink}
+	})
+	context.JSON(http.StatusOK, MaintenancesResponse{Data: maintenances, Page: envelope})
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"strconv"
+	"time"
+)
+
+// requestDuration is how long each request took, by route rather than path so that the status page urls in the paths
+// don't make a series each
+var requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "statusphere_api_request_duration_seconds",
+	Help:    "Duration of the api requests by method, route and status code.",
+	Buckets: metrics.DurationBuckets,
+}, []string{"method", "route", "code"})
+
+// recordRequestMetrics observes the duration and status code of each request
+func recordRequestMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		requestDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,426 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/graphql"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// endpoint is a route of the api, the routes are registered and the OpenAPI document is generated from the same definitions
+type endpoint struct {
+	method  string
+	path    string
+	summary string
+	params  []parameter
+	// body is the type of the request body, nil if the endpoint doesn't take one
+	body interface{}
+	// response is the type of the body of a successful response
+	response interface{}
+	// eventStream is the type of the data of the server-sent events, if the endpoint streams rather than responds
+	eventStream interface{}
+	// admin endpoints require the admin token
+	admin bool
+	// apiKey endpoints require an api key even if the other endpoints don't
+	apiKey bool
+	// public endpoints don't require an api key even if the other endpoints do, e.g. the widget that is embedded in pages
+	public bool
+	// html endpoints respond with html unless they are asked for json, response is the type of the json
+	html bool
+	// feed endpoints respond with an Atom or iCalendar feed
+	feed bool
+	// root endpoints are served from the root rather than under /api/v1 and without the noindex header, they are the
+	// pages that other sites link to or embed
+	root    bool
+	handler gin.HandlerFunc
+}
+
+// prefix is the path the route of the endpoint is under
+func (e endpoint) prefix() string {
+	if e.root {
+		return ""
+	}
+	return "/api/v1"
+}
+
+type parameter struct {
+	name        string
+	description string
+	// kind is the OpenAPI type of the parameter, string if empty
+	kind     string
+	format   string
+	required bool
+}
+
+var (
+	statusPageUrlParam  = parameter{name: "statusPageUrl", description: "Url of the status page", required: true}
+	limitParam          = parameter{name: "limit", description: "Maximum number of items to return, every item if it isn't set unless the endpoint has a default", kind: "integer"}
+	cursorParam         = parameter{name: "cursor", description: "The next_cursor of the previous page"}
+	subscriptionIdParam = parameter{name: "id", description: "Id of the subscription", required: true}
+	tagParam            = parameter{name: "tag", description: "Comma separated tags, only match the status pages with one of them"}
+)
+
+// endpoints returns every route of the api, under /api/v1 unless it is a root endpoint
+func (s *Server) endpoints() []endpoint {
+	return []endpoint{
+		{method: http.MethodGet, path: "/incidents", summary: "Get the incidents of a status page", handler: s.incidents,
+			params: []parameter{
+				statusPageUrlParam, limitParam, cursorParam,
+				{name: "impact", description: "Comma separated impacts to match, e.g. major,critical"},
+				{name: "component", description: "Only match the incidents that affect this component"},
+				{name: "state", description: "open or resolved"},
+				{name: "from", description: "Only match the incidents that started at or after this time", format: "date-time"},
+				{name: "to", description: "Only match the incidents that started before this time", format: "date-time"},
+				{name: "sort", description: "startTime (the default) or duration"},
+				{name: "order", description: "desc (the default) or asc"},
+			}, response: IncidentsResponse{}},
+		{method: http.MethodGet, path: "/incidents/query", summary: "Query the incidents of every status page with a filter expression", handler: s.incidentsQuery,
+			params: []parameter{{name: "filter", description: "Filter expression, e.g. impact = 'major'", required: true}, limitParam, cursorParam}, response: IncidentsQueryResponse{}},
+		{method: http.MethodGet, path: "/incidents/correlated", summary: "Get the status pages with incidents open at the same time as an incident or window", handler: s.incidentsCorrelated,
+			params: []parameter{
+				{name: "incident", description: "Deep link of the incident whose span is the window"},
+				{name: "from", description: "Start of the window, required without incident", format: "date-time"},
+				{name: "to", description: "End of the window, defaults to now", format: "date-time"},
+				{name: "statusPageUrl", description: "Leave out this status page, defaults to the status page of the incident"},
+			}, response: CorrelatedIncidentsResponse{}},
+		{method: http.MethodGet, path: "/incidents/stream", summary: "Stream the incident changes as server-sent events", handler: s.incidentsStream,
+			params: []parameter{{name: "statusPageUrl", description: "Only stream the changes of this status page"}}, eventStream: StreamEvent{}},
+		{method: http.MethodGet, path: "/maintenances", summary: "Get the maintenances of a status page", handler: s.maintenances,
+			params: []parameter{statusPageUrlParam, limitParam, cursorParam}, response: MaintenancesResponse{}},
+		{method: http.MethodGet, path: "/components", summary: "Get the components of a status page", handler: s.components,
+			params: []parameter{statusPageUrlParam, limitParam, cursorParam}, response: ComponentsResponse{}},
+		{method: http.MethodGet, path: "/statusSnapshots", summary: "Get the status history of a status page", handler: s.statusSnapshots,
+			params: []parameter{
+				statusPageUrlParam,
+				{name: "at", description: "Return the snapshot in effect at this time", format: "date-time"},
+				{name: "from", description: "Start of the range of snapshots to return", format: "date-time"},
+				{name: "to", description: "End of the range of snapshots to return", format: "date-time"},
+			}, response: StatusSnapshotsResponse{}},
+		{method: http.MethodGet, path: "/currentStatus", summary: "Get the current status of a status page", handler: s.currentStatus,
+			params: []parameter{statusPageUrlParam}, response: CurrentStatusResponse{}},
+		{method: http.MethodGet, path: "/embed/*statusPageUrl", summary: "Get a widget of the current status and recent incidents of a status page", handler: s.embed,
+			params: []parameter{
+				{name: "statusPageUrl", description: "Url of the status page, escaped or as it is"},
+				{name: "format", description: "html (the default) or json"},
+			}, response: EmbedResponse{}, html: true, public: true, root: true},
+		{method: http.MethodGet, path: "/feeds/*feed", summary: "Get an Atom feed of the incidents of a status page or tag, or an iCalendar feed of the maintenances", handler: s.feed,
+			params: []parameter{
+				{name: "feed", description: "{statusPageUrl}.atom, the url escaped or as it is, tag/{tag}.atom or maintenance.ics"},
+				{name: "tag", description: "Comma separated tags, only put the maintenances of the status pages with one of them in maintenance.ics"},
+				{name: "statusPageUrl", description: "Only put the maintenances of this status page in maintenance.ics, can be repeated"},
+			}, feed: true, public: true, root: true},
+		{method: http.MethodGet, path: "/uptime", summary: "Get the availability of a status page and its components", handler: s.uptime,
+			params: []parameter{statusPageUrlParam, {name: "period", description: "How far back to compute the availability, e.g. 90d (the default) or 12h, at most 365d"}}, response: UptimeResponse{}},
+		{method: http.MethodGet, path: "/summary", summary: "Get the current status and open incidents of every status page", handler: s.summary,
+			params: []parameter{tagParam}, response: SummaryResponse{}},
+		{method: http.MethodGet, path: "/tags", summary: "Get every tag with the status of its status pages", handler: s.tags,
+			response: TagsResponse{}},
+		{method: http.MethodGet, path: "/statusPage", summary: "Get a status page by url or name", handler: s.statusPage,
+			params: []parameter{
+				{name: "statusPageUrl", description: "Url of the status page, either it or statusPageName is required"},
+				{name: "statusPageName", description: "Name of the status page, case insensitive"},
+			}, response: StatusPageResponse{}},
+		{method: http.MethodGet, path: "/statusPages", summary: "List the status pages", handler: s.statusPages,
+			params: []parameter{tagParam, limitParam, cursorParam}, response: StatusPagesResponse{}},
+		{method: http.MethodGet, path: "/search", summary: "Search the status pages by name and url and the incidents by title and description", handler: s.search,
+			params: []parameter{
+				{name: "q", description: "Text to search for, in the web search syntax for the incidents", required: true},
+				{name: "limit", description: "Maximum number of status pages and of incidents to return, 10 by default and at most 50", kind: "integer"},
+			}, response: SearchResponse{}},
+		{method: http.MethodGet, path: "/statusPages/search", summary: "Search the status pages by name and url", handler: s.statusPageSearch,
+			params: []parameter{{name: "query", description: "Text to search for", required: true}}, response: StatusPageSearchResponse{}},
+		{method: http.MethodGet, path: "/statusPages/count", summary: "Count the status pages", handler: s.statusPageCount,
+			response: StatusPageCountResponse{}},
+		{method: http.MethodPost, path: "/statusPages/bulk", summary: "Register status pages in bulk, detecting the provider of the new ones", handler: s.bulkStatusPages,
+			body: BulkStatusPagesRequest{}, response: BulkStatusPagesResponse{}, admin: true},
+		{method: http.MethodGet, path: "/operator/summary", summary: "Get the health of the scraping pipeline", handler: s.operatorSummary,
+			response: OperatorSummaryResponse{}, admin: true},
+		{method: http.MethodGet, path: "/providers/features", summary: "Get what each provider can scrape", handler: s.providerFeatures,
+			response: ProviderFeaturesResponse{}},
+		{method: http.MethodGet, path: "/sync", summary: "Get the incident changes since a cursor", handler: s.sync,
+			params: []parameter{{name: "since", description: "Cursor returned by the previous sync, empty for the first sync"}}, response: SyncResponse{}},
+		{method: http.MethodPut, path: "/statusPage/scrapeConfig", summary: "Replace the scrape config of a status page", handler: s.updateScrapeConfig,
+			params: []parameter{statusPageUrlParam}, body: api.ScrapeConfig{}, response: ScrapeConfigResponse{}, admin: true},
+		{method: http.MethodPut, path: "/statusPage/tags", summary: "Replace the shared tags of a status page", handler: s.setStatusPageTags,
+			params: []parameter{statusPageUrlParam}, body: SetStatusPageTagsRequest{}, response: StatusPageTagsResponse{}, admin: true},
+		{method: http.MethodPost, path: "/statusPage", summary: "Add a status page, detecting its provider unless it is given", handler: s.createStatusPage,
+			body: CreateStatusPageRequest{}, response: StatusPageResponse{}, admin: true},
+		{method: http.MethodPost, path: "/statusPage/pause", summary: "Stop scraping a status page until it is resumed", handler: s.pauseStatusPage,
+			params: []parameter{statusPageUrlParam}, response: StatusPageResponse{}, admin: true},
+		{method: http.MethodPost, path: "/statusPage/resume", summary: "Resume scraping a paused status page", handler: s.resumeStatusPage,
+			params: []parameter{statusPageUrlParam}, response: StatusPageResponse{}, admin: true},
+		{method: http.MethodDelete, path: "/statusPage", summary: "Delete a status page with its incidents and history", handler: s.deleteStatusPage,
+			params: []parameter{statusPageUrlParam}, response: StatusPageResponse{}, admin: true},
+		{method: http.MethodPost, path: "/apiKeys", summary: "Issue an api key, the key is only returned once", handler: s.createAPIKey,
+			body: CreateAPIKeyRequest{}, response: CreateAPIKeyResponse{}, admin: true},
+		{method: http.MethodGet, path: "/apiKeys", summary: "List the api keys", handler: s.apiKeys,
+			response: APIKeysResponse{}, admin: true},
+		{method: http.MethodDelete, path: "/apiKeys", summary: "Revoke an api key", handler: s.revokeAPIKey,
+			params: []parameter{{name: "id", description: "Id of the api key", required: true}}, response: APIKeyResponse{}, admin: true},
+		{method: http.MethodPost, path: "/tenants", summary: "Create a tenant", handler: s.createTenant,
+			body: CreateTenantRequest{}, response: TenantResponse{}, admin: true},
+		{method: http.MethodGet, path: "/tenants", summary: "List the tenants", handler: s.tenants,
+			response: TenantsResponse{}, admin: true},
+		{method: http.MethodDelete, path: "/tenants", summary: "Delete a tenant and revoke its api keys", handler: s.deleteTenant,
+			params: []parameter{{name: "id", description: "Id of the tenant", required: true}}, response: TenantResponse{}, admin: true},
+		{method: http.MethodGet, path: "/tenant/statusPages", summary: "List the status pages the tenant of the api key tracks", handler: s.tenantStatusPages,
+			response: TenantStatusPagesResponse{}, apiKey: true},
+		{method: http.MethodPost, path: "/tenant/statusPages", summary: "Track status pages, the unknown ones are added", handler: s.trackStatusPages,
+			body: TrackStatusPagesRequest{}, response: TenantStatusPagesResponse{}, apiKey: true},
+		{method: http.MethodDelete, path: "/tenant/statusPages", summary: "Stop tracking a status page", handler: s.untrackStatusPage,
+			params: []parameter{statusPageUrlParam}, apiKey: true},
+		{method: http.MethodPut, path: "/tenant/tags", summary: "Replace the tags the tenant of the api key gave a status page", handler: tenantOnly(s.setStatusPageTags),
+			params: []parameter{statusPageUrlParam}, body: SetStatusPageTagsRequest{}, response: StatusPageTagsResponse{}, apiKey: true},
+		{method: http.MethodPost, path: "/tenant/apiKeys", summary: "Issue an api key of the tenant of the api key", handler: tenantOnly(s.createAPIKey),
+			body: CreateAPIKeyRequest{}, response: CreateAPIKeyResponse{}, apiKey: true},
+		{method: http.MethodGet, path: "/tenant/apiKeys", summary: "List the api keys of the tenant of the api key", handler: tenantOnly(s.apiKeys),
+			response: APIKeysResponse{}, apiKey: true},
+		{method: http.MethodDelete, path: "/tenant/apiKeys", summary: "Revoke an api key of the tenant of the api key", handler: tenantOnly(s.revokeAPIKey),
+			params: []parameter{{name: "id", description: "Id of the api key", required: true}}, response: APIKeyResponse{}, apiKey: true},
+		{method: http.MethodPost, path: "/subscriptions", summary: "Subscribe a webhook to incident changes, the signing secret is only returned once", handler: s.createSubscription,
+			body: CreateSubscriptionRequest{}, response: CreateSubscriptionResponse{}, apiKey: true},
+		{method: http.MethodGet, path: "/subscriptions", summary: "List the subscriptions of the api key", handler: s.subscriptions,
+			response: SubscriptionsResponse{}, apiKey: true},
+		{method: http.MethodDelete, path: "/subscriptions", summary: "Delete a subscription", handler: s.deleteSubscription,
+			params: []parameter{subscriptionIdParam}, response: SubscriptionResponse{}, apiKey: true},
+		{method: http.MethodGet, path: "/subscriptions/deliveries", summary: "Get the latest deliveries of a subscription", handler: s.subscriptionDeliveries,
+			params: []parameter{subscriptionIdParam, {name: "limit", description: "Maximum number of deliveries to return", kind: "integer"}}, response: WebhookDeliveriesResponse{}, apiKey: true},
+		{method: http.MethodPost, path: "/graphql", summary: "Run a GraphQL query", handler: s.graphQL,
+			body: graphql.Request{}, response: graphql.Response{}},
+		{method: http.MethodGet, path: "/graphql", summary: "Run a GraphQL query given in the query parameters", handler: s.graphQL,
+			params: []parameter{
+				{name: "query", description: "The GraphQL query", required: true},
+				{name: "variables", description: "The variables of the query as a json object"},
+				{name: "operationName", description: "The operation to run if the query has more than one"},
+			}, response: graphql.Response{}},
+		{method: http.MethodGet, path: "/graphql/schema", summary: "Get the schema of the GraphQL api as SDL", handler: s.graphQLSDL},
+		{method: http.MethodGet, path: "/openapi.json", summary: "Get this OpenAPI document", handler: s.openAPI},
+	}
+}
+
+// openAPI is a handler for the /openapi.json endpoint, it returns the OpenAPI document of the api
+func (s *Server) openAPI(context *gin.Context) {
+	context.JSON(http.StatusOK, s.openAPIDocument)
+}
+
+// enums are the values of the string types that only take a fixed set of values
+var enums = map[reflect.Type][]string{
+	reflect.TypeOf(Status("")):               {string(StatusUp), string(StatusDegraded), string(StatusUnknown)},
+	reflect.TypeOf(api.DeliveryStatus("")):   {string(api.DeliveryStatusPending), string(api.DeliveryStatusDelivered), string(api.DeliveryStatusFailed)},
+	reflect.TypeOf(BulkStatusPageResult("")): {string(BulkStatusPageCreated), string(BulkStatusPageUpdated), string(BulkStatusPageInvalid)},
+}
+
+// newOpenAPIDocument generates the OpenAPI 3 document of the endpoints, the api key is optional unless requireAPIKey
+// The schemas of the request and response bodies are derived from their types and json tags
+func newOpenAPIDocument(endpoints []endpoint, requireAPIKey bool) map[string]interface{} {
+	schemas := map[string]interface{}{}
+	errorSchema := schemaOf(reflect.TypeOf(api.ErrorResponse{}), schemas)
+	problemSchema := schemaOf(reflect.TypeOf(api.Problem{}), schemas)
+	paths := map[string]map[string]interface{}{}
+	for _, e := range endpoints {
+		operation := map[string]interface{}{
+			"summary":     e.summary,
+			"operationId": operationId(e),
+		}
+		var params []interface{}
+		for _, p := range e.params {
+			schema := map[string]interface{}{"type": "string"}
+			if p.kind != "" {
+				schema["type"] = p.kind
+			}
+			if p.format != "" {
+				schema["format"] = p.format
+			}
+			in := "query"
+			if strings.Contains(e.path, ":"+p.name) || strings.Contains(e.path, "*"+p.name) {
+				in = "path"
+			}
+			params = append(params, map[string]interface{}{
+				"name": p.name, "in": in, "description": p.description, "required": p.required || in == "path", "schema": schema,
+			})
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+		if e.body != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": schemaOf(reflect.TypeOf(e.body), schemas)}},
+			}
+		}
+		success := map[string]interface{}{"description": "OK"}
+		if e.response != nil {
+			success["content"] = map[string]interface{}{"application/json": map[string]interface{}{"schema": schemaOf(reflect.TypeOf(e.response), schemas)}}
+		}
+		if e.html {
+			success["content"].(map[string]interface{})["text/html"] = map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}
+		}
+		if e.feed {
+			success["content"] = map[string]interface{}{
+				"application/atom+xml": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}},
+				"text/calendar":        map[string]interface{}{"schema": map[string]interface{}{"type": "string"}},
+			}
+		}
+		if e.eventStream != nil {
+			// OpenAPI 3.0 can't describe the events, the schema is of the data of each event
+			success["description"] = "A stream of server-sent events, the data of each event is a " + reflect.TypeOf(e.eventStream).Name()
+			success["content"] = map[string]interface{}{"text/event-stream": map[string]interface{}{"schema": schemaOf(reflect.TypeOf(e.eventStream), schemas)}}
+		}
+		operation["responses"] = map[string]interface{}{
+			"200": success,
+			"default": map[string]interface{}{
+				"description": "Error, see the error codes in the README",
+				"content": map[string]interface{}{
+					"application/json":     map[string]interface{}{"schema": errorSchema},
+					api.ProblemContentType: map[string]interface{}{"schema": problemSchema},
+				},
+			},
+		}
+		if e.admin {
+			operation["security"] = []interface{}{map[string]interface{}{"adminToken": []string{}}}
+		} else if (requireAPIKey && !e.public) || e.apiKey {
+			operation["security"] = []interface{}{map[string]interface{}{"apiKey": []string{}}}
+		} else {
+			operation["security"] = []interface{}{map[string]interface{}{}, map[string]interface{}{"apiKey": []string{}}}
+		}
+		path := e.prefix() + openAPIPath(e.path)
+		if paths[path] == nil {
+			paths[path] = map[string]interface{}{}
+		}
+		paths[path][strings.ToLower(e.method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "Statusphere API",
+			"version": "v1",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"adminToken": map[string]interface{}{"type": "http", "scheme": "bearer"},
+				"apiKey":     map[string]interface{}{"type": "apiKey", "in": "header", "name": apiKeyHeader},
+			},
+		},
+	}
+}
+
+// openAPIPath writes the path parameters of a gin path, :name and *name, as {name}
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// operationId is the name that generated clients give the method of the endpoint, e.g. getStatusPagesSearch
+func operationId(e endpoint) string {
+	id := strings.ToLower(e.method)
+	for _, segment := range strings.Split(strings.Trim(e.path, "/"), "/") {
+		segment = strings.TrimLeft(strings.TrimSuffix(segment, ".json"), ":*")
+		if segment != "" {
+			id += strings.ToUpper(segment[:1]) + segment[1:]
+		}
+	}
+	return id
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// schemaOf returns the schema of the type, named struct types are added to schemas and referenced
+func schemaOf(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	if t.Kind() == reflect.Pointer {
+		schema := schemaOf(t.Elem(), schemas)
+		if _, isRef := schema["$ref"]; isRef {
+			return map[string]interface{}{"allOf": []interface{}{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	}
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == rawMessageType || (t.Implements(jsonMarshalerType) && t.Kind() != reflect.Struct):
+		// Arbitrary json
+		return map[string]interface{}{}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		schema := map[string]interface{}{"type": "string"}
+		if values, found := enums[t]; found {
+			schema["enum"] = values
+		}
+		return schema
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaOf(t.Elem(), schemas)}
+	case reflect.Struct:
+		name := t.Name()
+		if name == "" {
+			return structSchema(t, schemas)
+		}
+		ref := map[string]interface{}{"$ref": "#/components/schemas/" + name}
+		if _, found := schemas[name]; !found {
+			// Set before the fields are walked so a type that refers to itself terminates
+			schemas[name] = map[string]interface{}{}
+			schemas[name] = structSchema(t, schemas)
+		}
+		return ref
+	}
+	return map[string]interface{}{}
+}
+
+func structSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+	addStructProperties(t, schemas, properties, &required)
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// addStructProperties adds the fields of the struct as they are marshalled to json, the fields of embedded structs are promoted
+func addStructProperties(t reflect.Type, schemas map[string]interface{}, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			addStructProperties(field.Type, schemas, properties, required)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = schemaOf(field.Type, schemas)
+		if !strings.Contains(options, "omitempty") && field.Type.Kind() != reflect.Pointer {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package server
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// IngestionLagPercentiles are in seconds
+type IngestionLagPercentiles struct {
+	P50 float64 `json:"p50Seconds"`
+	P90 float64 `json:"p90Seconds"`
+	P99 float64 `json:"p99Seconds"`
+	Max float64 `json:"maxSeconds"`
+}
+
+type StorageSummary struct {
+	IncidentCount  int64 `json:"incidentCount"`
+	TotalSizeBytes int64 `json:"totalSizeBytes"`
+	// Growth is how much the database grew over the period, nil until its size has been recorded
+	Growth *StorageGrowth `json:"growth"`
+}
+
+// StorageGrowth is the difference between the current size of the database and its size at Since, which is later than
+// the start of the period if the size hasn't been recorded for that long
+type StorageGrowth struct {
+	Since         time.Time `json:"since"`
+	SizeBytes     int64     `json:"sizeBytes"`
+	IncidentCount int64     `json:"incidentCount"`
+}
+
+// FailingStatusPage is a status page whose last scrape failed
+type FailingStatusPage struct {
+	URL                     string    `json:"url"`
+	LastError               string    `json:"lastError"`
+	ConsecutiveFailureCount int       `json:"consecutiveFailureCount"`
+	LastSuccessfulScrapeAt  time.Time `json:"lastSuccessfulScrapeAt"`
+}
+
+// NotificationDeliverySummary counts the notifications of the period by outcome across every notifier
+type NotificationDeliverySummary struct {
+	NotifierDeliveries
+	// SuccessRate is the fraction of the finished deliveries that were delivered, 1 if none finished
+	SuccessRate float64 `json:"successRate"`
+	// Notifiers are the counts of each notifier, e.g. webhooks, notifier slack or pager pagerduty
+	Notifiers []NotifierDeliveries `json:"notifiers"`
+}
+
+// NotifierDeliveries counts the notifications of a notifier by outcome, only the webhook deliveries are ever pending
+type NotifierDeliveries struct {
+	Notifier  string `json:"notifier,omitempty"`
+	Delivered int64  `json:"delivered"`
+	Failed    int64  `json:"failed"`
+	Pending   int64  `json:"pending"`
+}
+
+type OperatorSummaryResponse struct {
+	StatusPagesTracked int `json:"statusPagesTracked"`
+	// StatusPagesIndexed are the status pages whose history has been scraped
+	StatusPagesIndexed int `json:"statusPagesIndexed"`
+	// ScrapeSuccessRate is the fraction of the scraped status pages whose last scrape succeeded
+	ScrapeSuccessRate float64 `json:"scrapeSuccessRate"`
+	// NotificationDeliveries are the notifications of the last week
+	NotificationDeliveries NotificationDeliverySummary `json:"notificationDeliveries"`
+	// IngestionLag is the time since each status page was last scraped
+	IngestionLag IngestionLagPercentiles `json:"ingestionLag"`
+	Storage      StorageSummary          `json:"storage"`
+	// FailingStatusPages are the status pages whose last scrape failed, the longest failing first
+	FailingStatusPages []FailingStatusPage `json:"failingStatusPages"`
+	// Database holds the most recently collected table stats, nil if they haven't been collected yet
+	Database *db.DbStats `json:"database"`
+}
+
+const operatorSummaryPeriod = 7 * 24 * time.Hour
+
+// operatorSummary is a handler for the /operator/summary endpoint, it requires the admin token.
+// It returns a rollup of the health of the whole platform, intended for a weekly ops review.
+func (s *Server) operatorSummary(context *gin.Context) {
+	ctx := context.Request.Context()
+
+	var statusPages []api.StatusPage
+	for _, statusPage := range s.statusPageCache.Items() {
+		statusPages = append(statusPages, statusPage.Object.(api.StatusPage))
+	}
+
+	response := OperatorSummaryResponse{StatusPagesTracked: len(statusPages)}
+	var lags []time.Duration
+	scraped, succeeded := 0, 0
+	for _, statusPage := range statusPages {
+		if statusPage.IsIndexed {
+			response.StatusPagesIndexed++
+		}
+		if !statusPage.LastCurrentlyScraped.IsZero() {
+			lags = append(lags, time.Since(statusPage.LastCurrentlyScraped))
+			scraped++
+			if statusPage.LastError == "" {
+				succeeded++
+			}
+		}
+		if statusPage.LastError != "" {
+			response.FailingStatusPages = append(response.FailingStatusPages, FailingStatusPage{
+				URL:                     statusPage.URL,
+				LastError:               statusPage.LastError,
+				ConsecutiveFailureCount: statusPage.ConsecutiveFailureCount,
+				LastSuccessfulScrapeAt:  statusPage.LastSuccessfulScrapeAt,
+			})
+		}
+	}
+	sort.Slice(response.FailingStatusPages, func(i, j int) bool {
+		return response.FailingStatusPages[i].ConsecutiveFailureCount > response.FailingStatusPages[j].ConsecutiveFailureCount
+	})
+	if scraped > 0 {
+		response.ScrapeSuccessRate = float64(succeeded) / float64(scraped)
+	}
+	response.IngestionLag = lagPercentiles(lags)
+
+	deliveries, err := s.getNotificationDeliverySummary(ctx, time.Now().Add(-operatorSummaryPeriod))
+	if err != nil {
+		s.logger.Error("failed to get notification deliveries", zap.Error(err))
+		respondWithInternalError(context, "failed to get notification deliveries")
+		return
+	}
+	response.NotificationDeliveries = deliveries
+
+	storage, err := s.getStorageSummary(ctx)
+	if err != nil {
+		s.logger.Error("failed to get storage summary", zap.Error(err))
+		respondWithInternalError(context, "failed to get storage summary")
+		return
+	}
+	response.Storage = storage
+	if stats, found := s.dbStatsCache.Get(dbStatsCacheKey); found {
+		dbStats := stats.(db.DbStats)
+		response.Database = &dbStats
+	}
+
+	context.JSON(http.StatusOK, response)
+}
+
+// getNotificationDeliverySummary counts the webhook deliveries created since the given time and the notifications the
+// other notifiers sent since then
+func (s *Server) getNotificationDeliverySummary(ctx context.Context, since time.Time) (NotificationDeliverySummary, error) {
+	webhooks, err := s.dbClient.GetWebhookDeliveryCounts(ctx, since)
+	if err != nil {
+		return NotificationDeliverySummary{}, errors.Wrap(err, "failed to get webhook delivery counts")
+	}
+	outcomes, err := s.dbClient.GetNotificationOutcomeCounts(ctx, since)
+	if err != nil {
+		return NotificationDeliverySummary{}, errors.Wrap(err, "failed to get notification outcome counts")
+	}
+	notifiers := []NotifierDeliveries{{
+		Notifier:  "webhooks",
+		Delivered: webhooks[api.DeliveryStatusDelivered],
+		Failed:    webhooks[api.DeliveryStatusFailed],
+		Pending:   webhooks[api.DeliveryStatusPending],
+	}}
+	for _, outcome := range outcomes {
+		notifiers = append(notifiers, NotifierDeliveries{Notifier: outcome.Notifier, Delivered: outcome.Delivered, Failed: outcome.Failed})
+	}
+	summary := NotificationDeliverySummary{SuccessRate: 1, Notifiers: notifiers}
+	for _, notifier := range notifiers {
+		summary.Delivered += notifier.Delivered
+		summary.Failed += notifier.Failed
+		summary.Pending += notifier.Pending
+	}
+	if finished := summary.Delivered + summary.Failed; finished > 0 {
+		summary.SuccessRate = float64(summary.Delivered) / float64(finished)
+	}
+	return summary, nil
+}
+
+func (s *Server) getStorageSummary(ctx context.Context) (StorageSummary, error) {
+	incidentCount, err := s.dbClient.GetIncidentCountSince(ctx, time.Time{})
+	if err != nil {
+		return StorageSummary{}, errors.Wrap(err, "failed to get incident count")
+	}
+	totalSize, err := s.dbClient.GetTotalSizeBytes(ctx)
+	if err != nil {
+		return StorageSummary{}, errors.Wrap(err, "failed to get total size")
+	}
+	summary := StorageSummary{IncidentCount: incidentCount, TotalSizeBytes: totalSize}
+	snapshot, err := s.dbClient.GetDbSizeSnapshotAt(ctx, time.Now().Add(-operatorSummaryPeriod))
+	if err != nil {
+		return StorageSummary{}, errors.Wrap(err, "failed to get db size snapshot")
+	}
+	if snapshot != nil {
+		summary.Growth = &StorageGrowth{
+			Since:         snapshot.CollectedAt,
+			SizeBytes:     totalSize - snapshot.TotalSizeBytes,
+			IncidentCount: incidentCount - snapshot.IncidentCount,
+		}
+	}
+	return summary, nil
+}
+
+// lagPercentiles computes the p50, p90, p99 and max of the given lags in seconds
+func lagPercentiles(lags []time.Duration) IngestionLagPercentiles {
+	if len(lags) == 0 {
+		return IngestionLagPercentiles{}
+	}
+	sort.Slice(lags, func(i, j int) bool {
+		return lags[i] < lags[j]
+	})
+	percentile := func(p float64) float64 {
+		return lags[int(p*float64(len(lags)-1))].Seconds()
+	}
+	return IngestionLagPercentiles{
+		P50: percentile(0.5),
+		P90: percentile(0.9),
+		P99: percentile(0.99),
+		Max: lags[len(lags)-1].Seconds(),
+	}
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/db"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// maxPageLimit bounds the items of a page of the list endpoints
+const maxPageLimit = 1000
+
+// Page is embedded in the responses of the list endpoints next to their data field, the items of the page
+type Page struct {
+	// NextCursor is sent as the cursor parameter to get the next page, it is null on the last page
+	NextCursor *string `json:"next_cursor"`
+	// Total is the number of items across every page
+	Total int `json:"total"`
+}
+
+// pageRequest is the page a list request asks for, a zero limit is every item after the key
+type pageRequest struct {
+	limit int
+	// after is the key of the last item of the previous page, nil for the first page, see db.Page
+	after []string
+}
+
+// keyPart validates a value of the key of a cursor
+type keyPart func(value string) bool
+
+func timeKey(value string) bool {
+	_, err := time.Parse(time.RFC3339Nano, value)
+	return err == nil
+}
+
+func integerKey(value string) bool {
+	_, err := strconv.ParseInt(value, 10, 64)
+	return err == nil
+}
+
+func textKey(value string) bool {
+	return true
+}
+
+// parsePageRequest parses the limit and cursor parameters, defaultLimit is used without a limit and key are the parts of
+// the key of the items of the list
+// It returns false if one is invalid, in which case the error has been written
+func parsePageRequest(context *gin.Context, defaultLimit int, key ...keyPart) (pageRequest, bool) {
+	page := pageRequest{limit: defaultLimit}
+	if limitStr := context.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			respondWithInvalidParameter(context, "limit", "limit must be a positive integer")
+			return page, false
+		}
+		page.limit = min(limit, maxPageLimit)
+	}
+	if cursor := context.Query("cursor"); cursor != "" {
+		after, ok := decodeKeyCursor(cursor, key)
+		if !ok {
+			respondWithInvalidParameter(context, "cursor", "cursor must be a next_cursor returned by a previous page")
+			return page, false
+		}
+		page.after = after
+	}
+	return page, true
+}
+
+// paginated returns true if the request asks for less than every item
+func (p pageRequest) paginated() bool {
+	return p.limit > 0 || p.after != nil
+}
+
+// query is the page to query, it has one more item than the limit to tell if there is a next page, see pageOf
+func (p pageRequest) query() db.Page {
+	page := db.Page{After: p.after}
+	if p.limit > 0 {
+		page.Limit = p.limit + 1
+	}
+	return page
+}
+
+// pageOf returns the items of the page out of the items that were queried for it, see pageRequest.query, and the
+// envelope of the page. key is the key of an item and total is the number of items across every page
+func pageOf[T any](items []T, page pageRequest, total int, key func(T) []string) ([]T, Page) {
+	envelope := Page{Total: total}
+	if page.limit > 0 && len(items) > page.limit {
+		items = items[:page.limit]
+		cursor := encodeKeyCursor(key(items[len(items)-1]))
+		envelope.NextCursor = &cursor
+	}
+	return items, envelope
+}
+
+// pageOfSorted returns the page of the items sorted by their key and its envelope, follows returns true if an item comes
+// after a key. It pages a list that is held in memory the same way as one that is queried
+func pageOfSorted[T any](items []T, page pageRequest, key func(T) []string, follows func(T, []string) bool) ([]T, Page) {
+	total := len(items)
+	if page.after != nil {
+		start := sort.Search(len(items), func(i int) bool {
+			return follows(items[i], page.after)
+		})
+		items = items[start:]
+	}
+	if page.limit > 0 {
+		items = items[:min(page.limit+1, len(items))]
+	}
+	return pageOf(items, page, total, key)
+}
+
+// The cursors are opaque to clients, they are the key of the last item of the page
+func encodeKeyCursor(key []string) string {
+	encoded, _ := json.Marshal(key)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+func decodeKeyCursor(cursor string, parts []keyPart) ([]string, bool) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, false
+	}
+	var key []string
+	err = json.Unmarshal(decoded, &key)
+	if err != nil || len(key) != len(parts) {
+		return nil, false
+	}
+	for i, part := range parts {
+		if !part(key[i]) {
+			return nil, false
+		}
+	}
+	return key, true
+}
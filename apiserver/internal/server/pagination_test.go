@@ -0,0 +1,58 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/api"
+)
+
+// TestPageOfSortedKeepsPosition checks that the incidents that start between two pages don't shift the second one
+func TestPageOfSortedKeepsPosition(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	incident := func(deepLink string, hoursAgo int) api.Incident {
+		return api.Incident{DeepLink: deepLink, StartTime: start.Add(-time.Duration(hoursAgo) * time.Hour)}
+	}
+	incidents := []api.Incident{incident("d", 1), incident("c", 2), incident("b", 2), incident("a", 3)}
+	sortIncidentsDescending(incidents)
+
+	first, envelope := pageOfSorted(incidents, pageRequest{limit: 2}, incidentKey, incidentFollows)
+	if len(first) != 2 || first[0].DeepLink != "d" || first[1].DeepLink != "c" {
+		t.Fatalf("got first page %v", first)
+	}
+	if envelope.NextCursor == nil || envelope.Total != 4 {
+		t.Fatalf("got envelope %+v", envelope)
+	}
+	after, ok := decodeKeyCursor(*envelope.NextCursor, []keyPart{timeKey, textKey})
+	if !ok {
+		t.Fatalf("failed to decode cursor %q", *envelope.NextCursor)
+	}
+
+	incidents = append([]api.Incident{incident("e", 0)}, incidents...)
+	second, envelope := pageOfSorted(incidents, pageRequest{limit: 2, after: after}, incidentKey, incidentFollows)
+	if len(second) != 2 || second[0].DeepLink != "b" || second[1].DeepLink != "a" {
+		t.Errorf("got second page %v", second)
+	}
+	if envelope.NextCursor != nil {
+		t.Errorf("got a next cursor after the last page")
+	}
+}
+
+func TestDecodeKeyCursor(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor string
+		valid  bool
+	}{
+		{name: "key", cursor: encodeKeyCursor([]string{"2024-03-01T12:00:00Z", "https://status.example.com/incidents/1"}), valid: true},
+		{name: "missing part", cursor: encodeKeyCursor([]string{"2024-03-01T12:00:00Z"})},
+		{name: "invalid time", cursor: encodeKeyCursor([]string{"yesterday", "https://status.example.com/incidents/1"})},
+		{name: "not base64", cursor: "not a cursor!"},
+	}
+	for _, test := range tests {
+		_, ok := decodeKeyCursor(test.cursor, []keyPart{timeKey, textKey})
+		if ok != test.valid {
+			t.Errorf("%s: got valid %v, want %v", test.name, ok, test.valid)
+		}
+	}
+}
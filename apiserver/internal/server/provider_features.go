@@ -0,0 +1,25 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type ProviderFeaturesResponse struct {
+	Providers []api.ProviderFeatures `json:"providers"`
+}
+
+// providerFeatures is a handler for the /providers/features endpoint.
+// It returns what data each scraper provider is able to extract
+func (s *Server) providerFeatures(context *gin.Context) {
+	ctx := context.Request.Context()
+	features, err := s.dbClient.GetProviderFeatures(ctx)
+	if err != nil {
+		s.logger.Error("failed to get provider features", zap.Error(err))
+		respondWithInternalError(context, "failed to get provider features")
+		return
+	}
+	context.JSON(http.StatusOK, ProviderFeaturesResponse{Providers: features})
+}
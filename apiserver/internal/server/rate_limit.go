@@ -0,0 +1,169 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/patrickmn/go-cache"
+	"go.uber.org/zap"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// apiKeyHeader is the header that clients send their api key in
+const apiKeyHeader = "X-API-Key"
+
+// apiKeyContextKey is the key of the authenticated api key in the gin context
+const apiKeyContextKey = "apiKey"
+
+// maxIdleBuckets is how many buckets the limiter keeps before it drops the ones that have refilled
+const maxIdleBuckets = 10000
+
+// bucket is a token bucket that holds up to a minute of requests and refills continuously
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// rateLimiter keeps a token bucket per api key, and per client ip for the requests without a key
+// The buckets are in memory, so each replica of the api server limits on its own
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{buckets: map[string]*bucket{}}
+}
+
+// limit is the state of a bucket after a request
+type limit struct {
+	allowed bool
+	// remaining is how many more requests the bucket allows right away
+	remaining int
+	// retryAfter is how long until the next token if the request wasn't allowed
+	retryAfter time.Duration
+	// reset is how long until the bucket is full again
+	reset time.Duration
+}
+
+// allow takes a token from the bucket of key, if the bucket is empty the request isn't allowed
+func (l *rateLimiter) allow(key string, perMinute int, now time.Time) limit {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	capacity := float64(perMinute)
+	b, found := l.buckets[key]
+	if !found {
+		if len(l.buckets) >= maxIdleBuckets {
+			l.prune(now)
+		}
+		b = &bucket{tokens: capacity, updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.updated).Minutes()*capacity)
+	b.updated = now
+	result := limit{allowed: b.tokens >= 1}
+	if result.allowed {
+		b.tokens--
+	} else {
+		result.retryAfter = time.Duration((1 - b.tokens) / capacity * float64(time.Minute))
+	}
+	result.remaining = int(b.tokens)
+	result.reset = time.Duration((capacity - b.tokens) / capacity * float64(time.Minute))
+	return result
+}
+
+// prune drops the buckets that have had a minute to refill, they are the same as a new bucket
+func (l *rateLimiter) prune(now time.Time) {
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= time.Minute {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// hashAPIKey is how api keys are stored and looked up
+func hashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// rateLimit authenticates the api key of the request and rate limits it per key
+// The requests without a key are rejected if keys are required, unless the endpoint is public, otherwise they are rate
+// limited per client ip
+func (s *Server) rateLimit(public bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limitKey := "ip:" + c.ClientIP()
+		perMinute := s.config.AnonymousRateLimitPerMinute
+		if rawKey := c.GetHeader(apiKeyHeader); rawKey != "" {
+			key, err := s.getAPIKey(c, rawKey)
+			if err != nil {
+				s.logger.Error("failed to get api key", zap.Error(err))
+				respondWithInternalError(c, "failed to get api key")
+				return
+			}
+			if key == nil || key.RevokedAt != nil {
+				respondWithError(c, api.ErrorCodeUnauthorized, "the api key is not valid", nil)
+				return
+			}
+			c.Set(apiKeyContextKey, *key)
+			limitKey = "key:" + key.ID
+			perMinute = key.RateLimitPerMinute
+			if perMinute == 0 {
+				perMinute = s.config.APIKeyRateLimitPerMinute
+			}
+		} else if s.config.RequireAPIKey && !public {
+			respondWithError(c, api.ErrorCodeUnauthorized, "an api key is required in the "+apiKeyHeader+" header", nil)
+			return
+		}
+
+		if perMinute <= 0 {
+			c.Next()
+			return
+		}
+		result := s.rateLimiter.allow(limitKey, perMinute, time.Now())
+		c.Header("X-RateLimit-Limit", strconv.Itoa(perMinute))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(result.reset.Seconds()))))
+		if !result.allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(result.retryAfter.Seconds()))))
+			respondWithError(c, api.ErrorCodeRateLimited, "rate limit exceeded", map[string]string{"limitPerMinute": strconv.Itoa(perMinute)})
+			return
+		}
+		c.Next()
+	}
+}
+
+// requireAPIKey rejects the requests without a valid api key, it runs after rateLimit which authenticates the key
+func requireAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, found := c.Get(apiKeyContextKey); !found {
+			respondWithError(c, api.ErrorCodeUnauthorized, "an api key is required in the "+apiKeyHeader+" header", nil)
+			return
+		}
+		c.Next()
+	}
+}
+
+// requestAPIKey returns the api key that authenticated the request
+func requestAPIKey(c *gin.Context) api.APIKey {
+	return c.MustGet(apiKeyContextKey).(api.APIKey)
+}
+
+// getAPIKey returns the api key, nil if it doesn't exist. Keys are cached so a request doesn't hit the database,
+// a revoked key can be accepted until its cache entry expires
+func (s *Server) getAPIKey(c *gin.Context, rawKey string) (*api.APIKey, error) {
+	keyHash := hashAPIKey(rawKey)
+	if cached, found := s.apiKeyCache.Get(keyHash); found {
+		return cached.(*api.APIKey), nil
+	}
+	key, err := s.dbClient.GetAPIKeyByHash(c.Request.Context(), keyHash)
+	if err != nil {
+		return nil, err
+	}
+	s.apiKeyCache.Set(keyHash, key, cache.DefaultExpiration)
+	return key, nil
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/patrickmn/go-cache"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type ScrapeConfigResponse struct {
+	StatusPage api.StatusPage `json:"statusPage"`
+}
+
+// updateScrapeConfig is a handler for the PUT /statusPage/scrapeConfig endpoint, it requires the admin token.
+// It has a required query parameter of statusPageUrl and replaces the scrape config of the status page with the body,
+// an empty object clears it. The scrapers pick the change up the next time they refresh their status pages
+func (s *Server) updateScrapeConfig(context *gin.Context) {
+	ctx := context.Request.Context()
+	statusPageUrl := s.canonicalStatusPageUrl(context.Query("statusPageUrl"))
+	if statusPageUrl == "" {
+		respondWithMissingParameter(context, "statusPageUrl", "statusPageUrl is required")
+		return
+	}
+
+	var config api.ScrapeConfig
+	decoder := json.NewDecoder(context.Request.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&config)
+	if err != nil {
+		respondWithError(context, api.ErrorCodeInvalidBody, "the body must be a scrape config: "+err.Error(), nil)
+		return
+	}
+	if reason := validateScrapeConfig(&config); reason != "" {
+		respondWithError(context, api.ErrorCodeInvalidBody, reason, nil)
+		return
+	}
+
+	statusPage, err := s.dbClient.GetStatusPage(ctx, statusPageUrl)
+	if err != nil {
+		s.logger.Error("failed to get status page", zap.Error(err), zap.String("statusPageUrl", statusPageUrl))
+		respondWithInternalError(context, "failed to get status page")
+		return
+	}
+	if statusPage == nil {
+		respondWithStatusPageNotFound(context)
+		return
+	}
+
+	statusPage.ScrapeConfig = &config
+	if isEmptyScrapeConfig(config) {
+		statusPage.ScrapeConfig = nil
+	}
+	err = s.dbClient.SetStatusPageScrapeConfig(ctx, statusPageUrl, statusPage.ScrapeConfig)
+	if err != nil {
+		s.logger.Error("failed to update scrape config", zap.Error(err), zap.String("statusPageUrl", statusPageUrl))
+		respondWithInternalError(context, "failed to update scrape config")
+		return
+	}
+	s.logger.Info("updated scrape config", zap.String("statusPageUrl", statusPageUrl), zap.Any("scrapeConfig", statusPage.ScrapeConfig))
+	s.statusPageCache.Set(statusPageUrl, *statusPage, cache.DefaultExpiration)
+	context.JSON(http.StatusOK, ScrapeConfigResponse{StatusPage: *statusPage})
+}
+
+// validateScrapeConfig returns why the scrape config is invalid, empty if it is valid
+func validateScrapeConfig(config *api.ScrapeConfig) string {
+	if config.IntervalSeconds < 0 {
+		return "intervalSeconds must not be negative"
+	}
+	if string(config.Selectors) == "null" {
+		config.Selectors = nil
+	}
+	if len(config.Selectors) > 0 {
+		var selectors map[string]interface{}
+		if json.Unmarshal(config.Selectors, &selectors) != nil {
+			return "selectors must be a provider definition object"
+		}
+	}
+	return ""
+}
+
+func isEmptyScrapeConfig(config api.ScrapeConfig) bool {
+	return len(config.Headers) == 0 && len(config.Selectors) == 0 && config.IntervalSeconds == 0 && config.Provider == "" && config.RequiresJS == nil
+}
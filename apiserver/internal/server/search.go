@@ -0,0 +1,88 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 50
+)
+
+type SearchStatusPageResult struct {
+	StatusPage api.StatusPage `json:"statusPage"`
+	// Score is how far the query is from the name or url of the status page, lower is better
+	Score int `json:"score"`
+}
+
+type SearchIncidentResult struct {
+	Incident api.Incident `json:"incident"`
+	// StatusPageName is the name of the status page of the incident
+	StatusPageName string `json:"statusPageName"`
+	// Rank is how well the title and description of the incident match the query, higher is better
+	Rank float64 `json:"rank"`
+}
+
+type SearchResponse struct {
+	// StatusPages are the status pages whose name or url match the query, best match first
+	StatusPages []SearchStatusPageResult `json:"statusPages"`
+	// Incidents are the incidents whose title or description match the query, best match first
+	Incidents []SearchIncidentResult `json:"incidents"`
+}
+
+// search is a handler for the /search endpoint.
+// It has a required query parameter of q, and returns up to limit status pages and up to limit incidents that match it
+// The status pages are matched fuzzily on their name and url, the incidents with the full text index of their title and
+// description, where q is in the web search syntax, e.g. "database outage" -resolved
+func (s *Server) search(context *gin.Context) {
+	ctx := context.Request.Context()
+	query := context.Query("q")
+	if query == "" {
+		respondWithMissingParameter(context, "q", "q is required")
+		return
+	}
+	limit := defaultSearchLimit
+	if limitStr := context.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			respondWithInvalidParameter(context, "limit", "limit must be a positive integer")
+			return
+		}
+		limit = min(parsed, maxSearchLimit)
+	}
+
+	response := SearchResponse{StatusPages: []SearchStatusPageResult{}, Incidents: []SearchIncidentResult{}}
+	for _, ranked := range s.rankStatusPages(context, query) {
+		if len(response.StatusPages) == limit {
+			break
+		}
+		response.StatusPages = append(response.StatusPages, SearchStatusPageResult{StatusPage: ranked.StatusPage, Score: ranked.Score})
+	}
+
+	// Twice the limit is fetched as the incidents of the sandbox status pages are left out
+	incidents, err := s.dbClient.SearchIncidents(ctx, query, 2*limit)
+	if err != nil {
+		s.logger.Error("failed to search incidents", zap.Error(err), zap.String("q", query))
+		respondWithInternalError(context, "failed to search incidents")
+		return
+	}
+	for _, result := range incidents {
+		if len(response.Incidents) == limit {
+			break
+		}
+		cached, found := s.statusPageCache.Get(result.StatusPageUrl)
+		if !found {
+			continue
+		}
+		statusPage, ok := cached.(api.StatusPage)
+		if !ok || !includeStatusPage(context, statusPage) {
+			continue
+		}
+		response.Incidents = append(response.Incidents, SearchIncidentResult{Incident: result.Incident, StatusPageName: statusPage.Name, Rank: result.Rank})
+	}
+	context.JSON(http.StatusOK, response)
+}
@@ -1,65 +1,177 @@
 package server
 
 import (
+	"crypto/subtle"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/metoro-io/statusphere/common/api"
 	"github.com/metoro-io/statusphere/common/db"
+	"github.com/metoro-io/statusphere/common/graphql"
+	"github.com/metoro-io/statusphere/common/metrics"
 	"github.com/metoro-io/statusphere/common/utils"
 	"github.com/patrickmn/go-cache"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.uber.org/zap"
+	"net/http"
+	"slices"
+	"strings"
 	"time"
 )
 
+type Config struct {
+	// AdminToken authorizes the endpoints that edit status pages, sent as a bearer token
+	// The endpoints are disabled if it is empty
+	AdminToken string `envconfig:"API_ADMIN_TOKEN"`
+	// RequireAPIKey rejects the requests that don't send an api key in the X-API-Key header
+	RequireAPIKey bool `envconfig:"API_REQUIRE_API_KEY" default:"false"`
+	// APIKeyRateLimitPerMinute is the rate limit of the api keys that don't have their own
+	APIKeyRateLimitPerMinute int `envconfig:"API_KEY_RATE_LIMIT_PER_MINUTE" default:"600"`
+	// AnonymousRateLimitPerMinute is the rate limit of each client ip that doesn't send an api key, zero disables it
+	AnonymousRateLimitPerMinute int `envconfig:"API_ANONYMOUS_RATE_LIMIT_PER_MINUTE" default:"60"`
+	// CacheMaxAge is how long clients and CDNs can reuse the incident responses before revalidating them with their ETag
+	// Zero makes them revalidate every time
+	CacheMaxAge time.Duration `envconfig:"API_CACHE_MAX_AGE" default:"30s"`
+	// PublicURL is the url the api is reached at, e.g. https://api.example.com, it is the base of the links of the feeds
+	// The scheme and host of the request are used if it is empty
+	PublicURL string `envconfig:"API_PUBLIC_URL"`
+	// MetricsAddress is where the Prometheus metrics of the api server and its queries are served on /metrics, apart
+	// from the api so that they aren't public, empty disables them
+	MetricsAddress string `envconfig:"API_METRICS_ADDRESS" default:":9090"`
+	// CORSAllowedOrigins are the origins of the browser apps that can call the api, * allows every origin
+	// An origin can have a wildcard, e.g. https://*.example.com
+	CORSAllowedOrigins []string `envconfig:"API_CORS_ALLOWED_ORIGINS" default:"http://localhost:3000,https://metoro.io"`
+	CORSAllowedMethods []string `envconfig:"API_CORS_ALLOWED_METHODS" default:"GET,HEAD,POST,PUT,DELETE,OPTIONS"`
+	CORSAllowedHeaders []string `envconfig:"API_CORS_ALLOWED_HEADERS" default:"Origin,Content-Length,Content-Type,Authorization,X-API-Key,If-None-Match,Last-Event-ID,traceparent,tracestate"`
+	// CORSMaxAge is how long browsers can cache the answer to a preflight request
+	CORSMaxAge time.Duration `envconfig:"API_CORS_MAX_AGE" default:"12h"`
+}
+
+func GetConfigFromEnvironment() (Config, error) {
+	var config Config
+	err := envconfig.Process("STATUSPHERE", &config)
+	return config, err
+}
+
 type Server struct {
+	config               Config
 	logger               *zap.Logger
 	dbClient             *db.DbClient
 	statusPageCache      *cache.Cache
+	aliasCache           *cache.Cache
 	incidentCache        *cache.Cache
 	currentIncidentCache *cache.Cache
+	dbStatsCache         *cache.Cache
+	summaryCache         *cache.Cache
+	apiKeyCache          *cache.Cache
+	// tenantCache holds the set of the status pages that each tenant tracks
+	tenantCache *cache.Cache
+	// tagCache holds the tags of each status page, by tenant with the shared tags under the empty tenant
+	tagCache       *cache.Cache
+	rateLimiter    *rateLimiter
+	incidentStream *incidentStream
+	// httpClient probes the status pages that are registered to detect their provider
+	httpClient *http.Client
+	// openAPIDocument is generated once from the endpoints
+	openAPIDocument map[string]interface{}
+	graphQLSchema   *graphql.Schema
 }
 
-func NewServer(logger *zap.Logger, dbClient *db.DbClient) *Server {
-	return &Server{
+func NewServer(logger *zap.Logger, dbClient *db.DbClient, config Config) *Server {
+	s := &Server{
+		config:               config,
 		logger:               logger,
 		dbClient:             dbClient,
 		statusPageCache:      cache.New(15*time.Minute, 15*time.Minute),
+		aliasCache:           cache.New(15*time.Minute, 15*time.Minute),
 		incidentCache:        cache.New(1*time.Minute, 1*time.Minute),
 		currentIncidentCache: cache.New(1*time.Minute, 1*time.Minute),
+		dbStatsCache:         cache.New(cache.NoExpiration, cache.NoExpiration),
+		summaryCache:         cache.New(1*time.Minute, 1*time.Minute),
+		apiKeyCache:          cache.New(1*time.Minute, 1*time.Minute),
+		tenantCache:          cache.New(1*time.Minute, 1*time.Minute),
+		tagCache:             cache.New(1*time.Minute, 1*time.Minute),
+		rateLimiter:          newRateLimiter(),
+		incidentStream:       newIncidentStream(),
+		httpClient:           &http.Client{Timeout: 10 * time.Second},
 	}
+	s.graphQLSchema = s.newGraphQLSchema()
+	s.openAPIDocument = newOpenAPIDocument(s.endpoints(), config.RequireAPIKey)
+	prometheus.MustRegister(statusCollector{s: s})
+	return s
 }
 
 func (s *Server) Serve() error {
+	if s.config.MetricsAddress != "" {
+		metrics.Serve(s.logger, s.config.MetricsAddress)
+	}
+
 	r := gin.New()
 	r.UseH2C = true
-	r.Use(gin.Recovery())
+	// The requests are traced if telemetry is enabled, joining the trace of the caller if it sends a traceparent
+	r.Use(otelgin.Middleware("statusphere-apiserver"))
+	// A panic is logged by gin and answered with an internal error like any other failure
+	r.Use(gin.CustomRecovery(func(context *gin.Context, _ interface{}) {
+		respondWithInternalError(context, "the request failed")
+	}))
 
-	corsHandler := handleCors()
+	corsHandler, err := handleCors(s.config)
+	if err != nil {
+		return err
+	}
 	r.Use(corsHandler)
-	r.Use(gzip.Gzip(gzip.BestSpeed))
+	// The gzip writer doesn't flush, so the stream would be buffered
+	r.Use(gzip.Gzip(gzip.BestSpeed, gzip.WithExcludedPaths([]string{"/api/v1/incidents/stream"})))
 
 	r.Use(ginZap(s.logger))
+	r.Use(recordRequestMetrics())
 
 	apiV1 := r.Group("/api/v1")
-	{
-		apiV1.Use(addNoIndexHeader())
-		apiV1.GET("/incidents", s.incidents)
-		apiV1.GET("/currentStatus", s.currentStatus)
-		apiV1.GET("/statusPage", s.statusPage)
-		apiV1.GET("/statusPages", s.statusPages)
-		apiV1.GET("/statusPages/search", s.statusPageSearch)
-		apiV1.GET("/statusPages/count", s.statusPageCount)
+	apiV1.Use(addNoIndexHeader())
+	for _, e := range s.endpoints() {
+		handlers := []gin.HandlerFunc{e.handler}
+		if e.admin {
+			handlers = append([]gin.HandlerFunc{s.requireAdminToken()}, handlers...)
+		} else if e.apiKey {
+			handlers = append([]gin.HandlerFunc{s.rateLimit(false), requireAPIKey(), s.scopeToTenant()}, handlers...)
+		} else {
+			handlers = append([]gin.HandlerFunc{s.rateLimit(e.public), s.scopeToTenant()}, handlers...)
+		}
+		if e.root {
+			r.Handle(e.method, e.path, handlers...)
+		} else {
+			apiV1.Handle(e.method, e.path, handlers...)
+		}
 	}
+	r.NoRoute(func(context *gin.Context) {
+		respondWithError(context, api.ErrorCodeNotFound, "endpoint not found", nil)
+	})
 	return errors.Wrap(r.Run(":80"), "Failed to start server")
 }
 
-func handleCors() gin.HandlerFunc {
+// handleCors lets the configured origins call the api from a browser
+func handleCors(config Config) (gin.HandlerFunc, error) {
 	corsConfig := cors.DefaultConfig()
-	// Development cors
-	corsConfig.AllowOrigins = []string{"http://localhost:3000", "https://metoro.io"}
-	handlerFunc := cors.New(corsConfig)
-	return handlerFunc
+	corsConfig.AllowMethods = config.CORSAllowedMethods
+	corsConfig.AllowHeaders = config.CORSAllowedHeaders
+	// The headers that clients act on, the browser hides the others from them
+	corsConfig.ExposeHeaders = []string{"ETag", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"}
+	corsConfig.MaxAge = config.CORSMaxAge
+	corsConfig.AllowWildcard = true
+	if slices.Contains(config.CORSAllowedOrigins, "*") {
+		corsConfig.AllowAllOrigins = true
+	} else {
+		corsConfig.AllowOrigins = config.CORSAllowedOrigins
+	}
+	// cors.New panics on an invalid config, e.g. an origin without a scheme
+	err := corsConfig.Validate()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid cors config")
+	}
+	return cors.New(corsConfig), nil
 }
 
 // Middleware to make Gin log using Zap
@@ -106,6 +218,18 @@ func ginZap(logger *zap.Logger) gin.HandlerFunc {
 	}
 }
 
+// requireAdminToken rejects the requests that don't carry the admin token as a bearer token
+func (s *Server) requireAdminToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if s.config.AdminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.config.AdminToken)) != 1 {
+			respondWithError(c, api.ErrorCodeUnauthorized, "a valid admin token is required", nil)
+			return
+		}
+		c.Next()
+	}
+}
+
 func addNoIndexHeader() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("X-Robots-Tag", "noindex")
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"time"
+)
+
+// providerStatuses are the values of the status label of statusphere_provider_status, outage is an open critical incident
+var providerStatuses = []string{"operational", "degraded", "outage", "unknown"}
+
+var (
+	providerStatusDesc        = prometheus.NewDesc("statusphere_provider_status", "Status of each status page, 1 for its current status and 0 for the others.", []string{"page", "name", "status"}, nil)
+	providerOpenIncidentsDesc = prometheus.NewDesc("statusphere_provider_open_incidents", "Open incidents of each status page.", []string{"page", "name"}, nil)
+)
+
+// statusCollector exports the status and number of open incidents of every status page as gauges, so that alerting
+// can fire on the outages of a vendor, e.g. statusphere_provider_status{page="https://www.githubstatus.com",status="outage"} == 1
+// They are read from the caches when the metrics are scraped, the sandbox status pages are left out
+type statusCollector struct {
+	s *Server
+}
+
+func (c statusCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- providerStatusDesc
+	descs <- providerOpenIncidentsDesc
+}
+
+func (c statusCollector) Collect(metrics chan<- prometheus.Metric) {
+	incidents, ok := c.s.statusMetricsIncidents()
+	if !ok {
+		return
+	}
+	for _, statusPage := range c.s.statusMetricsPages() {
+		// The page label is the full url, the http and https copies of a page would otherwise be duplicate series
+		page := statusPage.URL
+		current := providerStatus(statusPage, incidents[statusPage.URL])
+		for _, status := range providerStatuses {
+			value := 0.0
+			if status == current {
+				value = 1
+			}
+			metrics <- prometheus.MustNewConstMetric(providerStatusDesc, prometheus.GaugeValue, value, page, statusPage.Name, status)
+		}
+		metrics <- prometheus.MustNewConstMetric(providerOpenIncidentsDesc, prometheus.GaugeValue, float64(len(incidents[statusPage.URL])), page, statusPage.Name)
+	}
+}
+
+// statusMetricsPages returns the status pages that have status metrics
+func (s *Server) statusMetricsPages() []api.StatusPage {
+	var statusPages []api.StatusPage
+	for _, item := range s.statusPageCache.Items() {
+		statusPage, ok := item.Object.(api.StatusPage)
+		if ok && !statusPage.IsSandbox {
+			statusPages = append(statusPages, statusPage)
+		}
+	}
+	return statusPages
+}
+
+// statusMetricsIncidents returns the open incidents by status page, false if they couldn't be read, in which case the
+// series are left out rather than reported as operational
+func (s *Server) statusMetricsIncidents() (map[string][]api.Incident, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	incidents, err := s.getAllCurrentIncidents(ctx)
+	if err != nil {
+		s.logger.Error("failed to get current incidents for the status metrics", zap.Error(err))
+		return nil, false
+	}
+	return incidents, true
+}
+
+// providerStatus is the status of the status page given its open incidents, unknown if it isn't indexed like in the summary
+func providerStatus(statusPage api.StatusPage, incidents []api.Incident) string {
+	if !statusPage.IsIndexed {
+		return "unknown"
+	}
+	status := "operational"
+	for _, incident := range incidents {
+		if incident.Impact == api.ImpactCritical {
+			return "outage"
+		}
+		status = "degraded"
+	}
+	return status
+}
@@ -14,23 +14,23 @@ type StatusPageResponse struct {
 // StatusPage is a handler for the /status-page endpoint.
 // It has a required query parameter of statusPageUrl XOR statusPageName
 func (s *Server) statusPage(context *gin.Context) {
-	statusPageUrl := context.Query("statusPageUrl")
+	statusPageUrl := s.canonicalStatusPageUrl(context.Query("statusPageUrl"))
 	statusPageName := strings.ToLower(context.Query("statusPageName"))
 
 	if statusPageUrl == "" && statusPageName == "" {
-		context.JSON(http.StatusBadRequest, gin.H{"error": "statusPageUrl or statusPageName is required"})
+		respondWithMissingParameter(context, "statusPageUrl", "statusPageUrl or statusPageName is required")
 		return
 	}
 
 	if statusPageUrl != "" && statusPageName != "" {
-		context.JSON(http.StatusBadRequest, gin.H{"error": "statusPageUrl and statusPageName are mutually exclusive"})
+		respondWithInvalidParameter(context, "statusPageName", "statusPageUrl and statusPageName are mutually exclusive")
 		return
 	}
 
 	if statusPageUrl != "" {
 		statusPage, found := s.statusPageCache.Get(statusPageUrl)
 		if !found {
-			context.JSON(http.StatusNotFound, gin.H{"error": "status page not known to statusphere"})
+			respondWithStatusPageNotFound(context)
 			return
 		}
 		context.JSON(http.StatusOK, StatusPageResponse{StatusPage: statusPage.(api.StatusPage)})
@@ -40,10 +40,13 @@ func (s *Server) statusPage(context *gin.Context) {
 	if statusPageName != "" {
 		for _, statusPage := range s.statusPageCache.Items() {
 			if strings.ToLower(statusPage.Object.(api.StatusPage).Name) == statusPageName {
+				if !includeStatusPage(context, statusPage.Object.(api.StatusPage)) {
+					continue
+				}
 				context.JSON(http.StatusOK, StatusPageResponse{StatusPage: statusPage.Object.(api.StatusPage)})
 				return
 			}
 		}
-		context.JSON(http.StatusNotFound, gin.H{"error": "status page not known to statusphere"})
+		respondWithStatusPageNotFound(context)
 	}
 }
@@ -0,0 +1,196 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/patrickmn/go-cache"
+	"go.uber.org/zap"
+	"net/http"
+	neturl "net/url"
+	"time"
+)
+
+type CreateStatusPageRequest struct {
+	URL string `json:"url"`
+	// Name defaults to the host of the url
+	Name string `json:"name,omitempty"`
+	// Provider is the provider that scrapes the status page, it is detected if it is empty
+	Provider string `json:"provider,omitempty"`
+	// Timezone is the IANA timezone that the status page prints local times in
+	Timezone     string            `json:"timezone,omitempty"`
+	ScrapeConfig *api.ScrapeConfig `json:"scrapeConfig,omitempty"`
+}
+
+// createStatusPage is a handler for the POST /statusPage endpoint, it requires the admin token.
+// It adds the status page of the body, its provider is detected unless it is given
+func (s *Server) createStatusPage(context *gin.Context) {
+	ctx := context.Request.Context()
+	var request CreateStatusPageRequest
+	decoder := json.NewDecoder(context.Request.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&request)
+	if err != nil {
+		respondWithError(context, api.ErrorCodeInvalidBody, "the body must be a status page: "+err.Error(), nil)
+		return
+	}
+	url, reason := s.normalizeStatusPageUrl(request.URL)
+	if reason != "" {
+		respondWithError(context, api.ErrorCodeInvalidBody, reason, nil)
+		return
+	}
+	if request.Timezone != "" {
+		if _, err := time.LoadLocation(request.Timezone); err != nil {
+			respondWithError(context, api.ErrorCodeInvalidBody, "unknown timezone "+request.Timezone, nil)
+			return
+		}
+	}
+	if request.ScrapeConfig != nil {
+		if reason := validateScrapeConfig(request.ScrapeConfig); reason != "" {
+			respondWithError(context, api.ErrorCodeInvalidBody, reason, nil)
+			return
+		}
+		if isEmptyScrapeConfig(*request.ScrapeConfig) {
+			request.ScrapeConfig = nil
+		}
+	}
+	if request.Provider != "" {
+		features, err := s.dbClient.GetProviderFeatures(ctx)
+		if err != nil {
+			s.logger.Error("failed to get provider features", zap.Error(err))
+			respondWithInternalError(context, "failed to get providers")
+			return
+		}
+		known := false
+		for _, feature := range features {
+			known = known || feature.Provider == request.Provider
+		}
+		if !known {
+			respondWithError(context, api.ErrorCodeInvalidBody, "unknown provider "+request.Provider, nil)
+			return
+		}
+	}
+
+	existing, err := s.dbClient.GetStatusPage(ctx, url)
+	if err != nil {
+		s.logger.Error("failed to get status page", zap.Error(err), zap.String("statusPageUrl", url))
+		respondWithInternalError(context, "failed to get status page")
+		return
+	}
+	if existing != nil {
+		respondWithError(context, api.ErrorCodeStatusPageExists, "a status page is already stored at the url", map[string]string{"statusPageUrl": url})
+		return
+	}
+
+	statusPage := api.StatusPage{
+		URL:          url,
+		Name:         request.Name,
+		Provider:     request.Provider,
+		Timezone:     request.Timezone,
+		ScrapeConfig: request.ScrapeConfig,
+	}
+	if statusPage.Name == "" {
+		parsed, _ := neturl.Parse(url)
+		statusPage.Name = parsed.Host
+	}
+	if statusPage.Provider == "" && (statusPage.ScrapeConfig == nil || statusPage.ScrapeConfig.Provider == "") {
+		if candidates := s.detectProvider(ctx, url); len(candidates) > 0 {
+			now := time.Now().UTC()
+			statusPage.Provider = candidates[0]
+			statusPage.ProviderDetectedAt = &now
+		}
+	}
+	err = s.dbClient.InsertStatusPage(ctx, statusPage)
+	if err != nil {
+		s.logger.Error("failed to create status page", zap.Error(err), zap.String("statusPageUrl", url))
+		respondWithInternalError(context, "failed to create status page")
+		return
+	}
+	s.logger.Info("created status page", zap.String("statusPageUrl", url), zap.String("provider", statusPage.Provider))
+	s.statusPageCache.Set(url, statusPage, cache.DefaultExpiration)
+	context.JSON(http.StatusOK, StatusPageResponse{StatusPage: statusPage})
+}
+
+// pauseStatusPage is a handler for the POST /statusPage/pause endpoint, it requires the admin token.
+// It has a required query parameter of statusPageUrl and stops the status page from being scraped until it is resumed
+func (s *Server) pauseStatusPage(context *gin.Context) {
+	now := time.Now().UTC()
+	s.setStatusPagePaused(context, &now)
+}
+
+// resumeStatusPage is a handler for the POST /statusPage/resume endpoint, it requires the admin token.
+// It has a required query parameter of statusPageUrl and resumes the scrapes of a paused status page
+func (s *Server) resumeStatusPage(context *gin.Context) {
+	s.setStatusPagePaused(context, nil)
+}
+
+func (s *Server) setStatusPagePaused(context *gin.Context, pausedAt *time.Time) {
+	ctx := context.Request.Context()
+	statusPageUrl := s.canonicalStatusPageUrl(context.Query("statusPageUrl"))
+	if statusPageUrl == "" {
+		respondWithMissingParameter(context, "statusPageUrl", "statusPageUrl is required")
+		return
+	}
+	statusPage, err := s.dbClient.GetStatusPage(ctx, statusPageUrl)
+	if err != nil {
+		s.logger.Error("failed to get status page", zap.Error(err), zap.String("statusPageUrl", statusPageUrl))
+		respondWithInternalError(context, "failed to get status page")
+		return
+	}
+	if statusPage == nil {
+		respondWithStatusPageNotFound(context)
+		return
+	}
+	// Pausing a paused status page keeps when it was paused
+	if pausedAt != nil && statusPage.PausedAt != nil {
+		context.JSON(http.StatusOK, StatusPageResponse{StatusPage: *statusPage})
+		return
+	}
+	err = s.dbClient.SetStatusPagePaused(ctx, statusPageUrl, pausedAt)
+	if err != nil {
+		s.logger.Error("failed to set status page paused", zap.Error(err), zap.String("statusPageUrl", statusPageUrl))
+		respondWithInternalError(context, "failed to update status page")
+		return
+	}
+	statusPage.PausedAt = pausedAt
+	s.logger.Info("set status page paused", zap.String("statusPageUrl", statusPageUrl), zap.Bool("paused", pausedAt != nil))
+	s.statusPageCache.Set(statusPageUrl, *statusPage, cache.DefaultExpiration)
+	context.JSON(http.StatusOK, StatusPageResponse{StatusPage: *statusPage})
+}
+
+// deleteStatusPage is a handler for the DELETE /statusPage endpoint, it requires the admin token.
+// It has a required query parameter of statusPageUrl and deletes the status page along with its incidents and history
+func (s *Server) deleteStatusPage(context *gin.Context) {
+	ctx := context.Request.Context()
+	statusPageUrl := s.canonicalStatusPageUrl(context.Query("statusPageUrl"))
+	if statusPageUrl == "" {
+		respondWithMissingParameter(context, "statusPageUrl", "statusPageUrl is required")
+		return
+	}
+	statusPage, err := s.dbClient.GetStatusPage(ctx, statusPageUrl)
+	if err != nil {
+		s.logger.Error("failed to get status page", zap.Error(err), zap.String("statusPageUrl", statusPageUrl))
+		respondWithInternalError(context, "failed to get status page")
+		return
+	}
+	if statusPage == nil {
+		respondWithStatusPageNotFound(context)
+		return
+	}
+	err = s.dbClient.DeleteStatusPage(ctx, statusPageUrl)
+	if err != nil {
+		s.logger.Error("failed to delete status page", zap.Error(err), zap.String("statusPageUrl", statusPageUrl))
+		respondWithInternalError(context, "failed to delete status page")
+		return
+	}
+	s.logger.Info("deleted status page", zap.String("statusPageUrl", statusPageUrl))
+	s.statusPageCache.Delete(statusPageUrl)
+	s.incidentCache.Delete(statusPageUrl)
+	s.currentIncidentCache.Delete(statusPageUrl)
+	for alias, item := range s.aliasCache.Items() {
+		if item.Object == statusPageUrl {
+			s.aliasCache.Delete(alias)
+		}
+	}
+	context.JSON(http.StatusOK, StatusPageResponse{StatusPage: *statusPage})
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
 	"net/http"
 )
 
@@ -11,5 +12,11 @@ type StatusPageCountResponse struct {
 
 // statusPageCount is a handler for the /statusPages/count endpoint.
 func (s *Server) statusPageCount(context *gin.Context) {
-	context.JSON(http.StatusOK, StatusPageCountResponse{StatusPageCount: s.statusPageCache.ItemCount()})
+	count := 0
+	for _, statusPage := range s.statusPageCache.Items() {
+		if includeStatusPage(context, statusPage.Object.(api.StatusPage)) {
+			count++
+		}
+	}
+	context.JSON(http.StatusOK, StatusPageCountResponse{StatusPageCount: count})
 }
@@ -25,13 +25,32 @@ type statusPageRanked struct {
 func (s *Server) statusPageSearch(context *gin.Context) {
 	query := context.Query("query")
 	if query == "" {
-		context.JSON(http.StatusBadRequest, gin.H{"error": "query is required"})
+		respondWithMissingParameter(context, "query", "query is required")
 		return
 	}
 
+	statusPagesRanked := s.rankStatusPages(context, query)
+
+	var statusPages []api.StatusPage
+	for _, statusPage := range statusPagesRanked {
+		statusPages = append(statusPages, statusPage.StatusPage)
+	}
+
+	if len(statusPages) > 25 {
+		statusPages = statusPages[:25]
+	}
+
+	context.JSON(http.StatusOK, StatusPageSearchResponse{StatusPages: statusPages})
+}
+
+// rankStatusPages returns the status pages whose name or url fuzzy match the query, best match first
+func (s *Server) rankStatusPages(context *gin.Context, query string) []statusPageRanked {
 	var statusPagesRanked []statusPageRanked
 
 	for _, statusPage := range s.statusPageCache.Items() {
+		if !includeStatusPage(context, statusPage.Object.(api.StatusPage)) {
+			continue
+		}
 		score := math.MaxInt
 		nameMatch := fuzzy.RankMatch(query, statusPage.Object.(api.StatusPage).Name)
 		urlMatch := fuzzy.RankMatch(query, statusPage.Object.(api.StatusPage).URL)
@@ -53,15 +72,5 @@ func (s *Server) statusPageSearch(context *gin.Context) {
 	sort.Slice(statusPagesRanked, func(i, j int) bool {
 		return statusPagesRanked[i].Score < statusPagesRanked[j].Score
 	})
-
-	var statusPages []api.StatusPage
-	for _, statusPage := range statusPagesRanked {
-		statusPages = append(statusPages, statusPage.StatusPage)
-	}
-
-	if len(statusPages) > 25 {
-		statusPages = statusPages[:25]
-	}
-
-	context.JSON(http.StatusOK, StatusPageSearchResponse{StatusPages: statusPages})
+	return statusPagesRanked
 }
@@ -1,27 +1,70 @@
 package server
 
 import (
+	"context"
 	"github.com/gin-gonic/gin"
 	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
 	"net/http"
+	"slices"
 	"sort"
 	"strings"
 )
 
 type StatusPagesResponse struct {
-	StatusPages []api.StatusPage `json:"statusPages"`
+	Data []api.StatusPage `json:"data"`
+	Page
 }
 
+// statusPages is a handler for the /statusPages endpoint, it returns the status pages by name, every one unless a limit is given
+// The tag parameter keeps the status pages with one of its comma separated tags
 func (s *Server) statusPages(context *gin.Context) {
-	var statusPages []api.StatusPage
+	page, ok := parsePageRequest(context, 0, textKey, textKey)
+	if !ok {
+		return
+	}
+	matchesTag, ok := s.tagFilter(context)
+	if !ok {
+		return
+	}
+	statusPages := []api.StatusPage{}
 	for _, statusPage := range s.statusPageCache.Items() {
+		if !includeStatusPage(context, statusPage.Object.(api.StatusPage)) || !matchesTag(statusPage.Object.(api.StatusPage).URL) {
+			continue
+		}
 		statusPages = append(statusPages, statusPage.Object.(api.StatusPage))
 	}
 
-	// Sort the status pages by name alphabetically a to z
+	// Sort the status pages by name alphabetically a to z, the url tells the status pages with the same name apart
 	sort.Slice(statusPages, func(i, j int) bool {
-		return strings.ToLower(statusPages[i].Name) < strings.ToLower(statusPages[j].Name)
+		return slices.Compare(statusPageKey(statusPages[i]), statusPageKey(statusPages[j])) < 0
 	})
 
-	context.JSON(http.StatusOK, StatusPagesResponse{StatusPages: statusPages})
+	statusPages, envelope := pageOfSorted(statusPages, page, statusPageKey, statusPageFollows)
+	context.JSON(http.StatusOK, StatusPagesResponse{Data: statusPages, Page: envelope})
+}
+
+// statusPageKey is the key of the status page in the list of status pages, see db.Page
+func statusPageKey(statusPage api.StatusPage) []string {
+	return []string{strings.ToLower(statusPage.Name), statusPage.URL}
+}
+
+func statusPageFollows(statusPage api.StatusPage, key []string) bool {
+	return slices.Compare(statusPageKey(statusPage), key) > 0
+}
+
+// includeStatusPage returns false for the status pages that the request can't see, see visibleStatusPage
+func includeStatusPage(context *gin.Context, statusPage api.StatusPage) bool {
+	return visibleStatusPage(context.Request.Context(), statusPage)
+}
+
+// visibleStatusPage returns false for the synthetic sandbox status pages unless the request is made with an api key of
+// the sandbox tenant, so that sandbox data never shows up alongside the real status pages
+// The requests of a tenant only include the status pages that the tenant tracks
+func visibleStatusPage(ctx context.Context, statusPage api.StatusPage) bool {
+	if urls, found := tenantScope(ctx); found && !urls[statusPage.URL] {
+		return false
+	}
+	tenantID, _ := db.TenantFromContext(ctx)
+	return statusPage.IsSandbox == (tenantID == api.SandboxTenantID)
 }
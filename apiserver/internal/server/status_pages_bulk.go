@@ -0,0 +1,228 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/detect"
+	"github.com/patrickmn/go-cache"
+	"go.uber.org/zap"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxBulkStatusPages bounds the status pages of a bulk registration, each new one is probed to detect its provider
+	maxBulkStatusPages = 500
+	// detectConcurrency is how many status pages are probed at once
+	detectConcurrency = 16
+	detectTimeout     = 20 * time.Second
+)
+
+type BulkStatusPageResult string
+
+const (
+	BulkStatusPageCreated BulkStatusPageResult = "created"
+	BulkStatusPageUpdated BulkStatusPageResult = "updated"
+	BulkStatusPageInvalid BulkStatusPageResult = "invalid"
+)
+
+type BulkStatusPageItem struct {
+	URL string `json:"url"`
+	// Name defaults to the host of the url for a new status page, an existing status page keeps its name if it is empty
+	Name string `json:"name,omitempty"`
+	// Provider is the provider that scrapes the status page, it is detected if it is empty
+	Provider string `json:"provider,omitempty"`
+}
+
+type BulkStatusPagesRequest struct {
+	StatusPages []BulkStatusPageItem `json:"statusPages"`
+}
+
+type BulkStatusPageItemResult struct {
+	// URL is the url the status page is stored at, the url of an alias is replaced by the url it redirects to
+	URL    string               `json:"url"`
+	Result BulkStatusPageResult `json:"result"`
+	// Error is why an invalid item wasn't registered
+	Error string `json:"error,omitempty"`
+	// StatusPage is the registered status page, it is empty for an invalid item
+	StatusPage *api.StatusPage `json:"statusPage,omitempty"`
+	// DetectedProviders are the providers that the status page looks like it is scraped by, most likely first
+	// Only set when the provider was detected
+	DetectedProviders []string `json:"detectedProviders,omitempty"`
+}
+
+type BulkStatusPagesResponse struct {
+	// Results are in the order of the request
+	Results []BulkStatusPageItemResult `json:"results"`
+}
+
+// bulkStatusPages is a handler for the POST /statusPages/bulk endpoint, it requires the admin token.
+// It validates each status page of the body and registers the valid ones, the provider of a new status page is
+// detected unless it is given. The other items are registered even if some are invalid, the results say which
+func (s *Server) bulkStatusPages(context *gin.Context) {
+	ctx := context.Request.Context()
+	var request BulkStatusPagesRequest
+	decoder := json.NewDecoder(context.Request.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&request)
+	if err != nil {
+		respondWithError(context, api.ErrorCodeInvalidBody, "the body must be a list of status pages: "+err.Error(), nil)
+		return
+	}
+	if len(request.StatusPages) == 0 {
+		respondWithError(context, api.ErrorCodeInvalidBody, "statusPages is required", nil)
+		return
+	}
+	if len(request.StatusPages) > maxBulkStatusPages {
+		respondWithError(context, api.ErrorCodeInvalidBody, "at most "+strconv.Itoa(maxBulkStatusPages)+" status pages can be registered at once", nil)
+		return
+	}
+
+	features, err := s.dbClient.GetProviderFeatures(ctx)
+	if err != nil {
+		s.logger.Error("failed to get provider features", zap.Error(err))
+		respondWithInternalError(context, "failed to get providers")
+		return
+	}
+	knownProviders := make(map[string]bool, len(features))
+	for _, feature := range features {
+		knownProviders[feature.Provider] = true
+	}
+
+	results := make([]BulkStatusPageItemResult, len(request.StatusPages))
+	seen := map[string]bool{}
+	var urls []string
+	for i, item := range request.StatusPages {
+		url, reason := s.normalizeStatusPageUrl(item.URL)
+		if reason == "" && seen[url] {
+			reason = "the url is already in the request"
+		}
+		if reason == "" && item.Provider != "" && !knownProviders[item.Provider] {
+			reason = "unknown provider " + item.Provider
+		}
+		if reason != "" {
+			results[i] = BulkStatusPageItemResult{URL: item.URL, Result: BulkStatusPageInvalid, Error: reason}
+			continue
+		}
+		seen[url] = true
+		urls = append(urls, url)
+		results[i].URL = url
+	}
+
+	stored, err := s.dbClient.GetStatusPagesByUrls(ctx, urls)
+	if err != nil {
+		s.logger.Error("failed to get status pages", zap.Error(err))
+		respondWithInternalError(context, "failed to get status pages")
+		return
+	}
+	existing := make(map[string]api.StatusPage, len(stored))
+	for _, statusPage := range stored {
+		existing[statusPage.URL] = statusPage
+	}
+
+	// Only new status pages are probed, the scraper keeps the provider of the existing ones up to date
+	var toDetect []int
+	for i, item := range request.StatusPages {
+		if results[i].Result != BulkStatusPageInvalid && item.Provider == "" {
+			if _, found := existing[results[i].URL]; !found {
+				toDetect = append(toDetect, i)
+			}
+		}
+	}
+	s.detectProviders(ctx, results, toDetect)
+
+	now := time.Now().UTC()
+	var statusPages []api.StatusPage
+	registered := map[int]int{}
+	for i, item := range request.StatusPages {
+		if results[i].Result == BulkStatusPageInvalid {
+			continue
+		}
+		statusPage, found := existing[results[i].URL]
+		results[i].Result = BulkStatusPageUpdated
+		if !found {
+			statusPage = api.StatusPage{URL: results[i].URL}
+			results[i].Result = BulkStatusPageCreated
+		}
+		if item.Name != "" {
+			statusPage.Name = item.Name
+		} else if statusPage.Name == "" {
+			parsed, _ := neturl.Parse(statusPage.URL)
+			statusPage.Name = parsed.Host
+		}
+		if item.Provider != "" {
+			// A given provider is a hint, it is used until it is changed
+			statusPage.Provider = item.Provider
+			statusPage.ProviderDetectedAt = nil
+		} else if len(results[i].DetectedProviders) > 0 {
+			statusPage.Provider = results[i].DetectedProviders[0]
+			statusPage.ProviderDetectedAt = &now
+		}
+		registered[i] = len(statusPages)
+		statusPages = append(statusPages, statusPage)
+	}
+
+	err = s.dbClient.RegisterStatusPages(ctx, statusPages)
+	if err != nil {
+		s.logger.Error("failed to register status pages", zap.Error(err), zap.Int("statusPages", len(statusPages)))
+		respondWithInternalError(context, "failed to register status pages")
+		return
+	}
+	for i, j := range registered {
+		results[i].StatusPage = &statusPages[j]
+	}
+	for _, statusPage := range statusPages {
+		s.statusPageCache.Set(statusPage.URL, statusPage, cache.DefaultExpiration)
+	}
+	s.logger.Info("registered status pages", zap.Int("statusPages", len(statusPages)), zap.Int("invalid", len(request.StatusPages)-len(statusPages)))
+	context.JSON(http.StatusOK, BulkStatusPagesResponse{Results: results})
+}
+
+// normalizeStatusPageUrl returns the url that the status page is stored at, or why the url isn't a valid status page
+func (s *Server) normalizeStatusPageUrl(rawUrl string) (string, string) {
+	parsed, err := neturl.Parse(strings.TrimSpace(rawUrl))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", "the url must be an absolute http or https url"
+	}
+	if parsed.User != nil || parsed.RawQuery != "" || parsed.Fragment != "" {
+		return "", "the url must not have credentials, a query or a fragment"
+	}
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+	return s.canonicalStatusPageUrl(strings.TrimSuffix(parsed.String(), "/")), ""
+}
+
+// detectProviders probes the status pages of the results at the given indexes and sets their detected providers
+// A status page that can't be classified is left for the scraper to detect when it is first scraped
+func (s *Server) detectProviders(ctx context.Context, results []BulkStatusPageItemResult, indexes []int) {
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, detectConcurrency)
+	for _, i := range indexes {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			results[i].DetectedProviders = s.detectProvider(ctx, results[i].URL)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// detectProvider returns the providers that the status page looks like it is scraped by, most likely first
+func (s *Server) detectProvider(ctx context.Context, url string) []string {
+	probeCtx, cancel := context.WithTimeout(ctx, detectTimeout)
+	defer cancel()
+	candidates, err := detect.Probe(probeCtx, s.httpClient, url)
+	if err != nil {
+		s.logger.Info("failed to detect the provider of the status page", zap.String("url", url), zap.Error(err))
+		return nil
+	}
+	return candidates
+}
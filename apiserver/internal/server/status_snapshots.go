@@ -0,0 +1,87 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+type StatusSnapshotsResponse struct {
+	Snapshots []api.StatusSnapshot `json:"snapshots"`
+}
+
+// statusSnapshotsDefaultPeriod is the period returned when no from is given
+const statusSnapshotsDefaultPeriod = 24 * time.Hour
+
+// statusSnapshots is a handler for the /statusSnapshots endpoint.
+// It has a required query parameter of statusPageUrl and returns the overall status history of the status page.
+// With at, only the snapshot in effect at that time is returned, otherwise the snapshots taken in [from, to) are,
+// which default to the last day. The times are RFC 3339.
+func (s *Server) statusSnapshots(context *gin.Context) {
+	ctx := context.Request.Context()
+	statusPageUrl := s.canonicalStatusPageUrl(context.Query("statusPageUrl"))
+	if statusPageUrl == "" {
+		respondWithMissingParameter(context, "statusPageUrl", "statusPageUrl is required")
+		return
+	}
+	if _, found := s.statusPageCache.Get(statusPageUrl); !found {
+		respondWithStatusPageNotFound(context)
+		return
+	}
+
+	if atStr := context.Query("at"); atStr != "" {
+		at, err := time.Parse(time.RFC3339, atStr)
+		if err != nil {
+			respondWithInvalidParameter(context, "at", "at must be an RFC 3339 time")
+			return
+		}
+		snapshot, err := s.dbClient.GetStatusSnapshotAt(ctx, statusPageUrl, at)
+		if err != nil {
+			s.logger.Error("failed to get status snapshot", zap.Error(err))
+			respondWithInternalError(context, "failed to get status snapshot")
+			return
+		}
+		snapshots := []api.StatusSnapshot{}
+		if snapshot != nil {
+			snapshots = append(snapshots, *snapshot)
+		}
+		context.JSON(http.StatusOK, StatusSnapshotsResponse{Snapshots: snapshots})
+		return
+	}
+
+	to := time.Now()
+	if toStr := context.Query("to"); toStr != "" {
+		parsed, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			respondWithInvalidParameter(context, "to", "to must be an RFC 3339 time")
+			return
+		}
+		to = parsed
+	}
+	from := to.Add(-statusSnapshotsDefaultPeriod)
+	if fromStr := context.Query("from"); fromStr != "" {
+		parsed, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			respondWithInvalidParameter(context, "from", "from must be an RFC 3339 time")
+			return
+		}
+		from = parsed
+	}
+	if !from.Before(to) {
+		respondWithInvalidParameter(context, "from", "from must be before to")
+		return
+	}
+
+	snapshots, err := s.dbClient.GetStatusSnapshots(ctx, statusPageUrl, from, to)
+	if err != nil {
+		s.logger.Error("failed to get status snapshots", zap.Error(err))
+		respondWithInternalError(context, "failed to get status snapshots")
+		return
+	}
+	if snapshots == nil {
+		snapshots = []api.StatusSnapshot{}
+	}
+	context.JSON(http.StatusOK, StatusSnapshotsResponse{Snapshots: snapshots})
+}
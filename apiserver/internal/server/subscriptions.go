@@ -0,0 +1,209 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"go.uber.org/zap"
+	"net/http"
+	neturl "net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+)
+
+type CreateSubscriptionRequest struct {
+	// URL is where the payloads are posted, it must be http or https
+	URL string `json:"url"`
+	// StatusPageUrls only sends the changes of these status pages, every status page if empty
+	StatusPageUrls []string `json:"statusPageUrls,omitempty"`
+	// Impacts only sends the changes of incidents with these impacts, every impact if empty
+	Impacts []api.Impact `json:"impacts,omitempty"`
+	// EventTypes are the changes that are sent, incident.created, incident.updated and incident.resolved if empty
+	EventTypes []api.ChangeEventType `json:"eventTypes,omitempty"`
+}
+
+type CreateSubscriptionResponse struct {
+	Subscription api.Subscription `json:"subscription"`
+	// Secret is the key of the signatures of the payloads, it can't be retrieved again
+	Secret string `json:"secret"`
+}
+
+type SubscriptionsResponse struct {
+	Subscriptions []api.Subscription `json:"subscriptions"`
+}
+
+type SubscriptionResponse struct {
+	Subscription api.Subscription `json:"subscription"`
+}
+
+type WebhookDeliveriesResponse struct {
+	Deliveries []api.WebhookDelivery `json:"deliveries"`
+}
+
+// createSubscription is a handler for the POST /subscriptions endpoint, it requires an api key.
+// It subscribes a webhook to the incident changes that match the filters of the body
+func (s *Server) createSubscription(context *gin.Context) {
+	ctx := context.Request.Context()
+	apiKey := requestAPIKey(context)
+	var request CreateSubscriptionRequest
+	decoder := json.NewDecoder(context.Request.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&request)
+	if err != nil {
+		respondWithError(context, api.ErrorCodeInvalidBody, "the body must be a subscription request: "+err.Error(), nil)
+		return
+	}
+	parsed, err := neturl.Parse(request.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		respondWithError(context, api.ErrorCodeInvalidBody, "url must be an absolute http or https url", nil)
+		return
+	}
+	for _, impact := range request.Impacts {
+		if impact.Severity() == -1 {
+			respondWithError(context, api.ErrorCodeInvalidBody, "unknown impact "+string(impact), nil)
+			return
+		}
+	}
+	for _, eventType := range request.EventTypes {
+		if !slices.Contains(api.SubscribableEventTypes, eventType) && eventType != api.ChangeEventIncidentDeleted {
+			respondWithError(context, api.ErrorCodeInvalidBody, "unknown event type "+string(eventType), nil)
+			return
+		}
+	}
+	// The subscriptions of a tenant are limited to the status pages it tracks when they are created
+	tracked, isTenant := tenantScope(ctx)
+	if isTenant && len(request.StatusPageUrls) == 0 {
+		respondWithError(context, api.ErrorCodeInvalidBody, "statusPageUrls is required for the api keys of a tenant", nil)
+		return
+	}
+	statusPageUrls := make([]string, 0, len(request.StatusPageUrls))
+	for _, statusPageUrl := range request.StatusPageUrls {
+		statusPageUrl = s.canonicalStatusPageUrl(statusPageUrl)
+		if _, found := s.statusPageCache.Get(statusPageUrl); !found || (isTenant && !tracked[statusPageUrl]) {
+			respondWithError(context, api.ErrorCodeStatusPageNotFound, "status page not known to statusphere", map[string]string{"statusPageUrl": statusPageUrl})
+			return
+		}
+		statusPageUrls = append(statusPageUrls, statusPageUrl)
+	}
+
+	id, err := randomHex(8)
+	if err != nil {
+		s.logger.Error("failed to generate subscription id", zap.Error(err))
+		respondWithInternalError(context, "failed to generate subscription")
+		return
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		s.logger.Error("failed to generate subscription secret", zap.Error(err))
+		respondWithInternalError(context, "failed to generate subscription")
+		return
+	}
+	subscription := api.Subscription{
+		ID:             id,
+		APIKeyID:       apiKey.ID,
+		URL:            request.URL,
+		Secret:         secret,
+		StatusPageUrls: statusPageUrls,
+		Impacts:        request.Impacts,
+		EventTypes:     request.EventTypes,
+		CreatedAt:      time.Now().UTC(),
+	}
+	err = s.dbClient.CreateSubscription(ctx, subscription)
+	if err != nil {
+		s.logger.Error("failed to create subscription", zap.Error(err), zap.String("apiKeyId", apiKey.ID))
+		respondWithInternalError(context, "failed to create subscription")
+		return
+	}
+	s.logger.Info("created subscription", zap.String("id", subscription.ID), zap.String("apiKeyId", apiKey.ID), zap.String("url", subscription.URL))
+	context.JSON(http.StatusOK, CreateSubscriptionResponse{Subscription: subscription, Secret: secret})
+}
+
+// subscriptions is a handler for the GET /subscriptions endpoint, it requires an api key.
+// It lists the subscriptions of the api key
+func (s *Server) subscriptions(context *gin.Context) {
+	apiKey := requestAPIKey(context)
+	subscriptions, err := s.dbClient.GetSubscriptions(context.Request.Context(), apiKey.ID)
+	if err != nil {
+		s.logger.Error("failed to get subscriptions", zap.Error(err), zap.String("apiKeyId", apiKey.ID))
+		respondWithInternalError(context, "failed to get subscriptions")
+		return
+	}
+	if subscriptions == nil {
+		subscriptions = []api.Subscription{}
+	}
+	context.JSON(http.StatusOK, SubscriptionsResponse{Subscriptions: subscriptions})
+}
+
+// deleteSubscription is a handler for the DELETE /subscriptions endpoint, it requires an api key.
+// It has a required query parameter of id, the pending deliveries of the subscription are dropped
+func (s *Server) deleteSubscription(context *gin.Context) {
+	ctx := context.Request.Context()
+	subscription, found := s.getSubscription(context)
+	if !found {
+		return
+	}
+	err := s.dbClient.DeleteSubscription(ctx, subscription.ID)
+	if err != nil {
+		s.logger.Error("failed to delete subscription", zap.Error(err), zap.String("id", subscription.ID))
+		respondWithInternalError(context, "failed to delete subscription")
+		return
+	}
+	s.logger.Info("deleted subscription", zap.String("id", subscription.ID))
+	context.JSON(http.StatusOK, SubscriptionResponse{Subscription: *subscription})
+}
+
+// subscriptionDeliveries is a handler for the GET /subscriptions/deliveries endpoint, it requires an api key.
+// It has a required query parameter of id and an optional limit, and returns the latest deliveries newest first
+func (s *Server) subscriptionDeliveries(context *gin.Context) {
+	limit := defaultDeliveriesLimit
+	if limitStr := context.Query("limit"); limitStr != "" {
+		limitInt, err := strconv.Atoi(limitStr)
+		if err != nil || limitInt < 1 || limitInt > maxDeliveriesLimit {
+			respondWithInvalidParameter(context, "limit", "limit must be an integer between 1 and "+strconv.Itoa(maxDeliveriesLimit))
+			return
+		}
+		limit = limitInt
+	}
+	subscription, found := s.getSubscription(context)
+	if !found {
+		return
+	}
+	deliveries, err := s.dbClient.GetWebhookDeliveries(context.Request.Context(), subscription.ID, limit)
+	if err != nil {
+		s.logger.Error("failed to get webhook deliveries", zap.Error(err), zap.String("id", subscription.ID))
+		respondWithInternalError(context, "failed to get webhook deliveries")
+		return
+	}
+	if deliveries == nil {
+		deliveries = []api.WebhookDelivery{}
+	}
+	context.JSON(http.StatusOK, WebhookDeliveriesResponse{Deliveries: deliveries})
+}
+
+// getSubscription returns the subscription of the id query parameter, it responds with an error if the api key
+// doesn't have a subscription with that id
+func (s *Server) getSubscription(context *gin.Context) (*api.Subscription, bool) {
+	id := context.Query("id")
+	if id == "" {
+		respondWithMissingParameter(context, "id", "id is required")
+		return nil, false
+	}
+	apiKey := requestAPIKey(context)
+	subscription, err := s.dbClient.GetSubscription(context.Request.Context(), apiKey.ID, id)
+	if err != nil {
+		s.logger.Error("failed to get subscription", zap.Error(err), zap.String("id", id))
+		respondWithInternalError(context, "failed to get subscription")
+		return nil, false
+	}
+	if subscription == nil {
+		respondWithError(context, api.ErrorCodeNotFound, "subscription not found", map[string]string{"parameter": "id"})
+		return nil, false
+	}
+	return subscription, true
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/patrickmn/go-cache"
+	"go.uber.org/zap"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+type StatusPageSummary struct {
+	StatusPageUrl string `json:"statusPageUrl"`
+	Name          string `json:"name"`
+	Status        Status `json:"status"`
+	IsIndexed     bool   `json:"isIndexed"`
+	// Tags are the tags of the status page, the ones of the tenant for the requests of a tenant
+	Tags []string `json:"tags"`
+	// OpenIncidents are the current incidents of the status page, most recent first
+	OpenIncidents []api.Incident `json:"openIncidents"`
+}
+
+type SummaryResponse struct {
+	// Counts is the number of status pages in each status
+	Counts      map[Status]int      `json:"counts"`
+	StatusPages []StatusPageSummary `json:"statusPages"`
+	GeneratedAt time.Time           `json:"generatedAt"`
+}
+
+const summaryCacheKey = "summary"
+
+// summary is a handler for the /summary endpoint.
+// It returns the current status and open incidents of every status page, or of the ones with one of the comma separated
+// tags of the tag parameter, ordered by name
+func (s *Server) summary(context *gin.Context) {
+	matchesTag, ok := s.tagFilter(context)
+	if !ok {
+		return
+	}
+	summaries, ok := s.statusPageSummaries(context)
+	if !ok {
+		return
+	}
+	response := SummaryResponse{
+		Counts:      map[Status]int{StatusUp: 0, StatusDegraded: 0, StatusUnknown: 0},
+		StatusPages: []StatusPageSummary{},
+		GeneratedAt: time.Now().UTC(),
+	}
+	for _, summary := range summaries {
+		if !matchesTag(summary.StatusPageUrl) {
+			continue
+		}
+		response.Counts[summary.Status]++
+		response.StatusPages = append(response.StatusPages, summary)
+	}
+	context.JSON(http.StatusOK, response)
+}
+
+// statusPageSummaries returns the current status, open incidents and tags of the status pages the request includes,
+// ordered by name. The open incidents of all the status pages are read with a single query and cached for a minute
+// It returns false if they couldn't be read, in which case the error has been written
+func (s *Server) statusPageSummaries(context *gin.Context) ([]StatusPageSummary, bool) {
+	ctx := context.Request.Context()
+	incidentsByStatusPage, err := s.getAllCurrentIncidents(ctx)
+	if err != nil {
+		s.logger.Error("failed to get current incidents", zap.Error(err))
+		respondWithInternalError(context, "failed to get current incidents")
+		return nil, false
+	}
+
+	tags, err := s.getStatusPageTags(ctx)
+	if err != nil {
+		s.logger.Error("failed to get status page tags", zap.Error(err))
+		respondWithInternalError(context, "failed to get status page tags")
+		return nil, false
+	}
+
+	summaries := []StatusPageSummary{}
+	for _, item := range s.statusPageCache.Items() {
+		statusPage := item.Object.(api.StatusPage)
+		if !includeStatusPage(context, statusPage) {
+			continue
+		}
+		summary := StatusPageSummary{
+			StatusPageUrl: statusPage.URL,
+			Name:          statusPage.Name,
+			Status:        StatusUnknown,
+			IsIndexed:     statusPage.IsIndexed,
+			Tags:          []string{},
+			OpenIncidents: []api.Incident{},
+		}
+		if statusPageTags, found := tags[statusPage.URL]; found {
+			summary.Tags = statusPageTags
+		}
+		if statusPage.IsIndexed {
+			summary.Status = StatusUp
+			if incidents := incidentsByStatusPage[statusPage.URL]; len(incidents) > 0 {
+				summary.Status = StatusDegraded
+				summary.OpenIncidents = incidents
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return strings.ToLower(summaries[i].Name) < strings.ToLower(summaries[j].Name)
+	})
+	return summaries, true
+}
+
+// getAllCurrentIncidents returns the current incidents of every status page by status page, most recent first
+// They are read with a single query and cached for a minute
+func (s *Server) getAllCurrentIncidents(ctx context.Context) (map[string][]api.Incident, error) {
+	var currentIncidents []api.Incident
+	if cached, found := s.summaryCache.Get(summaryCacheKey); found {
+		currentIncidents = cached.([]api.Incident)
+	} else {
+		var err error
+		currentIncidents, err = s.dbClient.GetAllCurrentIncidents(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.summaryCache.Set(summaryCacheKey, currentIncidents, cache.DefaultExpiration)
+	}
+	incidentsByStatusPage := map[string][]api.Incident{}
+	for _, incident := range currentIncidents {
+		incidentsByStatusPage[incident.StatusPageUrl] = append(incidentsByStatusPage[incident.StatusPageUrl], incident)
+	}
+	return incidentsByStatusPage, nil
+}
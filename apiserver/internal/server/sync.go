@@ -0,0 +1,171 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+type SyncStatus struct {
+	StatusPageUrl string `json:"statusPageUrl"`
+	Status        Status `json:"status"`
+}
+
+type SyncResponse struct {
+	// Cursor is passed as since to the next sync
+	Cursor string `json:"cursor"`
+	// HasMore is true if there are more changes after the cursor, the client should sync again straight away
+	HasMore bool `json:"hasMore"`
+	// ResyncRequired is true if the changes since the cursor are no longer retained
+	// The client has to refetch everything through the other endpoints and then sync from the returned cursor
+	ResyncRequired bool `json:"resyncRequired"`
+	// Incidents holds the latest version of every incident that was created or changed
+	Incidents []api.Incident `json:"incidents"`
+	// DeletedIncidents holds the deep links of the incidents that were deleted
+	DeletedIncidents []string `json:"deletedIncidents"`
+	// StatusPages holds the status pages that had incident changes
+	StatusPages []api.StatusPage `json:"statusPages"`
+	// Statuses holds the current status of the status pages that had incident changes
+	Statuses []SyncStatus `json:"statuses"`
+}
+
+const syncLimit = 500
+
+// sync is a handler for the /sync endpoint.
+// It has an optional query parameter of since, the cursor returned by the previous sync
+// It returns the incidents that were created, updated or deleted since the cursor along with the status pages they
+// belong to and their current status, so clients can keep a local copy up to date without refetching everything.
+// Without since, or if since is older than the retained changes, it returns resyncRequired and the latest cursor.
+func (s *Server) sync(context *gin.Context) {
+	ctx := context.Request.Context()
+
+	oldest, latest, err := s.dbClient.GetChangeEventCursorRange(ctx)
+	if err != nil {
+		s.logger.Error("failed to get change event range", zap.Error(err))
+		respondWithInternalError(context, "failed to get changes")
+		return
+	}
+
+	sinceStr := context.Query("since")
+	if sinceStr == "" {
+		context.JSON(http.StatusOK, SyncResponse{Cursor: latest.String(), ResyncRequired: true})
+		return
+	}
+	since, stale, err := parseChangeEventCursor(sinceStr)
+	if err != nil {
+		respondWithInvalidParameter(context, "since", "since must be a cursor returned by a previous sync")
+		return
+	}
+	if stale || latest.Before(since) || (!oldest.IsZero() && since.Before(oldest)) {
+		context.JSON(http.StatusOK, SyncResponse{Cursor: latest.String(), ResyncRequired: true})
+		return
+	}
+
+	events, err := s.dbClient.GetChangeEventsSince(ctx, since, syncLimit)
+	if err != nil {
+		s.logger.Error("failed to get change events", zap.Error(err), zap.String("since", sinceStr))
+		respondWithInternalError(context, "failed to get changes")
+		return
+	}
+
+	response := SyncResponse{
+		Cursor:           sinceStr,
+		HasMore:          len(events) == syncLimit,
+		Incidents:        []api.Incident{},
+		DeletedIncidents: []string{},
+		StatusPages:      []api.StatusPage{},
+		Statuses:         []SyncStatus{},
+	}
+	if len(events) > 0 {
+		response.Cursor = events[len(events)-1].Cursor().String()
+	}
+
+	// Only the latest change to each incident matters to the client
+	latestEvents := make(map[string]api.ChangeEvent)
+	var deepLinks []string
+	for _, event := range events {
+		if _, found := latestEvents[event.DeepLink]; !found {
+			deepLinks = append(deepLinks, event.DeepLink)
+		}
+		latestEvents[event.DeepLink] = event
+	}
+
+	statusPageUrls := make(map[string]bool)
+	for _, deepLink := range deepLinks {
+		event := latestEvents[deepLink]
+		statusPage, found := s.getStatusPageFromCache(event.StatusPageUrl)
+		if !found {
+			// The page was added since the cache was refreshed, or has been deleted
+			stored, err := s.dbClient.GetStatusPage(ctx, event.StatusPageUrl)
+			if err != nil {
+				s.logger.Error("failed to get status page", zap.Error(err), zap.String("statusPageUrl", event.StatusPageUrl))
+				respondWithInternalError(context, "failed to get status page")
+				return
+			}
+			if stored != nil {
+				statusPage, found = *stored, true
+			}
+		}
+		if found && !includeStatusPage(context, statusPage) {
+			continue
+		}
+		// The events of a deleted status page only go to the keys that aren't scoped to a tenant
+		if _, isTenant := tenantScope(ctx); !found && isTenant {
+			continue
+		}
+		if event.Type == api.ChangeEventIncidentDeleted {
+			response.DeletedIncidents = append(response.DeletedIncidents, deepLink)
+		} else {
+			response.Incidents = append(response.Incidents, api.Incident(event.Incident))
+		}
+		if !statusPageUrls[event.StatusPageUrl] {
+			statusPageUrls[event.StatusPageUrl] = true
+			if found {
+				response.StatusPages = append(response.StatusPages, statusPage)
+			}
+		}
+	}
+
+	for _, statusPage := range response.StatusPages {
+		status := StatusUnknown
+		if statusPage.IsIndexed {
+			incidents, found, err := s.getCurrentIncidentsFromCache(ctx, statusPage.URL)
+			if err != nil || !found {
+				incidents, _, err = s.getCurrentIncidentsFromDatabase(ctx, statusPage.URL)
+				if err != nil {
+					s.logger.Error("failed to get current incidents", zap.Error(err), zap.String("statusPageUrl", statusPage.URL))
+					respondWithInternalError(context, "failed to get current status")
+					return
+				}
+			}
+			status = StatusUp
+			if len(incidents) > 0 {
+				status = StatusDegraded
+			}
+		}
+		response.Statuses = append(response.Statuses, SyncStatus{StatusPageUrl: statusPage.URL, Status: status})
+	}
+
+	context.JSON(http.StatusOK, response)
+}
+
+// parseChangeEventCursor parses a cursor of /sync or an id of the incident stream. stale is true for the bare outbox ids
+// that were the cursors before the events were ordered by transaction, their position can't be told
+func parseChangeEventCursor(value string) (cursor api.ChangeEventCursor, stale bool, err error) {
+	if _, err := strconv.ParseUint(value, 10, 64); err == nil {
+		return api.ChangeEventCursor{}, true, nil
+	}
+	cursor, err = api.ParseChangeEventCursor(value)
+	return cursor, false, err
+}
+
+func (s *Server) getStatusPageFromCache(statusPageUrl string) (api.StatusPage, bool) {
+	statusPageInterface, found := s.statusPageCache.Get(statusPageUrl)
+	if !found {
+		return api.StatusPage{}, false
+	}
+	statusPage, ok := statusPageInterface.(api.StatusPage)
+	return statusPage, ok
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/patrickmn/go-cache"
+	"go.uber.org/zap"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxTagsPerStatusPage bounds the tags of a single status page
+const maxTagsPerStatusPage = 20
+
+// tagPattern is what a tag looks like once it is lower cased, e.g. payments or tier-1
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,49}$`)
+
+type SetStatusPageTagsRequest struct {
+	// Tags replace the tags of the status page, an empty list clears them
+	Tags []string `json:"tags"`
+}
+
+type StatusPageTagsResponse struct {
+	StatusPageUrl string   `json:"statusPageUrl"`
+	Tags          []string `json:"tags"`
+}
+
+type TagSummary struct {
+	Tag string `json:"tag"`
+	// Status is the worst status of the status pages with the tag
+	Status Status `json:"status"`
+	// Counts is the number of status pages with the tag in each status
+	Counts         map[Status]int `json:"counts"`
+	StatusPageUrls []string       `json:"statusPageUrls"`
+}
+
+type TagsResponse struct {
+	Tags []TagSummary `json:"tags"`
+}
+
+// setStatusPageTags is a handler for the PUT /statusPage/tags endpoint, which requires the admin token and sets the
+// shared tags, and for the PUT /tenant/tags endpoint, which requires an api key of a tenant and sets the tags of the
+// tenant. It has a required query parameter of statusPageUrl and replaces the tags of the status page with the body
+func (s *Server) setStatusPageTags(context *gin.Context) {
+	ctx := context.Request.Context()
+	statusPageUrl := s.canonicalStatusPageUrl(context.Query("statusPageUrl"))
+	if statusPageUrl == "" {
+		respondWithMissingParameter(context, "statusPageUrl", "statusPageUrl is required")
+		return
+	}
+	if _, found := s.getStatusPageFromCache(statusPageUrl); !found {
+		respondWithStatusPageNotFound(context)
+		return
+	}
+
+	var request SetStatusPageTagsRequest
+	decoder := json.NewDecoder(context.Request.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&request)
+	if err != nil {
+		respondWithError(context, api.ErrorCodeInvalidBody, "the body must be a list of tags: "+err.Error(), nil)
+		return
+	}
+	tags, reason := normalizeTags(request.Tags)
+	if reason != "" {
+		respondWithError(context, api.ErrorCodeInvalidBody, reason, nil)
+		return
+	}
+
+	err = s.dbClient.SetStatusPageTags(ctx, statusPageUrl, tags)
+	if err != nil {
+		s.logger.Error("failed to set status page tags", zap.Error(err), zap.String("statusPageUrl", statusPageUrl))
+		respondWithInternalError(context, "failed to set status page tags")
+		return
+	}
+	tenantID, _ := db.TenantFromContext(ctx)
+	s.logger.Info("set status page tags", zap.String("tenantId", tenantID), zap.String("statusPageUrl", statusPageUrl), zap.Strings("tags", tags))
+	s.tagCache.Delete(tenantID)
+	context.JSON(http.StatusOK, StatusPageTagsResponse{StatusPageUrl: statusPageUrl, Tags: tags})
+}
+
+// normalizeTags lower cases and deduplicates the tags and sorts them, it returns why they are invalid if they are
+func normalizeTags(tags []string) ([]string, string) {
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !tagPattern.MatchString(tag) {
+			return nil, "tags must be 1 to 50 letters, digits, '-', '_' or '.', starting with a letter or digit: " + tag
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > maxTagsPerStatusPage {
+		return nil, "a status page can have at most 20 tags"
+	}
+	sort.Strings(normalized)
+	return normalized, ""
+}
+
+// tags is a handler for the /tags endpoint.
+// It returns every tag with the status of its status pages, the requests of a tenant get the tags of the tenant
+func (s *Server) tags(context *gin.Context) {
+	summaries, ok := s.statusPageSummaries(context)
+	if !ok {
+		return
+	}
+	byTag := map[string]*TagSummary{}
+	for _, summary := range summaries {
+		for _, tag := range summary.Tags {
+			tagSummary, found := byTag[tag]
+			if !found {
+				tagSummary = &TagSummary{Tag: tag, Status: StatusUp, Counts: map[Status]int{StatusUp: 0, StatusDegraded: 0, StatusUnknown: 0}, StatusPageUrls: []string{}}
+				byTag[tag] = tagSummary
+			}
+			tagSummary.Counts[summary.Status]++
+			tagSummary.StatusPageUrls = append(tagSummary.StatusPageUrls, summary.StatusPageUrl)
+		}
+	}
+	response := TagsResponse{Tags: []TagSummary{}}
+	for _, tagSummary := range byTag {
+		// A degraded status page is worse than one whose status is unknown
+		if tagSummary.Counts[StatusDegraded] > 0 {
+			tagSummary.Status = StatusDegraded
+		} else if tagSummary.Counts[StatusUnknown] > 0 {
+			tagSummary.Status = StatusUnknown
+		}
+		response.Tags = append(response.Tags, *tagSummary)
+	}
+	sort.Slice(response.Tags, func(i, j int) bool {
+		return response.Tags[i].Tag < response.Tags[j].Tag
+	})
+	context.JSON(http.StatusOK, response)
+}
+
+// getStatusPageTags returns the tags of each status page, they are the tags of the tenant of the request or the shared
+// tags, and are cached for a minute
+func (s *Server) getStatusPageTags(ctx context.Context) (map[string][]string, error) {
+	tenantID, _ := db.TenantFromContext(ctx)
+	if cached, found := s.tagCache.Get(tenantID); found {
+		return cached.(map[string][]string), nil
+	}
+	tags, err := s.dbClient.GetStatusPageTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byStatusPage := map[string][]string{}
+	for _, tag := range tags {
+		byStatusPage[tag.StatusPageUrl] = append(byStatusPage[tag.StatusPageUrl], tag.Tag)
+	}
+	s.tagCache.Set(tenantID, byStatusPage, cache.DefaultExpiration)
+	return byStatusPage, nil
+}
+
+// tagFilter returns whether a status page has one of the comma separated tags of the tag query parameter, every status
+// page matches if it isn't set. It returns false if the tags couldn't be read, in which case the error has been written
+func (s *Server) tagFilter(context *gin.Context) (func(statusPageUrl string) bool, bool) {
+	wanted := map[string]bool{}
+	for _, tag := range strings.Split(context.Query("tag"), ",") {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			wanted[tag] = true
+		}
+	}
+	if len(wanted) == 0 {
+		return func(string) bool { return true }, true
+	}
+	tags, err := s.getStatusPageTags(context.Request.Context())
+	if err != nil {
+		s.logger.Error("failed to get status page tags", zap.Error(err))
+		respondWithInternalError(context, "failed to get status page tags")
+		return nil, false
+	}
+	return func(statusPageUrl string) bool {
+		for _, tag := range tags[statusPageUrl] {
+			if wanted[tag] {
+				return true
+			}
+		}
+		return false
+	}, true
+}
@@ -0,0 +1,356 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/patrickmn/go-cache"
+	"go.uber.org/zap"
+	"net/http"
+	neturl "net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxTrackedStatusPages bounds the status pages that are tracked by a single request
+const maxTrackedStatusPages = 500
+
+// tenantStatusPagesKey is the key of the status pages that the tenant of the request tracks in the request context
+type tenantStatusPagesKey struct{}
+
+type CreateTenantRequest struct {
+	Name string `json:"name"`
+}
+
+type TenantResponse struct {
+	Tenant api.Tenant `json:"tenant"`
+}
+
+type TenantsResponse struct {
+	Tenants []api.Tenant `json:"tenants"`
+}
+
+type TrackStatusPagesRequest struct {
+	// StatusPageUrls are tracked by the tenant, the ones that aren't known to statusphere yet are added
+	StatusPageUrls []string `json:"statusPageUrls"`
+}
+
+type TenantStatusPagesResponse struct {
+	StatusPages []api.StatusPage `json:"statusPages"`
+}
+
+// createTenant is a handler for the POST /tenants endpoint, it requires the admin token.
+// Api keys are issued to the tenant with its id, see createAPIKey
+func (s *Server) createTenant(context *gin.Context) {
+	ctx := context.Request.Context()
+	var request CreateTenantRequest
+	decoder := json.NewDecoder(context.Request.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&request)
+	if err != nil {
+		respondWithError(context, api.ErrorCodeInvalidBody, "the body must be a tenant request: "+err.Error(), nil)
+		return
+	}
+	if request.Name == "" {
+		respondWithError(context, api.ErrorCodeInvalidBody, "name is required", nil)
+		return
+	}
+	id, err := randomHex(8)
+	if err != nil {
+		s.logger.Error("failed to generate tenant id", zap.Error(err))
+		respondWithInternalError(context, "failed to generate tenant")
+		return
+	}
+	tenant := api.Tenant{ID: id, Name: request.Name, CreatedAt: time.Now().UTC()}
+	err = s.dbClient.CreateTenant(ctx, tenant)
+	if err != nil {
+		s.logger.Error("failed to create tenant", zap.Error(err), zap.String("name", request.Name))
+		respondWithInternalError(context, "failed to create tenant")
+		return
+	}
+	s.logger.Info("created tenant", zap.String("id", tenant.ID), zap.String("name", tenant.Name))
+	context.JSON(http.StatusOK, TenantResponse{Tenant: tenant})
+}
+
+// tenants is a handler for the GET /tenants endpoint, it requires the admin token.
+func (s *Server) tenants(context *gin.Context) {
+	tenants, err := s.dbClient.GetTenants(context.Request.Context())
+	if err != nil {
+		s.logger.Error("failed to get tenants", zap.Error(err))
+		respondWithInternalError(context, "failed to get tenants")
+		return
+	}
+	if tenants == nil {
+		tenants = []api.Tenant{}
+	}
+	context.JSON(http.StatusOK, TenantsResponse{Tenants: tenants})
+}
+
+// deleteTenant is a handler for the DELETE /tenants endpoint, it requires the admin token.
+// It has a required query parameter of id. The api keys of the tenant are revoked, its status pages are kept
+func (s *Server) deleteTenant(context *gin.Context) {
+	ctx := context.Request.Context()
+	id := context.Query("id")
+	if id == "" {
+		respondWithMissingParameter(context, "id", "id is required")
+		return
+	}
+	if id == api.SandboxTenantID {
+		respondWithError(context, api.ErrorCodeInvalidParameter, "the sandbox tenant is built in and can't be deleted", map[string]string{"parameter": "id"})
+		return
+	}
+	tenant, err := s.dbClient.GetTenant(ctx, id)
+	if err != nil {
+		s.logger.Error("failed to get tenant", zap.Error(err), zap.String("id", id))
+		respondWithInternalError(context, "failed to get tenant")
+		return
+	}
+	if tenant == nil {
+		respondWithError(context, api.ErrorCodeNotFound, "tenant not found", map[string]string{"parameter": "id"})
+		return
+	}
+	err = s.dbClient.DeleteTenant(ctx, id, time.Now().UTC())
+	if err != nil {
+		s.logger.Error("failed to delete tenant", zap.Error(err), zap.String("id", id))
+		respondWithInternalError(context, "failed to delete tenant")
+		return
+	}
+	s.logger.Info("deleted tenant", zap.String("id", id))
+	s.apiKeyCache.Flush()
+	s.tenantCache.Delete(id)
+	s.tagCache.Delete(id)
+	context.JSON(http.StatusOK, TenantResponse{Tenant: *tenant})
+}
+
+// tenantStatusPages is a handler for the GET /tenant/statusPages endpoint, it requires an api key of a tenant.
+// It lists the status pages that the tenant tracks
+func (s *Server) tenantStatusPages(context *gin.Context) {
+	tenantID, ok := requireTenant(context)
+	if !ok {
+		return
+	}
+	urls, err := s.getTenantStatusPages(context.Request.Context(), tenantID)
+	if err != nil {
+		s.logger.Error("failed to get tenant status pages", zap.Error(err), zap.String("tenantId", tenantID))
+		respondWithInternalError(context, "failed to get tracked status pages")
+		return
+	}
+	statusPages := []api.StatusPage{}
+	for url := range urls {
+		if statusPage, found := s.getStatusPageFromCache(url); found {
+			statusPages = append(statusPages, statusPage)
+		}
+	}
+	sort.Slice(statusPages, func(i, j int) bool {
+		return strings.ToLower(statusPages[i].Name) < strings.ToLower(statusPages[j].Name)
+	})
+	context.JSON(http.StatusOK, TenantStatusPagesResponse{StatusPages: statusPages})
+}
+
+// trackStatusPages is a handler for the POST /tenant/statusPages endpoint, it requires an api key of a tenant.
+// The tenant starts tracking the status pages of the body. A status page that isn't known to statusphere is added, its
+// provider is detected when it is first scraped. A status page tracked by several tenants is only scraped once
+func (s *Server) trackStatusPages(context *gin.Context) {
+	ctx := context.Request.Context()
+	tenantID, ok := requireTenant(context)
+	if !ok {
+		return
+	}
+	if tenantID == api.SandboxTenantID {
+		respondWithError(context, api.ErrorCodeUnauthorized, "the sandbox tenant only tracks the sandbox status pages", nil)
+		return
+	}
+	var request TrackStatusPagesRequest
+	decoder := json.NewDecoder(context.Request.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&request)
+	if err != nil {
+		respondWithError(context, api.ErrorCodeInvalidBody, "the body must be a list of status page urls: "+err.Error(), nil)
+		return
+	}
+	if len(request.StatusPageUrls) == 0 {
+		respondWithError(context, api.ErrorCodeInvalidBody, "statusPageUrls is required", nil)
+		return
+	}
+	if len(request.StatusPageUrls) > maxTrackedStatusPages {
+		respondWithError(context, api.ErrorCodeInvalidBody, "at most "+strconv.Itoa(maxTrackedStatusPages)+" status pages can be tracked at once", nil)
+		return
+	}
+
+	var urls []string
+	for _, rawUrl := range request.StatusPageUrls {
+		url, reason := s.normalizeStatusPageUrl(rawUrl)
+		if reason != "" {
+			respondWithError(context, api.ErrorCodeInvalidBody, reason, map[string]string{"statusPageUrl": rawUrl})
+			return
+		}
+		urls = append(urls, url)
+	}
+	stored, err := s.dbClient.GetStatusPagesByUrls(ctx, urls)
+	if err != nil {
+		s.logger.Error("failed to get status pages", zap.Error(err), zap.String("tenantId", tenantID))
+		respondWithInternalError(context, "failed to get status pages")
+		return
+	}
+	existing := make(map[string]bool, len(stored))
+	for _, statusPage := range stored {
+		if statusPage.IsSandbox {
+			respondWithError(context, api.ErrorCodeInvalidBody, "the sandbox status pages can only be used with an api key of the sandbox tenant", map[string]string{"statusPageUrl": statusPage.URL})
+			return
+		}
+		existing[statusPage.URL] = true
+	}
+	var added []api.StatusPage
+	for _, url := range urls {
+		if !existing[url] {
+			existing[url] = true
+			parsed, _ := neturl.Parse(url)
+			added = append(added, api.StatusPage{URL: url, Name: parsed.Host})
+		}
+	}
+	err = s.dbClient.RegisterStatusPages(ctx, added)
+	if err != nil {
+		s.logger.Error("failed to register status pages", zap.Error(err), zap.String("tenantId", tenantID))
+		respondWithInternalError(context, "failed to register status pages")
+		return
+	}
+	err = s.dbClient.TrackStatusPages(ctx, tenantID, urls, time.Now().UTC())
+	if err != nil {
+		s.logger.Error("failed to track status pages", zap.Error(err), zap.String("tenantId", tenantID))
+		respondWithInternalError(context, "failed to track status pages")
+		return
+	}
+	s.logger.Info("tracked status pages", zap.String("tenantId", tenantID), zap.Int("statusPages", len(urls)), zap.Int("added", len(added)))
+	for _, statusPage := range added {
+		s.statusPageCache.Set(statusPage.URL, statusPage, cache.DefaultExpiration)
+	}
+	s.tenantCache.Delete(tenantID)
+	s.tenantStatusPages(context)
+}
+
+// untrackStatusPage is a handler for the DELETE /tenant/statusPages endpoint, it requires an api key of a tenant.
+// It has a required query parameter of statusPageUrl, the tenant stops tracking the status page. The status page is
+// kept, other tenants may track it
+func (s *Server) untrackStatusPage(context *gin.Context) {
+	ctx := context.Request.Context()
+	tenantID, ok := requireTenant(context)
+	if !ok {
+		return
+	}
+	if tenantID == api.SandboxTenantID {
+		respondWithError(context, api.ErrorCodeUnauthorized, "the sandbox tenant only tracks the sandbox status pages", nil)
+		return
+	}
+	statusPageUrl := s.canonicalStatusPageUrl(context.Query("statusPageUrl"))
+	if statusPageUrl == "" {
+		respondWithMissingParameter(context, "statusPageUrl", "statusPageUrl is required")
+		return
+	}
+	found, err := s.dbClient.UntrackStatusPage(ctx, tenantID, statusPageUrl)
+	if err != nil {
+		s.logger.Error("failed to untrack status page", zap.Error(err), zap.String("tenantId", tenantID), zap.String("statusPageUrl", statusPageUrl))
+		respondWithInternalError(context, "failed to untrack status page")
+		return
+	}
+	if !found {
+		respondWithStatusPageNotFound(context)
+		return
+	}
+	s.logger.Info("untracked status page", zap.String("tenantId", tenantID), zap.String("statusPageUrl", statusPageUrl))
+	s.tenantCache.Delete(tenantID)
+	s.tagCache.Delete(tenantID)
+	context.Status(http.StatusNoContent)
+}
+
+// scopeToTenant limits the requests made with an api key of a tenant to the status pages the tenant tracks, it runs
+// after rateLimit which authenticates the key. The queries of the request are scoped to the tenant, see db.WithTenant,
+// and a request for a single status page that the tenant doesn't track gets status_page_not_found
+func (s *Server) scopeToTenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		statusPageUrl := c.Query("statusPageUrl")
+		if statusPageUrl == "" {
+			statusPageUrl = embedStatusPageUrl(c.Param("statusPageUrl"))
+		}
+		if statusPageUrl != "" {
+			statusPageUrl = s.canonicalStatusPageUrl(statusPageUrl)
+		}
+		key, found := c.Get(apiKeyContextKey)
+		if !found || key.(api.APIKey).TenantID == "" {
+			// The sandbox status pages are only served to the api keys of the sandbox tenant
+			if statusPage, found := s.getStatusPageFromCache(statusPageUrl); found && statusPage.IsSandbox {
+				respondWithStatusPageNotFound(c)
+				return
+			}
+			c.Next()
+			return
+		}
+		tenantID := key.(api.APIKey).TenantID
+		urls, err := s.getTenantStatusPages(c.Request.Context(), tenantID)
+		if err != nil {
+			s.logger.Error("failed to get tenant status pages", zap.Error(err), zap.String("tenantId", tenantID))
+			respondWithInternalError(c, "failed to get tracked status pages")
+			return
+		}
+		ctx := context.WithValue(db.WithTenant(c.Request.Context(), tenantID), tenantStatusPagesKey{}, urls)
+		c.Request = c.Request.WithContext(ctx)
+
+		if statusPageUrl != "" && !urls[statusPageUrl] {
+			respondWithStatusPageNotFound(c)
+			return
+		}
+		if statusPage, found := s.getStatusPageFromCache(statusPageUrl); found && !visibleStatusPage(ctx, statusPage) {
+			respondWithStatusPageNotFound(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// tenantScope returns the status pages that the tenant of the request tracks, false if the request isn't of a tenant
+func tenantScope(ctx context.Context) (map[string]bool, bool) {
+	urls, found := ctx.Value(tenantStatusPagesKey{}).(map[string]bool)
+	return urls, found
+}
+
+// getTenantStatusPages returns the set of the status pages that the tenant tracks, it is cached for a minute
+func (s *Server) getTenantStatusPages(ctx context.Context, tenantID string) (map[string]bool, error) {
+	if cached, found := s.tenantCache.Get(tenantID); found {
+		return cached.(map[string]bool), nil
+	}
+	urls, err := s.dbClient.GetTenantStatusPageUrls(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]bool, len(urls))
+	for _, url := range urls {
+		set[url] = true
+	}
+	s.tenantCache.Set(tenantID, set, cache.DefaultExpiration)
+	return set, nil
+}
+
+// requireTenant returns the tenant of the api key of the request, it returns false if the key doesn't belong to a
+// tenant, in which case the error has been written
+func requireTenant(context *gin.Context) (string, bool) {
+	tenantID := requestAPIKey(context).TenantID
+	if tenantID == "" {
+		respondWithError(context, api.ErrorCodeUnauthorized, "the api key doesn't belong to a tenant", nil)
+		return "", false
+	}
+	return tenantID, true
+}
+
+// tenantOnly wraps a handler that is shared with the admin endpoints so that it only serves the api keys of a tenant,
+// the handler is then scoped to the tenant by the context
+func tenantOnly(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		if _, ok := requireTenant(context); !ok {
+			return
+		}
+		handler(context)
+	}
+}
@@ -0,0 +1,231 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"go.uber.org/zap"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultUptimePeriod = 90 * 24 * time.Hour
+	maxUptimePeriod     = 365 * 24 * time.Hour
+	// openIncidentWindow is how recently an incident without an end time has to have started to count as ongoing,
+	// older ones were dropped by the status page without ever being resolved
+	openIncidentWindow = 14 * 24 * time.Hour
+)
+
+type UptimeStats struct {
+	// AvailabilityPercent is the share of the period without a major or critical incident
+	AvailabilityPercent float64 `json:"availabilityPercent"`
+	// DowntimeSeconds is how long major or critical incidents were ongoing, overlapping incidents are counted once
+	DowntimeSeconds int64 `json:"downtimeSeconds"`
+	// DegradedSeconds is how long minor incidents were ongoing outside of the downtime
+	DegradedSeconds int64 `json:"degradedSeconds"`
+	// Incidents is the number of incidents that were ongoing during the period
+	Incidents int `json:"incidents"`
+}
+
+type ComponentUptime struct {
+	Name string `json:"name"`
+	UptimeStats
+}
+
+type UptimeResponse struct {
+	StatusPageUrl string    `json:"statusPageUrl"`
+	From          time.Time `json:"from"`
+	To            time.Time `json:"to"`
+	IsIndexed     bool      `json:"isIndexed"`
+	// Uptime is the uptime of the whole status page, any incident counts
+	Uptime UptimeStats `json:"uptime"`
+	// Components are the uptime of each component, from the incidents that affect it
+	Components []ComponentUptime `json:"components"`
+}
+
+// uptime is a handler for the /uptime endpoint.
+// It has a required query parameter of statusPageUrl and an optional period, e.g. 90d or 12h, which defaults to 90 days.
+// It computes the availability of the status page and its components over the last period from the stored incidents
+func (s *Server) uptime(context *gin.Context) {
+	ctx := context.Request.Context()
+	statusPageUrl := s.canonicalStatusPageUrl(context.Query("statusPageUrl"))
+	if statusPageUrl == "" {
+		respondWithMissingParameter(context, "statusPageUrl", "statusPageUrl is required")
+		return
+	}
+	period := defaultUptimePeriod
+	if periodStr := context.Query("period"); periodStr != "" {
+		parsed, ok := parsePeriod(periodStr)
+		if !ok || parsed <= 0 || parsed > maxUptimePeriod {
+			respondWithInvalidParameter(context, "period", "period must be a number of days such as 90d or a duration such as 12h, of at most 365d")
+			return
+		}
+		period = parsed
+	}
+	statusPageInterface, found := s.statusPageCache.Get(statusPageUrl)
+	if !found {
+		respondWithStatusPageNotFound(context)
+		return
+	}
+	statusPage, ok := statusPageInterface.(api.StatusPage)
+	if !ok {
+		respondWithInternalError(context, "failed to cast status page to api.StatusPage")
+		return
+	}
+
+	to := time.Now().UTC().Truncate(time.Second)
+	from := to.Add(-period)
+	response := UptimeResponse{StatusPageUrl: statusPageUrl, From: from, To: to, IsIndexed: statusPage.IsIndexed, Components: []ComponentUptime{}}
+	if !statusPage.IsIndexed {
+		response.Uptime = uptimeStats(nil, from, to)
+		context.JSON(http.StatusOK, response)
+		return
+	}
+
+	incidents, err := s.dbClient.GetIncidentsByQuery(ctx, statusPageUrl, db.IncidentQuery{StartedBefore: &to, EndedAfter: &from, Ascending: true})
+	if err != nil {
+		s.logger.Error("failed to get incidents", zap.Error(err), zap.String("statusPageUrl", statusPageUrl))
+		respondWithInternalError(context, "failed to get incidents")
+		return
+	}
+	components, err := s.dbClient.GetComponents(ctx, statusPageUrl)
+	if err != nil {
+		s.logger.Error("failed to get components", zap.Error(err), zap.String("statusPageUrl", statusPageUrl))
+		respondWithInternalError(context, "failed to get components")
+		return
+	}
+
+	var counted []api.Incident
+	byComponent := map[string][]api.Incident{}
+	for _, component := range components {
+		byComponent[component.Name] = nil
+	}
+	for _, incident := range incidents {
+		if incident.Impact == api.ImpactMaintenance {
+			continue
+		}
+		if incident.EndTime == nil && incident.StartTime.Before(to.Add(-openIncidentWindow)) {
+			continue
+		}
+		counted = append(counted, incident)
+		for _, component := range incident.Components {
+			byComponent[component] = append(byComponent[component], incident)
+		}
+	}
+
+	response.Uptime = uptimeStats(counted, from, to)
+	for name, componentIncidents := range byComponent {
+		response.Components = append(response.Components, ComponentUptime{Name: name, UptimeStats: uptimeStats(componentIncidents, from, to)})
+	}
+	sort.Slice(response.Components, func(i, j int) bool {
+		return response.Components[i].Name < response.Components[j].Name
+	})
+	context.JSON(http.StatusOK, response)
+}
+
+// parsePeriod parses a number of days such as 90d, or a Go duration such as 12h
+func parsePeriod(period string) (time.Duration, bool) {
+	if days, found := strings.CutSuffix(period, "d"); found {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, false
+		}
+		return time.Duration(n) * 24 * time.Hour, true
+	}
+	duration, err := time.ParseDuration(period)
+	if err != nil {
+		return 0, false
+	}
+	return duration, true
+}
+
+// uptimeStats computes the uptime of [from, to) from the incidents, ongoing incidents last until to
+func uptimeStats(incidents []api.Incident, from time.Time, to time.Time) UptimeStats {
+	var down, degraded []interval
+	for _, incident := range incidents {
+		span := interval{start: incident.StartTime, end: to}
+		if incident.EndTime != nil && incident.EndTime.Before(to) {
+			span.end = *incident.EndTime
+		}
+		if span.start.Before(from) {
+			span.start = from
+		}
+		if !span.start.Before(span.end) {
+			continue
+		}
+		if incident.Impact.Severity() >= api.ImpactMajor.Severity() {
+			down = append(down, span)
+		} else {
+			degraded = append(degraded, span)
+		}
+	}
+	down = mergeIntervals(down)
+	downtime := totalDuration(down)
+	// Time that was both down and degraded only counts as downtime
+	degradedTime := totalDuration(mergeIntervals(degraded)) - totalDuration(intersectIntervals(mergeIntervals(degraded), down))
+	period := to.Sub(from)
+	return UptimeStats{
+		AvailabilityPercent: float64(period-downtime) / float64(period) * 100,
+		DowntimeSeconds:     int64(downtime.Seconds()),
+		DegradedSeconds:     int64(degradedTime.Seconds()),
+		Incidents:           len(incidents),
+	}
+}
+
+type interval struct {
+	start time.Time
+	end   time.Time
+}
+
+// mergeIntervals returns the union of the intervals as disjoint intervals sorted by start
+func mergeIntervals(intervals []interval) []interval {
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].start.Before(intervals[j].start)
+	})
+	var merged []interval
+	for _, current := range intervals {
+		if len(merged) > 0 && !current.start.After(merged[len(merged)-1].end) {
+			if current.end.After(merged[len(merged)-1].end) {
+				merged[len(merged)-1].end = current.end
+			}
+			continue
+		}
+		merged = append(merged, current)
+	}
+	return merged
+}
+
+// intersectIntervals returns the overlap of two lists of disjoint intervals sorted by start
+func intersectIntervals(a []interval, b []interval) []interval {
+	var intersection []interval
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		start, end := a[i].start, a[i].end
+		if b[j].start.After(start) {
+			start = b[j].start
+		}
+		if b[j].end.Before(end) {
+			end = b[j].end
+		}
+		if start.Before(end) {
+			intersection = append(intersection, interval{start: start, end: end})
+		}
+		if a[i].end.Before(b[j].end) {
+			i++
+		} else {
+			j++
+		}
+	}
+	return intersection
+}
+
+func totalDuration(intervals []interval) time.Duration {
+	var total time.Duration
+	for _, i := range intervals {
+		total += i.end.Sub(i.start)
+	}
+	return total
+}
@@ -5,12 +5,14 @@ import (
 	"errors"
 	"github.com/metoro-io/statusphere/apiserver/internal/server"
 	"github.com/metoro-io/statusphere/common/db"
+	"github.com/metoro-io/statusphere/common/telemetry"
 	"github.com/metoro-io/statusphere/common/utils"
 	"go.uber.org/zap"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
 func main() {
@@ -22,13 +24,28 @@ func main() {
 		panic(err)
 	}
 
+	telemetryConfig, err := telemetry.GetConfigFromEnvironment()
+	if err != nil {
+		panic(err)
+	}
+	shutdownTelemetry, err := telemetry.Init(ctx, logger, telemetryConfig, "statusphere-apiserver")
+	if err != nil {
+		panic(err)
+	}
+
 	dbClient, err := db.NewDbClientFromEnvironment(logger)
 	if err != nil {
 		panic(err)
 	}
 
-	s := server.NewServer(logger, dbClient)
+	config, err := server.GetConfigFromEnvironment()
+	if err != nil {
+		panic(err)
+	}
+
+	s := server.NewServer(logger, dbClient, config)
 	s.StartCaches(ctx)
+	s.StartIncidentStream(ctx)
 
 	go func() {
 		if err := s.Serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -42,5 +59,10 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	sig := <-quit
 	logger.Info("Shutting down", zap.String("signal", sig.String()))
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelShutdown()
+	if err := shutdownTelemetry(shutdownCtx); err != nil {
+		logger.Warn("failed to flush telemetry", zap.Error(err))
+	}
 
 }
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/db"
+	"go.uber.org/zap"
+	"io"
+	"os"
+)
+
+const usage = `statusphere is a command line tool for operating a statusphere deployment
+
+Usage:
+  statusphere export [-o file]    dump all status pages and incidents as JSONL (stdout by default)
+  statusphere import [-i file]    load a JSONL dump (stdin by default)
+  statusphere correct-dst -url X [-force]  re-normalize incidents of a status page after its timezone has been configured,
+                                  if they are an hour off in daylight saving time or -force is set
+  statusphere backfill -url X     scrape the whole incident archive of a status page again, the scraper picks it up within minutes
+  statusphere scrape -url X       scrape a status page now and print its incidents, through the trigger of a running scraper
+  statusphere dedup               merge incidents that share a deep link and enforce a unique deep link
+  statusphere backup export -o file    write a consistent, checksummed snapshot of every table
+  statusphere backup import -i file    restore a snapshot, replacing the contents of every table in it
+
+The database is configured with the same STATUSPHERE_POSTGRES_* environment variables as the scraper and api server.
+scrape needs STATUSPHERE_SCRAPER_TRIGGER_URL and STATUSPHERE_API_ADMIN_TOKEN instead.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		panic(err)
+	}
+
+	err = run(context.Background(), logger, os.Args[1], os.Args[2:])
+	if err != nil {
+		logger.Error("command failed", zap.String("command", os.Args[1]), zap.Error(err))
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, logger *zap.Logger, command string, args []string) error {
+	switch command {
+	case "export":
+		flags := flag.NewFlagSet("export", flag.ExitOnError)
+		output := flags.String("o", "", "file to write the dump to, defaults to stdout")
+		_ = flags.Parse(args)
+		return export(ctx, logger, *output)
+	case "import":
+		flags := flag.NewFlagSet("import", flag.ExitOnError)
+		input := flags.String("i", "", "file to read the dump from, defaults to stdin")
+		_ = flags.Parse(args)
+		return load(ctx, logger, *input)
+	case "backup":
+		return backup(ctx, logger, args)
+	case "dedup":
+		return dedup(ctx, logger)
+	case "backfill":
+		flags := flag.NewFlagSet("backfill", flag.ExitOnError)
+		url := flags.String("url", "", "url of the status page to backfill")
+		_ = flags.Parse(args)
+		return backfill(ctx, logger, *url)
+	case "scrape":
+		flags := flag.NewFlagSet("scrape", flag.ExitOnError)
+		url := flags.String("url", "", "url of the status page to scrape")
+		_ = flags.Parse(args)
+		return scrape(ctx, *url)
+	case "correct-dst":
+		flags := flag.NewFlagSet("correct-dst", flag.ExitOnError)
+		url := flags.String("url", "", "url of the status page to correct")
+		force := flags.Bool("force", false, "correct the incidents even if they don't show the drift")
+		_ = flags.Parse(args)
+		return correctDST(ctx, logger, *url, *force)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	return nil
+}
+
+func export(ctx context.Context, logger *zap.Logger, output string) error {
+	dbClient, err := db.NewDbClientFromEnvironment(logger)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return dbClient.DumpToJSONL(ctx, w)
+}
+
+func load(ctx context.Context, logger *zap.Logger, input string) error {
+	dbClient, err := db.NewDbClientFromEnvironment(logger)
+	if err != nil {
+		return err
+	}
+	err = dbClient.AutoMigrate(ctx)
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if input != "" {
+		f, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	return dbClient.LoadFromJSONL(ctx, r)
+}
+
+func correctDST(ctx context.Context, logger *zap.Logger, url string, force bool) error {
+	if url == "" {
+		return errors.New("-url is required")
+	}
+	dbClient, err := db.NewDbClientFromEnvironment(logger)
+	if err != nil {
+		return err
+	}
+	_, err = dbClient.CorrectDSTDrift(ctx, url, force)
+	return err
+}
+
+func backfill(ctx context.Context, logger *zap.Logger, url string) error {
+	if url == "" {
+		return errors.New("-url is required")
+	}
+	dbClient, err := db.NewDbClientFromEnvironment(logger)
+	if err != nil {
+		return err
+	}
+	return dbClient.RequestBackfill(ctx, url)
+}
+
+func dedup(ctx context.Context, logger *zap.Logger) error {
+	dbClient, err := db.NewDbClientFromEnvironment(logger)
+	if err != nil {
+		return err
+	}
+	_, err = dbClient.Deduplicate(ctx)
+	return err
+}
+
+func backup(ctx context.Context, logger *zap.Logger, args []string) error {
+	if len(args) < 1 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	flags := flag.NewFlagSet("backup "+args[0], flag.ExitOnError)
+	path := flags.String("o", "", "file to write the snapshot to")
+	if args[0] == "import" {
+		path = flags.String("i", "", "file to read the snapshot from")
+	}
+	_ = flags.Parse(args[1:])
+	if *path == "" {
+		return errors.New("a snapshot file is required")
+	}
+
+	dbClient, err := db.NewDbClientFromEnvironment(logger)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "export":
+		f, err := os.Create(*path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		manifest, err := dbClient.ExportBackup(ctx, f)
+		if err != nil {
+			return err
+		}
+		for _, table := range manifest.Tables {
+			logger.Info("exported table", zap.String("table", table.Name), zap.Int64("rows", table.Rows), zap.String("sha256", table.Sha256))
+		}
+		return nil
+	case "import":
+		err = dbClient.AutoMigrate(ctx)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(*path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = dbClient.ImportBackup(ctx, f)
+		return err
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/metoro-io/statusphere/common/api"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strings"
+)
+
+type scrapeTriggerConfig struct {
+	// URL is where a scraper serves its scrape trigger, e.g. http://scraper:8081
+	URL   string `envconfig:"SCRAPER_TRIGGER_URL" required:"true"`
+	Token string `envconfig:"API_ADMIN_TOKEN" required:"true"`
+}
+
+// scrape asks a scraper to scrape the status page now and prints the incidents it scraped
+func scrape(ctx context.Context, url string) error {
+	if url == "" {
+		return errors.New("-url is required")
+	}
+	var config scrapeTriggerConfig
+	err := envconfig.Process("STATUSPHERE", &config)
+	if err != nil {
+		return err
+	}
+
+	endpoint := strings.TrimSuffix(config.URL, "/") + "/api/v1/scrape?url=" + neturl.QueryEscape(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+config.Token)
+	req.Header.Set("Accept", "application/json, "+api.ProblemContentType)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var problem api.Problem
+		if json.Unmarshal(body, &problem) == nil && problem.Code != "" {
+			return fmt.Errorf("%s: %s", problem.Code, problem.Detail)
+		}
+		return fmt.Errorf("scrape trigger returned %s", resp.Status)
+	}
+	_, err = os.Stdout.Write(body)
+	return err
+}
@@ -16,6 +16,21 @@ const (
 	ImpactNone        Impact = "none"
 )
 
+// ImpactsBySeverity lists the known impacts from least to most severe
+var ImpactsBySeverity = []Impact{ImpactNone, ImpactMaintenance, ImpactMinor, ImpactMajor, ImpactCritical}
+
+// Severity orders impacts from least to most severe, it returns -1 for unknown impacts
+func (i Impact) Severity() int {
+	for severity, impact := range ImpactsBySeverity {
+		if impact == i {
+			return severity
+		}
+	}
+	return -1
+}
+
+// IncidentEventArray is the legacy free form representation of incident updates
+// Deprecated: use IncidentUpdateArray
 type IncidentEventArray []IncidentEvent
 
 func (sla *IncidentEventArray) Scan(src interface{}) error {
@@ -27,6 +42,8 @@ func (sla IncidentEventArray) Value() (driver.Value, error) {
 	return string(val), err
 }
 
+// IncidentEvent is the legacy free form representation of an incident update
+// Deprecated: use IncidentUpdate
 type IncidentEvent struct {
 	Title       string    `json:"title"`
 	Description string    `json:"description"`
@@ -42,18 +59,42 @@ func NewIncidentEvent(title string, description string, time time.Time) Incident
 }
 
 type Incident struct {
-	Title         string             `json:"title"`
-	Components    []string           `gorm:"column:components;type:jsonb" json:"components"`
-	Events        IncidentEventArray `gorm:"column:events;type:jsonb" json:"events"`
-	StartTime     time.Time          `gorm:"secondarykey" json:"startTime"`
-	EndTime       *time.Time         `gorm:"secondarykey" json:"endTime"`
-	Description   *string            `json:"description"`
-	DeepLink      string             `gorm:"primarykey" json:"deepLink"`
-	Impact        Impact             `gorm:"secondarykey" json:"impact"`
-	StatusPageUrl string             `gorm:"secondarykey" json:"statusPageUrl"`
+	Title       string              `json:"title"`
+	Components  []string            `gorm:"column:components;type:jsonb;serializer:json" json:"components"`
+	Events      IncidentUpdateArray `gorm:"column:events;type:jsonb" json:"events"`
+	StartTime   time.Time           `gorm:"secondarykey" json:"startTime"`
+	EndTime     *time.Time          `gorm:"secondarykey" json:"endTime"`
+	Description *string             `json:"description"`
+	// DescriptionText and DescriptionHTML are the description as plaintext and as html that is safe to render
+	// Description is kept as the provider scraped it, which is raw html for most providers
+	DescriptionText *string `json:"descriptionText,omitempty"`
+	DescriptionHTML *string `gorm:"column:description_html" json:"descriptionHtml,omitempty"`
+	DeepLink        string  `gorm:"primarykey" json:"deepLink"`
+	Impact          Impact  `gorm:"secondarykey" json:"impact"`
+	// EndTimeInferred is true if the status page never gave the incident an end time, and EndTime is when the incident
+	// was first missing from the ongoing incidents of the status page
+	EndTimeInferred bool `json:"endTimeInferred,omitempty"`
+	// RawImpact is the severity the status page gave the incident before it was normalized to Impact, e.g. partial_outage
+	// It is empty if the provider derives the impact rather than reading it from the status page
+	RawImpact     string `json:"rawImpact,omitempty"`
+	StatusPageUrl string `gorm:"secondarykey" json:"statusPageUrl"`
+	// Language is the ISO 639-1 code of the language the incident is written in, empty if it couldn't be detected
+	Language string `json:"language,omitempty"`
+	// TranslatedTitle and TranslatedDescription are set if the incident is not in the language the scraper translates to
+	// and a translator is configured
+	TranslatedTitle       *string `json:"translatedTitle,omitempty"`
+	TranslatedDescription *string `json:"translatedDescription,omitempty"`
+	// UpdatedAt is when the stored incident last changed, a scrape that finds it unchanged leaves it as it is
+	UpdatedAt time.Time `gorm:"default:now()" json:"updatedAt"`
+	// Provider is the name of the provider that scraped the incident, it isn't stored
+	Provider string `gorm:"-" json:"-"`
+	// ScheduledStart and ScheduledEnd are the planned window of a maintenance, if the provider knows it
+	// They are not stored on the incident, maintenances are stored separately, see MaintenanceFromIncident
+	ScheduledStart *time.Time `gorm:"-" json:"-"`
+	ScheduledEnd   *time.Time `gorm:"-" json:"-"`
 }
 
-func NewIncident(title string, components []string, events []IncidentEvent, startTime time.Time, endTime *time.Time, description *string, deepLink string, impact Impact, statusPageUrl string) Incident {
+func NewIncident(title string, components []string, events []IncidentUpdate, startTime time.Time, endTime *time.Time, description *string, deepLink string, impact Impact, statusPageUrl string) Incident {
 	return Incident{
 		Title:         title,
 		Components:    components,
@@ -70,13 +111,83 @@ func NewIncident(title string, components []string, events []IncidentEvent, star
 type StatusPage struct {
 	Name string `gorm:"secondarykey" json:"name"`
 	URL  string `gorm:"primarykey" json:"url"`
+	// Category and LogoURL are set for the status pages of the vendor catalog, e.g. Cloud or CI/CD
+	Category string `json:"category,omitempty"`
+	LogoURL  string `gorm:"column:logo_url" json:"logoUrl,omitempty"`
 	// Used to determine if we should run a scrape for this status page
 	LastHistoricallyScraped time.Time `json:"lastHistoricallyScraped"`
-	LastCurrentlyScraped    time.Time `json:"lastCurrentlyScraped"`
+	// BackfilledAt is when the whole incident archive of the status page was last scraped, nil if it never has been
+	// Status pages that haven't been backfilled are backfilled instead of being historically scraped
+	BackfilledAt *time.Time `json:"backfilledAt,omitempty"`
+	// BackfillCursor is where an unfinished backfill resumes from, it is set by the provider after each page of the archive
+	// so a scraper that crashes or is redeployed doesn't start the archive over
+	BackfillCursor       string    `json:"-"`
+	LastCurrentlyScraped time.Time `json:"lastCurrentlyScraped"`
 	// IsIndexed is used to determine if the status page has ever been indexed in the search engine successfully
 	IsIndexed bool `json:"isIndexed"`
+	// Timezone is the IANA timezone that the status page prints local times in, e.g. America/Los_Angeles
+	// Empty if the status page prints times in UTC or with an explicit offset
+	Timezone string `json:"timezone,omitempty"`
+	// IsSandbox is true for the synthetic status pages generated by the mock provider
+	IsSandbox bool `json:"isSandbox"`
+	// TimezoneCorrected is set once the incidents scraped before Timezone was configured have been re-normalized
+	TimezoneCorrected bool `json:"timezoneCorrected"`
+	// Provider is the name of the provider that scrapes the status page, it can be given as a hint when the status page is added
+	// Otherwise it is detected before the status page is first scraped, ProviderDetectedAt is when it was detected
+	Provider           string     `json:"provider,omitempty"`
+	ProviderDetectedAt *time.Time `json:"providerDetectedAt,omitempty"`
+	// RequiresJS is set for status pages that render their incidents client side
+	// Their html is rendered in a headless browser before it is parsed
+	RequiresJS bool `gorm:"column:requires_js" json:"requiresJs,omitempty"`
+	// RobotsTxt overrides whether the scraper follows the robots.txt of the status page, see RobotsTxtRespect and RobotsTxtIgnore
+	// Empty follows the scraper's configuration
+	RobotsTxt string `json:"robotsTxt,omitempty"`
+	// ScrapeConfig overrides how the status page is scraped, it is edited through the api
+	ScrapeConfig *ScrapeConfig `gorm:"type:jsonb;serializer:json" json:"scrapeConfig,omitempty"`
+	// ScrapeIntervalSeconds is how often the current incidents of the status page are scraped
+	// Zero uses the default interval of the scraper
+	ScrapeIntervalSeconds int `json:"scrapeIntervalSeconds,omitempty"`
+	// ScrapePriority orders the status pages that are due to be scraped, higher priorities are scraped first
+	// when the scraper is running at its concurrency limit
+	ScrapePriority int `json:"scrapePriority,omitempty"`
+	// HasActiveIncident is set when the last scrape of the status page found an ongoing incident
+	// The status page is scraped more frequently until it is cleared
+	HasActiveIncident bool `json:"hasActiveIncident"`
+	// ConsecutiveFailureCount is the number of scrapes of the status page that have failed in a row
+	// Once it reaches the scraper's threshold the status page is degraded and only scraped after a cool-down
+	ConsecutiveFailureCount int `json:"consecutiveFailureCount"`
+	// LastSuccessfulScrapeAt is when the current incidents of the status page were last scraped without an error
+	LastSuccessfulScrapeAt time.Time `json:"lastSuccessfulScrapeAt"`
+	// DeadSince is when the status page started failing its scrapes because it no longer exists, it answers 404 or 410
+	// or its host doesn't resolve. It is cleared when a scrape succeeds or fails for another reason
+	DeadSince *time.Time `json:"deadSince,omitempty"`
+	// IsDead is set once the status page has been gone long enough that it is quarantined,
+	// it is then only scraped occasionally to check whether it has come back
+	IsDead bool `json:"isDead"`
+	// PausedAt is when an operator paused the scrapes of the status page, nil if it isn't paused
+	// A paused status page keeps its incidents but isn't scraped until it is resumed
+	PausedAt *time.Time `json:"pausedAt,omitempty"`
+	// LastError is the error of the last scrape, empty if it succeeded
+	LastError string `json:"lastError,omitempty"`
+	// ScrapeDurationMs is how long the last scrape took
+	ScrapeDurationMs int64 `json:"scrapeDurationMs"`
+	// ContentHash is the hash of the responses of the last scrape whose incidents were processed
+	// A scrape that fetches the same content skips the processing, ContentHashedAt is when it was last done
+	ContentHash     string    `json:"-"`
+	ContentHashedAt time.Time `json:"-"`
+	// StatusPageMetadata is read from the home page of the status page by the scraper
+	// MetadataRefreshedAt is when it was last read, it is read again periodically
+	StatusPageMetadata  `gorm:"embedded"`
+	MetadataRefreshedAt *time.Time `json:"-"`
 }
 
+const (
+	// RobotsTxtRespect makes the scraper follow the robots.txt of the status page even if it is disabled or the provider uses an API
+	RobotsTxtRespect = "respect"
+	// RobotsTxtIgnore makes the scraper ignore the robots.txt of the status page
+	RobotsTxtIgnore = "ignore"
+)
+
 func NewStatusPage(name string, url string) StatusPage {
 	return StatusPage{
 		Name:                    name,
@@ -0,0 +1,18 @@
+package api
+
+import "time"
+
+// APIKey identifies a client of the api, its requests are rate limited per key
+// The key itself is only returned when it is created, only its hash is stored
+type APIKey struct {
+	ID      string `gorm:"primarykey" json:"id"`
+	Name    string `json:"name"`
+	KeyHash string `gorm:"uniqueIndex" json:"-"`
+	// RateLimitPerMinute is how many requests the key can make per minute, zero uses the server's default
+	RateLimitPerMinute int `json:"rateLimitPerMinute"`
+	// TenantID is the tenant the key belongs to, the key only sees the status pages the tenant tracks
+	// A key without a tenant sees every status page
+	TenantID  string     `gorm:"index" json:"tenantId,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChangeEventType is the kind of change that happened to an incident
+type ChangeEventType string
+
+const (
+	ChangeEventIncidentCreated  ChangeEventType = "incident.created"
+	ChangeEventIncidentUpdated  ChangeEventType = "incident.updated"
+	ChangeEventIncidentResolved ChangeEventType = "incident.resolved"
+	ChangeEventIncidentDeleted  ChangeEventType = "incident.deleted"
+)
+
+// ChangeEvent records a change to an incident
+// Change events are written to the outbox in the same transaction as the incident change
+// and are then published to downstream consumers
+type ChangeEvent struct {
+	ID uint64 `gorm:"primarykey;autoIncrement;index:idx_outbox_position,priority:2" json:"id"`
+	// TxID is the transaction that wrote the event, see ChangeEventCursor
+	TxID          uint64           `gorm:"index:idx_outbox_position,priority:1;default:(pg_current_xact_id()::text)::bigint" json:"-"`
+	Type          ChangeEventType  `json:"type"`
+	StatusPageUrl string           `gorm:"index" json:"statusPageUrl"`
+	DeepLink      string           `json:"deepLink"`
+	Incident      IncidentSnapshot `gorm:"type:jsonb" json:"incident"`
+	CreatedAt     time.Time        `json:"createdAt"`
+	DispatchedAt  *time.Time       `gorm:"index" json:"dispatchedAt,omitempty"`
+	// ClaimedUntil is when the claim of the dispatcher publishing the event runs out
+	ClaimedUntil *time.Time `json:"-"`
+	// PublishedTo are the publishers that were given the event, so that a failed dispatch is only retried with the others
+	PublishedTo []string `gorm:"type:jsonb;serializer:json" json:"-"`
+}
+
+// ChangeEventCursor is the position of a change event in the outbox. The events are ordered by the transaction that
+// wrote them and then by id, as the ids are allocated before the transactions commit a later id can become visible first,
+// but every transaction that commits later has a later id than the ones that are read, see db.GetChangeEventsSince
+type ChangeEventCursor struct {
+	TxID uint64
+	ID   uint64
+}
+
+func (e ChangeEvent) Cursor() ChangeEventCursor {
+	return ChangeEventCursor{TxID: e.TxID, ID: e.ID}
+}
+
+// Before returns true if the cursor is before the other one
+func (c ChangeEventCursor) Before(other ChangeEventCursor) bool {
+	return c.TxID < other.TxID || (c.TxID == other.TxID && c.ID < other.ID)
+}
+
+func (c ChangeEventCursor) IsZero() bool {
+	return c == ChangeEventCursor{}
+}
+
+func (c ChangeEventCursor) String() string {
+	return strconv.FormatUint(c.TxID, 10) + "-" + strconv.FormatUint(c.ID, 10)
+}
+
+// ParseChangeEventCursor parses a cursor formatted by String
+func ParseChangeEventCursor(value string) (ChangeEventCursor, error) {
+	txID, id, found := strings.Cut(value, "-")
+	if !found {
+		return ChangeEventCursor{}, errors.Errorf("invalid cursor %q", value)
+	}
+	var cursor ChangeEventCursor
+	var err error
+	cursor.TxID, err = strconv.ParseUint(txID, 10, 64)
+	if err != nil {
+		return ChangeEventCursor{}, errors.Errorf("invalid cursor %q", value)
+	}
+	cursor.ID, err = strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return ChangeEventCursor{}, errors.Errorf("invalid cursor %q", value)
+	}
+	return cursor, nil
+}
+
+func NewChangeEvent(eventType ChangeEventType, incident Incident) ChangeEvent {
+	return ChangeEvent{
+		Type:          eventType,
+		StatusPageUrl: incident.StatusPageUrl,
+		DeepLink:      incident.DeepLink,
+		Incident:      IncidentSnapshot(incident),
+		CreatedAt:     time.Now(),
+	}
+}
+
+// IncidentSnapshot is a copy of an incident at the time of a change, stored as jsonb
+type IncidentSnapshot Incident
+
+func (s *IncidentSnapshot) Scan(src interface{}) error {
+	return json.Unmarshal(src.([]byte), s)
+}
+
+func (s IncidentSnapshot) Value() (driver.Value, error) {
+	val, err := json.Marshal(s)
+	return string(val), err
+}
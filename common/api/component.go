@@ -0,0 +1,25 @@
+package api
+
+import "time"
+
+// The component statuses used by Statuspage, providers map the statuses of other platforms onto them
+const (
+	ComponentStatusOperational         = "operational"
+	ComponentStatusDegradedPerformance = "degraded_performance"
+	ComponentStatusPartialOutage       = "partial_outage"
+	ComponentStatusMajorOutage         = "major_outage"
+	ComponentStatusUnderMaintenance    = "under_maintenance"
+)
+
+// Component is an entry of the component list of a status page, e.g. the Actions component of githubstatus.com
+// The names match the components of the incidents of the status page
+type Component struct {
+	StatusPageUrl string `gorm:"primarykey" json:"statusPageUrl"`
+	Name          string `gorm:"primarykey" json:"name"`
+	// Group is the name of the group the component is listed under, empty if it is not grouped
+	Group string `gorm:"column:group_name" json:"group,omitempty"`
+	// Status is the current status of the component, e.g. operational or major_outage
+	Status      string    `json:"status"`
+	Description string    `json:"description,omitempty"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
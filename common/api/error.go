@@ -0,0 +1,148 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ErrorCode is a machine readable error code returned by the api, clients should branch on the code rather than the message
+type ErrorCode string
+
+const (
+	// ErrorCodeMissingParameter means a required query parameter was not set, details.parameter names it
+	ErrorCodeMissingParameter ErrorCode = "missing_parameter"
+	// ErrorCodeInvalidParameter means a query parameter could not be parsed or is out of range, details.parameter names it
+	ErrorCodeInvalidParameter ErrorCode = "invalid_parameter"
+	// ErrorCodeInvalidFilter means the filter expression could not be parsed
+	ErrorCodeInvalidFilter ErrorCode = "invalid_filter"
+	// ErrorCodeInvalidBody means the request body could not be parsed
+	ErrorCodeInvalidBody ErrorCode = "invalid_body"
+	// ErrorCodeUnauthorized means the endpoint requires the admin token or an api key and it was missing or wrong
+	ErrorCodeUnauthorized ErrorCode = "unauthorized"
+	// ErrorCodeStatusPageNotFound means the status page is not known to statusphere
+	ErrorCodeStatusPageNotFound ErrorCode = "status_page_not_found"
+	// ErrorCodeStatusPageExists means a status page is already stored at the url
+	ErrorCodeStatusPageExists ErrorCode = "status_page_exists"
+	// ErrorCodeNotFound means the endpoint does not exist
+	ErrorCodeNotFound ErrorCode = "not_found"
+	// ErrorCodeScrapeInProgress means the status page is already being scraped, it can be retried once that scrape is done
+	ErrorCodeScrapeInProgress ErrorCode = "scrape_in_progress"
+	// ErrorCodeScrapeFailed means the status page couldn't be scraped, it can be retried
+	ErrorCodeScrapeFailed ErrorCode = "scrape_failed"
+	// ErrorCodeRateLimited means the api key or client made too many requests, Retry-After says when to retry
+	ErrorCodeRateLimited ErrorCode = "rate_limited"
+	// ErrorCodeInternal means the request failed on the server, it can be retried
+	ErrorCodeInternal ErrorCode = "internal"
+)
+
+const errorDocsURL = "https://github.com/metoro-io/statusphere#error-codes"
+
+// ProblemContentType is the content type of the RFC 7807 error responses, they are returned to the clients that accept it
+// The other clients get an ErrorResponse
+const ProblemContentType = "application/problem+json"
+
+type errorCodeInfo struct {
+	status int
+	title  string
+}
+
+var errorCodes = map[ErrorCode]errorCodeInfo{
+	ErrorCodeMissingParameter:   {http.StatusBadRequest, "Missing parameter"},
+	ErrorCodeInvalidParameter:   {http.StatusBadRequest, "Invalid parameter"},
+	ErrorCodeInvalidFilter:      {http.StatusBadRequest, "Invalid filter"},
+	ErrorCodeInvalidBody:        {http.StatusBadRequest, "Invalid body"},
+	ErrorCodeUnauthorized:       {http.StatusUnauthorized, "Unauthorized"},
+	ErrorCodeStatusPageNotFound: {http.StatusNotFound, "Status page not found"},
+	ErrorCodeStatusPageExists:   {http.StatusConflict, "Status page exists"},
+	ErrorCodeNotFound:           {http.StatusNotFound, "Not found"},
+	ErrorCodeScrapeInProgress:   {http.StatusConflict, "Scrape in progress"},
+	ErrorCodeScrapeFailed:       {http.StatusBadGateway, "Scrape failed"},
+	ErrorCodeRateLimited:        {http.StatusTooManyRequests, "Rate limited"},
+	ErrorCodeInternal:           {http.StatusInternalServerError, "Internal error"},
+}
+
+// Status is the http status of the responses with the error code
+func (c ErrorCode) Status() int {
+	if info, found := errorCodes[c]; found {
+		return info.status
+	}
+	return http.StatusInternalServerError
+}
+
+// ErrorResponse is the body of the error responses of the api unless the client accepts problem details
+type ErrorResponse struct {
+	Error Error `json:"error"`
+}
+
+type Error struct {
+	Code    ErrorCode         `json:"code"`
+	Message string            `json:"message"`
+	Details map[string]string `json:"details,omitempty"`
+	// Retryable is true if the same request may succeed if it is retried later
+	Retryable bool   `json:"retryable"`
+	DocsURL   string `json:"docsUrl"`
+}
+
+func NewError(code ErrorCode, message string, details map[string]string) Error {
+	return Error{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		Retryable: code.retryable(),
+		DocsURL:   errorDocsURL,
+	}
+}
+
+// AcceptsProblem returns true if the Accept header of a request asks for RFC 7807 problem details
+func AcceptsProblem(accept string) bool {
+	for _, mediaType := range strings.Split(accept, ",") {
+		mediaType, _, _ = strings.Cut(mediaType, ";")
+		if strings.EqualFold(strings.TrimSpace(mediaType), ProblemContentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// Problem is the body of the error responses of the api for the clients that accept problem details, an RFC 7807
+// problem details object
+// Code, Details, Retryable and DocsURL are extension members
+type Problem struct {
+	// Type identifies the error code as a URN, e.g. urn:statusphere:error:missing_parameter
+	Type string `json:"type"`
+	// Title is a short summary of the error code, it is the same for every error with the code
+	Title string `json:"title"`
+	// Status is the http status of the response
+	Status int `json:"status"`
+	// Detail explains this occurrence of the error
+	Detail string `json:"detail"`
+	// Instance is the path of the request that failed
+	Instance string            `json:"instance,omitempty"`
+	Code     ErrorCode         `json:"code"`
+	Details  map[string]string `json:"details,omitempty"`
+	// Retryable is true if the same request may succeed if it is retried later
+	Retryable bool   `json:"retryable"`
+	DocsURL   string `json:"docsUrl"`
+}
+
+func NewProblem(code ErrorCode, detail string, details map[string]string, instance string) Problem {
+	title := string(code)
+	if info, found := errorCodes[code]; found {
+		title = info.title
+	}
+	return Problem{
+		Type:      "urn:statusphere:error:" + string(code),
+		Title:     title,
+		Status:    code.Status(),
+		Detail:    detail,
+		Instance:  instance,
+		Code:      code,
+		Details:   details,
+		Retryable: code.retryable(),
+		DocsURL:   errorDocsURL,
+	}
+}
+
+func (c ErrorCode) retryable() bool {
+	return c == ErrorCodeInternal || c == ErrorCodeRateLimited || c == ErrorCodeScrapeInProgress || c == ErrorCodeScrapeFailed
+}
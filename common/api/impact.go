@@ -0,0 +1,59 @@
+package api
+
+import "strings"
+
+// impactAliases maps the severities used by the different status page providers to impacts
+// The keys are lower cased with spaces and dashes replaced by underscores, see NormalizeImpact
+var impactAliases = map[string]Impact{
+	// Impacts
+	"none":        ImpactNone,
+	"minor":       ImpactMinor,
+	"major":       ImpactMajor,
+	"critical":    ImpactCritical,
+	"maintenance": ImpactMaintenance,
+	// Component statuses, e.g. Atlassian Statuspage, Instatus and Cachet
+	"operational":           ImpactNone,
+	"degraded_performance":  ImpactMinor,
+	"degradedperformance":   ImpactMinor,
+	"degraded":              ImpactMinor,
+	"partial_outage":        ImpactMajor,
+	"partialoutage":         ImpactMajor,
+	"major_outage":          ImpactCritical,
+	"majoroutage":           ImpactCritical,
+	"outage":                ImpactCritical,
+	"downtime":              ImpactCritical,
+	"down":                  ImpactCritical,
+	"under_maintenance":     ImpactMaintenance,
+	"undermaintenance":      ImpactMaintenance,
+	"scheduled":             ImpactMaintenance,
+	"scheduled_maintenance": ImpactMaintenance,
+	// Severity levels
+	"low":        ImpactMinor,
+	"medium":     ImpactMajor,
+	"high":       ImpactCritical,
+	"info":       ImpactNone,
+	"notice":     ImpactNone,
+	"warning":    ImpactMinor,
+	"disruption": ImpactMajor,
+	"sev3":       ImpactMinor,
+	"sev2":       ImpactMajor,
+	"sev1":       ImpactCritical,
+	// Status colors
+	"green":  ImpactNone,
+	"blue":   ImpactMaintenance,
+	"yellow": ImpactMinor,
+	"orange": ImpactMajor,
+	"red":    ImpactCritical,
+}
+
+// NormalizeImpact maps the severity a status page gives an incident to an impact, so incidents of different
+// providers can be compared, e.g. "partial_outage", "Major Outage", "high" and "red"
+// Severities that are not recognised are mapped to ImpactNone
+func NormalizeImpact(raw string) Impact {
+	key := strings.ToLower(strings.TrimSpace(raw))
+	key = strings.NewReplacer(" ", "_", "-", "_").Replace(key)
+	if impact, found := impactAliases[key]; found {
+		return impact
+	}
+	return ImpactNone
+}
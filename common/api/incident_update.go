@@ -0,0 +1,158 @@
+package api
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+// IncidentState is the state an incident is in after an update was posted
+type IncidentState string
+
+const (
+	IncidentStateInvestigating IncidentState = "investigating"
+	IncidentStateIdentified    IncidentState = "identified"
+	IncidentStateMonitoring    IncidentState = "monitoring"
+	IncidentStateResolved      IncidentState = "resolved"
+	IncidentStateUpdate        IncidentState = "update"
+	IncidentStateScheduled     IncidentState = "scheduled"
+	IncidentStateInProgress    IncidentState = "in_progress"
+	IncidentStateVerifying     IncidentState = "verifying"
+	IncidentStateCompleted     IncidentState = "completed"
+	IncidentStatePostmortem    IncidentState = "postmortem"
+	IncidentStateUnknown       IncidentState = "unknown"
+)
+
+var knownIncidentStates = map[IncidentState]bool{
+	IncidentStateInvestigating: true,
+	IncidentStateIdentified:    true,
+	IncidentStateMonitoring:    true,
+	IncidentStateResolved:      true,
+	IncidentStateUpdate:        true,
+	IncidentStateScheduled:     true,
+	IncidentStateInProgress:    true,
+	IncidentStateVerifying:     true,
+	IncidentStateCompleted:     true,
+	IncidentStatePostmortem:    true,
+}
+
+// ParseIncidentState converts the free form state label used by a status page (e.g. "In progress", "Resolved")
+// into an IncidentState. Unrecognised labels are mapped to IncidentStateUnknown
+func ParseIncidentState(label string) IncidentState {
+	normalised := strings.ToLower(strings.TrimSpace(label))
+	normalised = strings.ReplaceAll(normalised, " ", "_")
+	normalised = strings.ReplaceAll(normalised, "-", "_")
+	if knownIncidentStates[IncidentState(normalised)] {
+		return IncidentState(normalised)
+	}
+	return IncidentStateUnknown
+}
+
+// IsTerminal returns true if no further updates are expected after this state
+func (s IncidentState) IsTerminal() bool {
+	return s == IncidentStateResolved || s == IncidentStateCompleted || s == IncidentStatePostmortem
+}
+
+// IncidentUpdate is a single update posted to an incident
+type IncidentUpdate struct {
+	Time  time.Time     `json:"time"`
+	State IncidentState `json:"state"`
+	Body  string        `json:"body"`
+	// Source is the name of the provider that the update was scraped with
+	Source string `json:"source"`
+	// ComponentChanges are the component status changes posted with the update, if the provider reports them
+	ComponentChanges []ComponentChange `json:"componentChanges,omitempty"`
+}
+
+// ComponentChange is a change to the status of a single component, e.g. Actions going from operational to degraded_performance
+// The statuses are the ones used by the status page
+type ComponentChange struct {
+	Component string `json:"component"`
+	OldStatus string `json:"oldStatus"`
+	NewStatus string `json:"newStatus"`
+}
+
+func NewIncidentUpdate(time time.Time, state IncidentState, body string, source string) IncidentUpdate {
+	return IncidentUpdate{
+		Time:   time,
+		State:  state,
+		Body:   body,
+		Source: source,
+	}
+}
+
+type IncidentUpdateArray []IncidentUpdate
+
+// incidentUpdateOrEvent holds the union of the fields of IncidentUpdate and the legacy IncidentEvent
+// so that rows written before the updates were typed can still be read
+type incidentUpdateOrEvent struct {
+	Time   time.Time     `json:"time"`
+	State  IncidentState `json:"state"`
+	Body   string        `json:"body"`
+	Source string        `json:"source"`
+	// ComponentChanges is only set on typed updates
+	ComponentChanges []ComponentChange `json:"componentChanges"`
+	Title            string            `json:"title"`
+	Description      string            `json:"description"`
+}
+
+func (a *IncidentUpdateArray) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return errors.Errorf("unsupported type %T for incident updates", src)
+	}
+	var raw []incidentUpdateOrEvent
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+	if raw == nil {
+		*a = nil
+		return nil
+	}
+
+	updates := make(IncidentUpdateArray, 0, len(raw))
+	for _, r := range raw {
+		if r.State == "" && (r.Title != "" || r.Description != "") {
+			updates = append(updates, IncidentEvent{Title: r.Title, Description: r.Description, Time: r.Time}.ToUpdate())
+			continue
+		}
+		updates = append(updates, IncidentUpdate{Time: r.Time, State: r.State, Body: r.Body, Source: r.Source, ComponentChanges: r.ComponentChanges})
+	}
+	*a = updates
+	return nil
+}
+
+func (a IncidentUpdateArray) Value() (driver.Value, error) {
+	val, err := json.Marshal(a)
+	return string(val), err
+}
+
+// ToUpdate converts a legacy free form event into a typed update
+// The title of a legacy event holds the state label, e.g. "Investigating"
+func (e IncidentEvent) ToUpdate() IncidentUpdate {
+	return IncidentUpdate{
+		Time:  e.Time,
+		State: ParseIncidentState(e.Title),
+		Body:  e.Description,
+	}
+}
+
+// ToUpdates converts a list of legacy free form events into typed updates
+func (sla IncidentEventArray) ToUpdates() IncidentUpdateArray {
+	updates := make(IncidentUpdateArray, 0, len(sla))
+	for _, event := range sla {
+		updates = append(updates, event.ToUpdate())
+	}
+	return updates
+}
@@ -0,0 +1,49 @@
+package api
+
+import "time"
+
+// Maintenance is a scheduled maintenance window published on a status page
+// Maintenances are scraped like incidents, with an impact of maintenance, but are stored separately
+type Maintenance struct {
+	Title      string              `json:"title"`
+	Components []string            `gorm:"column:components;type:jsonb;serializer:json" json:"components"`
+	Updates    IncidentUpdateArray `gorm:"column:updates;type:jsonb" json:"updates"`
+	// ScheduledStart and ScheduledEnd are the planned window, ScheduledEnd is nil if the status page doesn't publish it
+	ScheduledStart time.Time  `gorm:"secondarykey" json:"scheduledStart"`
+	ScheduledEnd   *time.Time `json:"scheduledEnd"`
+	// EndTime is when the maintenance was completed, nil until then
+	EndTime *time.Time `json:"endTime"`
+	// State is the state of the latest update, e.g. scheduled, in_progress or completed
+	State         IncidentState `json:"state"`
+	Description   *string       `json:"description"`
+	DeepLink      string        `gorm:"primarykey" json:"deepLink"`
+	StatusPageUrl string        `gorm:"secondarykey" json:"statusPageUrl"`
+}
+
+// MaintenanceFromIncident converts an incident with an impact of maintenance into a Maintenance
+// The planned window is taken from the scheduled times of the incident, falling back to its start time
+func MaintenanceFromIncident(incident Incident) Maintenance {
+	scheduledStart := incident.StartTime
+	if incident.ScheduledStart != nil {
+		scheduledStart = *incident.ScheduledStart
+	}
+	state := IncidentStateScheduled
+	if len(incident.Events) > 0 {
+		state = incident.Events[len(incident.Events)-1].State
+	}
+	if incident.EndTime != nil && !state.IsTerminal() {
+		state = IncidentStateCompleted
+	}
+	return Maintenance{
+		Title:          incident.Title,
+		Components:     incident.Components,
+		Updates:        incident.Events,
+		ScheduledStart: scheduledStart,
+		ScheduledEnd:   incident.ScheduledEnd,
+		EndTime:        incident.EndTime,
+		State:          state,
+		Description:    incident.Description,
+		DeepLink:       incident.DeepLink,
+		StatusPageUrl:  incident.StatusPageUrl,
+	}
+}
@@ -0,0 +1,43 @@
+package api
+
+import "time"
+
+// ProviderFeatures describes what data a scraper provider is able to extract from the status pages it supports
+// so users know what data quality to expect before onboarding a vendor hosted on that provider
+type ProviderFeatures struct {
+	Provider string `gorm:"primarykey" json:"provider"`
+	// Incidents is true if the provider extracts incidents
+	Incidents bool `json:"incidents"`
+	// IncidentUpdates is true if the individual updates posted to an incident are extracted
+	IncidentUpdates bool `json:"incidentUpdates"`
+	// Components is true if the components affected by an incident are extracted
+	Components bool `json:"components"`
+	// Maintenance is true if scheduled maintenance windows are extracted
+	Maintenance bool `json:"maintenance"`
+	// CurrentStatus is true if the current overall status of the page is extracted
+	CurrentStatus bool `json:"currentStatus"`
+	// Webhooks is true if the provider can receive pushed updates rather than only being polled
+	Webhooks bool `json:"webhooks"`
+	// API is true if the provider reads a machine readable API or data file rather than crawling the status page
+	// robots.txt is not applied to its requests unless a status page opts in
+	API bool `json:"api"`
+	// HistoryDepth is a human readable description of how far back a historical scrape goes
+	HistoryDepth string `json:"historyDepth"`
+}
+
+// ProviderConfig is the definition of a declarative provider, it describes where the incidents are on a status page
+// so a new status page format can be onboarded without writing a provider
+// Config holds the definition as YAML or JSON, see the declarative provider package for the format
+type ProviderConfig struct {
+	Name      string    `gorm:"primarykey" json:"name"`
+	Config    string    `json:"config"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// HeaderProfile holds the request headers, e.g. User-Agent and Accept-Language, that a provider sends
+// Some status pages serve different or blocked content depending on them
+type HeaderProfile struct {
+	Provider  string            `gorm:"primarykey" json:"provider"`
+	Headers   map[string]string `gorm:"type:jsonb;serializer:json" json:"headers"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+}
@@ -0,0 +1,20 @@
+package api
+
+import "encoding/json"
+
+// ScrapeConfig overrides how a single status page is scraped, so the quirks of a status page don't need code changes
+// Every field is optional, a field that isn't set leaves the scraper's behaviour for the status page as it is
+type ScrapeConfig struct {
+	// Headers are sent with every request to the status page, they take precedence over the header profile of the provider
+	Headers map[string]string `json:"headers,omitempty"`
+	// Selectors is a declarative provider definition that scrapes the status page instead of the registered providers
+	// See the declarative provider package for the format, its name and hosts default to the status page's
+	Selectors json.RawMessage `json:"selectors,omitempty"`
+	// IntervalSeconds is how often the current incidents of the status page are scraped, it takes precedence over
+	// ScrapeIntervalSeconds
+	IntervalSeconds int `json:"intervalSeconds,omitempty"`
+	// Provider is the name of the provider that scrapes the status page, the provider isn't detected or matched
+	Provider string `json:"provider,omitempty"`
+	// RequiresJS overrides whether the status page is rendered in a headless browser before it is parsed
+	RequiresJS *bool `json:"requiresJs,omitempty"`
+}
@@ -0,0 +1,12 @@
+package api
+
+import "time"
+
+// StatusPageAlias is a url that a status page used to be at, it permanently redirects to the status page's current url
+// Requests for the alias are answered with the status page it redirects to
+type StatusPageAlias struct {
+	URL           string `gorm:"primarykey" json:"url"`
+	StatusPageUrl string `gorm:"secondarykey" json:"statusPageUrl"`
+	// RedirectedAt is when the status page was moved from the alias to its current url
+	RedirectedAt time.Time `json:"redirectedAt"`
+}
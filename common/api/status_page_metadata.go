@@ -0,0 +1,15 @@
+package api
+
+// StatusPageMetadata describes the vendor of a status page as its home page presents it
+// Fields that the home page doesn't give are empty
+type StatusPageMetadata struct {
+	// DisplayName is the vendor's name as the status page shows it, e.g. from its og:site_name
+	DisplayName string `json:"displayName,omitempty"`
+	Description string `json:"description,omitempty"`
+	// TwitterHandle is the vendor's Twitter/X account without the @
+	TwitterHandle string `json:"twitterHandle,omitempty"`
+	// SupportURL is where the status page sends its visitors for support, it can be a mailto link
+	SupportURL string `gorm:"column:support_url" json:"supportUrl,omitempty"`
+	// FaviconURL is the url of the largest icon that the status page links to, for the status pages without a LogoURL
+	FaviconURL string `gorm:"column:favicon_url" json:"faviconUrl,omitempty"`
+}
@@ -0,0 +1,29 @@
+package api
+
+import "time"
+
+// StatusSnapshot is the overall status of a status page at a point in time, e.g. its "All Systems Operational" banner
+// The status in effect at a given time is the latest snapshot taken at or before it
+type StatusSnapshot struct {
+	StatusPageUrl string    `gorm:"primarykey" json:"statusPageUrl"`
+	Time          time.Time `gorm:"primarykey" json:"time"`
+	// Indicator is the severity of the status using the impacts, e.g. none when everything is operational
+	Indicator   Impact `json:"indicator"`
+	Description string `json:"description"`
+	// Derived is true if the status page doesn't publish an overall status and it was derived from the ongoing incidents
+	Derived bool `json:"derived"`
+}
+
+// StatusDescriptions are the banners that Statuspage shows for each indicator, they describe derived statuses
+var StatusDescriptions = map[Impact]string{
+	ImpactNone:        "All Systems Operational",
+	ImpactMaintenance: "Service Under Maintenance",
+	ImpactMinor:       "Minor Service Outage",
+	ImpactMajor:       "Partial System Outage",
+	ImpactCritical:    "Major System Outage",
+}
+
+// SameStatus returns true if both snapshots show the same status, ignoring when they were taken
+func (s StatusSnapshot) SameStatus(other StatusSnapshot) bool {
+	return s.Indicator == other.Indicator && s.Description == other.Description && s.Derived == other.Derived
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"slices"
+	"time"
+)
+
+// Subscription is a webhook that is sent the incident changes that match its filters
+// It belongs to the api key that registered it, an empty filter matches everything
+type Subscription struct {
+	ID       string `gorm:"primarykey" json:"id"`
+	APIKeyID string `gorm:"index" json:"apiKeyId"`
+	URL      string `json:"url"`
+	// Secret signs the payloads, it is only returned when the subscription is created
+	Secret         string            `json:"-"`
+	StatusPageUrls []string          `gorm:"type:jsonb;serializer:json" json:"statusPageUrls"`
+	Impacts        []Impact          `gorm:"type:jsonb;serializer:json" json:"impacts"`
+	EventTypes     []ChangeEventType `gorm:"type:jsonb;serializer:json" json:"eventTypes"`
+	CreatedAt      time.Time         `json:"createdAt"`
+}
+
+// SubscribableEventTypes are the change events that subscriptions can receive, the default of a subscription
+// Deletions are only sent to the subscriptions that ask for them, they happen when a status page moves
+var SubscribableEventTypes = []ChangeEventType{ChangeEventIncidentCreated, ChangeEventIncidentUpdated, ChangeEventIncidentResolved}
+
+// Matches returns true if the change event passes the filters of the subscription
+func (s Subscription) Matches(event ChangeEvent) bool {
+	eventTypes := s.EventTypes
+	if len(eventTypes) == 0 {
+		eventTypes = SubscribableEventTypes
+	}
+	if !slices.Contains(eventTypes, event.Type) {
+		return false
+	}
+	if len(s.StatusPageUrls) > 0 && !slices.Contains(s.StatusPageUrls, event.StatusPageUrl) {
+		return false
+	}
+	if len(s.Impacts) > 0 && !slices.Contains(s.Impacts, event.Incident.Impact) {
+		return false
+	}
+	return true
+}
+
+type DeliveryStatus string
+
+const (
+	// DeliveryStatusPending deliveries haven't been accepted yet and will be attempted at NextAttemptAt
+	DeliveryStatusPending DeliveryStatus = "pending"
+	// DeliveryStatusDelivered deliveries were answered with a 2xx
+	DeliveryStatusDelivered DeliveryStatus = "delivered"
+	// DeliveryStatusFailed deliveries ran out of attempts
+	DeliveryStatusFailed DeliveryStatus = "failed"
+)
+
+// WebhookDelivery is the delivery of a change event to a subscription, it is retried until it succeeds or runs out of attempts
+type WebhookDelivery struct {
+	ID             uint64         `gorm:"primarykey;autoIncrement" json:"id"`
+	SubscriptionID string         `gorm:"uniqueIndex:idx_webhook_delivery_event" json:"subscriptionId"`
+	EventID        uint64         `gorm:"uniqueIndex:idx_webhook_delivery_event" json:"eventId"`
+	Payload        WebhookPayload `gorm:"type:jsonb;serializer:json" json:"payload"`
+	Status         DeliveryStatus `gorm:"index" json:"status"`
+	Attempts       int            `json:"attempts"`
+	NextAttemptAt  time.Time      `gorm:"index" json:"nextAttemptAt"`
+	// LastStatusCode is the http status of the last attempt, zero if the request failed before a response
+	LastStatusCode int        `json:"lastStatusCode,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	DeliveredAt    *time.Time `json:"deliveredAt,omitempty"`
+}
+
+// WebhookPayload is the body that is posted to a subscription
+type WebhookPayload struct {
+	// ID is the id of the change event, it is the same for every attempt so receivers can deduplicate
+	ID            uint64          `json:"id"`
+	Type          ChangeEventType `json:"type"`
+	StatusPageUrl string          `json:"statusPageUrl"`
+	Incident      Incident        `json:"incident"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	// Updates is the number of updates that were collapsed into the delivery of the latest one, zero if none were
+	Updates int `json:"updates,omitempty"`
+}
+
+func NewWebhookPayload(event ChangeEvent) WebhookPayload {
+	return WebhookPayload{
+		ID:            event.ID,
+		Type:          event.Type,
+		StatusPageUrl: event.StatusPageUrl,
+		Incident:      Incident(event.Incident),
+		CreatedAt:     event.CreatedAt,
+	}
+}
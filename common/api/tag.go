@@ -0,0 +1,9 @@
+package api
+
+// StatusPageTag is a tag that groups status pages, e.g. payments or tier-1
+// The tags without a tenant are shared and set with the admin token, a tenant has its own tags
+type StatusPageTag struct {
+	TenantID      string `gorm:"primarykey" json:"tenantId,omitempty"`
+	StatusPageUrl string `gorm:"primarykey;index" json:"statusPageUrl"`
+	Tag           string `gorm:"primarykey" json:"tag"`
+}
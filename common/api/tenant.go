@@ -0,0 +1,22 @@
+package api
+
+import "time"
+
+// Tenant is an organization with its own list of tracked status pages, its api keys only see those status pages
+// The status pages themselves are shared, a status page tracked by several tenants is scraped once
+type Tenant struct {
+	ID        string    `gorm:"primarykey" json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TenantStatusPage is a status page that a tenant tracks
+type TenantStatusPage struct {
+	TenantID      string    `gorm:"primarykey" json:"tenantId"`
+	StatusPageUrl string    `gorm:"primarykey;index" json:"statusPageUrl"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// SandboxTenantID is the built-in tenant that tracks the synthetic sandbox status pages, the sandbox status pages are
+// only served to its api keys and its api keys only see the sandbox status pages
+const SandboxTenantID = "sandbox"
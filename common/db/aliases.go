@@ -0,0 +1,215 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+	"time"
+)
+
+const statusPageAliasesTableName = "status_page_aliases"
+
+// GetStatusPageAliases returns every url that a status page has been moved away from
+func (d *DbClient) GetStatusPageAliases(ctx context.Context) ([]api.StatusPageAlias, error) {
+	var aliases []api.StatusPageAlias
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, statusPageAliasesTableName)).Find(&aliases)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return aliases, nil
+}
+
+// GetStatusPageAlias returns the alias with the given url, nil if the url isn't an alias
+func (d *DbClient) GetStatusPageAlias(ctx context.Context, url string) (*api.StatusPageAlias, error) {
+	var alias api.StatusPageAlias
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, statusPageAliasesTableName)).Where("url = ?", url).First(&alias)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &alias, nil
+}
+
+// MoveStatusPage moves the status page at from to the url it permanently redirects to, so its incidents aren't split
+// across both urls. The incidents, maintenances, components and status history are moved with it, and the deep links
+// under from are rewritten to be under to. If a status page already exists at to, its rows win over the moved ones.
+// from is kept as an alias of to, along with the aliases that pointed to from.
+func (d *DbClient) MoveStatusPage(ctx context.Context, from string, to string, at time.Time) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would move status page", zap.String("from", from), zap.String("to", to))
+		return nil
+	}
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := moveIncidents(tx, from, to, d.upsertBatchSize)
+		if err != nil {
+			return err
+		}
+		err = moveMaintenances(tx, from, to, d.upsertBatchSize)
+		if err != nil {
+			return err
+		}
+
+		// Components, status snapshots, the tracking of tenants and tags are keyed on the status page url, the rows that
+		// are already at to are kept
+		for _, moved := range []struct {
+			table string
+			key   string
+		}{
+			{table: componentsTableName, key: `"name"`},
+			{table: statusSnapshotsTableName, key: `"time"`},
+			{table: tenantStatusPagesTableName, key: `"tenant_id"`},
+			{table: statusPageTagsTableName, key: `"tenant_id", "tag"`},
+		} {
+			table := fmt.Sprintf("%s.%s", schemaName, moved.table)
+			result := tx.Exec(fmt.Sprintf(`UPDATE %[1]s SET status_page_url = @to WHERE status_page_url = @from
+	AND (%[2]s) NOT IN (SELECT %[2]s FROM %[1]s WHERE status_page_url = @to)`, table, moved.key),
+				map[string]interface{}{"from": from, "to": to})
+			if result.Error != nil {
+				return errors.Wrapf(result.Error, "failed to move %s", moved.table)
+			}
+			result = tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE status_page_url = ?", table), from)
+			if result.Error != nil {
+				return errors.Wrapf(result.Error, "failed to delete the %s left at the old url", moved.table)
+			}
+		}
+
+		statusPageTable := fmt.Sprintf("%s.%s", schemaName, statusPageTableName)
+		var existing int64
+		result := tx.Table(statusPageTable).Where("url = ?", to).Count(&existing)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to check for a status page at the new url")
+		}
+		if existing > 0 {
+			result = tx.Table(statusPageTable).Where("url = ?", from).Delete(&api.StatusPage{})
+		} else {
+			result = tx.Table(statusPageTable).Where("url = ?", from).Update("url", to)
+		}
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to move status page")
+		}
+
+		result = tx.Table(fmt.Sprintf("%s.%s", schemaName, scrapeClaimsTableName)).Where("url = ?", from).Delete(&ScrapeClaim{})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to delete scrape claim")
+		}
+
+		aliasTable := fmt.Sprintf("%s.%s", schemaName, statusPageAliasesTableName)
+		// A status page that moves back to an old url is no longer aliased there
+		result = tx.Table(aliasTable).Where("url = ?", to).Delete(&api.StatusPageAlias{})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to delete alias of the new url")
+		}
+		result = tx.Table(aliasTable).Where("status_page_url = ?", from).Update("status_page_url", to)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to update aliases")
+		}
+		alias := api.StatusPageAlias{URL: from, StatusPageUrl: to, RedirectedAt: at}
+		result = tx.Table(aliasTable).Clauses(clause.OnConflict{UpdateAll: true}).Create(&alias)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to create alias")
+		}
+		return nil
+	})
+}
+
+// moveIncidents moves the incidents of from to to, syncing clients are sent a deletion of each incident
+// and the creation of the moved one, as its deep link can change
+func moveIncidents(tx *gorm.DB, from string, to string, batchSize int) error {
+	table := fmt.Sprintf("%s.%s", schemaName, incidentsTableName)
+	var incidents []api.Incident
+	result := tx.Table(table).Where("status_page_url = ?", from).Find(&incidents)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to get incidents to move")
+	}
+	if len(incidents) == 0 {
+		return nil
+	}
+	result = tx.Table(table).Where("status_page_url = ?", from).Delete(&api.Incident{})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to delete moved incidents")
+	}
+
+	deepLinks := make([]string, 0, len(incidents))
+	for _, incident := range incidents {
+		deepLinks = append(deepLinks, moveDeepLink(incident.DeepLink, from, to))
+	}
+	// The incidents that were already scraped at to are newer than the moved ones
+	var existing []string
+	result = tx.Table(table).Where("deep_link IN ?", deepLinks).Pluck("deep_link", &existing)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to get the incidents already at the new url")
+	}
+	alreadyMoved := map[string]bool{}
+	for _, deepLink := range existing {
+		alreadyMoved[deepLink] = true
+	}
+
+	events := make([]api.ChangeEvent, 0, 2*len(incidents))
+	moved := make([]api.Incident, 0, len(incidents))
+	now := time.Now().UTC()
+	for i, incident := range incidents {
+		events = append(events, api.NewChangeEvent(api.ChangeEventIncidentDeleted, incident))
+		if alreadyMoved[deepLinks[i]] {
+			continue
+		}
+		alreadyMoved[deepLinks[i]] = true
+		incident.StatusPageUrl = to
+		incident.DeepLink = deepLinks[i]
+		incident.UpdatedAt = now
+		moved = append(moved, incident)
+		events = append(events, api.NewChangeEvent(api.ChangeEventIncidentCreated, incident))
+	}
+	if len(moved) > 0 {
+		result = tx.Table(table).CreateInBatches(&moved, batchSize)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to insert moved incidents")
+		}
+	}
+	result = tx.Table(fmt.Sprintf("%s.%s", schemaName, outboxTableName)).CreateInBatches(&events, batchSize)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to write outbox events")
+	}
+	return nil
+}
+
+func moveMaintenances(tx *gorm.DB, from string, to string, batchSize int) error {
+	table := fmt.Sprintf("%s.%s", schemaName, maintenancesTableName)
+	var maintenances []api.Maintenance
+	result := tx.Table(table).Where("status_page_url = ?", from).Find(&maintenances)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to get maintenances to move")
+	}
+	if len(maintenances) == 0 {
+		return nil
+	}
+	result = tx.Table(table).Where("status_page_url = ?", from).Delete(&api.Maintenance{})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to delete moved maintenances")
+	}
+	for i := range maintenances {
+		maintenances[i].StatusPageUrl = to
+		maintenances[i].DeepLink = moveDeepLink(maintenances[i].DeepLink, from, to)
+	}
+	result = tx.Table(table).Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&maintenances, batchSize)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to insert moved maintenances")
+	}
+	return nil
+}
+
+// moveDeepLink rewrites a deep link under from to be under to, the deep links that are elsewhere are kept
+func moveDeepLink(deepLink string, from string, to string) string {
+	from = strings.TrimSuffix(from, "/")
+	to = strings.TrimSuffix(to, "/")
+	if deepLink == from || strings.HasPrefix(deepLink, from+"/") || strings.HasPrefix(deepLink, from+"#") || strings.HasPrefix(deepLink, from+"?") {
+		return to + strings.TrimPrefix(deepLink, from)
+	}
+	return deepLink
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"time"
+)
+
+const apiKeysTableName = "api_keys"
+
+// CreateAPIKey stores a new api key
+func (d *DbClient) CreateAPIKey(ctx context.Context, key api.APIKey) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would create api key", zap.String("id", key.ID), zap.String("name", key.Name))
+		return nil
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, apiKeysTableName)).Create(&key)
+	return result.Error
+}
+
+// GetAPIKeyByHash returns the api key with the given hash, nil if there isn't one
+func (d *DbClient) GetAPIKeyByHash(ctx context.Context, keyHash string) (*api.APIKey, error) {
+	var key api.APIKey
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, apiKeysTableName)).Where("key_hash = ?", keyHash).First(&key)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &key, nil
+}
+
+// GetAPIKeys returns every api key, including the revoked ones, oldest first
+// Only the keys of the tenant are returned if the context is scoped to one
+func (d *DbClient) GetAPIKeys(ctx context.Context) ([]api.APIKey, error) {
+	var keys []api.APIKey
+	tx := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, apiKeysTableName))
+	if tenantID, found := TenantFromContext(ctx); found {
+		tx = tx.Where("tenant_id = ?", tenantID)
+	}
+	result := tx.Order("created_at").Find(&keys)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey revokes the api key with the given id and returns it, nil if there is no such key
+// A key that is already revoked keeps its original revocation time. Only the keys of the tenant can be revoked if the
+// context is scoped to one
+func (d *DbClient) RevokeAPIKey(ctx context.Context, id string, at time.Time) (*api.APIKey, error) {
+	table := fmt.Sprintf("%s.%s", schemaName, apiKeysTableName)
+	var key api.APIKey
+	tx := d.db.WithContext(ctx).Table(table).Where("id = ?", id)
+	if tenantID, found := TenantFromContext(ctx); found {
+		tx = tx.Where("tenant_id = ?", tenantID)
+	}
+	result := tx.First(&key)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	if key.RevokedAt != nil {
+		return &key, nil
+	}
+	if d.dryRun {
+		d.logger.Info("dry run: would revoke api key", zap.String("id", id))
+		return &key, nil
+	}
+	result = d.db.WithContext(ctx).Table(table).Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", at)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	key.RevokedAt = &at
+	return &key, nil
+}
@@ -0,0 +1,358 @@
+package db
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// BackupFormatVersion is the version of the backup archive layout, bumped on incompatible changes
+const BackupFormatVersion = 1
+
+const backupManifestName = "manifest.json"
+
+// backupExcludedTables hold ephemeral state that must not be restored
+var backupExcludedTables = map[string]bool{
+	leaderTableName:       true,
+	scrapeClaimsTableName: true,
+}
+
+type BackupManifest struct {
+	FormatVersion int           `json:"formatVersion"`
+	CreatedAt     time.Time     `json:"createdAt"`
+	Schema        string        `json:"schema"`
+	Tables        []BackupTable `json:"tables"`
+}
+
+type BackupTable struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+	Rows int64  `json:"rows"`
+	// Sha256 is the hex encoded checksum of the table file
+	Sha256 string `json:"sha256"`
+}
+
+// ExportBackup writes a gzipped tar archive of every statusphere table to w
+// All tables are read in a single repeatable read transaction so the archive is a consistent snapshot.
+// The archive contains a manifest followed by one JSONL file per table with a checksum of each file in the manifest.
+func (d *DbClient) ExportBackup(ctx context.Context, w io.Writer) (*BackupManifest, error) {
+	tmpDir, err := os.MkdirTemp("", "statusphere-backup-")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create temporary directory")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	manifest := &BackupManifest{FormatVersion: BackupFormatVersion, CreatedAt: time.Now().UTC(), Schema: schemaName}
+	err = d.db.Transaction(func(tx *gorm.DB) error {
+		tables, err := backupTables(tx)
+		if err != nil {
+			return err
+		}
+		for _, table := range tables {
+			backupTable, err := exportTable(tx, table, tmpDir)
+			if err != nil {
+				return errors.Wrapf(err, "failed to export table %s", table)
+			}
+			manifest.Tables = append(manifest.Tables, *backupTable)
+		}
+		return nil
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal manifest")
+	}
+	err = tarWriter.WriteHeader(&tar.Header{Name: backupManifestName, Mode: 0644, Size: int64(len(manifestBytes)), ModTime: manifest.CreatedAt})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to write manifest header")
+	}
+	if _, err := tarWriter.Write(manifestBytes); err != nil {
+		return nil, errors.Wrap(err, "failed to write manifest")
+	}
+	for _, table := range manifest.Tables {
+		err := addFileToTar(tarWriter, filepath.Join(tmpDir, table.File), table.File, manifest.CreatedAt)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to add table %s to the archive", table.Name)
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to close archive")
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to close archive")
+	}
+	d.logger.Info("exported backup", zap.Int("tables", len(manifest.Tables)))
+	return manifest, nil
+}
+
+func backupTables(tx *gorm.DB) ([]string, error) {
+	var tables []string
+	result := tx.Raw("SELECT tablename FROM pg_tables WHERE schemaname = ? ORDER BY tablename", schemaName).Scan(&tables)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "failed to list tables")
+	}
+	var included []string
+	for _, table := range tables {
+		if !backupExcludedTables[table] {
+			included = append(included, table)
+		}
+	}
+	return included, nil
+}
+
+func exportTable(tx *gorm.DB, table string, dir string) (*BackupTable, error) {
+	fileName := table + ".jsonl"
+	f, err := os.Create(filepath.Join(dir, fileName))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	checksum := sha256.New()
+	writer := bufio.NewWriter(io.MultiWriter(f, checksum))
+	rows, err := tx.Raw(fmt.Sprintf("SELECT row_to_json(t)::text FROM %s t", quoteTable(table))).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var count int64
+	for rows.Next() {
+		var row string
+		if err := rows.Scan(&row); err != nil {
+			return nil, err
+		}
+		if _, err := writer.WriteString(row + "\n"); err != nil {
+			return nil, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := writer.Flush(); err != nil {
+		return nil, err
+	}
+	return &BackupTable{Name: table, File: fileName, Rows: count, Sha256: hex.EncodeToString(checksum.Sum(nil))}, nil
+}
+
+func addFileToTar(tarWriter *tar.Writer, path string, name string, modTime time.Time) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	err = tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: info.Size(), ModTime: modTime})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(tarWriter, f)
+	return err
+}
+
+const backupImportBatchSize = 500
+
+// ImportBackup restores an archive produced by ExportBackup
+// Every checksum is verified before anything is written. The contents of each table in the archive then
+// replace the contents of the matching table in a single transaction, so a failed import changes nothing.
+// The restored change events are marked as dispatched so that the notifiers aren't sent them again.
+// The schema must already exist, see AutoMigrate.
+func (d *DbClient) ImportBackup(ctx context.Context, r io.Reader) (*BackupManifest, error) {
+	tmpDir, err := os.MkdirTemp("", "statusphere-restore-")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create temporary directory")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	manifest, checksums, err := extractBackup(r, tmpDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range manifest.Tables {
+		if checksums[table.File] != table.Sha256 {
+			return nil, errors.Errorf("checksum mismatch for table %s, the archive is corrupt", table.Name)
+		}
+	}
+
+	if d.dryRun {
+		d.logger.Info("dry run: would import backup", zap.Any("manifest", manifest))
+		return manifest, nil
+	}
+
+	err = d.db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range manifest.Tables {
+			err := importTable(tx, table, filepath.Join(tmpDir, table.File))
+			if err != nil {
+				return errors.Wrapf(err, "failed to import table %s", table.Name)
+			}
+		}
+		// The events that weren't dispatched when the backup was taken were published by the instance it came from
+		result := tx.Exec(fmt.Sprintf("UPDATE %s.%s SET dispatched_at = ? WHERE dispatched_at IS NULL", schemaName, outboxTableName), time.Now().UTC())
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to mark the restored change events as dispatched")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	d.logger.Info("imported backup", zap.Int("tables", len(manifest.Tables)), zap.Time("createdAt", manifest.CreatedAt))
+	return manifest, nil
+}
+
+// extractBackup extracts the table files of the archive into dir and returns the manifest with the checksums of the extracted files
+func extractBackup(r io.Reader, dir string) (*BackupManifest, map[string]string, error) {
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to read archive")
+	}
+	tarReader := tar.NewReader(gzipReader)
+
+	var manifest *BackupManifest
+	checksums := make(map[string]string)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to read archive")
+		}
+		if header.Name == backupManifestName {
+			manifest = &BackupManifest{}
+			if err := json.NewDecoder(tarReader).Decode(manifest); err != nil {
+				return nil, nil, errors.Wrap(err, "failed to read manifest")
+			}
+			continue
+		}
+		if strings.ContainsAny(header.Name, `/\`) || !strings.HasSuffix(header.Name, ".jsonl") {
+			return nil, nil, errors.Errorf("unexpected file %q in archive", header.Name)
+		}
+		checksum, err := extractFile(tarReader, filepath.Join(dir, header.Name))
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to extract %s", header.Name)
+		}
+		checksums[header.Name] = checksum
+	}
+	if manifest == nil {
+		return nil, nil, errors.New("archive has no manifest")
+	}
+	if manifest.FormatVersion != BackupFormatVersion {
+		return nil, nil, errors.Errorf("unsupported backup format version %d, expected %d", manifest.FormatVersion, BackupFormatVersion)
+	}
+	return manifest, checksums, nil
+}
+
+func extractFile(r io.Reader, path string) (string, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	checksum := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, checksum), r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(checksum.Sum(nil)), nil
+}
+
+func importTable(tx *gorm.DB, table BackupTable, path string) error {
+	if backupExcludedTables[table.Name] {
+		return nil
+	}
+	var exists bool
+	result := tx.Raw("SELECT EXISTS (SELECT 1 FROM pg_tables WHERE schemaname = ? AND tablename = ?)", schemaName, table.Name).Scan(&exists)
+	if result.Error != nil {
+		return result.Error
+	}
+	if !exists {
+		return errors.New("table does not exist in the target database")
+	}
+
+	result = tx.Exec(fmt.Sprintf("TRUNCATE %s", quoteTable(table.Name)))
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to truncate table")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxJsonlLineSize)
+
+	insert := fmt.Sprintf("INSERT INTO %s SELECT * FROM json_populate_recordset(NULL::%s, ?::json)", quoteTable(table.Name), quoteTable(table.Name))
+	var batch []string
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		result := tx.Exec(insert, "["+strings.Join(batch, ",")+"]")
+		batch = batch[:0]
+		return result.Error
+	}
+	var rows int64
+	for scanner.Scan() {
+		batch = append(batch, scanner.Text())
+		rows++
+		if len(batch) >= backupImportBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	if rows != table.Rows {
+		return errors.Errorf("expected %d rows but the archive contains %d", table.Rows, rows)
+	}
+	return resetSequences(tx, table.Name)
+}
+
+// resetSequences moves the sequences backing serial columns past the imported ids
+func resetSequences(tx *gorm.DB, table string) error {
+	var columns []string
+	result := tx.Raw("SELECT column_name FROM information_schema.columns WHERE table_schema = ? AND table_name = ? AND column_default LIKE 'nextval%'", schemaName, table).Scan(&columns)
+	if result.Error != nil {
+		return result.Error
+	}
+	for _, column := range columns {
+		result := tx.Exec(fmt.Sprintf("SELECT setval(pg_get_serial_sequence(?, ?), COALESCE((SELECT MAX(%q) FROM %s), 0) + 1, false)", column, quoteTable(table)), schemaName+"."+table, column)
+		if result.Error != nil {
+			return errors.Wrapf(result.Error, "failed to reset sequence for %s", column)
+		}
+	}
+	return nil
+}
+
+func quoteTable(table string) string {
+	return fmt.Sprintf("%q.%q", schemaName, table)
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+// GetIncident returns the incident with the given deep link, nil if there is none or it is out of the tenant scope
+func (d *DbClient) GetIncident(ctx context.Context, deepLink string) (*api.Incident, error) {
+	var incident api.Incident
+	result := scopeToTenant(ctx, d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)), "status_page_url").Where("deep_link = ?", deepLink).First(&incident)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &incident, nil
+}
+
+// GetOverlappingIncidents returns the incidents of every status page that were open at some point in [from, to), ordered
+// by status page and start time. Incidents without an end time are open until now if they started in the current
+// incident window, see GetCurrentIncidents. Maintenances are left out
+func (d *DbClient) GetOverlappingIncidents(ctx context.Context, from time.Time, to time.Time) ([]api.Incident, error) {
+	var incidents []api.Incident
+	result := scopeToTenant(ctx, d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)), "status_page_url").
+		Where("start_time < ? AND (end_time > ? OR (end_time IS NULL AND start_time > ?)) AND impact <> ?", to, from, time.Now().Add(-currentIncidentWindow), api.ImpactMaintenance).
+		Order("status_page_url, start_time").
+		Find(&incidents)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return incidents, nil
+}
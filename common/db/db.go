@@ -6,15 +6,13 @@ import (
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/filter"
 	"github.com/metoro-io/statusphere/common/status_pages"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
-	"gorm.io/gorm/logger"
-	"log"
-	"os"
 	"time"
 )
 
@@ -26,6 +24,16 @@ type Config struct {
 	User     string `envconfig:"POSTGRES_USER"`
 	Password string `envconfig:"POSTGRES_PASSWORD"`
 	Database string `envconfig:"POSTGRES_DATABASE"`
+	// UpsertBatchSize is the maximum number of rows written in a single insert statement
+	// Postgres limits a statement to 65535 parameters so large upserts have to be chunked
+	UpsertBatchSize int `envconfig:"DB_UPSERT_BATCH_SIZE" default:"1000"`
+	// DryRun makes all write methods log the rows they would write instead of executing them
+	// This is useful when testing new parsers against a production database
+	DryRun bool `envconfig:"DB_DRY_RUN" default:"false"`
+	// LogLevel is the level gorm logs at, one of silent, error, warn or info
+	LogLevel string `envconfig:"DB_LOG_LEVEL" default:"silent"`
+	// SlowQueryThreshold is the duration after which queries are logged as slow at the warn level
+	SlowQueryThreshold time.Duration `envconfig:"DB_SLOW_QUERY_THRESHOLD" default:"1s"`
 }
 
 func getConfigFromEnvironment() (Config, error) {
@@ -35,8 +43,10 @@ func getConfigFromEnvironment() (Config, error) {
 }
 
 type DbClient struct {
-	db     *gorm.DB
-	logger *zap.Logger
+	db              *gorm.DB
+	logger          *zap.Logger
+	upsertBatchSize int
+	dryRun          bool
 }
 
 func NewDbClientFromEnvironment(lg *zap.Logger) (*DbClient, error) {
@@ -44,11 +54,16 @@ func NewDbClientFromEnvironment(lg *zap.Logger) (*DbClient, error) {
 	if err != nil {
 		return nil, err
 	}
+	logLevel, err := parseGormLogLevel(config.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+	gormLogger := newZapGormLogger(lg, logLevel, config.SlowQueryThreshold)
 
 	// Check to see if the database exists in postgres
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		config.Host, config.Port, config.User, config.Password, config.Database)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormLogger})
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to connect to postgres")
 	}
@@ -72,30 +87,47 @@ func NewDbClientFromEnvironment(lg *zap.Logger) (*DbClient, error) {
 	// Connect to the database
 	dsn = fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		config.Host, config.Port, config.User, config.Password, config.Database)
-	newLogger := logger.New(
-		log.New(os.Stdout, "\r\n", log.LstdFlags), // io writer
-		logger.Config{
-			SlowThreshold:             time.Second,   // Slow SQL threshold
-			LogLevel:                  logger.Silent, // Log level
-			IgnoreRecordNotFoundError: true,          // Ignore ErrRecordNotFound error for logger
-			ParameterizedQueries:      true,          // Don't include params in the SQL log
-			Colorful:                  false,         // Disable color
-		},
-	)
 	db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: newLogger,
+		Logger: gormLogger,
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to connect to postgres")
 	}
 
-	return &DbClient{db: db, logger: lg}, nil
+	err = instrument(db, config.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	upsertBatchSize := config.UpsertBatchSize
+	if upsertBatchSize <= 0 {
+		upsertBatchSize = defaultUpsertBatchSize
+	}
+
+	if config.DryRun {
+		lg.Warn("db client is running in dry run mode, no writes will be executed")
+	}
+
+	return &DbClient{db: db, logger: lg, upsertBatchSize: upsertBatchSize, dryRun: config.DryRun}, nil
 }
 
+const defaultUpsertBatchSize = 1000
+
 const statusPageTableName = "status_page"
 const incidentsTableName = "incidents"
+const providerFeaturesTableName = "provider_features"
+const providerConfigsTableName = "provider_configs"
+const headerProfilesTableName = "header_profiles"
+const maintenancesTableName = "maintenances"
+const componentsTableName = "components"
+const statusSnapshotsTableName = "status_snapshots"
 
 func (d *DbClient) AutoMigrate(ctx context.Context) error {
+	if d.dryRun {
+		d.logger.Info("dry run: skipping auto migration")
+		return nil
+	}
+
 	// Create the schema if it does not exist
 	d.db.Exec(fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", schemaName))
 
@@ -104,10 +136,6 @@ func (d *DbClient) AutoMigrate(ctx context.Context) error {
 	if err != nil {
 		return errors.Wrap(err, "failed to auto-migrate status_page table")
 	}
-	err = d.SeedStatusPages()
-	if err != nil {
-		return errors.Wrap(err, "failed to seed status pages")
-	}
 
 	// Create the incidents table
 	err = d.db.Table(fmt.Sprintf(fmt.Sprintf("%s.%s", schemaName, incidentsTableName))).AutoMigrate(&api.Incident{})
@@ -115,12 +143,129 @@ func (d *DbClient) AutoMigrate(ctx context.Context) error {
 		return errors.Wrap(err, "failed to auto-migrate incidents table")
 	}
 
+	// Index the text of the incidents for SearchIncidents
+	err = d.db.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS incidents_search_idx ON %s.%s USING GIN (%s)", schemaName, incidentsTableName, incidentSearchDocument)).Error
+	if err != nil {
+		return errors.Wrap(err, "failed to create incidents search index")
+	}
+
+	// Create the outbox table
+	err = d.db.Table(fmt.Sprintf("%s.%s", schemaName, outboxTableName)).AutoMigrate(&api.ChangeEvent{})
+	if err != nil {
+		return errors.Wrap(err, "failed to auto-migrate outbox table")
+	}
+
+	// Create the provider features table
+	err = d.db.Table(fmt.Sprintf("%s.%s", schemaName, providerFeaturesTableName)).AutoMigrate(&api.ProviderFeatures{})
+	if err != nil {
+		return errors.Wrap(err, "failed to auto-migrate provider features table")
+	}
+
+	// Create the declarative provider configs table
+	err = d.db.Table(fmt.Sprintf("%s.%s", schemaName, providerConfigsTableName)).AutoMigrate(&api.ProviderConfig{})
+	if err != nil {
+		return errors.Wrap(err, "failed to auto-migrate provider configs table")
+	}
+
+	err = d.db.Table(fmt.Sprintf("%s.%s", schemaName, maintenancesTableName)).AutoMigrate(&api.Maintenance{})
+	if err != nil {
+		return errors.Wrap(err, "failed to auto-migrate maintenances table")
+	}
+
+	err = d.db.Table(fmt.Sprintf("%s.%s", schemaName, componentsTableName)).AutoMigrate(&api.Component{})
+	if err != nil {
+		return errors.Wrap(err, "failed to auto-migrate components table")
+	}
+
+	err = d.db.Table(fmt.Sprintf("%s.%s", schemaName, statusSnapshotsTableName)).AutoMigrate(&api.StatusSnapshot{})
+	if err != nil {
+		return errors.Wrap(err, "failed to auto-migrate status snapshots table")
+	}
+
+	err = d.db.Table(fmt.Sprintf("%s.%s", schemaName, headerProfilesTableName)).AutoMigrate(&api.HeaderProfile{})
+	if err != nil {
+		return errors.Wrap(err, "failed to auto-migrate header profiles table")
+	}
+
+	err = d.db.Table(fmt.Sprintf("%s.%s", schemaName, statusPageAliasesTableName)).AutoMigrate(&api.StatusPageAlias{})
+	if err != nil {
+		return errors.Wrap(err, "failed to auto-migrate status page aliases table")
+	}
+
+	err = d.db.Table(fmt.Sprintf("%s.%s", schemaName, apiKeysTableName)).AutoMigrate(&api.APIKey{})
+	if err != nil {
+		return errors.Wrap(err, "failed to auto-migrate api keys table")
+	}
+
+	err = d.db.Table(fmt.Sprintf("%s.%s", schemaName, subscriptionsTableName)).AutoMigrate(&api.Subscription{})
+	if err != nil {
+		return errors.Wrap(err, "failed to auto-migrate subscriptions table")
+	}
+
+	err = d.db.Table(fmt.Sprintf("%s.%s", schemaName, webhookDeliveriesTableName)).AutoMigrate(&api.WebhookDelivery{})
+	if err != nil {
+		return errors.Wrap(err, "failed to auto-migrate webhook deliveries table")
+	}
+
+	err = d.db.Table(fmt.Sprintf("%s.%s", schemaName, tenantsTableName)).AutoMigrate(&api.Tenant{})
+	if err != nil {
+		return errors.Wrap(err, "failed to auto-migrate tenants table")
+	}
+
+	err = d.db.Table(fmt.Sprintf("%s.%s", schemaName, tenantStatusPagesTableName)).AutoMigrate(&api.TenantStatusPage{})
+	if err != nil {
+		return errors.Wrap(err, "failed to auto-migrate tenant status pages table")
+	}
+
+	err = d.db.Table(fmt.Sprintf("%s.%s", schemaName, statusPageTagsTableName)).AutoMigrate(&api.StatusPageTag{})
+	if err != nil {
+		return errors.Wrap(err, "failed to auto-migrate status page tags table")
+	}
+
+	err = d.db.Table(fmt.Sprintf("%s.%s", schemaName, emailDigestsTableName)).AutoMigrate(&EmailDigest{})
+	if err != nil {
+		return errors.Wrap(err, "failed to auto-migrate email digests table")
+	}
+
+	err = d.db.Table(fmt.Sprintf("%s.%s", schemaName, notificationThrottlesTableName)).AutoMigrate(&NotificationThrottle{})
+	if err != nil {
+		return errors.Wrap(err, "failed to auto-migrate notification throttles table")
+	}
+
+	err = d.db.Table(fmt.Sprintf("%s.%s", schemaName, notificationOutcomesTableName)).AutoMigrate(&NotificationOutcome{})
+	if err != nil {
+		return errors.Wrap(err, "failed to auto-migrate notification outcomes table")
+	}
+
+	err = d.db.Table(fmt.Sprintf("%s.%s", schemaName, dbSizeSnapshotsTableName)).AutoMigrate(&DbSizeSnapshot{})
+	if err != nil {
+		return errors.Wrap(err, "failed to auto-migrate db size snapshots table")
+	}
+
+	// Create the scrape queue claims table
+	err = d.db.Table(fmt.Sprintf("%s.%s", schemaName, scrapeClaimsTableName)).AutoMigrate(&ScrapeClaim{})
+	if err != nil {
+		return errors.Wrap(err, "failed to auto-migrate scrape claims table")
+	}
+
+	// Create the scraper leader table
+	err = d.db.Table(fmt.Sprintf("%s.%s", schemaName, leaderTableName)).AutoMigrate(&LeaderHeartbeat{})
+	if err != nil {
+		return errors.Wrap(err, "failed to auto-migrate scraper leader table")
+	}
+
+	// Seeded last as the moved status pages are looked up in the aliases table
+	err = d.SeedStatusPages()
+	if err != nil {
+		return errors.Wrap(err, "failed to seed status pages")
+	}
+
 	return nil
 }
 
 func (d *DbClient) GetAllStatusPages(ctx context.Context) ([]api.StatusPage, error) {
 	var statusPages []api.StatusPage
-	result := d.db.Table(fmt.Sprintf(fmt.Sprintf("%s.%s", schemaName, statusPageTableName))).Find(&statusPages)
+	result := scopeToTenant(ctx, d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, statusPageTableName)), "url").Find(&statusPages)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -140,6 +285,10 @@ func (d *DbClient) GetStatusPage(ctx context.Context, url string) (*api.StatusPa
 }
 
 func (d *DbClient) UpdateStatusPage(ctx context.Context, statusPage api.StatusPage) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would update status page", zap.Any("statusPage", statusPage))
+		return nil
+	}
 	result := d.db.Table(fmt.Sprintf(fmt.Sprintf("%s.%s", schemaName, statusPageTableName))).Where("url = ?", statusPage.URL).Updates(&statusPage)
 	if result.Error != nil {
 		return result.Error
@@ -148,6 +297,10 @@ func (d *DbClient) UpdateStatusPage(ctx context.Context, statusPage api.StatusPa
 }
 
 func (d *DbClient) InsertStatusPage(ctx context.Context, statusPage api.StatusPage) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would insert status page", zap.Any("statusPage", statusPage))
+		return nil
+	}
 	result := d.db.Table(fmt.Sprintf(fmt.Sprintf("%s.%s", schemaName, statusPageTableName))).Create(&statusPage)
 	if result.Error != nil {
 		return result.Error
@@ -155,6 +308,19 @@ func (d *DbClient) InsertStatusPage(ctx context.Context, statusPage api.StatusPa
 	return nil
 }
 
+// EnsureStatusPages inserts the status pages that aren't stored yet, the ones that are keep their stored fields
+func (d *DbClient) EnsureStatusPages(ctx context.Context, statusPages []api.StatusPage) error {
+	if len(statusPages) == 0 {
+		return nil
+	}
+	if d.dryRun {
+		d.logger.Info("dry run: would ensure status pages", zap.Int("count", len(statusPages)))
+		return nil
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, statusPageTableName)).Clauses(clause.OnConflict{DoNothing: true}).Create(&statusPages)
+	return result.Error
+}
+
 func (d *DbClient) GetIncidents(ctx context.Context, statusPageUrl string) ([]api.Incident, error) {
 	var incidents []api.Incident
 	result := d.db.Table(fmt.Sprintf(fmt.Sprintf("%s.%s", schemaName, incidentsTableName))).Where("status_page_url = ?", statusPageUrl).Find(&incidents)
@@ -164,39 +330,763 @@ func (d *DbClient) GetIncidents(ctx context.Context, statusPageUrl string) ([]ap
 	return incidents, nil
 }
 
+// DeleteIncidents deletes every incident of the given status page
+func (d *DbClient) DeleteIncidents(ctx context.Context, statusPageUrl string) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would delete incidents", zap.String("url", statusPageUrl))
+		return nil
+	}
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return deleteIncidents(tx, statusPageUrl, d.upsertBatchSize)
+	})
+}
+
+// deleteIncidents deletes the incidents and maintenances of the status page, syncing clients are sent a deletion of each incident
+func deleteIncidents(tx *gorm.DB, statusPageUrl string, batchSize int) error {
+	var incidents []api.Incident
+	result := tx.Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).Where("status_page_url = ?", statusPageUrl).Find(&incidents)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to get incidents to delete")
+	}
+	result = tx.Table(fmt.Sprintf("%s.%s", schemaName, maintenancesTableName)).Where("status_page_url = ?", statusPageUrl).Delete(&api.Maintenance{})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to delete maintenances")
+	}
+	if len(incidents) == 0 {
+		return nil
+	}
+
+	// Record the deletions so that syncing clients drop the incidents too
+	events := make([]api.ChangeEvent, 0, len(incidents))
+	for _, incident := range incidents {
+		events = append(events, api.NewChangeEvent(api.ChangeEventIncidentDeleted, incident))
+	}
+	result = tx.Table(fmt.Sprintf("%s.%s", schemaName, outboxTableName)).CreateInBatches(&events, batchSize)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to write outbox events")
+	}
+
+	result = tx.Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).Where("status_page_url = ?", statusPageUrl).Delete(&api.Incident{})
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// DeleteStatusPage deletes the status page along with its incidents, maintenances, components, status history and aliases
+// It is no longer tracked by the tenants that tracked it
+func (d *DbClient) DeleteStatusPage(ctx context.Context, statusPageUrl string) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would delete status page", zap.String("url", statusPageUrl))
+		return nil
+	}
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := deleteIncidents(tx, statusPageUrl, d.upsertBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, table := range []string{componentsTableName, statusSnapshotsTableName, statusPageAliasesTableName} {
+			result := tx.Exec(fmt.Sprintf("DELETE FROM %s.%s WHERE status_page_url = ?", schemaName, table), statusPageUrl)
+			if result.Error != nil {
+				return errors.Wrapf(result.Error, "failed to delete %s", table)
+			}
+		}
+		result := tx.Table(fmt.Sprintf("%s.%s", schemaName, scrapeClaimsTableName)).Where("url = ?", statusPageUrl).Delete(&ScrapeClaim{})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to delete scrape claim")
+		}
+		result = tx.Table(fmt.Sprintf("%s.%s", schemaName, tenantStatusPagesTableName)).Where("status_page_url = ?", statusPageUrl).Delete(&api.TenantStatusPage{})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to delete tenant status pages")
+		}
+		result = tx.Table(fmt.Sprintf("%s.%s", schemaName, statusPageTagsTableName)).Where("status_page_url = ?", statusPageUrl).Delete(&api.StatusPageTag{})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to delete status page tags")
+		}
+		result = tx.Table(fmt.Sprintf("%s.%s", schemaName, statusPageTableName)).Where("url = ?", statusPageUrl).Delete(&api.StatusPage{})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to delete status page")
+		}
+		return nil
+	})
+}
+
+// ResetLastScraped marks the status page as never having been scraped so it is scraped again, including historically
+func (d *DbClient) ResetLastScraped(ctx context.Context, statusPageUrl string) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would reset last scraped times", zap.String("url", statusPageUrl))
+		return nil
+	}
+	result := d.db.Table(fmt.Sprintf("%s.%s", schemaName, statusPageTableName)).Where("url = ?", statusPageUrl).Updates(map[string]interface{}{
+		"last_historically_scraped": time.Time{},
+		"last_currently_scraped":    time.Time{},
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// SetStatusPageHasActiveIncident records whether the last scrape of the status page found an ongoing incident
+func (d *DbClient) SetStatusPageHasActiveIncident(ctx context.Context, statusPageUrl string, active bool) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would set status page active incident", zap.String("url", statusPageUrl), zap.Bool("active", active))
+		return nil
+	}
+	// Update rather than Updates so that false is written
+	result := d.db.Table(fmt.Sprintf("%s.%s", schemaName, statusPageTableName)).Where("url = ?", statusPageUrl).Update("has_active_incident", active)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// SetStatusPagePaused pauses the scrapes of the status page from pausedAt, a nil pausedAt resumes them
+func (d *DbClient) SetStatusPagePaused(ctx context.Context, statusPageUrl string, pausedAt *time.Time) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would set status page paused", zap.String("url", statusPageUrl), zap.Bool("paused", pausedAt != nil))
+		return nil
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, statusPageTableName)).Where("url = ?", statusPageUrl).Update("paused_at", pausedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// SetStatusPageBackfillCursor records where an unfinished backfill of the status page resumes from, an empty cursor clears it
+func (d *DbClient) SetStatusPageBackfillCursor(ctx context.Context, statusPageUrl string, cursor string) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would set status page backfill cursor", zap.String("url", statusPageUrl), zap.String("cursor", cursor))
+		return nil
+	}
+	// Update rather than Updates so that an empty cursor is written
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, statusPageTableName)).Where("url = ?", statusPageUrl).Update("backfill_cursor", cursor)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// SetStatusPageProvider records the provider that was detected for the status page, an empty provider clears it
+func (d *DbClient) SetStatusPageProvider(ctx context.Context, statusPageUrl string, provider string, detectedAt *time.Time) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would set status page provider", zap.String("url", statusPageUrl), zap.String("provider", provider))
+		return nil
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, statusPageTableName)).Where("url = ?", statusPageUrl).
+		Updates(map[string]interface{}{"provider": provider, "provider_detected_at": detectedAt})
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// SetStatusPageScrapeConfig replaces the scrape config of the status page, nil clears it
+func (d *DbClient) SetStatusPageScrapeConfig(ctx context.Context, statusPageUrl string, config *api.ScrapeConfig) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would set status page scrape config", zap.String("url", statusPageUrl), zap.Any("scrapeConfig", config))
+		return nil
+	}
+	statusPage := api.StatusPage{ScrapeConfig: config}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, statusPageTableName)).Where("url = ?", statusPageUrl).
+		Select("scrape_config").Updates(&statusPage)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// SetStatusPageContentHash records the hash of the content whose incidents were last processed for the status page
+func (d *DbClient) SetStatusPageContentHash(ctx context.Context, statusPageUrl string, hash string, hashedAt time.Time) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would set status page content hash", zap.String("url", statusPageUrl), zap.String("hash", hash))
+		return nil
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, statusPageTableName)).Where("url = ?", statusPageUrl).
+		Updates(map[string]interface{}{"content_hash": hash, "content_hashed_at": hashedAt})
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// SetStatusPageMetadata records the metadata read from the home page of the status page
+func (d *DbClient) SetStatusPageMetadata(ctx context.Context, statusPageUrl string, metadata api.StatusPageMetadata, refreshedAt time.Time) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would set status page metadata", zap.String("url", statusPageUrl), zap.Any("metadata", metadata))
+		return nil
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, statusPageTableName)).Where("url = ?", statusPageUrl).
+		Updates(map[string]interface{}{
+			"display_name":          metadata.DisplayName,
+			"description":           metadata.Description,
+			"twitter_handle":        metadata.TwitterHandle,
+			"support_url":           metadata.SupportURL,
+			"favicon_url":           metadata.FaviconURL,
+			"metadata_refreshed_at": refreshedAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// RequestBackfill clears the backfilled time and cursor of the status page so that the scraper walks its whole incident archive
+// again from the beginning
+// It returns an error if there is no status page with the url
+func (d *DbClient) RequestBackfill(ctx context.Context, statusPageUrl string) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would request backfill", zap.String("url", statusPageUrl))
+		return nil
+	}
+	// Clearing the last historically scraped time as well means the backfill isn't held back by a recent failed attempt
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, statusPageTableName)).Where("url = ?", statusPageUrl).
+		Updates(map[string]interface{}{"backfilled_at": nil, "backfill_cursor": "", "last_historically_scraped": time.Time{}})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("status page not found")
+	}
+	return nil
+}
+
+// UpdateStatusPageScrapeResult writes the outcome of the last scrape of the status page
+// Unlike UpdateStatusPage the zero values are written, e.g. to clear the last error once a scrape succeeds
+func (d *DbClient) UpdateStatusPageScrapeResult(ctx context.Context, statusPage api.StatusPage) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would update status page scrape result", zap.String("url", statusPage.URL), zap.String("lastError", statusPage.LastError))
+		return nil
+	}
+	result := d.db.Table(fmt.Sprintf("%s.%s", schemaName, statusPageTableName)).Where("url = ?", statusPage.URL).
+		Select("last_currently_scraped", "is_indexed", "consecutive_failure_count", "last_successful_scrape_at", "last_error", "scrape_duration_ms", "dead_since", "is_dead").
+		Updates(&statusPage)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// QueryIncidents returns the page of the incidents matching the given filter expression, most recent first
+// The key of an incident is its start time and deep link, see IncidentPageKey
+func (d *DbClient) QueryIncidents(ctx context.Context, expression *filter.Expression, page Page) ([]api.Incident, error) {
+	var incidents []api.Incident
+	where, args := expression.ToSQL()
+	tx := scopeToTenant(ctx, d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).Where(where, args...), "status_page_url")
+	result := paginate(tx, page, true, keyColumn{expression: "start_time", cast: "timestamptz"}, keyColumn{expression: "deep_link", cast: "text"}).Find(&incidents)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return incidents, nil
+}
+
+// CountIncidents returns the number of incidents matching the given filter expression
+func (d *DbClient) CountIncidents(ctx context.Context, expression *filter.Expression) (int64, error) {
+	var count int64
+	where, args := expression.ToSQL()
+	result := scopeToTenant(ctx, d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).Where(where, args...), "status_page_url").Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return count, nil
+}
+
+// currentIncidentWindow is how recently a current incident has to have started
+const currentIncidentWindow = 14 * 24 * time.Hour
+
 // Current incidents are incidents that have not ended and have a start time in the last two weeks
 // The two week cutiff is not ideal but some incidents don't have a specified end time
 func (d *DbClient) GetCurrentIncidents(ctx context.Context, statusPageUrl string) ([]api.Incident, error) {
 	var incidents []api.Incident
-	result := d.db.Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).Where("status_page_url = ? AND start_time > ? AND end_time = NULL", statusPageUrl, time.Now().Add(-14*24*time.Hour)).Find(&incidents)
+	result := d.db.Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).Where("status_page_url = ? AND start_time > ? AND end_time IS NULL", statusPageUrl, time.Now().Add(-currentIncidentWindow)).Find(&incidents)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return incidents, nil
 }
 
-func (d *DbClient) CreateOrUpdateIncidents(ctx context.Context, incidents []api.Incident) error {
-	result := d.db.Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).Clauses(
+// GetAllCurrentIncidents returns the current incidents of every status page in one query, see GetCurrentIncidents
+func (d *DbClient) GetAllCurrentIncidents(ctx context.Context) ([]api.Incident, error) {
+	var incidents []api.Incident
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).
+		Where("start_time > ? AND end_time IS NULL", time.Now().Add(-currentIncidentWindow)).
+		Order("start_time DESC").
+		Find(&incidents)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return incidents, nil
+}
+
+// CreateOrUpdateIncidents upserts the given incidents keyed on their deep link
+// The incidents are written in chunks of upsertBatchSize to stay under the postgres parameter limit
+// A change event is written to the outbox for every incident that is created, updated or resolved, the events are returned
+func (d *DbClient) CreateOrUpdateIncidents(ctx context.Context, incidents []api.Incident) ([]api.ChangeEvent, error) {
+	return d.upsertIncidents(ctx, incidents, true)
+}
+
+// CreateOrUpdateIncidentsWithoutPublishing is CreateOrUpdateIncidents for maintenance writes such as imports, backfills
+// and corrections. Their change events are written already dispatched, so /sync still returns the changes but they
+// aren't published to the notifiers and webhooks
+func (d *DbClient) CreateOrUpdateIncidentsWithoutPublishing(ctx context.Context, incidents []api.Incident) ([]api.ChangeEvent, error) {
+	return d.upsertIncidents(ctx, incidents, false)
+}
+
+func (d *DbClient) upsertIncidents(ctx context.Context, incidents []api.Incident, publish bool) ([]api.ChangeEvent, error) {
+	if len(incidents) == 0 {
+		return nil, nil
+	}
+	if d.dryRun {
+		for _, incident := range incidents {
+			d.logger.Info("dry run: would upsert incident", zap.Any("incident", incident))
+		}
+		return nil, nil
+	}
+	// The change events are written in the same transaction as the incidents so they can never diverge
+	var changes []api.ChangeEvent
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		changes = nil
+		for start := 0; start < len(incidents); start += d.upsertBatchSize {
+			batch := incidents[start:min(start+d.upsertBatchSize, len(incidents))]
+			events, err := changeEventsForUpsert(tx, batch, true)
+			if err != nil {
+				return err
+			}
+
+			result := tx.Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).Clauses(
+				clause.OnConflict{
+					Columns:   []clause.Column{{Name: "deep_link"}},                                                                                                                                                                                                                                                         // Primary key
+					DoUpdates: clause.AssignmentColumns([]string{"title", "components", "events", "start_time", "end_time", "end_time_inferred", "description", "description_text", "description_html", "impact", "raw_impact", "status_page_url", "language", "translated_title", "translated_description", "updated_at"}), // Update the data column
+				},
+			).Create(&batch)
+			if result.Error != nil {
+				return result.Error
+			}
+
+			if !publish {
+				dispatchedAt := time.Now().UTC()
+				for i := range events {
+					events[i].DispatchedAt = &dispatchedAt
+				}
+			}
+			if len(events) > 0 {
+				result = tx.Table(fmt.Sprintf("%s.%s", schemaName, outboxTableName)).CreateInBatches(&events, d.upsertBatchSize)
+				if result.Error != nil {
+					return errors.Wrap(result.Error, "failed to write outbox events")
+				}
+			}
+			changes = append(changes, events...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// InferIncidentResolutions resolves the ongoing incidents of the status page that a current scrape at scrapedAt
+// no longer found, their end time is set to scrapedAt and marked as inferred. Incidents the scrape found ongoing
+// that had an inferred end time are reopened, in case they were only missing from a scrape by mistake.
+// Only incidents that started in the last activeIncidentWindow are considered, older ones are left as they are
+func (d *DbClient) InferIncidentResolutions(ctx context.Context, statusPageUrl string, scraped []api.Incident, scrapedAt time.Time) ([]api.ChangeEvent, error) {
+	scrapedByDeepLink := make(map[string]api.Incident, len(scraped))
+	for _, incident := range scraped {
+		scrapedByDeepLink[incident.DeepLink] = incident
+	}
+	table := fmt.Sprintf("%s.%s", schemaName, incidentsTableName)
+	var candidates []api.Incident
+	result := d.db.WithContext(ctx).Table(table).
+		Where("status_page_url = ? AND start_time > ? AND start_time <= ? AND (end_time IS NULL OR end_time_inferred)", statusPageUrl, scrapedAt.Add(-activeIncidentWindow), scrapedAt).
+		Find(&candidates)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "failed to get ongoing incidents")
+	}
+
+	var changed []api.Incident
+	var events []api.ChangeEvent
+	for _, incident := range candidates {
+		current, found := scrapedByDeepLink[incident.DeepLink]
+		switch {
+		case incident.EndTime == nil && !found:
+			incident.EndTime = &scrapedAt
+			incident.EndTimeInferred = true
+			events = append(events, api.NewChangeEvent(api.ChangeEventIncidentResolved, incident))
+		case incident.EndTimeInferred && found && current.EndTime == nil:
+			incident.EndTime = nil
+			incident.EndTimeInferred = false
+			events = append(events, api.NewChangeEvent(api.ChangeEventIncidentUpdated, incident))
+		default:
+			continue
+		}
+		changed = append(changed, incident)
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+	if d.dryRun {
+		for _, incident := range changed {
+			d.logger.Info("dry run: would infer incident resolution", zap.String("deepLink", incident.DeepLink), zap.Timep("endTime", incident.EndTime))
+		}
+		return nil, nil
+	}
+
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, incident := range changed {
+			result := tx.Table(table).Where("deep_link = ?", incident.DeepLink).Updates(map[string]interface{}{
+				"end_time":          incident.EndTime,
+				"end_time_inferred": incident.EndTimeInferred,
+				"updated_at":        time.Now().UTC(),
+			})
+			if result.Error != nil {
+				return errors.Wrap(result.Error, "failed to update incident end time")
+			}
+		}
+		result := tx.Table(fmt.Sprintf("%s.%s", schemaName, outboxTableName)).CreateInBatches(&events, d.upsertBatchSize)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to write outbox events")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// activeIncidentWindow is how long after it started an incident without an end time can have its resolution inferred
+// Some status pages never resolve their incidents, the ones that have been open for longer are not inferred all at once
+const activeIncidentWindow = 14 * 24 * time.Hour
+
+// CreateOrUpdateMaintenances upserts the given maintenances keyed on their deep link
+func (d *DbClient) CreateOrUpdateMaintenances(ctx context.Context, maintenances []api.Maintenance) error {
+	if len(maintenances) == 0 {
+		return nil
+	}
+	if d.dryRun {
+		for _, maintenance := range maintenances {
+			d.logger.Info("dry run: would upsert maintenance", zap.Any("maintenance", maintenance))
+		}
+		return nil
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, maintenancesTableName)).Clauses(
 		clause.OnConflict{
-			Columns:   []clause.Column{{Name: "deep_link"}},                                                                                                      // Primary key
-			DoUpdates: clause.AssignmentColumns([]string{"title", "components", "events", "start_time", "end_time", "description", "impact", "status_page_url"}), // Update the data column
+			Columns:   []clause.Column{{Name: "deep_link"}},
+			DoUpdates: clause.AssignmentColumns([]string{"title", "components", "updates", "scheduled_start", "scheduled_end", "end_time", "state", "description", "status_page_url"}),
 		},
-	).Create(&incidents)
+	).CreateInBatches(&maintenances, d.upsertBatchSize)
 	if result.Error != nil {
 		return result.Error
 	}
 	return nil
 }
 
+// GetMaintenances returns the maintenances of the status page
+func (d *DbClient) GetMaintenances(ctx context.Context, statusPageUrl string) ([]api.Maintenance, error) {
+	var maintenances []api.Maintenance
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, maintenancesTableName)).Where("status_page_url = ?", statusPageUrl).Order("scheduled_start DESC").Find(&maintenances)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return maintenances, nil
+}
+
+// GetMaintenancesPage returns the page of the maintenances of the status page, latest first
+// The key of a maintenance is its scheduled start and deep link
+func (d *DbClient) GetMaintenancesPage(ctx context.Context, statusPageUrl string, page Page) ([]api.Maintenance, error) {
+	var maintenances []api.Maintenance
+	tx := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, maintenancesTableName)).Where("status_page_url = ?", statusPageUrl)
+	result := paginate(tx, page, true, keyColumn{expression: "scheduled_start", cast: "timestamptz"}, keyColumn{expression: "deep_link", cast: "text"}).Find(&maintenances)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return maintenances, nil
+}
+
+// CountMaintenances returns the number of maintenances of the status page
+func (d *DbClient) CountMaintenances(ctx context.Context, statusPageUrl string) (int64, error) {
+	var count int64
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, maintenancesTableName)).Where("status_page_url = ?", statusPageUrl).Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return count, nil
+}
+
+// GetUpcomingMaintenances returns the maintenances of every status page whose window ends after since, or starts after it
+// if it has no scheduled end, earliest first
+func (d *DbClient) GetUpcomingMaintenances(ctx context.Context, since time.Time) ([]api.Maintenance, error) {
+	var maintenances []api.Maintenance
+	tx := scopeToTenant(ctx, d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, maintenancesTableName)).Where("COALESCE(scheduled_end, scheduled_start) >= ?", since), "status_page_url")
+	result := tx.Order("scheduled_start").Find(&maintenances)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return maintenances, nil
+}
+
+// CreateOrUpdateComponents upserts the given components keyed on their status page and name
+func (d *DbClient) CreateOrUpdateComponents(ctx context.Context, components []api.Component) error {
+	if len(components) == 0 {
+		return nil
+	}
+	if d.dryRun {
+		for _, component := range components {
+			d.logger.Info("dry run: would upsert component", zap.Any("component", component))
+		}
+		return nil
+	}
+	return d.createOrUpdateComponents(d.db.WithContext(ctx), components)
+}
+
+func (d *DbClient) createOrUpdateComponents(tx *gorm.DB, components []api.Component) error {
+	result := tx.Table(fmt.Sprintf("%s.%s", schemaName, componentsTableName)).Clauses(
+		clause.OnConflict{
+			Columns:   []clause.Column{{Name: "status_page_url"}, {Name: "name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"group_name", "status", "description", "updated_at"}),
+		},
+	).CreateInBatches(&components, d.upsertBatchSize)
+	return result.Error
+}
+
+// ReplaceComponents makes the given components the component list of the status page
+// Components that are no longer listed are deleted
+func (d *DbClient) ReplaceComponents(ctx context.Context, statusPageUrl string, components []api.Component) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would replace components", zap.String("url", statusPageUrl), zap.Int("count", len(components)))
+		return nil
+	}
+	names := make([]string, 0, len(components))
+	for i := range components {
+		components[i].StatusPageUrl = statusPageUrl
+		names = append(names, components[i].Name)
+	}
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Table(fmt.Sprintf("%s.%s", schemaName, componentsTableName)).Where("status_page_url = ?", statusPageUrl)
+		if len(names) > 0 {
+			query = query.Where("name NOT IN ?", names)
+		}
+		result := query.Delete(&api.Component{})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to delete removed components")
+		}
+		if len(components) == 0 {
+			return nil
+		}
+		return d.createOrUpdateComponents(tx, components)
+	})
+}
+
+// GetComponents returns the component list of the status page
+func (d *DbClient) GetComponents(ctx context.Context, statusPageUrl string) ([]api.Component, error) {
+	var components []api.Component
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, componentsTableName)).Where("status_page_url = ?", statusPageUrl).Order("group_name, name").Find(&components)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return components, nil
+}
+
+// GetComponentsPage returns the page of the component list of the status page
+// The key of a component is its group and name
+func (d *DbClient) GetComponentsPage(ctx context.Context, statusPageUrl string, page Page) ([]api.Component, error) {
+	var components []api.Component
+	tx := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, componentsTableName)).Where("status_page_url = ?", statusPageUrl)
+	result := paginate(tx, page, false, keyColumn{expression: "group_name", cast: "text"}, keyColumn{expression: "name", cast: "text"}).Find(&components)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return components, nil
+}
+
+// CountComponents returns the number of components of the status page
+func (d *DbClient) CountComponents(ctx context.Context, statusPageUrl string) (int64, error) {
+	var count int64
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, componentsTableName)).Where("status_page_url = ?", statusPageUrl).Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return count, nil
+}
+
+// GetComponent returns the named component of the status page, or nil if it doesn't exist
+func (d *DbClient) GetComponent(ctx context.Context, statusPageUrl string, name string) (*api.Component, error) {
+	var component api.Component
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, componentsTableName)).Where("status_page_url = ? AND name = ?", statusPageUrl, name).First(&component)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &component, nil
+}
+
+// DeleteComponents deletes the component list of the status page
+func (d *DbClient) DeleteComponents(ctx context.Context, statusPageUrl string) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would delete components", zap.String("url", statusPageUrl))
+		return nil
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, componentsTableName)).Where("status_page_url = ?", statusPageUrl).Delete(&api.Component{})
+	return result.Error
+}
+
+// InsertStatusSnapshot stores the overall status of a status page at a point in time
+func (d *DbClient) InsertStatusSnapshot(ctx context.Context, snapshot api.StatusSnapshot) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would insert status snapshot", zap.Any("snapshot", snapshot))
+		return nil
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, statusSnapshotsTableName)).Clauses(clause.OnConflict{DoNothing: true}).Create(&snapshot)
+	return result.Error
+}
+
+// GetStatusSnapshotAt returns the snapshot of the status page that was in effect at the given time
+// It returns nil if there is no snapshot from before then
+func (d *DbClient) GetStatusSnapshotAt(ctx context.Context, statusPageUrl string, at time.Time) (*api.StatusSnapshot, error) {
+	var snapshot api.StatusSnapshot
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, statusSnapshotsTableName)).
+		Where("status_page_url = ? AND time <= ?", statusPageUrl, at).Order("time DESC").First(&snapshot)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &snapshot, nil
+}
+
+// GetStatusSnapshots returns the snapshots of the status page taken in [from, to), oldest first
+func (d *DbClient) GetStatusSnapshots(ctx context.Context, statusPageUrl string, from time.Time, to time.Time) ([]api.StatusSnapshot, error) {
+	var snapshots []api.StatusSnapshot
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, statusSnapshotsTableName)).
+		Where("status_page_url = ? AND time >= ? AND time < ?", statusPageUrl, from, to).Order("time").Find(&snapshots)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return snapshots, nil
+}
+
+// GetIncidentCountSince returns the number of incidents that started after the given time
+func (d *DbClient) GetIncidentCountSince(ctx context.Context, since time.Time) (int64, error) {
+	var count int64
+	result := d.db.Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).Where("start_time > ?", since).Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return count, nil
+}
+
+// GetTotalSizeBytes returns the total on disk size of all the statusphere tables, including indexes
+func (d *DbClient) GetTotalSizeBytes(ctx context.Context) (int64, error) {
+	var size int64
+	result := d.db.Raw("SELECT COALESCE(SUM(pg_total_relation_size(quote_ident(schemaname) || '.' || quote_ident(tablename))), 0) FROM pg_tables WHERE schemaname = ?", schemaName).Scan(&size)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return size, nil
+}
+
+// ReplaceProviderFeatures replaces the stored provider feature matrix with the given one
+// The scraper calls this on startup so the api server always serves the matrix of the deployed providers
+func (d *DbClient) ReplaceProviderFeatures(ctx context.Context, features []api.ProviderFeatures) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would replace provider features", zap.Any("features", features))
+		return nil
+	}
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Table(fmt.Sprintf("%s.%s", schemaName, providerFeaturesTableName)).Where("1 = 1").Delete(&api.ProviderFeatures{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if len(features) == 0 {
+			return nil
+		}
+		return tx.Table(fmt.Sprintf("%s.%s", schemaName, providerFeaturesTableName)).Create(&features).Error
+	})
+}
+
+func (d *DbClient) GetProviderFeatures(ctx context.Context) ([]api.ProviderFeatures, error) {
+	var features []api.ProviderFeatures
+	result := d.db.Table(fmt.Sprintf("%s.%s", schemaName, providerFeaturesTableName)).Order("provider").Find(&features)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return features, nil
+}
+
+// GetProviderConfigs returns the stored declarative provider definitions
+func (d *DbClient) GetProviderConfigs(ctx context.Context) ([]api.ProviderConfig, error) {
+	var configs []api.ProviderConfig
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, providerConfigsTableName)).Order("name").Find(&configs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return configs, nil
+}
+
+// GetHeaderProfiles returns the stored request header profiles of the providers
+func (d *DbClient) GetHeaderProfiles(ctx context.Context) ([]api.HeaderProfile, error) {
+	var profiles []api.HeaderProfile
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, headerProfilesTableName)).Order("provider").Find(&profiles)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return profiles, nil
+}
+
+// SeedStatusPages upserts the status pages of the vendor catalog, so a new deployment isn't empty
+// A status page that is already stored only has its name, category and logo updated from the catalog
 func (d *DbClient) SeedStatusPages() error {
+	ctx := context.Background()
+	stored, err := d.GetAllStatusPages(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get status pages")
+	}
+	storedByUrl := make(map[string]api.StatusPage, len(stored))
+	for _, statusPage := range stored {
+		storedByUrl[statusPage.URL] = statusPage
+	}
+	aliases, err := d.GetStatusPageAliases(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get status page aliases")
+	}
+	moved := make(map[string]bool, len(aliases))
+	for _, alias := range aliases {
+		moved[alias.URL] = true
+	}
+
+	table := fmt.Sprintf("%s.%s", schemaName, statusPageTableName)
 	for _, statusPage := range status_pages.StatusPages {
-		if page, err := d.GetStatusPage(context.Background(), statusPage.URL); err != nil || page == nil {
-			// Status page already exists
-			err := d.InsertStatusPage(context.Background(), statusPage)
+		// A seeded status page that has moved is already stored at the url it redirects to
+		if moved[statusPage.URL] {
+			continue
+		}
+		existing, found := storedByUrl[statusPage.URL]
+		if !found {
+			err := d.InsertStatusPage(ctx, statusPage)
 			if err != nil {
 				return errors.Wrap(err, "failed to seed status pages")
 			}
-			d.logger.Info("Seeded status page: %s", zap.String("url", statusPage.URL))
+			d.logger.Info("seeded status page", zap.String("url", statusPage.URL))
+			continue
+		}
+		if existing.Name == statusPage.Name && existing.Category == statusPage.Category && existing.LogoURL == statusPage.LogoURL {
+			continue
+		}
+		if d.dryRun {
+			d.logger.Info("dry run: would update seeded status page", zap.String("url", statusPage.URL))
+			continue
+		}
+		result := d.db.WithContext(ctx).Table(table).Where("url = ?", statusPage.URL).Updates(map[string]interface{}{
+			"name":     statusPage.Name,
+			"category": statusPage.Category,
+			"logo_url": statusPage.LogoURL,
+		})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to update seeded status page")
 		}
 	}
 	return nil
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"sort"
+	"time"
+)
+
+const incidentsDeepLinkIndexName = "incidents_deep_link_unique"
+
+// Deduplicate merges incidents that share a deep link into a single row and then adds a unique index on deep_link
+// Tables created before deep_link was the conflict key can hold several rows for the same incident
+// which breaks the upserts. It returns the number of deep links that were merged and is safe to run repeatedly.
+func (d *DbClient) Deduplicate(ctx context.Context) (int, error) {
+	table := fmt.Sprintf("%s.%s", schemaName, incidentsTableName)
+
+	var deepLinks []string
+	err := d.db.WithContext(ctx).Table(table).
+		Select("deep_link").
+		Group("deep_link").
+		Having("count(*) > 1").
+		Pluck("deep_link", &deepLinks).Error
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to find duplicated incidents")
+	}
+
+	if d.dryRun {
+		d.logger.Info("dry run: would merge duplicated incidents", zap.Strings("deepLinks", deepLinks))
+		return len(deepLinks), nil
+	}
+
+	for _, deepLink := range deepLinks {
+		err = d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var duplicates []api.Incident
+			err := tx.Table(table).Where("deep_link = ?", deepLink).Find(&duplicates).Error
+			if err != nil {
+				return errors.Wrap(err, "failed to get duplicated incidents")
+			}
+			merged := mergeIncidents(duplicates)
+			err = tx.Table(table).Where("deep_link = ?", deepLink).Delete(&api.Incident{}).Error
+			if err != nil {
+				return errors.Wrap(err, "failed to delete duplicated incidents")
+			}
+			return errors.Wrap(tx.Table(table).Create(&merged).Error, "failed to insert merged incident")
+		})
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to merge incidents with deep link %s", deepLink)
+		}
+	}
+
+	err = d.db.WithContext(ctx).Exec(fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s (deep_link)", incidentsDeepLinkIndexName, table)).Error
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create unique index on deep_link")
+	}
+	d.logger.Info("deduplicated incidents", zap.Int("merged", len(deepLinks)))
+	return len(deepLinks), nil
+}
+
+// mergeIncidents combines rows of the same incident, the widest time range and most severe impact win
+// and the updates of every row are kept. An incident can be reopened, see InferIncidentResolutions, so it is only
+// resolved if the row that was updated last is
+func mergeIncidents(duplicates []api.Incident) api.Incident {
+	latest := duplicates[0]
+	for _, incident := range duplicates {
+		if incident.UpdatedAt.After(latest.UpdatedAt) {
+			latest = incident
+		}
+	}
+	merged := duplicates[0]
+	merged.UpdatedAt = latest.UpdatedAt
+	merged.EndTime = nil
+	merged.EndTimeInferred = false
+	seenUpdates := map[string]bool{}
+	seenComponents := map[string]bool{}
+	var updates api.IncidentUpdateArray
+	var components []string
+	for _, incident := range duplicates {
+		if incident.StartTime.Before(merged.StartTime) {
+			merged.StartTime = incident.StartTime
+		}
+		if latest.EndTime != nil && incident.EndTime != nil && (merged.EndTime == nil || incident.EndTime.After(*merged.EndTime)) {
+			merged.EndTime = incident.EndTime
+			merged.EndTimeInferred = incident.EndTimeInferred
+		}
+		if incident.Impact.Severity() > merged.Impact.Severity() {
+			merged.Impact = incident.Impact
+		}
+		if merged.Description == nil {
+			merged.Description = incident.Description
+			merged.DescriptionText = incident.DescriptionText
+			merged.DescriptionHTML = incident.DescriptionHTML
+		}
+		for _, update := range incident.Events {
+			key := fmt.Sprintf("%s|%s|%s", update.Time.UTC().Format(time.RFC3339Nano), update.State, update.Body)
+			if !seenUpdates[key] {
+				seenUpdates[key] = true
+				updates = append(updates, update)
+			}
+		}
+		for _, component := range incident.Components {
+			if !seenComponents[component] {
+				seenComponents[component] = true
+				components = append(components, component)
+			}
+		}
+	}
+	sort.SliceStable(updates, func(i, j int) bool {
+		return updates[i].Time.Before(updates[j].Time)
+	})
+	merged.Events = updates
+	merged.Components = components
+	return merged
+}
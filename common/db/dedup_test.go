@@ -0,0 +1,109 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/api"
+)
+
+func TestMergeIncidents(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time {
+		return start.Add(time.Duration(minutes) * time.Minute)
+	}
+	endingAt := func(minutes int) *time.Time {
+		end := at(minutes)
+		return &end
+	}
+	investigating := api.IncidentUpdate{Time: at(0), State: api.IncidentStateInvestigating, Body: "Investigating"}
+	monitoring := api.IncidentUpdate{Time: at(30), State: api.IncidentStateMonitoring, Body: "A fix is deployed"}
+	resolved := api.IncidentUpdate{Time: at(60), State: api.IncidentStateResolved, Body: "Resolved"}
+
+	tests := []struct {
+		name       string
+		duplicates []api.Incident
+		start      time.Time
+		end        *time.Time
+		impact     api.Impact
+		updates    []api.IncidentUpdate
+		components []string
+	}{
+		{
+			name: "only the latest row resolved",
+			duplicates: []api.Incident{
+				{StartTime: at(0), Impact: api.ImpactMinor, UpdatedAt: at(10), Events: api.IncidentUpdateArray{investigating}},
+				{StartTime: at(5), EndTime: endingAt(60), Impact: api.ImpactMinor, UpdatedAt: at(60), Events: api.IncidentUpdateArray{investigating, resolved}},
+			},
+			start:   at(0),
+			end:     endingAt(60),
+			impact:  api.ImpactMinor,
+			updates: []api.IncidentUpdate{investigating, resolved},
+		},
+		{
+			name: "latest row ongoing",
+			duplicates: []api.Incident{
+				{StartTime: at(0), EndTime: endingAt(60), Impact: api.ImpactMinor, UpdatedAt: at(60), Events: api.IncidentUpdateArray{investigating, resolved}},
+				{StartTime: at(0), Impact: api.ImpactMinor, UpdatedAt: at(90), Events: api.IncidentUpdateArray{investigating}},
+			},
+			start:   at(0),
+			end:     nil,
+			impact:  api.ImpactMinor,
+			updates: []api.IncidentUpdate{investigating, resolved},
+		},
+		{
+			name: "mixed impacts",
+			duplicates: []api.Incident{
+				{StartTime: at(10), EndTime: endingAt(40), Impact: api.ImpactMinor, UpdatedAt: at(40), Components: []string{"API"}},
+				{StartTime: at(0), EndTime: endingAt(60), Impact: api.ImpactCritical, UpdatedAt: at(50), Components: []string{"API", "Dashboard"}},
+				{StartTime: at(5), EndTime: endingAt(50), Impact: api.ImpactMajor, UpdatedAt: at(70), Components: []string{"Dashboard"}},
+			},
+			start:      at(0),
+			end:        endingAt(60),
+			impact:     api.ImpactCritical,
+			components: []string{"API", "Dashboard"},
+		},
+		{
+			name: "duplicate updates",
+			duplicates: []api.Incident{
+				{StartTime: at(0), Impact: api.ImpactMajor, UpdatedAt: at(30), Events: api.IncidentUpdateArray{monitoring, investigating}},
+				{StartTime: at(0), Impact: api.ImpactMajor, UpdatedAt: at(20), Events: api.IncidentUpdateArray{investigating, monitoring}},
+			},
+			start:   at(0),
+			end:     nil,
+			impact:  api.ImpactMajor,
+			updates: []api.IncidentUpdate{investigating, monitoring},
+		},
+	}
+	for _, test := range tests {
+		merged := mergeIncidents(test.duplicates)
+		if !merged.StartTime.Equal(test.start) {
+			t.Errorf("%s: got start %v, want %v", test.name, merged.StartTime, test.start)
+		}
+		if (merged.EndTime == nil) != (test.end == nil) || (merged.EndTime != nil && !merged.EndTime.Equal(*test.end)) {
+			t.Errorf("%s: got end %v, want %v", test.name, merged.EndTime, test.end)
+		}
+		if merged.Impact != test.impact {
+			t.Errorf("%s: got impact %s, want %s", test.name, merged.Impact, test.impact)
+		}
+		if len(merged.Events) != len(test.updates) {
+			t.Errorf("%s: got %d updates, want %d", test.name, len(merged.Events), len(test.updates))
+		} else {
+			for i, update := range test.updates {
+				if merged.Events[i].State != update.State || !merged.Events[i].Time.Equal(update.Time) {
+					t.Errorf("%s: got update %d %s at %v, want %s at %v", test.name, i, merged.Events[i].State, merged.Events[i].Time, update.State, update.Time)
+				}
+			}
+		}
+		if len(merged.Components) != len(test.components) {
+			t.Errorf("%s: got components %v, want %v", test.name, merged.Components, test.components)
+		} else {
+			for i, component := range test.components {
+				if merged.Components[i] != component {
+					t.Errorf("%s: got components %v, want %v", test.name, merged.Components, test.components)
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/utils"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"time"
+)
+
+// dstDriftTolerance allows for the clocks of the status page and the scraper when looking for the drift
+const dstDriftTolerance = 5 * time.Minute
+
+// CorrectDSTDrift re-normalizes the stored incidents of a status page that prints local times in its configured timezone
+// Incidents scraped before the timezone was configured were parsed with the standard offset all year round
+// so the ones that fall in daylight saving time are an hour off. It returns the number of incidents corrected.
+// The correction is only applied once per status page as it is not idempotent, and only if the stored incidents show
+// the drift, see detectDSTDrift, unless force is set, as it would shift the correct times of a page with explicit offsets
+func (d *DbClient) CorrectDSTDrift(ctx context.Context, statusPageUrl string, force bool) (int, error) {
+	statusPage, err := d.GetStatusPage(ctx, statusPageUrl)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get status page")
+	}
+	if statusPage == nil {
+		return 0, errors.New("status page not found")
+	}
+	if statusPage.Timezone == "" {
+		return 0, errors.New("status page has no timezone configured")
+	}
+	if statusPage.TimezoneCorrected {
+		return 0, errors.New("status page has already been corrected")
+	}
+	loc, err := time.LoadLocation(statusPage.Timezone)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to load the status page timezone")
+	}
+
+	incidents, err := d.GetIncidents(ctx, statusPageUrl)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get incidents")
+	}
+
+	inDST, outOfDST := detectDSTDrift(incidents, loc)
+	if !force && (inDST == 0 || outOfDST > 0) {
+		return 0, errors.Errorf("no consistent dst drift in the incidents, %d were published an hour ahead of when they were stored in daylight saving time and %d outside of it", inDST, outOfDST)
+	}
+
+	var corrected []api.Incident
+	for _, incident := range incidents {
+		if correctIncidentDSTDrift(&incident, loc) {
+			corrected = append(corrected, incident)
+		}
+	}
+
+	_, err = d.CreateOrUpdateIncidentsWithoutPublishing(ctx, corrected)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to update incidents")
+	}
+	statusPage.TimezoneCorrected = true
+	err = d.UpdateStatusPage(ctx, *statusPage)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to mark the status page as corrected")
+	}
+	d.logger.Info("corrected dst drift", zap.String("url", statusPageUrl), zap.Int("incidents", len(corrected)))
+	return len(corrected), nil
+}
+
+// detectDSTDrift looks for the drift in the incidents as stored: a page that labels its local times with the standard
+// offset in the summer has updates that are up to an hour ahead of when they were scraped, which can't happen with the
+// right offset. It returns the number of incidents whose latest update is ahead of when the incident was last stored,
+// in daylight saving time and outside of it. The drift is consistent if there are some of the first and none of the
+// second, incidents that were scraped long after they were updated, such as the history, don't show it either way
+func detectDSTDrift(incidents []api.Incident, loc *time.Location) (int, int) {
+	inDST, outOfDST := 0, 0
+	for _, incident := range incidents {
+		latest := incident.StartTime
+		for _, event := range incident.Events {
+			if event.Time.After(latest) {
+				latest = event.Time
+			}
+		}
+		ahead := latest.Sub(incident.UpdatedAt)
+		if ahead <= dstDriftTolerance || ahead > time.Hour+dstDriftTolerance {
+			continue
+		}
+		if _, drifted := utils.CorrectDSTDrift(latest, loc); drifted {
+			inDST++
+		} else {
+			outOfDST++
+		}
+	}
+	return inDST, outOfDST
+}
+
+func correctIncidentDSTDrift(incident *api.Incident, loc *time.Location) bool {
+	changed := false
+	if t, ok := utils.CorrectDSTDrift(incident.StartTime, loc); ok {
+		incident.StartTime = t
+		changed = true
+	}
+	if incident.EndTime != nil {
+		if t, ok := utils.CorrectDSTDrift(*incident.EndTime, loc); ok {
+			incident.EndTime = &t
+			changed = true
+		}
+	}
+	for i := range incident.Events {
+		if t, ok := utils.CorrectDSTDrift(incident.Events[i].Time, loc); ok {
+			incident.Events[i].Time = t
+			changed = true
+		}
+	}
+	return changed
+}
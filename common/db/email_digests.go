@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+const emailDigestsTableName = "email_digests"
+
+// EmailDigest records that the digest of a period was sent to a recipient, so that a digest is sent once however many
+// scrapers run
+type EmailDigest struct {
+	Recipient   string    `gorm:"primarykey"`
+	Period      string    `gorm:"primarykey"`
+	PeriodStart time.Time `gorm:"primarykey"`
+	SentAt      time.Time `gorm:"index"`
+}
+
+// ClaimEmailDigest records the digest as sent, it returns false if it already was
+func (d *DbClient) ClaimEmailDigest(ctx context.Context, digest EmailDigest) (bool, error) {
+	if d.dryRun {
+		d.logger.Info("dry run: would claim email digest", zap.String("recipient", digest.Recipient), zap.String("period", digest.Period), zap.Time("periodStart", digest.PeriodStart))
+		return false, nil
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, emailDigestsTableName)).Clauses(clause.OnConflict{DoNothing: true}).Create(&digest)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ReleaseEmailDigest undoes the claim of a digest that couldn't be sent, so that it is retried
+func (d *DbClient) ReleaseEmailDigest(ctx context.Context, digest EmailDigest) error {
+	if d.dryRun {
+		return nil
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, emailDigestsTableName)).
+		Where("recipient = ? AND period = ? AND period_start = ?", digest.Recipient, digest.Period, digest.PeriodStart).Delete(&EmailDigest{})
+	return result.Error
+}
+
+// DeleteEmailDigestsSentBefore deletes the records of the digests sent before the given time
+func (d *DbClient) DeleteEmailDigestsSentBefore(ctx context.Context, before time.Time) (int64, error) {
+	if d.dryRun {
+		return 0, nil
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, emailDigestsTableName)).Where("sent_at < ?", before).Delete(&EmailDigest{})
+	return result.RowsAffected, result.Error
+}
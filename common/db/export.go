@@ -0,0 +1,151 @@
+package db
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"io"
+)
+
+const (
+	jsonlRecordTypeStatusPage = "statusPage"
+	jsonlRecordTypeIncident   = "incident"
+)
+
+// jsonlRecord is a single line of a JSONL dump
+// Exactly one of StatusPage or Incident is set depending on Type
+type jsonlRecord struct {
+	Type       string          `json:"type"`
+	StatusPage *api.StatusPage `json:"statusPage,omitempty"`
+	Incident   *api.Incident   `json:"incident,omitempty"`
+}
+
+const dumpBatchSize = 1000
+
+// maxJsonlLineSize is the largest single record we will read back, some incidents have very long descriptions
+const maxJsonlLineSize = 16 * 1024 * 1024
+
+// DumpToJSONL writes all status pages followed by all incidents to the writer as newline delimited JSON
+func (d *DbClient) DumpToJSONL(ctx context.Context, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+
+	statusPages, err := d.GetAllStatusPages(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get status pages")
+	}
+	for i := range statusPages {
+		err := encoder.Encode(jsonlRecord{Type: jsonlRecordTypeStatusPage, StatusPage: &statusPages[i]})
+		if err != nil {
+			return errors.Wrap(err, "failed to write status page")
+		}
+	}
+
+	var batch []api.Incident
+	result := d.db.Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).Order("deep_link").FindInBatches(&batch, dumpBatchSize, func(tx *gorm.DB, batchNumber int) error {
+		for i := range batch {
+			err := encoder.Encode(jsonlRecord{Type: jsonlRecordTypeIncident, Incident: &batch[i]})
+			if err != nil {
+				return errors.Wrap(err, "failed to write incident")
+			}
+		}
+		return nil
+	})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to dump incidents")
+	}
+	return nil
+}
+
+// LoadFromJSONL reads newline delimited JSON produced by DumpToJSONL and upserts every record
+// Existing status pages and incidents with the same primary key are overwritten, the changes aren't published
+func (d *DbClient) LoadFromJSONL(ctx context.Context, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxJsonlLineSize)
+
+	var statusPages []api.StatusPage
+	var incidents []api.Incident
+	flush := func() error {
+		if len(statusPages) > 0 {
+			err := d.UpsertStatusPages(ctx, statusPages)
+			if err != nil {
+				return errors.Wrap(err, "failed to load status pages")
+			}
+			statusPages = nil
+		}
+		if len(incidents) > 0 {
+			_, err := d.CreateOrUpdateIncidentsWithoutPublishing(ctx, incidents)
+			if err != nil {
+				return errors.Wrap(err, "failed to load incidents")
+			}
+			incidents = nil
+		}
+		return nil
+	}
+
+	line := 0
+	loaded := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record jsonlRecord
+		err := json.Unmarshal(scanner.Bytes(), &record)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse line %d", line)
+		}
+		switch {
+		case record.Type == jsonlRecordTypeStatusPage && record.StatusPage != nil:
+			statusPages = append(statusPages, *record.StatusPage)
+		case record.Type == jsonlRecordTypeIncident && record.Incident != nil:
+			incidents = append(incidents, *record.Incident)
+		default:
+			return errors.Errorf("unknown record on line %d", line)
+		}
+		loaded++
+		if len(statusPages)+len(incidents) >= d.upsertBatchSize {
+			err := flush()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Wrap(err, "failed to read input")
+	}
+	err := flush()
+	if err != nil {
+		return err
+	}
+	d.logger.Info("loaded records", zap.Int("records", loaded))
+	return nil
+}
+
+// UpsertStatusPages inserts the given status pages, overwriting any existing status page with the same url
+func (d *DbClient) UpsertStatusPages(ctx context.Context, statusPages []api.StatusPage) error {
+	if len(statusPages) == 0 {
+		return nil
+	}
+	if d.dryRun {
+		for _, statusPage := range statusPages {
+			d.logger.Info("dry run: would upsert status page", zap.Any("statusPage", statusPage))
+		}
+		return nil
+	}
+	result := d.db.Table(fmt.Sprintf("%s.%s", schemaName, statusPageTableName)).Clauses(
+		clause.OnConflict{
+			Columns:   []clause.Column{{Name: "url"}}, // Primary key
+			UpdateAll: true,
+		},
+	).CreateInBatches(&statusPages, d.upsertBatchSize)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
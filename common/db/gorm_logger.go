@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"github.com/metoro-io/statusphere/common/utils"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"strings"
+	"time"
+)
+
+// zapGormLogger is a gorm logger that writes structured logs through zap
+// Log lines include the MDC of the context so queries can be correlated with the scrape or request that made them
+type zapGormLogger struct {
+	logger        *zap.Logger
+	level         logger.LogLevel
+	slowThreshold time.Duration
+}
+
+func newZapGormLogger(lg *zap.Logger, level logger.LogLevel, slowThreshold time.Duration) *zapGormLogger {
+	return &zapGormLogger{
+		logger:        lg.Named("gorm"),
+		level:         level,
+		slowThreshold: slowThreshold,
+	}
+}
+
+// parseGormLogLevel converts the configured log level to a gorm log level
+func parseGormLogLevel(level string) (logger.LogLevel, error) {
+	switch strings.ToLower(level) {
+	case "", "silent":
+		return logger.Silent, nil
+	case "error":
+		return logger.Error, nil
+	case "warn":
+		return logger.Warn, nil
+	case "info":
+		return logger.Info, nil
+	default:
+		return logger.Silent, errors.Errorf("unknown db log level %q, expected silent, error, warn or info", level)
+	}
+}
+
+func (l *zapGormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	newLogger := *l
+	newLogger.level = level
+	return &newLogger
+}
+
+func (l *zapGormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Info {
+		utils.GetLogger(ctx, l.logger).Sugar().Infof(msg, args...)
+	}
+}
+
+func (l *zapGormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Warn {
+		utils.GetLogger(ctx, l.logger).Sugar().Warnf(msg, args...)
+	}
+}
+
+func (l *zapGormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Error {
+		utils.GetLogger(ctx, l.logger).Sugar().Errorf(msg, args...)
+	}
+}
+
+func (l *zapGormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && l.level >= logger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		utils.GetLogger(ctx, l.logger).Error("query failed", zap.Error(err), zap.String("sql", sql), zap.Int64("rows", rows), zap.Duration("elapsed", elapsed))
+	case l.slowThreshold != 0 && elapsed > l.slowThreshold && l.level >= logger.Warn:
+		sql, rows := fc()
+		utils.GetLogger(ctx, l.logger).Warn("slow query", zap.String("sql", sql), zap.Int64("rows", rows), zap.Duration("elapsed", elapsed), zap.Duration("threshold", l.slowThreshold))
+	case l.level >= logger.Info:
+		sql, rows := fc()
+		utils.GetLogger(ctx, l.logger).Info("query", zap.String("sql", sql), zap.Int64("rows", rows), zap.Duration("elapsed", elapsed))
+	}
+}
+
+// ParamsFilter keeps query parameters out of the logs, they can contain incident text
+func (l *zapGormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"gorm.io/gorm"
+	"strconv"
+	"time"
+)
+
+type IncidentSort string
+
+const (
+	IncidentSortStartTime IncidentSort = "startTime"
+	// IncidentSortDuration orders by how long the incidents lasted, the ongoing incidents last until now
+	IncidentSortDuration IncidentSort = "duration"
+)
+
+// IncidentQuery filters and orders the incidents of a status page, the zero value returns every incident most recent first
+type IncidentQuery struct {
+	// Impacts matches the incidents with any of the impacts
+	Impacts []api.Impact
+	// Component matches the incidents that affect the component
+	Component string
+	// Open matches the incidents that haven't ended if true and the ones that have if false
+	Open *bool
+	// StartedAfter and StartedBefore bound the start time of the incidents
+	StartedAfter  *time.Time
+	StartedBefore *time.Time
+	// EndedAfter matches the incidents that ended after the time or haven't ended
+	EndedAfter *time.Time
+	SortBy     IncidentSort
+	Ascending  bool
+	// Page is the page of the incidents, every incident by default. The key of an incident is its start time and deep
+	// link, preceded by its duration in microseconds when sorted by duration, see IncidentPageKey
+	Page Page
+}
+
+// IncidentPageKey returns the key of the incident in the incidents sorted by sortBy, see Page
+func IncidentPageKey(incident api.Incident, sortBy IncidentSort, now time.Time) []string {
+	key := []string{incident.StartTime.UTC().Format(time.RFC3339Nano), incident.DeepLink}
+	if sortBy == IncidentSortDuration {
+		end := now
+		if incident.EndTime != nil {
+			end = *incident.EndTime
+		}
+		key = append([]string{strconv.FormatInt(end.Sub(incident.StartTime).Microseconds(), 10)}, key...)
+	}
+	return key
+}
+
+// GetIncidentsByQuery returns the incidents of the status page that match the query, in the order of the query
+// The ongoing incidents last until now when sorted by duration, so they can move between the pages as they go on
+func (d *DbClient) GetIncidentsByQuery(ctx context.Context, statusPageUrl string, query IncidentQuery) ([]api.Incident, error) {
+	tx, err := d.incidentsMatching(ctx, statusPageUrl, query)
+	if err != nil {
+		return nil, err
+	}
+	columns := []keyColumn{{expression: "start_time", cast: "timestamptz"}, {expression: "deep_link", cast: "text"}}
+	if query.SortBy == IncidentSortDuration {
+		columns = append([]keyColumn{{expression: "(EXTRACT(EPOCH FROM COALESCE(end_time, now()) - start_time) * 1000000)::bigint", cast: "bigint"}}, columns...)
+	}
+	var incidents []api.Incident
+	result := paginate(tx, query.Page, !query.Ascending, columns...).Find(&incidents)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return incidents, nil
+}
+
+// CountIncidentsByQuery returns the number of incidents of the status page that match the query, across every page
+func (d *DbClient) CountIncidentsByQuery(ctx context.Context, statusPageUrl string, query IncidentQuery) (int64, error) {
+	tx, err := d.incidentsMatching(ctx, statusPageUrl, query)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	result := tx.Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return count, nil
+}
+
+// incidentsMatching selects the incidents of the status page that match the filters of the query
+func (d *DbClient) incidentsMatching(ctx context.Context, statusPageUrl string, query IncidentQuery) (*gorm.DB, error) {
+	tx := scopeToTenant(ctx, d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).Where("status_page_url = ?", statusPageUrl), "status_page_url")
+	if len(query.Impacts) > 0 {
+		tx = tx.Where("impact IN ?", query.Impacts)
+	}
+	if query.Component != "" {
+		component, err := json.Marshal([]string{query.Component})
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Where("components @> ?::jsonb", string(component))
+	}
+	if query.Open != nil {
+		if *query.Open {
+			tx = tx.Where("end_time IS NULL")
+		} else {
+			tx = tx.Where("end_time IS NOT NULL")
+		}
+	}
+	if query.StartedAfter != nil {
+		tx = tx.Where("start_time >= ?", *query.StartedAfter)
+	}
+	if query.StartedBefore != nil {
+		tx = tx.Where("start_time < ?", *query.StartedBefore)
+	}
+	if query.EndedAfter != nil {
+		tx = tx.Where("(end_time IS NULL OR end_time > ?)", *query.EndedAfter)
+	}
+	return tx, nil
+}
+
+// GetLatestIncidents returns the incidents of the status pages that changed last, the most recently updated first
+func (d *DbClient) GetLatestIncidents(ctx context.Context, statusPageUrls []string, limit int) ([]api.Incident, error) {
+	if len(statusPageUrls) == 0 {
+		return []api.Incident{}, nil
+	}
+	var incidents []api.Incident
+	tx := scopeToTenant(ctx, d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).Where("status_page_url IN ?", statusPageUrls), "status_page_url")
+	result := tx.Order("updated_at DESC").Limit(limit).Find(&incidents)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return incidents, nil
+}
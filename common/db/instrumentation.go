@@ -0,0 +1,118 @@
+package db
+
+import (
+	"github.com/metoro-io/statusphere/common/metrics"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	queryStartKey = "instrumentation:start"
+	querySpanKey  = "instrumentation:span"
+)
+
+// tracer starts a span per query, they are exported if telemetry is enabled, see the telemetry package
+var tracer = otel.Tracer("github.com/metoro-io/statusphere/common/db")
+
+var (
+	// queryDuration is how long each query took by operation, e.g. query or create, and table
+	queryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "statusphere_db_query_duration_seconds",
+		Help:    "Duration of the queries by operation and table.",
+		Buckets: metrics.DurationBuckets,
+	}, []string{"operation", "table"})
+	// queryErrors counts the queries that failed, finding no record isn't a failure
+	queryErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "statusphere_db_query_errors_total",
+		Help: "Failed queries by operation and table.",
+	}, []string{"operation", "table"})
+)
+
+// instrument times and traces the queries of the connection and exposes the stats of its pool
+// It is called once per process, registering the pool metrics twice fails
+func instrument(db *gorm.DB, database string) error {
+	callbacks := db.Callback()
+	operations := []struct {
+		name   string
+		before func(name string, fn func(*gorm.DB)) error
+		after  func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", callbacks.Create().Before("gorm:create").Register, callbacks.Create().After("gorm:create").Register},
+		{"query", callbacks.Query().Before("gorm:query").Register, callbacks.Query().After("gorm:query").Register},
+		{"update", callbacks.Update().Before("gorm:update").Register, callbacks.Update().After("gorm:update").Register},
+		{"delete", callbacks.Delete().Before("gorm:delete").Register, callbacks.Delete().After("gorm:delete").Register},
+		{"row", callbacks.Row().Before("gorm:row").Register, callbacks.Row().After("gorm:row").Register},
+		{"raw", callbacks.Raw().Before("gorm:raw").Register, callbacks.Raw().After("gorm:raw").Register},
+	}
+	for _, operation := range operations {
+		err := operation.before("instrumentation:before_"+operation.name, startQuery(operation.name))
+		if err != nil {
+			return errors.Wrap(err, "failed to register the query instrumentation")
+		}
+		err = operation.after("instrumentation:after_"+operation.name, endQuery(operation.name))
+		if err != nil {
+			return errors.Wrap(err, "failed to register the query instrumentation")
+		}
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return errors.Wrap(err, "failed to get the connection pool")
+	}
+	err = prometheus.Register(collectors.NewDBStatsCollector(sqlDB, database))
+	if err != nil {
+		return errors.Wrap(err, "failed to register the connection pool metrics")
+	}
+	return nil
+}
+
+func startQuery(operation string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		tx.InstanceSet(queryStartKey, time.Now())
+		if tx.Statement.Context == nil {
+			return
+		}
+		_, span := tracer.Start(tx.Statement.Context, "db "+operation, trace.WithSpanKind(trace.SpanKindClient))
+		tx.InstanceSet(querySpanKey, span)
+	}
+}
+
+func endQuery(operation string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		start, found := tx.InstanceGet(queryStartKey)
+		if !found {
+			return
+		}
+		// The raw statements have no table
+		table := tx.Statement.Table
+		failed := tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound)
+		queryDuration.WithLabelValues(operation, table).Observe(time.Since(start.(time.Time)).Seconds())
+		if failed {
+			queryErrors.WithLabelValues(operation, table).Inc()
+		}
+		if value, found := tx.InstanceGet(querySpanKey); found {
+			span := value.(trace.Span)
+			// The statement has placeholders rather than the values, which can be secrets such as the hashes of api keys
+			span.SetAttributes(
+				attribute.String("db.system", "postgresql"),
+				attribute.String("db.operation", operation),
+				attribute.String("db.sql.table", table),
+				attribute.String("db.statement", tx.Statement.SQL.String()),
+				attribute.Int64("db.rows_affected", tx.RowsAffected),
+			)
+			if failed {
+				span.RecordError(tx.Error)
+				span.SetStatus(codes.Error, tx.Error.Error())
+			}
+			span.End()
+		}
+	}
+}
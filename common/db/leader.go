@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"time"
+)
+
+const leaderTableName = "scraper_leader"
+
+// leaderLockNamespace is the advisory lock namespace used for leader election, see locks.go
+const leaderLockNamespace int32 = 3
+
+// LeaderHeartbeat is the row the current scraper leader periodically updates
+// Standby scrapers use it to detect a leader that is still connected but no longer making progress
+type LeaderHeartbeat struct {
+	Name        string    `gorm:"primarykey"`
+	HolderID    string    `gorm:"column:holder_id"`
+	BackendPid  int       `gorm:"column:backend_pid"`
+	HeartbeatAt time.Time `gorm:"column:heartbeat_at"`
+}
+
+const scraperLeaderName = "scraper"
+
+// LeaderLock is held by the scraper leader for as long as its database session is alive
+type LeaderLock struct {
+	conn *sql.Conn
+}
+
+// AcquireLeaderLock attempts to become the scraper leader without blocking
+// If another session holds the leadership acquired is false
+func (d *DbClient) AcquireLeaderLock(ctx context.Context) (*LeaderLock, bool, error) {
+	sqlDb, err := d.db.DB()
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to get the underlying sql db")
+	}
+	conn, err := sqlDb.Conn(ctx)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to get a connection")
+	}
+	var acquired bool
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1, 0)", leaderLockNamespace).Scan(&acquired)
+	if err != nil {
+		_ = conn.Close()
+		return nil, false, errors.Wrap(err, "failed to acquire leader lock")
+	}
+	if !acquired {
+		_ = conn.Close()
+		return nil, false, nil
+	}
+	return &LeaderLock{conn: conn}, true, nil
+}
+
+// Heartbeat records that the leader is alive
+// It uses the session that holds the lock so an error means the leadership may have been lost
+func (l *LeaderLock) Heartbeat(ctx context.Context, holderID string) error {
+	_, err := l.conn.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s.%s (name, holder_id, backend_pid, heartbeat_at) VALUES ($1, $2, pg_backend_pid(), now())
+ON CONFLICT (name) DO UPDATE SET holder_id = EXCLUDED.holder_id, backend_pid = EXCLUDED.backend_pid, heartbeat_at = EXCLUDED.heartbeat_at`, schemaName, leaderTableName), scraperLeaderName, holderID)
+	if err != nil {
+		return errors.Wrap(err, "failed to write leader heartbeat")
+	}
+	return nil
+}
+
+// Release gives up the leadership
+func (l *LeaderLock) Release() {
+	_, _ = l.conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1, 0)", leaderLockNamespace)
+	_ = l.conn.Close()
+}
+
+// TerminateStaleLeaderSession terminates the database session of a leader that holds the leader lock but whose last
+// heartbeat is older than staleAfter, releasing its advisory lock so that a standby can take over. It returns the
+// heartbeat of the terminated leader, nil if the leader is alive or there is none
+// The staleness is computed by postgres, which wrote the heartbeat, so that the clocks of the scrapers don't matter, and
+// only the session that pg_locks shows holding the lock is terminated, never a session that reused the pid of a leader
+func (d *DbClient) TerminateStaleLeaderSession(ctx context.Context, staleAfter time.Duration) (*LeaderHeartbeat, error) {
+	if d.dryRun {
+		d.logger.Info("dry run: would terminate the session of a stale leader", zap.Duration("staleAfter", staleAfter))
+		return nil, nil
+	}
+	var heartbeats []LeaderHeartbeat
+	// The stale leader is found before its session is terminated, the materialized cte keeps postgres from calling
+	// pg_terminate_backend on rows that don't match
+	result := d.db.WithContext(ctx).Raw(fmt.Sprintf(`WITH stale AS MATERIALIZED (
+	SELECT h.name, h.holder_id, h.backend_pid, h.heartbeat_at FROM %s.%s h
+	JOIN pg_locks l ON l.pid = h.backend_pid AND l.locktype = 'advisory' AND l.granted
+		AND l.database = (SELECT oid FROM pg_database WHERE datname = current_database())
+		AND l.classid = ? AND l.objid = 0 AND l.objsubid = 2
+	WHERE h.name = ? AND now() - h.heartbeat_at > ? * interval '1 second'
+)
+SELECT * FROM stale WHERE pg_terminate_backend(backend_pid)`, schemaName, leaderTableName),
+		leaderLockNamespace, scraperLeaderName, staleAfter.Seconds()).Scan(&heartbeats)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "failed to terminate the leader session")
+	}
+	if len(heartbeats) == 0 {
+		return nil, nil
+	}
+	return &heartbeats[0], nil
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"hash/fnv"
+	"slices"
+)
+
+// Advisory lock namespaces, used as the first key of the two key form of pg_advisory_lock
+// so that current and historical scrapes of the same page don't block each other
+const (
+	scrapeLockNamespace           int32 = 1
+	historicalScrapeLockNamespace int32 = 2
+	// incidentLockNamespace is 4 as 3 is taken by the leader election, see leader.go
+	incidentLockNamespace int32 = 4
+)
+
+// AcquireScrapeLock attempts to take a postgres advisory lock for scraping the given status page
+// so that multiple scraper replicas never scrape the same page at the same time.
+// It does not block, if another session holds the lock acquired is false.
+// When acquired is true the caller must call release once the scrape is complete.
+func (d *DbClient) AcquireScrapeLock(ctx context.Context, statusPageUrl string) (release func(), acquired bool, err error) {
+	return d.acquireAdvisoryLock(ctx, scrapeLockNamespace, statusPageUrl)
+}
+
+// AcquireHistoricalScrapeLock is AcquireScrapeLock for historical scrapes
+func (d *DbClient) AcquireHistoricalScrapeLock(ctx context.Context, statusPageUrl string) (release func(), acquired bool, err error) {
+	return d.acquireAdvisoryLock(ctx, historicalScrapeLockNamespace, statusPageUrl)
+}
+
+func (d *DbClient) acquireAdvisoryLock(ctx context.Context, namespace int32, key string) (func(), bool, error) {
+	sqlDb, err := d.db.DB()
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to get the underlying sql db")
+	}
+	// Advisory locks belong to a session so we need to hold on to a single connection until the lock is released
+	conn, err := sqlDb.Conn(ctx)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to get a connection")
+	}
+
+	lockKey := advisoryLockKey(key)
+	var acquired bool
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1, $2)", namespace, lockKey).Scan(&acquired)
+	if err != nil {
+		_ = conn.Close()
+		return nil, false, errors.Wrap(err, "failed to acquire advisory lock")
+	}
+	if !acquired {
+		_ = conn.Close()
+		return nil, false, nil
+	}
+
+	release := func() {
+		// Use a fresh context as the lock has to be released even if the scrape context was cancelled
+		_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1, $2)", namespace, lockKey)
+		if err != nil {
+			d.logger.Error("failed to release advisory lock", zap.String("key", key), zap.Error(err))
+		}
+		_ = conn.Close()
+	}
+	return release, true, nil
+}
+
+// lockIncidentsForUpsert takes the transaction level advisory locks of the deep links, so that concurrent upserts of
+// the same incidents, including ones that aren't stored yet and so have no row to lock, are diffed one after the other.
+// The keys are locked in order so that two transactions never wait on each other
+func lockIncidentsForUpsert(tx *gorm.DB, deepLinks []string) error {
+	keys := make([]int32, 0, len(deepLinks))
+	for _, deepLink := range deepLinks {
+		keys = append(keys, advisoryLockKey(deepLink))
+	}
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+	// unnest returns the keys in the order of the array
+	result := tx.Exec("SELECT pg_advisory_xact_lock(?, key) FROM unnest(ARRAY[?]::int[]) AS key", incidentLockNamespace, keys)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to lock the incidents")
+	}
+	return nil
+}
+
+func advisoryLockKey(key string) int32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int32(h.Sum32())
+}
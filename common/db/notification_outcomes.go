@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+const notificationOutcomesTableName = "notification_outcomes"
+
+// notificationOutcomeRetention is how long the counts of the notification outcomes are kept
+const notificationOutcomeRetention = 30 * 24 * time.Hour
+
+// NotificationOutcome counts the notifications a notifier delivered and failed to deliver in an hour, e.g. the Slack
+// messages or the pages of PagerDuty. The webhook deliveries are counted from their own table
+type NotificationOutcome struct {
+	Notifier  string    `gorm:"primarykey" json:"notifier"`
+	Hour      time.Time `gorm:"primarykey" json:"hour"`
+	Delivered int64     `json:"delivered"`
+	Failed    int64     `json:"failed"`
+}
+
+// RecordNotificationOutcome counts a notification of the notifier in the hour of at, the counts of the hours older than
+// the retention are deleted along the way
+func (d *DbClient) RecordNotificationOutcome(ctx context.Context, notifier string, delivered bool, at time.Time) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would record notification outcome", zap.String("notifier", notifier), zap.Bool("delivered", delivered))
+		return nil
+	}
+	outcome := NotificationOutcome{Notifier: notifier, Hour: at.UTC().Truncate(time.Hour)}
+	column := "failed"
+	if delivered {
+		outcome.Delivered = 1
+		column = "delivered"
+	} else {
+		outcome.Failed = 1
+	}
+	table := fmt.Sprintf("%s.%s", schemaName, notificationOutcomesTableName)
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Table(table).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "notifier"}, {Name: "hour"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{column: gorm.Expr(fmt.Sprintf("%s.%s + 1", notificationOutcomesTableName, column))}),
+		}).Create(&outcome)
+		if result.Error != nil {
+			return result.Error
+		}
+		return tx.Table(table).Where("hour < ?", outcome.Hour.Add(-notificationOutcomeRetention)).Delete(&NotificationOutcome{}).Error
+	})
+}
+
+// GetNotificationOutcomeCounts returns the number of notifications each notifier delivered and failed to deliver since
+// the given time, the counts are kept by the hour so the hour of since is counted whole
+func (d *DbClient) GetNotificationOutcomeCounts(ctx context.Context, since time.Time) ([]NotificationOutcome, error) {
+	var outcomes []NotificationOutcome
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, notificationOutcomesTableName)).
+		Select("notifier, sum(delivered) AS delivered, sum(failed) AS failed").
+		Where("hour >= ?", since.UTC().Truncate(time.Hour)).
+		Group("notifier").Order("notifier").Scan(&outcomes)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return outcomes, nil
+}
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+const notificationThrottlesTableName = "notification_throttles"
+
+// NotificationThrottle is the last notification of an incident that was sent to a destination, e.g. a chat channel or a
+// webhook subscription, and the latest update that was held back after it. It is kept in the database so that the
+// notifications are deduplicated and throttled across restarts and however many scrapers run
+type NotificationThrottle struct {
+	Destination string `gorm:"primarykey"`
+	DeepLink    string `gorm:"primarykey"`
+	// LastEventID is the change event of the last notification, zero if the incident was never notified
+	LastEventID uint64
+	LastType    api.ChangeEventType
+	LastSentAt  time.Time `gorm:"index"`
+	// Pending is the latest update that was held back, PendingCount the number of updates it stands for, it is sent at DueAt
+	Pending      *api.ChangeEvent `gorm:"type:jsonb;serializer:json"`
+	PendingCount int
+	DueAt        *time.Time `gorm:"index"`
+}
+
+// UpdateNotificationThrottle passes the throttle of the incident to the destination to update, with the row locked so
+// that concurrent notifications of the incident are decided one after the other, and saves it
+func (d *DbClient) UpdateNotificationThrottle(ctx context.Context, destination string, deepLink string, update func(throttle *NotificationThrottle)) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would update notification throttle", zap.String("destination", destination), zap.String("deepLink", deepLink))
+		update(&NotificationThrottle{Destination: destination, DeepLink: deepLink})
+		return nil
+	}
+	table := fmt.Sprintf("%s.%s", schemaName, notificationThrottlesTableName)
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The row is created first so that there is a row to lock for an incident that was never notified
+		throttle := NotificationThrottle{Destination: destination, DeepLink: deepLink}
+		result := tx.Table(table).Clauses(clause.OnConflict{DoNothing: true}).Create(&throttle)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to create notification throttle")
+		}
+		result = tx.Table(table).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("destination = ? AND deep_link = ?", destination, deepLink).First(&throttle)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to get notification throttle")
+		}
+		update(&throttle)
+		result = tx.Table(table).Save(&throttle)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to save notification throttle")
+		}
+		return nil
+	})
+}
+
+// ClaimDueNotificationThrottles returns the throttles of the destinations whose held back update is due, with the update
+// still pending, and records them as sent at now. The throttles are locked with SKIP LOCKED, so a held back update is
+// claimed by a single scraper
+func (d *DbClient) ClaimDueNotificationThrottles(ctx context.Context, destinations []string, now time.Time) ([]NotificationThrottle, error) {
+	if len(destinations) == 0 {
+		return nil, nil
+	}
+	if d.dryRun {
+		d.logger.Info("dry run: would claim due notification throttles", zap.Int("destinations", len(destinations)))
+		return nil, nil
+	}
+	table := fmt.Sprintf("%s.%s", schemaName, notificationThrottlesTableName)
+	var throttles []NotificationThrottle
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Table(table).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("destination IN ? AND due_at <= ?", destinations, now).
+			Find(&throttles)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to claim due notification throttles")
+		}
+		for _, throttle := range throttles {
+			if throttle.Pending == nil {
+				continue
+			}
+			result = tx.Table(table).Where("destination = ? AND deep_link = ?", throttle.Destination, throttle.DeepLink).
+				Updates(map[string]interface{}{
+					"last_event_id": throttle.Pending.ID,
+					"last_type":     throttle.Pending.Type,
+					"last_sent_at":  now,
+					"pending":       nil,
+					"pending_count": 0,
+					"due_at":        nil,
+				})
+			if result.Error != nil {
+				return errors.Wrap(result.Error, "failed to record notification throttle as sent")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return throttles, nil
+}
+
+// DeleteNotificationThrottlesSentBefore forgets the incidents that were last notified before the given time and have no
+// update held back
+func (d *DbClient) DeleteNotificationThrottlesSentBefore(ctx context.Context, before time.Time) (int64, error) {
+	if d.dryRun {
+		return 0, nil
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, notificationThrottlesTableName)).
+		Where("due_at IS NULL AND last_sent_at < ?", before).Delete(&NotificationThrottle{})
+	return result.RowsAffected, result.Error
+}
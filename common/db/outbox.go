@@ -0,0 +1,269 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+const outboxTableName = "outbox"
+
+// changeEventsForUpsert compares the incidents about to be upserted with their stored versions
+// and returns the change events that the upsert will cause. Unchanged incidents produce no events.
+// An incident without an end time keeps the end time that was inferred for it, see InferIncidentResolutions
+// If lock is true the incidents stay locked until the transaction ends, so that a concurrent upsert of the same
+// incidents is diffed against this one rather than both writing the same change events
+func changeEventsForUpsert(tx *gorm.DB, incidents []api.Incident, lock bool) ([]api.ChangeEvent, error) {
+	deepLinks := make([]string, 0, len(incidents))
+	for _, incident := range incidents {
+		deepLinks = append(deepLinks, incident.DeepLink)
+	}
+
+	query := tx.Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).Where("deep_link IN ?", deepLinks)
+	if lock {
+		err := lockIncidentsForUpsert(tx, deepLinks)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	var existing []api.Incident
+	result := query.Find(&existing)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "failed to get existing incidents")
+	}
+	existingByDeepLink := make(map[string]api.Incident, len(existing))
+	for _, incident := range existing {
+		existingByDeepLink[incident.DeepLink] = incident
+	}
+
+	var events []api.ChangeEvent
+	now := time.Now().UTC()
+	for i := range incidents {
+		previous, found := existingByDeepLink[incidents[i].DeepLink]
+		if found && previous.EndTimeInferred && incidents[i].EndTime == nil {
+			incidents[i].EndTime = previous.EndTime
+			incidents[i].EndTimeInferred = true
+		}
+		// The update time only moves when the incident changes, the api versions its responses with it
+		incidents[i].UpdatedAt = now
+		if found && !incidentChanged(previous, incidents[i]) && !incidentDetailsChanged(previous, incidents[i]) {
+			incidents[i].UpdatedAt = previous.UpdatedAt
+		}
+		incident := incidents[i]
+		switch {
+		case !found:
+			events = append(events, api.NewChangeEvent(api.ChangeEventIncidentCreated, incident))
+		case previous.EndTime == nil && incident.EndTime != nil:
+			events = append(events, api.NewChangeEvent(api.ChangeEventIncidentResolved, incident))
+		case incidentChanged(previous, incident):
+			events = append(events, api.NewChangeEvent(api.ChangeEventIncidentUpdated, incident))
+		}
+		// Later duplicates in the same batch are compared against this version
+		existingByDeepLink[incident.DeepLink] = incident
+	}
+	return events, nil
+}
+
+// PreviewIncidentChanges returns the change events that CreateOrUpdateIncidents would cause for the incidents
+// without writing anything, so a scrape can be checked against what is stored
+func (d *DbClient) PreviewIncidentChanges(ctx context.Context, incidents []api.Incident) ([]api.ChangeEvent, error) {
+	if len(incidents) == 0 {
+		return nil, nil
+	}
+	// changeEventsForUpsert carries the inferred end times over to the incidents it is given
+	incidents = append([]api.Incident(nil), incidents...)
+	return changeEventsForUpsert(d.db.WithContext(ctx), incidents, false)
+}
+
+func incidentChanged(previous api.Incident, current api.Incident) bool {
+	if previous.Title != current.Title || previous.Impact != current.Impact || previous.StatusPageUrl != current.StatusPageUrl {
+		return true
+	}
+	if !previous.StartTime.Equal(current.StartTime) || !timePointersEqual(previous.EndTime, current.EndTime) || previous.EndTimeInferred != current.EndTimeInferred {
+		return true
+	}
+	if !stringPointersEqual(previous.Description, current.Description) {
+		return true
+	}
+	if len(previous.Events) != len(current.Events) || len(previous.Components) != len(current.Components) {
+		return true
+	}
+	for i := range previous.Events {
+		if !previous.Events[i].Time.Equal(current.Events[i].Time) || previous.Events[i].State != current.Events[i].State || previous.Events[i].Body != current.Events[i].Body {
+			return true
+		}
+		if len(previous.Events[i].ComponentChanges) != len(current.Events[i].ComponentChanges) {
+			return true
+		}
+		for j := range previous.Events[i].ComponentChanges {
+			if previous.Events[i].ComponentChanges[j] != current.Events[i].ComponentChanges[j] {
+				return true
+			}
+		}
+	}
+	for i := range previous.Components {
+		if previous.Components[i] != current.Components[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// incidentDetailsChanged compares the stored fields that are derived from the incident rather than scraped,
+// a change to them doesn't cause a change event but is still served by the api
+func incidentDetailsChanged(previous api.Incident, current api.Incident) bool {
+	return previous.RawImpact != current.RawImpact || previous.Language != current.Language ||
+		!stringPointersEqual(previous.DescriptionText, current.DescriptionText) || !stringPointersEqual(previous.DescriptionHTML, current.DescriptionHTML) ||
+		!stringPointersEqual(previous.TranslatedTitle, current.TranslatedTitle) || !stringPointersEqual(previous.TranslatedDescription, current.TranslatedDescription)
+}
+
+func timePointersEqual(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
+
+func stringPointersEqual(a *string, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// DispatchOutboxEvents claims up to limit undispatched change events in order and passes them to publish
+// If publish succeeds the events are marked as dispatched, otherwise their claim is released and they are retried. The
+// PublishedTo of the events that publish updated is saved either way, so a retry skips the publishers that succeeded
+// The events are claimed for claimFor in a short transaction that locks them with SKIP LOCKED, so multiple dispatchers
+// never publish the same event concurrently without a transaction staying open while the publishers make their requests.
+// The events of a dispatcher that dies while publishing are claimed again once claimFor has passed
+func (d *DbClient) DispatchOutboxEvents(ctx context.Context, limit int, claimFor time.Duration, publish func(events []api.ChangeEvent) error) (int, error) {
+	if d.dryRun {
+		d.logger.Info("dry run: would dispatch outbox events")
+		return 0, nil
+	}
+	table := fmt.Sprintf("%s.%s", schemaName, outboxTableName)
+	var events []api.ChangeEvent
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Table(table).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("dispatched_at IS NULL AND (claimed_until IS NULL OR claimed_until < ?)", now).
+			Order("id").
+			Limit(limit).
+			Find(&events)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to claim outbox events")
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		result = tx.Table(table).Where("id IN ?", changeEventIDs(events)).Update("claimed_until", now.Add(claimFor))
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to claim outbox events")
+		}
+		return nil
+	})
+	if err != nil || len(events) == 0 {
+		return 0, err
+	}
+
+	err = publish(events)
+	if err != nil {
+		for _, event := range events {
+			result := d.db.WithContext(ctx).Table(table).Where("id = ?", event.ID).
+				Updates(map[string]interface{}{"claimed_until": nil, "published_to": publishedTo(event.PublishedTo)})
+			if result.Error != nil {
+				d.logger.Error("failed to release the claim of outbox event", zap.Uint64("event", event.ID), zap.Error(result.Error))
+			}
+		}
+		return 0, errors.Wrap(err, "failed to publish outbox events")
+	}
+	result := d.db.WithContext(ctx).Table(table).Where("id IN ?", changeEventIDs(events)).Update("dispatched_at", time.Now())
+	if result.Error != nil {
+		return 0, errors.Wrap(result.Error, "failed to mark outbox events as dispatched")
+	}
+	return len(events), nil
+}
+
+// publishedTo is the value of the published_to column, it isn't serialized by gorm in a map of updates
+func publishedTo(publishers []string) interface{} {
+	if len(publishers) == 0 {
+		return nil
+	}
+	value, _ := json.Marshal(publishers)
+	return string(value)
+}
+
+func changeEventIDs(events []api.ChangeEvent) []uint64 {
+	ids := make([]uint64, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+// DeleteDispatchedOutboxEvents removes events that were dispatched before the given time
+// The latest event is always kept so that sync cursors older than the retained events can be detected
+func (d *DbClient) DeleteDispatchedOutboxEvents(ctx context.Context, before time.Time) (int64, error) {
+	if d.dryRun {
+		d.logger.Info("dry run: would delete dispatched outbox events", zap.Time("before", before))
+		return 0, nil
+	}
+	table := fmt.Sprintf("%s.%s", schemaName, outboxTableName)
+	result := d.db.Table(table).Where(fmt.Sprintf("dispatched_at < ? AND (tx_id, id) < (SELECT tx_id, id FROM %s ORDER BY tx_id DESC, id DESC LIMIT 1)", table), before).Delete(&api.ChangeEvent{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// visibleTxIDs bounds the transactions whose events are read to the ones older than the oldest transaction that is still
+// running, every transaction that commits later has a later id so no event can become visible before a cursor
+const visibleTxIDs = "tx_id < (pg_snapshot_xmin(pg_current_snapshot())::text)::bigint"
+
+// GetChangeEventsSince returns up to limit change events after the cursor in order, see api.ChangeEventCursor
+// Only the events of the transactions that are older than every running transaction are returned, so an event is never
+// returned after the events that follow it
+func (d *DbClient) GetChangeEventsSince(ctx context.Context, since api.ChangeEventCursor, limit int) ([]api.ChangeEvent, error) {
+	var events []api.ChangeEvent
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, outboxTableName)).
+		Where("(tx_id, id) > (?, ?) AND "+visibleTxIDs, since.TxID, since.ID).
+		Order("tx_id, id").
+		Limit(limit).
+		Find(&events)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return events, nil
+}
+
+// GetChangeEventCursorRange returns the cursors of the oldest retained change event and of the latest one that can be
+// read, both are zero if there are none
+func (d *DbClient) GetChangeEventCursorRange(ctx context.Context) (api.ChangeEventCursor, api.ChangeEventCursor, error) {
+	table := fmt.Sprintf("%s.%s", schemaName, outboxTableName)
+	var oldest, latest []api.ChangeEvent
+	result := d.db.WithContext(ctx).Table(table).Select("tx_id, id").Order("tx_id, id").Limit(1).Find(&oldest)
+	if result.Error != nil {
+		return api.ChangeEventCursor{}, api.ChangeEventCursor{}, result.Error
+	}
+	result = d.db.WithContext(ctx).Table(table).Select("tx_id, id").Where(visibleTxIDs).Order("tx_id DESC, id DESC").Limit(1).Find(&latest)
+	if result.Error != nil {
+		return api.ChangeEventCursor{}, api.ChangeEventCursor{}, result.Error
+	}
+	var oldestCursor, latestCursor api.ChangeEventCursor
+	if len(oldest) > 0 {
+		oldestCursor = oldest[0].Cursor()
+	}
+	if len(latest) > 0 {
+		latestCursor = latest[0].Cursor()
+	}
+	return oldestCursor, latestCursor, nil
+}
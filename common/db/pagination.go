@@ -0,0 +1,56 @@
+package db
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"strings"
+)
+
+// ErrInvalidPageKey is returned for a page key that doesn't have a value for every column the list is ordered by
+var ErrInvalidPageKey = errors.New("invalid page key")
+
+// Page selects a page of a list, up to Limit items after the item whose key is After. A zero limit is every item and a
+// nil key starts from the first item. The key is the values of the columns the list is ordered by, as text, and ends
+// with a unique column, so the items inserted between two pages don't shift the second one
+type Page struct {
+	After []string
+	Limit int
+}
+
+// keyColumn is a column a list is ordered by, the value of the key for it is cast to cast
+type keyColumn struct {
+	expression string
+	cast       string
+}
+
+// paginate orders the query by the columns and selects the page. The columns are ordered in the same direction so the
+// key can be compared to them as a row
+func paginate(tx *gorm.DB, page Page, descending bool, columns ...keyColumn) *gorm.DB {
+	direction, comparison := "ASC", ">"
+	if descending {
+		direction, comparison = "DESC", "<"
+	}
+	expressions := make([]string, 0, len(columns))
+	placeholders := make([]string, 0, len(columns))
+	for _, column := range columns {
+		tx = tx.Order(column.expression + " " + direction)
+		expressions = append(expressions, column.expression)
+		placeholders = append(placeholders, "?::"+column.cast)
+	}
+	if page.After != nil {
+		if len(page.After) != len(columns) {
+			tx.AddError(ErrInvalidPageKey)
+			return tx
+		}
+		args := make([]interface{}, 0, len(page.After))
+		for _, value := range page.After {
+			args = append(args, value)
+		}
+		tx = tx.Where(fmt.Sprintf("(%s) %s (%s)", strings.Join(expressions, ", "), comparison, strings.Join(placeholders, ", ")), args...)
+	}
+	if page.Limit > 0 {
+		tx = tx.Limit(page.Limit)
+	}
+	return tx
+}
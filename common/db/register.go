@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+)
+
+// GetStatusPagesByUrls returns the status pages with the given urls, the urls that aren't status pages are skipped
+func (d *DbClient) GetStatusPagesByUrls(ctx context.Context, urls []string) ([]api.StatusPage, error) {
+	var statusPages []api.StatusPage
+	if len(urls) == 0 {
+		return statusPages, nil
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, statusPageTableName)).Where("url IN ?", urls).Find(&statusPages)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return statusPages, nil
+}
+
+// RegisterStatusPages inserts the given status pages, a status page that already exists only has its name and provider
+// updated so its scrape state is kept
+func (d *DbClient) RegisterStatusPages(ctx context.Context, statusPages []api.StatusPage) error {
+	if len(statusPages) == 0 {
+		return nil
+	}
+	if d.dryRun {
+		for _, statusPage := range statusPages {
+			d.logger.Info("dry run: would register status page", zap.String("url", statusPage.URL), zap.String("name", statusPage.Name), zap.String("provider", statusPage.Provider))
+		}
+		return nil
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, statusPageTableName)).Clauses(
+		clause.OnConflict{
+			Columns:   []clause.Column{{Name: "url"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name", "provider", "provider_detected_at"}),
+		},
+	).CreateInBatches(&statusPages, d.upsertBatchSize)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"time"
+)
+
+const scrapeClaimsTableName = "scrape_claims"
+
+// ScrapeClaim records that a scraper replica has taken a status page from the scrape queue
+// A claim that isn't released by the time it expires, e.g. because its replica crashed, can be taken by another replica
+type ScrapeClaim struct {
+	URL          string    `gorm:"primarykey"`
+	ClaimedBy    string    `gorm:"column:claimed_by"`
+	ClaimedUntil time.Time `gorm:"column:claimed_until;index"`
+}
+
+// ScrapeSchedule decides when a status page is due to be scraped again, see ClaimDueStatusPages
+type ScrapeSchedule struct {
+	// DefaultInterval is used for the status pages without a scrape interval of their own
+	DefaultInterval time.Duration
+	// ActiveIncidentInterval caps the interval while a status page has an ongoing incident
+	ActiveIncidentInterval time.Duration
+	// CircuitBreakerThreshold consecutive failed scrapes put a status page on the CircuitBreakerCoolDown interval
+	CircuitBreakerThreshold int
+	CircuitBreakerCoolDown  time.Duration
+	// DeadInterval is used for the status pages that are dead, to check whether they have come back
+	DeadInterval time.Duration
+}
+
+// ClaimDueStatusPages takes up to limit of the status pages that are due to be scraped for the replica claimedBy
+// ordered by priority and then by how long they have been waiting
+// The due status pages are selected with FOR UPDATE SKIP LOCKED so replicas claiming at the same time never block
+// each other or claim the same page. The claims expire after lease unless they are released first
+func (d *DbClient) ClaimDueStatusPages(ctx context.Context, claimedBy string, limit int, lease time.Duration, schedule ScrapeSchedule) ([]string, error) {
+	if d.dryRun {
+		return nil, nil
+	}
+	statusPageTable := fmt.Sprintf("%s.%s", schemaName, statusPageTableName)
+	claimsTable := fmt.Sprintf("%s.%s", schemaName, scrapeClaimsTableName)
+	// The conflict clause only replaces an expired claim, it guards against a replica whose snapshot
+	// predates a claim that was committed while it was selecting
+	query := fmt.Sprintf(`WITH due AS (
+	SELECT s.url FROM %[1]s s
+	WHERE s.last_currently_scraped < now() - make_interval(secs => CASE
+		WHEN s.is_dead THEN @deadInterval
+		WHEN s.consecutive_failure_count >= @threshold THEN @coolDown
+		WHEN s.has_active_incident THEN LEAST(COALESCE(NULLIF((s.scrape_config->>'intervalSeconds')::int, 0), NULLIF(s.scrape_interval_seconds, 0), @defaultInterval), @activeInterval)
+		ELSE COALESCE(NULLIF((s.scrape_config->>'intervalSeconds')::int, 0), NULLIF(s.scrape_interval_seconds, 0), @defaultInterval)
+	END)
+	AND s.paused_at IS NULL
+	AND NOT EXISTS (SELECT 1 FROM %[2]s c WHERE c.url = s.url AND c.claimed_until > now())
+	ORDER BY s.scrape_priority DESC, s.last_currently_scraped
+	LIMIT @limit
+	FOR UPDATE OF s SKIP LOCKED
+)
+INSERT INTO %[2]s (url, claimed_by, claimed_until)
+SELECT url, @claimedBy, now() + make_interval(secs => @lease) FROM due
+ON CONFLICT (url) DO UPDATE SET claimed_by = EXCLUDED.claimed_by, claimed_until = EXCLUDED.claimed_until
+WHERE %[3]s.claimed_until <= now()
+RETURNING url`, statusPageTable, claimsTable, scrapeClaimsTableName)
+
+	var urls []string
+	result := d.db.WithContext(ctx).Raw(query, map[string]interface{}{
+		"threshold":       schedule.CircuitBreakerThreshold,
+		"coolDown":        schedule.CircuitBreakerCoolDown.Seconds(),
+		"deadInterval":    schedule.DeadInterval.Seconds(),
+		"defaultInterval": schedule.DefaultInterval.Seconds(),
+		"activeInterval":  schedule.ActiveIncidentInterval.Seconds(),
+		"limit":           limit,
+		"claimedBy":       claimedBy,
+		"lease":           lease.Seconds(),
+	}).Scan(&urls)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "failed to claim due status pages")
+	}
+	return urls, nil
+}
+
+// ReleaseScrapeClaim releases the claim of the replica claimedBy on the status page, a claim that has since expired
+// and been taken by another replica is left alone
+func (d *DbClient) ReleaseScrapeClaim(ctx context.Context, statusPageUrl string, claimedBy string) error {
+	if d.dryRun {
+		return nil
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, scrapeClaimsTableName)).
+		Where("url = ? AND claimed_by = ?", statusPageUrl, claimedBy).Delete(&ScrapeClaim{})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to release scrape claim")
+	}
+	return nil
+}
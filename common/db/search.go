@@ -0,0 +1,38 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+)
+
+// incidentSearchDocument is the text of an incident that is searched, the search index is on the same expression
+const incidentSearchDocument = "to_tsvector('english', coalesce(title, '') || ' ' || coalesce(description_text, ''))"
+
+type IncidentSearchResult struct {
+	api.Incident
+	// Rank is how well the incident matches the search, higher is better
+	Rank float64
+}
+
+// SearchIncidents returns the incidents whose title or description match the search, best match first
+// The search is in the web search syntax of postgres, e.g. "database outage" -resolved
+func (d *DbClient) SearchIncidents(ctx context.Context, search string, limit int) ([]IncidentSearchResult, error) {
+	var results []IncidentSearchResult
+	// The query is raw, so the tenant scope is added to it rather than with scopeToTenant
+	tenantCondition := ""
+	args := []interface{}{search}
+	if tenantID, found := TenantFromContext(ctx); found {
+		tenantCondition = fmt.Sprintf(" AND status_page_url IN (SELECT status_page_url FROM %s.%s WHERE tenant_id = ?)", schemaName, tenantStatusPagesTableName)
+		args = append(args, tenantID)
+	}
+	args = append(args, limit)
+	result := d.db.WithContext(ctx).Raw(fmt.Sprintf(
+		"SELECT *, ts_rank(%[1]s, query) AS rank FROM %[2]s.%[3]s, websearch_to_tsquery('english', ?) query WHERE %[1]s @@ query%[4]s ORDER BY rank DESC, start_time DESC LIMIT ?",
+		incidentSearchDocument, schemaName, incidentsTableName, tenantCondition,
+	), args...).Scan(&results)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return results, nil
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"time"
+)
+
+const dbSizeSnapshotsTableName = "db_size_snapshots"
+
+// dbSizeSnapshotRetention is how long the size snapshots are kept, longer than the growth is reported over
+const dbSizeSnapshotRetention = 30 * 24 * time.Hour
+
+type TableStats struct {
+	Table     string `json:"table"`
+	Rows      int64  `json:"rows"`
+	SizeBytes int64  `json:"sizeBytes"`
+}
+
+type DbStats struct {
+	Tables []TableStats `json:"tables"`
+	// OldestIncident and NewestIncident are the earliest and latest incident start times, nil if there are no incidents
+	// A newest incident that stops moving forward is a sign that scraping has stalled
+	OldestIncident *time.Time `json:"oldestIncident"`
+	NewestIncident *time.Time `json:"newestIncident"`
+	CollectedAt    time.Time  `json:"collectedAt"`
+}
+
+// DbStats returns the row count and on disk size of every statusphere table along with the range of incident start times
+func (d *DbClient) DbStats(ctx context.Context) (*DbStats, error) {
+	var tables []string
+	result := d.db.Raw("SELECT tablename FROM pg_tables WHERE schemaname = ? ORDER BY tablename", schemaName).Scan(&tables)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "failed to list tables")
+	}
+
+	stats := &DbStats{CollectedAt: time.Now()}
+	for _, table := range tables {
+		tableStats := TableStats{Table: table}
+		result := d.db.Raw(fmt.Sprintf("SELECT COUNT(*) FROM %s", quoteTable(table))).Scan(&tableStats.Rows)
+		if result.Error != nil {
+			return nil, errors.Wrapf(result.Error, "failed to count rows of %s", table)
+		}
+		result = d.db.Raw("SELECT pg_total_relation_size(?::regclass)", quoteTable(table)).Scan(&tableStats.SizeBytes)
+		if result.Error != nil {
+			return nil, errors.Wrapf(result.Error, "failed to get the size of %s", table)
+		}
+		stats.Tables = append(stats.Tables, tableStats)
+	}
+
+	var incidentRange struct {
+		Oldest *time.Time
+		Newest *time.Time
+	}
+	result = d.db.Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).Select("MIN(start_time) AS oldest, MAX(start_time) AS newest").Scan(&incidentRange)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "failed to get incident time range")
+	}
+	stats.OldestIncident = incidentRange.Oldest
+	stats.NewestIncident = incidentRange.Newest
+	return stats, nil
+}
+
+// DbSizeSnapshot is the size of the database when the stats were collected, the growth of the database is the difference
+// between two snapshots
+type DbSizeSnapshot struct {
+	CollectedAt    time.Time `gorm:"primarykey" json:"collectedAt"`
+	TotalSizeBytes int64     `json:"totalSizeBytes"`
+	IncidentCount  int64     `json:"incidentCount"`
+}
+
+// Snapshot returns the size of the database in the stats
+func (s DbStats) Snapshot() DbSizeSnapshot {
+	snapshot := DbSizeSnapshot{CollectedAt: s.CollectedAt}
+	for _, table := range s.Tables {
+		snapshot.TotalSizeBytes += table.SizeBytes
+		if table.Table == incidentsTableName {
+			snapshot.IncidentCount = table.Rows
+		}
+	}
+	return snapshot
+}
+
+// RecordDbSizeSnapshot stores the snapshot and deletes the ones older than the retention
+func (d *DbClient) RecordDbSizeSnapshot(ctx context.Context, snapshot DbSizeSnapshot) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would record db size snapshot", zap.Int64("totalSizeBytes", snapshot.TotalSizeBytes))
+		return nil
+	}
+	table := fmt.Sprintf("%s.%s", schemaName, dbSizeSnapshotsTableName)
+	result := d.db.WithContext(ctx).Table(table).Create(&snapshot)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to record db size snapshot")
+	}
+	result = d.db.WithContext(ctx).Table(table).Where("collected_at < ?", snapshot.CollectedAt.Add(-dbSizeSnapshotRetention)).Delete(&DbSizeSnapshot{})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to delete old db size snapshots")
+	}
+	return nil
+}
+
+// GetDbSizeSnapshotAt returns the latest snapshot collected at or before the given time, or the oldest one if they were
+// all collected after it, nil if there are none
+func (d *DbClient) GetDbSizeSnapshotAt(ctx context.Context, at time.Time) (*DbSizeSnapshot, error) {
+	table := fmt.Sprintf("%s.%s", schemaName, dbSizeSnapshotsTableName)
+	var snapshots []DbSizeSnapshot
+	result := d.db.WithContext(ctx).Table(table).Where("collected_at <= ?", at).Order("collected_at DESC").Limit(1).Find(&snapshots)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if len(snapshots) == 0 {
+		result = d.db.WithContext(ctx).Table(table).Order("collected_at").Limit(1).Find(&snapshots)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+	}
+	if len(snapshots) == 0 {
+		return nil, nil
+	}
+	return &snapshots[0], nil
+}
@@ -0,0 +1,222 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+const (
+	subscriptionsTableName     = "subscriptions"
+	webhookDeliveriesTableName = "webhook_deliveries"
+)
+
+// CreateSubscription stores a new webhook subscription
+func (d *DbClient) CreateSubscription(ctx context.Context, subscription api.Subscription) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would create subscription", zap.String("id", subscription.ID), zap.String("url", subscription.URL))
+		return nil
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, subscriptionsTableName)).Create(&subscription)
+	return result.Error
+}
+
+// GetSubscriptions returns the subscriptions of the api key, oldest first
+func (d *DbClient) GetSubscriptions(ctx context.Context, apiKeyID string) ([]api.Subscription, error) {
+	var subscriptions []api.Subscription
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, subscriptionsTableName)).
+		Where("api_key_id = ?", apiKeyID).Order("created_at").Find(&subscriptions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return subscriptions, nil
+}
+
+// GetAllSubscriptions returns the subscriptions of every api key that isn't revoked
+func (d *DbClient) GetAllSubscriptions(ctx context.Context) ([]api.Subscription, error) {
+	var subscriptions []api.Subscription
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, subscriptionsTableName)).
+		Where(fmt.Sprintf("api_key_id IN (SELECT id FROM %s.%s WHERE revoked_at IS NULL)", schemaName, apiKeysTableName)).
+		Find(&subscriptions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return subscriptions, nil
+}
+
+// GetSubscription returns the subscription of the api key with the given id, nil if there isn't one
+func (d *DbClient) GetSubscription(ctx context.Context, apiKeyID string, id string) (*api.Subscription, error) {
+	var subscription api.Subscription
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, subscriptionsTableName)).
+		Where("api_key_id = ? AND id = ?", apiKeyID, id).First(&subscription)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &subscription, nil
+}
+
+// DeleteSubscription deletes the subscription and its deliveries, pending deliveries are no longer attempted
+func (d *DbClient) DeleteSubscription(ctx context.Context, id string) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would delete subscription", zap.String("id", id))
+		return nil
+	}
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Table(fmt.Sprintf("%s.%s", schemaName, webhookDeliveriesTableName)).Where("subscription_id = ?", id).Delete(&api.WebhookDelivery{})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to delete webhook deliveries")
+		}
+		result = tx.Table(fmt.Sprintf("%s.%s", schemaName, subscriptionsTableName)).Where("id = ?", id).Delete(&api.Subscription{})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to delete subscription")
+		}
+		return nil
+	})
+}
+
+// CreateWebhookDeliveries queues the deliveries, a delivery of an event that is already queued for the subscription is skipped
+// so redelivered outbox events aren't sent twice
+func (d *DbClient) CreateWebhookDeliveries(ctx context.Context, deliveries []api.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if d.dryRun {
+		d.logger.Info("dry run: would create webhook deliveries", zap.Int("deliveries", len(deliveries)))
+		return nil
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, webhookDeliveriesTableName)).
+		Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&deliveries, d.upsertBatchSize)
+	return result.Error
+}
+
+// DispatchWebhookDeliveries claims up to limit pending deliveries that are due and passes each to deliver along with its
+// subscription, deliver updates the status of the delivery which is then saved on its own. The deliveries are claimed
+// for claimFor in a short transaction that locks them with SKIP LOCKED, so a delivery is never attempted by two scrapers
+// at once, and are posted after it commits so that a slow webhook doesn't hold a transaction open
+func (d *DbClient) DispatchWebhookDeliveries(ctx context.Context, limit int, now time.Time, claimFor time.Duration, deliver func(delivery *api.WebhookDelivery, subscription api.Subscription)) (int, error) {
+	if d.dryRun {
+		d.logger.Info("dry run: would dispatch webhook deliveries")
+		return 0, nil
+	}
+	table := fmt.Sprintf("%s.%s", schemaName, webhookDeliveriesTableName)
+	var deliveries []api.WebhookDelivery
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Table(table).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", api.DeliveryStatusPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&deliveries)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to claim webhook deliveries")
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+		ids := make([]uint64, 0, len(deliveries))
+		for _, delivery := range deliveries {
+			ids = append(ids, delivery.ID)
+		}
+		// The claim pushes the next attempt back, a delivery that was claimed by a scraper that died is attempted again
+		// once the claim is over
+		result = tx.Table(table).Where("id IN ?", ids).Update("next_attempt_at", now.Add(claimFor))
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to claim webhook deliveries")
+		}
+		return nil
+	})
+	if err != nil || len(deliveries) == 0 {
+		return 0, err
+	}
+
+	ids := make([]string, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		ids = append(ids, delivery.SubscriptionID)
+	}
+	var subscriptions []api.Subscription
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, subscriptionsTableName)).Where("id IN ?", ids).Find(&subscriptions)
+	if result.Error != nil {
+		return 0, errors.Wrap(result.Error, "failed to get the subscriptions of the webhook deliveries")
+	}
+	subscriptionsByID := map[string]api.Subscription{}
+	for _, subscription := range subscriptions {
+		subscriptionsByID[subscription.ID] = subscription
+	}
+
+	// Each result is saved as soon as the delivery was attempted, so failing to save one doesn't resend the others
+	dispatched := 0
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		subscription, found := subscriptionsByID[delivery.SubscriptionID]
+		if !found {
+			continue
+		}
+		deliver(delivery, subscription)
+		result = d.db.WithContext(ctx).Table(table).Where("id = ?", delivery.ID).
+			Updates(map[string]interface{}{
+				"status":           delivery.Status,
+				"attempts":         delivery.Attempts,
+				"next_attempt_at":  delivery.NextAttemptAt,
+				"last_status_code": delivery.LastStatusCode,
+				"last_error":       delivery.LastError,
+				"delivered_at":     delivery.DeliveredAt,
+			})
+		if result.Error != nil {
+			d.logger.Error("failed to update webhook delivery", zap.Uint64("delivery", delivery.ID), zap.Error(result.Error))
+			continue
+		}
+		dispatched++
+	}
+	return dispatched, nil
+}
+
+// GetWebhookDeliveries returns the latest deliveries of the subscription, newest first
+func (d *DbClient) GetWebhookDeliveries(ctx context.Context, subscriptionID string, limit int) ([]api.WebhookDelivery, error) {
+	var deliveries []api.WebhookDelivery
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, webhookDeliveriesTableName)).
+		Where("subscription_id = ?", subscriptionID).Order("id DESC").Limit(limit).Find(&deliveries)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return deliveries, nil
+}
+
+// GetWebhookDeliveryCounts returns the number of webhook deliveries created since the given time by status
+func (d *DbClient) GetWebhookDeliveryCounts(ctx context.Context, since time.Time) (map[api.DeliveryStatus]int64, error) {
+	var rows []struct {
+		Status api.DeliveryStatus
+		Count  int64
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, webhookDeliveriesTableName)).
+		Select("status, count(*) AS count").Where("created_at >= ?", since).Group("status").Scan(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	counts := make(map[api.DeliveryStatus]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// DeleteFinishedWebhookDeliveries removes the deliveries that were delivered or failed before the given time
+func (d *DbClient) DeleteFinishedWebhookDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	if d.dryRun {
+		d.logger.Info("dry run: would delete finished webhook deliveries")
+		return 0, nil
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, webhookDeliveriesTableName)).
+		Where("status != ? AND created_at < ?", api.DeliveryStatusPending, before).Delete(&api.WebhookDelivery{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
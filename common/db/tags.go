@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const statusPageTagsTableName = "status_page_tags"
+
+// GetStatusPageTags returns the tags of the tenant of the context, or the shared tags if the context isn't scoped to a
+// tenant, ordered by tag
+func (d *DbClient) GetStatusPageTags(ctx context.Context) ([]api.StatusPageTag, error) {
+	tenantID, _ := TenantFromContext(ctx)
+	var tags []api.StatusPageTag
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, statusPageTagsTableName)).Where("tenant_id = ?", tenantID).Order("tag, status_page_url").Find(&tags)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return tags, nil
+}
+
+// SetStatusPageTags replaces the tags of the status page, they are the tags of the tenant of the context or the shared
+// tags if the context isn't scoped to a tenant
+func (d *DbClient) SetStatusPageTags(ctx context.Context, statusPageUrl string, tags []string) error {
+	tenantID, _ := TenantFromContext(ctx)
+	if d.dryRun {
+		d.logger.Info("dry run: would set status page tags", zap.String("tenantId", tenantID), zap.String("statusPageUrl", statusPageUrl), zap.Strings("tags", tags))
+		return nil
+	}
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		table := fmt.Sprintf("%s.%s", schemaName, statusPageTagsTableName)
+		result := tx.Table(table).Where("tenant_id = ? AND status_page_url = ?", tenantID, statusPageUrl).Delete(&api.StatusPageTag{})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to delete status page tags")
+		}
+		if len(tags) == 0 {
+			return nil
+		}
+		rows := make([]api.StatusPageTag, 0, len(tags))
+		for _, tag := range tags {
+			rows = append(rows, api.StatusPageTag{TenantID: tenantID, StatusPageUrl: statusPageUrl, Tag: tag})
+		}
+		result = tx.Table(table).Create(&rows)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to create status page tags")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,160 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+const tenantsTableName = "tenants"
+const tenantStatusPagesTableName = "tenant_status_pages"
+
+type tenantContextKey struct{}
+
+// WithTenant scopes the queries made with the returned context to the status pages that the tenant tracks
+// The queries of lists across status pages are scoped, see scopeToTenant
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant the context is scoped to, see WithTenant
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenantID, found := ctx.Value(tenantContextKey{}).(string)
+	return tenantID, found && tenantID != ""
+}
+
+// scopeToTenant restricts the query to the rows whose column is a status page that the tenant of the context tracks
+// The query is unchanged if the context isn't scoped to a tenant
+func scopeToTenant(ctx context.Context, tx *gorm.DB, column string) *gorm.DB {
+	tenantID, found := TenantFromContext(ctx)
+	if !found {
+		return tx
+	}
+	return tx.Where(fmt.Sprintf("%s IN (SELECT status_page_url FROM %s.%s WHERE tenant_id = ?)", column, schemaName, tenantStatusPagesTableName), tenantID)
+}
+
+// CreateTenant stores a new tenant
+func (d *DbClient) CreateTenant(ctx context.Context, tenant api.Tenant) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would create tenant", zap.String("id", tenant.ID), zap.String("name", tenant.Name))
+		return nil
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, tenantsTableName)).Create(&tenant)
+	return result.Error
+}
+
+// EnsureTenant stores the tenant unless there already is one with its id, several scrapers can ensure it at once
+func (d *DbClient) EnsureTenant(ctx context.Context, tenant api.Tenant) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would ensure tenant", zap.String("id", tenant.ID), zap.String("name", tenant.Name))
+		return nil
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, tenantsTableName)).Clauses(clause.OnConflict{DoNothing: true}).Create(&tenant)
+	return result.Error
+}
+
+// GetTenant returns the tenant with the given id, nil if there isn't one
+func (d *DbClient) GetTenant(ctx context.Context, id string) (*api.Tenant, error) {
+	var tenant api.Tenant
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, tenantsTableName)).Where("id = ?", id).First(&tenant)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &tenant, nil
+}
+
+// GetTenants returns every tenant, oldest first
+func (d *DbClient) GetTenants(ctx context.Context) ([]api.Tenant, error) {
+	var tenants []api.Tenant
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, tenantsTableName)).Order("created_at").Find(&tenants)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return tenants, nil
+}
+
+// DeleteTenant deletes the tenant with the list of status pages it tracks and its tags, and revokes its api keys
+// The status pages are kept, other tenants may track them
+func (d *DbClient) DeleteTenant(ctx context.Context, id string, at time.Time) error {
+	if d.dryRun {
+		d.logger.Info("dry run: would delete tenant", zap.String("id", id))
+		return nil
+	}
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Table(fmt.Sprintf("%s.%s", schemaName, tenantStatusPagesTableName)).Where("tenant_id = ?", id).Delete(&api.TenantStatusPage{})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to delete tenant status pages")
+		}
+		result = tx.Table(fmt.Sprintf("%s.%s", schemaName, statusPageTagsTableName)).Where("tenant_id = ?", id).Delete(&api.StatusPageTag{})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to delete tenant tags")
+		}
+		result = tx.Table(fmt.Sprintf("%s.%s", schemaName, apiKeysTableName)).Where("tenant_id = ? AND revoked_at IS NULL", id).Update("revoked_at", at)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to revoke tenant api keys")
+		}
+		result = tx.Table(fmt.Sprintf("%s.%s", schemaName, tenantsTableName)).Where("id = ?", id).Delete(&api.Tenant{})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to delete tenant")
+		}
+		return nil
+	})
+}
+
+// GetTenantStatusPageUrls returns the urls of the status pages that the tenant tracks
+func (d *DbClient) GetTenantStatusPageUrls(ctx context.Context, tenantID string) ([]string, error) {
+	var urls []string
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, tenantStatusPagesTableName)).Where("tenant_id = ?", tenantID).Order("status_page_url").Pluck("status_page_url", &urls)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return urls, nil
+}
+
+// TrackStatusPages adds the status pages to the ones that the tenant tracks, the ones it already tracks are skipped
+func (d *DbClient) TrackStatusPages(ctx context.Context, tenantID string, urls []string, at time.Time) error {
+	if len(urls) == 0 {
+		return nil
+	}
+	if d.dryRun {
+		d.logger.Info("dry run: would track status pages", zap.String("tenantId", tenantID), zap.Strings("urls", urls))
+		return nil
+	}
+	rows := make([]api.TenantStatusPage, 0, len(urls))
+	for _, url := range urls {
+		rows = append(rows, api.TenantStatusPage{TenantID: tenantID, StatusPageUrl: url, CreatedAt: at})
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, tenantStatusPagesTableName)).Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&rows, d.upsertBatchSize)
+	return result.Error
+}
+
+// UntrackStatusPage removes the status page from the ones that the tenant tracks along with the tags the tenant gave it,
+// it returns false if it wasn't tracked
+func (d *DbClient) UntrackStatusPage(ctx context.Context, tenantID string, url string) (bool, error) {
+	if d.dryRun {
+		d.logger.Info("dry run: would untrack status page", zap.String("tenantId", tenantID), zap.String("url", url))
+		return true, nil
+	}
+	var found bool
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Table(fmt.Sprintf("%s.%s", schemaName, tenantStatusPagesTableName)).Where("tenant_id = ? AND status_page_url = ?", tenantID, url).Delete(&api.TenantStatusPage{})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to delete tenant status page")
+		}
+		found = result.RowsAffected > 0
+		result = tx.Table(fmt.Sprintf("%s.%s", schemaName, statusPageTagsTableName)).Where("tenant_id = ? AND status_page_url = ?", tenantID, url).Delete(&api.StatusPageTag{})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to delete tenant tags")
+		}
+		return nil
+	})
+	return found, err
+}
@@ -0,0 +1,152 @@
+package detect
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/pkg/errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// maxBodySize bounds how much of a probed response is read
+const maxBodySize = 2 * 1024 * 1024
+
+// marker is a string that is only found on the pages of one status page product, and the providers that scrape it
+type marker struct {
+	text      string
+	providers []string
+}
+
+// markers are looked for in the generator meta tag first and then in the whole home page
+// Statuspage is checked last as the other products sometimes link to it
+var markers = []marker{
+	{text: "instatus", providers: []string{"Instatus"}},
+	{text: "betteruptime", providers: []string{"Better Stack"}},
+	{text: "betterstack", providers: []string{"Better Stack"}},
+	{text: "cachet", providers: []string{"Cachet"}},
+	{text: "statuspal", providers: []string{"Statuspal"}},
+	{text: "status.io", providers: []string{"status.io"}},
+	{text: "statuspage", providers: []string{"Atlassian API", "Atlassian"}},
+}
+
+// Probe fetches the home page and well-known endpoints of the status page and returns the names of the providers
+// that are likely to scrape it, most likely first. The candidates still have to be confirmed with their Matches
+// An empty list means the status page couldn't be classified
+func Probe(ctx context.Context, httpClient *http.Client, url string) ([]string, error) {
+	url = strings.TrimSuffix(url, "/")
+	var candidates []string
+	seen := map[string]bool{}
+	add := func(providers ...string) {
+		for _, provider := range providers {
+			if !seen[provider] {
+				seen[provider] = true
+				candidates = append(candidates, provider)
+			}
+		}
+	}
+
+	// The Statuspage API is the strongest signal, every Statuspage hosted page serves it
+	summary, found, err := get(ctx, httpClient, url+"/api/v2/summary.json")
+	if err != nil {
+		return nil, err
+	}
+	if found && isStatuspageSummary(summary) {
+		add("Atlassian API", "Atlassian")
+	}
+
+	home, found, err := get(ctx, httpClient, url)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		add(classifyHomePage(home)...)
+	}
+
+	// Statuspage serves its history as a feed, which the feed provider also reads
+	history, found, err := get(ctx, httpClient, url+"/history.rss")
+	if err != nil {
+		return nil, err
+	}
+	if found && strings.Contains(string(history), "<rss") {
+		add("Atlassian", "Feed")
+	}
+	return candidates, nil
+}
+
+func isStatuspageSummary(body []byte) bool {
+	var summary struct {
+		Page struct {
+			ID string `json:"id"`
+		} `json:"page"`
+		Components json.RawMessage `json:"components"`
+	}
+	err := json.Unmarshal(body, &summary)
+	return err == nil && summary.Page.ID != "" && summary.Components != nil
+}
+
+// classifyHomePage returns the providers whose markers are on the home page, the generator meta tag takes precedence
+func classifyHomePage(body []byte) []string {
+	var providers []string
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(body)))
+	if err == nil {
+		generator := strings.ToLower(doc.Find(`meta[name="generator"]`).AttrOr("content", ""))
+		for _, m := range markers {
+			if generator != "" && strings.Contains(generator, m.text) {
+				providers = append(providers, m.providers...)
+			}
+		}
+	}
+	page := strings.ToLower(string(body))
+	for _, m := range markers {
+		if strings.Contains(page, m.text) {
+			providers = append(providers, m.providers...)
+		}
+	}
+	return providers
+}
+
+// get returns false if the endpoint doesn't answer with a 200
+// A failed request is only an error if the context is done, an endpoint that can't be reached is just not a signal
+func get(ctx context.Context, httpClient *http.Client, url string) ([]byte, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to create the request")
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, false, ctx.Err()
+		}
+		return nil, false, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return nil, false, nil
+	}
+	return body, true, nil
+}
+
+// Gone returns true if the status page no longer exists, either its host doesn't resolve or it answers 404 or 410
+func Gone(ctx context.Context, httpClient *http.Client, url string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to create the request")
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return true, nil
+		}
+		return false, errors.Wrap(err, "failed to make the request")
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone, nil
+}
@@ -0,0 +1,340 @@
+// Package filter implements a small expression language for filtering incidents, e.g.
+//
+//	impact>=major AND component~"compute" AND region="eu-west-1"
+//
+// Expressions are parsed server side and compiled to a SQL where clause so that the same
+// semantics apply everywhere a filter is accepted.
+//
+// Supported fields:
+//   - impact: = != > >= < <= against none, maintenance, minor, major, critical (in increasing order of severity)
+//   - component, region: = != ~ against the incident components. Regions are stored as components
+//   - title, description, statusPageUrl: = != ~
+//   - language: = != against the ISO 639-1 code of the language the incident is written in, e.g. ja
+//   - rawImpact: = != ~ against the severity the status page gave the incident before it was normalized, e.g. partial_outage
+//   - start, end: = != > >= < <= against an RFC3339 timestamp or a YYYY-MM-DD date
+//   - state: = != against open or resolved
+//
+// The ~ operator is a case insensitive substring match. Comparisons can be combined with AND, OR, NOT and parentheses.
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+// Expression is a parsed filter expression
+type Expression struct {
+	source string
+	root   node
+}
+
+// Parse parses the given filter expression, returning an error describing the first problem found
+func Parse(input string) (*Expression, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, errors.Errorf("unexpected %q at position %d", p.peek().value, p.peek().pos)
+	}
+	return &Expression{source: input, root: root}, nil
+}
+
+// String returns the source of the expression
+func (e *Expression) String() string {
+	return e.source
+}
+
+// ToSQL compiles the expression to a where clause and the arguments for its placeholders
+func (e *Expression) ToSQL() (string, []interface{}) {
+	var sb strings.Builder
+	var args []interface{}
+	e.root.writeSQL(&sb, &args)
+	return sb.String(), args
+}
+
+type node interface {
+	writeSQL(sb *strings.Builder, args *[]interface{})
+}
+
+type binaryNode struct {
+	operator    string
+	left, right node
+}
+
+func (n binaryNode) writeSQL(sb *strings.Builder, args *[]interface{}) {
+	sb.WriteString("(")
+	n.left.writeSQL(sb, args)
+	sb.WriteString(" " + n.operator + " ")
+	n.right.writeSQL(sb, args)
+	sb.WriteString(")")
+}
+
+type notNode struct {
+	inner node
+}
+
+func (n notNode) writeSQL(sb *strings.Builder, args *[]interface{}) {
+	sb.WriteString("NOT (")
+	n.inner.writeSQL(sb, args)
+	sb.WriteString(")")
+}
+
+// sqlNode is a leaf of the expression that has already been compiled to SQL
+type sqlNode struct {
+	sql  string
+	args []interface{}
+}
+
+func (n sqlNode) writeSQL(sb *strings.Builder, args *[]interface{}) {
+	sb.WriteString(n.sql)
+	*args = append(*args, n.args...)
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{operator: "OR", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{operator: "AND", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.peek().kind == tokenNot {
+		p.next()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{inner: inner}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenLeftParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRightParen {
+			return nil, errors.Errorf("expected ')' at position %d", closing.pos)
+		}
+		return inner, nil
+	case tokenIdent:
+		op := p.next()
+		if op.kind != tokenOperator {
+			return nil, errors.Errorf("expected an operator after %q at position %d", t.value, op.pos)
+		}
+		value := p.next()
+		if value.kind != tokenIdent && value.kind != tokenString {
+			return nil, errors.Errorf("expected a value after %q at position %d", op.value, value.pos)
+		}
+		return compileComparison(t.value, op.value, value.value)
+	case tokenEOF:
+		return nil, errors.New("unexpected end of expression")
+	default:
+		return nil, errors.Errorf("unexpected %q at position %d", t.value, t.pos)
+	}
+}
+
+func compileComparison(field string, operator string, value string) (node, error) {
+	switch strings.ToLower(field) {
+	case "impact":
+		return compileImpact(operator, value)
+	case "component", "region":
+		return compileComponent(field, operator, value)
+	case "title":
+		return compileText("title", field, operator, value)
+	case "description":
+		return compileText("description", field, operator, value)
+	case "language":
+		return compileText("language", field, operator, value)
+	case "rawimpact":
+		return compileText("raw_impact", field, operator, value)
+	case "statuspageurl", "page":
+		return compileText("status_page_url", field, operator, value)
+	case "start":
+		return compileTime("start_time", field, operator, value)
+	case "end":
+		return compileTime("end_time", field, operator, value)
+	case "state":
+		return compileState(operator, value)
+	default:
+		return nil, errors.Errorf("unknown field %q", field)
+	}
+}
+
+func compileImpact(operator string, value string) (node, error) {
+	if !isComparisonOperator(operator) {
+		return nil, errors.Errorf("operator %q is not supported for impact", operator)
+	}
+	severity := api.Impact(strings.ToLower(value)).Severity()
+	if severity == -1 {
+		return nil, errors.Errorf("unknown impact %q", value)
+	}
+	var matching []string
+	for _, impact := range api.ImpactsBySeverity {
+		if compareInts(operator, impact.Severity(), severity) {
+			matching = append(matching, string(impact))
+		}
+	}
+	if len(matching) == 0 {
+		return sqlNode{sql: "FALSE"}, nil
+	}
+	return sqlNode{sql: "impact IN ?", args: []interface{}{matching}}, nil
+}
+
+func compileComponent(field string, operator string, value string) (node, error) {
+	switch operator {
+	case "=":
+		return sqlNode{sql: "components @> ?::jsonb", args: []interface{}{jsonStringArray(value)}}, nil
+	case "!=":
+		return sqlNode{sql: "NOT COALESCE(components @> ?::jsonb, FALSE)", args: []interface{}{jsonStringArray(value)}}, nil
+	case "~":
+		return sqlNode{sql: "EXISTS (SELECT 1 FROM jsonb_array_elements_text(CASE WHEN jsonb_typeof(components) = 'array' THEN components ELSE '[]'::jsonb END) AS component WHERE component ILIKE ?)", args: []interface{}{likePattern(value)}}, nil
+	default:
+		return nil, errors.Errorf("operator %q is not supported for %s", operator, field)
+	}
+}
+
+func compileText(column string, field string, operator string, value string) (node, error) {
+	switch operator {
+	case "=":
+		return sqlNode{sql: column + " = ?", args: []interface{}{value}}, nil
+	case "!=":
+		return sqlNode{sql: column + " IS DISTINCT FROM ?", args: []interface{}{value}}, nil
+	case "~":
+		return sqlNode{sql: column + " ILIKE ?", args: []interface{}{likePattern(value)}}, nil
+	default:
+		return nil, errors.Errorf("operator %q is not supported for %s", operator, field)
+	}
+}
+
+func compileTime(column string, field string, operator string, value string) (node, error) {
+	if !isComparisonOperator(operator) {
+		return nil, errors.Errorf("operator %q is not supported for %s", operator, field)
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t, err = time.Parse(time.DateOnly, value)
+		if err != nil {
+			return nil, errors.Errorf("invalid time %q for %s, expected RFC3339 or YYYY-MM-DD", value, field)
+		}
+	}
+	if operator == "!=" {
+		return sqlNode{sql: column + " IS DISTINCT FROM ?", args: []interface{}{t}}, nil
+	}
+	return sqlNode{sql: fmt.Sprintf("%s %s ?", column, operator), args: []interface{}{t}}, nil
+}
+
+func compileState(operator string, value string) (node, error) {
+	var open bool
+	switch strings.ToLower(value) {
+	case "open":
+		open = true
+	case "resolved", "closed":
+		open = false
+	default:
+		return nil, errors.Errorf("unknown state %q, expected open or resolved", value)
+	}
+	switch operator {
+	case "=":
+	case "!=":
+		open = !open
+	default:
+		return nil, errors.Errorf("operator %q is not supported for state", operator)
+	}
+	if open {
+		return sqlNode{sql: "end_time IS NULL"}, nil
+	}
+	return sqlNode{sql: "end_time IS NOT NULL"}, nil
+}
+
+func isComparisonOperator(operator string) bool {
+	switch operator {
+	case "=", "!=", ">", ">=", "<", "<=":
+		return true
+	}
+	return false
+}
+
+func compareInts(operator string, a int, b int) bool {
+	switch operator {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	}
+	return false
+}
+
+func likePattern(value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+	return "%" + escaped + "%"
+}
+
+func jsonStringArray(value string) string {
+	encoded, _ := json.Marshal([]string{value})
+	return string(encoded)
+}
@@ -0,0 +1,71 @@
+package filter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseToSQL(t *testing.T) {
+	tests := []struct {
+		input string
+		sql   string
+		args  []interface{}
+	}{
+		{
+			input: `impact>=major`,
+			sql:   `impact IN ?`,
+			args:  []interface{}{[]string{"major", "critical"}},
+		},
+		{
+			input: `impact>=major AND component~"compute" AND region="eu-west-1"`,
+			sql:   `((impact IN ? AND EXISTS (SELECT 1 FROM jsonb_array_elements_text(CASE WHEN jsonb_typeof(components) = 'array' THEN components ELSE '[]'::jsonb END) AS component WHERE component ILIKE ?)) AND components @> ?::jsonb)`,
+			args:  []interface{}{[]string{"major", "critical"}, "%compute%", `["eu-west-1"]`},
+		},
+		{
+			input: `NOT (state=open OR title~"50%")`,
+			sql:   `NOT ((end_time IS NULL OR title ILIKE ?))`,
+			args:  []interface{}{`%50\%%`},
+		},
+		{
+			input: `start >= 2024-03-01 and statusPageUrl = "https://www.githubstatus.com"`,
+			sql:   `(start_time >= ? AND status_page_url = ?)`,
+			args:  []interface{}{time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), "https://www.githubstatus.com"},
+		},
+	}
+
+	for _, test := range tests {
+		expression, err := Parse(test.input)
+		if err != nil {
+			t.Errorf("Failed to parse %q: %v", test.input, err)
+			continue
+		}
+		sql, args := expression.ToSQL()
+		if sql != test.sql {
+			t.Errorf("Unexpected sql for %q\ngot:  %s\nwant: %s", test.input, sql, test.sql)
+		}
+		if !reflect.DeepEqual(args, test.args) {
+			t.Errorf("Unexpected args for %q\ngot:  %#v\nwant: %#v", test.input, args, test.args)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	inputs := []string{
+		``,
+		`impact>=`,
+		`impact~major`,
+		`impact=severe`,
+		`colour="red"`,
+		`title~"unterminated`,
+		`(state=open`,
+		`state=open state=resolved`,
+		`start>yesterday`,
+	}
+
+	for _, input := range inputs {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Expected an error parsing %q", input)
+		}
+	}
+}
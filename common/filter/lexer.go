@@ -0,0 +1,104 @@
+package filter
+
+import (
+	"github.com/pkg/errors"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenOperator
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenLeftParen
+	tokenRightParen
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// lex splits the expression into tokens
+// Identifiers and bare values are any run of characters that are not whitespace, parentheses or operators
+// Strings are double quoted and support \" and \\ escapes
+func lex(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLeftParen, value: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRightParen, value: ")", pos: i})
+			i++
+		case r == '"':
+			start := i
+			i++
+			var sb strings.Builder
+			closed := false
+			for i < len(runes) {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					sb.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				if runes[i] == '"' {
+					closed = true
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if !closed {
+				return nil, errors.Errorf("unterminated string starting at position %d", start)
+			}
+			tokens = append(tokens, token{kind: tokenString, value: sb.String(), pos: start})
+		case isOperatorRune(r):
+			start := i
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' && r != '=' && r != '~' {
+				op += "="
+			}
+			i += len([]rune(op))
+			if op == "!" {
+				return nil, errors.Errorf("unexpected '!' at position %d, did you mean '!='", start)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, value: op, pos: start})
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' && runes[i] != '"' && !isOperatorRune(runes[i]) {
+				i++
+			}
+			word := string(runes[start:i])
+			switch strings.ToUpper(word) {
+			case "AND":
+				tokens = append(tokens, token{kind: tokenAnd, value: word, pos: start})
+			case "OR":
+				tokens = append(tokens, token{kind: tokenOr, value: word, pos: start})
+			case "NOT":
+				tokens = append(tokens, token{kind: tokenNot, value: word, pos: start})
+			default:
+				tokens = append(tokens, token{kind: tokenIdent, value: word, pos: start})
+			}
+		}
+	}
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(runes)})
+	return tokens, nil
+}
+
+func isOperatorRune(r rune) bool {
+	return r == '=' || r == '!' || r == '~' || r == '<' || r == '>'
+}
@@ -0,0 +1,693 @@
+// Package graphql executes GraphQL queries against Go values, so the api can serve nested reads in one round trip
+// without a code generated server.
+//
+// The GraphQL types are derived from the Go types: the fields of a struct are its json fields, with the same names and
+// values as in the REST api, and fields that have to be computed, such as a status page's incidents, are added with
+// resolvers. Only queries are supported, along with aliases, variables, fragments and the @skip and @include directives.
+// Introspection isn't, the schema is served as SDL instead.
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resolver returns the value of a field, parent is the Go value of the object the field is on
+type Resolver func(ctx context.Context, parent interface{}, args map[string]interface{}) (interface{}, error)
+
+type Argument struct {
+	Name string
+	// Type is the GraphQL type of the argument, Int, Float, String, Boolean, an enum name or a list of them, e.g. [String]
+	// A type ending with ! is required
+	Type        string
+	Description string
+	// Default is the value of the argument when it isn't given, nil if it has none
+	Default interface{}
+}
+
+// Field is a field of an object that is computed rather than read from the json of the Go value
+type Field struct {
+	Name        string
+	Description string
+	Arguments   []Argument
+	// Type is a value of the Go type that Resolve returns, the GraphQL type of the field is derived from it
+	Type    interface{}
+	Resolve Resolver
+}
+
+type Request struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+}
+
+type Response struct {
+	Data   interface{} `json:"data"`
+	Errors []Error     `json:"errors,omitempty"`
+}
+
+type Error struct {
+	Message string `json:"message"`
+	// Path is the path of the field that failed in the result, e.g. ["statusPages", "nodes", 0, "incidents"]
+	Path []interface{} `json:"path,omitempty"`
+}
+
+// Schema is the query type and the fields that are added to the Go types
+type Schema struct {
+	query    reflect.Type
+	fields   map[reflect.Type][]Field
+	maxDepth int
+	maxCost  int
+}
+
+// NewSchema returns a schema whose query type is the Go type of query, its fields are added with AddFields
+// maxDepth bounds how deeply selections can be nested and maxCost how many fields a query can resolve, see cost
+func NewSchema(query interface{}, maxDepth int, maxCost int) *Schema {
+	return &Schema{query: reflect.TypeOf(query), fields: map[reflect.Type][]Field{}, maxDepth: maxDepth, maxCost: maxCost}
+}
+
+// AddFields adds computed fields to the objects of the Go type of object, they take precedence over its json fields
+func (s *Schema) AddFields(object interface{}, fields ...Field) {
+	t := reflect.TypeOf(object)
+	s.fields[t] = append(s.fields[t], fields...)
+}
+
+func (s *Schema) field(t reflect.Type, name string) (Field, bool) {
+	for _, f := range s.fields[t] {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// Execute runs the query of the request, errors of single fields are returned alongside the rest of the data
+func (s *Schema) Execute(ctx context.Context, request Request) Response {
+	document, err := Parse(request.Query)
+	if err != nil {
+		return Response{Errors: []Error{{Message: "failed to parse the query: " + err.Error()}}}
+	}
+	op, err := document.operation(request.OperationName)
+	if err != nil {
+		return Response{Errors: []Error{{Message: err.Error()}}}
+	}
+	if op.kind != "query" {
+		return Response{Errors: []Error{{Message: op.kind + " operations are not supported"}}}
+	}
+	variables, err := coerceVariables(op.variables, request.Variables)
+	if err != nil {
+		return Response{Errors: []Error{{Message: err.Error()}}}
+	}
+	e := &executor{schema: s, document: document, variables: variables}
+	if e.cost(s.query, op.selections, 1) > s.maxCost {
+		return Response{Errors: []Error{{Message: fmt.Sprintf("the query resolves more than %d fields, counting the fields of every item its lists can return", s.maxCost)}}}
+	}
+	data := e.executeSelections(ctx, reflect.New(s.query).Elem(), op.selections, nil, 1)
+	return Response{Data: data, Errors: e.errors}
+}
+
+func (d *Document) operation(name string) (*operation, error) {
+	if name == "" {
+		if len(d.operations) > 1 {
+			return nil, errors.New("operationName is required when the document has more than one operation")
+		}
+		return d.operations[0], nil
+	}
+	for _, op := range d.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, errors.Errorf("unknown operation %q", name)
+}
+
+func coerceVariables(definitions []variableDefinition, given map[string]interface{}) (map[string]interface{}, error) {
+	variables := map[string]interface{}{}
+	for _, definition := range definitions {
+		value, found := given[definition.name]
+		if !found {
+			value = definition.defaultValue
+		}
+		coerced, err := coerce(definition.typ, value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value of variable $%s", definition.name)
+		}
+		variables[definition.name] = coerced
+	}
+	return variables, nil
+}
+
+type executor struct {
+	schema    *Schema
+	document  *Document
+	variables map[string]interface{}
+	errors    []Error
+}
+
+func (e *executor) fail(path []interface{}, message string) {
+	e.errors = append(e.errors, Error{Message: message, Path: append([]interface{}{}, path...)})
+}
+
+// executeSelections resolves the selected fields of the struct v
+func (e *executor) executeSelections(ctx context.Context, v reflect.Value, selections []selection, path []interface{}, depth int) interface{} {
+	if depth > e.schema.maxDepth {
+		e.fail(path, fmt.Sprintf("the query is nested more than %d levels deep", e.schema.maxDepth))
+		return nil
+	}
+	fields, err := e.collectFields(v.Type(), selections, map[string]bool{})
+	if err != nil {
+		e.fail(path, err.Error())
+		return nil
+	}
+	result := make(orderedObject, 0, len(fields))
+	for _, f := range fields {
+		fieldPath := append(path[:len(path):len(path)], f.key())
+		result = append(result, keyValue{key: f.key(), value: e.executeField(ctx, v, f, fieldPath, depth)})
+	}
+	return result
+}
+
+func (e *executor) executeField(ctx context.Context, v reflect.Value, f *field, path []interface{}, depth int) interface{} {
+	t := v.Type()
+	if f.name == "__typename" {
+		return typeName(t)
+	}
+	if definition, found := e.schema.field(t, f.name); found {
+		args, err := e.coerceArguments(definition.Arguments, f.arguments)
+		if err != nil {
+			e.fail(path, err.Error())
+			return nil
+		}
+		value, err := definition.Resolve(ctx, v.Interface(), args)
+		if err != nil {
+			e.fail(path, err.Error())
+			return nil
+		}
+		return e.complete(ctx, reflect.ValueOf(value), f, path, depth)
+	}
+	for _, jf := range jsonFields(t) {
+		if jf.name == f.name {
+			if len(f.arguments) > 0 {
+				e.fail(path, fmt.Sprintf("field %q of type %s has no arguments", f.name, typeName(t)))
+				return nil
+			}
+			return e.complete(ctx, v.FieldByIndex(jf.index), f, path, depth)
+		}
+	}
+	e.fail(path, fmt.Sprintf("type %s has no field %q", typeName(t), f.name))
+	return nil
+}
+
+// cost returns the number of fields the selections of the Go type t resolve before the query is executed, the fields
+// selected on a field with a first argument are counted once for each of the first items it can return
+// Aliases of a field are counted separately, so neither aliases nor wide lists can multiply the work of a small query
+// The invalid selections are left to the execution to report, it stops counting once the cost is over the maximum
+func (e *executor) cost(t reflect.Type, selections []selection, depth int) int {
+	if depth > e.schema.maxDepth {
+		return 0
+	}
+	fields, err := e.collectFields(t, selections, map[string]bool{})
+	if err != nil {
+		return 0
+	}
+	total := 0
+	for _, f := range fields {
+		total++
+		if len(f.selections) == 0 {
+			continue
+		}
+		fieldType, items := e.fieldType(t, f)
+		if fieldType == nil {
+			continue
+		}
+		total += items * e.cost(fieldType, f.selections, depth+1)
+		if total > e.schema.maxCost {
+			return total
+		}
+	}
+	return total
+}
+
+// fieldType returns the object type of the field of the Go type t, the element type for a list, and how many items the
+// field can return, which is its first argument if it has one
+func (e *executor) fieldType(t reflect.Type, f *field) (reflect.Type, int) {
+	var fieldType reflect.Type
+	items := 1
+	if definition, found := e.schema.field(t, f.name); found {
+		fieldType = reflect.TypeOf(definition.Type)
+		args, err := e.coerceArguments(definition.Arguments, f.arguments)
+		if first, ok := args["first"].(int); err == nil && ok && first > 1 {
+			items = first
+		}
+	} else {
+		for _, jf := range jsonFields(t) {
+			if jf.name == f.name {
+				fieldType = t.FieldByIndex(jf.index).Type
+			}
+		}
+	}
+	for fieldType != nil && (fieldType.Kind() == reflect.Pointer || fieldType.Kind() == reflect.Slice || fieldType.Kind() == reflect.Array) {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType == nil || fieldType.Kind() != reflect.Struct {
+		return nil, 0
+	}
+	return fieldType, items
+}
+
+// complete turns the value of a field into its result, the selections of the field are resolved on objects
+func (e *executor) complete(ctx context.Context, v reflect.Value, f *field, path []interface{}, depth int) interface{} {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	if isLeaf(v.Type()) {
+		if len(f.selections) > 0 {
+			e.fail(path, fmt.Sprintf("field %q is a scalar, it has no fields to select", f.name))
+			return nil
+		}
+		return v.Interface()
+	}
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			list[i] = e.complete(ctx, v.Index(i), f, append(path[:len(path):len(path)], i), depth)
+		}
+		return list
+	}
+	if len(f.selections) == 0 {
+		e.fail(path, fmt.Sprintf("field %q of type %s must have a selection of subfields", f.name, typeName(v.Type())))
+		return nil
+	}
+	return e.executeSelections(ctx, v, f.selections, path, depth+1)
+}
+
+// collectFields flattens the fragments of the selections and merges the fields with the same key
+func (e *executor) collectFields(t reflect.Type, selections []selection, visited map[string]bool) ([]*field, error) {
+	var fields []*field
+	byKey := map[string]*field{}
+	add := func(f *field) {
+		if existing, found := byKey[f.key()]; found {
+			if existing.name != f.name {
+				return
+			}
+			merged := *existing
+			merged.selections = append(append([]selection{}, existing.selections...), f.selections...)
+			*existing = merged
+			return
+		}
+		copied := *f
+		byKey[f.key()] = &copied
+		fields = append(fields, &copied)
+	}
+	for _, s := range selections {
+		switch s := s.(type) {
+		case *field:
+			include, err := e.included(s.directives)
+			if err != nil {
+				return nil, err
+			}
+			if include {
+				add(s)
+			}
+		case *fragmentSpread:
+			include, err := e.included(s.directives)
+			if err != nil {
+				return nil, err
+			}
+			if !include || visited[s.name] {
+				continue
+			}
+			fragment, found := e.document.fragments[s.name]
+			if !found {
+				return nil, errors.Errorf("unknown fragment %q", s.name)
+			}
+			if fragment.typeCondition != typeName(t) {
+				continue
+			}
+			visited[s.name] = true
+			spread, err := e.collectFields(t, fragment.selections, visited)
+			delete(visited, s.name)
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range spread {
+				add(f)
+			}
+		case *inlineFragment:
+			include, err := e.included(s.directives)
+			if err != nil {
+				return nil, err
+			}
+			if !include || (s.typeCondition != "" && s.typeCondition != typeName(t)) {
+				continue
+			}
+			inline, err := e.collectFields(t, s.selections, visited)
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range inline {
+				add(f)
+			}
+		}
+	}
+	return fields, nil
+}
+
+// included evaluates the @skip and @include directives
+func (e *executor) included(directives []directive) (bool, error) {
+	for _, d := range directives {
+		if d.name != "skip" && d.name != "include" {
+			return false, errors.Errorf("unknown directive @%s", d.name)
+		}
+		args, err := e.coerceArguments([]Argument{{Name: "if", Type: "Boolean!"}}, d.arguments)
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid @%s", d.name)
+		}
+		if args["if"].(bool) == (d.name == "skip") {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (e *executor) coerceArguments(definitions []Argument, given map[string]interface{}) (map[string]interface{}, error) {
+	for name := range given {
+		found := false
+		for _, definition := range definitions {
+			found = found || definition.Name == name
+		}
+		if !found {
+			return nil, errors.Errorf("unknown argument %q", name)
+		}
+	}
+	args := map[string]interface{}{}
+	for _, definition := range definitions {
+		value, found := given[definition.Name]
+		if found {
+			var err error
+			value, err = e.substituteVariables(value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if value == nil {
+			value = definition.Default
+		}
+		coerced, err := coerce(definition.Type, value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value of argument %q", definition.Name)
+		}
+		args[definition.Name] = coerced
+	}
+	return args, nil
+}
+
+func (e *executor) substituteVariables(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case variable:
+		substituted, found := e.variables[string(value)]
+		if !found {
+			return nil, errors.Errorf("variable $%s is not defined", value)
+		}
+		return substituted, nil
+	case []interface{}:
+		list := make([]interface{}, len(value))
+		for i, item := range value {
+			var err error
+			list[i], err = e.substituteVariables(item)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	}
+	return value, nil
+}
+
+// coerce checks that the value is of the GraphQL type and converts it to the Go value that resolvers are given:
+// int, float64, string, bool, []interface{} or nil
+func coerce(typ string, value interface{}) (interface{}, error) {
+	required := strings.HasSuffix(typ, "!")
+	typ = strings.TrimSuffix(typ, "!")
+	if value == nil {
+		if required {
+			return nil, errors.Errorf("a value of type %s! is required", typ)
+		}
+		return nil, nil
+	}
+	if strings.HasPrefix(typ, "[") {
+		inner := strings.TrimSuffix(strings.TrimPrefix(typ, "["), "]")
+		items, isList := value.([]interface{})
+		if !isList {
+			items = []interface{}{value}
+		}
+		list := make([]interface{}, len(items))
+		for i, item := range items {
+			var err error
+			list[i], err = coerce(inner, item)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	}
+	switch typ {
+	case "Int":
+		switch value := value.(type) {
+		case int:
+			return value, nil
+		case int64:
+			return int(value), nil
+		case float64:
+			if value == float64(int(value)) {
+				return int(value), nil
+			}
+		}
+	case "Float":
+		switch value := value.(type) {
+		case int:
+			return float64(value), nil
+		case int64:
+			return float64(value), nil
+		case float64:
+			return value, nil
+		}
+	case "String":
+		if value, isString := value.(string); isString {
+			return value, nil
+		}
+	case "Boolean":
+		if value, isBool := value.(bool); isBool {
+			return value, nil
+		}
+	default:
+		// Enums are given unquoted in queries and as strings in variables
+		switch value := value.(type) {
+		case enumValue:
+			return string(value), nil
+		case string:
+			return value, nil
+		}
+	}
+	return nil, errors.Errorf("expected a value of type %s", typ)
+}
+
+// orderedObject is an object of the result, its keys are in the order they were selected
+type orderedObject []keyValue
+
+type keyValue struct {
+	key   string
+	value interface{}
+}
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i, kv := range o {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		key, err := json.Marshal(kv.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(kv.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteString(":")
+		buf.Write(value)
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// isLeaf returns true for the types that are returned as they are rather than having fields selected
+func isLeaf(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct:
+		return t == timeType
+	case reflect.Slice:
+		return t == rawMessageType || t.Elem().Kind() == reflect.Uint8
+	case reflect.Array, reflect.Pointer:
+		return false
+	}
+	return true
+}
+
+func typeName(t reflect.Type) string {
+	return t.Name()
+}
+
+type jsonField struct {
+	name  string
+	index []int
+	typ   reflect.Type
+}
+
+var jsonFieldsCache sync.Map
+
+// jsonFields returns the fields of the struct type that are marshalled to json, the fields of embedded structs are promoted
+func jsonFields(t reflect.Type) []jsonField {
+	if cached, found := jsonFieldsCache.Load(t); found {
+		return cached.([]jsonField)
+	}
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if sf.Anonymous && name == "" && sf.Type.Kind() == reflect.Struct {
+			for _, promoted := range jsonFields(sf.Type) {
+				promoted.index = append([]int{i}, promoted.index...)
+				fields = append(fields, promoted)
+			}
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, jsonField{name: name, index: []int{i}, typ: sf.Type})
+	}
+	jsonFieldsCache.Store(t, fields)
+	return fields
+}
+
+// SDL returns the schema in the GraphQL schema definition language
+func (s *Schema) SDL() string {
+	var sb strings.Builder
+	sb.WriteString("\"An RFC 3339 timestamp\"\nscalar DateTime\n\n\"Arbitrary json\"\nscalar JSON\n")
+	queue := []reflect.Type{s.query}
+	seen := map[reflect.Type]bool{s.query: true}
+	for len(queue) > 0 {
+		t := queue[0]
+		queue = queue[1:]
+		sb.WriteString("\ntype " + typeName(t) + " {\n")
+		computed := map[string]bool{}
+		for _, f := range s.fields[t] {
+			computed[f.Name] = true
+		}
+		for _, jf := range jsonFields(t) {
+			if !computed[jf.name] {
+				sb.WriteString("  " + jf.name + ": " + sdlType(jf.typ, &queue, seen) + "\n")
+			}
+		}
+		for _, f := range s.fields[t] {
+			if f.Description != "" {
+				sb.WriteString("  " + strconvQuote(f.Description) + "\n")
+			}
+			sb.WriteString("  " + f.Name)
+			if len(f.Arguments) > 0 {
+				var args []string
+				for _, a := range f.Arguments {
+					arg := a.Name + ": " + a.Type
+					if a.Default != nil {
+						arg += " = " + sdlValue(a.Default)
+					}
+					args = append(args, arg)
+				}
+				sb.WriteString("(" + strings.Join(args, ", ") + ")")
+			}
+			sb.WriteString(": " + sdlType(reflect.TypeOf(f.Type), &queue, seen) + "\n")
+		}
+		sb.WriteString("}\n")
+	}
+	return sb.String()
+}
+
+// sdlType returns the GraphQL type of the Go type, the object types that haven't been written yet are queued
+func sdlType(t reflect.Type, queue *[]reflect.Type, seen map[reflect.Type]bool) string {
+	if t.Kind() == reflect.Pointer {
+		return strings.TrimSuffix(sdlType(t.Elem(), queue, seen), "!")
+	}
+	switch {
+	case t == timeType:
+		return "DateTime!"
+	case t == rawMessageType, t.Kind() == reflect.Map, t.Kind() == reflect.Interface:
+		return "JSON"
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return "[" + sdlType(t.Elem(), queue, seen) + "]"
+	case reflect.Struct:
+		if !seen[t] {
+			seen[t] = true
+			*queue = append(*queue, t)
+		}
+		return typeName(t) + "!"
+	case reflect.String:
+		return "String!"
+	case reflect.Bool:
+		return "Boolean!"
+	case reflect.Float32, reflect.Float64:
+		return "Float!"
+	}
+	return "Int!"
+}
+
+func sdlValue(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return strconvQuote(value)
+	case []interface{}:
+		items := make([]string, len(value))
+		for i, item := range value {
+			items[i] = sdlValue(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return fmt.Sprint(value)
+}
+
+func strconvQuote(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
@@ -0,0 +1,125 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type testQuery struct{}
+
+type testPage struct {
+	Name    string `json:"name"`
+	Private string `json:"-"`
+	testMetadata
+	Tags []string `json:"tags"`
+}
+
+type testMetadata struct {
+	Description string `json:"description"`
+}
+
+type testIncident struct {
+	Title string `json:"title"`
+}
+
+func newTestSchema() *Schema {
+	schema := NewSchema(testQuery{}, 4, 50)
+	schema.AddFields(testQuery{}, Field{
+		Name:      "page",
+		Arguments: []Argument{{Name: "name", Type: "String!"}},
+		Type:      (*testPage)(nil),
+		Resolve: func(ctx context.Context, parent interface{}, args map[string]interface{}) (interface{}, error) {
+			return &testPage{Name: args["name"].(string), Private: "secret", testMetadata: testMetadata{Description: "a page"}, Tags: []string{"a", "b"}}, nil
+		},
+	})
+	schema.AddFields(testPage{}, Field{
+		Name:      "incidents",
+		Arguments: []Argument{{Name: "first", Type: "Int", Default: 2}},
+		Type:      []testIncident{},
+		Resolve: func(ctx context.Context, parent interface{}, args map[string]interface{}) (interface{}, error) {
+			var incidents []testIncident
+			for i := 0; i < args["first"].(int); i++ {
+				incidents = append(incidents, testIncident{Title: parent.(testPage).Name})
+			}
+			return incidents, nil
+		},
+	})
+	return schema
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		request Request
+		want    string
+	}{
+		{
+			request: Request{Query: `{ page(name: "github") { name description tags } }`},
+			want:    `{"data":{"page":{"name":"github","description":"a page","tags":["a","b"]}}}`,
+		},
+		{
+			request: Request{Query: `query Page($name: String!, $first: Int) { p: page(name: $name) { __typename ...Incidents } }
+fragment Incidents on testPage { incidents(first: $first) { title } }`, Variables: map[string]interface{}{"name": "aws", "first": float64(1)}},
+			want: `{"data":{"p":{"__typename":"testPage","incidents":[{"title":"aws"}]}}}`,
+		},
+		{
+			request: Request{Query: `query($skip: Boolean!) { page(name: "x") { name @skip(if: $skip) ... on testPage { incidents { title } } } }`, Variables: map[string]interface{}{"skip": true}},
+			want:    `{"data":{"page":{"incidents":[{"title":"x"},{"title":"x"}]}}}`,
+		},
+		{
+			request: Request{Query: `{ page(name: "x") { private } }`},
+			want:    `{"data":{"page":{"private":null}},"errors":[{"message":"type testPage has no field \"private\"","path":["page","private"]}]}`,
+		},
+		{
+			request: Request{Query: `{ page(name: 1) { name } }`},
+			want:    `{"data":{"page":null},"errors":[{"message":"invalid value of argument \"name\": expected a value of type String","path":["page"]}]}`,
+		},
+		{
+			request: Request{Query: `{ page(name: "x") }`},
+			want:    `{"data":{"page":null},"errors":[{"message":"field \"page\" of type testPage must have a selection of subfields","path":["page"]}]}`,
+		},
+		{
+			request: Request{Query: `{ page(name: "x") { incidents(first: 30) { title } } }`},
+			want:    `{"data":{"page":{"incidents":[` + strings.TrimSuffix(strings.Repeat(`{"title":"x"},`, 30), ",") + `]}}}`,
+		},
+		{
+			request: Request{Query: `{ a: page(name: "x") { incidents(first: 30) { title } } b: page(name: "x") { incidents(first: 30) { title } } }`},
+			want:    `{"data":null,"errors":[{"message":"the query resolves more than 50 fields, counting the fields of every item its lists can return"}]}`,
+		},
+		{
+			request: Request{Query: `mutation { page(name: "x") { name } }`},
+			want:    `{"data":null,"errors":[{"message":"mutation operations are not supported"}]}`,
+		},
+	}
+
+	schema := newTestSchema()
+	for _, test := range tests {
+		got, err := json.Marshal(schema.Execute(context.Background(), test.request))
+		if err != nil {
+			t.Errorf("Failed to marshal the response of %q: %v", test.request.Query, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("Unexpected response for %q\ngot:  %s\nwant: %s", test.request.Query, got, test.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	inputs := []string{
+		``,
+		`{`,
+		`{ page( }`,
+		`{ page { } }`,
+		`{ page(name: "unterminated) { name } }`,
+		`query($name) { page { name } }`,
+		`{ page } fragment F on T { name } fragment F on T { name }`,
+	}
+
+	for _, input := range inputs {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Expected an error parsing %q", input)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package graphql
+
+import (
+	"github.com/pkg/errors"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// lex splits the document into tokens, commas, whitespace and comments are ignored
+// Strings support the escapes of the spec but not block strings
+func lex(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == ',' || r == '\uFEFF':
+			i++
+		case r == '#':
+			for i < len(runes) && runes[i] != '\n' && runes[i] != '\r' {
+				i++
+			}
+		case r == '.':
+			if i+2 >= len(runes) || runes[i+1] != '.' || runes[i+2] != '.' {
+				return nil, errors.Errorf("unexpected '.' at position %d, did you mean '...'", i)
+			}
+			tokens = append(tokens, token{kind: tokenPunctuator, value: "...", pos: i})
+			i += 3
+		case strings.ContainsRune("!$():=@[]{}|&", r):
+			tokens = append(tokens, token{kind: tokenPunctuator, value: string(r), pos: i})
+			i++
+		case r == '"':
+			start := i
+			if i+2 < len(runes) && runes[i+1] == '"' && runes[i+2] == '"' {
+				return nil, errors.Errorf("block strings are not supported, at position %d", start)
+			}
+			i++
+			var sb strings.Builder
+			closed := false
+			for i < len(runes) && !closed {
+				switch runes[i] {
+				case '"':
+					closed = true
+					i++
+				case '\n', '\r':
+					return nil, errors.Errorf("unterminated string starting at position %d", start)
+				case '\\':
+					if i+1 >= len(runes) {
+						return nil, errors.Errorf("unterminated string starting at position %d", start)
+					}
+					escaped, width, err := unescape(runes[i+1:])
+					if err != nil {
+						return nil, errors.Wrapf(err, "invalid escape at position %d", i)
+					}
+					sb.WriteRune(escaped)
+					i += 1 + width
+				default:
+					sb.WriteRune(runes[i])
+					i++
+				}
+			}
+			if !closed {
+				return nil, errors.Errorf("unterminated string starting at position %d", start)
+			}
+			tokens = append(tokens, token{kind: tokenString, value: sb.String(), pos: start})
+		case r == '-' || isDigit(r):
+			start := i
+			kind := tokenInt
+			i++
+			for i < len(runes) && isDigit(runes[i]) {
+				i++
+			}
+			if i < len(runes) && runes[i] == '.' {
+				kind = tokenFloat
+				i++
+				for i < len(runes) && isDigit(runes[i]) {
+					i++
+				}
+			}
+			if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+				kind = tokenFloat
+				i++
+				if i < len(runes) && (runes[i] == '+' || runes[i] == '-') {
+					i++
+				}
+				for i < len(runes) && isDigit(runes[i]) {
+					i++
+				}
+			}
+			value := string(runes[start:i])
+			if value == "-" || strings.HasSuffix(value, ".") || strings.HasSuffix(value, "e") || strings.HasSuffix(value, "E") {
+				return nil, errors.Errorf("invalid number %q at position %d", value, start)
+			}
+			tokens = append(tokens, token{kind: kind, value: value, pos: start})
+		case isNameStart(r):
+			start := i
+			for i < len(runes) && (isNameStart(runes[i]) || isDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenName, value: string(runes[start:i]), pos: start})
+		default:
+			return nil, errors.Errorf("unexpected %q at position %d", r, i)
+		}
+	}
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(runes)})
+	return tokens, nil
+}
+
+// unescape returns the character escaped by the runes following a backslash, and how many runes the escape spans
+func unescape(runes []rune) (rune, int, error) {
+	switch runes[0] {
+	case '"', '\\', '/':
+		return runes[0], 1, nil
+	case 'b':
+		return '\b', 1, nil
+	case 'f':
+		return '\f', 1, nil
+	case 'n':
+		return '\n', 1, nil
+	case 'r':
+		return '\r', 1, nil
+	case 't':
+		return '\t', 1, nil
+	case 'u':
+		if len(runes) < 5 {
+			return 0, 0, errors.New("\\u must be followed by 4 hex digits")
+		}
+		var code rune
+		for _, r := range runes[1:5] {
+			code *= 16
+			switch {
+			case r >= '0' && r <= '9':
+				code += r - '0'
+			case r >= 'a' && r <= 'f':
+				code += r - 'a' + 10
+			case r >= 'A' && r <= 'F':
+				code += r - 'A' + 10
+			default:
+				return 0, 0, errors.New("\\u must be followed by 4 hex digits")
+			}
+		}
+		return code, 5, nil
+	}
+	return 0, 0, errors.Errorf("unknown escape \\%c", runes[0])
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isNameStart(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
@@ -0,0 +1,477 @@
+package graphql
+
+import (
+	"github.com/pkg/errors"
+	"strconv"
+)
+
+// Document is a parsed GraphQL document
+type Document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind       string
+	name       string
+	variables  []variableDefinition
+	selections []selection
+}
+
+type variableDefinition struct {
+	name         string
+	typ          string
+	defaultValue interface{}
+}
+
+// selection is a *field, a *fragmentSpread or an *inlineFragment
+type selection interface{}
+
+type field struct {
+	alias      string
+	name       string
+	arguments  map[string]interface{}
+	directives []directive
+	selections []selection
+	pos        int
+}
+
+// key is the name of the field in the result
+func (f *field) key() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []directive
+	pos        int
+}
+
+type inlineFragment struct {
+	typeCondition string
+	directives    []directive
+	selections    []selection
+}
+
+type fragment struct {
+	name          string
+	typeCondition string
+	selections    []selection
+}
+
+type directive struct {
+	name      string
+	arguments map[string]interface{}
+}
+
+// variable is a reference to a variable in a value, it is replaced by the variable's value when the query is executed
+type variable string
+
+// enumValue is an unquoted name in a value, e.g. major
+type enumValue string
+
+// Parse parses the document, returning an error describing the first problem found
+func Parse(input string) (*Document, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	document := &Document{fragments: map[string]*fragment{}}
+	for p.peek().kind != tokenEOF {
+		switch {
+		case p.peekPunctuator("{"):
+			selections, err := p.parseSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+			document.operations = append(document.operations, &operation{kind: "query", selections: selections})
+		case p.peek().kind == tokenName && p.peek().value == "fragment":
+			fragment, err := p.parseFragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, found := document.fragments[fragment.name]; found {
+				return nil, errors.Errorf("fragment %q is defined more than once", fragment.name)
+			}
+			document.fragments[fragment.name] = fragment
+		case p.peek().kind == tokenName:
+			operation, err := p.parseOperation()
+			if err != nil {
+				return nil, err
+			}
+			document.operations = append(document.operations, operation)
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(document.operations) == 0 {
+		return nil, errors.New("the document has no operation")
+	}
+	return document, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) peekPunctuator(value string) bool {
+	return p.peek().kind == tokenPunctuator && p.peek().value == value
+}
+
+func (p *parser) unexpected() error {
+	t := p.peek()
+	if t.kind == tokenEOF {
+		return errors.Errorf("unexpected end of document at position %d", t.pos)
+	}
+	return errors.Errorf("unexpected %q at position %d", t.value, t.pos)
+}
+
+func (p *parser) expectPunctuator(value string) error {
+	if !p.peekPunctuator(value) {
+		return p.unexpected()
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) expectName() (string, error) {
+	if p.peek().kind != tokenName {
+		return "", p.unexpected()
+	}
+	return p.next().value, nil
+}
+
+func (p *parser) parseOperation() (*operation, error) {
+	kind := p.next().value
+	if kind != "query" && kind != "mutation" && kind != "subscription" {
+		return nil, errors.Errorf("unexpected %q at position %d, expected an operation", kind, p.tokens[p.pos-1].pos)
+	}
+	op := &operation{kind: kind}
+	if p.peek().kind == tokenName {
+		op.name = p.next().value
+	}
+	if p.peekPunctuator("(") {
+		p.next()
+		for !p.peekPunctuator(")") {
+			err := p.expectPunctuator("$")
+			if err != nil {
+				return nil, err
+			}
+			definition := variableDefinition{}
+			definition.name, err = p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			err = p.expectPunctuator(":")
+			if err != nil {
+				return nil, err
+			}
+			definition.typ, err = p.parseType()
+			if err != nil {
+				return nil, err
+			}
+			if p.peekPunctuator("=") {
+				p.next()
+				definition.defaultValue, err = p.parseValue(true)
+				if err != nil {
+					return nil, err
+				}
+			}
+			op.variables = append(op.variables, definition)
+		}
+		p.next()
+	}
+	if p.peekPunctuator("@") {
+		return nil, errors.Errorf("directives on operations are not supported, at position %d", p.peek().pos)
+	}
+	var err error
+	op.selections, err = p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+// parseType returns the type as it is written, e.g. [String!]!
+func (p *parser) parseType() (string, error) {
+	var typ string
+	if p.peekPunctuator("[") {
+		p.next()
+		inner, err := p.parseType()
+		if err != nil {
+			return "", err
+		}
+		err = p.expectPunctuator("]")
+		if err != nil {
+			return "", err
+		}
+		typ = "[" + inner + "]"
+	} else {
+		name, err := p.expectName()
+		if err != nil {
+			return "", err
+		}
+		typ = name
+	}
+	if p.peekPunctuator("!") {
+		p.next()
+		typ += "!"
+	}
+	return typ, nil
+}
+
+func (p *parser) parseFragment() (*fragment, error) {
+	p.next()
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, errors.Errorf("a fragment can't be named on, at position %d", p.tokens[p.pos-1].pos)
+	}
+	if on, err := p.expectName(); err != nil || on != "on" {
+		return nil, errors.Errorf("expected on after the name of fragment %q", name)
+	}
+	typeCondition, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	selections, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return &fragment{name: name, typeCondition: typeCondition, selections: selections}, nil
+}
+
+func (p *parser) parseSelectionSet() ([]selection, error) {
+	err := p.expectPunctuator("{")
+	if err != nil {
+		return nil, err
+	}
+	var selections []selection
+	for !p.peekPunctuator("}") {
+		if p.peekPunctuator("...") {
+			s, err := p.parseSpread()
+			if err != nil {
+				return nil, err
+			}
+			selections = append(selections, s)
+			continue
+		}
+		f, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, f)
+	}
+	p.next()
+	if len(selections) == 0 {
+		return nil, errors.Errorf("empty selection set at position %d", p.tokens[p.pos-1].pos)
+	}
+	return selections, nil
+}
+
+func (p *parser) parseSpread() (selection, error) {
+	pos := p.next().pos
+	if p.peek().kind == tokenName && p.peek().value != "on" {
+		spread := &fragmentSpread{name: p.next().value, pos: pos}
+		var err error
+		spread.directives, err = p.parseDirectives()
+		if err != nil {
+			return nil, err
+		}
+		return spread, nil
+	}
+	inline := &inlineFragment{}
+	if p.peek().kind == tokenName {
+		p.next()
+		var err error
+		inline.typeCondition, err = p.expectName()
+		if err != nil {
+			return nil, err
+		}
+	}
+	var err error
+	inline.directives, err = p.parseDirectives()
+	if err != nil {
+		return nil, err
+	}
+	inline.selections, err = p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return inline, nil
+}
+
+func (p *parser) parseField() (*field, error) {
+	pos := p.peek().pos
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	f := &field{name: name, pos: pos}
+	if p.peekPunctuator(":") {
+		p.next()
+		f.alias = name
+		f.name, err = p.expectName()
+		if err != nil {
+			return nil, err
+		}
+	}
+	f.arguments, err = p.parseArguments()
+	if err != nil {
+		return nil, err
+	}
+	f.directives, err = p.parseDirectives()
+	if err != nil {
+		return nil, err
+	}
+	if p.peekPunctuator("{") {
+		f.selections, err = p.parseSelectionSet()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (p *parser) parseArguments() (map[string]interface{}, error) {
+	arguments := map[string]interface{}{}
+	if !p.peekPunctuator("(") {
+		return arguments, nil
+	}
+	p.next()
+	for !p.peekPunctuator(")") {
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		if _, found := arguments[name]; found {
+			return nil, errors.Errorf("argument %q is given more than once", name)
+		}
+		err = p.expectPunctuator(":")
+		if err != nil {
+			return nil, err
+		}
+		arguments[name], err = p.parseValue(false)
+		if err != nil {
+			return nil, err
+		}
+	}
+	p.next()
+	return arguments, nil
+}
+
+func (p *parser) parseDirectives() ([]directive, error) {
+	var directives []directive
+	for p.peekPunctuator("@") {
+		p.next()
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		arguments, err := p.parseArguments()
+		if err != nil {
+			return nil, err
+		}
+		directives = append(directives, directive{name: name, arguments: arguments})
+	}
+	return directives, nil
+}
+
+// parseValue parses a value, constant values can't reference variables
+func (p *parser) parseValue(constant bool) (interface{}, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokenInt:
+		p.next()
+		value, err := strconv.ParseInt(t.value, 10, 64)
+		if err != nil {
+			return nil, errors.Errorf("invalid int %q at position %d", t.value, t.pos)
+		}
+		return value, nil
+	case tokenFloat:
+		p.next()
+		value, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, errors.Errorf("invalid float %q at position %d", t.value, t.pos)
+		}
+		return value, nil
+	case tokenString:
+		p.next()
+		return t.value, nil
+	case tokenName:
+		p.next()
+		switch t.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return enumValue(t.value), nil
+	case tokenPunctuator:
+		switch t.value {
+		case "$":
+			if constant {
+				return nil, errors.Errorf("unexpected variable at position %d", t.pos)
+			}
+			p.next()
+			name, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			return variable(name), nil
+		case "[":
+			p.next()
+			list := []interface{}{}
+			for !p.peekPunctuator("]") {
+				value, err := p.parseValue(constant)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, value)
+			}
+			p.next()
+			return list, nil
+		case "{":
+			p.next()
+			object := map[string]interface{}{}
+			for !p.peekPunctuator("}") {
+				name, err := p.expectName()
+				if err != nil {
+					return nil, err
+				}
+				err = p.expectPunctuator(":")
+				if err != nil {
+					return nil, err
+				}
+				object[name], err = p.parseValue(constant)
+				if err != nil {
+					return nil, err
+				}
+			}
+			p.next()
+			return object, nil
+		}
+	}
+	return nil, p.unexpected()
+}
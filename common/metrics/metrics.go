@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// DurationBuckets are the buckets of the latency histograms of the queries and api requests in seconds
+var DurationBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Serve serves the metrics registered with the default Prometheus registry on /metrics at the address in the
+// background, the scraper, api server and db client register theirs there along with the Go runtime metrics
+func Serve(logger *zap.Logger, address string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	go func() {
+		err := http.ListenAndServe(address, mux)
+		if err != nil {
+			logger.Error("metrics server stopped", zap.Error(err))
+		}
+	}()
+}
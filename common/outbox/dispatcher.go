@@ -0,0 +1,148 @@
+package outbox
+
+import (
+	"context"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Publisher receives incident change events from the outbox
+// Publish must be idempotent, an event is redelivered to a publisher if publishing a batch containing it fails. The
+// publishers that succeeded aren't given the event again
+type Publisher interface {
+	Name() string
+	Publish(ctx context.Context, events []api.ChangeEvent) error
+}
+
+const (
+	dispatchInterval  = 5 * time.Second
+	dispatchBatchSize = 100
+	// dispatchClaim is how long a dispatcher has to publish a batch before another dispatcher can claim its events,
+	// it allows for the retries of the notifiers
+	dispatchClaim = 10 * time.Minute
+	// dispatchedEventRetention is how long dispatched events are kept for debugging before being deleted
+	dispatchedEventRetention = 7 * 24 * time.Hour
+	cleanupInterval          = 1 * time.Hour
+)
+
+// Dispatcher publishes the change events written to the outbox to downstream publishers
+type Dispatcher struct {
+	logger     *zap.Logger
+	dbClient   *db.DbClient
+	publishers []Publisher
+}
+
+func NewDispatcher(logger *zap.Logger, dbClient *db.DbClient, publishers []Publisher) *Dispatcher {
+	return &Dispatcher{
+		logger:     logger,
+		dbClient:   dbClient,
+		publishers: publishers,
+	}
+}
+
+// Start starts the dispatcher goroutine, it runs until the context is cancelled
+func (d *Dispatcher) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(dispatchInterval)
+		defer ticker.Stop()
+		lastCleanup := time.Time{}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				d.dispatchAll(ctx)
+				if time.Since(lastCleanup) > cleanupInterval {
+					d.cleanup(ctx)
+					lastCleanup = time.Now()
+				}
+			}
+		}
+	}()
+}
+
+// dispatchAll drains the outbox in batches
+func (d *Dispatcher) dispatchAll(ctx context.Context) {
+	for {
+		dispatched, err := d.dbClient.DispatchOutboxEvents(ctx, dispatchBatchSize, dispatchClaim, func(events []api.ChangeEvent) error {
+			return d.publish(ctx, events)
+		})
+		if err != nil {
+			d.logger.Error("failed to dispatch outbox events", zap.Error(err))
+			return
+		}
+		if dispatched < dispatchBatchSize {
+			return
+		}
+	}
+}
+
+// publish gives each publisher the events it wasn't given yet, a publisher that fails doesn't stop the others. The
+// events are then recorded as published to the publishers that succeeded, and the batch fails if any publisher did
+func (d *Dispatcher) publish(ctx context.Context, events []api.ChangeEvent) error {
+	var failed []string
+	for _, publisher := range d.publishers {
+		var pending []int
+		for i, event := range events {
+			if !slices.Contains(event.PublishedTo, publisher.Name()) {
+				pending = append(pending, i)
+			}
+		}
+		if len(pending) == 0 {
+			continue
+		}
+		batch := make([]api.ChangeEvent, 0, len(pending))
+		for _, i := range pending {
+			batch = append(batch, events[i])
+		}
+		err := publisher.Publish(ctx, batch)
+		if err != nil {
+			d.logger.Error("failed to publish outbox events", zap.String("publisher", publisher.Name()), zap.Int("events", len(batch)), zap.Error(err))
+			failed = append(failed, publisher.Name())
+			continue
+		}
+		for _, i := range pending {
+			events[i].PublishedTo = append(events[i].PublishedTo, publisher.Name())
+		}
+	}
+	if len(failed) > 0 {
+		return errors.Errorf("failed to publish to %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func (d *Dispatcher) cleanup(ctx context.Context) {
+	deleted, err := d.dbClient.DeleteDispatchedOutboxEvents(ctx, time.Now().Add(-dispatchedEventRetention))
+	if err != nil {
+		d.logger.Error("failed to delete dispatched outbox events", zap.Error(err))
+		return
+	}
+	if deleted > 0 {
+		d.logger.Info("deleted dispatched outbox events", zap.Int64("events", deleted))
+	}
+}
+
+// LogPublisher logs every change event
+type LogPublisher struct {
+	logger *zap.Logger
+}
+
+func NewLogPublisher(logger *zap.Logger) *LogPublisher {
+	return &LogPublisher{logger: logger}
+}
+
+func (l *LogPublisher) Name() string {
+	return "log"
+}
+
+func (l *LogPublisher) Publish(ctx context.Context, events []api.ChangeEvent) error {
+	for _, event := range events {
+		l.logger.Info("incident changed", zap.String("type", string(event.Type)), zap.String("deepLink", event.DeepLink), zap.String("statusPageUrl", event.StatusPageUrl))
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package telemetry
+
+import (
+	"context"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/pkg/errors"
+	prometheusbridge "go.opentelemetry.io/contrib/bridges/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.uber.org/zap"
+	"time"
+)
+
+type Config struct {
+	// Enabled exports the traces and metrics with OTLP over http, the exporters are configured with the standard
+	// OTEL_EXPORTER_OTLP_* variables, e.g. OTEL_EXPORTER_OTLP_ENDPOINT=http://collector:4318
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the service name and add to the resource
+	Enabled bool `envconfig:"OTEL_ENABLED" default:"false"`
+	// MetricInterval is how often the metrics are exported
+	MetricInterval time.Duration `envconfig:"OTEL_METRIC_INTERVAL" default:"60s"`
+}
+
+func GetConfigFromEnvironment() (Config, error) {
+	var config Config
+	err := envconfig.Process("STATUSPHERE", &config)
+	return config, err
+}
+
+// Init sets the global tracer and meter providers to ones that export to the OTLP endpoint, the metrics are the ones
+// registered with the default Prometheus registry. The returned function flushes and stops the exporters
+// The processors are given every span whether or not telemetry is enabled. Nothing is exported if telemetry isn't enabled,
+// without processors the global providers then drop the spans
+func Init(ctx context.Context, logger *zap.Logger, config Config, serviceName string, processors ...sdktrace.SpanProcessor) (func(context.Context) error, error) {
+	var options []sdktrace.TracerProviderOption
+	for _, processor := range processors {
+		options = append(options, sdktrace.WithSpanProcessor(processor))
+	}
+	if !config.Enabled {
+		if len(processors) == 0 {
+			return func(context.Context) error { return nil }, nil
+		}
+		tracerProvider := sdktrace.NewTracerProvider(options...)
+		otel.SetTracerProvider(tracerProvider)
+		return tracerProvider.Shutdown, nil
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the telemetry resource")
+	}
+	// The variables take precedence over the default service name
+	res, err = resource.Merge(res, resource.Environment())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the telemetry resource from the environment")
+	}
+
+	traceExporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the trace exporter")
+	}
+	tracerProvider := sdktrace.NewTracerProvider(append(options, sdktrace.WithBatcher(traceExporter), sdktrace.WithResource(res))...)
+
+	metricExporter, err := otlpmetrichttp.New(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the metric exporter")
+	}
+	reader := sdkmetric.NewPeriodicReader(metricExporter, sdkmetric.WithInterval(config.MetricInterval), sdkmetric.WithProducer(prometheusbridge.NewMetricProducer()))
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader), sdkmetric.WithResource(res))
+
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetMeterProvider(meterProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Warn("failed to export telemetry", zap.Error(err))
+	}))
+	logger.Info("exporting telemetry", zap.String("service", serviceName))
+
+	return func(ctx context.Context) error {
+		err := tracerProvider.Shutdown(ctx)
+		if meterErr := meterProvider.Shutdown(ctx); err == nil {
+			err = meterErr
+		}
+		return err
+	}, nil
+}
@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+// zoneOffsets are the offsets in seconds east of UTC of the timezone abbreviations that status pages print
+// time.Parse only knows the offset of an abbreviation used by the local timezone, any other one is given a zero offset
+// Ambiguous abbreviations use their most common meaning on status pages, e.g. CST is US Central and IST is India
+var zoneOffsets = map[string]int{
+	"UTC":  0,
+	"GMT":  0,
+	"Z":    0,
+	"WET":  0,
+	"WEST": 1 * 3600,
+	"BST":  1 * 3600,
+	"CET":  1 * 3600,
+	"CEST": 2 * 3600,
+	"EET":  2 * 3600,
+	"EEST": 3 * 3600,
+	"MSK":  3 * 3600,
+	"IST":  5*3600 + 1800,
+	"SGT":  8 * 3600,
+	"HKT":  8 * 3600,
+	"AWST": 8 * 3600,
+	"JST":  9 * 3600,
+	"KST":  9 * 3600,
+	"ACST": 9*3600 + 1800,
+	"AEST": 10 * 3600,
+	"AEDT": 11 * 3600,
+	"NZST": 12 * 3600,
+	"NZDT": 13 * 3600,
+	"HST":  -10 * 3600,
+	"AKST": -9 * 3600,
+	"AKDT": -8 * 3600,
+	"PST":  -8 * 3600,
+	"PDT":  -7 * 3600,
+	"MST":  -7 * 3600,
+	"MDT":  -6 * 3600,
+	"CST":  -6 * 3600,
+	"CDT":  -5 * 3600,
+	"EST":  -5 * 3600,
+	"EDT":  -4 * 3600,
+}
+
+// zonedLayouts carry a numeric offset, which time.Parse handles itself
+var zonedLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	time.RFC1123Z,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05-07:00",
+}
+
+// localLayouts have no zone, the zone abbreviation if there is one has already been removed
+var localLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"Mon, 2 Jan 2006 15:04:05",
+	"Mon, 2 Jan 2006 15:04",
+	"2 Jan 2006 15:04:05",
+	"2 Jan 2006 15:04",
+	"Jan 2, 2006 15:04:05",
+	"Jan 2, 2006 15:04",
+	"Jan 2, 2006, 15:04",
+	"Jan 2, 2006 - 15:04",
+	"Jan 2, 2006 3:04 PM",
+	"Jan 2, 2006 3:04PM",
+	"January 2, 2006 15:04",
+	"January 2, 2006, 15:04",
+	"January 2, 2006 - 15:04",
+	"January 2, 2006 3:04 PM",
+	"January 2, 2006 3:04PM",
+	"2006-01-02",
+	"Jan 2, 2006",
+	"January 2, 2006",
+}
+
+// yearlessLayouts have neither a zone nor a year, the year is inferred from the reference time
+var yearlessLayouts = []string{
+	"Jan 2, 15:04",
+	"Jan 2, 15:04:05",
+	"Jan 2 15:04",
+	"Jan 2 15:04:05",
+	"Jan 2, 3:04 PM",
+	"Jan 2, 3:04PM",
+	"January 2, 15:04",
+	"January 2 15:04",
+	"January 2, 3:04 PM",
+	"January 2, 3:04PM",
+	"Mon, Jan 2, 15:04",
+	"2 Jan 15:04",
+	"2 Jan, 15:04",
+}
+
+// ParseTimestamp parses the timestamps printed by status pages and returns them in UTC
+// A trailing zone abbreviation is resolved to its offset, except for the abbreviations of loc which are resolved in loc
+// so that a page that prints "PST" all year round is still correct in the summer. A timestamp without a zone is
+// in loc, or UTC if loc is nil. A timestamp without a year, e.g. "Jan 2, 15:04 PST", is given the year that puts it
+// closest to reference, so one printed on the 2nd of January for the 31st of December is in the year before.
+func ParseTimestamp(value string, loc *time.Location, reference time.Time) (time.Time, error) {
+	value = strings.Join(strings.Fields(value), " ")
+	if loc == nil {
+		loc = time.UTC
+	}
+	if reference.IsZero() {
+		reference = time.Now()
+	}
+
+	for _, layout := range zonedLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	value, zone := splitZone(value, loc, reference)
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, value, zone); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	for _, layout := range yearlessLayouts {
+		if t, err := time.ParseInLocation(layout, value, zone); err == nil {
+			return closestYear(t, zone, reference).UTC(), nil
+		}
+	}
+	return time.Time{}, errors.Errorf("unrecognised timestamp %q", value)
+}
+
+// IsZoneAbbreviation returns true if the value is a timezone abbreviation that ParseTimestamp understands
+func IsZoneAbbreviation(value string) bool {
+	_, found := zoneOffsets[strings.ToUpper(value)]
+	return found
+}
+
+// splitZone removes a trailing zone abbreviation from the value and returns the location it refers to
+func splitZone(value string, loc *time.Location, reference time.Time) (string, *time.Location) {
+	index := strings.LastIndex(value, " ")
+	if index == -1 {
+		return value, loc
+	}
+	abbreviation := strings.ToUpper(strings.Trim(value[index+1:], "()"))
+	offset, found := zoneOffsets[abbreviation]
+	if !found {
+		return value, loc
+	}
+	value = strings.TrimSpace(value[:index])
+	if usesAbbreviation(loc, abbreviation, reference.Year()) {
+		return value, loc
+	}
+	return value, time.FixedZone(abbreviation, offset)
+}
+
+// usesAbbreviation returns true if loc is in the zone with the abbreviation during the year
+func usesAbbreviation(loc *time.Location, abbreviation string, year int) bool {
+	if loc == time.UTC {
+		return false
+	}
+	january, _ := time.Date(year, time.January, 1, 12, 0, 0, 0, loc).Zone()
+	july, _ := time.Date(year, time.July, 1, 12, 0, 0, 0, loc).Zone()
+	return abbreviation == january || abbreviation == july
+}
+
+// closestYear returns the wall clock time of t in the year before, of or after reference, whichever is closest to it
+func closestYear(t time.Time, loc *time.Location, reference time.Time) time.Time {
+	var closest time.Time
+	for year := reference.Year() - 1; year <= reference.Year()+1; year++ {
+		candidate := time.Date(year, t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+		if closest.IsZero() || absDuration(candidate.Sub(reference)) < absDuration(closest.Sub(reference)) {
+			closest = candidate
+		}
+	}
+	return closest
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"time"
+)
+
+// StandardOffset returns the offset in seconds east of UTC that the location uses outside of daylight saving time
+// in the year of t. This is the smaller of the offsets used in January and July.
+func StandardOffset(loc *time.Location, t time.Time) int {
+	_, january := time.Date(t.Year(), time.January, 1, 12, 0, 0, 0, loc).Zone()
+	_, july := time.Date(t.Year(), time.July, 1, 12, 0, 0, 0, loc).Zone()
+	return min(january, july)
+}
+
+// ReinterpretWallClock returns the instant at which the wall clock time of t occurs in loc
+// The location of t is discarded
+func ReinterpretWallClock(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.Date()
+	hour, minute, second := t.Clock()
+	return time.Date(year, month, day, hour, minute, second, t.Nanosecond(), loc)
+}
+
+// CorrectDSTDrift corrects a timestamp from a page which prints local wall clock times in loc
+// but labels them with the standard offset all year round, e.g. "PST" in the summer.
+// t must have been parsed using the standard offset of loc. If loc observes daylight saving time at that
+// wall clock time, the corrected UTC timestamp is returned along with true. Otherwise t is returned unchanged.
+func CorrectDSTDrift(t time.Time, loc *time.Location) (time.Time, bool) {
+	if loc == nil {
+		return t, false
+	}
+	wallClock := t.In(time.FixedZone("standard", StandardOffset(loc, t)))
+	corrected := ReinterpretWallClock(wallClock, loc)
+	drift := corrected.Sub(t)
+	if drift != time.Hour && drift != -time.Hour {
+		return t, false
+	}
+	return corrected.UTC(), true
+}
@@ -0,0 +1,53 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/pkg/errors"
+	"strconv"
+	"time"
+)
+
+// The headers of the webhook deliveries
+const (
+	SignatureHeader = "X-Statusphere-Signature"
+	TimestampHeader = "X-Statusphere-Timestamp"
+	EventHeader     = "X-Statusphere-Event"
+	DeliveryHeader  = "X-Statusphere-Delivery"
+)
+
+// DefaultTolerance is how far the timestamp of a delivery can be from the time it is verified, it bounds the window in
+// which a captured delivery can be replayed while allowing for clock skew and slow deliveries
+const DefaultTolerance = 5 * time.Minute
+
+var (
+	ErrInvalidSignature = errors.New("the signature doesn't match the payload")
+	ErrInvalidTimestamp = errors.New("the timestamp is outside of the tolerance")
+)
+
+// Sign returns the signature header of a payload, the hex HMAC-SHA256 of the timestamp, a dot and the body
+// keyed with the secret of the subscription. The timestamp is signed so receivers can reject replayed payloads
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and timestamp headers of a delivery against its body, the timestamp has to be within
+// tolerance of now. Receivers should verify the body as it was received, before it is parsed
+func Verify(secret string, timestamp string, signature string, body []byte, tolerance time.Duration, now time.Time) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.Wrap(ErrInvalidTimestamp, "the timestamp must be in unix seconds")
+	}
+	skew := now.Sub(time.Unix(seconds, 0))
+	if skew > tolerance || skew < -tolerance {
+		return ErrInvalidTimestamp
+	}
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/fixtures"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/urlgetter/dburlgetter"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"io"
+	"sort"
+	"strings"
+)
+
+// diffStatusPages scrapes status pages with the full fetch and parse pipeline and prints how the stored incidents
+// would change, nothing is written to the db
+func diffStatusPages(ctx context.Context, logger *zap.Logger, w io.Writer, dbClient *db.DbClient, getter *dburlgetter.DBURLGetter, s scraper.Scraper, args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	urls := flags.String("url", "", "comma separated urls of the status pages to diff")
+	all := flags.Bool("all", false, "diff every stored status page")
+	kind := flags.String("kind", fixtures.KindCurrent, "kind of scrape to diff, historical or current")
+	_ = flags.Parse(args)
+
+	var statusPageUrls []string
+	for _, url := range strings.Split(*urls, ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			statusPageUrls = append(statusPageUrls, url)
+		}
+	}
+	if *all {
+		statusPages, err := dbClient.GetAllStatusPages(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to get status pages")
+		}
+		for _, statusPage := range statusPages {
+			statusPageUrls = append(statusPageUrls, statusPage.URL)
+		}
+	}
+	if len(statusPageUrls) == 0 {
+		return errors.New("-url or -all is required")
+	}
+	if *kind != fixtures.KindHistorical && *kind != fixtures.KindCurrent {
+		return errors.Errorf("unknown scrape kind %q", *kind)
+	}
+
+	// The status page cache holds the timezones, providers and scrape configs that the scrapes use
+	getter.Start()
+	for _, url := range statusPageUrls {
+		err := diffStatusPage(ctx, w, dbClient, getter, s, url, *kind)
+		if err != nil {
+			// One broken status page shouldn't hide the diff of the others
+			logger.Error("failed to diff status page", zap.String("url", url), zap.Error(err))
+			fmt.Fprintf(w, "%s\n  error: %s\n\n", url, err)
+		}
+	}
+	return nil
+}
+
+func diffStatusPage(ctx context.Context, w io.Writer, dbClient *db.DbClient, getter *dburlgetter.DBURLGetter, s scraper.Scraper, url string, kind string) error {
+	scrapeCtx := providers.WithLocation(ctx, getter.Location(url))
+	if config := getter.ScrapeConfig(url); config != nil && len(config.Selectors) > 0 {
+		scrapeCtx = providers.WithSelectors(scrapeCtx, config.Selectors)
+	} else {
+		scrapeCtx = providers.WithHint(scrapeCtx, getter.Provider(url))
+	}
+	var incidents []api.Incident
+	var err error
+	if kind == fixtures.KindHistorical {
+		incidents, err = s.ScrapeStatusPageHistorical(scrapeCtx, url)
+	} else {
+		incidents, err = s.ScrapeStatusPageCurrent(scrapeCtx, url)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to scrape the status page")
+	}
+
+	events, err := dbClient.PreviewIncidentChanges(ctx, incidents)
+	if err != nil {
+		return errors.Wrap(err, "failed to compare the incidents with the stored ones")
+	}
+	stored, err := dbClient.GetIncidents(ctx, url)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the stored incidents")
+	}
+	storedByDeepLink := make(map[string]api.Incident, len(stored))
+	for _, incident := range stored {
+		storedByDeepLink[incident.DeepLink] = incident
+	}
+
+	provider := "no incidents"
+	if len(incidents) > 0 {
+		provider = incidents[0].Provider
+	}
+	fmt.Fprintf(w, "%s (%s, %d incidents scraped)\n", url, provider, len(incidents))
+	for _, event := range events {
+		incident := api.Incident(event.Incident)
+		switch event.Type {
+		case api.ChangeEventIncidentCreated:
+			fmt.Fprintf(w, "+ %s %q\n", incident.DeepLink, incident.Title)
+		default:
+			fmt.Fprintf(w, "~ %s %q\n", incident.DeepLink, incident.Title)
+			for _, line := range fieldChanges(storedByDeepLink[incident.DeepLink], incident) {
+				fmt.Fprintf(w, "    %s\n", line)
+			}
+		}
+	}
+	fmt.Fprintf(w, "  %d new, %d changed, %d unchanged\n\n", countEvents(events, api.ChangeEventIncidentCreated), len(events)-countEvents(events, api.ChangeEventIncidentCreated), len(incidents)-len(events))
+	return nil
+}
+
+// annotatedFields are set by the consumers after the scrape, so a scraped incident never has them
+var annotatedFields = map[string]bool{"language": true, "translatedTitle": true, "translatedDescription": true}
+
+// fieldChanges returns a line for every field of the incident that differs from its stored version, as json
+func fieldChanges(previous api.Incident, current api.Incident) []string {
+	previousFields, err := jsonFields(previous)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	currentFields, err := jsonFields(current)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	names := map[string]bool{}
+	for name := range previousFields {
+		names[name] = true
+	}
+	for name := range currentFields {
+		names[name] = true
+	}
+	var lines []string
+	for name := range names {
+		if annotatedFields[name] {
+			continue
+		}
+		before, after := previousFields[name], currentFields[name]
+		if string(before) == string(after) {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s -> %s", name, orNull(before), orNull(after)))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+func jsonFields(incident api.Incident) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(incident)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the incident")
+	}
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(data, &fields)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal the incident")
+	}
+	return fields, nil
+}
+
+func orNull(value json.RawMessage) string {
+	if value == nil {
+		return "null"
+	}
+	return string(value)
+}
+
+func countEvents(events []api.ChangeEvent, eventType api.ChangeEventType) int {
+	count := 0
+	for _, event := range events {
+		if event.Type == eventType {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"io"
+	"text/tabwriter"
+)
+
+func providerFeatures(scrapeProviders []providers.Provider) []api.ProviderFeatures {
+	var features []api.ProviderFeatures
+	for _, provider := range scrapeProviders {
+		features = append(features, provider.Features())
+	}
+	return features
+}
+
+func printFeatureMatrix(w io.Writer, scrapeProviders []providers.Provider) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROVIDER\tINCIDENTS\tUPDATES\tCOMPONENTS\tMAINTENANCE\tCURRENT STATUS\tWEBHOOKS\tAPI\tHISTORY DEPTH")
+	for _, f := range providerFeatures(scrapeProviders) {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", f.Provider, yesNo(f.Incidents), yesNo(f.IncidentUpdates), yesNo(f.Components), yesNo(f.Maintenance), yesNo(f.CurrentStatus), yesNo(f.Webhooks), yesNo(f.API), f.HistoryDepth)
+	}
+	_ = tw.Flush()
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,147 @@
+package body
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrTooLarge is returned when a response is bigger than the max body size, the download is aborted rather than truncated
+var ErrTooLarge = errors.New("response body exceeds the max body size")
+
+// acceptEncoding lists the content encodings the transport decodes, brotli isn't one of them
+const acceptEncoding = "gzip, deflate"
+
+type Config struct {
+	// MaxBodySize is the most bytes of a decoded response body that are read, zero disables the limit
+	MaxBodySize int64 `envconfig:"SCRAPER_MAX_BODY_SIZE" default:"10485760"`
+}
+
+func GetConfigFromEnvironment() (Config, error) {
+	var config Config
+	err := envconfig.Process("STATUSPHERE", &config)
+	return config, err
+}
+
+// Transport is an http.RoundTripper that asks for compressed responses, decodes them and caps the size of the body
+// The cap applies to the decoded body, so a small compressed response can't expand into gigabytes
+type Transport struct {
+	base        http.RoundTripper
+	maxBodySize int64
+}
+
+func NewTransport(base http.RoundTripper, config Config) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{base: base, maxBodySize: config.MaxBodySize}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Only the encodings that can be decoded are advertised, whatever a header profile asked for
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Method == http.MethodHead || resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified {
+		return resp, nil
+	}
+
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if encoding == "" || encoding == "identity" {
+		if t.maxBodySize > 0 && resp.ContentLength > t.maxBodySize {
+			resp.Body.Close()
+			return nil, errors.Wrapf(ErrTooLarge, "%s is %d bytes", req.URL, resp.ContentLength)
+		}
+	} else {
+		decoded, err := decode(encoding, resp.Body)
+		if err != nil {
+			resp.Body.Close()
+			return nil, errors.Wrapf(err, "failed to decode the response of %s", req.URL)
+		}
+		resp.Body = decoded
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Uncompressed = true
+	}
+	if t.maxBodySize > 0 {
+		resp.Body = &limitedBody{body: resp.Body, remaining: t.maxBodySize}
+	}
+	return resp, nil
+}
+
+// decode returns a reader of the decoded body that closes the underlying body
+func decode(encoding string, body io.ReadCloser) (io.ReadCloser, error) {
+	switch encoding {
+	case "gzip", "x-gzip":
+		reader, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		return &decodedBody{Reader: reader, body: body}, nil
+	case "deflate":
+		// deflate is meant to be zlib wrapped, but some servers send the raw stream
+		buffered := bufio.NewReader(body)
+		header, err := buffered.Peek(2)
+		if err == nil && isZlibHeader(header) {
+			reader, err := zlib.NewReader(buffered)
+			if err != nil {
+				return nil, err
+			}
+			return &decodedBody{Reader: reader, body: body}, nil
+		}
+		return &decodedBody{Reader: flate.NewReader(buffered), body: body}, nil
+	}
+	return nil, errors.Errorf("unsupported content encoding %q", encoding)
+}
+
+func isZlibHeader(header []byte) bool {
+	return header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0
+}
+
+type decodedBody struct {
+	io.Reader
+	body io.ReadCloser
+}
+
+func (d *decodedBody) Close() error {
+	if closer, ok := d.Reader.(io.Closer); ok {
+		_ = closer.Close()
+	}
+	return d.body.Close()
+}
+
+// limitedBody fails the read that goes past the limit instead of ending the body early
+type limitedBody struct {
+	body      io.ReadCloser
+	remaining int64
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, ErrTooLarge
+	}
+	// One byte more than the limit is read so a body of exactly the limit isn't an error
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.body.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n + int(l.remaining), ErrTooLarge
+	}
+	return n, err
+}
+
+func (l *limitedBody) Close() error {
+	return l.body.Close()
+}
@@ -0,0 +1,179 @@
+package clickhouseconsumer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type Config struct {
+	// URL is the address of the ClickHouse HTTP interface, e.g. http://clickhouse:8123
+	// The sink is disabled if this is empty
+	URL      string `envconfig:"CLICKHOUSE_URL"`
+	User     string `envconfig:"CLICKHOUSE_USER" default:"default"`
+	Password string `envconfig:"CLICKHOUSE_PASSWORD"`
+	Database string `envconfig:"CLICKHOUSE_DATABASE" default:"default"`
+	Table    string `envconfig:"CLICKHOUSE_TABLE" default:"statusphere_incidents"`
+	// Timeout bounds every request to ClickHouse so that a hung server can't stall the writes
+	Timeout time.Duration `envconfig:"CLICKHOUSE_TIMEOUT" default:"30s"`
+	// QueueSize is the number of scrape results that can be buffered before new ones are dropped
+	QueueSize int `envconfig:"CLICKHOUSE_QUEUE_SIZE" default:"100"`
+}
+
+func GetConfigFromEnvironment() (Config, error) {
+	var config Config
+	err := envconfig.Process("STATUSPHERE", &config)
+	return config, err
+}
+
+// ClickhouseConsumer mirrors incidents into ClickHouse for analytical queries
+// Postgres stays authoritative, so writes are asynchronous and best effort:
+// Consume never fails and incidents are dropped if ClickHouse can't keep up
+type ClickhouseConsumer struct {
+	logger     *zap.Logger
+	httpClient *http.Client
+	config     Config
+	queue      chan []api.Incident
+}
+
+func NewClickhouseConsumer(logger *zap.Logger, config Config) *ClickhouseConsumer {
+	return &ClickhouseConsumer{
+		logger:     logger,
+		httpClient: &http.Client{Timeout: config.Timeout},
+		config:     config,
+		queue:      make(chan []api.Incident, config.QueueSize),
+	}
+}
+
+// Start creates the table if it does not exist and starts the goroutine that writes to ClickHouse
+// If the table can't be created the goroutine isn't started, the consumer must then not be used
+func (c *ClickhouseConsumer) Start(ctx context.Context) error {
+	err := c.exec(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.%s (
+	deep_link String,
+	status_page_url String,
+	title String,
+	description String,
+	impact LowCardinality(String),
+	components Array(String),
+	start_time DateTime64(3, 'UTC'),
+	end_time Nullable(DateTime64(3, 'UTC')),
+	update_count UInt32,
+	scraped_at DateTime64(3, 'UTC')
+) ENGINE = ReplacingMergeTree(scraped_at)
+ORDER BY (status_page_url, deep_link)`, c.config.Database, c.config.Table), nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create clickhouse table")
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case incidents := <-c.queue:
+				err := c.insert(ctx, incidents)
+				if err != nil {
+					c.logger.Warn("failed to mirror incidents to clickhouse", zap.Error(err), zap.Int("incidents", len(incidents)))
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (c *ClickhouseConsumer) Consume(incidents []api.Incident) error {
+	if len(incidents) == 0 {
+		return nil
+	}
+	select {
+	case c.queue <- incidents:
+	default:
+		c.logger.Warn("clickhouse queue is full, dropping incidents", zap.Int("incidents", len(incidents)))
+	}
+	return nil
+}
+
+type clickhouseRow struct {
+	DeepLink      string   `json:"deep_link"`
+	StatusPageUrl string   `json:"status_page_url"`
+	Title         string   `json:"title"`
+	Description   string   `json:"description"`
+	Impact        string   `json:"impact"`
+	Components    []string `json:"components"`
+	StartTime     string   `json:"start_time"`
+	EndTime       *string  `json:"end_time"`
+	UpdateCount   int      `json:"update_count"`
+	ScrapedAt     string   `json:"scraped_at"`
+}
+
+const clickhouseTimeFormat = "2006-01-02 15:04:05.000"
+
+func (c *ClickhouseConsumer) insert(ctx context.Context, incidents []api.Incident) error {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	scrapedAt := time.Now().UTC().Format(clickhouseTimeFormat)
+	for _, incident := range incidents {
+		row := clickhouseRow{
+			DeepLink:      incident.DeepLink,
+			StatusPageUrl: incident.StatusPageUrl,
+			Title:         incident.Title,
+			Impact:        string(incident.Impact),
+			Components:    incident.Components,
+			StartTime:     incident.StartTime.UTC().Format(clickhouseTimeFormat),
+			UpdateCount:   len(incident.Events),
+			ScrapedAt:     scrapedAt,
+		}
+		if row.Components == nil {
+			row.Components = []string{}
+		}
+		if incident.Description != nil {
+			row.Description = *incident.Description
+		}
+		if incident.EndTime != nil {
+			endTime := incident.EndTime.UTC().Format(clickhouseTimeFormat)
+			row.EndTime = &endTime
+		}
+		err := encoder.Encode(row)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode row")
+		}
+	}
+	return c.exec(ctx, fmt.Sprintf("INSERT INTO %s.%s FORMAT JSONEachRow", c.config.Database, c.config.Table), &body)
+}
+
+// exec runs the query against the ClickHouse HTTP interface
+// If body is not nil the query is passed as a parameter and body is sent as the data
+func (c *ClickhouseConsumer) exec(ctx context.Context, query string, body io.Reader) error {
+	params := url.Values{}
+	var requestBody io.Reader = bytes.NewBufferString(query)
+	if body != nil {
+		params.Set("query", query)
+		requestBody = body
+	}
+	params.Set("database", c.config.Database)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.URL+"/?"+params.Encode(), requestBody)
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("X-ClickHouse-User", c.config.User)
+	req.Header.Set("X-ClickHouse-Key", c.config.Password)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to make request to clickhouse")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return errors.Errorf("clickhouse returned status %d: %s", resp.StatusCode, string(message))
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package consumers
+
+import (
+	"github.com/metoro-io/statusphere/common/api"
+	"time"
+)
+
+type Consumer interface {
+	// Consume consumes the given incidents
+	Consume(incidents []api.Incident) error
+}
+
+// ComponentConsumer is implemented by consumers that also consume the component lists of status pages
+type ComponentConsumer interface {
+	// ConsumeComponents consumes the full component list of the status page
+	ConsumeComponents(statusPageUrl string, components []api.Component) error
+}
+
+// StatusSnapshotConsumer is implemented by consumers that also consume the overall status of status pages
+type StatusSnapshotConsumer interface {
+	// ConsumeStatusSnapshot consumes the overall status of a status page, it is called after every scrape
+	ConsumeStatusSnapshot(snapshot api.StatusSnapshot) error
+}
+
+// CurrentIncidentsConsumer is implemented by consumers that resolve the incidents that vanish from status pages
+type CurrentIncidentsConsumer interface {
+	// ConsumeCurrentIncidents consumes every incident that a current scrape of the status page at scrapedAt found
+	// It is called after Consume, the stored incidents that are ongoing but weren't found have vanished
+	ConsumeCurrentIncidents(statusPageUrl string, incidents []api.Incident, scrapedAt time.Time) error
+}
+
+// BackfillConsumer is implemented by consumers that tell the incidents of a backfill apart, they are the archive of the
+// status page rather than changes to it so they mustn't be published as such
+type BackfillConsumer interface {
+	// ConsumeBackfill consumes incidents of the archive of a status page, it is called instead of Consume
+	ConsumeBackfill(incidents []api.Incident) error
+}
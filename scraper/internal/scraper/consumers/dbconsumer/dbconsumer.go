@@ -0,0 +1,115 @@
+package dbconsumer
+
+import (
+	"context"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/metrics"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+type DbConsumer struct {
+	logger   *zap.Logger
+	dbClient *db.DbClient
+}
+
+func NewDbConsumer(logger *zap.Logger, client *db.DbClient) *DbConsumer {
+	return &DbConsumer{
+		logger:   logger,
+		dbClient: client,
+	}
+}
+
+func (s *DbConsumer) Consume(incidents []api.Incident) error {
+	return s.consume(incidents, s.dbClient.CreateOrUpdateIncidents)
+}
+
+// ConsumeBackfill stores the incidents without publishing their changes, the archive of a status page would otherwise
+// notify every channel of years of incidents
+func (s *DbConsumer) ConsumeBackfill(incidents []api.Incident) error {
+	return s.consume(incidents, s.dbClient.CreateOrUpdateIncidentsWithoutPublishing)
+}
+
+func (s *DbConsumer) consume(incidents []api.Incident, upsert func(ctx context.Context, incidents []api.Incident) ([]api.ChangeEvent, error)) error {
+	// Scheduled maintenances are scraped as incidents but stored separately
+	var maintenances []api.Maintenance
+	var others []api.Incident
+	for _, incident := range incidents {
+		if incident.Impact == api.ImpactMaintenance {
+			maintenances = append(maintenances, api.MaintenanceFromIncident(incident))
+		} else {
+			others = append(others, incident)
+		}
+	}
+
+	changes, err := upsert(context.Background(), others)
+	if err != nil {
+		s.logger.Error("failed to create or update incidents", zap.Error(err))
+		return err
+	}
+	recordChanges(others, changes)
+	err = s.dbClient.CreateOrUpdateMaintenances(context.Background(), maintenances)
+	if err != nil {
+		s.logger.Error("failed to create or update maintenances", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// recordChanges counts the stored incidents that were changed by the provider that scraped them
+func recordChanges(incidents []api.Incident, changes []api.ChangeEvent) {
+	providerByDeepLink := make(map[string]string, len(incidents))
+	for _, incident := range incidents {
+		providerByDeepLink[incident.DeepLink] = incident.Provider
+	}
+	for _, change := range changes {
+		metrics.IncidentsChanged.WithLabelValues(metrics.ProviderLabel(providerByDeepLink[change.DeepLink]), strings.TrimPrefix(string(change.Type), "incident.")).Inc()
+	}
+}
+
+// ConsumeCurrentIncidents infers the end time of the ongoing incidents that are no longer on the status page
+// Status pages often drop an incident from their ongoing incidents without ever giving it an end time
+func (s *DbConsumer) ConsumeCurrentIncidents(statusPageUrl string, incidents []api.Incident, scrapedAt time.Time) error {
+	changes, err := s.dbClient.InferIncidentResolutions(context.Background(), statusPageUrl, incidents, scrapedAt)
+	if err != nil {
+		s.logger.Error("failed to infer incident resolutions", zap.Error(err), zap.String("url", statusPageUrl))
+		return err
+	}
+	for _, change := range changes {
+		s.logger.Info("inferred incident change", zap.String("type", string(change.Type)), zap.String("deepLink", change.DeepLink))
+	}
+	return nil
+}
+
+func (s *DbConsumer) ConsumeComponents(statusPageUrl string, components []api.Component) error {
+	err := s.dbClient.ReplaceComponents(context.Background(), statusPageUrl, components)
+	if err != nil {
+		s.logger.Error("failed to replace components", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// statusSnapshotHeartbeat is how often an unchanged status is snapshotted again
+// It keeps the gaps in scraping visible, so they aren't mistaken for the status staying the same
+const statusSnapshotHeartbeat = time.Hour
+
+// ConsumeStatusSnapshot stores the snapshot if the status has changed since the last snapshot, or if the heartbeat is due
+func (s *DbConsumer) ConsumeStatusSnapshot(snapshot api.StatusSnapshot) error {
+	latest, err := s.dbClient.GetStatusSnapshotAt(context.Background(), snapshot.StatusPageUrl, snapshot.Time)
+	if err != nil {
+		s.logger.Error("failed to get the latest status snapshot", zap.Error(err))
+		return err
+	}
+	if latest != nil && latest.SameStatus(snapshot) && snapshot.Time.Sub(latest.Time) < statusSnapshotHeartbeat {
+		return nil
+	}
+	err = s.dbClient.InsertStatusSnapshot(context.Background(), snapshot)
+	if err != nil {
+		s.logger.Error("failed to insert status snapshot", zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package contenthash
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// Recorder collects the bodies fetched during a scrape so it can tell whether the status page changed since the last one
+type Recorder struct {
+	mu sync.Mutex
+	// bodies holds the hash of the last body fetched from each url, so the order of the requests doesn't matter
+	bodies map[string][sha256.Size]byte
+	seed   string
+}
+
+type recorderKey struct{}
+
+// WithRecorder returns a context whose requests are recorded by the returned recorder
+// The seed is mixed into the hash, e.g. the timezone the status page is parsed in, so changing it counts as a change
+func WithRecorder(ctx context.Context, seed string) (context.Context, *Recorder) {
+	recorder := &Recorder{bodies: map[string][sha256.Size]byte{}, seed: seed}
+	return context.WithValue(ctx, recorderKey{}, recorder), recorder
+}
+
+func fromContext(ctx context.Context) *Recorder {
+	recorder, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return recorder
+}
+
+func (r *Recorder) record(url string, body []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bodies[url] = sha256.Sum256(body)
+}
+
+// Sum returns the hash of everything recorded, or an empty string if nothing was
+func (r *Recorder) Sum() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.bodies) == 0 {
+		return ""
+	}
+	urls := make([]string, 0, len(r.bodies))
+	for url := range r.bodies {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+	h := sha256.New()
+	h.Write([]byte(r.seed))
+	for _, url := range urls {
+		body := r.bodies[url]
+		h.Write([]byte(url))
+		h.Write(body[:])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Transport is an http.RoundTripper that records the successful responses of the requests made with a recorder
+// in their context. The body is read in full and handed on unchanged, requests without a recorder are passed through
+type Transport struct {
+	base http.RoundTripper
+}
+
+func NewTransport(base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{base: base}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	recorder := fromContext(req.Context())
+	if recorder == nil || resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	recorder.record(req.URL.String(), body)
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
@@ -0,0 +1,145 @@
+package fixtures
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	// KindHistorical fixtures are replayed through ScrapeStatusPageHistorical
+	// They are preferred as current scrapes filter the incidents relative to the time they run at
+	KindHistorical = "historical"
+	KindCurrent    = "current"
+)
+
+// Fixture is a scrape of a status page recorded so it can be replayed through the parsers
+// Incidents are the incidents the scrape is expected to return, a parse bug is locked in by correcting them
+type Fixture struct {
+	Provider string `json:"provider"`
+	URL      string `json:"url"`
+	Kind     string `json:"kind"`
+	// Timezone is the timezone the status page prints local times in, see api.StatusPage
+	Timezone   string         `json:"timezone,omitempty"`
+	RecordedAt time.Time      `json:"recordedAt"`
+	Responses  []Response     `json:"responses"`
+	Incidents  []api.Incident `json:"incidents"`
+}
+
+// Response is a recorded response, only the headers the parsers look at are kept
+type Response struct {
+	Method      string `json:"method"`
+	URL         string `json:"url"`
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        string `json:"body"`
+}
+
+func Load(path string) (Fixture, error) {
+	var fixture Fixture
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fixture, errors.Wrap(err, "failed to read fixture")
+	}
+	err = json.Unmarshal(data, &fixture)
+	if err != nil {
+		return fixture, errors.Wrapf(err, "failed to parse fixture %s", path)
+	}
+	return fixture, nil
+}
+
+func Save(path string, fixture Fixture) error {
+	sortIncidents(fixture.Incidents)
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal fixture")
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return errors.Wrap(err, "failed to create fixture directory")
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Replay scrapes the status page of the fixture with its provider, the requests are answered with the recorded responses
+func Replay(ctx context.Context, logger *zap.Logger, fixture Fixture) ([]api.Incident, error) {
+	client := &http.Client{Transport: NewReplayTransport(fixture.Responses)}
+	var provider providers.Provider
+	for _, registered := range providers.NewRegisteredProviders(logger, client) {
+		if registered.Name() == fixture.Provider {
+			provider = registered
+		}
+	}
+	if provider == nil {
+		return nil, errors.Errorf("provider %s is not registered", fixture.Provider)
+	}
+
+	loc := time.UTC
+	if fixture.Timezone != "" {
+		var err error
+		loc, err = time.LoadLocation(fixture.Timezone)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load the fixture timezone")
+		}
+	}
+	ctx = providers.WithLocation(ctx, loc)
+	// The requests of providers that look back from the current time are the recorded ones
+	ctx = providers.WithNow(ctx, fixture.RecordedAt)
+
+	s := scraper.NewScraper(logger, client, []providers.Provider{provider})
+	var incidents []api.Incident
+	var err error
+	switch fixture.Kind {
+	case KindHistorical:
+		incidents, err = s.ScrapeStatusPageHistorical(ctx, fixture.URL)
+	case KindCurrent:
+		incidents, err = s.ScrapeStatusPageCurrent(ctx, fixture.URL)
+	default:
+		return nil, errors.Errorf("unknown fixture kind %q", fixture.Kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sortIncidents(incidents)
+	return incidents, nil
+}
+
+// Canonical returns the incidents as they are stored in a fixture, so that a replay can be compared with the expected incidents
+func Canonical(incidents []api.Incident) (string, error) {
+	sorted := append([]api.Incident(nil), incidents...)
+	sortIncidents(sorted)
+	data, err := json.MarshalIndent(sorted, "", "  ")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal incidents")
+	}
+	// Round trip so times and empty values are in the same form as the ones read from a fixture
+	var roundTripped []api.Incident
+	err = json.Unmarshal(data, &roundTripped)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to unmarshal incidents")
+	}
+	data, err = json.MarshalIndent(roundTripped, "", "  ")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal incidents")
+	}
+	return string(data), nil
+}
+
+// sortIncidents orders the incidents so that providers that collect them in a map still replay in a stable order
+func sortIncidents(incidents []api.Incident) {
+	sort.SliceStable(incidents, func(i, j int) bool {
+		if incidents[i].DeepLink != incidents[j].DeepLink {
+			return incidents[i].DeepLink < incidents[j].DeepLink
+		}
+		return incidents[i].StartTime.Before(incidents[j].StartTime)
+	})
+}
@@ -0,0 +1,87 @@
+package fixtures
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	_ "github.com/metoro-io/statusphere/scraper/internal/scraper/providers/atlassian"
+	_ "github.com/metoro-io/statusphere/scraper/internal/scraper/providers/aws"
+	_ "github.com/metoro-io/statusphere/scraper/internal/scraper/providers/azure"
+	_ "github.com/metoro-io/statusphere/scraper/internal/scraper/providers/betterstack"
+	_ "github.com/metoro-io/statusphere/scraper/internal/scraper/providers/cachet"
+	_ "github.com/metoro-io/statusphere/scraper/internal/scraper/providers/feed"
+	_ "github.com/metoro-io/statusphere/scraper/internal/scraper/providers/google"
+	_ "github.com/metoro-io/statusphere/scraper/internal/scraper/providers/instatus"
+	_ "github.com/metoro-io/statusphere/scraper/internal/scraper/providers/salesforce"
+	_ "github.com/metoro-io/statusphere/scraper/internal/scraper/providers/statusio"
+	_ "github.com/metoro-io/statusphere/scraper/internal/scraper/providers/statuspal"
+	"go.uber.org/zap"
+)
+
+var update = flag.Bool("update", false, "rewrite the expected incidents of the fixtures with the incidents the parsers return")
+
+// TestFixtures replays every recorded fixture through its provider and checks that it returns the expected incidents
+// Record a fixture with `scraper record-fixture -url X`
+func TestFixtures(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "*", "*.json"))
+	if err != nil {
+		t.Fatalf("failed to list fixtures: %v", err)
+	}
+	for _, path := range paths {
+		path := path
+		t.Run(path, func(t *testing.T) {
+			fixture, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			incidents, err := Replay(context.Background(), zap.NewNop(), fixture)
+			if err != nil {
+				t.Fatalf("failed to replay fixture: %v", err)
+			}
+			if *update {
+				fixture.Incidents = incidents
+				err = Save(path, fixture)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			expected, err := Canonical(fixture.Incidents)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, err := Canonical(incidents)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected != actual {
+				t.Errorf("replayed incidents differ from the expected ones\nexpected: %s\nactual: %s", expected, actual)
+			}
+		})
+	}
+}
+
+// TestEveryProviderHasFixture checks that every provider imported above is covered by at least one fixture
+func TestEveryProviderHasFixture(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "*", "*.json"))
+	if err != nil {
+		t.Fatalf("failed to list fixtures: %v", err)
+	}
+	covered := map[string]bool{}
+	for _, path := range paths {
+		fixture, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		covered[fixture.Provider] = true
+	}
+	for _, provider := range providers.NewRegisteredProviders(zap.NewNop(), http.DefaultClient) {
+		if !covered[provider.Name()] {
+			t.Errorf("provider %s has no fixture", provider.Name())
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package fixtures
+
+import (
+	"context"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+// Record scrapes the status page with the registered providers and returns a fixture of the scrape
+// The expected incidents are the ones the sanitized responses replay to, they have to be checked by hand before
+// the fixture is committed, and corrected if the fixture is recorded to reproduce a parse bug
+func Record(ctx context.Context, logger *zap.Logger, base http.RoundTripper, url string, kind string, timezone string) (Fixture, error) {
+	loc := time.UTC
+	if timezone != "" {
+		var err error
+		loc, err = time.LoadLocation(timezone)
+		if err != nil {
+			return Fixture{}, errors.Wrap(err, "failed to load the timezone")
+		}
+	}
+	recorder := NewRecordingTransport(base)
+	client := &http.Client{Transport: recorder}
+	s := scraper.NewScraper(logger, client, providers.NewRegisteredProviders(logger, client))
+	// The scrape runs at the recorded time so that a replay makes the same requests
+	recordedAt := time.Now().UTC().Truncate(time.Second)
+	scrapeCtx := providers.WithNow(providers.WithLocation(ctx, loc), recordedAt)
+	var err error
+	switch kind {
+	case KindHistorical:
+		_, err = s.ScrapeStatusPageHistorical(scrapeCtx, url)
+	case KindCurrent:
+		_, err = s.ScrapeStatusPageCurrent(scrapeCtx, url)
+	default:
+		return Fixture{}, errors.Errorf("unknown fixture kind %q", kind)
+	}
+	if err != nil {
+		return Fixture{}, errors.Wrap(err, "failed to scrape the status page")
+	}
+
+	provider := recorder.Provider()
+	fixture := Fixture{
+		Provider:   provider,
+		URL:        url,
+		Kind:       kind,
+		Timezone:   timezone,
+		RecordedAt: recordedAt,
+		Responses:  recorder.Responses(provider),
+	}
+	fixture.Incidents, err = Replay(ctx, logger, fixture)
+	if err != nil {
+		return Fixture{}, errors.Wrap(err, "failed to replay the recorded responses")
+	}
+	return fixture, nil
+}
@@ -0,0 +1,135 @@
+package fixtures
+
+import (
+	"bytes"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RecordingTransport is an http.RoundTripper that records the responses to the requests it makes
+type RecordingTransport struct {
+	base      http.RoundTripper
+	mu        sync.Mutex
+	responses []recordedResponse
+}
+
+type recordedResponse struct {
+	provider string
+	response Response
+}
+
+func NewRecordingTransport(base http.RoundTripper) *RecordingTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &RecordingTransport{base: base}
+}
+
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.responses = append(t.responses, recordedResponse{
+		provider: providers.NameFromContext(req.Context()),
+		response: Response{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			Status:      resp.StatusCode,
+			ContentType: resp.Header.Get("Content-Type"),
+			Body:        string(body),
+		},
+	})
+	return resp, nil
+}
+
+// Provider returns the provider that made the last request, which is the provider that scraped the status page
+// as the providers that were tried before it only make requests to check whether they match
+func (t *RecordingTransport) Provider() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.responses) == 0 {
+		return ""
+	}
+	return t.responses[len(t.responses)-1].provider
+}
+
+// Responses returns the sanitized responses to the requests made by the provider
+func (t *RecordingTransport) Responses(provider string) []Response {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var responses []Response
+	for _, recorded := range t.responses {
+		if recorded.provider != provider {
+			continue
+		}
+		response := recorded.response
+		response.Body = Sanitize(response.Body)
+		responses = append(responses, response)
+	}
+	return responses
+}
+
+var (
+	emailRegex = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// csrfRegex matches the csrf tokens that pages embed in meta tags and forms
+	csrfRegex = regexp.MustCompile(`((?:name="csrf-token"|name="authenticity_token")[^>]*?(?:content|value)=")[^"]*(")`)
+)
+
+// Sanitize removes the email addresses and csrf tokens from a recorded body so a fixture can be committed
+func Sanitize(body string) string {
+	body = emailRegex.ReplaceAllString(body, "redacted@example.com")
+	return csrfRegex.ReplaceAllString(body, "${1}redacted${2}")
+}
+
+// ReplayTransport is an http.RoundTripper that answers requests with recorded responses
+// A request that wasn't recorded fails, so a parser change that makes new requests shows up in the fixtures
+type ReplayTransport struct {
+	responses map[string]Response
+}
+
+func NewReplayTransport(responses []Response) *ReplayTransport {
+	byRequest := map[string]Response{}
+	for _, response := range responses {
+		byRequest[response.Method+" "+response.URL] = response
+	}
+	return &ReplayTransport{responses: byRequest}
+}
+
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	response, found := t.responses[req.Method+" "+req.URL.String()]
+	if !found {
+		return nil, errors.Errorf("no recorded response for %s %s", req.Method, req.URL.String())
+	}
+	header := http.Header{}
+	if response.ContentType != "" {
+		header.Set("Content-Type", response.ContentType)
+	}
+	header.Set("Content-Length", strconv.Itoa(len(response.Body)))
+	return &http.Response{
+		Status:        strconv.Itoa(response.Status) + " " + http.StatusText(response.Status),
+		StatusCode:    response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(response.Body)),
+		ContentLength: int64(len(response.Body)),
+		Request:       req,
+	}, nil
+}
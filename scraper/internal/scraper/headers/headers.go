@@ -0,0 +1,83 @@
+package headers
+
+import (
+	"github.com/kelseyhightower/envconfig"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"net/http"
+)
+
+type Config struct {
+	// UserAgent is sent with every request, the Go default is used if empty
+	UserAgent string `envconfig:"SCRAPER_USER_AGENT"`
+	// Headers are sent with every request, e.g. Accept-Language:en-US
+	Headers map[string]string `envconfig:"SCRAPER_HEADERS"`
+}
+
+func GetConfigFromEnvironment() (Config, error) {
+	var config Config
+	err := envconfig.Process("STATUSPHERE", &config)
+	return config, err
+}
+
+// Transport is an http.RoundTripper that adds the header profile of the provider making the request
+// The headers of the provider's profile are added on top of the default headers, and the headers of the status page's
+// scrape config on top of both. None of them overrides a header that the provider set on the request itself,
+// e.g. the Accept of a JSON API
+type Transport struct {
+	base     http.RoundTripper
+	defaults map[string]string
+	profiles map[string]map[string]string
+	// pageHeaders returns the headers of the scrape config of the status page that the url is on
+	pageHeaders func(url string) map[string]string
+}
+
+func NewTransport(base http.RoundTripper, config Config, profiles []api.HeaderProfile, pageHeaders func(url string) map[string]string) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	defaults := map[string]string{}
+	for name, value := range config.Headers {
+		defaults[name] = value
+	}
+	if config.UserAgent != "" {
+		defaults["User-Agent"] = config.UserAgent
+	}
+	byProvider := map[string]map[string]string{}
+	for _, profile := range profiles {
+		byProvider[profile.Provider] = profile.Headers
+	}
+	return &Transport{
+		base:        base,
+		defaults:    defaults,
+		profiles:    byProvider,
+		pageHeaders: pageHeaders,
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	headers := map[string]string{}
+	for name, value := range t.defaults {
+		headers[http.CanonicalHeaderKey(name)] = value
+	}
+	for name, value := range t.profiles[providers.NameFromContext(req.Context())] {
+		headers[http.CanonicalHeaderKey(name)] = value
+	}
+	if t.pageHeaders != nil {
+		for name, value := range t.pageHeaders(req.URL.String()) {
+			headers[http.CanonicalHeaderKey(name)] = value
+		}
+	}
+	if len(headers) == 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	// A RoundTripper must not modify the request it is given
+	req = req.Clone(req.Context())
+	for name, value := range headers {
+		if req.Header.Get(name) == "" {
+			req.Header.Set(name, value)
+		}
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,139 @@
+package knowledgebase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	"html"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ConfluenceWriter appends incidents to a single Confluence page using the Confluence REST API
+type ConfluenceWriter struct {
+	httpClient *http.Client
+	baseURL    string
+	user       string
+	apiToken   string
+	pageID     string
+}
+
+func NewConfluenceWriter(config Config) *ConfluenceWriter {
+	return &ConfluenceWriter{
+		httpClient: http.DefaultClient,
+		baseURL:    strings.TrimSuffix(config.ConfluenceBaseURL, "/"),
+		user:       config.ConfluenceUser,
+		apiToken:   config.ConfluenceAPIToken,
+		pageID:     config.ConfluencePageID,
+	}
+}
+
+func (c *ConfluenceWriter) Name() string {
+	return "confluence"
+}
+
+type confluencePage struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Title   string `json:"title"`
+	Version struct {
+		Number int `json:"number"`
+	} `json:"version"`
+	Body struct {
+		Storage struct {
+			Value          string `json:"value"`
+			Representation string `json:"representation"`
+		} `json:"storage"`
+	} `json:"body"`
+}
+
+func (c *ConfluenceWriter) HasIncident(ctx context.Context, incident api.Incident) (bool, error) {
+	page, err := c.getPage(ctx)
+	if err != nil {
+		return false, err
+	}
+	return strings.Contains(page.Body.Storage.Value, html.EscapeString(incident.DeepLink)), nil
+}
+
+func (c *ConfluenceWriter) WriteIncident(ctx context.Context, incident api.Incident) error {
+	page, err := c.getPage(ctx)
+	if err != nil {
+		return err
+	}
+
+	update := map[string]interface{}{
+		"id":      page.ID,
+		"type":    page.Type,
+		"title":   page.Title,
+		"version": map[string]int{"number": page.Version.Number + 1},
+		"body": map[string]interface{}{
+			"storage": map[string]string{
+				"value":          page.Body.Storage.Value + confluenceIncidentSection(incident),
+				"representation": "storage",
+			},
+		},
+	}
+	body, err := json.Marshal(update)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal page update")
+	}
+	_, err = c.do(ctx, http.MethodPut, "/rest/api/content/"+c.pageID, body)
+	return err
+}
+
+func (c *ConfluenceWriter) getPage(ctx context.Context) (*confluencePage, error) {
+	response, err := c.do(ctx, http.MethodGet, "/rest/api/content/"+c.pageID+"?expand=body.storage,version", nil)
+	if err != nil {
+		return nil, err
+	}
+	var page confluencePage
+	err = json.Unmarshal(response, &page)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal confluence page")
+	}
+	return &page, nil
+}
+
+func (c *ConfluenceWriter) do(ctx context.Context, method string, path string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	req.SetBasicAuth(c.user, c.apiToken)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to make request to confluence")
+	}
+	defer resp.Body.Close()
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read confluence response")
+	}
+	if resp.StatusCode >= 300 {
+		return nil, errors.Errorf("confluence returned status %d: %s", resp.StatusCode, string(responseBody))
+	}
+	return responseBody, nil
+}
+
+// confluenceIncidentSection renders the incident in the Confluence storage format
+func confluenceIncidentSection(incident api.Incident) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("<h2>%s</h2>", html.EscapeString(incident.Title)))
+	sb.WriteString(fmt.Sprintf("<p>%s</p>", html.EscapeString(summary(incident))))
+	if len(incident.Events) > 0 {
+		sb.WriteString("<ul>")
+		for _, update := range incident.Events {
+			sb.WriteString(fmt.Sprintf("<li><strong>%s</strong> %s: %s</li>", html.EscapeString(update.Time.UTC().Format(time.RFC3339)), html.EscapeString(string(update.State)), html.EscapeString(update.Body)))
+		}
+		sb.WriteString("</ul>")
+	}
+	sb.WriteString(fmt.Sprintf(`<p><a href="%s">%s</a></p>`, html.EscapeString(incident.DeepLink), html.EscapeString(incident.DeepLink)))
+	return sb.String()
+}
@@ -0,0 +1,108 @@
+package knowledgebase
+
+import (
+	"context"
+	"fmt"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"time"
+)
+
+type Config struct {
+	// MinImpact is the least severe impact of resolved incidents that are written to the knowledge base
+	MinImpact string `envconfig:"KNOWLEDGE_BASE_MIN_IMPACT" default:"major"`
+
+	// Confluence is enabled if ConfluenceBaseURL is set, incidents are appended to the page with ConfluencePageID
+	ConfluenceBaseURL  string `envconfig:"CONFLUENCE_BASE_URL"`
+	ConfluenceUser     string `envconfig:"CONFLUENCE_USER"`
+	ConfluenceAPIToken string `envconfig:"CONFLUENCE_API_TOKEN"`
+	ConfluencePageID   string `envconfig:"CONFLUENCE_PAGE_ID"`
+
+	// Notion is enabled if NotionToken is set, incidents are added as pages of the database with NotionDatabaseID
+	NotionToken      string `envconfig:"NOTION_TOKEN"`
+	NotionDatabaseID string `envconfig:"NOTION_DATABASE_ID"`
+}
+
+func GetConfigFromEnvironment() (Config, error) {
+	var config Config
+	err := envconfig.Process("STATUSPHERE", &config)
+	return config, err
+}
+
+// Writer writes incidents to a knowledge base
+type Writer interface {
+	Name() string
+	// HasIncident returns true if the incident has already been written, writes have to be idempotent
+	// as the outbox redelivers events when publishing fails
+	HasIncident(ctx context.Context, incident api.Incident) (bool, error)
+	WriteIncident(ctx context.Context, incident api.Incident) error
+}
+
+// KnowledgeBasePublisher is an outbox publisher that records resolved major incidents in a knowledge base
+// building a reference of vendor outages for postmortems
+type KnowledgeBasePublisher struct {
+	logger    *zap.Logger
+	writer    Writer
+	minImpact api.Impact
+}
+
+func NewKnowledgeBasePublisher(logger *zap.Logger, writer Writer, minImpact api.Impact) *KnowledgeBasePublisher {
+	return &KnowledgeBasePublisher{
+		logger:    logger,
+		writer:    writer,
+		minImpact: minImpact,
+	}
+}
+
+// NewPublishersFromConfig returns a publisher for every knowledge base that is configured
+func NewPublishersFromConfig(logger *zap.Logger, config Config) ([]*KnowledgeBasePublisher, error) {
+	minImpact := api.Impact(config.MinImpact)
+	if minImpact.Severity() == -1 {
+		return nil, errors.Errorf("unknown knowledge base min impact %q", config.MinImpact)
+	}
+	var publishers []*KnowledgeBasePublisher
+	if config.ConfluenceBaseURL != "" {
+		publishers = append(publishers, NewKnowledgeBasePublisher(logger, NewConfluenceWriter(config), minImpact))
+	}
+	if config.NotionToken != "" {
+		publishers = append(publishers, NewKnowledgeBasePublisher(logger, NewNotionWriter(config), minImpact))
+	}
+	return publishers, nil
+}
+
+func (p *KnowledgeBasePublisher) Name() string {
+	return "knowledge base " + p.writer.Name()
+}
+
+func (p *KnowledgeBasePublisher) Publish(ctx context.Context, events []api.ChangeEvent) error {
+	for _, event := range events {
+		incident := api.Incident(event.Incident)
+		if event.Type != api.ChangeEventIncidentResolved || incident.Impact.Severity() < p.minImpact.Severity() {
+			continue
+		}
+		exists, err := p.writer.HasIncident(ctx, incident)
+		if err != nil {
+			return errors.Wrap(err, "failed to check if the incident is already in the knowledge base")
+		}
+		if exists {
+			continue
+		}
+		err = p.writer.WriteIncident(ctx, incident)
+		if err != nil {
+			return errors.Wrap(err, "failed to write the incident to the knowledge base")
+		}
+		p.logger.Info("wrote incident to knowledge base", zap.String("knowledgeBase", p.writer.Name()), zap.String("deepLink", incident.DeepLink))
+	}
+	return nil
+}
+
+// summary returns a one line summary of the incident
+func summary(incident api.Incident) string {
+	duration := "ongoing"
+	if incident.EndTime != nil {
+		duration = incident.EndTime.Sub(incident.StartTime).Round(time.Minute).String()
+	}
+	return fmt.Sprintf("%s impact on %s, started %s, lasted %s", incident.Impact, incident.StatusPageUrl, incident.StartTime.UTC().Format(time.RFC1123), duration)
+}
@@ -0,0 +1,129 @@
+package knowledgebase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+const notionBaseURL = "https://api.notion.com/v1"
+const notionVersion = "2022-06-28"
+
+// notionMaxTextLength is the maximum length of a single rich text object
+const notionMaxTextLength = 2000
+
+// NotionWriter adds incidents as pages of a Notion database
+// The database needs a title property called "Name", a url property called "Link",
+// a select property called "Impact" and a date property called "Date"
+type NotionWriter struct {
+	httpClient *http.Client
+	token      string
+	databaseID string
+}
+
+func NewNotionWriter(config Config) *NotionWriter {
+	return &NotionWriter{
+		httpClient: http.DefaultClient,
+		token:      config.NotionToken,
+		databaseID: config.NotionDatabaseID,
+	}
+}
+
+func (n *NotionWriter) Name() string {
+	return "notion"
+}
+
+func (n *NotionWriter) HasIncident(ctx context.Context, incident api.Incident) (bool, error) {
+	query := map[string]interface{}{
+		"filter": map[string]interface{}{
+			"property": "Link",
+			"url":      map[string]string{"equals": incident.DeepLink},
+		},
+		"page_size": 1,
+	}
+	response, err := n.do(ctx, http.MethodPost, "/databases/"+n.databaseID+"/query", query)
+	if err != nil {
+		return false, err
+	}
+	var result struct {
+		Results []json.RawMessage `json:"results"`
+	}
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to unmarshal notion query response")
+	}
+	return len(result.Results) > 0, nil
+}
+
+func (n *NotionWriter) WriteIncident(ctx context.Context, incident api.Incident) error {
+	date := map[string]string{"start": incident.StartTime.UTC().Format(time.RFC3339)}
+	if incident.EndTime != nil {
+		date["end"] = incident.EndTime.UTC().Format(time.RFC3339)
+	}
+	children := []interface{}{
+		notionBlock("paragraph", summary(incident)),
+	}
+	for _, update := range incident.Events {
+		children = append(children, notionBlock("bulleted_list_item", fmt.Sprintf("%s %s: %s", update.Time.UTC().Format(time.RFC3339), update.State, update.Body)))
+	}
+	page := map[string]interface{}{
+		"parent": map[string]string{"database_id": n.databaseID},
+		"properties": map[string]interface{}{
+			"Name":   map[string]interface{}{"title": notionText(incident.Title)},
+			"Link":   map[string]interface{}{"url": incident.DeepLink},
+			"Impact": map[string]interface{}{"select": map[string]string{"name": string(incident.Impact)}},
+			"Date":   map[string]interface{}{"date": date},
+		},
+		"children": children,
+	}
+	_, err := n.do(ctx, http.MethodPost, "/pages", page)
+	return err
+}
+
+func notionText(text string) []interface{} {
+	if len(text) > notionMaxTextLength {
+		text = text[:notionMaxTextLength]
+	}
+	return []interface{}{map[string]interface{}{"type": "text", "text": map[string]string{"content": text}}}
+}
+
+func notionBlock(blockType string, text string) map[string]interface{} {
+	return map[string]interface{}{
+		"object":  "block",
+		"type":    blockType,
+		blockType: map[string]interface{}{"rich_text": notionText(text)},
+	}
+}
+
+func (n *NotionWriter) do(ctx context.Context, method string, path string, body interface{}) ([]byte, error) {
+	requestBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal notion request")
+	}
+	req, err := http.NewRequestWithContext(ctx, method, notionBaseURL+path, bytes.NewReader(requestBody))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Authorization", "Bearer "+n.token)
+	req.Header.Set("Notion-Version", notionVersion)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to make request to notion")
+	}
+	defer resp.Body.Close()
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read notion response")
+	}
+	if resp.StatusCode >= 300 {
+		return nil, errors.Errorf("notion returned status %d: %s", resp.StatusCode, string(responseBody))
+	}
+	return responseBody, nil
+}
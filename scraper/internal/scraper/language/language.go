@@ -0,0 +1,107 @@
+package language
+
+import (
+	"strings"
+	"unicode"
+)
+
+// minStopwords is how many stopwords of a latin script language a text needs before it is detected as that language
+const minStopwords = 2
+
+// stopwords are frequent words of each latin script language, used to tell them apart
+// Words that are common to several of the languages, e.g. "in", are left out
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "we", "have", "has", "been", "this", "of", "to", "with", "for", "some", "users", "investigating", "resolved", "issue"},
+	"de": {"der", "die", "das", "und", "ist", "wir", "haben", "wurde", "mit", "für", "nicht", "eine", "einige", "störung", "behoben", "aktuell"},
+	"fr": {"le", "la", "les", "et", "est", "nous", "avons", "des", "une", "pour", "avec", "sur", "certains", "panne", "résolu", "problème"},
+	"es": {"el", "los", "las", "y", "es", "hemos", "una", "para", "con", "algunos", "usuarios", "problema", "resuelto", "está"},
+	"pt": {"o", "os", "as", "e", "é", "estamos", "uma", "para", "com", "alguns", "usuários", "problema", "resolvido", "não"},
+	"it": {"il", "gli", "e", "è", "abbiamo", "una", "per", "con", "alcuni", "utenti", "problema", "risolto", "non", "della"},
+	"nl": {"de", "het", "en", "is", "wij", "we", "hebben", "een", "voor", "met", "sommige", "gebruikers", "storing", "opgelost", "niet"},
+}
+
+// Detect returns the ISO 639-1 code of the language the text is written in, or an empty string if it can't be told
+// Languages with their own script are detected from the script, latin script languages from their stopwords
+func Detect(text string) string {
+	if language := detectScript(text); language != "" {
+		return language
+	}
+	return detectStopwords(text)
+}
+
+// detectScript returns the language of the script that most of the letters of the text are in, if it isn't latin
+func detectScript(text string) string {
+	counts := map[string]int{}
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			counts["ja"]++
+		case unicode.Is(unicode.Han, r):
+			counts["han"]++
+		case unicode.Is(unicode.Hangul, r):
+			counts["ko"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			counts["ru"]++
+		case unicode.Is(unicode.Greek, r):
+			counts["el"]++
+		case unicode.Is(unicode.Arabic, r):
+			counts["ar"]++
+		case unicode.Is(unicode.Hebrew, r):
+			counts["he"]++
+		case unicode.Is(unicode.Thai, r):
+			counts["th"]++
+		}
+	}
+	// Japanese mixes kana with kanji, Chinese has no kana
+	if counts["ja"] > 0 {
+		counts["ja"] += counts["han"]
+	} else {
+		counts["zh"] = counts["han"]
+	}
+	delete(counts, "han")
+
+	best, bestCount := "", 0
+	for language, count := range counts {
+		if count > bestCount {
+			best, bestCount = language, count
+		}
+	}
+	if bestCount*2 <= letters {
+		return ""
+	}
+	return best
+}
+
+// detectStopwords returns the latin script language with the most stopwords in the text
+// If no language has enough, or two are tied, the language is unknown
+func detectStopwords(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	best, bestCount, tied := "", 0, false
+	for language, languageStopwords := range stopwords {
+		count := 0
+		for _, word := range words {
+			for _, stopword := range languageStopwords {
+				if word == stopword {
+					count++
+					break
+				}
+			}
+		}
+		if count > bestCount {
+			best, bestCount, tied = language, count, false
+		} else if count == bestCount {
+			tied = true
+		}
+	}
+	if bestCount < minStopwords || tied {
+		return ""
+	}
+	return best
+}
@@ -0,0 +1,72 @@
+package language
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// LibreTranslateTranslator translates text with a LibreTranslate server, see https://libretranslate.com/docs
+type LibreTranslateTranslator struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+}
+
+func NewLibreTranslateTranslator(config Config) *LibreTranslateTranslator {
+	return &LibreTranslateTranslator{
+		httpClient: http.DefaultClient,
+		baseURL:    strings.TrimSuffix(config.LibreTranslateURL, "/"),
+		apiKey:     config.LibreTranslateAPIKey,
+	}
+}
+
+func (l *LibreTranslateTranslator) Name() string {
+	return "libretranslate"
+}
+
+type libreTranslateRequest struct {
+	Q      string `json:"q"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Format string `json:"format"`
+	APIKey string `json:"api_key,omitempty"`
+}
+
+type libreTranslateResponse struct {
+	TranslatedText string `json:"translatedText"`
+}
+
+func (l *LibreTranslateTranslator) Translate(ctx context.Context, text string, source string, target string) (string, error) {
+	requestBody, err := json.Marshal(libreTranslateRequest{Q: text, Source: source, Target: target, Format: "text", APIKey: l.apiKey})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal libretranslate request")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.baseURL+"/translate", bytes.NewReader(requestBody))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := l.httpClient.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to make request to libretranslate")
+	}
+	defer resp.Body.Close()
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read libretranslate response")
+	}
+	if resp.StatusCode >= 300 {
+		return "", errors.Errorf("libretranslate returned status %d: %s", resp.StatusCode, string(responseBody))
+	}
+	var response libreTranslateResponse
+	err = json.Unmarshal(responseBody, &response)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to unmarshal libretranslate response")
+	}
+	return response.TranslatedText, nil
+}
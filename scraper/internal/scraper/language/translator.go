@@ -0,0 +1,123 @@
+package language
+
+import (
+	"context"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/patrickmn/go-cache"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+type Config struct {
+	// TranslateTo is the language that the titles and descriptions of incidents are translated to
+	TranslateTo string `envconfig:"TRANSLATE_TO" default:"en"`
+
+	// LibreTranslate is enabled if LibreTranslateURL is set, e.g. https://libretranslate.com
+	LibreTranslateURL    string `envconfig:"LIBRETRANSLATE_URL"`
+	LibreTranslateAPIKey string `envconfig:"LIBRETRANSLATE_API_KEY"`
+}
+
+func GetConfigFromEnvironment() (Config, error) {
+	var config Config
+	err := envconfig.Process("STATUSPHERE", &config)
+	return config, err
+}
+
+// Translator translates text between languages, languages are ISO 639-1 codes
+type Translator interface {
+	Name() string
+	Translate(ctx context.Context, text string, source string, target string) (string, error)
+}
+
+// NewTranslatorFromConfig returns the translator that is configured, or nil if there is none
+func NewTranslatorFromConfig(config Config) Translator {
+	if config.LibreTranslateURL != "" {
+		return NewLibreTranslateTranslator(config)
+	}
+	return nil
+}
+
+// translationExpiry is how long a translation is reused for, incidents are scraped again every few minutes
+// so without it the same text would be translated over and over
+const translationExpiry = 24 * time.Hour
+
+// Annotator detects the language of scraped incidents and translates the ones that aren't in the target language
+type Annotator struct {
+	logger     *zap.Logger
+	translator Translator
+	target     string
+	// translations caches the translations by source language and text
+	translations *cache.Cache
+}
+
+// NewAnnotator returns an annotator that only detects languages if translator is nil
+func NewAnnotator(logger *zap.Logger, translator Translator, target string) *Annotator {
+	return &Annotator{
+		logger:       logger,
+		translator:   translator,
+		target:       target,
+		translations: cache.New(translationExpiry, time.Hour),
+	}
+}
+
+// Annotate sets the language of the incidents and, if a translator is configured, their translated title and description
+// An incident whose text fails to translate is kept without a translation
+func (a *Annotator) Annotate(ctx context.Context, incidents []api.Incident) {
+	for i := range incidents {
+		incident := &incidents[i]
+		incident.Language = Detect(incidentText(*incident))
+		if a.translator == nil || incident.Language == "" || incident.Language == a.target {
+			continue
+		}
+		title, err := a.translate(ctx, incident.Title, incident.Language)
+		if err != nil {
+			a.logger.Info("failed to translate the incident title", zap.String("translator", a.translator.Name()), zap.String("deep_link", incident.DeepLink), zap.Error(err))
+			continue
+		}
+		incident.TranslatedTitle = &title
+		if text := plainDescription(*incident); text != "" {
+			description, err := a.translate(ctx, text, incident.Language)
+			if err != nil {
+				a.logger.Info("failed to translate the incident description", zap.String("translator", a.translator.Name()), zap.String("deep_link", incident.DeepLink), zap.Error(err))
+				continue
+			}
+			incident.TranslatedDescription = &description
+		}
+	}
+}
+
+func (a *Annotator) translate(ctx context.Context, text string, source string) (string, error) {
+	key := source + ":" + text
+	if cached, found := a.translations.Get(key); found {
+		return cached.(string), nil
+	}
+	translated, err := a.translator.Translate(ctx, text, source, a.target)
+	if err != nil {
+		return "", err
+	}
+	a.translations.Set(key, translated, cache.DefaultExpiration)
+	return translated, nil
+}
+
+// incidentText is the text the language of an incident is detected from
+// Titles are often too short to tell on their own so the description and updates are included
+func incidentText(incident api.Incident) string {
+	parts := []string{incident.Title, plainDescription(incident)}
+	for _, update := range incident.Events {
+		parts = append(parts, update.Body)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// plainDescription is the description without the html markup, which would otherwise be detected and translated as text
+func plainDescription(incident api.Incident) string {
+	if incident.DescriptionText != nil {
+		return *incident.DescriptionText
+	}
+	if incident.Description != nil {
+		return *incident.Description
+	}
+	return ""
+}
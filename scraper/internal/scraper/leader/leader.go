@@ -0,0 +1,110 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/metoro-io/statusphere/common/db"
+	"go.uber.org/zap"
+	"os"
+	"time"
+)
+
+type Config struct {
+	// Enabled turns on active/standby mode, only the elected leader scrapes
+	Enabled bool `envconfig:"SCRAPER_LEADER_ELECTION" default:"false"`
+	// Interval is how often the leader heartbeats and standbys attempt to take over
+	Interval time.Duration `envconfig:"SCRAPER_LEADER_INTERVAL" default:"5s"`
+	// MissedHeartbeats is the number of intervals without a heartbeat after which a connected leader is considered hung
+	MissedHeartbeats int `envconfig:"SCRAPER_LEADER_MISSED_HEARTBEATS" default:"3"`
+}
+
+func GetConfigFromEnvironment() (Config, error) {
+	var config Config
+	err := envconfig.Process("STATUSPHERE", &config)
+	return config, err
+}
+
+// Elector elects a single leader among the scraper replicas
+// Leadership is a postgres advisory lock so it is released as soon as the leader's database session dies,
+// standbys poll for the lock every interval so they take over within one interval of the leader dying
+type Elector struct {
+	logger   *zap.Logger
+	dbClient *db.DbClient
+	config   Config
+	holderID string
+}
+
+func NewElector(logger *zap.Logger, dbClient *db.DbClient, config Config) *Elector {
+	hostname, _ := os.Hostname()
+	return &Elector{
+		logger:   logger,
+		dbClient: dbClient,
+		config:   config,
+		holderID: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+	}
+}
+
+// AwaitLeadership blocks as a warm standby until this replica becomes the leader
+// The returned channel is closed if the leadership is subsequently lost
+func (e *Elector) AwaitLeadership(ctx context.Context) (<-chan struct{}, error) {
+	ticker := time.NewTicker(e.config.Interval)
+	defer ticker.Stop()
+	e.logger.Info("waiting for leadership", zap.String("holderId", e.holderID))
+	for {
+		lock, acquired, err := e.dbClient.AcquireLeaderLock(ctx)
+		if err != nil {
+			e.logger.Error("failed to attempt leader election", zap.Error(err))
+		}
+		if acquired {
+			err = lock.Heartbeat(ctx, e.holderID)
+			if err != nil {
+				lock.Release()
+				return nil, err
+			}
+			e.logger.Info("became leader", zap.String("holderId", e.holderID))
+			lost := make(chan struct{})
+			go e.heartbeat(ctx, lock, lost)
+			return lost, nil
+		}
+		e.checkLeaderIsAlive(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkLeaderIsAlive terminates the session of a leader that still holds the lock but has stopped heartbeating
+func (e *Elector) checkLeaderIsAlive(ctx context.Context) {
+	staleAfter := time.Duration(e.config.MissedHeartbeats) * e.config.Interval
+	heartbeat, err := e.dbClient.TerminateStaleLeaderSession(ctx, staleAfter)
+	if err != nil {
+		e.logger.Error("failed to terminate stale leader session", zap.Error(err))
+		return
+	}
+	if heartbeat != nil {
+		e.logger.Warn("terminated the session of a leader that stopped heartbeating", zap.String("leader", heartbeat.HolderID), zap.Time("lastHeartbeat", heartbeat.HeartbeatAt))
+	}
+}
+
+func (e *Elector) heartbeat(ctx context.Context, lock *db.LeaderLock, lost chan struct{}) {
+	defer close(lost)
+	defer lock.Release()
+	ticker := time.NewTicker(e.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := lock.Heartbeat(ctx, e.holderID)
+			if err != nil {
+				e.logger.Error("lost leadership", zap.Error(err))
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package locker
+
+import "context"
+
+type Locker interface {
+	// AcquireScrapeLock attempts to take the lock for scraping the given url without blocking
+	// If acquired is true, release must be called once the scrape has finished
+	AcquireScrapeLock(ctx context.Context, url string) (release func(), acquired bool, err error)
+
+	// AcquireHistoricalScrapeLock is AcquireScrapeLock for historical scrapes
+	AcquireHistoricalScrapeLock(ctx context.Context, url string) (release func(), acquired bool, err error)
+}
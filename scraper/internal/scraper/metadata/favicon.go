@@ -0,0 +1,102 @@
+package metadata
+
+import (
+	"bytes"
+	"context"
+	"github.com/PuerkitoBio/goquery"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+)
+
+// icon is a candidate icon linked from the home page
+type icon struct {
+	href string
+	// rank orders the candidates, the larger icons that render better in a vendor list come first
+	rank int
+}
+
+// findFavicon returns the url of the largest icon that the home page links to, or of its /favicon.ico
+// It returns an empty url if the status page has no icon that loads
+func findFavicon(ctx context.Context, httpClient *http.Client, doc *goquery.Document, base *neturl.URL) string {
+	var best *icon
+	doc.Find("link[rel][href]").Each(func(_ int, link *goquery.Selection) {
+		rank := rankIcon(link.AttrOr("rel", ""), link.AttrOr("sizes", ""))
+		if rank < 0 {
+			return
+		}
+		href, err := base.Parse(strings.TrimSpace(link.AttrOr("href", "")))
+		if err != nil || (href.Scheme != "http" && href.Scheme != "https") {
+			return
+		}
+		if best == nil || rank > best.rank {
+			best = &icon{href: href.String(), rank: rank}
+		}
+	})
+	if best != nil && loads(ctx, httpClient, best.href) {
+		return best.href
+	}
+	fallback := (&neturl.URL{Scheme: base.Scheme, Host: base.Host, Path: "/favicon.ico"}).String()
+	if loads(ctx, httpClient, fallback) {
+		return fallback
+	}
+	return ""
+}
+
+// rankIcon ranks an icon link by its rel and its size, -1 if the link isn't an icon
+func rankIcon(rel string, sizes string) int {
+	rank := -1
+	for _, value := range strings.Fields(strings.ToLower(rel)) {
+		switch value {
+		case "apple-touch-icon", "apple-touch-icon-precomposed":
+			// Touch icons are at least 180px
+			rank = 180
+		case "icon":
+			if rank < 0 {
+				rank = 16
+			}
+		}
+	}
+	if rank < 0 {
+		return -1
+	}
+	for _, size := range strings.Fields(strings.ToLower(sizes)) {
+		if size == "any" {
+			// A scalable icon renders at any size
+			return 1024
+		}
+		width, _, found := strings.Cut(size, "x")
+		if !found {
+			continue
+		}
+		if parsed, err := strconv.Atoi(width); err == nil && parsed > rank {
+			rank = parsed
+		}
+	}
+	return rank
+}
+
+// loads returns true if the url answers with an image
+func loads(ctx context.Context, httpClient *http.Client, url string) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "image/") {
+		return true
+	}
+	// Icons are often served with a generic content type, so their content is sniffed
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(resp.Body, head)
+	return strings.HasPrefix(http.DetectContentType(head[:n]), "image/") || bytes.Contains(head[:n], []byte("<svg"))
+}
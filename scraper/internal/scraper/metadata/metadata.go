@@ -0,0 +1,139 @@
+package metadata
+
+import (
+	"context"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"regexp"
+	"strings"
+)
+
+// maxPageSize bounds how much of the home page is read
+const maxPageSize = 2 * 1024 * 1024
+
+// maxDescriptionLength bounds the description, some pages put their whole intro in the meta tag
+const maxDescriptionLength = 500
+
+var (
+	// titleSuffixRegex matches what status pages append to the vendor name in their title, e.g. "Acme Status" or "Acme - Status Page"
+	titleSuffixRegex = regexp.MustCompile(`(?i)\s*([-|:–—]\s*)?(system\s+)?status(\s+page)?\s*$`)
+	// twitterPathRegex matches the path of a link to a Twitter/X account, the handle is captured
+	twitterPathRegex = regexp.MustCompile(`^/@?([A-Za-z0-9_]{1,15})/?$`)
+	// supportTextRegex matches the text of a link to the support of the vendor
+	supportTextRegex = regexp.MustCompile(`(?i)\b(support|help|contact)\b`)
+)
+
+// twitterPaths are the paths of twitter.com and x.com that aren't accounts
+var twitterPaths = map[string]bool{"home": true, "share": true, "intent": true, "search": true, "hashtag": true, "i": true, "login": true}
+
+// Fetch reads the metadata of the status page from its home page: the vendor's name, its description,
+// its Twitter/X account, where to get support and its icon. The fields that the page doesn't give are empty
+func Fetch(ctx context.Context, httpClient *http.Client, url string) (api.StatusPageMetadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return api.StatusPageMetadata{}, errors.Wrap(err, "failed to create the request")
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return api.StatusPageMetadata{}, errors.Wrap(err, "failed to get the home page")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return api.StatusPageMetadata{}, errors.Errorf("home page returned %d", resp.StatusCode)
+	}
+	doc, err := goquery.NewDocumentFromReader(io.LimitReader(resp.Body, maxPageSize))
+	if err != nil {
+		return api.StatusPageMetadata{}, errors.Wrap(err, "failed to parse the home page")
+	}
+	// Links are relative to where the home page was redirected to
+	base := resp.Request.URL
+	if href, found := doc.Find("base[href]").Attr("href"); found {
+		if parsed, err := base.Parse(href); err == nil {
+			base = parsed
+		}
+	}
+
+	return api.StatusPageMetadata{
+		DisplayName:   displayName(doc),
+		Description:   description(doc),
+		TwitterHandle: twitterHandle(doc),
+		SupportURL:    supportURL(doc, base),
+		FaviconURL:    findFavicon(ctx, httpClient, doc, base),
+	}, nil
+}
+
+func meta(doc *goquery.Document, selector string) string {
+	return strings.TrimSpace(doc.Find(selector).First().AttrOr("content", ""))
+}
+
+// displayName prefers the site name the page declares over its title, which usually has "Status" appended
+func displayName(doc *goquery.Document) string {
+	for _, selector := range []string{`meta[property="og:site_name"]`, `meta[name="application-name"]`} {
+		if name := meta(doc, selector); name != "" {
+			return strings.TrimSpace(titleSuffixRegex.ReplaceAllString(name, ""))
+		}
+	}
+	title := strings.TrimSpace(doc.Find("title").First().Text())
+	return strings.TrimSpace(titleSuffixRegex.ReplaceAllString(title, ""))
+}
+
+func description(doc *goquery.Document) string {
+	for _, selector := range []string{`meta[name="description"]`, `meta[property="og:description"]`} {
+		if text := meta(doc, selector); text != "" {
+			text = strings.Join(strings.Fields(text), " ")
+			if len([]rune(text)) > maxDescriptionLength {
+				text = string([]rune(text)[:maxDescriptionLength])
+			}
+			return text
+		}
+	}
+	return ""
+}
+
+// twitterHandle returns the account in the twitter:site meta tag, or the first account that the page links to
+// The handle is returned without its @
+func twitterHandle(doc *goquery.Document) string {
+	if site := strings.TrimPrefix(meta(doc, `meta[name="twitter:site"]`), "@"); site != "" && twitterPathRegex.MatchString("/"+site) {
+		return site
+	}
+	handle := ""
+	doc.Find("a[href]").EachWithBreak(func(_ int, link *goquery.Selection) bool {
+		href, err := neturl.Parse(strings.TrimSpace(link.AttrOr("href", "")))
+		if err != nil {
+			return true
+		}
+		host := strings.TrimPrefix(strings.ToLower(href.Host), "www.")
+		if host != "twitter.com" && host != "x.com" {
+			return true
+		}
+		match := twitterPathRegex.FindStringSubmatch(href.Path)
+		if match == nil || twitterPaths[strings.ToLower(match[1])] {
+			return true
+		}
+		handle = match[1]
+		return false
+	})
+	return handle
+}
+
+// supportURL returns the first link whose text is about support, help or contact, e.g. "Visit our support site"
+func supportURL(doc *goquery.Document, base *neturl.URL) string {
+	url := ""
+	doc.Find("a[href]").EachWithBreak(func(_ int, link *goquery.Selection) bool {
+		text := strings.Join(strings.Fields(link.Text()+" "+link.AttrOr("title", "")), " ")
+		if !supportTextRegex.MatchString(text) {
+			return true
+		}
+		href, err := base.Parse(strings.TrimSpace(link.AttrOr("href", "")))
+		if err != nil || (href.Scheme != "http" && href.Scheme != "https" && href.Scheme != "mailto") {
+			return true
+		}
+		url = href.String()
+		return false
+	})
+	return url
+}
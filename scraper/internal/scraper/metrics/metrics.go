@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"github.com/kelseyhightower/envconfig"
+	commonmetrics "github.com/metoro-io/statusphere/common/metrics"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type Config struct {
+	// Address is where the metrics are served on /metrics, empty disables the metrics server
+	Address string `envconfig:"SCRAPER_METRICS_ADDRESS" default:":9090"`
+}
+
+func GetConfigFromEnvironment() (Config, error) {
+	var config Config
+	err := envconfig.Process("STATUSPHERE", &config)
+	return config, err
+}
+
+// durationBuckets are the buckets of the latency histograms in seconds, from a fast API up to a slow rendered page
+var durationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+var (
+	// FetchDuration is the latency of each request a provider makes
+	FetchDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{Name: "statusphere_scraper_fetch_duration_seconds", Help: "Latency of the requests made by the providers.", Buckets: durationBuckets}, []string{"provider"})
+	// HTTPResponses counts the responses to the requests of each provider by status code, error if no response was received
+	HTTPResponses = promauto.NewCounterVec(prometheus.CounterOpts{Name: "statusphere_scraper_http_responses_total", Help: "Responses to the requests made by the providers by status code."}, []string{"provider", "code"})
+	// ScrapeDuration is how long each scrape took
+	ScrapeDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{Name: "statusphere_scraper_scrape_duration_seconds", Help: "Duration of the scrapes by provider and kind of scrape.", Buckets: durationBuckets}, []string{"provider", "kind"})
+	// StageDuration is how long each stage of a scrape took, see the tracing package
+	StageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{Name: "statusphere_scraper_stage_duration_seconds", Help: "Time spent in each stage of the scrapes by kind of scrape.", Buckets: durationBuckets}, []string{"kind", "stage"})
+	// ScrapeFailures counts the scrapes that failed, reason is fetch if a request failed and parse otherwise
+	ScrapeFailures = promauto.NewCounterVec(prometheus.CounterOpts{Name: "statusphere_scraper_scrape_failures_total", Help: "Failed scrapes by provider, kind of scrape and reason."}, []string{"provider", "kind", "reason"})
+	// IncidentsFound counts the incidents returned by the scrapes, a provider whose rate drops to zero has likely broken
+	IncidentsFound = promauto.NewCounterVec(prometheus.CounterOpts{Name: "statusphere_scraper_incidents_found_total", Help: "Incidents returned by the scrapes by provider and kind of scrape."}, []string{"provider", "kind"})
+	// IncidentsChanged counts the stored incidents that a scrape created, updated or resolved
+	IncidentsChanged = promauto.NewCounterVec(prometheus.CounterOpts{Name: "statusphere_scraper_incidents_changed_total", Help: "Stored incidents created, updated or resolved by provider."}, []string{"provider", "change"})
+)
+
+// Serve serves the metrics of the process on /metrics in the background, the ones of the db client among them
+func Serve(logger *zap.Logger, config Config) {
+	commonmetrics.Serve(logger, config.Address)
+}
+
+// ProviderLabel returns the provider label value, unknown for requests that weren't made by a provider
+func ProviderLabel(name string) string {
+	if name == "" {
+		return "unknown"
+	}
+	return name
+}
+
+// Transport is an http.RoundTripper that records the latency and status code of the requests by provider
+type Transport struct {
+	base http.RoundTripper
+}
+
+func NewTransport(base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{base: base}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	provider := ProviderLabel(providers.NameFromContext(req.Context()))
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	FetchDuration.WithLabelValues(provider).Observe(time.Since(start).Seconds())
+	if err != nil {
+		HTTPResponses.WithLabelValues(provider, "error").Inc()
+		return nil, err
+	}
+	HTTPResponses.WithLabelValues(provider, strconv.Itoa(resp.StatusCode)).Inc()
+	return resp, nil
+}
@@ -0,0 +1,202 @@
+package notifier
+
+import (
+	"context"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/throttle"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	digestInterval = 5 * time.Minute
+	// digestRetention is how long the records of the sent digests are kept, longer than the longest period
+	digestRetention = 30 * 24 * time.Hour
+)
+
+// Start starts sending the digests and the collapsed updates, it runs until the context is cancelled
+// Each digest is claimed in the database before it is sent, so that it is sent once however many scrapers run, and
+// the digest of the latest period is sent after a restart if it hadn't been
+func (e *EmailNotifier) Start(ctx context.Context) {
+	recipients := map[string]Recipient{}
+	var destinations []string
+	for _, recipient := range e.recipients {
+		if recipient.Immediate {
+			recipients[recipientDestination(recipient)] = recipient
+			destinations = append(destinations, recipientDestination(recipient))
+		}
+	}
+	go func() {
+		ticker := time.NewTicker(throttle.FlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				e.sendDue(ctx, destinations, recipients)
+			}
+		}
+	}()
+	go func() {
+		ticker := time.NewTicker(digestInterval)
+		defer ticker.Stop()
+		lastCleanup := time.Time{}
+		for {
+			e.sendDigests(ctx, time.Now().UTC())
+			if time.Since(lastCleanup) > 24*time.Hour {
+				e.cleanup(ctx)
+				lastCleanup = time.Now()
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// sendDue sends the collapsed updates of the recipients whose window has passed
+func (e *EmailNotifier) sendDue(ctx context.Context, destinations []string, recipients map[string]Recipient) {
+	due, err := e.throttler.Due(ctx, destinations, time.Now())
+	if err != nil {
+		e.logger.Error("failed to get the collapsed incident emails", zap.Error(err))
+		return
+	}
+	for _, collapsed := range due {
+		notification, err := collapsedNotification(ctx, e.statusPages, collapsed)
+		if err != nil {
+			e.logger.Error("failed to get the status page of the collapsed incident email", zap.Uint64("event", collapsed.Event.ID), zap.Error(err))
+			continue
+		}
+		e.sendIncident(ctx, recipients[collapsed.Destination], notification)
+	}
+}
+
+// sendDigests sends the digest of the latest period that has ended to each of its recipients that haven't had it yet
+func (e *EmailNotifier) sendDigests(ctx context.Context, now time.Time) {
+	for _, period := range []string{DigestDaily, DigestWeekly} {
+		var recipients []Recipient
+		for _, recipient := range e.recipients {
+			if recipient.Digest == period {
+				recipients = append(recipients, recipient)
+			}
+		}
+		if len(recipients) == 0 {
+			continue
+		}
+		from, to := digestPeriod(period, now, e.config.EmailDigestHour)
+		incidents, statusPages, err := e.digestIncidents(ctx, from, to)
+		if err != nil {
+			e.logger.Error("failed to get the incidents of the digest", zap.String("period", period), zap.Error(err))
+			continue
+		}
+		for _, recipient := range recipients {
+			err := e.sendDigest(ctx, recipient, period, from, to, incidents, statusPages)
+			if err != nil {
+				e.logger.Error("failed to send digest", zap.String("recipient", recipient.Email), zap.String("period", period), zap.Error(err))
+			}
+		}
+	}
+}
+
+// digestPeriod returns the latest period that has ended, a day or a week that ends at the digest hour, on a Monday for
+// the weeks
+func digestPeriod(period string, now time.Time, hour int) (time.Time, time.Time) {
+	to := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+	if to.After(now) {
+		to = to.AddDate(0, 0, -1)
+	}
+	if period == DigestDaily {
+		return to.AddDate(0, 0, -1), to
+	}
+	for to.Weekday() != time.Monday {
+		to = to.AddDate(0, 0, -1)
+	}
+	return to.AddDate(0, 0, -7), to
+}
+
+// digestIncidents returns the incidents that were open during the period, maintenances aside, and the status pages
+func (e *EmailNotifier) digestIncidents(ctx context.Context, from time.Time, to time.Time) ([]api.Incident, map[string]api.StatusPage, error) {
+	incidents, err := e.dbClient.GetOverlappingIncidents(ctx, from, to)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get incidents")
+	}
+	statusPages, err := e.dbClient.GetAllStatusPages(ctx)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get status pages")
+	}
+	byUrl := make(map[string]api.StatusPage, len(statusPages))
+	for _, statusPage := range statusPages {
+		byUrl[statusPage.URL] = statusPage
+	}
+	return incidents, byUrl, nil
+}
+
+// sendDigest sends the digest of the incidents that match the filter of the recipient, unless there are none or the
+// digest has already been sent
+func (e *EmailNotifier) sendDigest(ctx context.Context, recipient Recipient, period string, from time.Time, to time.Time, incidents []api.Incident, statusPages map[string]api.StatusPage) error {
+	digest := digestEmail{Period: period, From: from, To: to, StatusPages: []digestStatusPage{}}
+	byStatusPage := map[string]int{}
+	for _, incident := range incidents {
+		statusPage, found := statusPages[incident.StatusPageUrl]
+		if !found || statusPage.IsSandbox || !recipient.matchesIncident(incident, statusPage) {
+			continue
+		}
+		index, found := byStatusPage[incident.StatusPageUrl]
+		if !found {
+			index = len(digest.StatusPages)
+			byStatusPage[incident.StatusPageUrl] = index
+			digest.StatusPages = append(digest.StatusPages, digestStatusPage{Name: statusPage.Name, URL: statusPage.URL})
+		}
+		digest.StatusPages[index].Incidents = append(digest.StatusPages[index].Incidents, incident)
+		digest.IncidentCount++
+	}
+	if digest.IncidentCount == 0 {
+		return nil
+	}
+	sort.Slice(digest.StatusPages, func(i, j int) bool {
+		return strings.ToLower(digest.StatusPages[i].Name) < strings.ToLower(digest.StatusPages[j].Name)
+	})
+
+	claim := db.EmailDigest{Recipient: recipient.Email, Period: period, PeriodStart: from, SentAt: time.Now().UTC()}
+	claimed, err := e.dbClient.ClaimEmailDigest(ctx, claim)
+	if err != nil {
+		return errors.Wrap(err, "failed to claim the digest")
+	}
+	if !claimed {
+		return nil
+	}
+	subject, body, err := e.templates.renderDigest(digest)
+	if err == nil {
+		err = retry(ctx, func() error {
+			return e.send(ctx, recipient.Email, subject, body)
+		})
+	}
+	recordOutcome(ctx, e.logger, e.dbClient, e.Name(), err)
+	if err != nil {
+		releaseErr := e.dbClient.ReleaseEmailDigest(ctx, claim)
+		if releaseErr != nil {
+			e.logger.Error("failed to release the digest, it won't be retried", zap.String("recipient", recipient.Email), zap.Error(releaseErr))
+		}
+		return err
+	}
+	e.logger.Info("sent digest", zap.String("recipient", recipient.Email), zap.String("period", period), zap.Int("incidents", digest.IncidentCount))
+	return nil
+}
+
+func (e *EmailNotifier) cleanup(ctx context.Context) {
+	deleted, err := e.dbClient.DeleteEmailDigestsSentBefore(ctx, time.Now().Add(-digestRetention))
+	if err != nil {
+		e.logger.Error("failed to delete old email digests", zap.Error(err))
+		return
+	}
+	if deleted > 0 {
+		e.logger.Info("deleted old email digests", zap.Int64("digests", deleted))
+	}
+}
@@ -0,0 +1,95 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// The limits of Discord on the parts of an embed
+const (
+	discordMaxTitleLength       = 256
+	discordMaxDescriptionLength = 4096
+	discordMaxFieldLength       = 1024
+)
+
+// DiscordSender posts notifications as embeds to the webhooks of Discord channels
+type DiscordSender struct {
+	httpClient *http.Client
+}
+
+func NewDiscordSender(config Config) *DiscordSender {
+	return &DiscordSender{httpClient: &http.Client{Timeout: config.Timeout}}
+}
+
+func (d *DiscordSender) Name() string {
+	return "discord"
+}
+
+func (d *DiscordSender) Send(ctx context.Context, channel Channel, notification Notification) error {
+	return postJSON(ctx, d.httpClient, channel.WebhookURL, map[string]interface{}{
+		"username": "statusphere",
+		"embeds":   []map[string]interface{}{discordEmbed(notification)},
+		// The incidents are scraped from third parties, their text must not ping anyone
+		"allowed_mentions": map[string]interface{}{"parse": []string{}},
+	})
+}
+
+// discordEmbed is the embed of a notification
+func discordEmbed(notification Notification) map[string]interface{} {
+	incident := notification.incident()
+	color := 0xcf222e
+	switch notification.Event.Type {
+	case api.ChangeEventIncidentUpdated:
+		color = 0xd4a72c
+	case api.ChangeEventIncidentResolved:
+		color = 0x1a7f37
+	case api.ChangeEventIncidentDeleted:
+		color = 0x8c959f
+	}
+	if incident.Impact == api.ImpactMaintenance && notification.Event.Type != api.ChangeEventIncidentResolved {
+		color = 0x0969da
+	}
+
+	fields := []map[string]interface{}{
+		discordField("Status", notification.state()),
+		discordField("Impact", string(incident.Impact)),
+		discordField("Started", discordDate(incident.StartTime)),
+	}
+	if duration, ended := notification.duration(); ended {
+		fields = append(fields, discordField("Duration", duration))
+	}
+	if len(incident.Components) > 0 {
+		fields = append(fields, discordField("Components", discordEscape(strings.Join(incident.Components, ", "))))
+	}
+
+	embed := map[string]interface{}{
+		"title":     truncate(notification.name()+": "+incident.Title, discordMaxTitleLength),
+		"url":       incident.DeepLink,
+		"color":     color,
+		"fields":    fields,
+		"footer":    map[string]string{"text": incident.StatusPageUrl},
+		"timestamp": notification.Event.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if update, found := notification.latestUpdate(); found {
+		embed["description"] = truncate(fmt.Sprintf("**Latest update** (%s, %s)\n%s", update.State, discordDate(update.Time), discordEscape(update.Body)), discordMaxDescriptionLength)
+	}
+	return embed
+}
+
+func discordField(name string, value string) map[string]interface{} {
+	return map[string]interface{}{"name": name, "value": truncate(value, discordMaxFieldLength), "inline": true}
+}
+
+// discordDate formats the time in the time zone of the reader
+func discordDate(t time.Time) string {
+	return fmt.Sprintf("<t:%d:f>", t.Unix())
+}
+
+// discordEscape escapes the characters that Discord treats as markdown in text
+func discordEscape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`", "|", `\|`, ">", `\>`).Replace(text)
+}
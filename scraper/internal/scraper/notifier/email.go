@@ -0,0 +1,222 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/throttle"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// Recipient is an email address that is sent the incident changes that match its filter as they happen if Immediate is
+// set, and a digest of the incidents that match it if Digest is daily or weekly
+type Recipient struct {
+	Email     string `json:"email"`
+	Immediate bool   `json:"immediate"`
+	Digest    string `json:"digest"`
+	// ThrottleSeconds replaces the throttle of the notifier for the immediate emails if it is set, -1 sends every update
+	ThrottleSeconds int `json:"throttleSeconds"`
+	Filter
+}
+
+// EmailNotifier emails the incident changes to the immediate recipients as an outbox publisher, and the digests to the
+// others once it is started. The emails are best effort, deduplicated and throttled like the other notifications
+type EmailNotifier struct {
+	logger      *zap.Logger
+	dbClient    *db.DbClient
+	config      Config
+	recipients  []Recipient
+	templates   *emailTemplates
+	throttler   *throttle.Throttler
+	statusPages *statusPageLookup
+}
+
+// NewEmailNotifierFromConfig returns the email notifier, nil if emails aren't configured
+func NewEmailNotifierFromConfig(logger *zap.Logger, dbClient *db.DbClient, config Config) (*EmailNotifier, error) {
+	if config.SMTPHost == "" || config.EmailRecipients == "" {
+		return nil, nil
+	}
+	var recipients []Recipient
+	err := json.Unmarshal([]byte(config.EmailRecipients), &recipients)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the email recipients")
+	}
+	for _, recipient := range recipients {
+		if recipient.Email == "" {
+			return nil, errors.New("a recipient has no email")
+		}
+		if recipient.Digest != "" && recipient.Digest != DigestDaily && recipient.Digest != DigestWeekly {
+			return nil, errors.Errorf("recipient %q has an unknown digest %q, it must be daily or weekly", recipient.Email, recipient.Digest)
+		}
+		err = recipient.validate()
+		if err != nil {
+			return nil, errors.Wrapf(err, "recipient %q", recipient.Email)
+		}
+	}
+	if config.EmailDigestHour < 0 || config.EmailDigestHour > 23 {
+		return nil, errors.Errorf("the email digest hour must be between 0 and 23, not %d", config.EmailDigestHour)
+	}
+	templates, err := loadEmailTemplates(config.EmailTemplateDir)
+	if err != nil {
+		return nil, err
+	}
+	return &EmailNotifier{
+		logger:      logger,
+		dbClient:    dbClient,
+		config:      config,
+		recipients:  recipients,
+		templates:   templates,
+		throttler:   throttle.NewThrottler(logger, dbClient, false),
+		statusPages: newStatusPageLookup(dbClient),
+	}, nil
+}
+
+func (e *EmailNotifier) Name() string {
+	return "notifier email"
+}
+
+func (e *EmailNotifier) Publish(ctx context.Context, events []api.ChangeEvent) error {
+	for _, event := range events {
+		statusPage, err := e.statusPages.get(ctx, event.StatusPageUrl)
+		if err != nil {
+			return errors.Wrap(err, "failed to get the status page of the event")
+		}
+		if statusPage.IsSandbox {
+			continue
+		}
+		notification := Notification{Event: event, StatusPage: statusPage}
+		for _, recipient := range e.recipients {
+			if !recipient.Immediate || !recipient.Matches(event, statusPage) {
+				continue
+			}
+			admitted, err := e.throttler.Admit(ctx, recipientDestination(recipient), throttleWindow(recipient.ThrottleSeconds, e.config.Throttle), event, time.Now())
+			if err != nil {
+				return err
+			}
+			if !admitted {
+				continue
+			}
+			e.sendIncident(ctx, recipient, notification)
+		}
+	}
+	return nil
+}
+
+// recipientDestination is the key of the recipient in the throttles
+func recipientDestination(recipient Recipient) string {
+	return "email " + recipient.Email
+}
+
+func (e *EmailNotifier) sendIncident(ctx context.Context, recipient Recipient, notification Notification) {
+	subject, body, err := e.templates.renderIncident(notification)
+	if err == nil {
+		err = retry(ctx, func() error {
+			return e.send(ctx, recipient.Email, subject, body)
+		})
+	}
+	recordOutcome(ctx, e.logger, e.dbClient, e.Name(), err)
+	if err != nil {
+		e.logger.Error("failed to send incident email", zap.String("recipient", recipient.Email), zap.Uint64("event", notification.Event.ID), zap.Error(err))
+		return
+	}
+	e.logger.Info("sent incident email", zap.String("recipient", recipient.Email), zap.String("type", string(notification.Event.Type)), zap.Int("updates", notification.Updates), zap.String("deepLink", notification.Event.DeepLink))
+}
+
+// send sends an html email to the recipient
+func (e *EmailNotifier) send(ctx context.Context, to string, subject string, body []byte) error {
+	address := net.JoinHostPort(e.config.SMTPHost, strconv.Itoa(e.config.SMTPPort))
+	dialer := &net.Dialer{Timeout: e.config.Timeout}
+	var conn net.Conn
+	var err error
+	if e.config.SMTPPort == 465 {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: e.config.SMTPHost}}).DialContext(ctx, "tcp", address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to the smtp server")
+	}
+	defer conn.Close()
+	err = conn.SetDeadline(time.Now().Add(e.config.Timeout))
+	if err != nil {
+		return errors.Wrap(err, "failed to set the deadline")
+	}
+
+	client, err := smtp.NewClient(conn, e.config.SMTPHost)
+	if err != nil {
+		return errors.Wrap(err, "failed to start the smtp session")
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok && e.config.SMTPPort != 465 {
+		err = client.StartTLS(&tls.Config{ServerName: e.config.SMTPHost})
+		if err != nil {
+			return errors.Wrap(err, "failed to start tls")
+		}
+	}
+	if e.config.SMTPUsername != "" {
+		err = client.Auth(smtp.PlainAuth("", e.config.SMTPUsername, e.config.SMTPPassword, e.config.SMTPHost))
+		if err != nil {
+			return errors.Wrap(err, "failed to authenticate")
+		}
+	}
+	err = client.Mail(e.config.EmailFrom)
+	if err != nil {
+		return errors.Wrap(err, "the smtp server rejected the sender")
+	}
+	err = client.Rcpt(to)
+	if err != nil {
+		return errors.Wrap(err, "the smtp server rejected the recipient")
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return errors.Wrap(err, "failed to start the message")
+	}
+	_, err = writer.Write(emailMessage(e.config.EmailFrom, to, subject, body))
+	if err != nil {
+		return errors.Wrap(err, "failed to write the message")
+	}
+	err = writer.Close()
+	if err != nil {
+		return errors.Wrap(err, "the smtp server rejected the message")
+	}
+	return client.Quit()
+}
+
+// emailMessage is the html email with its headers, the body is quoted-printable so that long lines are wrapped
+func emailMessage(from string, to string, subject string, body []byte) []byte {
+	var message bytes.Buffer
+	headers := [][2]string{
+		{"From", from},
+		{"To", to},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/html; charset=utf-8"},
+		{"Content-Transfer-Encoding", "quoted-printable"},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&message, "%s: %s\r\n", header[0], strings.NewReplacer("\r", "", "\n", "").Replace(header[1]))
+	}
+	message.WriteString("\r\n")
+	writer := quotedprintable.NewWriter(&message)
+	writer.Write(body)
+	writer.Close()
+	return message.Bytes()
+}
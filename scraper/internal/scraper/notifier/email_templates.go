@@ -0,0 +1,193 @@
+package notifier
+
+import (
+	"bytes"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// The names of the templates in EmailTemplateDir, the subjects are text templates and the bodies html templates
+// The incident templates are executed with an incidentEmail and the digest templates with a digestEmail
+const (
+	incidentSubjectTemplate = "incident_subject.txt"
+	incidentBodyTemplate    = "incident.html"
+	digestSubjectTemplate   = "digest_subject.txt"
+	digestBodyTemplate      = "digest.html"
+)
+
+type incidentEmail struct {
+	StatusPageName string
+	StatusPageUrl  string
+	// State is Opened, Updated, Resolved or Removed
+	State    string
+	Incident api.Incident
+	// LatestUpdate is the most recent update of the incident, nil if it has none
+	LatestUpdate *api.IncidentUpdate
+}
+
+type digestEmail struct {
+	// Period is daily or weekly
+	Period        string
+	From          time.Time
+	To            time.Time
+	IncidentCount int
+	// StatusPages are the status pages with incidents during the period by name, their incidents earliest first
+	StatusPages []digestStatusPage
+}
+
+type digestStatusPage struct {
+	Name      string
+	URL       string
+	Incidents []api.Incident
+}
+
+var emailTemplateFuncs = map[string]interface{}{
+	"date": func(t time.Time) string { return t.UTC().Format("Jan 2, 2006 15:04 UTC") },
+	// duration is how long the incident lasted, ongoing if it hasn't ended
+	"duration": func(incident api.Incident) string {
+		if incident.EndTime == nil {
+			return "ongoing"
+		}
+		return incident.EndTime.Sub(incident.StartTime).Round(time.Minute).String()
+	},
+	"title": func(text string) string {
+		if text == "" {
+			return text
+		}
+		return strings.ToUpper(text[:1]) + text[1:]
+	},
+}
+
+type emailTemplates struct {
+	incidentSubject *texttemplate.Template
+	incidentBody    *htmltemplate.Template
+	digestSubject   *texttemplate.Template
+	digestBody      *htmltemplate.Template
+}
+
+// loadEmailTemplates parses the default templates, replacing the ones that are in dir
+func loadEmailTemplates(dir string) (*emailTemplates, error) {
+	sources := map[string]string{
+		incidentSubjectTemplate: defaultIncidentSubject,
+		incidentBodyTemplate:    defaultIncidentBody,
+		digestSubjectTemplate:   defaultDigestSubject,
+		digestBodyTemplate:      defaultDigestBody,
+	}
+	if dir != "" {
+		for name := range sources {
+			source, err := os.ReadFile(filepath.Join(dir, name))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to read the email template %s", name)
+			}
+			sources[name] = string(source)
+		}
+	}
+
+	var templates emailTemplates
+	var err error
+	parseText := func(name string) *texttemplate.Template {
+		parsed, parseErr := texttemplate.New(name).Funcs(emailTemplateFuncs).Parse(sources[name])
+		if parseErr != nil && err == nil {
+			err = errors.Wrapf(parseErr, "failed to parse the email template %s", name)
+		}
+		return parsed
+	}
+	parseHTML := func(name string) *htmltemplate.Template {
+		parsed, parseErr := htmltemplate.New(name).Funcs(emailTemplateFuncs).Parse(sources[name])
+		if parseErr != nil && err == nil {
+			err = errors.Wrapf(parseErr, "failed to parse the email template %s", name)
+		}
+		return parsed
+	}
+	templates.incidentSubject = parseText(incidentSubjectTemplate)
+	templates.incidentBody = parseHTML(incidentBodyTemplate)
+	templates.digestSubject = parseText(digestSubjectTemplate)
+	templates.digestBody = parseHTML(digestBodyTemplate)
+	if err != nil {
+		return nil, err
+	}
+	return &templates, nil
+}
+
+func (t *emailTemplates) renderIncident(notification Notification) (string, []byte, error) {
+	data := incidentEmail{
+		StatusPageName: notification.name(),
+		StatusPageUrl:  notification.Event.StatusPageUrl,
+		State:          notification.state(),
+		Incident:       notification.incident(),
+	}
+	if update, found := notification.latestUpdate(); found {
+		data.LatestUpdate = &update
+	}
+	return render(t.incidentSubject, t.incidentBody, data)
+}
+
+func (t *emailTemplates) renderDigest(digest digestEmail) (string, []byte, error) {
+	return render(t.digestSubject, t.digestBody, digest)
+}
+
+func render(subjectTemplate *texttemplate.Template, bodyTemplate *htmltemplate.Template, data interface{}) (string, []byte, error) {
+	var subject bytes.Buffer
+	err := subjectTemplate.Execute(&subject, data)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to render the subject")
+	}
+	var body bytes.Buffer
+	err = bodyTemplate.Execute(&body, data)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to render the body")
+	}
+	return strings.TrimSpace(subject.String()), body.Bytes(), nil
+}
+
+const defaultIncidentSubject = `[{{.State}}] {{.StatusPageName}}: {{.Incident.Title}}`
+
+const defaultIncidentBody = `<!DOCTYPE html>
+<html>
+<body style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Helvetica, Arial, sans-serif; color: #1f2328;">
+<h2 style="margin: 0 0 8px;">{{.StatusPageName}}: {{.Incident.Title}}</h2>
+<table cellpadding="4" style="border-collapse: collapse;">
+<tr><td><b>Status</b></td><td>{{.State}}</td></tr>
+<tr><td><b>Impact</b></td><td>{{.Incident.Impact}}</td></tr>
+<tr><td><b>Started</b></td><td>{{date .Incident.StartTime}}</td></tr>
+<tr><td><b>Duration</b></td><td>{{duration .Incident}}</td></tr>
+{{- if .Incident.Components}}
+<tr><td><b>Components</b></td><td>{{range $i, $component := .Incident.Components}}{{if $i}}, {{end}}{{$component}}{{end}}</td></tr>
+{{- end}}
+</table>
+{{- with .LatestUpdate}}
+<h3 style="margin: 16px 0 4px;">Latest update ({{.State}}, {{date .Time}})</h3>
+<p style="white-space: pre-wrap;">{{.Body}}</p>
+{{- end}}
+<p><a href="{{.Incident.DeepLink}}">View the incident</a> on <a href="{{.StatusPageUrl}}">{{.StatusPageName}}</a></p>
+</body>
+</html>
+`
+
+const defaultDigestSubject = `{{title .Period}} vendor digest: {{.IncidentCount}} incident{{if ne .IncidentCount 1}}s{{end}} on {{len .StatusPages}} status page{{if ne (len .StatusPages) 1}}s{{end}}`
+
+const defaultDigestBody = `<!DOCTYPE html>
+<html>
+<body style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Helvetica, Arial, sans-serif; color: #1f2328;">
+<h2 style="margin: 0 0 4px;">{{title .Period}} vendor digest</h2>
+<p style="color: #656d76; margin: 0 0 16px;">{{date .From}} to {{date .To}}</p>
+{{- range .StatusPages}}
+<h3 style="margin: 16px 0 4px;"><a href="{{.URL}}">{{.Name}}</a></h3>
+<ul style="margin: 0; padding-left: 20px;">
+{{- range .Incidents}}
+<li><a href="{{.DeepLink}}">{{.Title}}</a> &middot; {{.Impact}} &middot; {{date .StartTime}} &middot; {{duration .}}</li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`
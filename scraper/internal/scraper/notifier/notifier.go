@@ -0,0 +1,450 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/throttle"
+	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+)
+
+type Config struct {
+	// SlackChannels, TeamsChannels and DiscordChannels are json lists of the channels to notify, see Channel
+	SlackChannels   string `envconfig:"SLACK_CHANNELS"`
+	TeamsChannels   string `envconfig:"TEAMS_CHANNELS"`
+	DiscordChannels string `envconfig:"DISCORD_CHANNELS"`
+	// Timeout bounds each attempt at sending a notification
+	Timeout time.Duration `envconfig:"NOTIFIER_TIMEOUT" default:"10s"`
+	// Throttle is how long the updates of an incident are collapsed for after a notification of it, the channels and
+	// recipients can set their own with throttleSeconds, 0 sends every update
+	Throttle time.Duration `envconfig:"NOTIFIER_THROTTLE" default:"10m"`
+
+	// Emails are sent if SMTPHost and EmailRecipients are set, port 465 is implicit TLS and the other ports use
+	// STARTTLS if the server supports it
+	SMTPHost     string `envconfig:"SMTP_HOST"`
+	SMTPPort     int    `envconfig:"SMTP_PORT" default:"587"`
+	SMTPUsername string `envconfig:"SMTP_USERNAME"`
+	SMTPPassword string `envconfig:"SMTP_PASSWORD"`
+	EmailFrom    string `envconfig:"EMAIL_FROM" default:"statusphere@localhost"`
+	// EmailRecipients is a json list of the recipients of the emails, see Recipient
+	EmailRecipients string `envconfig:"EMAIL_RECIPIENTS"`
+	// EmailTemplateDir has the templates that replace the default ones, see emailTemplateNames
+	EmailTemplateDir string `envconfig:"EMAIL_TEMPLATE_DIR"`
+	// EmailDigestHour is the hour of the day, in UTC, that the digests are sent at, the weekly digests on Mondays
+	EmailDigestHour int `envconfig:"EMAIL_DIGEST_HOUR" default:"8"`
+
+	// PagerDutyServices and OpsgenieIntegrations are json lists of the services that are paged, see Service
+	PagerDutyServices    string `envconfig:"PAGERDUTY_SERVICES"`
+	OpsgenieIntegrations string `envconfig:"OPSGENIE_INTEGRATIONS"`
+}
+
+func GetConfigFromEnvironment() (Config, error) {
+	var config Config
+	err := envconfig.Process("STATUSPHERE", &config)
+	return config, err
+}
+
+// Filter selects the incident changes that are notified, an empty filter matches everything
+type Filter struct {
+	StatusPageUrls []string              `json:"statusPageUrls"`
+	Impacts        []api.Impact          `json:"impacts"`
+	Providers      []string              `json:"providers"`
+	EventTypes     []api.ChangeEventType `json:"eventTypes"`
+}
+
+// Matches returns true if the change of an incident of the status page passes the filter
+func (f Filter) Matches(event api.ChangeEvent, statusPage api.StatusPage) bool {
+	eventTypes := f.EventTypes
+	if len(eventTypes) == 0 {
+		eventTypes = api.SubscribableEventTypes
+	}
+	return slices.Contains(eventTypes, event.Type) && f.matchesIncident(api.Incident(event.Incident), statusPage)
+}
+
+// matchesIncident returns true if the incident of the status page passes the filter, whatever its change
+func (f Filter) matchesIncident(incident api.Incident, statusPage api.StatusPage) bool {
+	if len(f.StatusPageUrls) > 0 && !slices.Contains(f.StatusPageUrls, incident.StatusPageUrl) {
+		return false
+	}
+	if len(f.Impacts) > 0 && !slices.Contains(f.Impacts, incident.Impact) {
+		return false
+	}
+	if len(f.Providers) > 0 && !slices.Contains(f.Providers, statusPage.Provider) {
+		return false
+	}
+	return true
+}
+
+// validate returns an error if the filter has an unknown impact
+func (f Filter) validate() error {
+	for _, impact := range f.Impacts {
+		if impact.Severity() == -1 {
+			return errors.Errorf("unknown impact %q", impact)
+		}
+	}
+	return nil
+}
+
+// Channel is where notifications are sent, along with the filter of the incident changes it is sent
+type Channel struct {
+	// Name identifies the channel in the logs, e.g. #vendor-status
+	Name string `json:"name"`
+	// WebhookURL is the incoming webhook that posts to the channel
+	WebhookURL string `json:"webhookUrl"`
+	// ThrottleSeconds replaces the throttle of the notifier for the channel if it is set, -1 sends every update
+	ThrottleSeconds int `json:"throttleSeconds"`
+	Filter
+}
+
+// throttleWindow is the window that the updates of an incident are collapsed in, the default unless throttleSeconds is set
+func throttleWindow(throttleSeconds int, defaultWindow time.Duration) time.Duration {
+	if throttleSeconds == 0 {
+		return defaultWindow
+	}
+	return max(time.Duration(throttleSeconds)*time.Second, 0)
+}
+
+// Notification is a change of an incident to send to a channel
+type Notification struct {
+	Event      api.ChangeEvent
+	StatusPage api.StatusPage
+	// Updates is the number of updates that were collapsed into the notification, see throttle.Throttler
+	Updates int
+}
+
+func (n Notification) incident() api.Incident {
+	return api.Incident(n.Event.Incident)
+}
+
+// name is the name of the status page, its url if it has none
+func (n Notification) name() string {
+	if n.StatusPage.Name == "" {
+		return n.Event.StatusPageUrl
+	}
+	return n.StatusPage.Name
+}
+
+// state describes the change in the messages
+func (n Notification) state() string {
+	switch n.Event.Type {
+	case api.ChangeEventIncidentUpdated:
+		if n.Updates > 1 {
+			return fmt.Sprintf("Updated (%d changes)", n.Updates)
+		}
+		return "Updated"
+	case api.ChangeEventIncidentResolved:
+		return "Resolved"
+	case api.ChangeEventIncidentDeleted:
+		return "Removed"
+	}
+	return "Opened"
+}
+
+// duration is how long the incident lasted, false if it is ongoing
+func (n Notification) duration() (string, bool) {
+	incident := n.incident()
+	if incident.EndTime == nil {
+		return "", false
+	}
+	return incident.EndTime.Sub(incident.StartTime).Round(time.Minute).String(), true
+}
+
+// latestUpdate returns the most recent update of the incident, false if it has none
+func (n Notification) latestUpdate() (api.IncidentUpdate, bool) {
+	var latest api.IncidentUpdate
+	found := false
+	for _, update := range n.Event.Incident.Events {
+		if !found || update.Time.After(latest.Time) {
+			latest = update
+			found = true
+		}
+	}
+	return latest, found
+}
+
+// Sender sends notifications to the channels of a chat service
+type Sender interface {
+	Name() string
+	Send(ctx context.Context, channel Channel, notification Notification) error
+}
+
+const (
+	// sendAttempts is how many times a notification is sent before it is dropped
+	sendAttempts = 3
+	sendBackoff  = 2 * time.Second
+	// statusPageCacheTTL is how long the status pages of the notifications are cached, they rarely change
+	statusPageCacheTTL = 5 * time.Minute
+	// maxErrorLength bounds the response body kept as the error of a failed post
+	maxErrorLength = 500
+)
+
+// Publisher is an outbox publisher that sends a notification of every change event to each channel it matches
+// Notifications are best effort: one that can't be sent after a few attempts is logged and dropped rather than failing
+// the batch, as the whole batch would be redelivered and the channels that were notified would be notified again
+// The notifications are deduplicated and throttled, the collapsed updates are sent once the publisher is started
+type Publisher struct {
+	logger      *zap.Logger
+	dbClient    *db.DbClient
+	sender      Sender
+	channels    []Channel
+	throttle    time.Duration
+	throttler   *throttle.Throttler
+	statusPages *statusPageLookup
+}
+
+func NewPublisher(logger *zap.Logger, dbClient *db.DbClient, sender Sender, channels []Channel, throttleWindow time.Duration) *Publisher {
+	return &Publisher{
+		logger:      logger,
+		dbClient:    dbClient,
+		sender:      sender,
+		channels:    channels,
+		throttle:    throttleWindow,
+		throttler:   throttle.NewThrottler(logger, dbClient, false),
+		statusPages: newStatusPageLookup(dbClient),
+	}
+}
+
+// destination is the key of the channel in the throttles, the webhook url is hashed as it is a secret
+func (p *Publisher) destination(channel Channel) string {
+	sum := sha256.Sum256([]byte(channel.WebhookURL))
+	return p.sender.Name() + " " + channel.Name + " " + hex.EncodeToString(sum[:16])
+}
+
+// NewPublishersFromConfig returns a publisher for every chat service that has channels configured
+func NewPublishersFromConfig(logger *zap.Logger, dbClient *db.DbClient, config Config) ([]*Publisher, error) {
+	var publishers []*Publisher
+	for _, configured := range []struct {
+		channels string
+		sender   Sender
+	}{
+		{channels: config.SlackChannels, sender: NewSlackSender(config)},
+		{channels: config.TeamsChannels, sender: NewTeamsSender(config)},
+		{channels: config.DiscordChannels, sender: NewDiscordSender(config)},
+	} {
+		if configured.channels == "" {
+			continue
+		}
+		channels, err := parseChannels(configured.channels)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the %s channels", configured.sender.Name())
+		}
+		publishers = append(publishers, NewPublisher(logger, dbClient, configured.sender, channels, config.Throttle))
+	}
+	return publishers, nil
+}
+
+// parseChannels parses a json list of channels and checks that they are complete
+func parseChannels(value string) ([]Channel, error) {
+	var channels []Channel
+	err := json.Unmarshal([]byte(value), &channels)
+	if err != nil {
+		return nil, err
+	}
+	for _, channel := range channels {
+		if channel.WebhookURL == "" {
+			return nil, errors.Errorf("channel %q has no webhookUrl", channel.Name)
+		}
+		err := channel.validate()
+		if err != nil {
+			return nil, errors.Wrapf(err, "channel %q", channel.Name)
+		}
+	}
+	return channels, nil
+}
+
+// postJSON posts the body to the webhook as json, a response other than a 2xx is an error
+func postJSON(ctx context.Context, httpClient *http.Client, url string, body interface{}) error {
+	_, err := postJSONWithHeaders(ctx, httpClient, url, nil, body)
+	return err
+}
+
+// postJSONWithHeaders posts the body as json with the headers and returns the status code of the response, zero if the
+// request failed before a response. A response other than a 2xx is an error
+func postJSONWithHeaders(ctx context.Context, httpClient *http.Client, url string, headers map[string]string, body interface{}) (int, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to marshal the message")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create the request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to make the request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorLength))
+		return resp.StatusCode, errors.Errorf("unexpected status code %d: %s", resp.StatusCode, respBody)
+	}
+	return resp.StatusCode, nil
+}
+
+func truncate(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+	return string(runes[:length-1]) + "…"
+}
+
+func (p *Publisher) Name() string {
+	return "notifier " + p.sender.Name()
+}
+
+func (p *Publisher) Publish(ctx context.Context, events []api.ChangeEvent) error {
+	for _, event := range events {
+		statusPage, err := p.statusPages.get(ctx, event.StatusPageUrl)
+		if err != nil {
+			return errors.Wrap(err, "failed to get the status page of the event")
+		}
+		// Sandbox status pages are synthetic, their incidents aren't worth a notification
+		if statusPage.IsSandbox {
+			continue
+		}
+		notification := Notification{Event: event, StatusPage: statusPage}
+		for _, channel := range p.channels {
+			if !channel.Matches(event, statusPage) {
+				continue
+			}
+			admitted, err := p.throttler.Admit(ctx, p.destination(channel), throttleWindow(channel.ThrottleSeconds, p.throttle), event, time.Now())
+			if err != nil {
+				return err
+			}
+			if !admitted {
+				continue
+			}
+			p.send(ctx, channel, notification)
+		}
+	}
+	return nil
+}
+
+// Start starts sending the collapsed updates once their window has passed, it runs until the context is cancelled
+func (p *Publisher) Start(ctx context.Context) {
+	channels := map[string]Channel{}
+	var destinations []string
+	for _, channel := range p.channels {
+		channels[p.destination(channel)] = channel
+		destinations = append(destinations, p.destination(channel))
+	}
+	go func() {
+		ticker := time.NewTicker(throttle.FlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.sendDue(ctx, destinations, channels)
+			}
+		}
+	}()
+}
+
+// sendDue sends the collapsed updates of the channels whose window has passed
+func (p *Publisher) sendDue(ctx context.Context, destinations []string, channels map[string]Channel) {
+	due, err := p.throttler.Due(ctx, destinations, time.Now())
+	if err != nil {
+		p.logger.Error("failed to get the collapsed notifications", zap.String("sender", p.sender.Name()), zap.Error(err))
+		return
+	}
+	for _, collapsed := range due {
+		notification, err := collapsedNotification(ctx, p.statusPages, collapsed)
+		if err != nil {
+			p.logger.Error("failed to get the status page of the collapsed notification", zap.Uint64("event", collapsed.Event.ID), zap.Error(err))
+			continue
+		}
+		p.send(ctx, channels[collapsed.Destination], notification)
+	}
+}
+
+// collapsedNotification is the notification of the updates that were held back
+func collapsedNotification(ctx context.Context, statusPages *statusPageLookup, collapsed throttle.Collapsed) (Notification, error) {
+	statusPage, err := statusPages.get(ctx, collapsed.Event.StatusPageUrl)
+	if err != nil {
+		return Notification{}, err
+	}
+	return Notification{Event: collapsed.Event, StatusPage: statusPage, Updates: collapsed.Updates}, nil
+}
+
+func (p *Publisher) send(ctx context.Context, channel Channel, notification Notification) {
+	err := retry(ctx, func() error {
+		return p.sender.Send(ctx, channel, notification)
+	})
+	recordOutcome(ctx, p.logger, p.dbClient, p.Name(), err)
+	if err != nil {
+		p.logger.Error("failed to send notification", zap.String("sender", p.sender.Name()), zap.String("channel", channel.Name), zap.Uint64("event", notification.Event.ID), zap.Error(err))
+		return
+	}
+	p.logger.Info("sent notification", zap.String("sender", p.sender.Name()), zap.String("channel", channel.Name), zap.String("type", string(notification.Event.Type)), zap.Int("updates", notification.Updates), zap.String("deepLink", notification.Event.DeepLink))
+}
+
+// recordOutcome counts whether a notification of the notifier was delivered for the operator summary, a failure to count
+// it is only logged
+func recordOutcome(ctx context.Context, logger *zap.Logger, dbClient *db.DbClient, notifier string, err error) {
+	recordErr := dbClient.RecordNotificationOutcome(ctx, notifier, err == nil, time.Now())
+	if recordErr != nil {
+		logger.Warn("failed to record notification outcome", zap.String("notifier", notifier), zap.Error(recordErr))
+	}
+}
+
+// retry makes up to sendAttempts attempts at sending a notification, waiting longer after each failure
+func retry(ctx context.Context, send func() error) error {
+	var err error
+	for attempt := 1; attempt <= sendAttempts; attempt++ {
+		err = send()
+		if err == nil || attempt == sendAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * sendBackoff):
+		}
+	}
+	return err
+}
+
+// statusPageLookup caches the status pages of the notifications
+type statusPageLookup struct {
+	dbClient *db.DbClient
+	cache    *cache.Cache
+}
+
+func newStatusPageLookup(dbClient *db.DbClient) *statusPageLookup {
+	return &statusPageLookup{dbClient: dbClient, cache: cache.New(statusPageCacheTTL, statusPageCacheTTL)}
+}
+
+// get returns the status page with the url, a status page with only the url if it has since been deleted
+func (l *statusPageLookup) get(ctx context.Context, url string) (api.StatusPage, error) {
+	if cached, found := l.cache.Get(url); found {
+		return cached.(api.StatusPage), nil
+	}
+	statusPage, err := l.dbClient.GetStatusPage(ctx, url)
+	if err != nil {
+		return api.StatusPage{}, err
+	}
+	if statusPage == nil {
+		statusPage = &api.StatusPage{URL: url, Name: url}
+	}
+	l.cache.Set(url, *statusPage, cache.DefaultExpiration)
+	return *statusPage, nil
+}
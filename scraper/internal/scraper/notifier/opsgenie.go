@@ -0,0 +1,86 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strings"
+)
+
+const opsgenieAPIURL = "https://api.opsgenie.com"
+
+// The limits of Opsgenie on the message and description of an alert
+const (
+	opsgenieMaxMessageLength     = 130
+	opsgenieMaxDescriptionLength = 15000
+)
+
+// opsgeniePriorities are the Opsgenie priorities of api.ImpactsBySeverity
+var opsgeniePriorities = []string{"P5", "P4", "P3", "P2", "P1"}
+
+// Opsgenie creates and closes alerts with the Alert API, an incident is deduplicated into a single alert by its alias
+type Opsgenie struct {
+	httpClient *http.Client
+}
+
+func NewOpsgenie(config Config) *Opsgenie {
+	return &Opsgenie{httpClient: &http.Client{Timeout: config.Timeout}}
+}
+
+func (o *Opsgenie) Name() string {
+	return "opsgenie"
+}
+
+func (o *Opsgenie) Trigger(ctx context.Context, service Service, notification Notification) error {
+	incident := notification.incident()
+	description := fmt.Sprintf("%s impact on %s since %s\n%s", incident.Impact, notification.name(), incident.StartTime.UTC().Format("Jan 2, 2006 15:04 UTC"), incident.DeepLink)
+	if update, found := notification.latestUpdate(); found {
+		description += fmt.Sprintf("\n\nLatest update (%s): %s", update.State, update.Body)
+	}
+	details := map[string]string{
+		"statusPageUrl": incident.StatusPageUrl,
+		"deepLink":      incident.DeepLink,
+		"impact":        string(incident.Impact),
+		"change":        notification.state(),
+	}
+	if len(incident.Components) > 0 {
+		details["components"] = strings.Join(incident.Components, ", ")
+	}
+	_, err := postJSONWithHeaders(ctx, o.httpClient, o.url(service, "/v2/alerts"), o.headers(service), map[string]interface{}{
+		"message":     truncate(notification.name()+": "+incident.Title, opsgenieMaxMessageLength),
+		"alias":       alertKey(incident.DeepLink),
+		"description": truncate(description, opsgenieMaxDescriptionLength),
+		"priority":    opsgeniePriorities[severityIndex(incident.Impact)],
+		"source":      "statusphere",
+		"entity":      notification.name(),
+		"tags":        []string{"statusphere", "vendor-incident", string(incident.Impact)},
+		"details":     details,
+	})
+	return err
+}
+
+func (o *Opsgenie) Resolve(ctx context.Context, service Service, notification Notification) error {
+	path := "/v2/alerts/" + neturl.PathEscape(alertKey(notification.Event.DeepLink)) + "/close?identifierType=alias"
+	statusCode, err := postJSONWithHeaders(ctx, o.httpClient, o.url(service, path), o.headers(service), map[string]string{
+		"source": "statusphere",
+		"note":   notification.name() + " " + strings.ToLower(notification.state()) + " the incident",
+	})
+	// The alert was never created, e.g. the incident wasn't severe enough to page
+	if statusCode == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+func (o *Opsgenie) url(service Service, path string) string {
+	base := opsgenieAPIURL
+	if service.APIURL != "" {
+		base = strings.TrimSuffix(service.APIURL, "/")
+	}
+	return base + path
+}
+
+func (o *Opsgenie) headers(service Service) map[string]string {
+	return map[string]string{"Authorization": "GenieKey " + service.Key}
+}
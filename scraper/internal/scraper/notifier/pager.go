@@ -0,0 +1,147 @@
+package notifier
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Service is an on-call service that is paged for the incidents that match its filter, the impacts default to critical
+// The alert is resolved when the incident is resolved, whatever its impact by then
+type Service struct {
+	// Name identifies the service in the logs
+	Name string `json:"name"`
+	// Key is the integration key, the routing key of a PagerDuty service or the api key of an Opsgenie integration
+	Key string `json:"key"`
+	// APIURL replaces the default api of the pager, e.g. https://api.eu.opsgenie.com for the EU instance of Opsgenie
+	APIURL string `json:"apiUrl"`
+	Filter
+}
+
+// Pager opens and resolves alerts, both have to be idempotent as an incident can change more than once
+type Pager interface {
+	Name() string
+	Trigger(ctx context.Context, service Service, notification Notification) error
+	Resolve(ctx context.Context, service Service, notification Notification) error
+}
+
+// PagerPublisher is an outbox publisher that triggers an alert for the incidents that open or change and resolves it when
+// they are resolved. Like the other notifications the alerts are best effort
+type PagerPublisher struct {
+	logger      *zap.Logger
+	dbClient    *db.DbClient
+	pager       Pager
+	services    []Service
+	statusPages *statusPageLookup
+}
+
+func NewPagerPublisher(logger *zap.Logger, dbClient *db.DbClient, pager Pager, services []Service) *PagerPublisher {
+	return &PagerPublisher{
+		logger:      logger,
+		dbClient:    dbClient,
+		pager:       pager,
+		services:    services,
+		statusPages: newStatusPageLookup(dbClient),
+	}
+}
+
+// NewPagerPublishersFromConfig returns a publisher for every pager that has services configured
+func NewPagerPublishersFromConfig(logger *zap.Logger, dbClient *db.DbClient, config Config) ([]*PagerPublisher, error) {
+	var publishers []*PagerPublisher
+	for _, configured := range []struct {
+		services string
+		pager    Pager
+	}{
+		{services: config.PagerDutyServices, pager: NewPagerDuty(config)},
+		{services: config.OpsgenieIntegrations, pager: NewOpsgenie(config)},
+	} {
+		if configured.services == "" {
+			continue
+		}
+		var services []Service
+		err := json.Unmarshal([]byte(configured.services), &services)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the %s services", configured.pager.Name())
+		}
+		for i, service := range services {
+			if service.Key == "" {
+				return nil, errors.Errorf("%s service %q has no key", configured.pager.Name(), service.Name)
+			}
+			err = service.validate()
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s service %q", configured.pager.Name(), service.Name)
+			}
+			if len(service.Impacts) == 0 {
+				services[i].Impacts = []api.Impact{api.ImpactCritical}
+			}
+		}
+		publishers = append(publishers, NewPagerPublisher(logger, dbClient, configured.pager, services))
+	}
+	return publishers, nil
+}
+
+func (p *PagerPublisher) Name() string {
+	return "pager " + p.pager.Name()
+}
+
+func (p *PagerPublisher) Publish(ctx context.Context, events []api.ChangeEvent) error {
+	for _, event := range events {
+		statusPage, err := p.statusPages.get(ctx, event.StatusPageUrl)
+		if err != nil {
+			return errors.Wrap(err, "failed to get the status page of the event")
+		}
+		if statusPage.IsSandbox {
+			continue
+		}
+		notification := Notification{Event: event, StatusPage: statusPage}
+		resolve := event.Type == api.ChangeEventIncidentResolved || event.Type == api.ChangeEventIncidentDeleted
+		// An incident that is already over, e.g. one that was first scraped or imported after it ended, would open an
+		// alert that no resolution ever follows
+		if !resolve && event.Incident.EndTime != nil {
+			continue
+		}
+		for _, service := range p.services {
+			if resolve {
+				// The impact may have been lowered since the alert was triggered, resolving an alert that was never
+				// triggered does nothing
+				unfiltered := service.Filter
+				unfiltered.Impacts = nil
+				if !unfiltered.matchesIncident(notification.incident(), statusPage) {
+					continue
+				}
+			} else if !service.Matches(event, statusPage) {
+				continue
+			}
+			err := retry(ctx, func() error {
+				if resolve {
+					return p.pager.Resolve(ctx, service, notification)
+				}
+				return p.pager.Trigger(ctx, service, notification)
+			})
+			recordOutcome(ctx, p.logger, p.dbClient, p.Name(), err)
+			if err != nil {
+				p.logger.Error("failed to page", zap.String("pager", p.pager.Name()), zap.String("service", service.Name), zap.Uint64("event", event.ID), zap.Error(err))
+				continue
+			}
+			p.logger.Info("paged", zap.String("pager", p.pager.Name()), zap.String("service", service.Name), zap.Bool("resolve", resolve), zap.String("deepLink", event.DeepLink))
+		}
+	}
+	return nil
+}
+
+// alertKey is the key that deduplicates the alerts of an incident, a deep link can be longer than the pagers allow
+func alertKey(deepLink string) string {
+	sum := sha256.Sum256([]byte(deepLink))
+	return "statusphere-" + hex.EncodeToString(sum[:16])
+}
+
+// severityIndex is the position of the impact in api.ImpactsBySeverity, which the pagers map to their own levels,
+// the least severe for an unknown impact
+func severityIndex(impact api.Impact) int {
+	return max(impact.Severity(), 0)
+}
@@ -0,0 +1,85 @@
+package notifier
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// pagerDutyMaxSummaryLength is the limit of PagerDuty on the summary of an event
+const pagerDutyMaxSummaryLength = 1024
+
+// pagerDutySeverities are the PagerDuty severities of api.ImpactsBySeverity
+var pagerDutySeverities = []string{"info", "info", "warning", "error", "critical"}
+
+// PagerDuty triggers and resolves alerts with the Events API v2, an incident is deduplicated into a single alert
+type PagerDuty struct {
+	httpClient *http.Client
+}
+
+func NewPagerDuty(config Config) *PagerDuty {
+	return &PagerDuty{httpClient: &http.Client{Timeout: config.Timeout}}
+}
+
+func (p *PagerDuty) Name() string {
+	return "pagerduty"
+}
+
+func (p *PagerDuty) Trigger(ctx context.Context, service Service, notification Notification) error {
+	incident := notification.incident()
+	details := map[string]interface{}{
+		"statusPageUrl": incident.StatusPageUrl,
+		"impact":        incident.Impact,
+		"startTime":     incident.StartTime,
+		"change":        notification.state(),
+	}
+	if len(incident.Components) > 0 {
+		details["components"] = incident.Components
+	}
+	if update, found := notification.latestUpdate(); found {
+		details["latestUpdate"] = update.Body
+	}
+	payload := map[string]interface{}{
+		"summary":        truncate(notification.name()+": "+incident.Title, pagerDutyMaxSummaryLength),
+		"source":         incident.StatusPageUrl,
+		"severity":       pagerDutySeverities[severityIndex(incident.Impact)],
+		"timestamp":      incident.StartTime.UTC().Format(time.RFC3339),
+		"group":          notification.name(),
+		"class":          "vendor incident",
+		"custom_details": details,
+	}
+	if len(incident.Components) > 0 {
+		payload["component"] = strings.Join(incident.Components, ", ")
+	}
+	return p.send(ctx, service, map[string]interface{}{
+		"routing_key":  service.Key,
+		"event_action": "trigger",
+		"dedup_key":    alertKey(incident.DeepLink),
+		"payload":      payload,
+		"links": []map[string]string{
+			{"href": incident.DeepLink, "text": "View the incident"},
+			{"href": incident.StatusPageUrl, "text": notification.name() + " status page"},
+		},
+		"client":     "statusphere",
+		"client_url": incident.StatusPageUrl,
+	})
+}
+
+func (p *PagerDuty) Resolve(ctx context.Context, service Service, notification Notification) error {
+	return p.send(ctx, service, map[string]interface{}{
+		"routing_key":  service.Key,
+		"event_action": "resolve",
+		"dedup_key":    alertKey(notification.Event.DeepLink),
+	})
+}
+
+func (p *PagerDuty) send(ctx context.Context, service Service, event map[string]interface{}) error {
+	url := pagerDutyEventsURL
+	if service.APIURL != "" {
+		url = service.APIURL
+	}
+	return postJSON(ctx, p.httpClient, url, event)
+}
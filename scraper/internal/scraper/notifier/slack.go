@@ -0,0 +1,96 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// slackMaxHeaderLength and slackMaxTextLength are the limits of Slack on the text of header and section blocks
+const (
+	slackMaxHeaderLength = 150
+	slackMaxTextLength   = 3000
+)
+
+// SlackSender posts notifications as Block Kit messages to the incoming webhooks of Slack channels
+type SlackSender struct {
+	httpClient *http.Client
+}
+
+func NewSlackSender(config Config) *SlackSender {
+	return &SlackSender{httpClient: &http.Client{Timeout: config.Timeout}}
+}
+
+func (s *SlackSender) Name() string {
+	return "slack"
+}
+
+func (s *SlackSender) Send(ctx context.Context, channel Channel, notification Notification) error {
+	return postJSON(ctx, s.httpClient, channel.WebhookURL, slackMessage(notification))
+}
+
+// slackMessage is the Block Kit message of a notification, text is shown where the blocks can't be, e.g. in push
+// notifications
+func slackMessage(notification Notification) map[string]interface{} {
+	incident := notification.incident()
+	name := notification.name()
+	state := notification.state()
+	emoji := ":red_circle:"
+	switch notification.Event.Type {
+	case api.ChangeEventIncidentUpdated:
+		emoji = ":large_orange_circle:"
+	case api.ChangeEventIncidentResolved:
+		emoji = ":large_green_circle:"
+	case api.ChangeEventIncidentDeleted:
+		emoji = ":white_circle:"
+	}
+	if incident.Impact == api.ImpactMaintenance && notification.Event.Type != api.ChangeEventIncidentResolved {
+		emoji = ":wrench:"
+	}
+
+	fields := []map[string]string{
+		slackField("Status", state),
+		slackField("Impact", string(incident.Impact)),
+		slackField("Started", slackDate(incident.StartTime)),
+	}
+	if duration, ended := notification.duration(); ended {
+		fields = append(fields, slackField("Duration", duration))
+	}
+	if len(incident.Components) > 0 {
+		fields = append(fields, slackField("Components", slackEscape(strings.Join(incident.Components, ", "))))
+	}
+
+	blocks := []map[string]interface{}{
+		{"type": "header", "text": map[string]interface{}{"type": "plain_text", "text": truncate(name+": "+incident.Title, slackMaxHeaderLength), "emoji": true}},
+		{"type": "section", "fields": fields},
+	}
+	if update, found := notification.latestUpdate(); found {
+		text := fmt.Sprintf("*Latest update* (%s, %s)\n%s", update.State, slackDate(update.Time), slackEscape(update.Body))
+		blocks = append(blocks, map[string]interface{}{"type": "section", "text": map[string]string{"type": "mrkdwn", "text": truncate(text, slackMaxTextLength)}})
+	}
+	blocks = append(blocks, map[string]interface{}{
+		"type":     "context",
+		"elements": []map[string]string{{"type": "mrkdwn", "text": fmt.Sprintf("<%s|View the incident> on <%s|%s>", incident.DeepLink, incident.StatusPageUrl, slackEscape(name))}},
+	})
+	return map[string]interface{}{
+		"text":   fmt.Sprintf("%s %s: %s (%s, %s)", emoji, slackEscape(name), slackEscape(incident.Title), state, incident.Impact),
+		"blocks": blocks,
+	}
+}
+
+func slackField(title string, value string) map[string]string {
+	return map[string]string{"type": "mrkdwn", "text": "*" + title + "*\n" + value}
+}
+
+// slackDate formats the time in the time zone of the reader, with the UTC time as the fallback
+func slackDate(t time.Time) string {
+	return fmt.Sprintf("<!date^%d^{date_short_pretty} {time}|%s>", t.Unix(), t.UTC().Format("Jan 2, 2006 15:04 UTC"))
+}
+
+// slackEscape escapes the characters that Slack treats as markup in text
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
@@ -0,0 +1,95 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// teamsMaxTextLength keeps the latest update well under the size limit of a Teams message
+const teamsMaxTextLength = 4000
+
+// TeamsSender posts notifications as Adaptive Cards to the incoming webhooks of Microsoft Teams channels, either the
+// webhooks of the Workflows app or the legacy connectors
+type TeamsSender struct {
+	httpClient *http.Client
+}
+
+func NewTeamsSender(config Config) *TeamsSender {
+	return &TeamsSender{httpClient: &http.Client{Timeout: config.Timeout}}
+}
+
+func (t *TeamsSender) Name() string {
+	return "teams"
+}
+
+func (t *TeamsSender) Send(ctx context.Context, channel Channel, notification Notification) error {
+	return postJSON(ctx, t.httpClient, channel.WebhookURL, map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content":     teamsCard(notification),
+		}},
+	})
+}
+
+// teamsCard is the Adaptive Card of a notification
+func teamsCard(notification Notification) map[string]interface{} {
+	incident := notification.incident()
+	color := "Attention"
+	switch notification.Event.Type {
+	case api.ChangeEventIncidentUpdated:
+		color = "Warning"
+	case api.ChangeEventIncidentResolved:
+		color = "Good"
+	case api.ChangeEventIncidentDeleted:
+		color = "Default"
+	}
+	if incident.Impact == api.ImpactMaintenance && notification.Event.Type != api.ChangeEventIncidentResolved {
+		color = "Accent"
+	}
+
+	facts := []map[string]string{
+		{"title": "Status", "value": notification.state()},
+		{"title": "Impact", "value": string(incident.Impact)},
+		// The facts can't format dates in the time zone of the reader, only the text blocks can
+		{"title": "Started", "value": incident.StartTime.UTC().Format("Jan 2, 2006 15:04 UTC")},
+	}
+	if duration, ended := notification.duration(); ended {
+		facts = append(facts, map[string]string{"title": "Duration", "value": duration})
+	}
+	if len(incident.Components) > 0 {
+		facts = append(facts, map[string]string{"title": "Components", "value": strings.Join(incident.Components, ", ")})
+	}
+
+	body := []map[string]interface{}{
+		{"type": "TextBlock", "text": notification.name() + ": " + incident.Title, "weight": "Bolder", "size": "Medium", "color": color, "wrap": true},
+		{"type": "FactSet", "facts": facts},
+	}
+	if update, found := notification.latestUpdate(); found {
+		body = append(body, map[string]interface{}{
+			"type": "TextBlock",
+			"text": truncate(fmt.Sprintf("**Latest update** (%s, %s)\n\n%s", update.State, teamsDate(update.Time), update.Body), teamsMaxTextLength),
+			"wrap": true,
+		})
+	}
+	return map[string]interface{}{
+		"type":    "AdaptiveCard",
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"version": "1.4",
+		"body":    body,
+		"actions": []map[string]string{
+			{"type": "Action.OpenUrl", "title": "View the incident", "url": incident.DeepLink},
+			{"type": "Action.OpenUrl", "title": "Open the status page", "url": incident.StatusPageUrl},
+		},
+	}
+}
+
+// teamsDate formats the time in the time zone of the reader, it is only formatted in text blocks
+func teamsDate(t time.Time) string {
+	formatted := t.UTC().Format(time.RFC3339)
+	return fmt.Sprintf("{{DATE(%s, SHORT)}} {{TIME(%s)}}", formatted, formatted)
+}
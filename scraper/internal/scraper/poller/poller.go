@@ -0,0 +1,640 @@
+package poller
+
+import (
+	"context"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/consumers"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/contenthash"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/language"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/locker"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/redirect"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/tracing"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/urlgetter"
+	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	neturl "net/url"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	// Workers is the number of scrapes that run at once, current and historical scrapes share the workers
+	// When more status pages are due than there are idle workers, the ones with the highest scrape priority are started first
+	Workers int `envconfig:"SCRAPER_MAX_CONCURRENT_SCRAPES" default:"50"`
+	// WorkersPerHost is the number of scrapes that run at once against a single host
+	WorkersPerHost int `envconfig:"SCRAPER_MAX_CONCURRENT_SCRAPES_PER_HOST" default:"2"`
+	// SkipUnchanged skips processing the incidents of a current scrape that fetched the same content as the last one
+	SkipUnchanged bool `envconfig:"SCRAPER_SKIP_UNCHANGED" default:"true"`
+	// UnchangedMaxAge is how long the incidents of an unchanged status page can go unprocessed,
+	// so that parser fixes and incidents that age into being resolved still reach it
+	UnchangedMaxAge time.Duration `envconfig:"SCRAPER_UNCHANGED_MAX_AGE" default:"1h"`
+}
+
+func GetConfigFromEnvironment() (Config, error) {
+	var config Config
+	err := envconfig.Process("STATUSPHERE", &config)
+	return config, err
+}
+
+// scrapeJob is a single scrape that is handed to a worker
+type scrapeJob struct {
+	url        string
+	historical bool
+	backfill   bool
+}
+
+type Poller struct {
+	config                              Config
+	urlGetter                           urlgetter.URLGetter
+	scraper                             scraper.Scraper
+	consumers                           []consumers.Consumer
+	locker                              locker.Locker
+	annotator                           *language.Annotator
+	currentlyExecutingScrapes           *cache.Cache
+	currentlyExecutingHistoricalScrapes *cache.Cache
+	// failedDetections holds the status pages whose provider couldn't be detected until they are due to be tried again
+	failedDetections *cache.Cache
+	logger           *zap.Logger
+	// jobs is unbuffered so a job is only sent when a worker is idle
+	jobs chan scrapeJob
+	// hostsMu guards scrapesPerHost, which counts the running scrapes of each host
+	hostsMu        sync.Mutex
+	scrapesPerHost map[string]int
+}
+
+func NewPoller(config Config, urlGetter urlgetter.URLGetter, scraper scraper.Scraper, consumers []consumers.Consumer, locker locker.Locker, annotator *language.Annotator, logger *zap.Logger) *Poller {
+	if config.Workers < 1 {
+		config.Workers = 1
+	}
+	if config.WorkersPerHost < 1 {
+		config.WorkersPerHost = 1
+	}
+	return &Poller{
+		config:                              config,
+		urlGetter:                           urlGetter,
+		scraper:                             scraper,
+		consumers:                           consumers,
+		locker:                              locker,
+		annotator:                           annotator,
+		currentlyExecutingScrapes:           cache.New(cache.NoExpiration, cache.NoExpiration),
+		currentlyExecutingHistoricalScrapes: cache.New(cache.NoExpiration, cache.NoExpiration),
+		failedDetections:                    cache.New(detectionRetryInterval, detectionRetryInterval),
+		logger:                              logger,
+		jobs:                                make(chan scrapeJob),
+		scrapesPerHost:                      make(map[string]int),
+	}
+}
+
+// Poll polls the scraper and sends the incidents to the consumers
+// It blocks forever unless an unrecoverable error occurs
+func (p *Poller) Poll() error {
+	for i := 0; i < p.config.Workers; i++ {
+		go p.worker()
+	}
+
+	ticker := time.NewTicker(1 * time.Second)
+	for {
+		select {
+		case <-ticker.C:
+			// Current scrapes are dispatched first so they are not starved by the long running historical scrapes
+			err := p.pollInner()
+			if err != nil {
+				p.logger.Error("failed to poll", zap.Error(err))
+			}
+			err = p.pollInnerHistorical()
+			if err != nil {
+				p.logger.Error("failed to poll", zap.Error(err))
+			}
+			err = p.pollInnerBackfill()
+			if err != nil {
+				p.logger.Error("failed to poll", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (p *Poller) worker() {
+	for job := range p.jobs {
+		switch {
+		case job.backfill:
+			p.backfill(job.url)
+		case job.historical:
+			p.scrapeHistorical(job.url)
+		default:
+			p.scrape(job.url)
+		}
+		p.releaseHost(job.url)
+	}
+}
+
+// dispatch hands the job to an idle worker
+// The job is not dispatched if its host is at its limit, or if every worker is busy in which case workersBusy is true
+func (p *Poller) dispatch(job scrapeJob) (dispatched bool, workersBusy bool) {
+	host := hostOf(job.url)
+	p.hostsMu.Lock()
+	if p.scrapesPerHost[host] >= p.config.WorkersPerHost {
+		p.hostsMu.Unlock()
+		return false, false
+	}
+	p.scrapesPerHost[host]++
+	p.hostsMu.Unlock()
+
+	select {
+	case p.jobs <- job:
+		return true, false
+	default:
+		p.releaseHost(job.url)
+		return false, true
+	}
+}
+
+func (p *Poller) releaseHost(url string) {
+	host := hostOf(url)
+	p.hostsMu.Lock()
+	defer p.hostsMu.Unlock()
+	p.scrapesPerHost[host]--
+	if p.scrapesPerHost[host] <= 0 {
+		delete(p.scrapesPerHost, host)
+	}
+}
+
+func hostOf(rawUrl string) string {
+	parsed, err := neturl.Parse(rawUrl)
+	if err != nil || parsed.Host == "" {
+		return rawUrl
+	}
+	return parsed.Host
+}
+
+func (p *Poller) pollInner() error {
+	urlsToScrape, err := p.urlGetter.GetUrlsToScrape()
+	if err != nil {
+		return err
+	}
+
+	// The urls are ordered by priority so the ones left over wait for the next poll
+	for i, url := range urlsToScrape {
+		if _, found := p.currentlyExecutingScrapes.Get(url); found {
+			p.release(url)
+			continue
+		}
+		p.currentlyExecutingScrapes.Set(url, true, cache.NoExpiration)
+		dispatched, workersBusy := p.dispatch(scrapeJob{url: url})
+		if !dispatched {
+			p.currentlyExecutingScrapes.Delete(url)
+			p.release(url)
+		}
+		if workersBusy {
+			for _, leftOver := range urlsToScrape[i+1:] {
+				p.release(leftOver)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// release hands a url that was given to this scraper back, if the url getter hands each url to a single scraper
+func (p *Poller) release(url string) {
+	releaser, ok := p.urlGetter.(urlgetter.Releaser)
+	if !ok {
+		return
+	}
+	err := releaser.Release(url)
+	if err != nil {
+		p.logger.Error("failed to release url", zap.Error(err), zap.String("url", url))
+	}
+}
+
+func (p *Poller) scrape(url string) {
+	defer p.currentlyExecutingScrapes.Delete(url)
+	// Released after the scrape result is recorded, so the url is no longer due when another scraper can take it
+	defer p.release(url)
+	_, _ = p.runScrape(url, false)
+}
+
+// ErrScrapeInProgress is returned by ScrapeNow when the status page is already being scraped
+var ErrScrapeInProgress = errors.New("the status page is already being scraped")
+
+// ScrapeNow scrapes the status page immediately, out of band of its schedule, and returns the scraped incidents
+// The incidents are processed even if the status page is unchanged since its last scrape
+func (p *Poller) ScrapeNow(url string) ([]api.Incident, error) {
+	// Add fails if a scheduled scrape of the status page is running
+	err := p.currentlyExecutingScrapes.Add(url, true, cache.NoExpiration)
+	if err != nil {
+		return nil, ErrScrapeInProgress
+	}
+	defer p.currentlyExecutingScrapes.Delete(url)
+	return p.runScrape(url, true)
+}
+
+// runScrape scrapes the current incidents of the status page and records the result
+// force processes the incidents of a status page that is unchanged since its last scrape
+func (p *Poller) runScrape(url string, force bool) ([]api.Incident, error) {
+	// Another replica may already be scraping this page
+	release, acquired, err := p.locker.AcquireScrapeLock(context.Background(), url)
+	if err != nil {
+		p.logger.Error("failed to acquire scrape lock", zap.Error(err), zap.String("url", url))
+		return nil, err
+	}
+	if !acquired {
+		p.logger.Debug("scrape lock held by another scraper", zap.String("url", url))
+		return nil, ErrScrapeInProgress
+	}
+	defer release()
+	p.logger.Info("scraping", zap.String("url", url))
+	defer p.logger.Info("finished scraping", zap.String("url", url))
+	start := time.Now()
+	ctx, redirects := redirect.WithRecorder(p.scrapeContext(url))
+	ctx, span := tracing.StartScrape(ctx, "current", url)
+	incidents, err := p.executeScrape(ctx, url, force)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+	result := urlgetter.ScrapeResult{Time: time.Now(), Duration: time.Since(start), Err: err}
+	if err != nil {
+		result.Gone = p.isGone(url)
+	}
+	recordErr := p.urlGetter.RecordScrapeResult(url, result)
+	if recordErr != nil {
+		p.logger.Error("failed to record scrape result", zap.Error(recordErr), zap.String("url", url))
+	}
+	if err != nil {
+		p.logger.Error("failed to scrape", zap.Error(err), zap.String("url", url))
+		return nil, err
+	}
+	p.moveIfRedirected(url, redirects)
+	p.refreshMetadata(url)
+	return incidents, nil
+}
+
+func (p *Poller) executeScrape(ctx context.Context, url string, force bool) ([]api.Incident, error) {
+	ctx, recorder := contenthash.WithRecorder(ctx, p.urlGetter.Location(url).String())
+	incidents, err := p.scraper.ScrapeStatusPageCurrent(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	hash := recorder.Sum()
+	if !force && p.unchanged(url, hash) {
+		// The components and status are fetched separately so they are still scraped
+		p.logger.Debug("status page unchanged, skipping its incidents", zap.String("url", url))
+		p.scrapeComponents(url)
+		p.snapshotStatus(url, incidents)
+		return incidents, nil
+	}
+	p.annotator.Annotate(context.Background(), incidents)
+	_, persist := tracing.Tracer.Start(ctx, tracing.StagePersist)
+	defer persist.End()
+	for _, consumer := range p.consumers {
+		err := consumer.Consume(incidents)
+		if err != nil {
+			return nil, err
+		}
+	}
+	p.resolveVanished(url, incidents, time.Now())
+	persist.End()
+	p.scrapeComponents(url)
+	p.snapshotStatus(url, incidents)
+
+	// The scraped incidents rather than the stored ones are used, as some providers only return the ongoing incidents
+	// and never see them resolved
+	err = p.urlGetter.UpdateHasActiveIncident(url, hasActiveIncident(incidents))
+	if err != nil {
+		p.logger.Error("failed to update active incident", zap.Error(err), zap.String("url", url))
+	}
+
+	// The hash is only recorded once the incidents are stored, so a scrape whose consumers failed is processed again
+	if hash != "" {
+		err = p.urlGetter.UpdateContentHash(url, hash, time.Now())
+		if err != nil {
+			p.logger.Error("failed to update content hash", zap.Error(err), zap.String("url", url))
+		}
+	}
+	return incidents, nil
+}
+
+// unchanged returns true if the scrape fetched the same content as the last scrape whose incidents were processed
+func (p *Poller) unchanged(url string, hash string) bool {
+	if !p.config.SkipUnchanged || hash == "" {
+		return false
+	}
+	previous, hashedAt := p.urlGetter.ContentHash(url)
+	return previous == hash && time.Since(hashedAt) < p.config.UnchangedMaxAge
+}
+
+// moveIfRedirected moves a status page whose scrape followed a permanent redirect to the url it redirects to,
+// so the incidents scraped from then on aren't stored under the old url
+func (p *Poller) moveIfRedirected(url string, redirects *redirect.Recorder) {
+	canonicalUrl := redirects.Target(url)
+	if canonicalUrl == "" {
+		return
+	}
+	p.logger.Info("status page permanently redirects, moving it", zap.String("url", url), zap.String("canonicalUrl", canonicalUrl))
+	err := p.urlGetter.MoveStatusPage(url, canonicalUrl, time.Now())
+	if err != nil {
+		p.logger.Error("failed to move status page", zap.Error(err), zap.String("url", url), zap.String("canonicalUrl", canonicalUrl))
+	}
+}
+
+// metadataRefreshInterval is how often the metadata of a status page is read again, in case the vendor has rebranded
+const metadataRefreshInterval = 7 * 24 * time.Hour
+
+// refreshMetadata reads the metadata of a status page that was just scraped if it hasn't been read recently
+// A failed read isn't recorded so it is tried again on the next scrape
+func (p *Poller) refreshMetadata(url string) {
+	refreshedAt := time.Now()
+	if refreshedAt.Sub(p.urlGetter.MetadataRefreshedAt(url)) < metadataRefreshInterval {
+		return
+	}
+	metadata, err := p.scraper.FetchMetadata(context.Background(), url)
+	if err != nil {
+		p.logger.Debug("failed to read the metadata of the status page", zap.Error(err), zap.String("url", url))
+		return
+	}
+	err = p.urlGetter.UpdateMetadata(url, metadata, refreshedAt)
+	if err != nil {
+		p.logger.Error("failed to update metadata", zap.Error(err), zap.String("url", url))
+	}
+}
+
+// isGone checks whether a status page that failed to scrape no longer exists
+func (p *Poller) isGone(url string) bool {
+	gone, err := p.scraper.IsGone(context.Background(), url)
+	if err != nil {
+		p.logger.Debug("failed to check whether the status page is gone", zap.Error(err), zap.String("url", url))
+		return false
+	}
+	return gone
+}
+
+// scrapeContext returns the context that the status page is scraped with
+// It carries the timezone the status page prints local times in so providers can parse them,
+// and the provider of the status page, which is detected the first time the status page is scraped
+// A status page whose scrape config has selectors is scraped with them instead
+func (p *Poller) scrapeContext(url string) context.Context {
+	ctx := providers.WithLocation(context.Background(), p.urlGetter.Location(url))
+	if config := p.urlGetter.ScrapeConfig(url); config != nil && len(config.Selectors) > 0 {
+		return providers.WithSelectors(ctx, config.Selectors)
+	}
+	return providers.WithHint(ctx, p.provider(url))
+}
+
+// detectionRetryInterval is how long after failing to detect the provider of a status page it is detected again
+const detectionRetryInterval = time.Hour
+
+// provider returns the provider of the status page, detecting it if it isn't known
+// The providers are matched on every scrape while it can't be detected
+func (p *Poller) provider(url string) string {
+	provider := p.urlGetter.Provider(url)
+	if provider != "" {
+		return provider
+	}
+	if _, failed := p.failedDetections.Get(url); failed {
+		return ""
+	}
+	provider, err := p.scraper.DetectProvider(context.Background(), url)
+	if err != nil {
+		p.logger.Warn("failed to detect the provider of the status page", zap.Error(err), zap.String("url", url))
+		p.failedDetections.Set(url, true, cache.DefaultExpiration)
+		return ""
+	}
+	p.logger.Info("detected the provider of the status page", zap.String("url", url), zap.String("provider", provider))
+	err = p.urlGetter.UpdateProvider(url, provider, time.Now())
+	if err != nil {
+		p.logger.Error("failed to update provider", zap.Error(err), zap.String("url", url))
+	}
+	return provider
+}
+
+// scrapeComponents updates the component list of the status page
+// Failing to get the components doesn't fail the scrape, the incidents have already been stored
+func (p *Poller) scrapeComponents(url string) {
+	components, supported, err := p.scraper.ScrapeStatusPageComponents(p.scrapeContext(url), url)
+	if err != nil {
+		p.logger.Error("failed to scrape components", zap.Error(err), zap.String("url", url))
+		return
+	}
+	if !supported {
+		return
+	}
+	for _, consumer := range p.consumers {
+		componentConsumer, ok := consumer.(consumers.ComponentConsumer)
+		if !ok {
+			continue
+		}
+		err := componentConsumer.ConsumeComponents(url, components)
+		if err != nil {
+			p.logger.Error("failed to consume components", zap.Error(err), zap.String("url", url))
+		}
+	}
+}
+
+// resolveVanished hands every incident the current scrape found to the consumers that resolve the vanished ones
+func (p *Poller) resolveVanished(url string, incidents []api.Incident, scrapedAt time.Time) {
+	for _, consumer := range p.consumers {
+		currentIncidentsConsumer, ok := consumer.(consumers.CurrentIncidentsConsumer)
+		if !ok {
+			continue
+		}
+		err := currentIncidentsConsumer.ConsumeCurrentIncidents(url, incidents, scrapedAt)
+		if err != nil {
+			p.logger.Error("failed to consume current incidents", zap.Error(err), zap.String("url", url))
+		}
+	}
+}
+
+// activeIncidentMaxAge is how old an incident without an end time can be before it is no longer treated as ongoing
+// Some status pages never resolve their incidents, this stops them being scraped at the active incident interval forever
+const activeIncidentMaxAge = 14 * 24 * time.Hour
+
+func hasActiveIncident(incidents []api.Incident) bool {
+	for _, incident := range incidents {
+		// Maintenances are planned so they don't need to be followed closely
+		if incident.Impact == api.ImpactMaintenance {
+			continue
+		}
+		if isOngoing(incident) {
+			return true
+		}
+	}
+	return false
+}
+
+func isOngoing(incident api.Incident) bool {
+	return incident.EndTime == nil && time.Since(incident.StartTime) < activeIncidentMaxAge
+}
+
+func (p *Poller) pollInnerHistorical() error {
+	urlsToScrape, err := p.urlGetter.GetHistoricalUrlsToScrape()
+	if err != nil {
+		return err
+	}
+
+	for _, url := range urlsToScrape {
+		if _, found := p.currentlyExecutingHistoricalScrapes.Get(url); found {
+			continue
+		}
+		p.currentlyExecutingHistoricalScrapes.Set(url, true, cache.NoExpiration)
+		dispatched, workersBusy := p.dispatch(scrapeJob{url: url, historical: true})
+		if !dispatched {
+			p.currentlyExecutingHistoricalScrapes.Delete(url)
+		}
+		if workersBusy {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (p *Poller) scrapeHistorical(url string) {
+	defer p.currentlyExecutingHistoricalScrapes.Delete(url)
+	release, acquired, err := p.locker.AcquireHistoricalScrapeLock(context.Background(), url)
+	if err != nil {
+		p.logger.Error("failed to acquire historical scrape lock", zap.Error(err), zap.String("url", url))
+		return
+	}
+	if !acquired {
+		p.logger.Debug("historical scrape lock held by another scraper", zap.String("url", url))
+		return
+	}
+	defer release()
+	p.logger.Info("scraping historical", zap.String("url", url))
+	defer p.logger.Info("finished scraping historical", zap.String("url", url))
+	defer func(urlGetter urlgetter.URLGetter, url string, time time.Time) {
+		_ = urlGetter.UpdateLastScrapedTimeHistorical(url, time)
+	}(p.urlGetter, url, time.Now())
+	err = p.executeScrapeHistorical(url, false)
+	if err != nil {
+		p.logger.Error("failed to scrape historical", zap.Error(err), zap.String("url", url))
+	}
+}
+
+// executeScrapeHistorical sends the incidents of a historical scrape to the consumers, as a backfill if backfill is set
+func (p *Poller) executeScrapeHistorical(url string, backfill bool) error {
+	p.currentlyExecutingHistoricalScrapes.Set(url, struct{}{}, cache.NoExpiration)
+	ctx, span := tracing.StartScrape(p.scrapeContext(url), "historical", url)
+	defer span.End()
+	incidents, err := p.scraper.ScrapeStatusPageHistorical(ctx, url)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	p.annotator.Annotate(context.Background(), incidents)
+	_, persist := tracing.Tracer.Start(ctx, tracing.StagePersist)
+	defer persist.End()
+	err = p.consume(incidents, backfill)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	return nil
+}
+
+// consume sends the incidents to every consumer, the incidents of a backfill are given to the consumers that tell them
+// apart as such so that the archive of a status page isn't published as new incidents
+func (p *Poller) consume(incidents []api.Incident, backfill bool) error {
+	for _, consumer := range p.consumers {
+		backfillConsumer, ok := consumer.(consumers.BackfillConsumer)
+		var err error
+		if backfill && ok {
+			err = backfillConsumer.ConsumeBackfill(incidents)
+		} else {
+			err = consumer.Consume(incidents)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pollInnerBackfill dispatches the backfills of the status pages that have never had their whole archive scraped
+// Backfills share the historical scrape bookkeeping so a page is never backfilled and historically scraped at once
+func (p *Poller) pollInnerBackfill() error {
+	urlsToScrape, err := p.urlGetter.GetBackfillUrlsToScrape()
+	if err != nil {
+		return err
+	}
+
+	for _, url := range urlsToScrape {
+		if _, found := p.currentlyExecutingHistoricalScrapes.Get(url); found {
+			continue
+		}
+		p.currentlyExecutingHistoricalScrapes.Set(url, true, cache.NoExpiration)
+		dispatched, workersBusy := p.dispatch(scrapeJob{url: url, backfill: true})
+		if !dispatched {
+			p.currentlyExecutingHistoricalScrapes.Delete(url)
+		}
+		if workersBusy {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (p *Poller) backfill(url string) {
+	defer p.currentlyExecutingHistoricalScrapes.Delete(url)
+	release, acquired, err := p.locker.AcquireHistoricalScrapeLock(context.Background(), url)
+	if err != nil {
+		p.logger.Error("failed to acquire historical scrape lock", zap.Error(err), zap.String("url", url))
+		return
+	}
+	if !acquired {
+		p.logger.Debug("historical scrape lock held by another scraper", zap.String("url", url))
+		return
+	}
+	defer release()
+	p.logger.Info("backfilling", zap.String("url", url))
+	defer p.logger.Info("finished backfilling", zap.String("url", url))
+	start := time.Now()
+	err = p.executeBackfill(url)
+	if err != nil {
+		p.logger.Error("failed to backfill", zap.Error(err), zap.String("url", url))
+		// The backfill is retried later, the incidents consumed so far are kept
+		_ = p.urlGetter.UpdateLastScrapedTimeHistorical(url, start)
+		return
+	}
+	err = p.urlGetter.UpdateBackfilled(url, start)
+	if err != nil {
+		p.logger.Error("failed to record backfill", zap.Error(err), zap.String("url", url))
+	}
+}
+
+// executeBackfill sends the whole incident archive of the status page to the consumers, as a backfill so that it isn't
+// published
+// Status pages whose provider can't walk its archive are historically scraped instead
+// An interrupted backfill resumes from the last archive page that was consumed
+func (p *Poller) executeBackfill(url string) error {
+	cursor, err := p.urlGetter.GetBackfillCursor(url)
+	if err != nil {
+		return err
+	}
+	if cursor != "" {
+		p.logger.Info("resuming backfill", zap.String("url", url), zap.String("cursor", cursor))
+	}
+	supported, err := p.scraper.ScrapeStatusPageBackfill(p.scrapeContext(url), url, cursor, func(incidents []api.Incident, cursor string) error {
+		p.annotator.Annotate(context.Background(), incidents)
+		err := p.consume(incidents, true)
+		if err != nil {
+			return err
+		}
+		// The cursor is only saved once every consumer has the page, so a page is never skipped on resume
+		return p.urlGetter.UpdateBackfillCursor(url, cursor)
+	})
+	if err != nil {
+		return err
+	}
+	if !supported {
+		return p.executeScrapeHistorical(url, true)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package poller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/consumers"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/language"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/urlgetter"
+	"go.uber.org/zap"
+)
+
+// archiveScraper walks an archive of two pages, or can't walk it at all in which case it scrapes it historically
+type archiveScraper struct {
+	scraper.Scraper
+	supported bool
+}
+
+func (s archiveScraper) ScrapeStatusPageBackfill(ctx context.Context, url string, cursor string, consume func(incidents []api.Incident, cursor string) error) (bool, error) {
+	if !s.supported {
+		return false, nil
+	}
+	for _, page := range []string{"1", "2"} {
+		err := consume([]api.Incident{{Title: "Outage", DeepLink: url + "/incidents/" + page, StatusPageUrl: url}}, page)
+		if err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+func (s archiveScraper) ScrapeStatusPageHistorical(ctx context.Context, url string) ([]api.Incident, error) {
+	return []api.Incident{{Title: "Outage", DeepLink: url + "/incidents/1", StatusPageUrl: url}}, nil
+}
+
+type backfillURLGetter struct {
+	urlgetter.URLGetter
+}
+
+func (g backfillURLGetter) GetBackfillCursor(url string) (string, error)         { return "", nil }
+func (g backfillURLGetter) UpdateBackfillCursor(url string, cursor string) error { return nil }
+func (g backfillURLGetter) Location(url string) *time.Location                   { return nil }
+func (g backfillURLGetter) ScrapeConfig(url string) *api.ScrapeConfig            { return nil }
+func (g backfillURLGetter) Provider(url string) string                           { return "atlassian" }
+
+// plainConsumer can't tell the incidents of a backfill apart, e.g. a mirror that doesn't publish them
+type plainConsumer struct {
+	consumed int
+}
+
+func (c *plainConsumer) Consume(incidents []api.Incident) error {
+	c.consumed += len(incidents)
+	return nil
+}
+
+// publishingConsumer publishes the incidents it consumes unless they are a backfill, like the db consumer
+type publishingConsumer struct {
+	published  int
+	backfilled int
+}
+
+func (c *publishingConsumer) Consume(incidents []api.Incident) error {
+	c.published += len(incidents)
+	return nil
+}
+
+func (c *publishingConsumer) ConsumeBackfill(incidents []api.Incident) error {
+	c.backfilled += len(incidents)
+	return nil
+}
+
+// TestBackfillIsNotPublished checks that the archive of a status page reaches the consumers as a backfill, whether the
+// provider walks the archive or it is scraped historically instead
+func TestBackfillIsNotPublished(t *testing.T) {
+	tests := []struct {
+		name      string
+		supported bool
+		incidents int
+	}{
+		{name: "archive", supported: true, incidents: 2},
+		{name: "historical", supported: false, incidents: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plain := &plainConsumer{}
+			publishing := &publishingConsumer{}
+			p := NewPoller(Config{}, backfillURLGetter{}, archiveScraper{supported: test.supported}, []consumers.Consumer{plain, publishing}, nil, language.NewAnnotator(zap.NewNop(), nil, "en"), zap.NewNop())
+			err := p.executeBackfill("https://status.example.com")
+			if err != nil {
+				t.Fatalf("failed to backfill: %v", err)
+			}
+			if publishing.published != 0 {
+				t.Errorf("%d backfilled incidents were published", publishing.published)
+			}
+			if publishing.backfilled != test.incidents {
+				t.Errorf("got %d backfilled incidents, want %d", publishing.backfilled, test.incidents)
+			}
+			if plain.consumed != test.incidents {
+				t.Errorf("the consumer without backfills got %d incidents, want %d", plain.consumed, test.incidents)
+			}
+		})
+	}
+}
+
+// TestHistoricalScrapeIsPublished checks that a regular historical scrape still publishes the incidents it finds
+func TestHistoricalScrapeIsPublished(t *testing.T) {
+	publishing := &publishingConsumer{}
+	p := NewPoller(Config{}, backfillURLGetter{}, archiveScraper{}, []consumers.Consumer{publishing}, nil, language.NewAnnotator(zap.NewNop(), nil, "en"), zap.NewNop())
+	err := p.executeScrapeHistorical("https://status.example.com", false)
+	if err != nil {
+		t.Fatalf("failed to scrape: %v", err)
+	}
+	if publishing.published != 1 || publishing.backfilled != 0 {
+		t.Errorf("got %d published and %d backfilled incidents, want 1 published", publishing.published, publishing.backfilled)
+	}
+}
@@ -0,0 +1,55 @@
+package poller
+
+import (
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/consumers"
+	"go.uber.org/zap"
+	"time"
+)
+
+// snapshotStatus records the overall status of the status page after it has been scraped
+// The status shown by the status page is used if its provider can scrape it, otherwise it is derived from the incidents
+func (p *Poller) snapshotStatus(url string, incidents []api.Incident) {
+	snapshot, supported, err := p.scraper.ScrapeStatusPageStatus(p.scrapeContext(url), url)
+	if err != nil {
+		p.logger.Error("failed to scrape status, deriving it from the incidents", zap.Error(err), zap.String("url", url))
+	}
+	if err != nil || !supported {
+		snapshot = deriveStatus(incidents)
+	}
+	snapshot.StatusPageUrl = url
+	snapshot.Time = time.Now()
+
+	for _, consumer := range p.consumers {
+		snapshotConsumer, ok := consumer.(consumers.StatusSnapshotConsumer)
+		if !ok {
+			continue
+		}
+		err := snapshotConsumer.ConsumeStatusSnapshot(snapshot)
+		if err != nil {
+			p.logger.Error("failed to consume status snapshot", zap.Error(err), zap.String("url", url))
+		}
+	}
+}
+
+// deriveStatus returns the status a status page would show for the incidents, the most severe ongoing incident sets it
+// Maintenances only count once they have started
+func deriveStatus(incidents []api.Incident) api.StatusSnapshot {
+	indicator := api.ImpactNone
+	for _, incident := range incidents {
+		if !isOngoing(incident) || incident.StartTime.After(time.Now()) {
+			continue
+		}
+		if incident.ScheduledStart != nil && incident.ScheduledStart.After(time.Now()) {
+			continue
+		}
+		if incident.Impact.Severity() > indicator.Severity() {
+			indicator = incident.Impact
+		}
+	}
+	return api.StatusSnapshot{
+		Indicator:   indicator,
+		Description: api.StatusDescriptions[indicator],
+		Derived:     true,
+	}
+}
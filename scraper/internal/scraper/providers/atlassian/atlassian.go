@@ -0,0 +1,462 @@
+package atlassian
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/utils"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (s *AtlassianProvider) Name() string {
+	return "Atlassian"
+}
+
+func (s *AtlassianProvider) Features() api.ProviderFeatures {
+	return api.ProviderFeatures{
+		Provider:        s.Name(),
+		Incidents:       true,
+		IncidentUpdates: true,
+		Components:      false,
+		Maintenance:     false,
+		CurrentStatus:   false,
+		Webhooks:        false,
+		API:             false,
+		HistoryDepth:    "10 years",
+	}
+}
+
+type AtlassianProvider struct {
+	logger     *zap.Logger
+	httpClient *http.Client
+}
+
+func NewAtlassianProvider(logger *zap.Logger, httpClient *http.Client) *AtlassianProvider {
+	return &AtlassianProvider{
+		logger:     logger,
+		httpClient: httpClient,
+	}
+}
+
+func init() {
+	providers.Register("atlassian", 100, func(logger *zap.Logger, httpClient *http.Client) providers.Provider {
+		return NewAtlassianProvider(logger, httpClient)
+	})
+}
+
+func (s *AtlassianProvider) Matches(ctx context.Context, url string) (bool, error) {
+	return s.isAtlassianPage(ctx, url)
+}
+
+func (s *AtlassianProvider) ScrapeStatusPageHistorical(ctx context.Context, url string) ([]api.Incident, error) {
+	return s.scrapeAtlassianPageHistorical(ctx, url)
+}
+
+func (s *AtlassianProvider) ScrapeStatusPageCurrent(ctx context.Context, url string) ([]api.Incident, error) {
+	return s.scrapeAtlassianPageCurrent(ctx, url)
+}
+
+// scrapeAtlassianPageCurrent is a helper function that will attempt to scrape the status
+// page using the atlassian method
+// If the atlassian method fails, it will return an error
+func (s *AtlassianProvider) scrapeAtlassianPageCurrent(ctx context.Context, url string) ([]api.Incident, error) {
+	// Get the current ongoing incidents
+	incidentsOngoing, err := s.getOngoingIncidents(ctx, url)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the ongoing incidents")
+	}
+
+	// Get the most recent historical incidentsHistoricalRecent
+	incidentsHistoricalRecent, err := s.getHistoricalPageOfIncidents(ctx, url, 1)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the most recent historical incidentsHistoricalRecent")
+	}
+
+	// De-dupe the incidents
+	incidents := append(incidentsOngoing, incidentsHistoricalRecent...)
+	incidentsDeDuped := make(map[string]api.Incident)
+	for _, incident := range incidents {
+		incidentsDeDuped[incident.DeepLink] = incident
+	}
+
+	incidents = []api.Incident{}
+	for _, incident := range incidentsDeDuped {
+		incidents = append(incidents, incident)
+	}
+
+	// Only the incidents that can still get new updates are fetched, the rest were filled in when they were last open
+	// or by the historical scrape
+	s.addIncidentUpdates(ctx, incidents, func(incident api.Incident) bool {
+		return incident.EndTime == nil || time.Since(*incident.EndTime) < recentlyEndedIncidentAge
+	})
+
+	return incidents, nil
+}
+
+// recentlyEndedIncidentAge is how long after an incident ends that the current scrape keeps fetching its updates
+// so the final updates posted around its resolution are picked up
+const recentlyEndedIncidentAge = 24 * time.Hour
+
+// maxBackfillPages stops a backfill of a history that never runs out, each page is a quarter so this is 50 years
+const maxBackfillPages = 200
+
+// backfillEmptyPages is how many history pages in a row without incidents end a backfill, two years of quarters
+// A single empty page isn't the end of the history, a page can go a quarter without an incident
+const backfillEmptyPages = 8
+
+// Backfill walks the history pages back until they run out of incidents
+// The cursor is the number of the next history page
+func (s *AtlassianProvider) Backfill(ctx context.Context, url string, cursor string, consume func(incidents []api.Incident, cursor string) error) error {
+	return s.backfill(ctx, url, cursor, nil, consume)
+}
+
+// backfill walks the history pages, the incidents with a deep link in known have already been scraped so they are skipped
+func (s *AtlassianProvider) backfill(ctx context.Context, url string, cursor string, known map[string]bool, consume func(incidents []api.Incident, cursor string) error) error {
+	firstPage := 1
+	if cursor != "" {
+		var err error
+		firstPage, err = strconv.Atoi(cursor)
+		if err != nil {
+			return errors.Wrapf(err, "invalid backfill cursor %q", cursor)
+		}
+	}
+	emptyPages := 0
+	for page := firstPage; page <= maxBackfillPages && emptyPages < backfillEmptyPages; page++ {
+		incidents, err := s.getHistoricalPageOfIncidents(ctx, url, page)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get history page %d", page)
+		}
+		if len(incidents) == 0 {
+			emptyPages++
+			continue
+		}
+		emptyPages = 0
+		var unknown []api.Incident
+		for _, incident := range incidents {
+			if !known[incident.DeepLink] {
+				unknown = append(unknown, incident)
+			}
+		}
+		if len(unknown) == 0 {
+			continue
+		}
+		s.addIncidentUpdates(ctx, unknown, func(incident api.Incident) bool {
+			return true
+		})
+		err = consume(unknown, strconv.Itoa(page+1))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scrapeAtlassianPageHistorical is a helper function that will attempt to scrape the status page using the atlassian method
+// If the atlassian method fails, it will return an error
+func (s *AtlassianProvider) scrapeAtlassianPageHistorical(ctx context.Context, url string) ([]api.Incident, error) {
+	var incidents []api.Incident
+
+	// Get the last 40 quarters of incidents == 10 years
+	i := 40
+	for page := 1; page <= i; page++ {
+		// Get the html of the status page
+		incidentPage, err := s.getHistoricalPageOfIncidents(ctx, url, page)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get the historical incidents")
+		}
+		incidents = append(incidents, incidentPage...)
+	}
+	s.addIncidentUpdates(ctx, incidents, func(incident api.Incident) bool {
+		return true
+	})
+	return incidents, nil
+}
+
+func (s *AtlassianProvider) scrapeStatusIoHistoryPage(ctx context.Context, url string, page int) (string, error) {
+	// First we get the status page history
+	historyUrl := url + "/history?page=" + strconv.Itoa(page)
+	history, err := s.get(ctx, historyUrl)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to make the get request to the history page")
+	}
+
+	// Pull out the HTML from the response
+	historyHtml, err := io.ReadAll(history.Body)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read the history page response body")
+	}
+
+	return string(historyHtml), nil
+}
+
+func (s *AtlassianProvider) getHistoricalPageOfIncidents(ctx context.Context, url string, page int) ([]api.Incident, error) {
+	historyPageHtml, err := s.scrapeStatusIoHistoryPage(ctx, url, page)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to scrape the status page history")
+	}
+
+	// Parse the incidents from the history page
+	incidentPage, err := s.parseIncidents(url, historyPageHtml, providers.LocationFromContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the incidents from the history page")
+	}
+	return incidentPage, nil
+}
+
+func (s *AtlassianProvider) parseIncidents(url string, html string, loc *time.Location) ([]api.Incident, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the history page html")
+	}
+
+	// Placeholder for incidents
+	var incidents []api.Incident
+
+	// Find the script tag with the JSON data
+	doc.Find("div[data-react-class='HistoryIndex']").Each(func(i int, selection *goquery.Selection) {
+		dataReactProps, exists := selection.Attr("data-react-props")
+		if !exists {
+			s.logger.Error("Could not find data-react-props attribute")
+			return
+		}
+
+		var pageStatus PageStatus
+
+		// Unmarshal the JSON into the PageStatus struct
+		err := json.Unmarshal([]byte(dataReactProps), &pageStatus)
+		if err != nil {
+			s.logger.Error("Error unmarshalling JSON", zap.Error(err))
+			return
+		}
+
+		// Transform data into Incident and IncidentEvent structs (example)
+		for _, month := range pageStatus.Months {
+			for _, inc := range month.Incidents {
+				link := url + "/incidents/" + inc.Code
+				// Parse the timestamp, add logic to handle parsing
+				startTime, endTime, err := parseDateString(month.Year, month.Month, inc.Timestamp, loc)
+				if err != nil {
+					s.logger.Error("Error parsing time", zap.Error(err), zap.String("timestamp", inc.Timestamp), zap.String("deep_link", link))
+					return
+				}
+
+				// Example transformation, customize as needed
+				incident := api.Incident{
+					Title:         inc.Name,
+					Description:   &inc.Message,
+					StartTime:     startTime,
+					EndTime:       endTime,
+					Impact:        api.NormalizeImpact(inc.Impact),
+					RawImpact:     inc.Impact,
+					DeepLink:      link,
+					StatusPageUrl: url,
+				}
+				incidents = append(incidents, incident)
+			}
+		}
+	})
+
+	return incidents, nil
+}
+
+func (s *AtlassianProvider) getOngoingIncidents(ctx context.Context, url string) ([]api.Incident, error) {
+	pageHtml, err := s.getOngoingIncidentsPageHtml(ctx, url)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the ongoing incidents page html")
+	}
+
+	// Parse the incidents from the ongoing incidents page
+	incidents, err := s.parseCurrentIncidents(url, pageHtml)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the incidents from the ongoing incidents page")
+	}
+	return incidents, nil
+}
+
+func (s *AtlassianProvider) getOngoingIncidentsPageHtml(ctx context.Context, url string) (string, error) {
+	history, err := s.get(ctx, url)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to make the get request to the history page")
+	}
+
+	// Pull out the HTML from the response
+	historyHtml, err := io.ReadAll(history.Body)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read the history page response body")
+	}
+
+	return string(historyHtml), nil
+}
+
+func (s *AtlassianProvider) parseCurrentIncidents(url string, html string) ([]api.Incident, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the history page html")
+	}
+
+	var incidents []api.Incident
+
+	// Find and iterate over each unresolved incident
+	doc.Find(".unresolved-incident").Each(func(i int, selection *goquery.Selection) {
+		var incident api.Incident
+
+		// Extract the incident's title
+		incident.Title = selection.Find(".actual-title").Text()
+		deepLink := selection.Find(".incident-title a").First().AttrOr("href", "")
+		deepLink = url + deepLink
+		incident.DeepLink = deepLink
+		incident.StatusPageUrl = url
+		var minTime *time.Time = nil
+
+		selection.Find(".update").Each(func(i int, sel *goquery.Selection) {
+			event := api.IncidentUpdate{Source: s.Name()}
+			// Extract the update's timestamp
+			timestamp := sel.Find("small").Find("span").First().AttrOr("data-datetime-unix", "")
+			timeInt, err := strconv.ParseInt(timestamp, 10, 64)
+			if err != nil {
+				s.logger.Error("Error parsing timestamp", zap.Error(err))
+				return
+			}
+			event.Time = time.UnixMilli(timeInt)
+			if minTime == nil || event.Time.Before(*minTime) {
+				minTime = &event.Time
+			}
+
+			// The title of the update is the state label, e.g. "Investigating"
+			event.State = api.ParseIncidentState(sel.Find("strong").Text())
+
+			// Extract the update's message
+			event.Body = sel.Find("span").First().Text()
+
+			incident.Events = append(incident.Events, event)
+		})
+		incident.StartTime = *minTime
+		// Append the extracted incident to the slice
+		incidents = append(incidents, incident)
+	})
+	return incidents, nil
+}
+
+// We determine if a page is an atlassian page by checking if there is a /history page and
+// that history page contains the data-react-class='HistoryIndex' attribute
+func (s *AtlassianProvider) isAtlassianPage(ctx context.Context, url string) (bool, error) {
+	// Get the history page
+	historyUrl := url + "/history"
+	history, err := s.get(ctx, historyUrl)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to make the get request to the history page")
+	}
+
+	// Pull out the HTML from the response
+	historyHtml, err := io.ReadAll(history.Body)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to read the history page response body")
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(historyHtml)))
+	if err != nil {
+		return false, errors.Wrap(err, "failed to parse the history page html")
+	}
+
+	// Find the script tag with the JSON data
+	found := false
+	doc.Find("div[data-react-class='HistoryIndex']").Each(func(i int, selection *goquery.Selection) {
+		found = true
+	})
+	return found, nil
+}
+
+// get makes a GET request that is cancelled with the scrape
+func (s *AtlassianProvider) get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.httpClient.Do(req)
+}
+
+// We need to parse strings in this format
+// "Mar <var data-var='date'>13</var>, <var data-var='time'>06:55</var> - <var data-var='time'>16:02</var> UTC"
+// "Feb <var data-var='date'>25</var>, <var data-var='time'>23:44</var> - Feb <var data-var='date'>26</var>, <var data-var='time'>20:27</var> UTC"
+// The year is that of the month the incident is listed under, the end time can be in the next month or year
+// Times are in the zone printed at the end, or the timezone of the status page if there is none
+func parseDateString(year int, month string, dateString string, loc *time.Location) (time.Time, *time.Time, error) {
+	monthTime, err := time.Parse("January", month)
+	if err != nil {
+		return time.Time{}, nil, errors.Wrapf(err, "failed to parse month %q", month)
+	}
+	// The incident started in the month it is listed under, so the year closest to the middle of that month is the right one
+	reference := time.Date(year, monthTime.Month(), 15, 0, 0, 0, 0, time.UTC)
+
+	text := strings.Join(strings.Fields(htmlTagRegex.ReplaceAllString(dateString, "")), " ")
+	startText, endText, hasEnd := strings.Cut(text, " - ")
+
+	// The zone is only printed once, after the end time if there is one
+	zone := ""
+	last := text
+	if hasEnd {
+		last = endText
+	}
+	if index := strings.LastIndex(last, " "); index != -1 && utils.IsZoneAbbreviation(last[index+1:]) {
+		zone = last[index:]
+		if hasEnd {
+			endText = strings.TrimSpace(endText[:index])
+		} else {
+			startText = strings.TrimSpace(startText[:index])
+		}
+	}
+
+	startTime, err := utils.ParseTimestamp(startText+zone, loc, reference)
+	if err != nil {
+		return time.Time{}, nil, errors.Wrap(err, "failed to parse the start time")
+	}
+	if !hasEnd {
+		return startTime, nil, nil
+	}
+
+	// An end time on the same day is printed without its date
+	if !strings.Contains(endText, ",") {
+		date, _, _ := strings.Cut(startText, ",")
+		endText = date + ", " + endText
+	}
+	endTime, err := utils.ParseTimestamp(endText+zone, loc, startTime)
+	if err != nil {
+		return time.Time{}, nil, errors.Wrap(err, "failed to parse the end time")
+	}
+	return startTime, &endTime, nil
+}
+
+var htmlTagRegex = regexp.MustCompile(`<[^>]+>`)
+
+// Additional structs to capture the overall structure of the JSON
+type PageStatus struct {
+	Components []Component `json:"components"`
+	Months     []Month     `json:"months"`
+}
+
+type Component struct {
+	Name string `json:"name"`
+}
+
+type Month struct {
+	Incidents []IncidentRaw `json:"incidents"`
+	Year      int           `json:"year"`
+	Month     string        `json:"name"`
+}
+
+type IncidentRaw struct {
+	Name      string `json:"name"`
+	Message   string `json:"message"`
+	Timestamp string `json:"timestamp"`
+	Code      string `json:"code"`
+	Impact    string `json:"impact"`
+}
@@ -0,0 +1,45 @@
+package atlassian
+
+import (
+	"github.com/metoro-io/statusphere/common/api"
+	neturl "net/url"
+	"regexp"
+)
+
+// edgeNetworks are the CDN status pages that name each data center (PoP) after its airport code,
+// e.g. "Frankfurt, Germany - (FRA)" on Cloudflare or "Frankfurt (FRA)" on Fastly
+var edgeNetworks = map[string]bool{
+	"www.cloudflarestatus.com": true,
+	"www.fastlystatus.com":     true,
+}
+
+var popCode = regexp.MustCompile(`\(([A-Z]{3})\)`)
+
+func isEdgeNetwork(url string) bool {
+	parsed, err := neturl.Parse(url)
+	if err != nil {
+		return false
+	}
+	return edgeNetworks[parsed.Hostname()]
+}
+
+// addPopComponents adds the airport code of every data center mentioned by the incident to its components
+// Data centers are often only named in the title or the updates, not as affected components
+func addPopComponents(incident *api.Incident) {
+	seen := make(map[string]bool, len(incident.Components))
+	for _, component := range incident.Components {
+		seen[component] = true
+	}
+	texts := append([]string{incident.Title}, incident.Components...)
+	for _, update := range incident.Events {
+		texts = append(texts, update.Body)
+	}
+	for _, text := range texts {
+		for _, match := range popCode.FindAllStringSubmatch(text, -1) {
+			if !seen[match[1]] {
+				seen[match[1]] = true
+				incident.Components = append(incident.Components, match[1])
+			}
+		}
+	}
+}
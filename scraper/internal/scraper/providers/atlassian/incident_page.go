@@ -0,0 +1,81 @@
+package atlassian
+
+import (
+	"context"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// addIncidentUpdates fills in the timeline of updates of the incidents that match the filter from their incident pages
+// The history pages only show the latest message of each incident, so every update needs a request for its incident page
+// Incidents that already have their updates, e.g. the ongoing incidents, are skipped
+// An incident whose page can't be scraped keeps its message as its description
+func (s *AtlassianProvider) addIncidentUpdates(ctx context.Context, incidents []api.Incident, filter func(incident api.Incident) bool) {
+	for i := range incidents {
+		if len(incidents[i].Events) > 0 || !filter(incidents[i]) {
+			continue
+		}
+		updates, err := s.getIncidentUpdates(ctx, incidents[i].DeepLink)
+		if err != nil {
+			s.logger.Info("failed to get the incident updates", zap.String("deep_link", incidents[i].DeepLink), zap.Error(err))
+			continue
+		}
+		incidents[i].Events = updates
+	}
+}
+
+func (s *AtlassianProvider) getIncidentUpdates(ctx context.Context, deepLink string) ([]api.IncidentUpdate, error) {
+	resp, err := s.get(ctx, deepLink)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to make the get request to the incident page")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the incident page response body")
+	}
+	return s.parseIncidentPage(string(body))
+}
+
+// parseIncidentPage parses the updates of an incident page, each one is an update-row with the state as its title, e.g.
+// <div class="update-row"><div class="update-title">Resolved</div><div class="update-body"><span class="whitespace-pre-wrap">...</span>
+// <div class="update-timestamp">Posted <span data-datetime-unix="1710340500000"></span></div></div></div>
+func (s *AtlassianProvider) parseIncidentPage(html string) ([]api.IncidentUpdate, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the incident page html")
+	}
+
+	var updates []api.IncidentUpdate
+	doc.Find(".update-row").Each(func(i int, selection *goquery.Selection) {
+		timestamp := selection.Find(".update-timestamp span[data-datetime-unix]").First().AttrOr("data-datetime-unix", "")
+		timeInt, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			s.logger.Info("failed to parse the update timestamp", zap.String("timestamp", timestamp), zap.Error(err))
+			return
+		}
+		state := api.ParseIncidentState(selection.Find(".update-title").First().Text())
+		body := strings.TrimSpace(selection.Find(".update-body .whitespace-pre-wrap").First().Text())
+		updates = append(updates, api.NewIncidentUpdate(time.UnixMilli(timeInt), state, body, s.Name()))
+	})
+	if len(updates) == 0 {
+		return nil, errors.New("no updates found on the incident page")
+	}
+
+	// The page lists the most recent update first
+	sort.SliceStable(updates, func(i, j int) bool {
+		return updates[i].Time.Before(updates[j].Time)
+	})
+	return updates, nil
+}
@@ -0,0 +1,342 @@
+package atlassian
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+)
+
+func init() {
+	// The api is preferred over scraping the html of the same pages
+	providers.Register("atlassian-api", 50, func(logger *zap.Logger, httpClient *http.Client) providers.Provider {
+		return NewStatuspageAPIProvider(logger, httpClient)
+	})
+}
+
+// StatuspageAPIProvider scrapes Atlassian Statuspage hosted pages through their public JSON API
+// The api only returns the 50 most recent incidents so older incidents are scraped from the html history pages
+type StatuspageAPIProvider struct {
+	logger     *zap.Logger
+	httpClient *http.Client
+	history    *AtlassianProvider
+}
+
+func NewStatuspageAPIProvider(logger *zap.Logger, httpClient *http.Client) *StatuspageAPIProvider {
+	return &StatuspageAPIProvider{
+		logger:     logger,
+		httpClient: httpClient,
+		history:    NewAtlassianProvider(logger, httpClient),
+	}
+}
+
+func (s *StatuspageAPIProvider) Name() string {
+	return "Atlassian API"
+}
+
+func (s *StatuspageAPIProvider) Features() api.ProviderFeatures {
+	return api.ProviderFeatures{
+		Provider:        s.Name(),
+		Incidents:       true,
+		IncidentUpdates: true,
+		Components:      true,
+		Maintenance:     true,
+		CurrentStatus:   false,
+		Webhooks:        false,
+		API:             true,
+		HistoryDepth:    "10 years",
+	}
+}
+
+type statuspageSummary struct {
+	Page struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"page"`
+}
+
+type statuspageIncidents struct {
+	Incidents []statuspageIncident `json:"incidents"`
+}
+
+type statuspageComponents struct {
+	Components []struct {
+		ID          string  `json:"id"`
+		Name        string  `json:"name"`
+		Status      string  `json:"status"`
+		Description *string `json:"description"`
+		GroupID     *string `json:"group_id"`
+		// Group is true for the entries that are groups of other components
+		Group bool `json:"group"`
+	} `json:"components"`
+}
+
+type statuspageScheduledMaintenances struct {
+	ScheduledMaintenances []statuspageIncident `json:"scheduled_maintenances"`
+}
+
+type statuspageIncident struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Status     string     `json:"status"`
+	Impact     string     `json:"impact"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+	// ScheduledFor and ScheduledUntil are only set on scheduled maintenances
+	ScheduledFor    *time.Time                 `json:"scheduled_for"`
+	ScheduledUntil  *time.Time                 `json:"scheduled_until"`
+	IncidentUpdates []statuspageIncidentUpdate `json:"incident_updates"`
+	Components      []struct {
+		Name string `json:"name"`
+	} `json:"components"`
+}
+
+type statuspageIncidentUpdate struct {
+	Status             string     `json:"status"`
+	Body               string     `json:"body"`
+	CreatedAt          time.Time  `json:"created_at"`
+	DisplayAt          *time.Time `json:"display_at"`
+	AffectedComponents []struct {
+		Name      string `json:"name"`
+		OldStatus string `json:"old_status"`
+		NewStatus string `json:"new_status"`
+	} `json:"affected_components"`
+}
+
+// Matches checks that the page serves the Statuspage summary api
+func (s *StatuspageAPIProvider) Matches(ctx context.Context, url string) (bool, error) {
+	var summary statuspageSummary
+	found, err := s.getJson(ctx, url+"/api/v2/summary.json", &summary)
+	if err != nil {
+		return false, err
+	}
+	return found && summary.Page.ID != "", nil
+}
+
+func (s *StatuspageAPIProvider) ScrapeStatusPageCurrent(ctx context.Context, url string) ([]api.Incident, error) {
+	return s.getIncidents(ctx, url)
+}
+
+func (s *StatuspageAPIProvider) ScrapeStatusPageHistorical(ctx context.Context, url string) ([]api.Incident, error) {
+	historical, err := s.history.ScrapeStatusPageHistorical(ctx, url)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to scrape the history pages")
+	}
+	recent, err := s.getIncidents(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	// The api incidents have updates and components so they replace their html counterparts
+	recentDeepLinks := make(map[string]bool, len(recent))
+	for _, incident := range recent {
+		recentDeepLinks[incident.DeepLink] = true
+	}
+	incidents := recent
+	for _, incident := range historical {
+		if !recentDeepLinks[incident.DeepLink] {
+			incidents = append(incidents, incident)
+		}
+	}
+	return incidents, nil
+}
+
+// Backfill passes the api incidents first, then walks the html history pages for the older ones
+// The cursor is the number of the next history page, the api incidents are only passed when the backfill starts
+func (s *StatuspageAPIProvider) Backfill(ctx context.Context, url string, cursor string, consume func(incidents []api.Incident, cursor string) error) error {
+	recent, err := s.getIncidents(ctx, url)
+	if err != nil {
+		return err
+	}
+	if cursor == "" {
+		cursor = "1"
+		err = consume(recent, cursor)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The api incidents have updates and components so they aren't replaced by their html counterparts
+	recentDeepLinks := make(map[string]bool, len(recent))
+	for _, incident := range recent {
+		recentDeepLinks[incident.DeepLink] = true
+	}
+	return s.history.backfill(ctx, url, cursor, recentDeepLinks, consume)
+}
+
+func (s *StatuspageAPIProvider) getIncidents(ctx context.Context, url string) ([]api.Incident, error) {
+	var response statuspageIncidents
+	found, err := s.getJson(ctx, url+"/api/v2/incidents.json", &response)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the incidents")
+	}
+	if !found {
+		return nil, errors.New("page does not serve the statuspage api")
+	}
+
+	incidents := make([]api.Incident, 0, len(response.Incidents))
+	for _, inc := range response.Incidents {
+		incidents = append(incidents, s.toIncident(url, inc))
+	}
+
+	// The scheduled maintenances are listed separately, with their planned window
+	var maintenances statuspageScheduledMaintenances
+	found, err = s.getJson(ctx, url+"/api/v2/scheduled-maintenances.json", &maintenances)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the scheduled maintenances")
+	}
+	seen := make(map[string]bool, len(incidents))
+	for _, incident := range incidents {
+		seen[incident.DeepLink] = true
+	}
+	for _, inc := range maintenances.ScheduledMaintenances {
+		maintenance := s.toIncident(url, inc)
+		maintenance.Impact = api.ImpactMaintenance
+		if !seen[maintenance.DeepLink] {
+			incidents = append(incidents, maintenance)
+		}
+	}
+	return incidents, nil
+}
+
+func (s *StatuspageAPIProvider) toIncident(url string, inc statuspageIncident) api.Incident {
+	startTime := inc.CreatedAt
+	if inc.StartedAt != nil {
+		startTime = *inc.StartedAt
+	}
+
+	impact := api.NormalizeImpact(inc.Impact)
+	if isMaintenanceStatus(inc.Status) {
+		impact = api.ImpactMaintenance
+	}
+
+	var components []string
+	for _, component := range inc.Components {
+		components = append(components, component.Name)
+	}
+
+	var updates []api.IncidentUpdate
+	for _, update := range inc.IncidentUpdates {
+		updateTime := update.CreatedAt
+		if update.DisplayAt != nil {
+			updateTime = *update.DisplayAt
+		}
+		incidentUpdate := api.NewIncidentUpdate(updateTime, api.ParseIncidentState(update.Status), update.Body, s.Name())
+		for _, component := range update.AffectedComponents {
+			if component.OldStatus != component.NewStatus {
+				incidentUpdate.ComponentChanges = append(incidentUpdate.ComponentChanges, api.ComponentChange{Component: component.Name, OldStatus: component.OldStatus, NewStatus: component.NewStatus})
+			}
+		}
+		updates = append(updates, incidentUpdate)
+	}
+	// The api returns the most recent update first
+	sort.SliceStable(updates, func(i, j int) bool {
+		return updates[i].Time.Before(updates[j].Time)
+	})
+
+	// Use the same deep link as the html scraper so both produce the same incident
+	incident := api.NewIncident(inc.Name, components, updates, startTime, inc.ResolvedAt, nil, url+"/incidents/"+inc.ID, impact, url)
+	incident.RawImpact = inc.Impact
+	incident.ScheduledStart = inc.ScheduledFor
+	incident.ScheduledEnd = inc.ScheduledUntil
+	if isEdgeNetwork(url) {
+		addPopComponents(&incident)
+	}
+	return incident
+}
+
+func isMaintenanceStatus(status string) bool {
+	switch api.ParseIncidentState(status) {
+	case api.IncidentStateScheduled, api.IncidentStateInProgress, api.IncidentStateVerifying, api.IncidentStateCompleted:
+		return true
+	}
+	return false
+}
+
+func (s *StatuspageAPIProvider) ScrapeComponents(ctx context.Context, url string) ([]api.Component, error) {
+	var response statuspageComponents
+	found, err := s.getJson(ctx, url+"/api/v2/components.json", &response)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the components")
+	}
+	if !found {
+		return nil, errors.New("page does not serve the statuspage api")
+	}
+
+	groups := map[string]string{}
+	for _, component := range response.Components {
+		if component.Group {
+			groups[component.ID] = component.Name
+		}
+	}
+	var components []api.Component
+	for _, component := range response.Components {
+		if component.Group {
+			continue
+		}
+		c := api.Component{Name: component.Name, Status: component.Status, StatusPageUrl: url}
+		if component.GroupID != nil {
+			c.Group = groups[*component.GroupID]
+		}
+		if component.Description != nil {
+			c.Description = *component.Description
+		}
+		components = append(components, c)
+	}
+	return components, nil
+}
+
+func (s *StatuspageAPIProvider) ScrapeStatus(ctx context.Context, url string) (api.StatusSnapshot, error) {
+	var response struct {
+		Status struct {
+			Indicator   string `json:"indicator"`
+			Description string `json:"description"`
+		} `json:"status"`
+	}
+	found, err := s.getJson(ctx, url+"/api/v2/status.json", &response)
+	if err != nil {
+		return api.StatusSnapshot{}, errors.Wrap(err, "failed to get the status")
+	}
+	if !found {
+		return api.StatusSnapshot{}, errors.New("page does not serve the statuspage api")
+	}
+	return api.StatusSnapshot{Indicator: api.Impact(response.Status.Indicator), Description: response.Status.Description}, nil
+}
+
+// getJson unmarshals the json response of the url into v, it returns false if the url is not found
+func (s *StatuspageAPIProvider) getJson(ctx context.Context, url string, v interface{}) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to create the request")
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to make the get request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to read the response body")
+	}
+	err = json.Unmarshal(body, v)
+	if err != nil {
+		// Pages that are not hosted on statuspage can serve html for any path
+		return false, nil
+	}
+	return true, nil
+}
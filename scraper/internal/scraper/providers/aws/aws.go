@@ -0,0 +1,258 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	currentEventsURL = "https://health.aws.amazon.com/public/currentevents"
+	historyEventsURL = "https://history-events-us-west-2-prod.s3.amazonaws.com/historyevents.json"
+)
+
+var dashboardHosts = map[string]bool{
+	"status.aws.amazon.com": true,
+	"health.aws.amazon.com": true,
+}
+
+// regionSuffix matches the region at the end of an AWS service key, e.g. ec2-us-east-1 or s3-us-gov-west-1
+var regionSuffix = regexp.MustCompile(`-([a-z]{2}(?:-gov)?-[a-z]+-\d)$`)
+
+// Event statuses published by the health dashboard
+const (
+	eventStatusResolved      = 0
+	eventStatusInformational = 1
+	eventStatusDegradation   = 2
+	eventStatusDisruption    = 3
+)
+
+func init() {
+	providers.Register("aws", 10, func(logger *zap.Logger, httpClient *http.Client) providers.Provider {
+		return NewAWSProvider(logger, httpClient)
+	})
+}
+
+// AWSProvider scrapes the AWS Health Dashboard through the event json that backs it
+// Events are published per service and region, the region is added to the components of the incident
+type AWSProvider struct {
+	logger           *zap.Logger
+	httpClient       *http.Client
+	currentEventsURL string
+	historyEventsURL string
+}
+
+func NewAWSProvider(logger *zap.Logger, httpClient *http.Client) *AWSProvider {
+	return &AWSProvider{
+		logger:           logger,
+		httpClient:       httpClient,
+		currentEventsURL: currentEventsURL,
+		historyEventsURL: historyEventsURL,
+	}
+}
+
+func (s *AWSProvider) Name() string {
+	return "AWS"
+}
+
+func (s *AWSProvider) Features() api.ProviderFeatures {
+	return api.ProviderFeatures{
+		Provider:        s.Name(),
+		Incidents:       true,
+		IncidentUpdates: true,
+		Components:      true,
+		Maintenance:     false,
+		CurrentStatus:   false,
+		Webhooks:        false,
+		API:             true,
+		HistoryDepth:    "12 months",
+	}
+}
+
+// flexibleInt accepts numbers that the dashboard sometimes encodes as strings
+type flexibleInt int64
+
+func (f *flexibleInt) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		return nil
+	}
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return err
+	}
+	*f = flexibleInt(i)
+	return nil
+}
+
+type awsEvent struct {
+	Date        flexibleInt `json:"date"`
+	Status      flexibleInt `json:"status"`
+	Service     string      `json:"service"`
+	ServiceName string      `json:"service_name"`
+	RegionName  string      `json:"region_name"`
+	Summary     string      `json:"summary"`
+	EventLog    []struct {
+		Summary   string      `json:"summary"`
+		Message   string      `json:"message"`
+		Status    flexibleInt `json:"status"`
+		Timestamp flexibleInt `json:"timestamp"`
+	} `json:"event_log"`
+}
+
+func (s *AWSProvider) Matches(ctx context.Context, url string) (bool, error) {
+	parsed, err := neturl.Parse(url)
+	if err != nil {
+		return false, nil
+	}
+	return dashboardHosts[parsed.Hostname()], nil
+}
+
+func (s *AWSProvider) ScrapeStatusPageCurrent(ctx context.Context, url string) ([]api.Incident, error) {
+	var events []awsEvent
+	err := s.getJson(ctx, s.currentEventsURL, &events)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the current events")
+	}
+	return s.toIncidents(url, events), nil
+}
+
+func (s *AWSProvider) ScrapeStatusPageHistorical(ctx context.Context, url string) ([]api.Incident, error) {
+	// The history is keyed by service and region
+	var history map[string][]awsEvent
+	err := s.getJson(ctx, s.historyEventsURL, &history)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the history events")
+	}
+	var events []awsEvent
+	for service, serviceEvents := range history {
+		for _, event := range serviceEvents {
+			if event.Service == "" {
+				event.Service = service
+			}
+			events = append(events, event)
+		}
+	}
+
+	current, err := s.ScrapeStatusPageCurrent(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return append(s.toIncidents(url, events), current...), nil
+}
+
+func (s *AWSProvider) toIncidents(url string, events []awsEvent) []api.Incident {
+	incidents := make([]api.Incident, 0, len(events))
+	for _, event := range events {
+		incidents = append(incidents, s.toIncident(url, event))
+	}
+	return incidents
+}
+
+func (s *AWSProvider) toIncident(url string, event awsEvent) api.Incident {
+	startTime := time.Unix(int64(event.Date), 0).UTC()
+
+	var updates []api.IncidentUpdate
+	worstStatus := event.Status
+	for _, entry := range event.EventLog {
+		state := api.IncidentStateUpdate
+		if entry.Status == eventStatusResolved {
+			state = api.IncidentStateResolved
+		}
+		worstStatus = max(worstStatus, entry.Status)
+		updates = append(updates, api.NewIncidentUpdate(time.Unix(int64(entry.Timestamp), 0).UTC(), state, entry.Message, s.Name()))
+	}
+	sort.SliceStable(updates, func(i, j int) bool {
+		return updates[i].Time.Before(updates[j].Time)
+	})
+
+	var endTime *time.Time
+	if event.Status == eventStatusResolved || strings.HasPrefix(event.Summary, "[RESOLVED]") {
+		end := startTime
+		if len(updates) > 0 {
+			end = updates[len(updates)-1].Time
+		}
+		endTime = &end
+	}
+
+	components := []string{event.ServiceName}
+	if event.ServiceName == "" {
+		components = []string{event.Service}
+	}
+	if match := regionSuffix.FindStringSubmatch(event.Service); match != nil {
+		components = append(components, match[1])
+	}
+
+	title := strings.TrimSpace(strings.TrimPrefix(event.Summary, "[RESOLVED]"))
+	// The dashboard has no page per event, the service key and start time identify it
+	deepLink := fmt.Sprintf("%s#%s-%d", url, event.Service, int64(event.Date))
+	return api.NewIncident(title, components, updates, startTime, endTime, nil, deepLink, impact(worstStatus), url)
+}
+
+func impact(status flexibleInt) api.Impact {
+	switch status {
+	case eventStatusDisruption:
+		return api.ImpactMajor
+	case eventStatusDegradation:
+		return api.ImpactMinor
+	}
+	return api.ImpactNone
+}
+
+func (s *AWSProvider) getJson(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the request")
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to make the get request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the response body")
+	}
+	return errors.Wrap(json.Unmarshal(decodeUTF16(body), v), "failed to unmarshal the response")
+}
+
+// decodeUTF16 converts a UTF-16 body with a byte order mark to UTF-8, the current events are served as UTF-16
+func decodeUTF16(body []byte) []byte {
+	if len(body) < 2 {
+		return body
+	}
+	var bigEndian bool
+	switch {
+	case body[0] == 0xFE && body[1] == 0xFF:
+		bigEndian = true
+	case body[0] == 0xFF && body[1] == 0xFE:
+		bigEndian = false
+	default:
+		return body
+	}
+	units := make([]uint16, 0, (len(body)-2)/2)
+	for i := 2; i+1 < len(body); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(body[i])<<8|uint16(body[i+1]))
+		} else {
+			units = append(units, uint16(body[i+1])<<8|uint16(body[i]))
+		}
+	}
+	return []byte(string(utf16.Decode(units)))
+}
@@ -0,0 +1,217 @@
+package azure
+
+import (
+	"context"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers/feed"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+)
+
+const (
+	statusPageURL = "https://azure.status.microsoft/en-us/status"
+	feedURL       = "https://rssfeed.azure.status.microsoft/en-us/status/feed/"
+)
+
+func init() {
+	providers.Register("azure", 13, func(logger *zap.Logger, httpClient *http.Client) providers.Provider {
+		return NewAzureProvider(logger, httpClient)
+	})
+}
+
+// AzureProvider scrapes the Azure status page
+// Incidents come from the status feed, the region by service matrix on the page says which services and regions
+// are currently affected and how badly, so it provides the components and impact of the open incidents
+type AzureProvider struct {
+	logger        *zap.Logger
+	httpClient    *http.Client
+	statusPageURL string
+	feedURL       string
+}
+
+func NewAzureProvider(logger *zap.Logger, httpClient *http.Client) *AzureProvider {
+	return &AzureProvider{
+		logger:        logger,
+		httpClient:    httpClient,
+		statusPageURL: statusPageURL,
+		feedURL:       feedURL,
+	}
+}
+
+func (s *AzureProvider) Name() string {
+	return "Azure"
+}
+
+func (s *AzureProvider) Features() api.ProviderFeatures {
+	return api.ProviderFeatures{
+		Provider:        s.Name(),
+		Incidents:       true,
+		IncidentUpdates: false,
+		Components:      true,
+		Maintenance:     false,
+		CurrentStatus:   false,
+		Webhooks:        false,
+		API:             false,
+		HistoryDepth:    "feed length",
+	}
+}
+
+// degradedCell is a service that is not healthy in a region of the status matrix
+type degradedCell struct {
+	service string
+	region  string
+	impact  api.Impact
+}
+
+func (s *AzureProvider) Matches(ctx context.Context, url string) (bool, error) {
+	parsed, err := neturl.Parse(url)
+	if err != nil {
+		return false, nil
+	}
+	switch parsed.Hostname() {
+	case "azure.status.microsoft", "status.azure.com":
+		return true, nil
+	case "azure.microsoft.com":
+		return strings.Contains(parsed.Path, "/status"), nil
+	}
+	return false, nil
+}
+
+func (s *AzureProvider) ScrapeStatusPageCurrent(ctx context.Context, url string) ([]api.Incident, error) {
+	body, err := s.get(ctx, s.feedURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the status feed")
+	}
+	items, ok := feed.Parse(body)
+	if !ok {
+		return nil, errors.New("failed to parse the status feed")
+	}
+
+	page, err := s.get(ctx, s.statusPageURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the status page")
+	}
+	cells, err := parseStatusMatrix(string(page))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the status matrix")
+	}
+
+	var incidents []api.Incident
+	for _, item := range items {
+		published, err := feed.ParseTime(item.Published)
+		if err != nil {
+			s.logger.Info("skipping feed item", zap.String("title", item.Title), zap.Error(err))
+			continue
+		}
+		components, impact := affected(item, cells)
+		description := item.Description
+		updates := []api.IncidentUpdate{api.NewIncidentUpdate(published, api.IncidentStateUpdate, description, s.Name())}
+		// Every item links to the status page so the guid identifies the incident
+		deepLink := url + "#" + item.GUID
+		if item.GUID == "" {
+			deepLink = url + "#" + published.UTC().Format("20060102T150405Z")
+		}
+		incidents = append(incidents, api.NewIncident(strings.TrimSpace(item.Title), components, updates, published, nil, &description, deepLink, impact, url))
+	}
+	return incidents, nil
+}
+
+// ScrapeStatusPageHistorical returns the same incidents as a current scrape, the feed only holds the active incidents
+func (s *AzureProvider) ScrapeStatusPageHistorical(ctx context.Context, url string) ([]api.Incident, error) {
+	return s.ScrapeStatusPageCurrent(ctx, url)
+}
+
+// affected returns the degraded services and regions mentioned by the feed item and the worst impact among them
+// Items that mention no degraded service are reported as minor
+func affected(item feed.Item, cells []degradedCell) ([]string, api.Impact) {
+	text := strings.ToLower(item.Title + " " + item.Description)
+	var components []string
+	seen := make(map[string]bool)
+	impact := api.ImpactMinor
+	for _, cell := range cells {
+		if !strings.Contains(text, strings.ToLower(cell.service)) {
+			continue
+		}
+		for _, component := range []string{cell.service, cell.region} {
+			if !seen[component] {
+				seen[component] = true
+				components = append(components, component)
+			}
+		}
+		if cell.impact.Severity() > impact.Severity() {
+			impact = cell.impact
+		}
+	}
+	return components, impact
+}
+
+// parseStatusMatrix returns the cells of the region by service tables that are not healthy
+// Each table has the regions as header columns and one row per service with a status icon per region
+func parseStatusMatrix(html string) ([]degradedCell, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, err
+	}
+	var cells []degradedCell
+	doc.Find("table.status-table").Each(func(i int, table *goquery.Selection) {
+		var regions []string
+		table.Find("thead th").Each(func(i int, th *goquery.Selection) {
+			regions = append(regions, strings.TrimSpace(th.Text()))
+		})
+		table.Find("tbody tr").Each(func(i int, row *goquery.Selection) {
+			columns := row.Find("td")
+			service := strings.TrimSpace(columns.First().Text())
+			if service == "" {
+				return
+			}
+			columns.Each(func(column int, td *goquery.Selection) {
+				if column == 0 || column >= len(regions) {
+					return
+				}
+				impact, degraded := cellImpact(td.Find("[class*='status-icon']").AttrOr("class", ""))
+				if degraded {
+					cells = append(cells, degradedCell{service: service, region: regions[column], impact: impact})
+				}
+			})
+		})
+	})
+	return cells, nil
+}
+
+func cellImpact(class string) (api.Impact, bool) {
+	switch {
+	case strings.Contains(class, "error"):
+		return api.ImpactMajor, true
+	case strings.Contains(class, "warning"):
+		return api.ImpactMinor, true
+	case strings.Contains(class, "information"):
+		return api.ImpactNone, true
+	}
+	return api.ImpactNone, false
+}
+
+func (s *AzureProvider) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the request")
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to make the get request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the response body")
+	}
+	return body, nil
+}
@@ -0,0 +1,227 @@
+package betterstack
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+)
+
+func init() {
+	providers.Register("betterstack", 71, func(logger *zap.Logger, httpClient *http.Client) providers.Provider {
+		return NewBetterStackProvider(logger, httpClient)
+	})
+}
+
+// BetterStackProvider scrapes Better Stack (formerly Better Uptime) hosted status pages through their index.json
+// The document follows JSON:API, the status reports and their updates are in the included resources
+type BetterStackProvider struct {
+	logger     *zap.Logger
+	httpClient *http.Client
+}
+
+func NewBetterStackProvider(logger *zap.Logger, httpClient *http.Client) *BetterStackProvider {
+	return &BetterStackProvider{
+		logger:     logger,
+		httpClient: httpClient,
+	}
+}
+
+func (s *BetterStackProvider) Name() string {
+	return "Better Stack"
+}
+
+func (s *BetterStackProvider) Features() api.ProviderFeatures {
+	return api.ProviderFeatures{
+		Provider:        s.Name(),
+		Incidents:       true,
+		IncidentUpdates: true,
+		Components:      true,
+		Maintenance:     true,
+		CurrentStatus:   false,
+		Webhooks:        false,
+		API:             true,
+		HistoryDepth:    "recent only",
+	}
+}
+
+type jsonAPIResource struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	Attributes    json.RawMessage `json:"attributes"`
+	Relationships map[string]struct {
+		Data []struct {
+			ID   string `json:"id"`
+			Type string `json:"type"`
+		} `json:"data"`
+	} `json:"relationships"`
+}
+
+type betterStackIndex struct {
+	Data     jsonAPIResource   `json:"data"`
+	Included []jsonAPIResource `json:"included"`
+}
+
+type statusReportAttributes struct {
+	Title          string     `json:"title"`
+	ReportType     string     `json:"report_type"`
+	StartsAt       time.Time  `json:"starts_at"`
+	EndsAt         *time.Time `json:"ends_at"`
+	AggregateState string     `json:"aggregate_state"`
+}
+
+type statusUpdateAttributes struct {
+	Message           string    `json:"message"`
+	PublishedAt       time.Time `json:"published_at"`
+	AffectedResources []struct {
+		StatusPageResourceID string `json:"status_page_resource_id"`
+		Status               string `json:"status"`
+	} `json:"affected_resources"`
+}
+
+type statusPageResourceAttributes struct {
+	PublicName string `json:"public_name"`
+}
+
+// Matches checks that the page serves a Better Stack index.json
+func (s *BetterStackProvider) Matches(ctx context.Context, url string) (bool, error) {
+	index, found, err := s.getIndex(ctx, url)
+	if err != nil {
+		return false, err
+	}
+	return found && index.Data.Type == "status_page", nil
+}
+
+func (s *BetterStackProvider) ScrapeStatusPageCurrent(ctx context.Context, url string) ([]api.Incident, error) {
+	index, found, err := s.getIndex(ctx, url)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the index")
+	}
+	if !found {
+		return nil, errors.New("page is not a better stack page")
+	}
+	return s.parseIncidents(url, index)
+}
+
+// ScrapeStatusPageHistorical returns the same reports as a current scrape, index.json only includes the recent ones
+func (s *BetterStackProvider) ScrapeStatusPageHistorical(ctx context.Context, url string) ([]api.Incident, error) {
+	return s.ScrapeStatusPageCurrent(ctx, url)
+}
+
+func (s *BetterStackProvider) parseIncidents(url string, index *betterStackIndex) ([]api.Incident, error) {
+	resourceNames := make(map[string]string)
+	updates := make(map[string]statusUpdateAttributes)
+	for _, resource := range index.Included {
+		switch resource.Type {
+		case "status_page_resource":
+			var attributes statusPageResourceAttributes
+			if err := json.Unmarshal(resource.Attributes, &attributes); err != nil {
+				return nil, errors.Wrap(err, "failed to unmarshal status page resource")
+			}
+			resourceNames[resource.ID] = attributes.PublicName
+		case "status_update":
+			var attributes statusUpdateAttributes
+			if err := json.Unmarshal(resource.Attributes, &attributes); err != nil {
+				return nil, errors.Wrap(err, "failed to unmarshal status update")
+			}
+			updates[resource.ID] = attributes
+		}
+	}
+
+	var incidents []api.Incident
+	for _, resource := range index.Included {
+		if resource.Type != "status_report" {
+			continue
+		}
+		var report statusReportAttributes
+		if err := json.Unmarshal(resource.Attributes, &report); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal status report")
+		}
+
+		impact := parseState(report.AggregateState)
+		rawImpact := report.AggregateState
+		if report.ReportType == "maintenance" {
+			impact = api.ImpactMaintenance
+		}
+
+		var incidentUpdates []api.IncidentUpdate
+		var components []string
+		seenComponents := make(map[string]bool)
+		for _, ref := range resource.Relationships["status_updates"].Data {
+			update, found := updates[ref.ID]
+			if !found {
+				continue
+			}
+			incidentUpdates = append(incidentUpdates, api.NewIncidentUpdate(update.PublishedAt, api.IncidentStateUpdate, update.Message, s.Name()))
+			for _, affected := range update.AffectedResources {
+				// A resolved report keeps the worst state its components were in
+				if stateImpact := parseState(affected.Status); impact != api.ImpactMaintenance && stateImpact.Severity() > impact.Severity() {
+					impact = stateImpact
+					rawImpact = affected.Status
+				}
+				name := resourceNames[affected.StatusPageResourceID]
+				if name != "" && !seenComponents[name] {
+					seenComponents[name] = true
+					components = append(components, name)
+				}
+			}
+		}
+		sort.SliceStable(incidentUpdates, func(i, j int) bool {
+			return incidentUpdates[i].Time.Before(incidentUpdates[j].Time)
+		})
+		if report.EndsAt != nil && len(incidentUpdates) > 0 {
+			incidentUpdates[len(incidentUpdates)-1].State = api.IncidentStateResolved
+		}
+
+		incident := api.NewIncident(report.Title, components, incidentUpdates, report.StartsAt, report.EndsAt, nil, url+"/incidents/"+resource.ID, impact, url)
+		incident.RawImpact = rawImpact
+		incidents = append(incidents, incident)
+	}
+	return incidents, nil
+}
+
+// parseState maps a Better Stack resource or report state to an impact
+func parseState(state string) api.Impact {
+	switch state {
+	case "downtime":
+		return api.ImpactMajor
+	case "degraded":
+		return api.ImpactMinor
+	case "maintenance":
+		return api.ImpactMaintenance
+	}
+	return api.ImpactNone
+}
+
+// getIndex returns false if the page does not serve a json index
+func (s *BetterStackProvider) getIndex(ctx context.Context, url string) (*betterStackIndex, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/index.json", nil)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to create the request")
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to make the get request to the index")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to read the index response body")
+	}
+	var index betterStackIndex
+	err = json.Unmarshal(body, &index)
+	if err != nil {
+		return nil, false, nil
+	}
+	return &index, true, nil
+}
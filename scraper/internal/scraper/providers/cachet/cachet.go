@@ -0,0 +1,367 @@
+package cachet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// cachetTimeLayout is the layout of the timestamps returned by the Cachet api, they are in UTC
+const cachetTimeLayout = "2006-01-02 15:04:05"
+
+const (
+	currentPerPage  = 20
+	historyPerPage  = 50
+	maxHistoryPages = 50
+)
+
+func init() {
+	providers.Register("cachet", 72, func(logger *zap.Logger, httpClient *http.Client) providers.Provider {
+		return NewCachetProvider(logger, httpClient)
+	})
+}
+
+// CachetProvider scrapes self hosted Cachet status pages through the Cachet REST api
+type CachetProvider struct {
+	logger     *zap.Logger
+	httpClient *http.Client
+}
+
+func NewCachetProvider(logger *zap.Logger, httpClient *http.Client) *CachetProvider {
+	return &CachetProvider{
+		logger:     logger,
+		httpClient: httpClient,
+	}
+}
+
+func (s *CachetProvider) Name() string {
+	return "Cachet"
+}
+
+func (s *CachetProvider) Features() api.ProviderFeatures {
+	return api.ProviderFeatures{
+		Provider:        s.Name(),
+		Incidents:       true,
+		IncidentUpdates: true,
+		Components:      true,
+		Maintenance:     true,
+		CurrentStatus:   false,
+		Webhooks:        false,
+		API:             true,
+		HistoryDepth:    fmt.Sprintf("%d incidents", historyPerPage*maxHistoryPages),
+	}
+}
+
+// Cachet incident statuses
+const (
+	incidentStatusScheduled     = 0
+	incidentStatusInvestigating = 1
+	incidentStatusIdentified    = 2
+	incidentStatusWatching      = 3
+	incidentStatusFixed         = 4
+)
+
+// Cachet component statuses
+const (
+	componentStatusOperational       = 1
+	componentStatusPerformanceIssues = 2
+	componentStatusPartialOutage     = 3
+	componentStatusMajorOutage       = 4
+)
+
+type cachetTime struct {
+	time.Time
+}
+
+func (t *cachetTime) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		return nil
+	}
+	parsed, err := time.ParseInLocation(cachetTimeLayout, s, time.UTC)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+type cachetIncidents struct {
+	Data []cachetIncident `json:"data"`
+	Meta struct {
+		Pagination struct {
+			Links struct {
+				NextPage *string `json:"next_page"`
+			} `json:"links"`
+		} `json:"pagination"`
+	} `json:"meta"`
+}
+
+type cachetIncident struct {
+	ID          int64       `json:"id"`
+	ComponentID int64       `json:"component_id"`
+	Name        string      `json:"name"`
+	Status      int         `json:"status"`
+	Message     string      `json:"message"`
+	ScheduledAt *cachetTime `json:"scheduled_at"`
+	OccurredAt  *cachetTime `json:"occurred_at"`
+	CreatedAt   cachetTime  `json:"created_at"`
+	UpdatedAt   cachetTime  `json:"updated_at"`
+}
+
+type cachetIncidentUpdates struct {
+	Data []struct {
+		Status    int        `json:"status"`
+		Message   string     `json:"message"`
+		CreatedAt cachetTime `json:"created_at"`
+	} `json:"data"`
+}
+
+type cachetComponents struct {
+	Data []struct {
+		ID          int64  `json:"id"`
+		Name        string `json:"name"`
+		Status      int    `json:"status"`
+		Description string `json:"description"`
+		GroupID     int64  `json:"group_id"`
+	} `json:"data"`
+}
+
+type cachetComponentGroups struct {
+	Data []struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	} `json:"data"`
+}
+
+type cachetComponent struct {
+	name   string
+	status int
+}
+
+// Matches checks that the page answers the Cachet ping endpoint
+func (s *CachetProvider) Matches(ctx context.Context, url string) (bool, error) {
+	var ping struct {
+		Data string `json:"data"`
+	}
+	err := s.getJson(ctx, url+"/api/v1/ping", &ping)
+	if err != nil {
+		return false, nil
+	}
+	return ping.Data == "Pong!", nil
+}
+
+func (s *CachetProvider) ScrapeStatusPageCurrent(ctx context.Context, url string) ([]api.Incident, error) {
+	return s.scrape(ctx, url, currentPerPage, 1)
+}
+
+func (s *CachetProvider) ScrapeStatusPageHistorical(ctx context.Context, url string) ([]api.Incident, error) {
+	return s.scrape(ctx, url, historyPerPage, maxHistoryPages)
+}
+
+func (s *CachetProvider) scrape(ctx context.Context, url string, perPage int, pages int) ([]api.Incident, error) {
+	components, err := s.getComponents(ctx, url)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the components")
+	}
+
+	var incidents []api.Incident
+	next := fmt.Sprintf("%s/api/v1/incidents?sort=id&order=desc&per_page=%d", url, perPage)
+	for page := 0; page < pages && next != ""; page++ {
+		var response cachetIncidents
+		err := s.getJson(ctx, next, &response)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get the incidents")
+		}
+		for _, inc := range response.Data {
+			updates, err := s.getUpdates(ctx, url, inc.ID)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get the updates of incident %d", inc.ID)
+			}
+			incidents = append(incidents, s.toIncident(url, inc, updates, components))
+		}
+		next = ""
+		if response.Meta.Pagination.Links.NextPage != nil {
+			next = *response.Meta.Pagination.Links.NextPage
+		}
+	}
+	return incidents, nil
+}
+
+func (s *CachetProvider) toIncident(url string, inc cachetIncident, updates []api.IncidentUpdate, components map[int64]cachetComponent) api.Incident {
+	startTime := inc.CreatedAt.Time
+	if inc.OccurredAt != nil && !inc.OccurredAt.IsZero() {
+		startTime = inc.OccurredAt.Time
+	} else if inc.ScheduledAt != nil && !inc.ScheduledAt.IsZero() {
+		startTime = inc.ScheduledAt.Time
+	}
+
+	var endTime *time.Time
+	if inc.Status == incidentStatusFixed {
+		end := inc.UpdatedAt.Time
+		if len(updates) > 0 {
+			end = updates[len(updates)-1].Time
+		}
+		endTime = &end
+	}
+
+	var componentNames []string
+	component, found := components[inc.ComponentID]
+	if found {
+		componentNames = append(componentNames, component.name)
+	}
+
+	// The first message of a Cachet incident is on the incident itself, later ones are updates
+	// The incident status is the latest one so the first message was posted while investigating
+	firstState := parseStatus(inc.Status)
+	if len(updates) > 0 && inc.Status != incidentStatusScheduled {
+		firstState = api.IncidentStateInvestigating
+	}
+	updates = append([]api.IncidentUpdate{api.NewIncidentUpdate(startTime, firstState, inc.Message, s.Name())}, updates...)
+
+	description := inc.Message
+	incident := api.NewIncident(inc.Name, componentNames, updates, startTime, endTime, &description, fmt.Sprintf("%s/incidents/%d", url, inc.ID), impact(inc, component, found), url)
+	if inc.ScheduledAt != nil && !inc.ScheduledAt.IsZero() {
+		incident.ScheduledStart = &inc.ScheduledAt.Time
+	}
+	return incident
+}
+
+// impact derives the impact of an incident from the status of its component
+// Cachet does not record the impact of an incident, only the current status of components,
+// so resolved incidents or incidents without a component are reported as minor
+func impact(inc cachetIncident, component cachetComponent, found bool) api.Impact {
+	if inc.Status == incidentStatusScheduled {
+		return api.ImpactMaintenance
+	}
+	if inc.Status == incidentStatusFixed || !found {
+		return api.ImpactMinor
+	}
+	switch component.status {
+	case componentStatusMajorOutage:
+		return api.ImpactCritical
+	case componentStatusPartialOutage:
+		return api.ImpactMajor
+	}
+	return api.ImpactMinor
+}
+
+// parseStatus maps a Cachet incident status to a state
+func parseStatus(status int) api.IncidentState {
+	switch status {
+	case incidentStatusScheduled:
+		return api.IncidentStateScheduled
+	case incidentStatusInvestigating:
+		return api.IncidentStateInvestigating
+	case incidentStatusIdentified:
+		return api.IncidentStateIdentified
+	case incidentStatusWatching:
+		return api.IncidentStateMonitoring
+	case incidentStatusFixed:
+		return api.IncidentStateResolved
+	}
+	return api.IncidentStateUnknown
+}
+
+func (s *CachetProvider) getUpdates(ctx context.Context, url string, incidentID int64) ([]api.IncidentUpdate, error) {
+	var response cachetIncidentUpdates
+	err := s.getJson(ctx, fmt.Sprintf("%s/api/v1/incidents/%d/updates?per_page=100", url, incidentID), &response)
+	if err != nil {
+		return nil, err
+	}
+	var updates []api.IncidentUpdate
+	for _, update := range response.Data {
+		updates = append(updates, api.NewIncidentUpdate(update.CreatedAt.Time, parseStatus(update.Status), update.Message, s.Name()))
+	}
+	sort.SliceStable(updates, func(i, j int) bool {
+		return updates[i].Time.Before(updates[j].Time)
+	})
+	return updates, nil
+}
+
+func (s *CachetProvider) getComponents(ctx context.Context, url string) (map[int64]cachetComponent, error) {
+	var response cachetComponents
+	err := s.getJson(ctx, url+"/api/v1/components?per_page=1000", &response)
+	if err != nil {
+		return nil, err
+	}
+	components := make(map[int64]cachetComponent, len(response.Data))
+	for _, component := range response.Data {
+		components[component.ID] = cachetComponent{name: component.Name, status: component.Status}
+	}
+	return components, nil
+}
+
+func (s *CachetProvider) ScrapeComponents(ctx context.Context, url string) ([]api.Component, error) {
+	var response cachetComponents
+	err := s.getJson(ctx, url+"/api/v1/components?per_page=1000", &response)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the components")
+	}
+	var groupsResponse cachetComponentGroups
+	err = s.getJson(ctx, url+"/api/v1/components/groups?per_page=1000", &groupsResponse)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the component groups")
+	}
+	groups := make(map[int64]string, len(groupsResponse.Data))
+	for _, group := range groupsResponse.Data {
+		groups[group.ID] = group.Name
+	}
+
+	components := make([]api.Component, 0, len(response.Data))
+	for _, component := range response.Data {
+		components = append(components, api.Component{
+			StatusPageUrl: url,
+			Name:          component.Name,
+			Group:         groups[component.GroupID],
+			Status:        componentStatus(component.Status),
+			Description:   component.Description,
+		})
+	}
+	return components, nil
+}
+
+// componentStatus maps a Cachet component status onto the Statuspage ones
+func componentStatus(status int) string {
+	switch status {
+	case componentStatusPerformanceIssues:
+		return api.ComponentStatusDegradedPerformance
+	case componentStatusPartialOutage:
+		return api.ComponentStatusPartialOutage
+	case componentStatusMajorOutage:
+		return api.ComponentStatusMajorOutage
+	}
+	return api.ComponentStatusOperational
+}
+
+func (s *CachetProvider) getJson(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the request")
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to make the get request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the response body")
+	}
+	return errors.Wrap(json.Unmarshal(body, v), "failed to unmarshal the response")
+}
@@ -0,0 +1,79 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+type providerNameKey struct{}
+
+// WithName returns a context that records which provider is making the requests made with it
+func WithName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, providerNameKey{}, name)
+}
+
+// NameFromContext returns the name of the provider that the request is made by, or an empty string if it is unknown
+func NameFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(providerNameKey{}).(string)
+	return name
+}
+
+type hintKey struct{}
+
+// WithHint returns a context that records which provider scrapes the status page, so the providers don't have to be matched
+func WithHint(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, hintKey{}, name)
+}
+
+// HintFromContext returns the provider that scrapes the status page, or an empty string if it has to be matched
+func HintFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(hintKey{}).(string)
+	return name
+}
+
+type locationKey struct{}
+
+// WithLocation returns a context that records the timezone the status page being scraped prints local times in
+func WithLocation(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, locationKey{}, loc)
+}
+
+// LocationFromContext returns the timezone the status page prints local times in, or UTC if it is unknown
+func LocationFromContext(ctx context.Context) *time.Location {
+	loc, _ := ctx.Value(locationKey{}).(*time.Location)
+	if loc == nil {
+		return time.UTC
+	}
+	return loc
+}
+
+type nowKey struct{}
+
+// WithNow returns a context whose scrape runs as if it were the given time, so that a recorded scrape can be replayed
+func WithNow(ctx context.Context, now time.Time) context.Context {
+	return context.WithValue(ctx, nowKey{}, now)
+}
+
+// NowFromContext returns the time that the scrape runs at, the current time unless the scrape is replayed
+func NowFromContext(ctx context.Context) time.Time {
+	now, found := ctx.Value(nowKey{}).(time.Time)
+	if !found {
+		return time.Now()
+	}
+	return now
+}
+
+type selectorsKey struct{}
+
+// WithSelectors returns a context that carries the provider definition from the scrape config of the status page
+// being scraped, the status page is scraped with it instead of the registered providers
+func WithSelectors(ctx context.Context, selectors json.RawMessage) context.Context {
+	return context.WithValue(ctx, selectorsKey{}, selectors)
+}
+
+// SelectorsFromContext returns the provider definition that the status page is scraped with, nil if it has none
+func SelectorsFromContext(ctx context.Context) json.RawMessage {
+	selectors, _ := ctx.Value(selectorsKey{}).(json.RawMessage)
+	return selectors
+}
@@ -0,0 +1,192 @@
+package declarative
+
+import (
+	"github.com/kelseyhightower/envconfig"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	FormatHTML = "html"
+	FormatJSON = "json"
+)
+
+// defaultPriority places declarative providers after the built in providers but before the feed fallback
+const defaultPriority = 90
+
+type EnvConfig struct {
+	// Dir is a directory of *.yaml, *.yml and *.json provider definitions
+	Dir string `envconfig:"DECLARATIVE_PROVIDERS_DIR"`
+}
+
+func GetConfigFromEnvironment() (EnvConfig, error) {
+	var config EnvConfig
+	err := envconfig.Process("STATUSPHERE", &config)
+	return config, err
+}
+
+// Config is a declarative provider definition
+// The incidents are either elements matched by a CSS selector in an html document
+// or the elements of an array at a JSON path (gjson syntax) in a json document
+// Fields are found relative to each incident with the same kind of selector or path
+type Config struct {
+	Name string `yaml:"name"`
+	// Priority orders the provider among the others, lower goes first
+	Priority int `yaml:"priority"`
+	// Hosts are the status page hosts the provider scrapes, URLPattern can be used instead to match whole urls
+	Hosts      []string `yaml:"hosts"`
+	URLPattern string   `yaml:"urlPattern"`
+	// Format is html or json
+	Format string `yaml:"format"`
+	// Path is appended to the status page url to get the document with the incidents, e.g. /history.json
+	Path string `yaml:"path"`
+	// Incidents selects each incident in the document
+	Incidents   string `yaml:"incidents"`
+	Title       Field  `yaml:"title"`
+	Link        Field  `yaml:"link"`
+	Start       Field  `yaml:"start"`
+	End         Field  `yaml:"end"`
+	Impact      Field  `yaml:"impact"`
+	Description Field  `yaml:"description"`
+	Components  Field  `yaml:"components"`
+	// Updates optionally selects the updates of each incident
+	Updates *Updates `yaml:"updates"`
+	// TimeLayout is the Go time layout of the timestamps, RFC3339 by default, "unix" for unix seconds or "auto" to
+	// recognise the common formats, including ones without a year such as "Jan 2, 15:04 PST"
+	// Timestamps without a zone are in the timezone of the status page
+	TimeLayout string `yaml:"timeLayout"`
+	// ImpactMap maps the lower cased impact values of the status page to our impacts, other values are normalized, e.g. partial_outage is major
+	ImpactMap map[string]api.Impact `yaml:"impactMap"`
+	// ResolvedStates are the lower cased update states that resolve an incident if it has no end field
+	ResolvedStates []string `yaml:"resolvedStates"`
+
+	urlPattern *regexp.Regexp
+}
+
+// Field selects a value relative to an incident or update
+// In html documents Selector is a CSS selector, the text of the element is used unless Attribute is set
+// and an empty selector selects the incident itself. In json documents Path is a gjson path.
+type Field struct {
+	Selector  string `yaml:"selector"`
+	Attribute string `yaml:"attribute"`
+	Path      string `yaml:"path"`
+}
+
+type Updates struct {
+	Items string `yaml:"items"`
+	Time  Field  `yaml:"time"`
+	State Field  `yaml:"state"`
+	Body  Field  `yaml:"body"`
+}
+
+// Parse parses a YAML or JSON provider definition
+func Parse(data []byte) (Config, error) {
+	var config Config
+	err := yaml.Unmarshal(data, &config)
+	if err != nil {
+		return Config{}, errors.Wrap(err, "failed to parse the provider definition")
+	}
+	if config.Priority == 0 {
+		config.Priority = defaultPriority
+	}
+	if config.TimeLayout == "" {
+		config.TimeLayout = "2006-01-02T15:04:05Z07:00"
+	}
+	return config, config.validate()
+}
+
+// scrapeConfigName is the name of the providers defined in the scrape config of a status page that don't set one
+const scrapeConfigName = "Scrape config"
+
+// ParseForStatusPage parses the selectors of the scrape config of the status page at url
+// They are a provider definition whose name and hosts can be left out, the hosts default to the status page's
+func ParseForStatusPage(data []byte, url string) (Config, error) {
+	var config Config
+	err := yaml.Unmarshal(data, &config)
+	if err != nil {
+		return Config{}, errors.Wrap(err, "failed to parse the selectors")
+	}
+	if config.Name == "" {
+		config.Name = scrapeConfigName
+	}
+	if len(config.Hosts) == 0 && config.URLPattern == "" {
+		parsed, err := neturl.Parse(url)
+		if err != nil {
+			return Config{}, errors.Wrap(err, "failed to parse the status page url")
+		}
+		config.Hosts = []string{parsed.Hostname()}
+	}
+	if config.TimeLayout == "" {
+		config.TimeLayout = "2006-01-02T15:04:05Z07:00"
+	}
+	return config, config.validate()
+}
+
+func (c *Config) validate() error {
+	if c.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(c.Hosts) == 0 && c.URLPattern == "" {
+		return errors.New("hosts or urlPattern is required")
+	}
+	if c.URLPattern != "" {
+		pattern, err := regexp.Compile(c.URLPattern)
+		if err != nil {
+			return errors.Wrap(err, "invalid urlPattern")
+		}
+		c.urlPattern = pattern
+	}
+	if c.Format != FormatHTML && c.Format != FormatJSON {
+		return errors.Errorf("format must be %s or %s", FormatHTML, FormatJSON)
+	}
+	if c.Incidents == "" {
+		return errors.New("incidents is required")
+	}
+	if c.Link.isEmpty() {
+		return errors.New("link is required, it identifies the incident")
+	}
+	if c.Title.isEmpty() || c.Start.isEmpty() {
+		return errors.New("title and start are required")
+	}
+	for impact, mapped := range c.ImpactMap {
+		if mapped.Severity() == -1 {
+			return errors.Errorf("impactMap maps %q to unknown impact %q", impact, mapped)
+		}
+	}
+	return nil
+}
+
+func (f Field) isEmpty() bool {
+	return f.Selector == "" && f.Attribute == "" && f.Path == ""
+}
+
+// LoadDir parses every provider definition in the directory
+func LoadDir(dir string) ([]Config, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the provider definitions directory")
+	}
+	var configs []Config
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", entry.Name())
+		}
+		config, err := Parse(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid provider definition %s", entry.Name())
+		}
+		configs = append(configs, config)
+	}
+	return configs, nil
+}
@@ -0,0 +1,232 @@
+package declarative
+
+import (
+	"context"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/utils"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Register registers a provider for every definition, definitions whose name is already registered are skipped
+func Register(logger *zap.Logger, configs []Config) {
+	for _, config := range configs {
+		name := "declarative:" + config.Name
+		if providers.IsRegistered(name) {
+			logger.Warn("skipping duplicate declarative provider", zap.String("name", config.Name))
+			continue
+		}
+		providers.Register(name, config.Priority, func(logger *zap.Logger, httpClient *http.Client) providers.Provider {
+			return NewDeclarativeProvider(logger, httpClient, config)
+		})
+	}
+}
+
+// DeclarativeProvider scrapes the status pages described by a provider definition
+type DeclarativeProvider struct {
+	logger     *zap.Logger
+	httpClient *http.Client
+	config     Config
+}
+
+func NewDeclarativeProvider(logger *zap.Logger, httpClient *http.Client, config Config) *DeclarativeProvider {
+	return &DeclarativeProvider{
+		logger:     logger,
+		httpClient: httpClient,
+		config:     config,
+	}
+}
+
+func (s *DeclarativeProvider) Name() string {
+	return s.config.Name
+}
+
+func (s *DeclarativeProvider) Features() api.ProviderFeatures {
+	return api.ProviderFeatures{
+		Provider:        s.Name(),
+		Incidents:       true,
+		IncidentUpdates: s.config.Updates != nil,
+		Components:      !s.config.Components.isEmpty(),
+		Maintenance:     false,
+		CurrentStatus:   false,
+		Webhooks:        false,
+		API:             s.config.Format == FormatJSON,
+		HistoryDepth:    "document length",
+	}
+}
+
+func (s *DeclarativeProvider) Matches(ctx context.Context, url string) (bool, error) {
+	if s.config.urlPattern != nil && s.config.urlPattern.MatchString(url) {
+		return true, nil
+	}
+	parsed, err := neturl.Parse(url)
+	if err != nil {
+		return false, nil
+	}
+	return slices.Contains(s.config.Hosts, parsed.Hostname()), nil
+}
+
+func (s *DeclarativeProvider) ScrapeStatusPageCurrent(ctx context.Context, url string) ([]api.Incident, error) {
+	body, err := s.get(ctx, url+s.config.Path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the document")
+	}
+
+	var items []item
+	if s.config.Format == FormatJSON {
+		if !gjson.ValidBytes(body) {
+			return nil, errors.New("document is not valid json")
+		}
+		for _, result := range gjson.GetBytes(body, s.config.Incidents).Array() {
+			items = append(items, jsonItem{result})
+		}
+	} else {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(body)))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse the document")
+		}
+		doc.Find(s.config.Incidents).Each(func(i int, selection *goquery.Selection) {
+			items = append(items, htmlItem{selection})
+		})
+	}
+
+	loc := providers.LocationFromContext(ctx)
+	var incidents []api.Incident
+	for _, it := range items {
+		incident, err := s.toIncident(url, it, loc)
+		if err != nil {
+			s.logger.Info("skipping incident", zap.String("provider", s.Name()), zap.Error(err))
+			continue
+		}
+		incidents = append(incidents, incident)
+	}
+	return incidents, nil
+}
+
+// ScrapeStatusPageHistorical returns the same incidents as a current scrape, a definition describes a single document
+func (s *DeclarativeProvider) ScrapeStatusPageHistorical(ctx context.Context, url string) ([]api.Incident, error) {
+	return s.ScrapeStatusPageCurrent(ctx, url)
+}
+
+func (s *DeclarativeProvider) toIncident(url string, it item, loc *time.Location) (api.Incident, error) {
+	link := it.value(s.config.Link)
+	if link == "" {
+		return api.Incident{}, errors.New("incident has no link")
+	}
+	deepLink, err := resolve(url, link)
+	if err != nil {
+		return api.Incident{}, err
+	}
+	start, err := s.parseTime(it.value(s.config.Start), loc)
+	if err != nil {
+		return api.Incident{}, errors.Wrap(err, "failed to parse the start time")
+	}
+	var end *time.Time
+	if endValue := it.value(s.config.End); !s.config.End.isEmpty() && endValue != "" {
+		parsed, err := s.parseTime(endValue, loc)
+		if err != nil {
+			return api.Incident{}, errors.Wrap(err, "failed to parse the end time")
+		}
+		end = &parsed
+	}
+
+	var updates []api.IncidentUpdate
+	if s.config.Updates != nil {
+		for _, update := range it.children(s.config.Updates.Items) {
+			updateTime, err := s.parseTime(update.value(s.config.Updates.Time), loc)
+			if err != nil {
+				return api.Incident{}, errors.Wrap(err, "failed to parse the update time")
+			}
+			state := update.value(s.config.Updates.State)
+			updates = append(updates, api.NewIncidentUpdate(updateTime, api.ParseIncidentState(state), update.value(s.config.Updates.Body), s.Name()))
+			if end == nil && slices.Contains(s.config.ResolvedStates, strings.ToLower(strings.TrimSpace(state))) {
+				resolvedAt := updateTime
+				end = &resolvedAt
+			}
+		}
+		sort.SliceStable(updates, func(i, j int) bool {
+			return updates[i].Time.Before(updates[j].Time)
+		})
+	}
+
+	var description *string
+	if !s.config.Description.isEmpty() {
+		value := it.value(s.config.Description)
+		description = &value
+	}
+
+	rawImpact := it.value(s.config.Impact)
+	incident := api.NewIncident(it.value(s.config.Title), it.values(s.config.Components), updates, start, end, description, deepLink, s.impact(rawImpact), url)
+	incident.RawImpact = rawImpact
+	return incident, nil
+}
+
+func (s *DeclarativeProvider) impact(value string) api.Impact {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if mapped, found := s.config.ImpactMap[value]; found {
+		return mapped
+	}
+	return api.NormalizeImpact(value)
+}
+
+func (s *DeclarativeProvider) parseTime(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if s.config.TimeLayout == "unix" {
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	if s.config.TimeLayout == "auto" {
+		return utils.ParseTimestamp(value, loc, time.Now())
+	}
+	t, err := time.ParseInLocation(s.config.TimeLayout, value, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC(), nil
+}
+
+func resolve(base string, ref string) (string, error) {
+	baseUrl, err := neturl.Parse(base)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse the page url")
+	}
+	refUrl, err := neturl.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse the incident link")
+	}
+	return baseUrl.ResolveReference(refUrl).String(), nil
+}
+
+func (s *DeclarativeProvider) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the request")
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to make the get request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the response body")
+	}
+	return body, nil
+}
@@ -0,0 +1,101 @@
+package declarative
+
+import (
+	"github.com/PuerkitoBio/goquery"
+	"github.com/tidwall/gjson"
+	"strings"
+)
+
+// item is an incident or update in either an html or a json document
+type item interface {
+	// value returns the first value selected by the field
+	value(field Field) string
+	// values returns every value selected by the field
+	values(field Field) []string
+	// children returns the items selected by a CSS selector or JSON path
+	children(selector string) []item
+}
+
+type htmlItem struct {
+	selection *goquery.Selection
+}
+
+func (h htmlItem) find(field Field) *goquery.Selection {
+	if field.Selector == "" {
+		return h.selection
+	}
+	return h.selection.Find(field.Selector)
+}
+
+func (h htmlItem) value(field Field) string {
+	if field.isEmpty() {
+		return ""
+	}
+	selection := h.find(field).First()
+	if field.Attribute != "" {
+		return strings.TrimSpace(selection.AttrOr(field.Attribute, ""))
+	}
+	return strings.TrimSpace(selection.Text())
+}
+
+func (h htmlItem) values(field Field) []string {
+	if field.isEmpty() {
+		return nil
+	}
+	var values []string
+	h.find(field).Each(func(i int, selection *goquery.Selection) {
+		value := strings.TrimSpace(selection.Text())
+		if field.Attribute != "" {
+			value = strings.TrimSpace(selection.AttrOr(field.Attribute, ""))
+		}
+		if value != "" {
+			values = append(values, value)
+		}
+	})
+	return values
+}
+
+func (h htmlItem) children(selector string) []item {
+	var children []item
+	h.selection.Find(selector).Each(func(i int, selection *goquery.Selection) {
+		children = append(children, htmlItem{selection})
+	})
+	return children
+}
+
+type jsonItem struct {
+	result gjson.Result
+}
+
+func (j jsonItem) value(field Field) string {
+	if field.Path == "" {
+		return ""
+	}
+	return j.result.Get(field.Path).String()
+}
+
+func (j jsonItem) values(field Field) []string {
+	if field.Path == "" {
+		return nil
+	}
+	result := j.result.Get(field.Path)
+	if !result.IsArray() {
+		if result.String() == "" {
+			return nil
+		}
+		return []string{result.String()}
+	}
+	var values []string
+	for _, value := range result.Array() {
+		values = append(values, value.String())
+	}
+	return values
+}
+
+func (j jsonItem) children(path string) []item {
+	var children []item
+	for _, result := range j.result.Get(path).Array() {
+		children = append(children, jsonItem{result})
+	}
+	return children
+}
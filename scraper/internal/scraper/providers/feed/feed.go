@@ -0,0 +1,282 @@
+package feed
+
+import (
+	"context"
+	"encoding/xml"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/utils"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+)
+
+// candidatePaths are the feed paths used by common status page platforms, tried if the page does not link a feed
+var candidatePaths = []string{"/history.rss", "/history.atom", "/feed.rss", "/feed.atom", "/rss", "/feed"}
+
+func init() {
+	// Feeds carry the least information so every other provider is preferred
+	providers.Register("feed", 1000, func(logger *zap.Logger, httpClient *http.Client) providers.Provider {
+		return NewFeedProvider(logger, httpClient)
+	})
+}
+
+// FeedProvider is a generic fallback that turns the items of a status page's RSS or Atom feed into incidents
+// Feeds do not say how severe an incident is so every incident has no impact
+type FeedProvider struct {
+	logger     *zap.Logger
+	httpClient *http.Client
+}
+
+func NewFeedProvider(logger *zap.Logger, httpClient *http.Client) *FeedProvider {
+	return &FeedProvider{
+		logger:     logger,
+		httpClient: httpClient,
+	}
+}
+
+func (s *FeedProvider) Name() string {
+	return "Feed"
+}
+
+func (s *FeedProvider) Features() api.ProviderFeatures {
+	return api.ProviderFeatures{
+		Provider:        s.Name(),
+		Incidents:       true,
+		IncidentUpdates: false,
+		Components:      false,
+		Maintenance:     false,
+		CurrentStatus:   false,
+		Webhooks:        false,
+		API:             false,
+		HistoryDepth:    "feed length",
+	}
+}
+
+type rssFeed struct {
+	XMLName xml.Name `xml:"rss"`
+	Items   []struct {
+		Title       string `xml:"title"`
+		Link        string `xml:"link"`
+		GUID        string `xml:"guid"`
+		PubDate     string `xml:"pubDate"`
+		Description string `xml:"description"`
+	} `xml:"channel>item"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name `xml:"feed"`
+	Entries []struct {
+		Title string `xml:"title"`
+		ID    string `xml:"id"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+		Content   string `xml:"content"`
+		Summary   string `xml:"summary"`
+	} `xml:"entry"`
+}
+
+// Item is an RSS item or Atom entry
+type Item struct {
+	Title string
+	// Link is the link of the item, or its guid or id if it has none
+	Link        string
+	GUID        string
+	Published   string
+	Description string
+}
+
+func (s *FeedProvider) Matches(ctx context.Context, url string) (bool, error) {
+	feedUrl, err := s.findFeed(ctx, url)
+	if err != nil {
+		return false, err
+	}
+	return feedUrl != "", nil
+}
+
+func (s *FeedProvider) ScrapeStatusPageCurrent(ctx context.Context, url string) ([]api.Incident, error) {
+	feedUrl, err := s.findFeed(ctx, url)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find the feed")
+	}
+	if feedUrl == "" {
+		return nil, errors.New("page has no feed")
+	}
+	body, err := s.get(ctx, feedUrl)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the feed")
+	}
+	items, ok := Parse(body)
+	if !ok {
+		return nil, errors.New("failed to parse the feed")
+	}
+
+	var incidents []api.Incident
+	for _, item := range items {
+		incident, err := s.toIncident(url, item)
+		if err != nil {
+			s.logger.Info("skipping feed item", zap.String("title", item.Title), zap.Error(err))
+			continue
+		}
+		incidents = append(incidents, incident)
+	}
+	return incidents, nil
+}
+
+// ScrapeStatusPageHistorical returns the same incidents as a current scrape, a feed only holds the recent items
+func (s *FeedProvider) ScrapeStatusPageHistorical(ctx context.Context, url string) ([]api.Incident, error) {
+	return s.ScrapeStatusPageCurrent(ctx, url)
+}
+
+func (s *FeedProvider) toIncident(url string, item Item) (api.Incident, error) {
+	if item.Link == "" {
+		return api.Incident{}, errors.New("item has no link")
+	}
+	published, err := ParseTime(item.Published)
+	if err != nil {
+		return api.Incident{}, err
+	}
+
+	// Status page feeds usually prefix each update with its state in bold, the most recent first
+	state := api.IncidentStateUnknown
+	body := item.Description
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(item.Description))
+	if err == nil {
+		state = api.ParseIncidentState(doc.Find("strong").First().Text())
+		body = strings.TrimSpace(doc.Text())
+	}
+
+	var endTime *time.Time
+	if state.IsTerminal() {
+		endTime = &published
+	}
+	updates := []api.IncidentUpdate{api.NewIncidentUpdate(published, state, body, s.Name())}
+	return api.NewIncident(strings.TrimSpace(item.Title), nil, updates, published, endTime, &body, item.Link, api.ImpactNone, url), nil
+}
+
+// findFeed returns the feed linked from the page, or the first candidate path that serves a feed
+// It returns an empty string if the page has no feed
+func (s *FeedProvider) findFeed(ctx context.Context, url string) (string, error) {
+	page, err := s.get(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := Parse(page); ok {
+		return url, nil
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(page)))
+	if err == nil {
+		var linked string
+		doc.Find(`link[rel="alternate"]`).EachWithBreak(func(i int, selection *goquery.Selection) bool {
+			feedType := selection.AttrOr("type", "")
+			if feedType == "application/rss+xml" || feedType == "application/atom+xml" {
+				linked = selection.AttrOr("href", "")
+				return false
+			}
+			return true
+		})
+		if linked != "" {
+			return resolve(url, linked)
+		}
+	}
+
+	for _, path := range candidatePaths {
+		body, err := s.get(ctx, url+path)
+		if err != nil {
+			continue
+		}
+		if _, ok := Parse(body); ok {
+			return url + path, nil
+		}
+	}
+	return "", nil
+}
+
+// Parse parses an RSS or Atom document, it returns false if the document is neither
+func Parse(body []byte) ([]Item, bool) {
+	var rss rssFeed
+	if err := xml.Unmarshal(body, &rss); err == nil {
+		var items []Item
+		for _, item := range rss.Items {
+			link := item.Link
+			if link == "" {
+				link = item.GUID
+			}
+			items = append(items, Item{Title: item.Title, Link: link, GUID: item.GUID, Published: item.PubDate, Description: item.Description})
+		}
+		return items, true
+	}
+
+	var atom atomFeed
+	if err := xml.Unmarshal(body, &atom); err == nil {
+		var items []Item
+		for _, entry := range atom.Entries {
+			link := entry.ID
+			for _, l := range entry.Links {
+				if l.Rel == "" || l.Rel == "alternate" {
+					link = l.Href
+					break
+				}
+			}
+			published := entry.Published
+			if published == "" {
+				published = entry.Updated
+			}
+			description := entry.Content
+			if description == "" {
+				description = entry.Summary
+			}
+			items = append(items, Item{Title: entry.Title, Link: link, GUID: entry.ID, Published: published, Description: description})
+		}
+		return items, true
+	}
+	return nil, false
+}
+
+// ParseTime parses the publish time of an item in any of the layouts used by feeds
+// Zone abbreviations are resolved to their offset, time.Parse would treat any but the local ones as UTC
+func ParseTime(value string) (time.Time, error) {
+	return utils.ParseTimestamp(value, time.UTC, time.Now())
+}
+
+func resolve(base string, ref string) (string, error) {
+	baseUrl, err := neturl.Parse(base)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse the page url")
+	}
+	refUrl, err := neturl.Parse(ref)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse the feed url")
+	}
+	return baseUrl.ResolveReference(refUrl).String(), nil
+}
+
+func (s *FeedProvider) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the request")
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to make the get request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the response body")
+	}
+	return body, nil
+}
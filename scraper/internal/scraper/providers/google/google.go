@@ -0,0 +1,193 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+func init() {
+	providers.Register("google-cloud", 11, func(logger *zap.Logger, httpClient *http.Client) providers.Provider {
+		return NewGoogleProvider(logger, httpClient, "Google Cloud", func(u *neturl.URL) bool {
+			return u.Hostname() == "status.cloud.google.com" || u.Hostname() == "status.firebase.google.com"
+		})
+	})
+	providers.Register("google-workspace", 12, func(logger *zap.Logger, httpClient *http.Client) providers.Provider {
+		return NewGoogleProvider(logger, httpClient, "Google Workspace", func(u *neturl.URL) bool {
+			return u.Hostname() == "www.google.com" && strings.HasPrefix(u.Path, "/appsstatus")
+		})
+	})
+}
+
+// GoogleProvider scrapes the Google status dashboards, Google Cloud, Firebase and Google Workspace
+// all publish their incidents in the same incidents.json format next to the dashboard
+type GoogleProvider struct {
+	logger     *zap.Logger
+	httpClient *http.Client
+	name       string
+	matches    func(u *neturl.URL) bool
+}
+
+func NewGoogleProvider(logger *zap.Logger, httpClient *http.Client, name string, matches func(u *neturl.URL) bool) *GoogleProvider {
+	return &GoogleProvider{
+		logger:     logger,
+		httpClient: httpClient,
+		name:       name,
+		matches:    matches,
+	}
+}
+
+func (s *GoogleProvider) Name() string {
+	return s.name
+}
+
+func (s *GoogleProvider) Features() api.ProviderFeatures {
+	return api.ProviderFeatures{
+		Provider:        s.Name(),
+		Incidents:       true,
+		IncidentUpdates: true,
+		Components:      true,
+		Maintenance:     false,
+		CurrentStatus:   false,
+		Webhooks:        false,
+		API:             true,
+		HistoryDepth:    "1 year",
+	}
+}
+
+type googleIncident struct {
+	ID               string     `json:"id"`
+	Begin            time.Time  `json:"begin"`
+	End              *time.Time `json:"end"`
+	ExternalDesc     string     `json:"external_desc"`
+	StatusImpact     string     `json:"status_impact"`
+	Severity         string     `json:"severity"`
+	URI              string     `json:"uri"`
+	AffectedProducts []struct {
+		Title string `json:"title"`
+	} `json:"affected_products"`
+	Updates []struct {
+		When              time.Time `json:"when"`
+		Text              string    `json:"text"`
+		Status            string    `json:"status"`
+		AffectedLocations []struct {
+			ID    string `json:"id"`
+			Title string `json:"title"`
+		} `json:"affected_locations"`
+	} `json:"updates"`
+}
+
+func (s *GoogleProvider) Matches(ctx context.Context, url string) (bool, error) {
+	parsed, err := neturl.Parse(url)
+	if err != nil {
+		return false, nil
+	}
+	return s.matches(parsed), nil
+}
+
+// ScrapeStatusPageCurrent returns every published incident, the dashboard only serves the whole history
+func (s *GoogleProvider) ScrapeStatusPageCurrent(ctx context.Context, url string) ([]api.Incident, error) {
+	var response []googleIncident
+	err := s.getJson(ctx, strings.TrimSuffix(url, "/")+"/incidents.json", &response)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the incidents")
+	}
+	incidents := make([]api.Incident, 0, len(response))
+	for _, inc := range response {
+		incidents = append(incidents, s.toIncident(url, inc))
+	}
+	return incidents, nil
+}
+
+func (s *GoogleProvider) ScrapeStatusPageHistorical(ctx context.Context, url string) ([]api.Incident, error) {
+	return s.ScrapeStatusPageCurrent(ctx, url)
+}
+
+func (s *GoogleProvider) toIncident(url string, inc googleIncident) api.Incident {
+	var components []string
+	seen := make(map[string]bool)
+	addComponent := func(component string) {
+		if component != "" && !seen[component] {
+			seen[component] = true
+			components = append(components, component)
+		}
+	}
+	for _, product := range inc.AffectedProducts {
+		addComponent(product.Title)
+	}
+
+	var updates []api.IncidentUpdate
+	for _, update := range inc.Updates {
+		for _, location := range update.AffectedLocations {
+			// The location id is the region name, e.g. us-central1
+			addComponent(location.ID)
+		}
+		state := api.IncidentStateUpdate
+		if update.Status == "AVAILABLE" {
+			state = api.IncidentStateResolved
+		}
+		updates = append(updates, api.NewIncidentUpdate(update.When, state, update.Text, s.Name()))
+	}
+	sort.SliceStable(updates, func(i, j int) bool {
+		return updates[i].Time.Before(updates[j].Time)
+	})
+
+	deepLink := strings.TrimSuffix(url, "/") + "/" + strings.TrimPrefix(inc.URI, "/")
+	if inc.URI == "" {
+		deepLink = strings.TrimSuffix(url, "/") + "/incidents/" + inc.ID
+	}
+	incident := api.NewIncident(inc.ExternalDesc, components, updates, inc.Begin, inc.End, nil, deepLink, impact(inc.StatusImpact, inc.Severity), url)
+	incident.RawImpact = inc.StatusImpact
+	if incident.RawImpact == "" {
+		incident.RawImpact = inc.Severity
+	}
+	return incident
+}
+
+// impact maps the status impact of an incident to an impact, falling back to its severity
+func impact(statusImpact string, severity string) api.Impact {
+	switch statusImpact {
+	case "SERVICE_OUTAGE":
+		return api.ImpactCritical
+	case "SERVICE_DISRUPTION":
+		return api.ImpactMajor
+	case "SERVICE_INFORMATION":
+		return api.ImpactMinor
+	}
+	switch severity {
+	case "high":
+		return api.ImpactMajor
+	case "medium", "low":
+		return api.ImpactMinor
+	}
+	return api.ImpactNone
+}
+
+func (s *GoogleProvider) getJson(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the request")
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to make the get request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the response body")
+	}
+	return errors.Wrap(json.Unmarshal(body, v), "failed to unmarshal the response")
+}
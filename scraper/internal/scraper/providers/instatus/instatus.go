@@ -0,0 +1,147 @@
+package instatus
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"time"
+)
+
+func init() {
+	providers.Register("instatus", 60, func(logger *zap.Logger, httpClient *http.Client) providers.Provider {
+		return NewInstatusProvider(logger, httpClient)
+	})
+}
+
+// InstatusProvider scrapes Instatus hosted status pages through their public summary.json endpoint
+// Instatus only publishes the active incidents and maintenances so there is no history to scrape
+type InstatusProvider struct {
+	logger     *zap.Logger
+	httpClient *http.Client
+}
+
+func NewInstatusProvider(logger *zap.Logger, httpClient *http.Client) *InstatusProvider {
+	return &InstatusProvider{
+		logger:     logger,
+		httpClient: httpClient,
+	}
+}
+
+func (s *InstatusProvider) Name() string {
+	return "Instatus"
+}
+
+func (s *InstatusProvider) Features() api.ProviderFeatures {
+	return api.ProviderFeatures{
+		Provider:        s.Name(),
+		Incidents:       true,
+		IncidentUpdates: false,
+		Components:      false,
+		Maintenance:     true,
+		CurrentStatus:   false,
+		Webhooks:        false,
+		API:             true,
+		HistoryDepth:    "active only",
+	}
+}
+
+type instatusSummary struct {
+	Page struct {
+		Name   string `json:"name"`
+		URL    string `json:"url"`
+		Status string `json:"status"`
+	} `json:"page"`
+	ActiveIncidents    []instatusIncident    `json:"activeIncidents"`
+	ActiveMaintenances []instatusMaintenance `json:"activeMaintenances"`
+}
+
+type instatusIncident struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name"`
+	Started time.Time `json:"started"`
+	Status  string    `json:"status"`
+	Impact  string    `json:"impact"`
+	URL     string    `json:"url"`
+}
+
+type instatusMaintenance struct {
+	ID     string    `json:"id"`
+	Name   string    `json:"name"`
+	Start  time.Time `json:"start"`
+	Status string    `json:"status"`
+	URL    string    `json:"url"`
+}
+
+// Matches checks that the page serves the Instatus summary
+func (s *InstatusProvider) Matches(ctx context.Context, url string) (bool, error) {
+	summary, found, err := s.getSummary(ctx, url)
+	if err != nil {
+		return false, err
+	}
+	return found && summary.Page.Name != "" && summary.Page.Status != "", nil
+}
+
+func (s *InstatusProvider) ScrapeStatusPageCurrent(ctx context.Context, url string) ([]api.Incident, error) {
+	summary, found, err := s.getSummary(ctx, url)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the summary")
+	}
+	if !found {
+		return nil, errors.New("page is not an instatus page")
+	}
+
+	var incidents []api.Incident
+	for _, inc := range summary.ActiveIncidents {
+		// The impact is the component status the incident causes, e.g. PARTIALOUTAGE
+		incident := api.NewIncident(inc.Name, nil, nil, inc.Started, nil, nil, deepLink(url, inc.URL, inc.ID), api.NormalizeImpact(inc.Impact), url)
+		incident.RawImpact = inc.Impact
+		incidents = append(incidents, incident)
+	}
+	for _, maintenance := range summary.ActiveMaintenances {
+		incidents = append(incidents, api.NewIncident(maintenance.Name, nil, nil, maintenance.Start, nil, nil, deepLink(url, maintenance.URL, maintenance.ID), api.ImpactMaintenance, url))
+	}
+	return incidents, nil
+}
+
+func (s *InstatusProvider) ScrapeStatusPageHistorical(ctx context.Context, url string) ([]api.Incident, error) {
+	return s.ScrapeStatusPageCurrent(ctx, url)
+}
+
+func deepLink(pageUrl string, incidentUrl string, id string) string {
+	if incidentUrl != "" {
+		return incidentUrl
+	}
+	return pageUrl + "/" + id
+}
+
+// getSummary returns false if the page does not serve a json summary
+func (s *InstatusProvider) getSummary(ctx context.Context, url string) (*instatusSummary, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/summary.json", nil)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to create the request")
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to make the get request to the summary")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to read the summary response body")
+	}
+	var summary instatusSummary
+	err = json.Unmarshal(body, &summary)
+	if err != nil {
+		return nil, false, nil
+	}
+	return &summary, true, nil
+}
@@ -0,0 +1,162 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SandboxHost is the host of the synthetic status pages served by the mock provider
+// The .invalid TLD is reserved so these urls can never clash with a real status page
+const SandboxHost = "sandbox.statusphere.invalid"
+
+const sandboxUrlPrefix = "https://" + SandboxHost + "/"
+
+// IsSandboxURL returns true if the url is a synthetic status page served by the mock provider
+func IsSandboxURL(url string) bool {
+	return strings.HasPrefix(url, sandboxUrlPrefix)
+}
+
+// SandboxURL returns the url of the synthetic status page with the given slug
+func SandboxURL(slug string) string {
+	return sandboxUrlPrefix + slug
+}
+
+// MockProvider generates a realistic, deterministic stream of synthetic incidents for sandbox status pages
+// Time is split into slots, each slot may contain one incident that progresses through the usual
+// investigating, identified, monitoring and resolved updates as time passes
+type MockProvider struct {
+	logger *zap.Logger
+	now    func() time.Time
+}
+
+func NewMockProvider(logger *zap.Logger) *MockProvider {
+	return &MockProvider{
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+func (s *MockProvider) Name() string {
+	return "Mock"
+}
+
+func (s *MockProvider) Features() api.ProviderFeatures {
+	return api.ProviderFeatures{
+		Provider:        s.Name(),
+		Incidents:       true,
+		IncidentUpdates: true,
+		Components:      true,
+		Maintenance:     true,
+		CurrentStatus:   false,
+		Webhooks:        false,
+		API:             false,
+		HistoryDepth:    "90 days",
+	}
+}
+
+const (
+	slotLength               = 6 * time.Hour
+	incidentProbability      = 0.3
+	currentScrapeLookback    = 3 * 24 * time.Hour
+	historicalScrapeLookback = 90 * 24 * time.Hour
+)
+
+func init() {
+	// The mock provider only accepts sandbox urls and makes no requests so it goes first
+	providers.Register("mock", 0, func(logger *zap.Logger, httpClient *http.Client) providers.Provider {
+		return NewMockProvider(logger)
+	})
+}
+
+func (s *MockProvider) Matches(ctx context.Context, url string) (bool, error) {
+	return IsSandboxURL(url), nil
+}
+
+func (s *MockProvider) ScrapeStatusPageCurrent(ctx context.Context, url string) ([]api.Incident, error) {
+	if !IsSandboxURL(url) {
+		return nil, errors.New("page is not a sandbox page")
+	}
+	return s.generateIncidents(url, s.now().Add(-currentScrapeLookback), s.now()), nil
+}
+
+func (s *MockProvider) ScrapeStatusPageHistorical(ctx context.Context, url string) ([]api.Incident, error) {
+	if !IsSandboxURL(url) {
+		return nil, errors.New("page is not a sandbox page")
+	}
+	return s.generateIncidents(url, s.now().Add(-historicalScrapeLookback), s.now()), nil
+}
+
+var mockTitles = []string{
+	"Elevated error rates",
+	"Increased latency",
+	"Degraded performance",
+	"Partial outage",
+	"Delayed processing of background jobs",
+	"Login failures for some users",
+}
+
+var mockComponents = []string{"API", "Dashboard", "Webhooks", "Authentication", "eu-west-1", "us-east-1"}
+
+var mockImpacts = []api.Impact{api.ImpactMinor, api.ImpactMinor, api.ImpactMajor, api.ImpactCritical, api.ImpactMaintenance}
+
+// generateIncidents returns the incidents in every slot between from and now that has started by now
+func (s *MockProvider) generateIncidents(url string, from time.Time, now time.Time) []api.Incident {
+	var incidents []api.Incident
+	for slot := from.Unix() / int64(slotLength.Seconds()); slot <= now.Unix()/int64(slotLength.Seconds()); slot++ {
+		incident, ok := generateIncident(url, slot, now)
+		if ok {
+			incidents = append(incidents, incident)
+		}
+	}
+	return incidents
+}
+
+func generateIncident(url string, slot int64, now time.Time) (api.Incident, bool) {
+	h := fnv.New64a()
+	_, _ = fmt.Fprintf(h, "%s/%d", url, slot)
+	rng := rand.New(rand.NewSource(int64(h.Sum64())))
+	if rng.Float64() > incidentProbability {
+		return api.Incident{}, false
+	}
+
+	slotStart := time.Unix(slot*int64(slotLength.Seconds()), 0).UTC()
+	startTime := slotStart.Add(time.Duration(rng.Int63n(int64(slotLength / 2))))
+	if startTime.After(now) {
+		return api.Incident{}, false
+	}
+	duration := 20*time.Minute + time.Duration(rng.Int63n(int64(3*time.Hour)))
+	endTime := startTime.Add(duration)
+
+	impact := mockImpacts[rng.Intn(len(mockImpacts))]
+	title := mockTitles[rng.Intn(len(mockTitles))]
+	if impact == api.ImpactMaintenance {
+		title = "Scheduled maintenance"
+	}
+	component := mockComponents[rng.Intn(len(mockComponents))]
+
+	states := []api.IncidentState{api.IncidentStateInvestigating, api.IncidentStateIdentified, api.IncidentStateMonitoring, api.IncidentStateResolved}
+	var updates []api.IncidentUpdate
+	for i, state := range states {
+		updateTime := startTime.Add(duration * time.Duration(i) / time.Duration(len(states)-1))
+		if updateTime.After(now) {
+			break
+		}
+		updates = append(updates, api.NewIncidentUpdate(updateTime, state, fmt.Sprintf("%s: %s affecting %s.", state, strings.ToLower(title), component), "Mock"))
+	}
+
+	description := fmt.Sprintf("Synthetic incident affecting %s.", component)
+	incident := api.NewIncident(title, []string{component}, updates, startTime, nil, &description, fmt.Sprintf("%s/incidents/%d", url, slot), impact, url)
+	if !endTime.After(now) {
+		incident.EndTime = &endTime
+	}
+	return incident, true
+}
@@ -0,0 +1,49 @@
+package providers
+
+import (
+	"context"
+	"github.com/metoro-io/statusphere/common/api"
+)
+
+// Provider scrapes one status page format, each provider lives in its own package and registers itself with Register
+type Provider interface {
+	// Matches returns true if the status page at the given URL is in the format this provider understands
+	// The scraper only scrapes a page with the first provider that matches it
+	Matches(ctx context.Context, url string) (bool, error)
+
+	// ScrapeStatusPageHistorical scrapes the status page at the given URL and returns a list of incidents
+	// The incidents are historical, meaning they are not just the current incidents, this can be expected to return a large number of incidents
+	// And take a long time to run, so we should only run this infrequently, maybe once per week per page
+	ScrapeStatusPageHistorical(ctx context.Context, url string) ([]api.Incident, error)
+
+	// ScrapeStatusPageCurrent scrapes the status page at the given URL and returns a list of incidents
+	// The incidents are current, meaning they are only the recent incidents, this can be expected to return a small number of incidents
+	// And take a short time to run, so we should run this frequently, maybe once per 5 minutes per page
+	ScrapeStatusPageCurrent(ctx context.Context, url string) ([]api.Incident, error)
+	Name() string
+
+	// Features describes what data the provider extracts
+	Features() api.ProviderFeatures
+}
+
+// ComponentProvider is implemented by providers that can scrape the component list of a status page
+type ComponentProvider interface {
+	// ScrapeComponents returns the components of the status page with their current status
+	ScrapeComponents(ctx context.Context, url string) ([]api.Component, error)
+}
+
+// BackfillProvider is implemented by providers that can walk the whole incident archive of a status page
+// Providers that don't implement it are backfilled with a historical scrape
+type BackfillProvider interface {
+	// Backfill passes every incident in the archive of the status page to consume, a page of the archive at a time
+	// It walks back until the archive runs out rather than stopping after a fixed number of pages
+	// Each page is passed with the cursor of the page after it, a backfill started with that cursor resumes from there
+	// An empty cursor starts at the beginning of the archive
+	Backfill(ctx context.Context, url string, cursor string, consume func(incidents []api.Incident, cursor string) error) error
+}
+
+// StatusProvider is implemented by providers that can scrape the overall status that a status page shows
+type StatusProvider interface {
+	// ScrapeStatus returns the indicator and description of the current status of the status page
+	ScrapeStatus(ctx context.Context, url string) (api.StatusSnapshot, error)
+}
@@ -0,0 +1,66 @@
+package providers
+
+import (
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// Factory creates a provider
+type Factory func(logger *zap.Logger, httpClient *http.Client) Provider
+
+type registration struct {
+	name     string
+	priority int
+	factory  Factory
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]registration{}
+)
+
+// Register makes a provider available to the scraper, it is called from the init function of the provider package
+// Providers are tried in ascending priority order, cheap providers that make no requests should have a low priority
+// It panics if a provider with the same name has already been registered
+func Register(name string, priority int, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, found := registry[name]; found {
+		panic(fmt.Sprintf("provider %s registered twice", name))
+	}
+	registry[name] = registration{name: name, priority: priority, factory: factory}
+}
+
+// IsRegistered returns true if a provider with the name has been registered
+func IsRegistered(name string) bool {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	_, found := registry[name]
+	return found
+}
+
+// NewRegisteredProviders creates every registered provider in priority order
+// A provider package has to be imported, usually for its side effects, for it to be registered
+func NewRegisteredProviders(logger *zap.Logger, httpClient *http.Client) []Provider {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registrations := make([]registration, 0, len(registry))
+	for _, r := range registry {
+		registrations = append(registrations, r)
+	}
+	sort.Slice(registrations, func(i, j int) bool {
+		if registrations[i].priority != registrations[j].priority {
+			return registrations[i].priority < registrations[j].priority
+		}
+		return registrations[i].name < registrations[j].name
+	})
+
+	providers := make([]Provider, 0, len(registrations))
+	for _, r := range registrations {
+		providers = append(providers, r.factory(logger, httpClient))
+	}
+	return providers
+}
@@ -0,0 +1,245 @@
+package salesforce
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const trustAPIURL = "https://api.status.salesforce.com/v1"
+
+const (
+	pageSize                 = 100
+	maxPages                 = 20
+	currentScrapeLookback    = 7 * 24 * time.Hour
+	historicalScrapeLookback = 365 * 24 * time.Hour
+)
+
+func init() {
+	providers.Register("salesforce", 14, func(logger *zap.Logger, httpClient *http.Client) providers.Provider {
+		return NewSalesforceProvider(logger, httpClient)
+	})
+}
+
+// SalesforceProvider scrapes status.salesforce.com through the Salesforce Trust api
+// Incidents and maintenances are reported per instance (e.g. NA1) so the instances are the components
+type SalesforceProvider struct {
+	logger     *zap.Logger
+	httpClient *http.Client
+	apiURL     string
+}
+
+func NewSalesforceProvider(logger *zap.Logger, httpClient *http.Client) *SalesforceProvider {
+	return &SalesforceProvider{
+		logger:     logger,
+		httpClient: httpClient,
+		apiURL:     trustAPIURL,
+	}
+}
+
+func (s *SalesforceProvider) Name() string {
+	return "Salesforce"
+}
+
+func (s *SalesforceProvider) Features() api.ProviderFeatures {
+	return api.ProviderFeatures{
+		Provider:        s.Name(),
+		Incidents:       true,
+		IncidentUpdates: true,
+		Components:      true,
+		Maintenance:     true,
+		CurrentStatus:   false,
+		Webhooks:        false,
+		API:             true,
+		HistoryDepth:    "1 year",
+	}
+}
+
+type trustIncident struct {
+	ID           int64     `json:"id"`
+	InstanceKeys []string  `json:"instanceKeys"`
+	ServiceKeys  []string  `json:"serviceKeys"`
+	CreatedAt    time.Time `json:"createdAt"`
+	Impacts      []struct {
+		StartTime time.Time  `json:"startTime"`
+		EndTime   *time.Time `json:"endTime"`
+		Type      string     `json:"type"`
+		Severity  string     `json:"severity"`
+	} `json:"IncidentImpacts"`
+	Events []struct {
+		Type      string    `json:"type"`
+		Message   string    `json:"message"`
+		CreatedAt time.Time `json:"createdAt"`
+	} `json:"IncidentEvents"`
+}
+
+type trustMaintenance struct {
+	ID               int64     `json:"id"`
+	Name             string    `json:"name"`
+	Status           string    `json:"status"`
+	PlannedStartTime time.Time `json:"plannedStartTime"`
+	PlannedEndTime   time.Time `json:"plannedEndTime"`
+	InstanceKeys     []string  `json:"instanceKeys"`
+	ServiceKeys      []string  `json:"serviceKeys"`
+	Message          struct {
+		MaintenanceType string `json:"maintenanceType"`
+	} `json:"message"`
+}
+
+func (s *SalesforceProvider) Matches(ctx context.Context, url string) (bool, error) {
+	parsed, err := neturl.Parse(url)
+	if err != nil {
+		return false, nil
+	}
+	return parsed.Hostname() == "status.salesforce.com", nil
+}
+
+func (s *SalesforceProvider) ScrapeStatusPageCurrent(ctx context.Context, url string) ([]api.Incident, error) {
+	return s.scrape(ctx, url, providers.NowFromContext(ctx).Add(-currentScrapeLookback))
+}
+
+func (s *SalesforceProvider) ScrapeStatusPageHistorical(ctx context.Context, url string) ([]api.Incident, error) {
+	return s.scrape(ctx, url, providers.NowFromContext(ctx).Add(-historicalScrapeLookback))
+}
+
+func (s *SalesforceProvider) scrape(ctx context.Context, url string, since time.Time) ([]api.Incident, error) {
+	var incidents []api.Incident
+	for page := 0; page < maxPages; page++ {
+		var response []trustIncident
+		err := s.getJson(ctx, s.pageURL("incidents", since, page), &response)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get the incidents")
+		}
+		for _, inc := range response {
+			incidents = append(incidents, s.incidentToIncident(url, inc))
+		}
+		if len(response) < pageSize {
+			break
+		}
+	}
+	for page := 0; page < maxPages; page++ {
+		var response []trustMaintenance
+		err := s.getJson(ctx, s.pageURL("maintenances", since, page), &response)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get the maintenances")
+		}
+		for _, maintenance := range response {
+			incidents = append(incidents, s.maintenanceToIncident(url, maintenance))
+		}
+		if len(response) < pageSize {
+			break
+		}
+	}
+	return incidents, nil
+}
+
+func (s *SalesforceProvider) pageURL(resource string, since time.Time, page int) string {
+	query := neturl.Values{}
+	query.Set("startTime", since.UTC().Format(time.RFC3339))
+	query.Set("limit", fmt.Sprint(pageSize))
+	query.Set("offset", fmt.Sprint(page*pageSize))
+	return fmt.Sprintf("%s/%s?%s", s.apiURL, resource, query.Encode())
+}
+
+func (s *SalesforceProvider) incidentToIncident(url string, inc trustIncident) api.Incident {
+	startTime := inc.CreatedAt
+	var endTime *time.Time
+	resolved := len(inc.Impacts) > 0
+	impact := api.ImpactMinor
+	var rawImpact string
+	var impactTypes []string
+	for _, incidentImpact := range inc.Impacts {
+		if incidentImpact.StartTime.Before(startTime) {
+			startTime = incidentImpact.StartTime
+		}
+		if incidentImpact.EndTime == nil {
+			resolved = false
+		} else if endTime == nil || incidentImpact.EndTime.After(*endTime) {
+			endTime = incidentImpact.EndTime
+		}
+		if incidentImpact.Severity == "major" {
+			impact = api.ImpactMajor
+		}
+		if rawImpact == "" || incidentImpact.Severity == "major" {
+			rawImpact = incidentImpact.Severity
+		}
+		impactTypes = append(impactTypes, incidentImpact.Type)
+	}
+	if !resolved {
+		endTime = nil
+	}
+
+	var updates []api.IncidentUpdate
+	for _, event := range inc.Events {
+		updates = append(updates, api.NewIncidentUpdate(event.CreatedAt, api.IncidentStateUpdate, event.Message, s.Name()))
+	}
+	sort.SliceStable(updates, func(i, j int) bool {
+		return updates[i].Time.Before(updates[j].Time)
+	})
+	if resolved && len(updates) > 0 {
+		updates[len(updates)-1].State = api.IncidentStateResolved
+	}
+
+	title := fmt.Sprintf("Incident #%d", inc.ID)
+	if len(impactTypes) > 0 {
+		title = fmt.Sprintf("%s: %s", title, strings.Join(impactTypes, ", "))
+	}
+	incident := api.NewIncident(title, components(inc.InstanceKeys, inc.ServiceKeys), updates, startTime, endTime, nil, fmt.Sprintf("%s/incidents/%d", url, inc.ID), impact, url)
+	incident.RawImpact = rawImpact
+	return incident
+}
+
+func (s *SalesforceProvider) maintenanceToIncident(url string, maintenance trustMaintenance) api.Incident {
+	var endTime *time.Time
+	if strings.Contains(strings.ToLower(maintenance.Status), "complete") {
+		endTime = &maintenance.PlannedEndTime
+	}
+	title := maintenance.Name
+	if title == "" {
+		title = fmt.Sprintf("Maintenance #%d", maintenance.ID)
+	}
+	if maintenance.Message.MaintenanceType != "" {
+		title = fmt.Sprintf("%s (%s)", title, maintenance.Message.MaintenanceType)
+	}
+	updates := []api.IncidentUpdate{api.NewIncidentUpdate(maintenance.PlannedStartTime, api.ParseIncidentState(maintenance.Status), maintenance.Status, s.Name())}
+	incident := api.NewIncident(title, components(maintenance.InstanceKeys, maintenance.ServiceKeys), updates, maintenance.PlannedStartTime, endTime, nil, fmt.Sprintf("%s/maintenances/%d", url, maintenance.ID), api.ImpactMaintenance, url)
+	incident.ScheduledStart = &maintenance.PlannedStartTime
+	incident.ScheduledEnd = &maintenance.PlannedEndTime
+	return incident
+}
+
+// components returns the affected instances followed by the affected services
+func components(instanceKeys []string, serviceKeys []string) []string {
+	return append(append([]string{}, instanceKeys...), serviceKeys...)
+}
+
+func (s *SalesforceProvider) getJson(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the request")
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to make the get request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the response body")
+	}
+	return errors.Wrap(json.Unmarshal(body, v), "failed to unmarshal the response")
+}
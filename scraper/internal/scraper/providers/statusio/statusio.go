@@ -0,0 +1,278 @@
+package statusio
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"regexp"
+	"sort"
+	"time"
+)
+
+const publicAPIURL = "https://api.status.io/1.0/status/"
+
+// knownPageIDs are the status.io page ids of status pages whose html does not reference them
+var knownPageIDs = map[string]string{
+	"status.gitlab.com": "5b36dc6502d06804c08349f7",
+}
+
+// pageIDPattern finds the status.io page id referenced by the html of a hosted status page
+var pageIDPattern = regexp.MustCompile(`(?:api\.status\.io/1\.0/status/|/pages/(?:history/)?|statuspage_id["']?\s*[:=]\s*["'])([0-9a-f]{24})`)
+
+// Message states and component status codes used by status.io
+const (
+	stateInvestigating = 100
+	stateIdentified    = 200
+	stateMonitoring    = 300
+
+	statusOperational         = 100
+	statusPlannedMaintenance  = 200
+	statusDegradedPerformance = 300
+	statusPartialDisruption   = 400
+	statusServiceDisruption   = 500
+	statusSecurityEvent       = 600
+)
+
+func init() {
+	providers.Register("statusio", 73, func(logger *zap.Logger, httpClient *http.Client) providers.Provider {
+		return NewStatusIoProvider(logger, httpClient)
+	})
+}
+
+// StatusIoProvider scrapes status.io hosted status pages, such as status.gitlab.com, through the status.io public api
+// Every message of an incident reports the status of the affected components so each message records
+// the component status changes it made. The public api only returns the active incidents and maintenances.
+type StatusIoProvider struct {
+	logger     *zap.Logger
+	httpClient *http.Client
+	apiURL     string
+}
+
+func NewStatusIoProvider(logger *zap.Logger, httpClient *http.Client) *StatusIoProvider {
+	return &StatusIoProvider{
+		logger:     logger,
+		httpClient: httpClient,
+		apiURL:     publicAPIURL,
+	}
+}
+
+func (s *StatusIoProvider) Name() string {
+	return "status.io"
+}
+
+func (s *StatusIoProvider) Features() api.ProviderFeatures {
+	return api.ProviderFeatures{
+		Provider:        s.Name(),
+		Incidents:       true,
+		IncidentUpdates: true,
+		Components:      true,
+		Maintenance:     true,
+		CurrentStatus:   false,
+		Webhooks:        false,
+		API:             true,
+		HistoryDepth:    "active only",
+	}
+}
+
+type statusIoResponse struct {
+	Result struct {
+		Incidents   []statusIoIncident `json:"incidents"`
+		Maintenance struct {
+			Active []statusIoIncident `json:"active"`
+		} `json:"maintenance"`
+	} `json:"result"`
+}
+
+type statusIoIncident struct {
+	ID                 string    `json:"_id"`
+	Name               string    `json:"name"`
+	DatetimeOpen       time.Time `json:"datetime_open"`
+	ComponentsAffected []struct {
+		Name string `json:"name"`
+	} `json:"components_affected"`
+	ContainersAffected []struct {
+		Name string `json:"name"`
+	} `json:"containers_affected"`
+	Messages []struct {
+		Details  string    `json:"details"`
+		State    int       `json:"state"`
+		Status   int       `json:"status"`
+		Datetime time.Time `json:"datetime"`
+	} `json:"messages"`
+}
+
+func (s *StatusIoProvider) Matches(ctx context.Context, url string) (bool, error) {
+	pageID, err := s.pageID(ctx, url)
+	if err != nil {
+		return false, err
+	}
+	return pageID != "", nil
+}
+
+func (s *StatusIoProvider) ScrapeStatusPageCurrent(ctx context.Context, url string) ([]api.Incident, error) {
+	pageID, err := s.pageID(ctx, url)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find the status.io page id")
+	}
+	if pageID == "" {
+		return nil, errors.New("page is not a status.io page")
+	}
+
+	var response statusIoResponse
+	err = s.getJson(ctx, s.apiURL+pageID, &response)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the status")
+	}
+
+	var incidents []api.Incident
+	for _, inc := range response.Result.Incidents {
+		incidents = append(incidents, s.toIncident(url, inc, false))
+	}
+	for _, maintenance := range response.Result.Maintenance.Active {
+		incidents = append(incidents, s.toIncident(url, maintenance, true))
+	}
+	return incidents, nil
+}
+
+func (s *StatusIoProvider) ScrapeStatusPageHistorical(ctx context.Context, url string) ([]api.Incident, error) {
+	return s.ScrapeStatusPageCurrent(ctx, url)
+}
+
+func (s *StatusIoProvider) toIncident(url string, inc statusIoIncident, maintenance bool) api.Incident {
+	var components []string
+	for _, component := range inc.ComponentsAffected {
+		components = append(components, component.Name)
+	}
+	for _, container := range inc.ContainersAffected {
+		components = append(components, container.Name)
+	}
+
+	messages := inc.Messages
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].Datetime.Before(messages[j].Datetime)
+	})
+
+	impact := api.ImpactNone
+	previousStatus := statusOperational
+	var updates []api.IncidentUpdate
+	for _, message := range messages {
+		update := api.NewIncidentUpdate(message.Datetime, parseState(message.State, maintenance), message.Details, s.Name())
+		if message.Status != previousStatus {
+			for _, component := range inc.ComponentsAffected {
+				update.ComponentChanges = append(update.ComponentChanges, api.ComponentChange{Component: component.Name, OldStatus: statusName(previousStatus), NewStatus: statusName(message.Status)})
+			}
+			previousStatus = message.Status
+		}
+		if statusImpact := parseImpact(message.Status); statusImpact.Severity() > impact.Severity() {
+			impact = statusImpact
+		}
+		updates = append(updates, update)
+	}
+	if maintenance {
+		impact = api.ImpactMaintenance
+	}
+	return api.NewIncident(inc.Name, components, updates, inc.DatetimeOpen, nil, nil, url+"/pages/incident/"+inc.ID, impact, url)
+}
+
+func parseState(state int, maintenance bool) api.IncidentState {
+	if maintenance {
+		return api.IncidentStateInProgress
+	}
+	switch state {
+	case stateInvestigating:
+		return api.IncidentStateInvestigating
+	case stateIdentified:
+		return api.IncidentStateIdentified
+	case stateMonitoring:
+		return api.IncidentStateMonitoring
+	}
+	return api.IncidentStateUpdate
+}
+
+func parseImpact(status int) api.Impact {
+	switch status {
+	case statusServiceDisruption, statusSecurityEvent:
+		return api.ImpactCritical
+	case statusPartialDisruption:
+		return api.ImpactMajor
+	case statusDegradedPerformance:
+		return api.ImpactMinor
+	case statusPlannedMaintenance:
+		return api.ImpactMaintenance
+	}
+	return api.ImpactNone
+}
+
+func statusName(status int) string {
+	switch status {
+	case statusOperational:
+		return "operational"
+	case statusPlannedMaintenance:
+		return "planned_maintenance"
+	case statusDegradedPerformance:
+		return "degraded_performance"
+	case statusPartialDisruption:
+		return "partial_service_disruption"
+	case statusServiceDisruption:
+		return "service_disruption"
+	case statusSecurityEvent:
+		return "security_event"
+	}
+	return "unknown"
+}
+
+// pageID returns the status.io page id of the status page, or an empty string if it is not hosted on status.io
+func (s *StatusIoProvider) pageID(ctx context.Context, url string) (string, error) {
+	parsed, err := neturl.Parse(url)
+	if err != nil {
+		return "", nil
+	}
+	if pageID, found := knownPageIDs[parsed.Hostname()]; found {
+		return pageID, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create the request")
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to make the get request to the status page")
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read the status page response body")
+	}
+	match := pageIDPattern.FindSubmatch(body)
+	if match == nil {
+		return "", nil
+	}
+	return string(match[1]), nil
+}
+
+func (s *StatusIoProvider) getJson(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the request")
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to make the get request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the response body")
+	}
+	return errors.Wrap(json.Unmarshal(body, v), "failed to unmarshal the response")
+}
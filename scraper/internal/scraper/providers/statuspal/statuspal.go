@@ -0,0 +1,204 @@
+package statuspal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const statuspalHostSuffix = ".statuspal.io"
+const statuspalAPIURL = "https://statuspal.io/api/v2/status_pages/"
+
+// maxHistoryPages bounds the number of incident pages followed in a historical scrape
+const maxHistoryPages = 50
+
+func init() {
+	providers.Register("statuspal", 70, func(logger *zap.Logger, httpClient *http.Client) providers.Provider {
+		return NewStatuspalProvider(logger, httpClient)
+	})
+}
+
+// StatuspalProvider scrapes status pages hosted on <subdomain>.statuspal.io through the public Statuspal api
+// Pages served from a custom domain can not be mapped to their subdomain so they are not supported
+type StatuspalProvider struct {
+	logger     *zap.Logger
+	httpClient *http.Client
+	apiURL     string
+}
+
+func NewStatuspalProvider(logger *zap.Logger, httpClient *http.Client) *StatuspalProvider {
+	return &StatuspalProvider{
+		logger:     logger,
+		httpClient: httpClient,
+		apiURL:     statuspalAPIURL,
+	}
+}
+
+func (s *StatuspalProvider) Name() string {
+	return "Statuspal"
+}
+
+func (s *StatuspalProvider) Features() api.ProviderFeatures {
+	return api.ProviderFeatures{
+		Provider:        s.Name(),
+		Incidents:       true,
+		IncidentUpdates: true,
+		Components:      true,
+		Maintenance:     true,
+		CurrentStatus:   false,
+		Webhooks:        false,
+		API:             true,
+		HistoryDepth:    fmt.Sprintf("%d pages", maxHistoryPages),
+	}
+}
+
+type statuspalIncidents struct {
+	Incidents []statuspalIncident `json:"incidents"`
+	Links     struct {
+		Next string `json:"next"`
+	} `json:"links"`
+}
+
+type statuspalIncident struct {
+	ID       int64      `json:"id"`
+	Title    string     `json:"title"`
+	Type     string     `json:"type"`
+	StartsAt time.Time  `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+	URL      string     `json:"url"`
+	Updates  []struct {
+		Type        string    `json:"type"`
+		Description string    `json:"description"`
+		PostedAt    time.Time `json:"posted_at"`
+	} `json:"updates"`
+	Services []struct {
+		Name string `json:"name"`
+	} `json:"services"`
+}
+
+// Matches accepts pages on a statuspal.io subdomain
+func (s *StatuspalProvider) Matches(ctx context.Context, url string) (bool, error) {
+	_, ok := subdomain(url)
+	return ok, nil
+}
+
+func (s *StatuspalProvider) ScrapeStatusPageCurrent(ctx context.Context, url string) ([]api.Incident, error) {
+	return s.scrape(ctx, url, 1)
+}
+
+func (s *StatuspalProvider) ScrapeStatusPageHistorical(ctx context.Context, url string) ([]api.Incident, error) {
+	return s.scrape(ctx, url, maxHistoryPages)
+}
+
+func (s *StatuspalProvider) scrape(ctx context.Context, url string, pages int) ([]api.Incident, error) {
+	sub, ok := subdomain(url)
+	if !ok {
+		return nil, errors.New("page is not a statuspal page")
+	}
+
+	var incidents []api.Incident
+	next := s.apiURL + sub + "/incidents"
+	for page := 0; page < pages && next != ""; page++ {
+		var response statuspalIncidents
+		err := s.getJson(ctx, next, &response)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get the incidents")
+		}
+		for _, inc := range response.Incidents {
+			incidents = append(incidents, s.toIncident(url, inc))
+		}
+		next = response.Links.Next
+	}
+	return incidents, nil
+}
+
+func (s *StatuspalProvider) toIncident(url string, inc statuspalIncident) api.Incident {
+	var components []string
+	for _, service := range inc.Services {
+		components = append(components, service.Name)
+	}
+
+	var updates []api.IncidentUpdate
+	for _, update := range inc.Updates {
+		updates = append(updates, api.NewIncidentUpdate(update.PostedAt, parseUpdateType(update.Type), update.Description, s.Name()))
+	}
+	sort.SliceStable(updates, func(i, j int) bool {
+		return updates[i].Time.Before(updates[j].Time)
+	})
+
+	link := inc.URL
+	if link == "" {
+		link = fmt.Sprintf("%s/incidents/%d", url, inc.ID)
+	}
+	incident := api.NewIncident(inc.Title, components, updates, inc.StartsAt, inc.EndsAt, nil, link, parseImpact(inc.Type), url)
+	incident.RawImpact = inc.Type
+	return incident
+}
+
+func parseImpact(incidentType string) api.Impact {
+	switch incidentType {
+	case "major":
+		return api.ImpactMajor
+	case "minor":
+		return api.ImpactMinor
+	case "scheduled":
+		return api.ImpactMaintenance
+	}
+	return api.ImpactNone
+}
+
+func parseUpdateType(updateType string) api.IncidentState {
+	switch updateType {
+	case "issue":
+		return api.IncidentStateInvestigating
+	case "resolve":
+		return api.IncidentStateResolved
+	case "retrospective":
+		return api.IncidentStatePostmortem
+	}
+	return api.ParseIncidentState(updateType)
+}
+
+// subdomain returns the statuspal subdomain of the status page url
+func subdomain(url string) (string, bool) {
+	parsed, err := neturl.Parse(url)
+	if err != nil {
+		return "", false
+	}
+	sub, found := strings.CutSuffix(parsed.Hostname(), statuspalHostSuffix)
+	if !found || sub == "" || strings.Contains(sub, ".") {
+		return "", false
+	}
+	return sub, true
+}
+
+func (s *StatuspalProvider) getJson(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the request")
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to make the get request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the response body")
+	}
+	return errors.Wrap(json.Unmarshal(body, v), "failed to unmarshal the response")
+}
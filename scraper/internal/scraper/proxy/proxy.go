@@ -0,0 +1,69 @@
+package proxy
+
+import (
+	"github.com/kelseyhightower/envconfig"
+	"github.com/pkg/errors"
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+)
+
+type Config struct {
+	// Proxies are the outbound proxies the scraper sends its requests through, e.g. http://proxy:3128 or socks5://proxy:1080
+	// If empty the standard HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are used
+	Proxies []string `envconfig:"SCRAPER_PROXIES"`
+	// Rotate sends every request through the next proxy of the pool
+	// Otherwise each host is always scraped through the same proxy, so a status page sees a single client
+	Rotate bool `envconfig:"SCRAPER_PROXY_ROTATE" default:"false"`
+}
+
+func GetConfigFromEnvironment() (Config, error) {
+	var config Config
+	err := envconfig.Process("STATUSPHERE", &config)
+	return config, err
+}
+
+// Pool picks the proxy that each request is sent through
+type Pool struct {
+	proxies []*url.URL
+	rotate  bool
+	next    atomic.Uint64
+}
+
+func NewPool(config Config) (*Pool, error) {
+	pool := &Pool{rotate: config.Rotate}
+	for _, raw := range config.Proxies {
+		proxyUrl, err := url.Parse(raw)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse proxy %q", raw)
+		}
+		switch proxyUrl.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, errors.Errorf("unsupported proxy scheme %q, expected http, https, socks5 or socks5h", proxyUrl.Scheme)
+		}
+		pool.proxies = append(pool.proxies, proxyUrl)
+	}
+	return pool, nil
+}
+
+// Proxy is used as the Proxy of an http.Transport
+func (p *Pool) Proxy(req *http.Request) (*url.URL, error) {
+	if len(p.proxies) == 0 {
+		return http.ProxyFromEnvironment(req)
+	}
+	if p.rotate {
+		return p.proxies[(p.next.Add(1)-1)%uint64(len(p.proxies))], nil
+	}
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(req.URL.Host))
+	return p.proxies[hash.Sum32()%uint32(len(p.proxies))], nil
+}
+
+// NewTransport returns a copy of http.DefaultTransport that sends its requests through the pool
+func NewTransport(pool *Pool) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = pool.Proxy
+	return transport
+}
@@ -0,0 +1,120 @@
+package ratelimit
+
+import (
+	"github.com/kelseyhightower/envconfig"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	// RequestsPerMinute is the most requests sent to a single host per minute, zero disables the limit
+	RequestsPerMinute int `envconfig:"SCRAPER_REQUESTS_PER_MINUTE_PER_HOST" default:"30"`
+	// Jitter is the most that is randomly added to the delay before each request, so requests don't land on a fixed beat
+	Jitter time.Duration `envconfig:"SCRAPER_REQUEST_JITTER" default:"1s"`
+}
+
+func GetConfigFromEnvironment() (Config, error) {
+	var config Config
+	err := envconfig.Process("STATUSPHERE", &config)
+	return config, err
+}
+
+// maxRetryAfter caps how long a host that returned 429 is left alone for, so a bogus Retry-After can't stop it being scraped
+const maxRetryAfter = 10 * time.Minute
+
+// Transport is an http.RoundTripper that spaces out the requests to each host
+// Requests wait until their host is allowed another one, and a host that answers 429 is not sent any requests
+// until its Retry-After has passed
+type Transport struct {
+	base     http.RoundTripper
+	interval time.Duration
+	jitter   time.Duration
+	mu       sync.Mutex
+	// next is the earliest time the next request can be sent to each host
+	next map[string]time.Time
+}
+
+func NewTransport(base http.RoundTripper, config Config) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{
+		base:     base,
+		interval: time.Minute / time.Duration(config.RequestsPerMinute),
+		jitter:   config.Jitter,
+		next:     make(map[string]time.Time),
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	wait := t.reserve(host)
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		t.backOff(host, parseRetryAfter(resp.Header.Get("Retry-After")))
+	}
+	return resp, nil
+}
+
+// reserve takes the next slot for the host and returns how long to wait for it
+// Slots are at least interval apart, the jitter is added on top so it never brings two requests closer together
+func (t *Transport) reserve(host string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	slot := t.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	if t.jitter > 0 {
+		slot = slot.Add(time.Duration(rand.Int63n(int64(t.jitter))))
+	}
+	t.next[host] = slot.Add(t.interval)
+	return slot.Sub(now)
+}
+
+func (t *Transport) backOff(host string, retryAfter time.Duration) {
+	if retryAfter <= 0 {
+		// Without a Retry-After skip a minute's worth of requests
+		retryAfter = time.Minute
+	}
+	if retryAfter > maxRetryAfter {
+		retryAfter = maxRetryAfter
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	until := time.Now().Add(retryAfter)
+	if t.next[host].Before(until) {
+		t.next[host] = until
+	}
+}
+
+// parseRetryAfter parses a Retry-After header, which is either a number of seconds or an http date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
@@ -0,0 +1,97 @@
+package redirect
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// maxRedirects is the number of redirects a request follows, the same as the default of http.Client
+const maxRedirects = 10
+
+// Recorder collects the permanent redirects that the requests of a scrape followed
+// so a status page that has moved to another url can be canonicalized
+type Recorder struct {
+	mu sync.Mutex
+	// targets maps each requested url to where it permanently redirects
+	targets map[string]string
+}
+
+type recorderKey struct{}
+
+// WithRecorder returns a context whose redirected requests are recorded by the returned recorder
+func WithRecorder(ctx context.Context) (context.Context, *Recorder) {
+	recorder := &Recorder{targets: map[string]string{}}
+	return context.WithValue(ctx, recorderKey{}, recorder), recorder
+}
+
+func fromContext(ctx context.Context) *Recorder {
+	recorder, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return recorder
+}
+
+func (r *Recorder) record(from string, to string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.targets[from] = to
+}
+
+// Target returns the url that the status page at statusPageUrl has permanently moved to, empty if it hasn't
+// The providers request paths below the status page, so a redirect of one of them that keeps its path
+// moves the status page too, e.g. status.example.com/history.json to example.statuspage.io/history.json
+func (r *Recorder) Target(statusPageUrl string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	base := strings.TrimSuffix(statusPageUrl, "/")
+	for from, to := range r.targets {
+		if !strings.HasPrefix(from, base) {
+			continue
+		}
+		suffix := strings.TrimSuffix(strings.TrimPrefix(from, base), "/")
+		if suffix != "" && !strings.HasPrefix(suffix, "/") && !strings.HasPrefix(suffix, "?") {
+			continue
+		}
+		to = strings.TrimSuffix(to, "/")
+		if !strings.HasSuffix(to, suffix) {
+			continue
+		}
+		target := strings.TrimSuffix(to, suffix)
+		if !isAbsolute(target) || target == base {
+			continue
+		}
+		return target
+	}
+	return ""
+}
+
+func isAbsolute(rawUrl string) bool {
+	parsed, err := url.Parse(rawUrl)
+	return err == nil && parsed.IsAbs() && parsed.Host != ""
+}
+
+// CheckRedirect is the CheckRedirect of the scraper's http.Client, it follows redirects like the default
+// and records the chains that are only made of permanent redirects with the recorder of the request's context
+func CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return errors.New("stopped after 10 redirects")
+	}
+	recorder := fromContext(req.Context())
+	if recorder == nil {
+		return nil
+	}
+	// via[0] is the original request, the following requests were made for a redirect response
+	for _, previous := range append(via[1:], req) {
+		if previous.Response == nil || !isPermanent(previous.Response.StatusCode) {
+			return nil
+		}
+	}
+	recorder.record(via[0].URL.String(), req.URL.String())
+	return nil
+}
+
+func isPermanent(statusCode int) bool {
+	return statusCode == http.StatusMovedPermanently || statusCode == http.StatusPermanentRedirect
+}
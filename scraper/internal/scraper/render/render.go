@@ -0,0 +1,95 @@
+package render
+
+import (
+	"context"
+	"github.com/chromedp/chromedp"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"time"
+)
+
+type Config struct {
+	// Enabled renders the status pages flagged with requires_js in a headless browser before they are parsed
+	Enabled bool `envconfig:"RENDER_ENABLED" default:"false"`
+	// ChromeURL is the devtools websocket url of a running chrome, e.g. ws://chrome:9222
+	// If empty a local headless chrome is started
+	ChromeURL string `envconfig:"RENDER_CHROME_URL"`
+	// Timeout bounds loading and rendering a single page
+	Timeout time.Duration `envconfig:"RENDER_TIMEOUT" default:"30s"`
+	// Settle is how long to wait after the page has loaded for its scripts to render the incidents
+	Settle time.Duration `envconfig:"RENDER_SETTLE" default:"2s"`
+}
+
+func GetConfigFromEnvironment() (Config, error) {
+	var config Config
+	err := envconfig.Process("STATUSPHERE", &config)
+	return config, err
+}
+
+// Renderer loads pages in a headless chrome and returns their html after the scripts on the page have run
+type Renderer struct {
+	logger     *zap.Logger
+	config     Config
+	browserCtx context.Context
+	cancel     context.CancelFunc
+}
+
+// NewRenderer starts, or connects to, the browser that the pages are rendered in
+func NewRenderer(logger *zap.Logger, config Config) (*Renderer, error) {
+	var allocCtx context.Context
+	var cancelAlloc context.CancelFunc
+	if config.ChromeURL != "" {
+		allocCtx, cancelAlloc = chromedp.NewRemoteAllocator(context.Background(), config.ChromeURL)
+	} else {
+		allocCtx, cancelAlloc = chromedp.NewExecAllocator(context.Background(), chromedp.DefaultExecAllocatorOptions[:]...)
+	}
+	browserCtx, cancelBrowser := chromedp.NewContext(allocCtx)
+	cancel := func() {
+		cancelBrowser()
+		cancelAlloc()
+	}
+
+	// Running without actions starts the browser, the pages are then rendered in tabs of it
+	err := chromedp.Run(browserCtx)
+	if err != nil {
+		cancel()
+		return nil, errors.Wrap(err, "failed to start browser")
+	}
+
+	return &Renderer{
+		logger:     logger,
+		config:     config,
+		browserCtx: browserCtx,
+		cancel:     cancel,
+	}, nil
+}
+
+// Render loads the url in a new tab and returns the html of the page once it has rendered
+func (r *Renderer) Render(ctx context.Context, url string) (string, error) {
+	tabCtx, cancelTab := chromedp.NewContext(r.browserCtx)
+	defer cancelTab()
+	tabCtx, cancelTimeout := context.WithTimeout(tabCtx, r.config.Timeout)
+	defer cancelTimeout()
+	// Stop rendering if the scrape is cancelled
+	stop := context.AfterFunc(ctx, cancelTimeout)
+	defer stop()
+
+	var html string
+	err := chromedp.Run(tabCtx,
+		chromedp.Navigate(url),
+		chromedp.WaitReady("body"),
+		chromedp.Sleep(r.config.Settle),
+		chromedp.OuterHTML("html", &html),
+	)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to render %s", url)
+	}
+	r.logger.Debug("rendered page", zap.String("url", url), zap.Int("bytes", len(html)))
+	return html, nil
+}
+
+// Close shuts down the browser, or disconnects from it if it is remote
+func (r *Renderer) Close() {
+	r.cancel()
+}
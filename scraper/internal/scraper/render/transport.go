@@ -0,0 +1,61 @@
+package render
+
+import (
+	"github.com/pkg/errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Transport is an http.RoundTripper that swaps the html documents of the status pages that require javascript
+// for their rendered html, so that the providers parse the incidents that the page renders client side
+// Other responses, e.g. the JSON APIs of the status pages, are passed through untouched
+type Transport struct {
+	// Base makes the requests, http.DefaultTransport if nil
+	Base     http.RoundTripper
+	Renderer *Renderer
+	// RequiresJS returns true if the url belongs to a status page that has to be rendered
+	RequiresJS func(url string) bool
+}
+
+func NewTransport(base http.RoundTripper, renderer *Renderer, requiresJS func(url string) bool) *Transport {
+	return &Transport{
+		Base:       base,
+		Renderer:   renderer,
+		RequiresJS: requiresJS,
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	// Only successful html documents are rendered, so errors and redirects still reach the providers as they are
+	if req.Method != http.MethodGet || resp.StatusCode != http.StatusOK || !isHTML(resp) || !t.RequiresJS(req.URL.String()) {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	html, err := t.Renderer.Render(req.Context(), req.URL.String())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to render page")
+	}
+	resp.Body = io.NopCloser(strings.NewReader(html))
+	resp.ContentLength = int64(len(html))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(html)))
+	resp.Header.Del("Content-Encoding")
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+func isHTML(resp *http.Response) bool {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return err == nil && mediaType == "text/html"
+}
@@ -0,0 +1,85 @@
+package retry
+
+import (
+	"github.com/kelseyhightower/envconfig"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/body"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+type Config struct {
+	// Retries is how many more times a request that failed with a transient error is made, zero disables retries
+	Retries int `envconfig:"SCRAPER_RETRIES" default:"2"`
+	// Backoff is the delay before the first retry, it doubles for every retry after that
+	Backoff time.Duration `envconfig:"SCRAPER_RETRY_BACKOFF" default:"500ms"`
+}
+
+func GetConfigFromEnvironment() (Config, error) {
+	var config Config
+	err := envconfig.Process("STATUSPHERE", &config)
+	return config, err
+}
+
+// Transport is an http.RoundTripper that retries GET requests that fail with a transient error
+// Connection errors and 502, 503 and 504 responses are transient, except for responses over the max body size, anything else is returned as it is
+type Transport struct {
+	base    http.RoundTripper
+	retries int
+	backoff time.Duration
+}
+
+func NewTransport(base http.RoundTripper, config Config) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{
+		base:    base,
+		retries: config.Retries,
+		backoff: config.Backoff,
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return t.base.RoundTrip(req)
+	}
+
+	backoff := t.backoff
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if attempt >= t.retries || !isTransient(resp, err) || req.Context().Err() != nil {
+			return resp, err
+		}
+		if resp != nil {
+			// Drain the body so the connection can be reused
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+func isTransient(resp *http.Response, err error) bool {
+	// An oversized response will be just as big the next time
+	if errors.Is(err, body.ErrTooLarge) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
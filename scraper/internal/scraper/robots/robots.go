@@ -0,0 +1,132 @@
+package robots
+
+import (
+	"context"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type Config struct {
+	// Respect makes the scraper follow the robots.txt of the status pages it crawls
+	// Providers that read an API are exempt, a status page can opt in or out with its robots_txt column
+	Respect bool `envconfig:"SCRAPER_RESPECT_ROBOTS_TXT" default:"false"`
+	// UserAgent is the product token the robots.txt groups are matched against
+	UserAgent string `envconfig:"SCRAPER_ROBOTS_USER_AGENT" default:"statusphere"`
+	// CacheTTL is how long the robots.txt of a host is cached for
+	CacheTTL time.Duration `envconfig:"SCRAPER_ROBOTS_CACHE_TTL" default:"24h"`
+}
+
+func GetConfigFromEnvironment() (Config, error) {
+	var config Config
+	err := envconfig.Process("STATUSPHERE", &config)
+	return config, err
+}
+
+// unreachableTTL is how long a robots.txt that couldn't be fetched is treated as allowing everything before it is retried
+const unreachableTTL = 10 * time.Minute
+
+// maxRobotsSize bounds how much of a robots.txt is read, RFC 9309 asks crawlers to parse at least 500KiB
+const maxRobotsSize = 512 * 1024
+
+// ErrDisallowed is returned for the requests that the robots.txt of their host disallows
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// Transport is an http.RoundTripper that refuses the requests disallowed by the robots.txt of their host
+// The robots.txt is only applied when it is enabled and the provider making the request doesn't read an API,
+// unless the status page overrides it. The rules of each host are cached for the configured TTL
+type Transport struct {
+	base      http.RoundTripper
+	respect   bool
+	userAgent string
+	ttl       time.Duration
+	// apiProviders are the names of the providers that read an API, see api.ProviderFeatures
+	apiProviders map[string]bool
+	// policy returns the robots_txt override of the status page that the url is on, empty if it has none
+	policy func(url string) string
+	rules  *cache.Cache
+}
+
+func NewTransport(base http.RoundTripper, config Config, features []api.ProviderFeatures, policy func(url string) string) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	apiProviders := map[string]bool{}
+	for _, f := range features {
+		if f.API {
+			apiProviders[f.Provider] = true
+		}
+	}
+	return &Transport{
+		base:         base,
+		respect:      config.Respect,
+		userAgent:    config.UserAgent,
+		ttl:          config.CacheTTL,
+		apiProviders: apiProviders,
+		policy:       policy,
+		rules:        cache.New(config.CacheTTL, time.Hour),
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Path == "/robots.txt" || !t.applies(req) {
+		return t.base.RoundTrip(req)
+	}
+	hostRules := t.rulesFor(req.Context(), req.URL)
+	if !hostRules.allowed(req.URL.RequestURI()) {
+		return nil, errors.Wrap(ErrDisallowed, req.URL.String())
+	}
+	return t.base.RoundTrip(req)
+}
+
+// applies returns true if the robots.txt has to be followed for the request
+func (t *Transport) applies(req *http.Request) bool {
+	switch t.policy(req.URL.String()) {
+	case api.RobotsTxtRespect:
+		return true
+	case api.RobotsTxtIgnore:
+		return false
+	}
+	return t.respect && !t.apiProviders[providers.NameFromContext(req.Context())]
+}
+
+func (t *Transport) rulesFor(ctx context.Context, u *url.URL) rules {
+	origin := u.Scheme + "://" + u.Host
+	if cached, found := t.rules.Get(origin); found {
+		return cached.(rules)
+	}
+	hostRules, ttl := t.fetch(ctx, origin)
+	t.rules.Set(origin, hostRules, ttl)
+	return hostRules
+}
+
+// fetch gets and parses the robots.txt of the origin, returning how long to cache it for
+// A missing robots.txt allows everything, as does one that can't be fetched, which is retried sooner
+func (t *Transport) fetch(ctx context.Context, origin string) (rules, time.Duration) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+"/robots.txt", nil)
+	if err != nil {
+		return allowAll, unreachableTTL
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return allowAll, unreachableTTL
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+		return allowAll, t.ttl
+	}
+	if resp.StatusCode != http.StatusOK {
+		return allowAll, unreachableTTL
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRobotsSize))
+	if err != nil {
+		return allowAll, unreachableTTL
+	}
+	return parse(string(body), t.userAgent), t.ttl
+}
@@ -0,0 +1,130 @@
+package robots
+
+import (
+	"bufio"
+	"strings"
+)
+
+// rule is an Allow or Disallow line of a robots.txt
+type rule struct {
+	allow   bool
+	pattern string
+}
+
+// rules are the rules of the robots.txt group that applies to the scraper
+type rules []rule
+
+// allowAll is used for hosts without a robots.txt
+var allowAll = rules{}
+
+// group is a set of consecutive User-agent lines and the rules that follow them
+type group struct {
+	agents []string
+	rules  rules
+}
+
+// parse parses a robots.txt and returns the rules of the group for userAgent
+// The group naming the longest product token contained in userAgent is used, falling back to the * group
+// Groups naming the same agent are merged as described in RFC 9309
+func parse(body string, userAgent string) rules {
+	var groups []*group
+	var current *group
+	// A User-agent line directly after another one adds to the same group
+	lastWasAgent := false
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if !lastWasAgent {
+				current = &group{}
+				groups = append(groups, current)
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			lastWasAgent = true
+		case "allow", "disallow":
+			lastWasAgent = false
+			// Rules before the first User-agent line don't belong to a group, and an empty Disallow allows everything
+			if current == nil || value == "" {
+				continue
+			}
+			current.rules = append(current.rules, rule{allow: key == "allow", pattern: value})
+		default:
+			// Other lines, e.g. Sitemap and Crawl-delay, don't end the list of agents
+		}
+	}
+
+	userAgent = strings.ToLower(userAgent)
+	var matched rules
+	var wildcard rules
+	longest := 0
+	for _, g := range groups {
+		for _, agent := range g.agents {
+			switch {
+			case agent == "*":
+				wildcard = append(wildcard, g.rules...)
+			case agent != "" && strings.Contains(userAgent, agent) && len(agent) >= longest:
+				if len(agent) > longest {
+					matched = nil
+					longest = len(agent)
+				}
+				matched = append(matched, g.rules...)
+			}
+		}
+	}
+	if longest > 0 {
+		return matched
+	}
+	return wildcard
+}
+
+// allowed returns true if the rules allow path, which includes the query string
+// The longest matching pattern decides, an Allow wins a tie
+func (r rules) allowed(path string) bool {
+	allow := true
+	longest := -1
+	for _, rule := range r {
+		if !matches(rule.pattern, path) {
+			continue
+		}
+		if len(rule.pattern) > longest || (len(rule.pattern) == longest && rule.allow) {
+			longest = len(rule.pattern)
+			allow = rule.allow
+		}
+	}
+	return allow
+}
+
+// matches reports whether path starts with pattern, where * matches any characters and a trailing $ anchors the end
+func matches(pattern string, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = strings.TrimSuffix(pattern, "$")
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for i, part := range parts[1:] {
+		if anchored && i == len(parts)-2 {
+			// The last part has to match the end of the path
+			return strings.HasSuffix(rest, part)
+		}
+		index := strings.Index(rest, part)
+		if index < 0 {
+			return false
+		}
+		rest = rest[index+len(part):]
+	}
+	return !anchored || rest == ""
+}
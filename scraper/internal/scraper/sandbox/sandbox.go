@@ -0,0 +1,101 @@
+package sandbox
+
+import (
+	"context"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers/mock"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"time"
+)
+
+type Config struct {
+	// Enabled seeds the sandbox status pages, whose incidents are generated by the mock provider
+	Enabled bool `envconfig:"SANDBOX_ENABLED" default:"false"`
+	// ResetInterval is how often the sandbox incidents are wiped and regenerated
+	ResetInterval time.Duration `envconfig:"SANDBOX_RESET_INTERVAL" default:"24h"`
+}
+
+func GetConfigFromEnvironment() (Config, error) {
+	var config Config
+	err := envconfig.Process("STATUSPHERE", &config)
+	return config, err
+}
+
+// StatusPages are the synthetic status pages that make up the sandbox
+var StatusPages = []api.StatusPage{
+	{Name: "Sandbox Acme Cloud", URL: mock.SandboxURL("acme-cloud"), IsSandbox: true, Provider: "Mock"},
+	{Name: "Sandbox Globex Payments", URL: mock.SandboxURL("globex-payments"), IsSandbox: true, Provider: "Mock"},
+	{Name: "Sandbox Initech Mail", URL: mock.SandboxURL("initech-mail"), IsSandbox: true, Provider: "Mock"},
+	{Name: "Sandbox Umbrella CDN", URL: mock.SandboxURL("umbrella-cdn"), IsSandbox: true, Provider: "Mock"},
+	{Name: "Sandbox Hooli Search", URL: mock.SandboxURL("hooli-search"), IsSandbox: true, Provider: "Mock"},
+}
+
+// Sandbox maintains a set of synthetic status pages so integrators can develop against
+// realistic incident streams without touching production data
+type Sandbox struct {
+	logger   *zap.Logger
+	dbClient *db.DbClient
+	config   Config
+}
+
+func NewSandbox(logger *zap.Logger, dbClient *db.DbClient, config Config) *Sandbox {
+	return &Sandbox{
+		logger:   logger,
+		dbClient: dbClient,
+		config:   config,
+	}
+}
+
+// Start seeds the sandbox status pages along with the sandbox tenant that tracks them, and periodically resets them
+// Api keys issued to the sandbox tenant are the only ones that see the sandbox status pages
+func (s *Sandbox) Start(ctx context.Context) error {
+	var urls []string
+	for _, statusPage := range StatusPages {
+		urls = append(urls, statusPage.URL)
+	}
+	err := s.dbClient.EnsureStatusPages(ctx, StatusPages)
+	if err != nil {
+		return errors.Wrap(err, "failed to seed sandbox status pages")
+	}
+	err = s.dbClient.EnsureTenant(ctx, api.Tenant{ID: api.SandboxTenantID, Name: "Sandbox", CreatedAt: time.Now().UTC()})
+	if err != nil {
+		return errors.Wrap(err, "failed to create sandbox tenant")
+	}
+	err = s.dbClient.TrackStatusPages(ctx, api.SandboxTenantID, urls, time.Now().UTC())
+	if err != nil {
+		return errors.Wrap(err, "failed to track sandbox status pages")
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.config.ResetInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.reset(ctx)
+			}
+		}
+	}()
+	return nil
+}
+
+// reset deletes the sandbox incidents and marks the pages as never scraped so the full history is regenerated
+func (s *Sandbox) reset(ctx context.Context) {
+	s.logger.Info("resetting sandbox")
+	for _, statusPage := range StatusPages {
+		err := s.dbClient.DeleteIncidents(ctx, statusPage.URL)
+		if err != nil {
+			s.logger.Error("failed to delete sandbox incidents", zap.String("url", statusPage.URL), zap.Error(err))
+			continue
+		}
+		err = s.dbClient.ResetLastScraped(ctx, statusPage.URL)
+		if err != nil {
+			s.logger.Error("failed to reset sandbox status page", zap.String("url", statusPage.URL), zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package sanitize
+
+import (
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	neturl "net/url"
+	"regexp"
+	"strings"
+)
+
+// allowed are the elements that are kept in the safe html, every other element is replaced by its children
+var allowed = map[atom.Atom]bool{
+	atom.A: true, atom.B: true, atom.Blockquote: true, atom.Br: true, atom.Code: true, atom.Em: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true, atom.Hr: true,
+	atom.I: true, atom.Li: true, atom.Ol: true, atom.P: true, atom.Pre: true, atom.Strong: true, atom.U: true, atom.Ul: true,
+}
+
+// dropped are the elements that are removed along with everything in them
+var dropped = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Iframe: true, atom.Object: true, atom.Embed: true, atom.Noscript: true,
+	atom.Template: true, atom.Head: true, atom.Title: true, atom.Svg: true, atom.Math: true, atom.Form: true,
+	atom.Button: true, atom.Select: true, atom.Textarea: true, atom.Input: true,
+}
+
+// blocks are the elements that start a new line in the plaintext
+var blocks = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Blockquote: true, atom.Div: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+	atom.Footer: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Header: true, atom.Hr: true, atom.Li: true, atom.Ol: true, atom.P: true, atom.Pre: true, atom.Section: true,
+	atom.Table: true, atom.Tr: true, atom.Ul: true,
+}
+
+// allowedSchemes are the schemes of the links that are kept, other links keep their text but lose their href
+var allowedSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+var (
+	tagRegex        = regexp.MustCompile(`</?[a-zA-Z][a-zA-Z0-9]*(\s[^>]*)?/?>`)
+	spaceRegex      = regexp.MustCompile(`[ \t\r\f\v\x{00a0}]+`)
+	blankLinesRegex = regexp.MustCompile(`\n{3,}`)
+)
+
+// Description turns the raw description of an incident, which is html for most providers and plaintext for the others,
+// into plaintext and into html that is safe to render. Scripts, styles, event handlers and unsafe links are removed,
+// the only attribute kept is the href of a link
+func Description(raw string) (text string, safeHTML string) {
+	if !tagRegex.MatchString(raw) {
+		return fromPlaintext(html.UnescapeString(raw))
+	}
+	nodes, err := html.ParseFragment(strings.NewReader(raw), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		return fromPlaintext(tagRegex.ReplaceAllString(raw, " "))
+	}
+	var textBuilder, htmlBuilder strings.Builder
+	for _, node := range nodes {
+		writeText(&textBuilder, node)
+		writeHTML(&htmlBuilder, node)
+	}
+	return normalizeText(textBuilder.String()), strings.TrimSpace(htmlBuilder.String())
+}
+
+// fromPlaintext keeps the line breaks of a plaintext description, blank lines separate paragraphs
+func fromPlaintext(raw string) (string, string) {
+	text := normalizeText(raw)
+	if text == "" {
+		return "", ""
+	}
+	var htmlBuilder strings.Builder
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		htmlBuilder.WriteString("<p>")
+		htmlBuilder.WriteString(strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br>"))
+		htmlBuilder.WriteString("</p>")
+	}
+	return text, htmlBuilder.String()
+}
+
+func writeText(b *strings.Builder, node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		b.WriteString(spaceRegex.ReplaceAllString(strings.ReplaceAll(node.Data, "\n", " "), " "))
+		return
+	case html.ElementNode:
+		if dropped[node.DataAtom] {
+			return
+		}
+		if node.DataAtom == atom.Br {
+			b.WriteString("\n")
+			return
+		}
+	}
+	block := node.Type == html.ElementNode && blocks[node.DataAtom]
+	if block {
+		b.WriteString("\n")
+		if node.DataAtom == atom.Li {
+			b.WriteString("- ")
+		}
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		writeText(b, child)
+	}
+	// List items are on consecutive lines, the other blocks are separated by a blank line
+	if block && node.DataAtom != atom.Li {
+		b.WriteString("\n")
+	}
+}
+
+func writeHTML(b *strings.Builder, node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		b.WriteString(html.EscapeString(node.Data))
+		return
+	case html.ElementNode:
+		if dropped[node.DataAtom] {
+			return
+		}
+		if !allowed[node.DataAtom] {
+			writeChildrenHTML(b, node)
+			return
+		}
+	default:
+		writeChildrenHTML(b, node)
+		return
+	}
+
+	b.WriteString("<" + node.DataAtom.String())
+	if node.DataAtom == atom.A {
+		if href := safeHref(node); href != "" {
+			b.WriteString(` href="` + html.EscapeString(href) + `" rel="nofollow noopener noreferrer"`)
+		}
+	}
+	b.WriteString(">")
+	if node.DataAtom == atom.Br || node.DataAtom == atom.Hr {
+		return
+	}
+	writeChildrenHTML(b, node)
+	b.WriteString("</" + node.DataAtom.String() + ">")
+}
+
+func writeChildrenHTML(b *strings.Builder, node *html.Node) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		writeHTML(b, child)
+	}
+}
+
+// safeHref returns the href of the link if it is absolute and has an allowed scheme
+func safeHref(node *html.Node) string {
+	for _, attr := range node.Attr {
+		if attr.Namespace != "" || attr.Key != "href" {
+			continue
+		}
+		parsed, err := neturl.Parse(strings.TrimSpace(attr.Val))
+		if err != nil || !allowedSchemes[strings.ToLower(parsed.Scheme)] {
+			return ""
+		}
+		return parsed.String()
+	}
+	return ""
+}
+
+// normalizeText trims every line and collapses the runs of spaces and blank lines
+func normalizeText(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(spaceRegex.ReplaceAllString(line, " "))
+	}
+	return strings.TrimSpace(blankLinesRegex.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
@@ -0,0 +1,290 @@
+package scraper
+
+import (
+	"context"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/detect"
+	"github.com/metoro-io/statusphere/common/utils"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/metadata"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/metrics"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers/declarative"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/sanitize"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/tracing"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	neturl "net/url"
+	"time"
+)
+
+func (s *scraper) ScrapeStatusPageHistorical(ctx context.Context, url string) ([]api.Incident, error) {
+	ctx = utils.UpdateContextMdc(ctx, map[string]string{"url": url})
+	return s.cascadingScrapeHistorical(ctx, url)
+}
+
+// cascadingScrapeHistorical scrapes the status page with the first provider that matches it
+// This is useful because different status pages are structured differently
+func (s *scraper) cascadingScrapeHistorical(ctx context.Context, url string) ([]api.Incident, error) {
+	provider, err := s.matchProvider(ctx, url, scrapeKindHistorical)
+	if err != nil {
+		return nil, err
+	}
+	ctx = utils.UpdateContextMdc(ctx, map[string]string{"provider": provider.Name()})
+	ctx = providers.WithName(ctx, provider.Name())
+	start := time.Now()
+	parseCtx, parse := tracing.Tracer.Start(ctx, tracing.StageParse)
+	incidents, err := provider.ScrapeStatusPageHistorical(parseCtx, url)
+	parse.End()
+	observeScrape(provider.Name(), scrapeKindHistorical, start, len(incidents), err)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to scrape the status page using the %s provider", provider.Name())
+	}
+	_, normalize := tracing.Tracer.Start(ctx, tracing.StageNormalize)
+	setProvider(incidents, provider.Name())
+	normalizeImpacts(incidents)
+	normalizeDescriptions(incidents)
+	normalize.End()
+	utils.GetLogger(ctx, s.logger).Info("Successfully scraped the status page using the provider method")
+	return incidents, nil
+}
+
+func (s *scraper) ScrapeStatusPageCurrent(ctx context.Context, url string) ([]api.Incident, error) {
+	ctx = utils.UpdateContextMdc(ctx, map[string]string{"url": url})
+	return s.cascadingScrapeCurrent(ctx, url)
+}
+
+// cascadingScrapeCurrent scrapes the status page with the first provider that matches it
+// This is useful because different status pages are structured differently
+func (s *scraper) cascadingScrapeCurrent(ctx context.Context, url string) ([]api.Incident, error) {
+	provider, err := s.matchProvider(ctx, url, scrapeKindCurrent)
+	if err != nil {
+		return nil, err
+	}
+	ctx = utils.UpdateContextMdc(ctx, map[string]string{"provider": provider.Name()})
+	ctx = providers.WithName(ctx, provider.Name())
+	start := time.Now()
+	parseCtx, parse := tracing.Tracer.Start(ctx, tracing.StageParse)
+	incidents, err := provider.ScrapeStatusPageCurrent(parseCtx, url)
+	parse.End()
+	observeScrape(provider.Name(), scrapeKindCurrent, start, len(incidents), err)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to scrape the status page using the %s provider", provider.Name())
+	}
+	_, normalize := tracing.Tracer.Start(ctx, tracing.StageNormalize)
+	setProvider(incidents, provider.Name())
+	normalizeImpacts(incidents)
+	normalizeDescriptions(incidents)
+	normalize.End()
+	utils.GetLogger(ctx, s.logger).Info("Successfully scraped the status page using the provider method")
+	return incidents, nil
+}
+
+func (s *scraper) ScrapeStatusPageBackfill(ctx context.Context, url string, cursor string, consume func(incidents []api.Incident, cursor string) error) (bool, error) {
+	ctx = utils.UpdateContextMdc(ctx, map[string]string{"url": url})
+	provider, err := s.matchProvider(ctx, url, scrapeKindBackfill)
+	if err != nil {
+		return false, err
+	}
+	backfillProvider, ok := provider.(providers.BackfillProvider)
+	if !ok {
+		return false, nil
+	}
+	ctx = utils.UpdateContextMdc(ctx, map[string]string{"provider": provider.Name()})
+	ctx = providers.WithName(ctx, provider.Name())
+	start := time.Now()
+	found := 0
+	err = backfillProvider.Backfill(ctx, url, cursor, func(incidents []api.Incident, cursor string) error {
+		found += len(incidents)
+		setProvider(incidents, provider.Name())
+		normalizeImpacts(incidents)
+		normalizeDescriptions(incidents)
+		return consume(incidents, cursor)
+	})
+	observeScrape(provider.Name(), scrapeKindBackfill, start, found, err)
+	if err != nil {
+		return true, errors.Wrapf(err, "failed to backfill the status page using the %s provider", provider.Name())
+	}
+	utils.GetLogger(ctx, s.logger).Info("Successfully backfilled the status page using the provider method")
+	return true, nil
+}
+
+func (s *scraper) ScrapeStatusPageComponents(ctx context.Context, url string) ([]api.Component, bool, error) {
+	ctx = utils.UpdateContextMdc(ctx, map[string]string{"url": url})
+	provider, err := s.matchProvider(ctx, url, scrapeKindComponents)
+	if err != nil {
+		return nil, false, err
+	}
+	componentProvider, ok := provider.(providers.ComponentProvider)
+	if !ok {
+		return nil, false, nil
+	}
+	ctx = utils.UpdateContextMdc(ctx, map[string]string{"provider": provider.Name()})
+	ctx = providers.WithName(ctx, provider.Name())
+	start := time.Now()
+	components, err := componentProvider.ScrapeComponents(ctx, url)
+	observeScrape(provider.Name(), scrapeKindComponents, start, 0, err)
+	if err != nil {
+		return nil, true, errors.Wrapf(err, "failed to scrape the components using the %s provider", provider.Name())
+	}
+	return components, true, nil
+}
+
+func (s *scraper) ScrapeStatusPageStatus(ctx context.Context, url string) (api.StatusSnapshot, bool, error) {
+	ctx = utils.UpdateContextMdc(ctx, map[string]string{"url": url})
+	provider, err := s.matchProvider(ctx, url, scrapeKindStatus)
+	if err != nil {
+		return api.StatusSnapshot{}, false, err
+	}
+	statusProvider, ok := provider.(providers.StatusProvider)
+	if !ok {
+		return api.StatusSnapshot{}, false, nil
+	}
+	ctx = utils.UpdateContextMdc(ctx, map[string]string{"provider": provider.Name()})
+	ctx = providers.WithName(ctx, provider.Name())
+	start := time.Now()
+	status, err := statusProvider.ScrapeStatus(ctx, url)
+	observeScrape(provider.Name(), scrapeKindStatus, start, 0, err)
+	if err != nil {
+		return api.StatusSnapshot{}, true, errors.Wrapf(err, "failed to scrape the status using the %s provider", provider.Name())
+	}
+	return status, true, nil
+}
+
+// normalizeImpacts maps the impacts that providers copied from the status page as they are to a known impact
+// The original value is kept as the raw impact if the provider didn't set one
+func normalizeImpacts(incidents []api.Incident) {
+	for i := range incidents {
+		if incidents[i].Impact.Severity() != -1 {
+			continue
+		}
+		if incidents[i].RawImpact == "" {
+			incidents[i].RawImpact = string(incidents[i].Impact)
+		}
+		incidents[i].Impact = api.NormalizeImpact(string(incidents[i].Impact))
+	}
+}
+
+// normalizeDescriptions sets the plaintext and safe html variants of the descriptions, so clients don't have to
+// sanitize the html that the providers copied from the status page
+func normalizeDescriptions(incidents []api.Incident) {
+	for i := range incidents {
+		if incidents[i].Description == nil {
+			continue
+		}
+		text, safeHTML := sanitize.Description(*incidents[i].Description)
+		incidents[i].DescriptionText = &text
+		incidents[i].DescriptionHTML = &safeHTML
+	}
+}
+
+// The kinds of scrape that the metrics are broken down by
+const (
+	scrapeKindCurrent    = "current"
+	scrapeKindHistorical = "historical"
+	scrapeKindBackfill   = "backfill"
+	scrapeKindComponents = "components"
+	scrapeKindStatus     = "status"
+)
+
+// observeScrape records the outcome of a scrape in the metrics
+func observeScrape(provider string, kind string, start time.Time, incidents int, err error) {
+	metrics.ScrapeDuration.WithLabelValues(provider, kind).Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.ScrapeFailures.WithLabelValues(provider, kind, failureReason(err)).Inc()
+		return
+	}
+	if kind != scrapeKindComponents && kind != scrapeKindStatus {
+		metrics.IncidentsFound.WithLabelValues(provider, kind).Add(float64(incidents))
+	}
+}
+
+// failureReason tells a request that failed apart from a response the provider couldn't make sense of
+func failureReason(err error) string {
+	var urlErr *neturl.Error
+	if errors.As(err, &urlErr) {
+		return "fetch"
+	}
+	return "parse"
+}
+
+// setProvider records which provider scraped the incidents
+func setProvider(incidents []api.Incident, provider string) {
+	for i := range incidents {
+		incidents[i].Provider = provider
+	}
+}
+
+func (s *scraper) DetectProvider(ctx context.Context, url string) (string, error) {
+	ctx = utils.UpdateContextMdc(ctx, map[string]string{"url": url})
+	candidates, err := detect.Probe(ctx, s.httpClient, url)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to probe the status page")
+	}
+	for _, candidate := range candidates {
+		provider := s.provider(candidate)
+		if provider == nil {
+			continue
+		}
+		matches, err := provider.Matches(providers.WithName(ctx, provider.Name()), url)
+		if err != nil {
+			utils.GetLogger(ctx, s.logger).Info("Failed to determine if the detected provider matches the status page", zap.String("provider", provider.Name()), zap.Error(err))
+			continue
+		}
+		if matches {
+			return provider.Name(), nil
+		}
+	}
+	// The probes don't know every provider, e.g. the declarative ones, so the rest are matched in turn
+	provider, err := s.matchProvider(ctx, url, "detect")
+	if err != nil {
+		return "", err
+	}
+	return provider.Name(), nil
+}
+
+func (s *scraper) IsGone(ctx context.Context, url string) (bool, error) {
+	return detect.Gone(ctx, s.httpClient, url)
+}
+
+func (s *scraper) FetchMetadata(ctx context.Context, url string) (api.StatusPageMetadata, error) {
+	return metadata.Fetch(ctx, s.httpClient, url)
+}
+
+// provider returns the provider with the name, nil if it isn't registered
+func (s *scraper) provider(name string) providers.Provider {
+	for _, provider := range s.providers {
+		if provider.Name() == name {
+			return provider
+		}
+	}
+	return nil
+}
+
+// matchProvider returns the provider of the status page, which is the provider defined by the selectors of its
+// scrape config or the hinted provider if there is one, and otherwise the first provider that matches the status page
+// A provider that fails to determine whether it matches is skipped
+func (s *scraper) matchProvider(ctx context.Context, url string, kind string) (providers.Provider, error) {
+	if selectors := providers.SelectorsFromContext(ctx); len(selectors) > 0 {
+		config, err := declarative.ParseForStatusPage(selectors, url)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid selectors in the scrape config of the status page")
+		}
+		return declarative.NewDeclarativeProvider(s.logger, s.httpClient, config), nil
+	}
+	if hinted := s.provider(providers.HintFromContext(ctx)); hinted != nil {
+		return hinted, nil
+	}
+	for _, provider := range s.providers {
+		matches, err := provider.Matches(providers.WithName(ctx, provider.Name()), url)
+		if err != nil {
+			utils.GetLogger(ctx, s.logger).Info("Failed to determine if the provider matches the status page", zap.String("provider", provider.Name()), zap.Error(err))
+			continue
+		}
+		if matches {
+			return provider, nil
+		}
+	}
+	// A status page that no longer matches its provider is often the first sign that the provider has broken
+	metrics.ScrapeFailures.WithLabelValues(metrics.ProviderLabel(""), kind, "match").Inc()
+	return nil, errors.New("no provider matches the status page")
+}
@@ -0,0 +1,59 @@
+package scraper
+
+import (
+	"context"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type Scraper interface {
+	// ScrapeStatusPageHistorical scrapes the status page at the given URL and returns a list of incidents
+	// The incidents are historical, meaning they are not just the current incidents, this can be expected to return a large number of incidents
+	// And take a long time to run, so we should only run this infrequently, maybe once per week per page
+	ScrapeStatusPageHistorical(ctx context.Context, url string) ([]api.Incident, error)
+
+	// ScrapeStatusPageCurrent scrapes the status page at the given URL and returns a list of incidents
+	// The incidents are current, meaning they are only the recent incidents, this can be expected to return a small number of incidents
+	// And take a short time to run, so we should run this frequently, maybe once per 5 minutes per page
+	ScrapeStatusPageCurrent(ctx context.Context, url string) ([]api.Incident, error)
+
+	// ScrapeStatusPageBackfill scrapes the whole incident archive of the status page at the given URL
+	// The incidents are passed to consume a page of the archive at a time as an archive can hold thousands of them,
+	// along with the cursor that resumes the backfill after that page. An empty cursor starts at the beginning
+	// supported is false if the provider of the status page can't walk its archive
+	ScrapeStatusPageBackfill(ctx context.Context, url string, cursor string, consume func(incidents []api.Incident, cursor string) error) (supported bool, err error)
+
+	// ScrapeStatusPageComponents scrapes the component list of the status page at the given URL
+	// supported is false if the provider of the status page can't scrape components
+	ScrapeStatusPageComponents(ctx context.Context, url string) (components []api.Component, supported bool, err error)
+
+	// DetectProvider returns the name of the provider that scrapes the status page at the given URL
+	// The well-known endpoints of the status page products are probed so that the likely providers are tried first
+	DetectProvider(ctx context.Context, url string) (string, error)
+
+	// IsGone returns true if the status page at the given URL no longer exists
+	IsGone(ctx context.Context, url string) (bool, error)
+
+	// FetchMetadata reads the metadata of the status page at the given URL from its home page
+	FetchMetadata(ctx context.Context, url string) (api.StatusPageMetadata, error)
+
+	// ScrapeStatusPageStatus scrapes the overall status shown by the status page at the given URL
+	// supported is false if the provider of the status page can't scrape it
+	ScrapeStatusPageStatus(ctx context.Context, url string) (status api.StatusSnapshot, supported bool, err error)
+}
+
+type scraper struct {
+	providers  []providers.Provider
+	logger     *zap.Logger
+	httpClient *http.Client
+}
+
+func NewScraper(logger *zap.Logger, httpClient *http.Client, providers []providers.Provider) Scraper {
+	return &scraper{
+		logger:     logger,
+		httpClient: httpClient,
+		providers:  providers,
+	}
+}
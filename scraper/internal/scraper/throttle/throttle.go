@@ -0,0 +1,122 @@
+package throttle
+
+import (
+	"context"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+const (
+	// FlushInterval is how often the collapsed updates are checked for the end of their window
+	FlushInterval = 30 * time.Second
+	// retention is how long an incident is remembered after its last notification, so that a late duplicate of it is
+	// still dropped
+	retention       = 24 * time.Hour
+	cleanupInterval = 1 * time.Hour
+)
+
+// Throttler deduplicates and throttles the notifications of each incident to each destination, e.g. a chat channel or a
+// webhook subscription. A change is dropped if it repeats the last one that was sent, and the updates that follow a
+// notification within the window of the destination are collapsed into a single notification of the latest update once
+// the window has passed. The openings and resolutions are never held back, and a resolution drops the updates that were
+// held back before it. The state is kept in the database, so it survives restarts and is shared by the scrapers
+type Throttler struct {
+	logger   *zap.Logger
+	dbClient *db.DbClient
+	// redeliver admits an event again if it was the last one sent, for the destinations that deduplicate the events
+	// themselves, so that an event isn't lost if the batch that recorded it as sent failed and is redelivered
+	redeliver bool
+
+	mu          sync.Mutex
+	lastCleanup time.Time
+}
+
+// Collapsed is a notification of the updates that were held back, Updates is the number of updates it stands for
+type Collapsed struct {
+	Destination string
+	Event       api.ChangeEvent
+	Updates     int
+}
+
+func NewThrottler(logger *zap.Logger, dbClient *db.DbClient, redeliver bool) *Throttler {
+	return &Throttler{logger: logger, dbClient: dbClient, redeliver: redeliver}
+}
+
+// Admit returns true if the event is to be sent to the destination now, it is then recorded as sent. Otherwise it was a
+// duplicate or it is held back until the window of the destination has passed
+func (t *Throttler) Admit(ctx context.Context, destination string, window time.Duration, event api.ChangeEvent, now time.Time) (bool, error) {
+	admitted := false
+	err := t.dbClient.UpdateNotificationThrottle(ctx, destination, event.DeepLink, func(throttle *db.NotificationThrottle) {
+		if throttle.LastEventID != 0 {
+			// The outbox redelivers the events of a batch that failed, and the state of an incident only changes once
+			if event.ID == throttle.LastEventID {
+				admitted = t.redeliver
+				return
+			}
+			if event.Type != api.ChangeEventIncidentUpdated && event.Type == throttle.LastType {
+				return
+			}
+			if throttle.Pending != nil && event.ID == throttle.Pending.ID {
+				return
+			}
+			if event.Type == api.ChangeEventIncidentUpdated && window > 0 && now.Sub(throttle.LastSentAt) < window {
+				dueAt := throttle.LastSentAt.Add(window)
+				throttle.Pending = &event
+				throttle.PendingCount++
+				throttle.DueAt = &dueAt
+				return
+			}
+		}
+		admitted = true
+		throttle.LastEventID = event.ID
+		throttle.LastType = event.Type
+		throttle.LastSentAt = now
+		throttle.Pending = nil
+		throttle.PendingCount = 0
+		throttle.DueAt = nil
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "failed to throttle notification")
+	}
+	return admitted, nil
+}
+
+// Due returns the collapsed updates of the destinations whose window has passed, they are recorded as sent. The
+// incidents that haven't been notified for a while are forgotten
+func (t *Throttler) Due(ctx context.Context, destinations []string, now time.Time) ([]Collapsed, error) {
+	t.cleanup(ctx, now)
+	throttles, err := t.dbClient.ClaimDueNotificationThrottles(ctx, destinations, now)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the collapsed notifications")
+	}
+	var due []Collapsed
+	for _, throttle := range throttles {
+		if throttle.Pending == nil {
+			continue
+		}
+		due = append(due, Collapsed{Destination: throttle.Destination, Event: *throttle.Pending, Updates: throttle.PendingCount})
+	}
+	return due, nil
+}
+
+func (t *Throttler) cleanup(ctx context.Context, now time.Time) {
+	t.mu.Lock()
+	if now.Sub(t.lastCleanup) < cleanupInterval {
+		t.mu.Unlock()
+		return
+	}
+	t.lastCleanup = now
+	t.mu.Unlock()
+	deleted, err := t.dbClient.DeleteNotificationThrottlesSentBefore(ctx, now.Add(-retention))
+	if err != nil {
+		t.logger.Error("failed to delete old notification throttles", zap.Error(err))
+		return
+	}
+	if deleted > 0 {
+		t.logger.Info("deleted old notification throttles", zap.Int64("deleted", deleted))
+	}
+}
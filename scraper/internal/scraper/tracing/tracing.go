@@ -0,0 +1,259 @@
+package tracing
+
+import (
+	"context"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/metrics"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The stages of a scrape that are timed
+const (
+	StageFetch     = "fetch"
+	StageParse     = "parse"
+	StageNormalize = "normalize"
+	StagePersist   = "persist"
+	// stageOther is the time of a scrape that isn't spent in any of the stages, e.g. waiting for a lock
+	stageOther = "other"
+)
+
+type Config struct {
+	// SlowScrapeThreshold is how long a scrape has to take for its stage timings to be logged, zero disables the log
+	SlowScrapeThreshold time.Duration `envconfig:"SCRAPER_SLOW_SCRAPE_THRESHOLD" default:"30s"`
+}
+
+func GetConfigFromEnvironment() (Config, error) {
+	var config Config
+	err := envconfig.Process("STATUSPHERE", &config)
+	return config, err
+}
+
+// Tracer starts the spans of the scrapes, the spans named after a stage are the stages of the scrape they are nested in
+// They are exported if telemetry is enabled, see the telemetry package
+var Tracer = otel.Tracer("github.com/metoro-io/statusphere/scraper/internal/scraper/tracing")
+
+// scrapeKindKey is the attribute that marks the span of a scrape, its value is the kind of scrape, e.g. current
+const scrapeKindKey = attribute.Key("statusphere.scrape.kind")
+
+// StartScrape starts the span of a scrape of the given kind, e.g. current, whose stages are timed
+func StartScrape(ctx context.Context, kind string, url string) (context.Context, trace.Span) {
+	return Tracer.Start(ctx, "scrape "+kind, trace.WithAttributes(scrapeKindKey.String(kind), attribute.String("url", url)))
+}
+
+// StageProcessor is a span processor that times the stages of the scrapes from their spans
+// The time of a stage is the time of its spans less the time of the stage spans nested in them,
+// e.g. the parse stage doesn't include the requests the provider made while parsing
+// It must be registered with the tracer provider, see telemetry.Init, spans that end after their scrape aren't counted
+type StageProcessor struct {
+	logger              *zap.Logger
+	slowScrapeThreshold time.Duration
+
+	mu    sync.Mutex
+	spans map[trace.SpanID]*stageSpan
+}
+
+// stageSpan is a span started within a scrape
+type stageSpan struct {
+	name     string
+	scrape   *scrapeSpans
+	parent   *stageSpan
+	duration time.Duration
+	ended    bool
+	// children are the nearest stage spans nested in the span
+	children []*stageSpan
+}
+
+// scrapeSpans are the spans of a scrape, the first is the span of the scrape itself
+type scrapeSpans struct {
+	kind   string
+	fields []zap.Field
+	spans  []trace.SpanID
+}
+
+func NewStageProcessor(logger *zap.Logger, config Config) *StageProcessor {
+	return &StageProcessor{
+		logger:              logger,
+		slowScrapeThreshold: config.SlowScrapeThreshold,
+		spans:               map[trace.SpanID]*stageSpan{},
+	}
+}
+
+func (p *StageProcessor) OnStart(_ context.Context, s sdktrace.ReadWriteSpan) {
+	var kind string
+	var fields []zap.Field
+	for _, kv := range s.Attributes() {
+		if kv.Key == scrapeKindKey {
+			kind = kv.Value.AsString()
+		} else if kv.Value.Type() == attribute.STRING {
+			fields = append(fields, zap.String(string(kv.Key), kv.Value.AsString()))
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if kind != "" {
+		scrape := &scrapeSpans{kind: kind, fields: fields, spans: []trace.SpanID{s.SpanContext().SpanID()}}
+		p.spans[s.SpanContext().SpanID()] = &stageSpan{name: kind, scrape: scrape}
+		return
+	}
+	parent, found := p.spans[s.Parent().SpanID()]
+	if !found {
+		return
+	}
+	span := &stageSpan{name: s.Name(), scrape: parent.scrape, parent: parent}
+	if isStage(span.name) {
+		// The time of a stage is taken from the nearest stage, or the scrape, that it is nested in
+		for parent.parent != nil && !isStage(parent.name) {
+			parent = parent.parent
+		}
+		parent.children = append(parent.children, span)
+	}
+	p.spans[s.SpanContext().SpanID()] = span
+	span.scrape.spans = append(span.scrape.spans, s.SpanContext().SpanID())
+}
+
+func (p *StageProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	p.mu.Lock()
+	span, found := p.spans[s.SpanContext().SpanID()]
+	if !found {
+		p.mu.Unlock()
+		return
+	}
+	span.ended = true
+	span.duration = s.EndTime().Sub(s.StartTime())
+	if span.parent != nil {
+		p.mu.Unlock()
+		return
+	}
+	for _, id := range span.scrape.spans {
+		delete(p.spans, id)
+	}
+	stages, counts := span.stages()
+	p.mu.Unlock()
+	p.record(span, stages, counts)
+}
+
+func (p *StageProcessor) Shutdown(context.Context) error {
+	return nil
+}
+
+func (p *StageProcessor) ForceFlush(context.Context) error {
+	return nil
+}
+
+func isStage(name string) bool {
+	return name == StageFetch || name == StageParse || name == StageNormalize || name == StagePersist
+}
+
+// stages adds up the time of each stage of the scrape, whose span is s
+func (s *stageSpan) stages() (map[string]time.Duration, map[string]int) {
+	stages := map[string]time.Duration{}
+	counts := map[string]int{}
+	var add func(span *stageSpan)
+	add = func(span *stageSpan) {
+		if !span.ended {
+			return
+		}
+		self := span.duration
+		for _, child := range span.children {
+			if child.ended {
+				self -= child.duration
+			}
+		}
+		// Concurrent nested spans can add up to more than their parent
+		if self < 0 {
+			self = 0
+		}
+		stage := span.name
+		if span.parent == nil {
+			stage = stageOther
+		}
+		stages[stage] += self
+		counts[stage]++
+		for _, child := range span.children {
+			add(child)
+		}
+	}
+	add(s)
+	return stages, counts
+}
+
+// record observes the stage timings of the scrape and logs them if the scrape was slow
+func (p *StageProcessor) record(scrape *stageSpan, stages map[string]time.Duration, counts map[string]int) {
+	for stage, duration := range stages {
+		metrics.StageDuration.WithLabelValues(scrape.name, stage).Observe(duration.Seconds())
+	}
+	if p.slowScrapeThreshold <= 0 || scrape.duration < p.slowScrapeThreshold {
+		return
+	}
+	fields := append([]zap.Field{zap.String("scrape", scrape.name), zap.Duration("duration", scrape.duration)}, scrape.scrape.fields...)
+	names := make([]string, 0, len(stages))
+	for stage := range stages {
+		names = append(names, stage)
+	}
+	sort.Strings(names)
+	for _, stage := range names {
+		fields = append(fields, zap.Duration(stage, stages[stage]))
+	}
+	fields = append(fields, zap.Int("fetches", counts[StageFetch]))
+	p.logger.Info("slow scrape", fields...)
+}
+
+// Transport is an http.RoundTripper that times the requests of a scrape as the fetch stage, until their body is read
+type Transport struct {
+	base http.RoundTripper
+}
+
+func NewTransport(base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{base: base}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Requests outside of a trace, e.g. outside of a scrape, aren't timed
+	if !trace.SpanContextFromContext(req.Context()).IsValid() {
+		return t.base.RoundTrip(req)
+	}
+	_, span := Tracer.Start(req.Context(), StageFetch, trace.WithAttributes(attribute.String("http.request.method", req.Method), attribute.String("url.full", req.URL.String())))
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	resp.Body = &spanBody{ReadCloser: resp.Body, span: span}
+	return resp, nil
+}
+
+// spanBody ends the fetch span once the body has been read or closed
+type spanBody struct {
+	io.ReadCloser
+	span trace.Span
+	once sync.Once
+}
+
+func (b *spanBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil {
+		b.once.Do(func() { b.span.End() })
+	}
+	return n, err
+}
+
+func (b *spanBody) Close() error {
+	b.once.Do(func() { b.span.End() })
+	return b.ReadCloser.Close()
+}
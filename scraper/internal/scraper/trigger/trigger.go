@@ -0,0 +1,135 @@
+package trigger
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/poller"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+)
+
+type Config struct {
+	// Address is where the scrape trigger is served, e.g. :8081, empty disables it
+	Address string `envconfig:"SCRAPER_TRIGGER_ADDRESS" default:""`
+	// Token is the bearer token that a trigger request has to carry, the same admin token as the api server's
+	Token string `envconfig:"API_ADMIN_TOKEN" default:""`
+}
+
+func GetConfigFromEnvironment() (Config, error) {
+	var config Config
+	err := envconfig.Process("STATUSPHERE", &config)
+	return config, err
+}
+
+type ScrapeResponse struct {
+	StatusPageUrl string         `json:"statusPageUrl"`
+	Incidents     []api.Incident `json:"incidents"`
+}
+
+// Server scrapes a status page on demand, for when an incident is unfolding and the next scheduled scrape is minutes away
+type Server struct {
+	logger   *zap.Logger
+	dbClient *db.DbClient
+	poller   *poller.Poller
+	config   Config
+}
+
+func NewServer(logger *zap.Logger, dbClient *db.DbClient, poller *poller.Poller, config Config) *Server {
+	return &Server{logger: logger, dbClient: dbClient, poller: poller, config: config}
+}
+
+// Serve serves the trigger in the background
+func (s *Server) Serve() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/scrape", s.scrape)
+	go func() {
+		err := http.ListenAndServe(s.config.Address, mux)
+		if err != nil {
+			s.logger.Error("scrape trigger server stopped", zap.Error(err))
+		}
+	}()
+}
+
+// scrape is a handler for POST /api/v1/scrape?url=..., it scrapes the status page and returns the scraped incidents
+func (s *Server) scrape(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		// The same as the api server, which doesn't tell a wrong method apart from a missing endpoint
+		respondWithError(w, r, api.ErrorCodeNotFound, "endpoint not found", nil)
+		return
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if s.config.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.config.Token)) != 1 {
+		respondWithError(w, r, api.ErrorCodeUnauthorized, "a valid admin token is required", nil)
+		return
+	}
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		respondWithError(w, r, api.ErrorCodeMissingParameter, "url is required", map[string]string{"parameter": "url"})
+		return
+	}
+
+	url, found, err := s.statusPageUrl(r.Context(), url)
+	if err != nil {
+		s.logger.Error("failed to get status page", zap.Error(err), zap.String("url", url))
+		respondWithError(w, r, api.ErrorCodeInternal, "failed to get status page", nil)
+		return
+	}
+	if !found {
+		respondWithError(w, r, api.ErrorCodeStatusPageNotFound, "status page not known to statusphere", nil)
+		return
+	}
+
+	s.logger.Info("scrape triggered", zap.String("url", url))
+	incidents, err := s.poller.ScrapeNow(url)
+	if err != nil {
+		if errors.Is(err, poller.ErrScrapeInProgress) {
+			respondWithError(w, r, api.ErrorCodeScrapeInProgress, err.Error(), nil)
+			return
+		}
+		respondWithError(w, r, api.ErrorCodeScrapeFailed, err.Error(), nil)
+		return
+	}
+	if incidents == nil {
+		incidents = []api.Incident{}
+	}
+	respond(w, http.StatusOK, ScrapeResponse{StatusPageUrl: url, Incidents: incidents})
+}
+
+// statusPageUrl returns the url the status page is stored under, following an alias of a status page that has moved
+func (s *Server) statusPageUrl(ctx context.Context, url string) (string, bool, error) {
+	alias, err := s.dbClient.GetStatusPageAlias(ctx, url)
+	if err != nil {
+		return url, false, err
+	}
+	if alias != nil {
+		url = alias.StatusPageUrl
+	}
+	statusPage, err := s.dbClient.GetStatusPage(ctx, url)
+	if err != nil {
+		return url, false, err
+	}
+	return url, statusPage != nil, nil
+}
+
+func respondWithError(w http.ResponseWriter, r *http.Request, code api.ErrorCode, message string, details map[string]string) {
+	if api.AcceptsProblem(r.Header.Get("Accept")) {
+		w.Header().Set("Content-Type", api.ProblemContentType)
+		respond(w, code.Status(), api.NewProblem(code, message, details, r.URL.Path))
+		return
+	}
+	respond(w, code.Status(), api.ErrorResponse{Error: api.NewError(code, message, details)})
+}
+
+func respond(w http.ResponseWriter, status int, body interface{}) {
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	}
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
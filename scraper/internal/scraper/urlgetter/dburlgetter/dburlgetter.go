@@ -0,0 +1,510 @@
+package dburlgetter
+
+import (
+	"context"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/urlgetter"
+	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	neturl "net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+type DBURLGetter struct {
+	logger          *zap.Logger
+	dbClient        *db.DbClient
+	StatusPageCache *cache.Cache
+}
+
+func NewDBURLGetter(logger *zap.Logger, client *db.DbClient) *DBURLGetter {
+	return &DBURLGetter{
+		logger:          logger,
+		dbClient:        client,
+		StatusPageCache: cache.New(time.Minute*20, time.Minute*10),
+	}
+}
+
+func (s *DBURLGetter) UpdateLastScrapedTimeHistorical(url string, time time.Time) error {
+	statusPage, err := s.dbClient.GetStatusPage(context.Background(), url)
+	if err != nil {
+		return errors.Wrap(err, "failed to get status page")
+	}
+	// The status page can be deleted while it is scraped
+	if statusPage == nil {
+		return nil
+	}
+	statusPage.LastHistoricallyScraped = time
+	err = s.dbClient.UpdateStatusPage(context.Background(), *statusPage)
+	if err != nil {
+		return errors.Wrap(err, "failed to update status page")
+	}
+	s.StatusPageCache.Set(url, *statusPage, cache.DefaultExpiration)
+	return nil
+}
+
+// maxLastErrorLength bounds the error stored on the status page, some errors quote the whole response
+const maxLastErrorLength = 1000
+
+func (s *DBURLGetter) RecordScrapeResult(url string, result urlgetter.ScrapeResult) error {
+	statusPage, err := s.dbClient.GetStatusPage(context.Background(), url)
+	if err != nil {
+		return errors.Wrap(err, "failed to get status page")
+	}
+	if statusPage == nil {
+		return errors.Errorf("status page %s not found", url)
+	}
+	statusPage.LastCurrentlyScraped = result.Time
+	statusPage.ScrapeDurationMs = result.Duration.Milliseconds()
+	recordDead(s.logger, statusPage, result)
+	if result.Err == nil {
+		statusPage.IsIndexed = true
+		statusPage.LastSuccessfulScrapeAt = result.Time
+		statusPage.LastError = ""
+		statusPage.ConsecutiveFailureCount = 0
+	} else {
+		statusPage.LastError = result.Err.Error()
+		if len(statusPage.LastError) > maxLastErrorLength {
+			statusPage.LastError = statusPage.LastError[:maxLastErrorLength]
+		}
+		statusPage.ConsecutiveFailureCount++
+		if statusPage.ConsecutiveFailureCount == circuitBreakerThreshold {
+			s.logger.Warn("status page degraded, pausing scrapes", zap.String("url", url), zap.Int("failures", statusPage.ConsecutiveFailureCount), zap.Duration("coolDown", circuitBreakerCoolDown))
+		}
+	}
+	err = s.dbClient.UpdateStatusPageScrapeResult(context.Background(), *statusPage)
+	if err != nil {
+		return errors.Wrap(err, "failed to update status page")
+	}
+	s.StatusPageCache.Set(url, *statusPage, cache.DefaultExpiration)
+	return nil
+}
+
+// A status page that has been gone for deadAfter is dead, it is then only scraped every deadReprobeInterval
+const deadAfter = 72 * time.Hour
+const deadReprobeInterval = 24 * time.Hour
+
+// recordDead tracks how long the status page has been gone for and quarantines it once it is dead
+func recordDead(logger *zap.Logger, statusPage *api.StatusPage, result urlgetter.ScrapeResult) {
+	if result.Err == nil || !result.Gone {
+		if statusPage.IsDead {
+			logger.Info("dead status page has come back", zap.String("url", statusPage.URL))
+		}
+		statusPage.DeadSince = nil
+		statusPage.IsDead = false
+		return
+	}
+	if statusPage.DeadSince == nil {
+		statusPage.DeadSince = &result.Time
+	}
+	if !statusPage.IsDead && result.Time.Sub(*statusPage.DeadSince) >= deadAfter {
+		logger.Warn("status page is dead, quarantining it", zap.String("url", statusPage.URL), zap.Time("deadSince", *statusPage.DeadSince))
+		statusPage.IsDead = true
+	}
+}
+
+func (s *DBURLGetter) UpdateHasActiveIncident(url string, active bool) error {
+	if cached, found := s.StatusPageCache.Get(url); found {
+		if statusPage, ok := cached.(api.StatusPage); ok && statusPage.HasActiveIncident == active {
+			return nil
+		}
+	}
+	err := s.dbClient.SetStatusPageHasActiveIncident(context.Background(), url, active)
+	if err != nil {
+		return errors.Wrap(err, "failed to update status page")
+	}
+	statusPage, err := s.dbClient.GetStatusPage(context.Background(), url)
+	if err != nil {
+		return errors.Wrap(err, "failed to get status page")
+	}
+	if statusPage != nil {
+		s.StatusPageCache.Set(url, *statusPage, cache.DefaultExpiration)
+	}
+	return nil
+}
+
+// defaultScrapeInterval is used for the status pages without a scrape interval of their own
+const defaultScrapeInterval = 5 * time.Minute
+
+// activeIncidentScrapeInterval is used while a status page has an ongoing incident, so that its updates are picked up quickly
+const activeIncidentScrapeInterval = 30 * time.Second
+
+// After circuitBreakerThreshold consecutive failed scrapes a status page is degraded, it is then only
+// scraped once every circuitBreakerCoolDown until a scrape succeeds
+const circuitBreakerThreshold = 5
+const circuitBreakerCoolDown = 30 * time.Minute
+
+func scrapeInterval(statusPage api.StatusPage) time.Duration {
+	if statusPage.IsDead {
+		return deadReprobeInterval
+	}
+	if statusPage.ConsecutiveFailureCount >= circuitBreakerThreshold {
+		return circuitBreakerCoolDown
+	}
+	interval := defaultScrapeInterval
+	if statusPage.ScrapeConfig != nil && statusPage.ScrapeConfig.IntervalSeconds > 0 {
+		interval = time.Duration(statusPage.ScrapeConfig.IntervalSeconds) * time.Second
+	} else if statusPage.ScrapeIntervalSeconds > 0 {
+		interval = time.Duration(statusPage.ScrapeIntervalSeconds) * time.Second
+	}
+	if statusPage.HasActiveIncident && interval > activeIncidentScrapeInterval {
+		return activeIncidentScrapeInterval
+	}
+	return interval
+}
+
+// GetUrlsToScrape returns the status pages whose scrape interval has passed, paused status pages are skipped
+// ordered by priority and then by how long they have been waiting
+func (s *DBURLGetter) GetUrlsToScrape() ([]string, error) {
+	var due []api.StatusPage
+	items := s.StatusPageCache.Items()
+	for _, v := range items {
+		statusPage, ok := v.Object.(api.StatusPage)
+		if !ok {
+			s.logger.Error("failed to cast status page")
+			continue
+		}
+		if statusPage.PausedAt == nil && time.Since(statusPage.LastCurrentlyScraped) > scrapeInterval(statusPage) {
+			due = append(due, statusPage)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if due[i].ScrapePriority != due[j].ScrapePriority {
+			return due[i].ScrapePriority > due[j].ScrapePriority
+		}
+		return due[i].LastCurrentlyScraped.Before(due[j].LastCurrentlyScraped)
+	})
+
+	urlsToUse := make([]string, 0, len(due))
+	for _, statusPage := range due {
+		urlsToUse = append(urlsToUse, statusPage.URL)
+	}
+	return urlsToUse, nil
+}
+
+const timeToRescrapeHistorical = 24 * time.Hour * 7
+
+func (s *DBURLGetter) GetHistoricalUrlsToScrape() ([]string, error) {
+	urlsToUse := []string{}
+	items := s.StatusPageCache.Items()
+	for k, v := range items {
+		statusPage, ok := v.Object.(api.StatusPage)
+		if !ok {
+			s.logger.Error("failed to cast status page")
+			continue
+		}
+		if !statusPage.IsDead && statusPage.PausedAt == nil && statusPage.BackfilledAt != nil && time.Since(statusPage.LastHistoricallyScraped) > timeToRescrapeHistorical {
+			urlsToUse = append(urlsToUse, k)
+		}
+	}
+	return urlsToUse, nil
+}
+
+// timeToRetryBackfill is how long after a failed backfill it is attempted again
+const timeToRetryBackfill = 6 * time.Hour
+
+func (s *DBURLGetter) GetBackfillUrlsToScrape() ([]string, error) {
+	urlsToUse := []string{}
+	items := s.StatusPageCache.Items()
+	for k, v := range items {
+		statusPage, ok := v.Object.(api.StatusPage)
+		if !ok {
+			s.logger.Error("failed to cast status page")
+			continue
+		}
+		// Every backfill attempt updates the last historically scraped time, so a new status page is backfilled straight away
+		if !statusPage.IsDead && statusPage.PausedAt == nil && statusPage.BackfilledAt == nil && time.Since(statusPage.LastHistoricallyScraped) > timeToRetryBackfill {
+			urlsToUse = append(urlsToUse, k)
+		}
+	}
+	return urlsToUse, nil
+}
+
+// GetBackfillCursor reads the cursor from the database rather than the cache
+// as the cache can be older than the progress of a backfill that was just interrupted
+func (s *DBURLGetter) GetBackfillCursor(url string) (string, error) {
+	statusPage, err := s.dbClient.GetStatusPage(context.Background(), url)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get status page")
+	}
+	if statusPage == nil {
+		return "", errors.New("status page not found")
+	}
+	return statusPage.BackfillCursor, nil
+}
+
+func (s *DBURLGetter) UpdateBackfillCursor(url string, cursor string) error {
+	err := s.dbClient.SetStatusPageBackfillCursor(context.Background(), url, cursor)
+	if err != nil {
+		return errors.Wrap(err, "failed to update backfill cursor")
+	}
+	return nil
+}
+
+func (s *DBURLGetter) UpdateBackfilled(url string, time time.Time) error {
+	statusPage, err := s.dbClient.GetStatusPage(context.Background(), url)
+	if err != nil {
+		return errors.Wrap(err, "failed to get status page")
+	}
+	// The status page can be deleted while it is scraped
+	if statusPage == nil {
+		return nil
+	}
+	statusPage.BackfilledAt = &time
+	statusPage.LastHistoricallyScraped = time
+	statusPage.BackfillCursor = ""
+	err = s.dbClient.UpdateStatusPage(context.Background(), *statusPage)
+	if err != nil {
+		return errors.Wrap(err, "failed to update status page")
+	}
+	// UpdateStatusPage skips empty values so the cursor is cleared separately
+	err = s.dbClient.SetStatusPageBackfillCursor(context.Background(), url, "")
+	if err != nil {
+		return errors.Wrap(err, "failed to clear backfill cursor")
+	}
+	s.StatusPageCache.Set(url, *statusPage, cache.DefaultExpiration)
+	return nil
+}
+
+func (s *DBURLGetter) Start() {
+	s.UpdateStatusPageCache()
+}
+
+func (s *DBURLGetter) UpdateStatusPageCache() {
+	// Update the cache every 1 minute
+	s.updateStatusPageCacheInner()
+	ticker := time.NewTicker(1 * time.Minute)
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				s.updateStatusPageCacheInner()
+			}
+		}
+	}()
+}
+
+func (s *DBURLGetter) updateStatusPageCacheInner() {
+	s.logger.Info("updating status page cache")
+	statusPages, err := s.dbClient.GetAllStatusPages(context.Background())
+	if err != nil {
+		s.logger.Error("failed to get status pages", zap.Error(err))
+		return
+	}
+	stored := map[string]bool{}
+	for _, statusPage := range statusPages {
+		stored[statusPage.URL] = true
+		s.StatusPageCache.Set(statusPage.URL, statusPage, cache.DefaultExpiration)
+	}
+	// Status pages that have been moved to another url by a scraper are no longer stored at the old one
+	for url := range s.StatusPageCache.Items() {
+		if !stored[url] {
+			s.StatusPageCache.Delete(url)
+		}
+	}
+}
+
+// RequiresJS returns true if the url is on a status page that has to be rendered in a headless browser
+// The scrape config of the status page takes precedence over its requires_js column
+func (s *DBURLGetter) RequiresJS(url string) bool {
+	statusPage, found := s.statusPageOf(url)
+	if !found {
+		return false
+	}
+	if statusPage.ScrapeConfig != nil && statusPage.ScrapeConfig.RequiresJS != nil {
+		return *statusPage.ScrapeConfig.RequiresJS
+	}
+	return statusPage.RequiresJS
+}
+
+// Headers returns the headers that the scrape config of the status page that the url is on sends, nil if it has none
+func (s *DBURLGetter) Headers(url string) map[string]string {
+	statusPage, found := s.statusPageOf(url)
+	if !found || statusPage.ScrapeConfig == nil || len(statusPage.ScrapeConfig.Headers) == 0 {
+		return nil
+	}
+	return statusPage.ScrapeConfig.Headers
+}
+
+// statusPageOf returns the status page that the url is on, the one with the longest path if several are on the host,
+// e.g. https://example.com/status rather than https://example.com for https://example.com/status/incidents/1
+// The hosts have to be the same and the path of the status page has to end at a segment of the path of the url
+func (s *DBURLGetter) statusPageOf(rawUrl string) (api.StatusPage, bool) {
+	parsed, err := neturl.Parse(rawUrl)
+	if err != nil {
+		return api.StatusPage{}, false
+	}
+	var match api.StatusPage
+	matchLength := -1
+	for _, item := range s.StatusPageCache.Items() {
+		statusPage, ok := item.Object.(api.StatusPage)
+		if !ok {
+			continue
+		}
+		statusPageParsed, err := neturl.Parse(statusPage.URL)
+		if err != nil || !strings.EqualFold(statusPageParsed.Host, parsed.Host) {
+			continue
+		}
+		path := strings.TrimSuffix(statusPageParsed.Path, "/")
+		if parsed.Path != path && !strings.HasPrefix(parsed.Path, path+"/") {
+			continue
+		}
+		// The url of the status page breaks ties so that the same one is picked every time
+		if len(path) > matchLength || (len(path) == matchLength && statusPage.URL < match.URL) {
+			match, matchLength = statusPage, len(path)
+		}
+	}
+	return match, matchLength >= 0
+}
+
+// ScrapeConfig returns the scrape config of the status page, nil if it has none
+func (s *DBURLGetter) ScrapeConfig(url string) *api.ScrapeConfig {
+	cached, found := s.StatusPageCache.Get(url)
+	if !found {
+		return nil
+	}
+	statusPage, ok := cached.(api.StatusPage)
+	if !ok {
+		return nil
+	}
+	return statusPage.ScrapeConfig
+}
+
+// Location returns the configured timezone of the status page, or nil if it has none or it is not a valid timezone
+func (s *DBURLGetter) Location(url string) *time.Location {
+	cached, found := s.StatusPageCache.Get(url)
+	if !found {
+		return nil
+	}
+	statusPage, ok := cached.(api.StatusPage)
+	if !ok || statusPage.Timezone == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(statusPage.Timezone)
+	if err != nil {
+		s.logger.Error("failed to load the status page timezone", zap.String("url", url), zap.String("timezone", statusPage.Timezone), zap.Error(err))
+		return nil
+	}
+	return loc
+}
+
+// RobotsTxt returns the robots.txt override of the status page that the url is on, empty if it has none
+func (s *DBURLGetter) RobotsTxt(url string) string {
+	statusPage, found := s.statusPageOf(url)
+	if !found {
+		return ""
+	}
+	return statusPage.RobotsTxt
+}
+
+func (s *DBURLGetter) ContentHash(url string) (string, time.Time) {
+	cached, found := s.StatusPageCache.Get(url)
+	if !found {
+		return "", time.Time{}
+	}
+	statusPage, ok := cached.(api.StatusPage)
+	if !ok {
+		return "", time.Time{}
+	}
+	return statusPage.ContentHash, statusPage.ContentHashedAt
+}
+
+func (s *DBURLGetter) UpdateContentHash(url string, hash string, time time.Time) error {
+	err := s.dbClient.SetStatusPageContentHash(context.Background(), url, hash, time)
+	if err != nil {
+		return errors.Wrap(err, "failed to update content hash")
+	}
+	// The cached status page is updated so the next scrape sees the hash before the cache is refreshed
+	if cached, found := s.StatusPageCache.Get(url); found {
+		if statusPage, ok := cached.(api.StatusPage); ok {
+			statusPage.ContentHash = hash
+			statusPage.ContentHashedAt = time
+			s.StatusPageCache.Set(url, statusPage, cache.DefaultExpiration)
+		}
+	}
+	return nil
+}
+
+func (s *DBURLGetter) MetadataRefreshedAt(url string) time.Time {
+	cached, found := s.StatusPageCache.Get(url)
+	if !found {
+		return time.Time{}
+	}
+	statusPage, ok := cached.(api.StatusPage)
+	if !ok || statusPage.MetadataRefreshedAt == nil {
+		return time.Time{}
+	}
+	return *statusPage.MetadataRefreshedAt
+}
+
+func (s *DBURLGetter) UpdateMetadata(url string, metadata api.StatusPageMetadata, time time.Time) error {
+	err := s.dbClient.SetStatusPageMetadata(context.Background(), url, metadata, time)
+	if err != nil {
+		return errors.Wrap(err, "failed to update metadata")
+	}
+	// The cached status page is updated so the metadata isn't read again before the cache is refreshed
+	if cached, found := s.StatusPageCache.Get(url); found {
+		if statusPage, ok := cached.(api.StatusPage); ok {
+			statusPage.StatusPageMetadata = metadata
+			statusPage.MetadataRefreshedAt = &time
+			s.StatusPageCache.Set(url, statusPage, cache.DefaultExpiration)
+		}
+	}
+	return nil
+}
+
+// providerDetectionMaxAge is how long a detected provider is used for before it is detected again,
+// in case the status page has moved to another product. Providers given as a hint are used until they are changed
+const providerDetectionMaxAge = 7 * 24 * time.Hour
+
+func (s *DBURLGetter) Provider(url string) string {
+	cached, found := s.StatusPageCache.Get(url)
+	if !found {
+		return ""
+	}
+	statusPage, ok := cached.(api.StatusPage)
+	if !ok {
+		return ""
+	}
+	if statusPage.ScrapeConfig != nil && statusPage.ScrapeConfig.Provider != "" {
+		return statusPage.ScrapeConfig.Provider
+	}
+	if statusPage.ProviderDetectedAt != nil && time.Since(*statusPage.ProviderDetectedAt) > providerDetectionMaxAge {
+		return ""
+	}
+	return statusPage.Provider
+}
+
+func (s *DBURLGetter) UpdateProvider(url string, provider string, time time.Time) error {
+	err := s.dbClient.SetStatusPageProvider(context.Background(), url, provider, &time)
+	if err != nil {
+		return errors.Wrap(err, "failed to update provider")
+	}
+	if cached, found := s.StatusPageCache.Get(url); found {
+		if statusPage, ok := cached.(api.StatusPage); ok {
+			statusPage.Provider = provider
+			statusPage.ProviderDetectedAt = &time
+			s.StatusPageCache.Set(url, statusPage, cache.DefaultExpiration)
+		}
+	}
+	return nil
+}
+
+func (s *DBURLGetter) MoveStatusPage(url string, canonicalUrl string, time time.Time) error {
+	err := s.dbClient.MoveStatusPage(context.Background(), url, canonicalUrl, time)
+	if err != nil {
+		return errors.Wrap(err, "failed to move status page")
+	}
+	s.StatusPageCache.Delete(url)
+	statusPage, err := s.dbClient.GetStatusPage(context.Background(), canonicalUrl)
+	if err != nil {
+		return errors.Wrap(err, "failed to get moved status page")
+	}
+	if statusPage != nil {
+		s.StatusPageCache.Set(canonicalUrl, *statusPage, cache.DefaultExpiration)
+	}
+	return nil
+}
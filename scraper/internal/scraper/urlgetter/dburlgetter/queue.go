@@ -0,0 +1,62 @@
+package dburlgetter
+
+import (
+	"context"
+	"fmt"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/metoro-io/statusphere/common/db"
+	"os"
+	"time"
+)
+
+type Config struct {
+	// Queue makes every scraper replica pull the status pages that are due from a postgres backed queue
+	// so the current scrapes are shared between the replicas instead of every replica polling every page
+	Queue bool `envconfig:"SCRAPER_QUEUE" default:"false"`
+	// QueueLease is how long a status page taken from the queue stays with its replica if it isn't released
+	// It has to be longer than a scrape, once it expires another replica can take the page
+	QueueLease time.Duration `envconfig:"SCRAPER_QUEUE_LEASE" default:"10m"`
+	// QueueBatchSize is the most status pages a replica takes from the queue at once
+	QueueBatchSize int `envconfig:"SCRAPER_QUEUE_BATCH_SIZE" default:"10"`
+}
+
+func GetConfigFromEnvironment() (Config, error) {
+	var config Config
+	err := envconfig.Process("STATUSPHERE", &config)
+	return config, err
+}
+
+// QueueURLGetter is a DBURLGetter whose current scrapes are claimed from the scrape queue
+// Each due status page is handed to a single replica, which releases it once it has been scraped
+// Historical scrapes and backfills are still shared out with the advisory locks
+type QueueURLGetter struct {
+	*DBURLGetter
+	config    Config
+	replicaID string
+}
+
+func NewQueueURLGetter(getter *DBURLGetter, config Config) *QueueURLGetter {
+	hostname, _ := os.Hostname()
+	return &QueueURLGetter{
+		DBURLGetter: getter,
+		config:      config,
+		replicaID:   fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+	}
+}
+
+// GetUrlsToScrape claims the status pages that are due from the queue
+// Unlike DBURLGetter the schedule is read from the database, so replicas with stale caches don't scrape a page twice
+func (s *QueueURLGetter) GetUrlsToScrape() ([]string, error) {
+	return s.dbClient.ClaimDueStatusPages(context.Background(), s.replicaID, s.config.QueueBatchSize, s.config.QueueLease, db.ScrapeSchedule{
+		DefaultInterval:         defaultScrapeInterval,
+		ActiveIncidentInterval:  activeIncidentScrapeInterval,
+		CircuitBreakerThreshold: circuitBreakerThreshold,
+		CircuitBreakerCoolDown:  circuitBreakerCoolDown,
+		DeadInterval:            deadReprobeInterval,
+	})
+}
+
+// Release hands a claimed status page back to the queue
+func (s *QueueURLGetter) Release(url string) error {
+	return s.dbClient.ReleaseScrapeClaim(context.Background(), url, s.replicaID)
+}
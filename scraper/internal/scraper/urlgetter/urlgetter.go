@@ -0,0 +1,89 @@
+package urlgetter
+
+import (
+	"github.com/metoro-io/statusphere/common/api"
+	"time"
+)
+
+type URLGetter interface {
+	// GetUrlsToScrape returns a list of URLs to scrape.
+	// This can be called at any point so the URLGetter should be able to return the URLs quickly
+	// And should only return URLs that should actually be scraped, in the order they should be scraped in
+	GetUrlsToScrape() ([]string, error)
+
+	// GetHistoricalUrlsToScrape returns a list of URLs to scrape that are historical
+	// This can be called at any point so the URLGetter should be able to return the URLs quickly
+	// And should only return URLs that should actually be historical scraped
+	GetHistoricalUrlsToScrape() ([]string, error)
+
+	// RecordScrapeResult records the outcome of a scrape of the given URL, including the last scraped time
+	RecordScrapeResult(url string, result ScrapeResult) error
+
+	// UpdateHasActiveIncident records whether the last scrape of the given URL found an ongoing incident
+	// URLs with an active incident are scraped more frequently
+	UpdateHasActiveIncident(url string, active bool) error
+
+	// UpdateLastScrapedTimeHistorical updates the last scraped time for the given URL for historical scraping
+	UpdateLastScrapedTimeHistorical(url string, time time.Time) error
+
+	// GetBackfillUrlsToScrape returns the URLs whose whole incident archive should be scraped
+	// These are the URLs that have never been backfilled, they are not returned by GetHistoricalUrlsToScrape
+	GetBackfillUrlsToScrape() ([]string, error)
+
+	// GetBackfillCursor returns the cursor an unfinished backfill of the given URL resumes from, empty if there is none
+	GetBackfillCursor(url string) (string, error)
+
+	// UpdateBackfillCursor records the progress of the backfill of the given URL
+	UpdateBackfillCursor(url string, cursor string) error
+
+	// UpdateBackfilled records that the whole incident archive of the given URL has been scraped and clears its cursor
+	UpdateBackfilled(url string, time time.Time) error
+
+	// ContentHash returns the hash of the content whose incidents were last processed for the given URL and when that was
+	// The hash is empty if it has never been recorded
+	ContentHash(url string) (hash string, hashedAt time.Time)
+
+	// UpdateContentHash records the hash of the content whose incidents were just processed for the given URL
+	UpdateContentHash(url string, hash string, time time.Time) error
+
+	// MetadataRefreshedAt returns when the metadata of the status page at the given URL was last read, zero if it never has been
+	MetadataRefreshedAt(url string) time.Time
+
+	// UpdateMetadata records the metadata read from the home page of the status page at the given URL
+	UpdateMetadata(url string, metadata api.StatusPageMetadata, time time.Time) error
+
+	// Provider returns the provider that scrapes the given URL, empty if it has to be detected
+	// A provider set in the scrape config of the status page takes precedence over the detected one
+	Provider(url string) string
+
+	// UpdateProvider records the provider that was detected for the given URL
+	UpdateProvider(url string, provider string, time time.Time) error
+
+	// MoveStatusPage moves the status page at the given URL to the canonical URL it permanently redirects to
+	// The URL is kept as an alias of the canonical URL
+	MoveStatusPage(url string, canonicalUrl string, time time.Time) error
+
+	// ScrapeConfig returns the overrides of how the status page at the given URL is scraped, nil if it has none
+	ScrapeConfig(url string) *api.ScrapeConfig
+
+	// Location returns the timezone that the status page at the given URL prints local times in
+	// It returns nil if the status page prints times in UTC or with an explicit offset
+	Location(url string) *time.Location
+}
+
+// Releaser is implemented by URLGetters that hand each URL returned by GetUrlsToScrape to a single scraper
+// The scraper releases every URL it was given once it has scraped it, or straight away if it won't scrape it
+type Releaser interface {
+	Release(url string) error
+}
+
+// ScrapeResult is the outcome of a scrape of a status page
+type ScrapeResult struct {
+	// Time is when the scrape finished
+	Time     time.Time
+	Duration time.Duration
+	// Err is nil if the scrape succeeded
+	Err error
+	// Gone is true if the scrape failed because the status page no longer exists
+	Gone bool
+}
@@ -0,0 +1,320 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/metoro-io/statusphere/common/webhooks"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/throttle"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+type Config struct {
+	// MaxAttempts is how many times a delivery is attempted before it is marked as failed
+	MaxAttempts int `envconfig:"WEBHOOK_MAX_ATTEMPTS" default:"8"`
+	// Timeout bounds each delivery attempt
+	Timeout time.Duration `envconfig:"WEBHOOK_TIMEOUT" default:"10s"`
+	// AllowPrivateNetworks lets the deliveries be posted to loopback, private and link local addresses, which are refused
+	// by default so that an api key can't make the scraper send requests to its own network
+	AllowPrivateNetworks bool `envconfig:"WEBHOOK_ALLOW_PRIVATE_NETWORKS" default:"false"`
+	// Throttle is how long the updates of an incident are collapsed for after a delivery of it to a subscription, 0
+	// delivers every update
+	Throttle time.Duration `envconfig:"WEBHOOK_THROTTLE" default:"10m"`
+}
+
+func GetConfigFromEnvironment() (Config, error) {
+	var config Config
+	err := envconfig.Process("STATUSPHERE", &config)
+	return config, err
+}
+
+const (
+	deliverInterval  = 5 * time.Second
+	deliverBatchSize = 50
+	// initialBackoff doubles with every failed attempt up to maxBackoff, 8 attempts span about an hour
+	initialBackoff = 30 * time.Second
+	maxBackoff     = 6 * time.Hour
+	// deliveryRetention is how long finished deliveries are kept so their status can be looked up
+	deliveryRetention = 7 * 24 * time.Hour
+	cleanupInterval   = 1 * time.Hour
+)
+
+// errPrivateAddress is the error of a delivery to an address that isn't public
+var errPrivateAddress = errors.New("the address of the webhook isn't public")
+
+// Publisher is an outbox publisher that queues a delivery of every change event for each subscription it matches
+// The deliveries are deduplicated and throttled like the notifications, the collapsed updates are queued once the
+// publisher is started
+type Publisher struct {
+	logger    *zap.Logger
+	dbClient  *db.DbClient
+	throttle  time.Duration
+	throttler *throttle.Throttler
+}
+
+func NewPublisher(logger *zap.Logger, dbClient *db.DbClient, config Config) *Publisher {
+	return &Publisher{
+		logger:   logger,
+		dbClient: dbClient,
+		throttle: config.Throttle,
+		// The receivers deduplicate on the id of the event, so a redelivered event is queued again rather than risk losing it
+		throttler: throttle.NewThrottler(logger, dbClient, true),
+	}
+}
+
+// destination is the key of the subscription in the throttles
+func destination(subscriptionID string) string {
+	return "webhook " + subscriptionID
+}
+
+func (p *Publisher) Name() string {
+	return "webhooks"
+}
+
+func (p *Publisher) Publish(ctx context.Context, events []api.ChangeEvent) error {
+	subscriptions, err := p.dbClient.GetAllSubscriptions(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get subscriptions")
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	var deliveries []api.WebhookDelivery
+	for _, event := range events {
+		for _, subscription := range subscriptions {
+			if !subscription.Matches(event) {
+				continue
+			}
+			admitted, err := p.throttler.Admit(ctx, destination(subscription.ID), p.throttle, event, now)
+			if err != nil {
+				return err
+			}
+			if !admitted {
+				continue
+			}
+			deliveries = append(deliveries, newDelivery(subscription.ID, api.NewWebhookPayload(event), now))
+		}
+	}
+	return p.dbClient.CreateWebhookDeliveries(ctx, deliveries)
+}
+
+func newDelivery(subscriptionID string, payload api.WebhookPayload, now time.Time) api.WebhookDelivery {
+	return api.WebhookDelivery{
+		SubscriptionID: subscriptionID,
+		EventID:        payload.ID,
+		Payload:        payload,
+		Status:         api.DeliveryStatusPending,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	}
+}
+
+// Start starts queueing the collapsed updates once their window has passed, it runs until the context is cancelled
+func (p *Publisher) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(throttle.FlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := p.queueDue(ctx)
+				if err != nil {
+					p.logger.Error("failed to queue the collapsed webhook deliveries", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// queueDue queues a delivery of the collapsed updates of the subscriptions whose window has passed
+func (p *Publisher) queueDue(ctx context.Context) error {
+	subscriptions, err := p.dbClient.GetAllSubscriptions(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get subscriptions")
+	}
+	destinations := make([]string, 0, len(subscriptions))
+	subscriptionIDs := map[string]string{}
+	for _, subscription := range subscriptions {
+		destinations = append(destinations, destination(subscription.ID))
+		subscriptionIDs[destination(subscription.ID)] = subscription.ID
+	}
+	now := time.Now().UTC()
+	due, err := p.throttler.Due(ctx, destinations, now)
+	if err != nil {
+		return err
+	}
+	deliveries := make([]api.WebhookDelivery, 0, len(due))
+	for _, collapsed := range due {
+		payload := api.NewWebhookPayload(collapsed.Event)
+		payload.Updates = collapsed.Updates
+		deliveries = append(deliveries, newDelivery(subscriptionIDs[collapsed.Destination], payload, now))
+	}
+	return p.dbClient.CreateWebhookDeliveries(ctx, deliveries)
+}
+
+// Deliverer posts the queued deliveries to their subscriptions and retries the failed ones with exponential backoff
+type Deliverer struct {
+	logger     *zap.Logger
+	dbClient   *db.DbClient
+	httpClient *http.Client
+	config     Config
+}
+
+func NewDeliverer(logger *zap.Logger, dbClient *db.DbClient, config Config) *Deliverer {
+	dialer := &net.Dialer{Timeout: config.Timeout}
+	if !config.AllowPrivateNetworks {
+		dialer.Control = publicAddressesOnly
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// A proxy would be dialed instead of the webhook, so the address of the webhook couldn't be checked
+	transport.Proxy = nil
+	return &Deliverer{
+		logger:     logger,
+		dbClient:   dbClient,
+		httpClient: &http.Client{Timeout: config.Timeout, Transport: transport},
+		config:     config,
+	}
+}
+
+// publicAddressesOnly refuses the connections to addresses that aren't public. It checks the address that is dialed
+// after the host was resolved, so neither a hostname that resolves to a private address nor a redirect to one gets through
+func publicAddressesOnly(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the address of the webhook")
+	}
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip) {
+		return errPrivateAddress
+	}
+	return nil
+}
+
+// sharedAddressSpace is the carrier grade nat range, see RFC 6598, which netip doesn't count as private
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// Start starts the deliverer goroutine, it runs until the context is cancelled
+func (d *Deliverer) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(deliverInterval)
+		defer ticker.Stop()
+		lastCleanup := time.Time{}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				d.deliverAll(ctx)
+				if time.Since(lastCleanup) > cleanupInterval {
+					d.cleanup(ctx)
+					lastCleanup = time.Now()
+				}
+			}
+		}
+	}()
+}
+
+// deliverAll attempts the due deliveries in batches
+func (d *Deliverer) deliverAll(ctx context.Context) {
+	// The deliveries of a batch are attempted one after the other, another scraper can claim them once it is over
+	claimFor := deliverBatchSize * d.config.Timeout
+	for {
+		dispatched, err := d.dbClient.DispatchWebhookDeliveries(ctx, deliverBatchSize, time.Now().UTC(), claimFor, func(delivery *api.WebhookDelivery, subscription api.Subscription) {
+			d.deliver(ctx, delivery, subscription)
+		})
+		if err != nil {
+			d.logger.Error("failed to dispatch webhook deliveries", zap.Error(err))
+			return
+		}
+		if dispatched < deliverBatchSize {
+			return
+		}
+	}
+}
+
+// deliver makes one attempt at the delivery and updates its status
+func (d *Deliverer) deliver(ctx context.Context, delivery *api.WebhookDelivery, subscription api.Subscription) {
+	delivery.Attempts++
+	statusCode, err := d.post(ctx, delivery, subscription)
+	delivery.LastStatusCode = statusCode
+	now := time.Now().UTC()
+	if err == nil {
+		delivery.Status = api.DeliveryStatusDelivered
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		return
+	}
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= d.config.MaxAttempts {
+		delivery.Status = api.DeliveryStatusFailed
+		d.logger.Warn("webhook delivery failed", zap.Uint64("delivery", delivery.ID), zap.String("subscription", subscription.ID), zap.Error(err))
+		return
+	}
+	delivery.NextAttemptAt = now.Add(backoff(delivery.Attempts))
+}
+
+func (d *Deliverer) post(ctx context.Context, delivery *api.WebhookDelivery, subscription api.Subscription) (int, error) {
+	body, err := json.Marshal(delivery.Payload)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to marshal the payload")
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create the request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "statusphere-webhooks")
+	req.Header.Set(webhooks.EventHeader, string(delivery.Payload.Type))
+	req.Header.Set(webhooks.DeliveryHeader, strconv.FormatUint(delivery.ID, 10))
+	req.Header.Set(webhooks.TimestampHeader, timestamp)
+	req.Header.Set(webhooks.SignatureHeader, webhooks.Sign(subscription.Secret, timestamp, body))
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to make the request")
+	}
+	defer resp.Body.Close()
+	// The body of the response isn't kept, the error of the delivery is readable by the api key
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func backoff(attempts int) time.Duration {
+	delay := initialBackoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+func (d *Deliverer) cleanup(ctx context.Context) {
+	deleted, err := d.dbClient.DeleteFinishedWebhookDeliveries(ctx, time.Now().Add(-deliveryRetention))
+	if err != nil {
+		d.logger.Error("failed to delete finished webhook deliveries", zap.Error(err))
+		return
+	}
+	if deleted > 0 {
+		d.logger.Info("deleted finished webhook deliveries", zap.Int64("deliveries", deleted))
+	}
+}
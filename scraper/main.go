@@ -3,15 +3,51 @@ package main
 import (
 	"context"
 	"github.com/metoro-io/statusphere/common/db"
+	"github.com/metoro-io/statusphere/common/outbox"
+	"github.com/metoro-io/statusphere/common/telemetry"
 	"github.com/metoro-io/statusphere/scraper/internal/scraper"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/body"
 	"github.com/metoro-io/statusphere/scraper/internal/scraper/consumers"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/consumers/clickhouseconsumer"
 	"github.com/metoro-io/statusphere/scraper/internal/scraper/consumers/dbconsumer"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/contenthash"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/headers"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/knowledgebase"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/language"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/leader"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/metrics"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/notifier"
 	"github.com/metoro-io/statusphere/scraper/internal/scraper/poller"
 	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
-	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers/atlassian"
+	_ "github.com/metoro-io/statusphere/scraper/internal/scraper/providers/atlassian"
+	_ "github.com/metoro-io/statusphere/scraper/internal/scraper/providers/aws"
+	_ "github.com/metoro-io/statusphere/scraper/internal/scraper/providers/azure"
+	_ "github.com/metoro-io/statusphere/scraper/internal/scraper/providers/betterstack"
+	_ "github.com/metoro-io/statusphere/scraper/internal/scraper/providers/cachet"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers/declarative"
+	_ "github.com/metoro-io/statusphere/scraper/internal/scraper/providers/feed"
+	_ "github.com/metoro-io/statusphere/scraper/internal/scraper/providers/google"
+	_ "github.com/metoro-io/statusphere/scraper/internal/scraper/providers/instatus"
+	_ "github.com/metoro-io/statusphere/scraper/internal/scraper/providers/mock"
+	_ "github.com/metoro-io/statusphere/scraper/internal/scraper/providers/salesforce"
+	_ "github.com/metoro-io/statusphere/scraper/internal/scraper/providers/statusio"
+	_ "github.com/metoro-io/statusphere/scraper/internal/scraper/providers/statuspal"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/proxy"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/ratelimit"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/redirect"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/render"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/retry"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/robots"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/sandbox"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/tracing"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/trigger"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/urlgetter"
 	"github.com/metoro-io/statusphere/scraper/internal/scraper/urlgetter/dburlgetter"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/webhooks"
 	"go.uber.org/zap"
 	"net/http"
+	"os"
+	"time"
 )
 
 func main() {
@@ -20,9 +56,59 @@ func main() {
 		panic(err)
 	}
 
-	scraper := scraper.NewScraper(logger, http.DefaultClient, []providers.Provider{
-		atlassian.NewAtlassianProvider(logger, http.DefaultClient),
-	})
+	// Providers register themselves when their package is imported, declarative providers are registered from their definitions
+	declarativeConfig, err := declarative.GetConfigFromEnvironment()
+	if err != nil {
+		logger.Error("failed to get declarative providers config", zap.Error(err))
+		return
+	}
+	if declarativeConfig.Dir != "" {
+		definitions, err := declarative.LoadDir(declarativeConfig.Dir)
+		if err != nil {
+			logger.Error("failed to load declarative providers", zap.Error(err))
+			return
+		}
+		declarative.Register(logger, definitions)
+	}
+
+	// `scraper features` prints the feature matrix of the providers and exits
+	if len(os.Args) > 1 && os.Args[1] == "features" {
+		printFeatureMatrix(os.Stdout, providers.NewRegisteredProviders(logger, http.DefaultClient))
+		return
+	}
+
+	// `scraper record-fixture -url X` records a scrape of the status page for the parser regression test and exits
+	if len(os.Args) > 1 && os.Args[1] == "record-fixture" {
+		err = recordFixture(context.Background(), logger, os.Args[2:])
+		if err != nil {
+			logger.Error("failed to record fixture", zap.Error(err))
+		}
+		return
+	}
+
+	telemetryConfig, err := telemetry.GetConfigFromEnvironment()
+	if err != nil {
+		logger.Error("failed to get telemetry config", zap.Error(err))
+		return
+	}
+	// The stages of the scrapes are timed from their spans
+	tracingConfig, err := tracing.GetConfigFromEnvironment()
+	if err != nil {
+		logger.Error("failed to get tracing config", zap.Error(err))
+		return
+	}
+	shutdownTelemetry, err := telemetry.Init(context.Background(), logger, telemetryConfig, "statusphere-scraper", tracing.NewStageProcessor(logger, tracingConfig))
+	if err != nil {
+		logger.Error("failed to start telemetry", zap.Error(err))
+		return
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := shutdownTelemetry(ctx); err != nil {
+			logger.Warn("failed to flush telemetry", zap.Error(err))
+		}
+	}()
 
 	dbClient, err := db.NewDbClientFromEnvironment(logger)
 	if err != nil {
@@ -36,11 +122,286 @@ func main() {
 		return
 	}
 
+	storedDefinitions, err := dbClient.GetProviderConfigs(context.Background())
+	if err != nil {
+		logger.Error("failed to get declarative providers", zap.Error(err))
+		return
+	}
+	for _, stored := range storedDefinitions {
+		definition, err := declarative.Parse([]byte(stored.Config))
+		if err != nil {
+			// A broken definition should not stop the other status pages being scraped
+			logger.Error("skipping invalid declarative provider", zap.String("name", stored.Name), zap.Error(err))
+			continue
+		}
+		declarative.Register(logger, []declarative.Config{definition})
+	}
+
 	getter := dburlgetter.NewDBURLGetter(logger, dbClient)
-	getter.Start()
-	poller := poller.NewPoller(getter, scraper, []consumers.Consumer{
+
+	metricsConfig, err := metrics.GetConfigFromEnvironment()
+	if err != nil {
+		logger.Error("failed to get metrics config", zap.Error(err))
+		return
+	}
+	if metricsConfig.Address != "" {
+		metrics.Serve(logger, metricsConfig)
+	}
+
+	proxyConfig, err := proxy.GetConfigFromEnvironment()
+	if err != nil {
+		logger.Error("failed to get proxy config", zap.Error(err))
+		return
+	}
+	proxyPool, err := proxy.NewPool(proxyConfig)
+	if err != nil {
+		logger.Error("failed to create proxy pool", zap.Error(err))
+		return
+	}
+	var transport http.RoundTripper = proxy.NewTransport(proxyPool)
+
+	// Responses are decoded and capped in size before anything else reads them
+	bodyConfig, err := body.GetConfigFromEnvironment()
+	if err != nil {
+		logger.Error("failed to get body config", zap.Error(err))
+		return
+	}
+	transport = body.NewTransport(transport, bodyConfig)
+
+	// Every request is measured, including each retry
+	transport = metrics.NewTransport(transport)
+
+	// The fetched bodies are hashed before they are rendered, so a scrape of an unchanged status page can be skipped
+	transport = contenthash.NewTransport(transport)
+
+	// Status pages flagged with requires_js are rendered in a headless browser before the providers parse them
+	renderConfig, err := render.GetConfigFromEnvironment()
+	if err != nil {
+		logger.Error("failed to get render config", zap.Error(err))
+		return
+	}
+	if renderConfig.Enabled {
+		renderer, err := render.NewRenderer(logger, renderConfig)
+		if err != nil {
+			logger.Error("failed to create renderer", zap.Error(err))
+			return
+		}
+		defer renderer.Close()
+		transport = render.NewTransport(transport, renderer, getter.RequiresJS)
+	}
+
+	// Space out the requests to each host so the status pages don't rate limit us
+	rateLimitConfig, err := ratelimit.GetConfigFromEnvironment()
+	if err != nil {
+		logger.Error("failed to get rate limit config", zap.Error(err))
+		return
+	}
+	if rateLimitConfig.RequestsPerMinute > 0 {
+		transport = ratelimit.NewTransport(transport, rateLimitConfig)
+	}
+
+	// Retries go through the rate limiter so retrying doesn't hammer a struggling status page
+	retryConfig, err := retry.GetConfigFromEnvironment()
+	if err != nil {
+		logger.Error("failed to get retry config", zap.Error(err))
+		return
+	}
+	if retryConfig.Retries > 0 {
+		transport = retry.NewTransport(transport, retryConfig)
+	}
+
+	// Providers send the headers of their profile, some status pages serve different content depending on the user agent
+	headersConfig, err := headers.GetConfigFromEnvironment()
+	if err != nil {
+		logger.Error("failed to get headers config", zap.Error(err))
+		return
+	}
+	headerProfiles, err := dbClient.GetHeaderProfiles(context.Background())
+	if err != nil {
+		logger.Error("failed to get header profiles", zap.Error(err))
+		return
+	}
+	transport = headers.NewTransport(transport, headersConfig, headerProfiles, getter.Headers)
+
+	// robots.txt is checked before anything else so disallowed pages don't use up the rate limit, it goes through
+	// the rest of the transports to fetch the robots.txt itself
+	robotsConfig, err := robots.GetConfigFromEnvironment()
+	if err != nil {
+		logger.Error("failed to get robots config", zap.Error(err))
+		return
+	}
+	// The providers used by the scraper need the http client, so the API providers are read from a separate set
+	transport = robots.NewTransport(transport, robotsConfig, providerFeatures(providers.NewRegisteredProviders(logger, http.DefaultClient)), getter.RobotsTxt)
+	// The requests of a scrape are timed as its fetch stage, including the time spent waiting on the rate limits and retries
+	transport = tracing.NewTransport(transport)
+	// Permanent redirects are recorded so the status pages that have moved are canonicalized
+	httpClient := &http.Client{Transport: transport, CheckRedirect: redirect.CheckRedirect}
+
+	scrapeProviders := providers.NewRegisteredProviders(logger, httpClient)
+	scraper := scraper.NewScraper(logger, httpClient, scrapeProviders)
+
+	// `scraper diff -url X` prints how a scrape would change the stored incidents without writing them and exits
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		err = diffStatusPages(context.Background(), logger, os.Stdout, dbClient, getter, scraper, os.Args[2:])
+		if err != nil {
+			logger.Error("failed to diff status pages", zap.Error(err))
+		}
+		return
+	}
+
+	err = dbClient.ReplaceProviderFeatures(context.Background(), providerFeatures(scrapeProviders))
+	if err != nil {
+		logger.Error("failed to store provider features", zap.Error(err))
+		return
+	}
+
+	leaderConfig, err := leader.GetConfigFromEnvironment()
+	if err != nil {
+		logger.Error("failed to get leader election config", zap.Error(err))
+		return
+	}
+	if leaderConfig.Enabled {
+		// Stay connected as a warm standby until the current leader dies
+		lost, err := leader.NewElector(logger, dbClient, leaderConfig).AwaitLeadership(context.Background())
+		if err != nil {
+			logger.Error("failed to become leader", zap.Error(err))
+			return
+		}
+		go func() {
+			<-lost
+			// Exit rather than risk two leaders scraping, the orchestrator restarts us as a standby
+			logger.Fatal("lost leadership, exiting")
+		}()
+	}
+
+	// Only the leader resets the sandbox
+	sandboxConfig, err := sandbox.GetConfigFromEnvironment()
+	if err != nil {
+		logger.Error("failed to get sandbox config", zap.Error(err))
+		return
+	}
+	if sandboxConfig.Enabled {
+		err = sandbox.NewSandbox(logger, dbClient, sandboxConfig).Start(context.Background())
+		if err != nil {
+			logger.Error("failed to start sandbox", zap.Error(err))
+			return
+		}
+	}
+
+	// The db consumer is authoritative so it must run first
+	scrapeConsumers := []consumers.Consumer{
 		dbconsumer.NewDbConsumer(logger, dbClient),
-	}, logger)
+	}
+
+	clickhouseConfig, err := clickhouseconsumer.GetConfigFromEnvironment()
+	if err != nil {
+		logger.Error("failed to get clickhouse config", zap.Error(err))
+		return
+	}
+	if clickhouseConfig.URL != "" {
+		// ClickHouse is only a mirror, the scraper runs without it if it is unavailable
+		clickhouseConsumer := clickhouseconsumer.NewClickhouseConsumer(logger, clickhouseConfig)
+		err = clickhouseConsumer.Start(context.Background())
+		if err != nil {
+			logger.Error("failed to start clickhouse consumer, incidents won't be mirrored to clickhouse", zap.Error(err))
+		} else {
+			scrapeConsumers = append(scrapeConsumers, clickhouseConsumer)
+		}
+	}
+
+	// Publish the incident change events written alongside the incidents
+	publishers := []outbox.Publisher{
+		outbox.NewLogPublisher(logger),
+	}
+	knowledgeBaseConfig, err := knowledgebase.GetConfigFromEnvironment()
+	if err != nil {
+		logger.Error("failed to get knowledge base config", zap.Error(err))
+		return
+	}
+	knowledgeBasePublishers, err := knowledgebase.NewPublishersFromConfig(logger, knowledgeBaseConfig)
+	if err != nil {
+		logger.Error("failed to create knowledge base publishers", zap.Error(err))
+		return
+	}
+	for _, publisher := range knowledgeBasePublishers {
+		publishers = append(publishers, publisher)
+	}
+	notifierConfig, err := notifier.GetConfigFromEnvironment()
+	if err != nil {
+		logger.Error("failed to get notifier config", zap.Error(err))
+		return
+	}
+	notifierPublishers, err := notifier.NewPublishersFromConfig(logger, dbClient, notifierConfig)
+	if err != nil {
+		logger.Error("failed to create notifier publishers", zap.Error(err))
+		return
+	}
+	for _, publisher := range notifierPublishers {
+		publishers = append(publishers, publisher)
+		publisher.Start(context.Background())
+	}
+	pagerPublishers, err := notifier.NewPagerPublishersFromConfig(logger, dbClient, notifierConfig)
+	if err != nil {
+		logger.Error("failed to create pager publishers", zap.Error(err))
+		return
+	}
+	for _, publisher := range pagerPublishers {
+		publishers = append(publishers, publisher)
+	}
+	emailNotifier, err := notifier.NewEmailNotifierFromConfig(logger, dbClient, notifierConfig)
+	if err != nil {
+		logger.Error("failed to create email notifier", zap.Error(err))
+		return
+	}
+	if emailNotifier != nil {
+		publishers = append(publishers, emailNotifier)
+		emailNotifier.Start(context.Background())
+	}
+	webhooksConfig, err := webhooks.GetConfigFromEnvironment()
+	if err != nil {
+		logger.Error("failed to get webhooks config", zap.Error(err))
+		return
+	}
+	webhooksPublisher := webhooks.NewPublisher(logger, dbClient, webhooksConfig)
+	webhooksPublisher.Start(context.Background())
+	publishers = append(publishers, webhooksPublisher)
+	outbox.NewDispatcher(logger, dbClient, publishers).Start(context.Background())
+
+	webhooks.NewDeliverer(logger, dbClient, webhooksConfig).Start(context.Background())
+
+	getter.Start()
+	urlGetterConfig, err := dburlgetter.GetConfigFromEnvironment()
+	if err != nil {
+		logger.Error("failed to get url getter config", zap.Error(err))
+		return
+	}
+	var urlGetter urlgetter.URLGetter = getter
+	if urlGetterConfig.Queue {
+		logger.Info("claiming status pages from the scrape queue")
+		urlGetter = dburlgetter.NewQueueURLGetter(getter, urlGetterConfig)
+	}
+	pollerConfig, err := poller.GetConfigFromEnvironment()
+	if err != nil {
+		logger.Error("failed to get poller config", zap.Error(err))
+		return
+	}
+	languageConfig, err := language.GetConfigFromEnvironment()
+	if err != nil {
+		logger.Error("failed to get language config", zap.Error(err))
+		return
+	}
+	annotator := language.NewAnnotator(logger, language.NewTranslatorFromConfig(languageConfig), languageConfig.TranslateTo)
+	poller := poller.NewPoller(pollerConfig, urlGetter, scraper, scrapeConsumers, dbClient, annotator, logger)
+
+	triggerConfig, err := trigger.GetConfigFromEnvironment()
+	if err != nil {
+		logger.Error("failed to get scrape trigger config", zap.Error(err))
+		return
+	}
+	if triggerConfig.Address != "" {
+		trigger.NewServer(logger, dbClient, poller, triggerConfig).Serve()
+	}
+
 	err = poller.Poll()
 	if err != nil {
 		logger.Error("failed to poll", zap.Error(err))
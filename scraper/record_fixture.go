@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/fixtures"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"net/http"
+	neturl "net/url"
+	"path/filepath"
+	"strings"
+)
+
+// fixturesDir is where the parser regression test looks for fixtures, relative to the root of the repository
+const fixturesDir = "scraper/internal/scraper/fixtures/testdata"
+
+// recordFixture records a scrape of a status page as a fixture for the parser regression test
+func recordFixture(ctx context.Context, logger *zap.Logger, args []string) error {
+	flags := flag.NewFlagSet("record-fixture", flag.ExitOnError)
+	url := flags.String("url", "", "url of the status page to record")
+	kind := flags.String("kind", fixtures.KindHistorical, "kind of scrape to record, historical or current")
+	timezone := flags.String("timezone", "", "timezone the status page prints local times in, if it doesn't print offsets")
+	output := flags.String("o", "", "file to write the fixture to, defaults to "+fixturesDir+"/<provider>/<host>.json")
+	_ = flags.Parse(args)
+	if *url == "" {
+		return errors.New("-url is required")
+	}
+
+	fixture, err := fixtures.Record(ctx, logger, http.DefaultTransport, *url, *kind, *timezone)
+	if err != nil {
+		return err
+	}
+	path := *output
+	if path == "" {
+		parsed, err := neturl.Parse(*url)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse the url")
+		}
+		provider := strings.ReplaceAll(strings.ToLower(fixture.Provider), " ", "-")
+		path = filepath.Join(fixturesDir, provider, parsed.Host+".json")
+	}
+	err = fixtures.Save(path, fixture)
+	if err != nil {
+		return err
+	}
+	logger.Info("recorded fixture, check its incidents before committing it", zap.String("path", path), zap.Int("incidents", len(fixture.Incidents)))
+	return nil
+}
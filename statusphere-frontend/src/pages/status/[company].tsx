@@ -29,7 +29,7 @@ export async function getServerSideProps(context: any) {
         );
 
         const currStatus: Status = (await currStatusResp).data.status
-        const outages = (await outagesResp).data.incidents
+        const outages = (await outagesResp).data.data
         return {
             props: {
                 statusPageDetails: statusPageDetails,